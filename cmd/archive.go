@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	"hyperwhisper/internal/awsapi"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db"
+)
+
+// archiveUploadTimeout bounds the upload of a single archived month
+const archiveUploadTimeout = 30 * time.Minute
+
+var ArchiveCommand = &cli.Command{
+	Name:  "archive",
+	Usage: "Move old data out of the database",
+	Commands: []*cli.Command{
+		{
+			Name:  "usage-logs",
+			Usage: "Upload usage log months past ARCHIVE_AFTER_MONTHS to S3 and drop their partitions",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "List the months that would be archived without uploading or dropping anything",
+				},
+			},
+			Action: archiveUsageLogs,
+		},
+	},
+}
+
+func archiveUsageLogs(ctx context.Context, cmd *cli.Command) error {
+	if err := config.Load(); err != nil {
+		return err
+	}
+
+	keepMonths := config.Int("ARCHIVE_AFTER_MONTHS")
+	if keepMonths <= 0 {
+		fmt.Println("Archival is disabled (ARCHIVE_AFTER_MONTHS is 0).")
+		return nil
+	}
+	bucket := config.String("ARCHIVE_S3_BUCKET")
+	if bucket == "" {
+		return errors.New("ARCHIVE_S3_BUCKET is required")
+	}
+
+	dryRun := cmd.Bool("dry-run")
+	var s3 *awsapi.Client
+	if !dryRun {
+		client, err := awsapi.NewFromConfig(archiveUploadTimeout)
+		if err != nil {
+			return err
+		}
+		s3 = client
+	}
+
+	if err := db.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	archived := 0
+	for _, table := range db.UsageLogTables {
+		months, err := db.ArchivableMonths(ctx, table, keepMonths, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to list %s partitions: %w", table, err)
+		}
+
+		for _, month := range months {
+			label := fmt.Sprintf("%s %s", table, month.Format("2006-01"))
+
+			if table == "trial_usage" {
+				active, err := db.UnexpiredTrialKeysWithUsage(ctx, month)
+				if err != nil {
+					return fmt.Errorf("%s: %w", label, err)
+				}
+				if active > 0 {
+					fmt.Printf("Skipping %s: %d unexpired trial key(s) still count its usage.\n", label, active)
+					continue
+				}
+			}
+
+			if dryRun {
+				fmt.Printf("Would archive %s.\n", label)
+				continue
+			}
+
+			// The month's totals outlive its logs
+			if err := db.RollupUsageMonth(ctx, table, month); err != nil {
+				return fmt.Errorf("%s: failed to roll up usage: %w", label, err)
+			}
+
+			key := path.Join(config.String("ARCHIVE_S3_PREFIX"), table, month.Format("2006-01")+".jsonl.gz")
+			rows, err := archiveMonth(ctx, s3, bucket, key, table, month)
+			if err != nil {
+				return fmt.Errorf("%s: %w", label, err)
+			}
+			if err := db.DropUsageMonth(ctx, table, month); err != nil {
+				return fmt.Errorf("%s was uploaded but its partition could not be dropped: %w", label, err)
+			}
+
+			fmt.Printf("Archived %s: %d row(s) to s3://%s/%s.\n", label, rows, bucket, key)
+			archived++
+		}
+	}
+
+	if !dryRun {
+		fmt.Printf("Archived %d month(s).\n", archived)
+	}
+	return nil
+}
+
+// archiveMonth exports a month to a gzipped temporary file and uploads it.
+// The file is needed because S3 signs the payload hash up front.
+func archiveMonth(ctx context.Context, s3 *awsapi.Client, bucket, key, table string, month time.Time) (int, error) {
+	f, err := os.CreateTemp("", "hyperwhisper-archive-*.jsonl.gz")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	hash := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(f, hash))
+	rows, err := db.ExportUsageMonth(ctx, table, month, gz)
+	if err != nil {
+		return 0, fmt.Errorf("export failed: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return 0, err
+	}
+
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	if err := s3.PutObject(ctx, config.String("ARCHIVE_S3_ENDPOINT"), bucket, key, f, size, hex.EncodeToString(hash.Sum(nil))); err != nil {
+		return 0, fmt.Errorf("upload failed: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,412 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	"hyperwhisper/internal/awsapi"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db"
+	"hyperwhisper/internal/encryption"
+)
+
+const (
+	// backupTransferTimeout bounds the upload or download of a backup
+	backupTransferTimeout = 30 * time.Minute
+	// backupMagic starts every backup file
+	backupMagic = "hyperwhisper-backup\n"
+	// backupFormatVersion is the version of the backup file format
+	backupFormatVersion = 1
+	// restoreBatchSize bounds how many rows are inserted per query
+	restoreBatchSize = 500
+)
+
+var BackupCommand = &cli.Command{
+	Name:  "backup",
+	Usage: "Encrypted backups of the database",
+	Commands: []*cli.Command{
+		{
+			Name:  "create",
+			Usage: "Back up users, keys, limits and settings to BACKUP_S3_BUCKET",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "include-logs",
+					Usage: "Also back up transcription and trial usage logs, transcripts and client error reports",
+				},
+				&cli.StringFlag{
+					Name:  "file",
+					Usage: "Write the backup to this local file instead of uploading it",
+				},
+			},
+			Action: backupCreate,
+		},
+		{
+			Name:      "restore",
+			Usage:     "Replace the database's contents with a backup from BACKUP_S3_BUCKET",
+			ArgsUsage: "<backup name>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "file",
+					Usage: "Read the backup from this local file instead of downloading it",
+				},
+				&cli.BoolFlag{
+					Name:  "force",
+					Usage: "Restore even though the database already has users",
+				},
+			},
+			Action: backupRestore,
+		},
+	},
+}
+
+// backupHeader follows backupMagic as a line of JSON. It is stored in the
+// clear, so restores can unwrap the key, and authenticated with every
+// encrypted chunk.
+type backupHeader struct {
+	FormatVersion int       `json:"format_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	SchemaVersion int64     `json:"schema_version"`
+	IncludesLogs  bool      `json:"includes_logs"`
+	Tables        []string  `json:"tables"`
+	// The backup's key, wrapped with the master key (see
+	// ENCRYPTION_KEY_PROVIDER)
+	MasterKeyID string `json:"master_key_id"`
+	WrappedKey  string `json:"wrapped_key"`
+}
+
+// backupRow is one line of the gzipped JSON Lines a backup encrypts
+type backupRow struct {
+	Table string          `json:"table"`
+	Row   json.RawMessage `json:"row"`
+}
+
+func backupCreate(ctx context.Context, cmd *cli.Command) error {
+	if err := config.Load(); err != nil {
+		return err
+	}
+
+	file := cmd.String("file")
+	bucket := config.String("BACKUP_S3_BUCKET")
+	var s3 *awsapi.Client
+	if file == "" {
+		if bucket == "" {
+			return errors.New("BACKUP_S3_BUCKET is required, or write to a local file with --file")
+		}
+		client, err := awsapi.NewFromConfig(backupTransferTimeout)
+		if err != nil {
+			return err
+		}
+		s3 = client
+	}
+
+	if err := db.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	schemaVersion, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	tables, err := db.BackupTables(ctx, cmd.Bool("include-logs"))
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	master, err := encryption.NewMasterKey()
+	if err != nil {
+		return err
+	}
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		return err
+	}
+	wrapped, err := master.Wrap(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to wrap backup key: %w", err)
+	}
+	header := backupHeader{
+		FormatVersion: backupFormatVersion,
+		CreatedAt:     time.Now().UTC(),
+		SchemaVersion: schemaVersion,
+		IncludesLogs:  cmd.Bool("include-logs"),
+		Tables:        tables,
+		MasterKeyID:   master.ID(),
+		WrappedKey:    wrapped,
+	}
+
+	// S3 signs the payload hash up front, so uploads go through a
+	// temporary file
+	var f *os.File
+	if file != "" {
+		f, err = os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	} else {
+		f, err = os.CreateTemp("", "hyperwhisper-backup-*")
+		if err == nil {
+			defer os.Remove(f.Name())
+		}
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	counts, err := writeBackup(ctx, io.MultiWriter(f, hash), header, key)
+	if err != nil {
+		if file != "" {
+			os.Remove(file)
+		}
+		return err
+	}
+
+	total := 0
+	for _, table := range tables {
+		fmt.Printf("  %-28s %d row(s)\n", table, counts[table])
+		total += counts[table]
+	}
+
+	if file != "" {
+		if err := f.Sync(); err != nil {
+			return err
+		}
+		fmt.Printf("Backed up %d row(s) of %d table(s) to %s.\n", total, len(tables), file)
+		return nil
+	}
+
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	name := "hyperwhisper-" + header.CreatedAt.Format("20060102T150405Z") + ".backup"
+	objectKey := path.Join(config.String("BACKUP_S3_PREFIX"), name)
+	if err := s3.PutObject(ctx, config.String("BACKUP_S3_ENDPOINT"), bucket, objectKey, f, size, hex.EncodeToString(hash.Sum(nil))); err != nil {
+		return fmt.Errorf("upload failed: %w", err)
+	}
+
+	fmt.Printf("Backed up %d row(s) of %d table(s) to s3://%s/%s.\n", total, len(tables), bucket, objectKey)
+	fmt.Printf("Restore it with: backup restore %s\n", name)
+	return nil
+}
+
+// writeBackup writes a whole backup to w and returns the rows written per
+// table
+func writeBackup(ctx context.Context, w io.Writer, header backupHeader, key []byte) (map[string]int, error) {
+	headerLine, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	prelude := append([]byte(backupMagic), append(headerLine, '\n')...)
+	if _, err := w.Write(prelude); err != nil {
+		return nil, err
+	}
+
+	sealed, err := encryption.NewStreamWriter(w, key, prelude)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(sealed)
+	buf := bufio.NewWriter(gz)
+	enc := json.NewEncoder(buf)
+
+	counts := make(map[string]int)
+	err = db.DumpTables(ctx, header.Tables, func(table string, row json.RawMessage) error {
+		counts[table]++
+		return enc.Encode(backupRow{Table: table, Row: row})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("export failed: %w", err)
+	}
+
+	if err := buf.Flush(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return counts, sealed.Close()
+}
+
+func backupRestore(ctx context.Context, cmd *cli.Command) error {
+	if err := config.Load(); err != nil {
+		return err
+	}
+
+	var body io.ReadCloser
+	source := cmd.String("file")
+	if source != "" {
+		f, err := os.Open(source)
+		if err != nil {
+			return err
+		}
+		body = f
+	} else {
+		name := cmd.Args().First()
+		if name == "" {
+			return errors.New("name the backup to restore, or restore a local file with --file")
+		}
+		bucket := config.String("BACKUP_S3_BUCKET")
+		if bucket == "" {
+			return errors.New("BACKUP_S3_BUCKET is required, or restore a local file with --file")
+		}
+		s3, err := awsapi.NewFromConfig(backupTransferTimeout)
+		if err != nil {
+			return err
+		}
+		objectKey := path.Join(config.String("BACKUP_S3_PREFIX"), name)
+		body, err = s3.GetObject(ctx, config.String("BACKUP_S3_ENDPOINT"), bucket, objectKey)
+		if err != nil {
+			return fmt.Errorf("download failed: %w", err)
+		}
+		source = fmt.Sprintf("s3://%s/%s", bucket, objectKey)
+	}
+	defer body.Close()
+
+	in := bufio.NewReader(body)
+	header, prelude, err := readBackupHeader(in)
+	if err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+
+	if err := db.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	schemaVersion, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if schemaVersion != header.SchemaVersion {
+		return fmt.Errorf("the backup was taken at schema version %d but the database is at %d; migrate to version %d first",
+			header.SchemaVersion, schemaVersion, header.SchemaVersion)
+	}
+	users, err := db.CountUsers(ctx)
+	if err != nil {
+		return err
+	}
+	if users > 0 && !cmd.Bool("force") {
+		return fmt.Errorf("the database already has %d user(s); restore with --force to replace them", users)
+	}
+
+	master, err := encryption.NewMasterKey()
+	if err != nil {
+		return err
+	}
+	key, err := master.Unwrap(ctx, header.MasterKeyID, header.WrappedKey)
+	if err != nil {
+		return fmt.Errorf("failed to unwrap backup key (master key %s): %w", header.MasterKeyID, err)
+	}
+	plaintext, err := encryption.NewStreamReader(in, key, prelude)
+	if err != nil {
+		return err
+	}
+	gz, err := gzip.NewReader(plaintext)
+	if err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+
+	fmt.Printf("Restoring backup of %s (schema version %d) from %s...\n",
+		header.CreatedAt.Format(time.RFC3339), header.SchemaVersion, source)
+	restore, err := db.BeginRestore(ctx, header.Tables)
+	if err != nil {
+		return fmt.Errorf("failed to empty tables: %w", err)
+	}
+	defer restore.Rollback()
+
+	counts, err := restoreRows(ctx, restore, json.NewDecoder(gz))
+	if err != nil {
+		return fmt.Errorf("restore failed, nothing was changed: %w", err)
+	}
+	if err := restore.Commit(ctx); err != nil {
+		return fmt.Errorf("restore failed, nothing was changed: %w", err)
+	}
+
+	total := 0
+	for _, table := range header.Tables {
+		fmt.Printf("  %-28s %d row(s)\n", table, counts[table])
+		total += counts[table]
+	}
+	fmt.Printf("Restored %d row(s) of %d table(s).\n", total, len(header.Tables))
+	if !header.IncludesLogs {
+		fmt.Println("The backup holds no usage logs; usage starts from zero.")
+	}
+	return nil
+}
+
+// readBackupHeader reads the magic and header of a backup. It also returns
+// the bytes read, which authenticate the encrypted rows.
+func readBackupHeader(in *bufio.Reader) (backupHeader, []byte, error) {
+	var header backupHeader
+	magic := make([]byte, len(backupMagic))
+	if _, err := io.ReadFull(in, magic); err != nil || string(magic) != backupMagic {
+		return header, nil, errors.New("not a HyperWhisper backup")
+	}
+	line, err := in.ReadBytes('\n')
+	if err != nil {
+		return header, nil, fmt.Errorf("invalid backup header: %w", err)
+	}
+	if err := json.Unmarshal(line, &header); err != nil {
+		return header, nil, fmt.Errorf("invalid backup header: %w", err)
+	}
+	if header.FormatVersion != backupFormatVersion {
+		return header, nil, fmt.Errorf("unsupported backup format version %d", header.FormatVersion)
+	}
+	return header, append(magic, line...), nil
+}
+
+// restoreRows inserts the rows of a backup in batches and returns the rows
+// restored per table
+func restoreRows(ctx context.Context, restore *db.Restore, dec *json.Decoder) (map[string]int, error) {
+	counts := make(map[string]int)
+	var table string
+	var batch []json.RawMessage
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := restore.Insert(ctx, table, batch); err != nil {
+			return err
+		}
+		counts[table] += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		var row backupRow
+		if err := dec.Decode(&row); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if row.Table != table || len(batch) == restoreBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			table = row.Table
+		}
+		batch = append(batch, bytes.Clone(row.Row))
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadBackupHeader(t *testing.T) {
+	header := `{"format_version":1,"schema_version":40,"tables":["users"],"master_key_id":"local:abc","wrapped_key":"xyz"}` + "\n"
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "valid", input: backupMagic + header + "sealed rows"},
+		{name: "not a backup", input: "PK\x03\x04" + header, wantErr: "not a HyperWhisper backup"},
+		{name: "empty", input: "", wantErr: "not a HyperWhisper backup"},
+		{name: "header not terminated", input: backupMagic + strings.TrimSuffix(header, "\n"), wantErr: "invalid backup header"},
+		{name: "header not JSON", input: backupMagic + "{\n", wantErr: "invalid backup header"},
+		{name: "newer format", input: backupMagic + `{"format_version":2}` + "\n", wantErr: "unsupported backup format version 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := bufio.NewReader(strings.NewReader(tt.input))
+			got, prelude, err := readBackupHeader(in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.SchemaVersion != 40 || got.MasterKeyID != "local:abc" || len(got.Tables) != 1 {
+				t.Errorf("header = %+v", got)
+			}
+			// The prelude authenticates the rows, so it must be the exact bytes
+			// writeBackup wrote
+			if string(prelude) != backupMagic+header {
+				t.Errorf("prelude = %q, want %q", prelude, backupMagic+header)
+			}
+			rest, _ := io.ReadAll(in)
+			if string(rest) != "sealed rows" {
+				t.Errorf("rest = %q, want the encrypted rows", rest)
+			}
+		})
+	}
+}
+
+func TestReadBackupHeaderRoundTrip(t *testing.T) {
+	header := backupHeader{FormatVersion: backupFormatVersion, SchemaVersion: 40, Tables: []string{"users", "api_keys"}}
+	line, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	written := append([]byte(backupMagic), append(line, '\n')...)
+
+	got, prelude, err := readBackupHeader(bufio.NewReader(bytes.NewReader(written)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(prelude, written) {
+		t.Errorf("prelude = %q, want %q", prelude, written)
+	}
+	if strings.Join(got.Tables, ",") != "users,api_keys" {
+		t.Errorf("tables = %v", got.Tables)
+	}
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"hyperwhisper/internal/config"
+
+	"github.com/urfave/cli/v3"
+)
+
+var ConfigCommand = &cli.Command{
+	Name:  "config",
+	Usage: "Configuration commands",
+	Commands: []*cli.Command{
+		{
+			Name:  "show",
+			Usage: "Print the effective configuration and validate it",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "redacted",
+					Value: true,
+					Usage: "Hide secret values (use --redacted=false to reveal them)",
+				},
+			},
+			Action: configShow,
+		},
+	},
+}
+
+func configShow(ctx context.Context, cmd *cli.Command) error {
+	loadErr := config.Load()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tSOURCE\tREQUIRED\tVALUE")
+	for _, e := range config.All(cmd.Bool("redacted")) {
+		required := ""
+		if e.RequiredInProd {
+			required = "prod"
+		}
+		source := string(e.Source)
+		if e.File != "" {
+			source = fmt.Sprintf("%s (%s)", e.Source, e.File)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Name, e.Kind, source, required, e.Value)
+	}
+	w.Flush()
+
+	if loadErr != nil {
+		fmt.Println()
+		return loadErr
+	}
+
+	fmt.Println("\nConfiguration is valid.")
+	return nil
+}
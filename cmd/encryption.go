@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db"
+)
+
+var EncryptionCommand = &cli.Command{
+	Name:  "encryption",
+	Usage: "Column encryption key management",
+	Commands: []*cli.Command{
+		{
+			Name:   "reencrypt",
+			Usage:  "Encrypt plaintext values and move values off retired data keys",
+			Action: encryptionReencrypt,
+		},
+		{
+			Name:   "rotate",
+			Usage:  "Create a new data key, rewrap keys with the current master key, then re-encrypt",
+			Action: encryptionRotate,
+		},
+	},
+}
+
+func connectForEncryption(ctx context.Context) error {
+	if err := config.Load(); err != nil {
+		return err
+	}
+	if err := db.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := db.InitEncryption(ctx); err != nil {
+		return fmt.Errorf("failed to load encryption keys: %w", err)
+	}
+	return nil
+}
+
+func encryptionReencrypt(ctx context.Context, cmd *cli.Command) error {
+	if err := connectForEncryption(ctx); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return runReencrypt(ctx)
+}
+
+func encryptionRotate(ctx context.Context, cmd *cli.Command) error {
+	if err := connectForEncryption(ctx); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	fmt.Println("Rotating encryption keys...")
+	if err := db.RotateEncryptionKeys(ctx); err != nil {
+		return fmt.Errorf("rotation failed: %w", err)
+	}
+
+	return runReencrypt(ctx)
+}
+
+func runReencrypt(ctx context.Context) error {
+	fmt.Println("Re-encrypting sensitive columns...")
+	stats, err := db.Reencrypt(ctx)
+	if err != nil {
+		return fmt.Errorf("re-encryption failed: %w", err)
+	}
+
+	fmt.Printf("Re-encrypted %d device fingerprint(s), %d transcription log IP(s), %d trial usage IP(s), %d transcript(s), %d API key IP(s), %d refresh token IP(s), %d login event IP(s), %d auth event IP(s), %d tenant Deepgram key(s), %d access log IP(s).\n",
+		stats.Fingerprints, stats.TranscriptionIPs, stats.TrialUsageIPs, stats.Transcripts, stats.APIKeyIPs, stats.RefreshTokenIPs, stats.LoginEventIPs, stats.AuthEventIPs, stats.TenantAPIKeys, stats.AccessLogIPs)
+	return nil
+}
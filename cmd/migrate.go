@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
 	"strconv"
 
 	"github.com/golang-migrate/migrate/v4"
@@ -12,6 +11,8 @@ import (
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/urfave/cli/v3"
 
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db"
 	"hyperwhisper/migrations"
 )
 
@@ -46,11 +47,7 @@ var MigrateCommand = &cli.Command{
 }
 
 func getDBURL() string {
-	dbURL := os.Getenv("DATABASE_URL")
-	if dbURL == "" {
-		dbURL = "postgres://localhost:5432/hyperwhisper?sslmode=disable"
-	}
-	return dbURL
+	return config.String("DATABASE_URL")
 }
 
 func newMigrate() (*migrate.Migrate, error) {
@@ -84,17 +81,35 @@ func migrateUp(ctx context.Context, cmd *cli.Command) error {
 
 	if errors.Is(err, migrate.ErrNoChange) {
 		fmt.Println("No migrations to apply.")
-		return nil
-	}
-
-	if err != nil {
+	} else if err != nil {
 		return fmt.Errorf("migration failed: %w", err)
+	} else {
+		fmt.Println("Migrations applied successfully.")
 	}
 
-	fmt.Println("Migrations applied successfully.")
+	// Seed what the schema's defaults can't know, unless migrating partway
+	if args.Len() == 0 {
+		if err := db.Connect(); err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer db.Close()
+		seedTrialPreset(ctx)
+	}
 	return nil
 }
 
+// seedTrialPreset applies the TRIAL_* settings to the default trial preset,
+// unless an admin has edited it
+func seedTrialPreset(ctx context.Context) {
+	created, err := db.SeedTrialPreset(ctx)
+	switch {
+	case err != nil:
+		fmt.Printf("Warning: Could not seed the default trial preset: %v\n", err)
+	case created:
+		fmt.Println("Applied the TRIAL_* settings to the default trial preset.")
+	}
+}
+
 func migrateDown(ctx context.Context, cmd *cli.Command) error {
 	m, err := newMigrate()
 	if err != nil {
@@ -0,0 +1,194 @@
+package cmd
+
+import "hyperwhisper/internal/auth"
+
+// apiPolicies decides who may call each API route. Every route registered
+// in setupAPIRoutes needs an entry (serve refuses to start otherwise), and
+// the policy middleware is the only place access tokens are checked.
+var apiPolicies = auth.Policies{
+	// Health checks
+	"GET /health": auth.Public,
+	"GET /ht":     auth.Public,
+
+	// Prometheus scrapes, authenticated with METRICS_TOKEN
+	"GET /metrics/usage": auth.ScopedToken,
+
+	// Accounts; token_refresh and signout read the refresh cookie and run
+	// the CSRF check themselves
+	"POST /signup":          auth.Public,
+	"GET /signup/policy":    auth.Public,
+	"POST /signin":          auth.Public,
+	"POST /token_refresh":   auth.Public,
+	"POST /signout":         auth.Public,
+	"POST /password/forgot": auth.Public,
+	"POST /password/reset":  auth.Public,
+
+	// Branding of the tenant whose domain was addressed
+	"GET /branding": auth.Public,
+
+	// The signed-in user
+	"GET /me":                    auth.AllowsScope(auth.Authenticated, auth.ScopeProfile),
+	"PATCH /me":                  auth.Authenticated,
+	"PUT /me/settings":           auth.Authenticated,
+	"POST /me/password":          auth.Authenticated,
+	"GET /me/sessions":           auth.Authenticated,
+	"DELETE /me/sessions/:jti":   auth.Authenticated,
+	"GET /me/security/logins":    auth.Authenticated,
+	"POST /me/export":            auth.Authenticated,
+	"GET /me/delete":             auth.Authenticated,
+	"POST /me/delete":            auth.Authenticated,
+	"DELETE /me/delete":          auth.Authenticated,
+	"GET /me/statements/:period": auth.Authenticated,
+
+	// Transcription: the proxy takes API and trial keys, the dashboard
+	// stream the user's access token
+	"GET /deepgram/listen":              auth.ScopedToken,
+	"GET /deepgram/dashboard/listen":    auth.Authenticated,
+	"POST /deepgram/keys":               auth.Authenticated,
+	"GET /deepgram/keys":                auth.Authenticated,
+	"DELETE /deepgram/keys/:id":         auth.Authenticated,
+	"POST /deepgram/keys/:id/reenable":  auth.Authenticated,
+	"POST /deepgram/keys/reactivate":    auth.Public,
+	"GET /deepgram/usage":               auth.AllowsScope(auth.AcceptsAPIKeys(auth.Authenticated), auth.ScopeUsageRead),
+	"GET /deepgram/logs":                auth.AllowsScope(auth.AcceptsAPIKeys(auth.Authenticated), auth.ScopeLogsRead),
+	"GET /deepgram/logs/:id":            auth.AllowsScope(auth.AcceptsAPIKeys(auth.Authenticated), auth.ScopeLogsRead),
+	"GET /deepgram/logs/:id/transcript": auth.AllowsScope(auth.AcceptsAPIKeys(auth.Authenticated), auth.ScopeTranscriptsRead),
+
+	// Third-party applications: users register them and consent on the
+	// authorize screen; applications then redeem codes and refresh tokens
+	// at the token endpoint with their client credentials
+	"POST /oauth/clients":         auth.Authenticated,
+	"GET /oauth/clients":          auth.Authenticated,
+	"DELETE /oauth/clients/:id":   auth.Authenticated,
+	"GET /oauth/authorize":        auth.Authenticated,
+	"POST /oauth/authorize":       auth.Authenticated,
+	"POST /oauth/token":           auth.Public,
+	"POST /oauth/device/code":     auth.Public,
+	"GET /oauth/device":           auth.Authenticated,
+	"POST /oauth/device":          auth.Authenticated,
+	"GET /me/oauth/grants":        auth.Authenticated,
+	"DELETE /me/oauth/grants/:id": auth.Authenticated,
+
+	// Organizations; membership and roles are checked by the handlers
+	"POST /organizations":                       auth.Authenticated,
+	"GET /organizations":                        auth.Authenticated,
+	"GET /organizations/:id/members":            auth.Authenticated,
+	"PUT /organizations/:id/members":            auth.Authenticated,
+	"DELETE /organizations/:id/members/:userId": auth.Authenticated,
+	"GET /organizations/:id/keys":               auth.Authenticated,
+	"POST /organizations/:id/keys":              auth.Authenticated,
+	"DELETE /organizations/:id/keys/:keyId":     auth.Authenticated,
+	"GET /organizations/:id/usage":              auth.Authenticated,
+
+	// Exports; kinds that export other users' data check for admins
+	"POST /exports":             auth.Authenticated,
+	"GET /exports":              auth.Authenticated,
+	"GET /exports/:id":          auth.Authenticated,
+	"GET /exports/:id/download": auth.Authenticated,
+
+	// Trials: provisioning is gated by device attestation, everything else
+	// needs the trial key
+	"GET /trial/limits":            auth.Public,
+	"POST /trial/provision":        auth.Public,
+	"POST /trial/rotate":           auth.ScopedToken,
+	"GET /trial/usage":             auth.ScopedToken,
+	"GET /trial/status":            auth.ScopedToken,
+	"POST /trial/grants":           auth.ScopedToken,
+	"POST /trial/grants/reconcile": auth.ScopedToken,
+
+	// Client error reports, with an optional API key
+	"POST /telemetry/errors": auth.Public,
+
+	// Coarse usage stats for the marketing site
+	"GET /stats/badge": auth.Public,
+
+	// Administration. Reads are tagged with the permission staff roles
+	// need for them; everything else is for admins only. Tenant staff only
+	// get the routes marked tenant-scoped, limited to their tenant.
+	"GET /admin/users":                               auth.TenantScoped(auth.Requires(auth.PermViewUsers)),
+	"POST /admin/users":                              auth.TenantScoped(auth.Admin),
+	"PUT /admin/users/:id":                           auth.TenantScoped(auth.Admin),
+	"DELETE /admin/users/:id":                        auth.TenantScoped(auth.Admin),
+	"POST /admin/users/merge":                        auth.Admin,
+	"PUT /admin/users/:id/billing-cycle":             auth.Admin,
+	"PUT /admin/users/:id/api-key-limit":             auth.Admin,
+	"POST /admin/users/:id/security-flag/clear":      auth.Admin,
+	"POST /admin/users/:id/suspend":                  auth.TenantScoped(auth.Admin),
+	"POST /admin/users/:id/unsuspend":                auth.TenantScoped(auth.Admin),
+	"GET /admin/tokens":                              auth.Requires(auth.PermViewUsers),
+	"POST /admin/tokens/revoke":                      auth.Admin,
+	"POST /admin/tokens/revoke-user/:id":             auth.Admin,
+	"POST /admin/tokens/cleanup":                     auth.Admin,
+	"GET /admin/settings":                            auth.Requires(auth.PermViewSettings),
+	"PUT /admin/settings":                            auth.Admin,
+	"GET /admin/settings/signup":                     auth.Requires(auth.PermViewSettings),
+	"PUT /admin/settings/signup":                     auth.Admin,
+	"GET /admin/settings/deepgram":                   auth.Requires(auth.PermViewSettings),
+	"PUT /admin/settings/deepgram":                   auth.Admin,
+	"GET /admin/reports/schedules":                   auth.Admin,
+	"POST /admin/reports/schedules":                  auth.Admin,
+	"DELETE /admin/reports/schedules/:id":            auth.Admin,
+	"GET /admin/invites":                             auth.Requires(auth.PermViewSettings),
+	"POST /admin/invites":                            auth.Admin,
+	"DELETE /admin/invites/:id":                      auth.Admin,
+	"GET /admin/deepgram/logs":                       auth.Requires(auth.PermViewLogs),
+	"GET /admin/deepgram/logs/:id":                   auth.Requires(auth.PermViewLogs),
+	"GET /admin/deepgram/keys":                       auth.Requires(auth.PermViewKeys),
+	"GET /admin/deepgram/usage":                      auth.Requires(auth.PermViewUsage),
+	"PUT /admin/deepgram/keys/:id/restrictions":      auth.Admin,
+	"POST /admin/deepgram/keys/:id/revoke":           auth.Admin,
+	"POST /admin/deepgram/keys/:id/unrevoke":         auth.Admin,
+	"POST /admin/deepgram/keys/:id/reenable":         auth.Admin,
+	"POST /admin/deepgram/keys/:id/transfer":         auth.Admin,
+	"POST /admin/statements":                         auth.Admin,
+	"GET /admin/deepgram/budget":                     auth.Requires(auth.PermViewUsage),
+	"GET /admin/organizations":                       auth.Requires(auth.PermViewUsers),
+	"PUT /admin/organizations/:id/quota":             auth.Admin,
+	"PUT /admin/organizations/:id/data-region":       auth.Admin,
+	"GET /admin/trial/keys":                          auth.Requires(auth.PermViewKeys),
+	"GET /admin/trial/usage":                         auth.Requires(auth.PermViewUsage),
+	"GET /admin/trial/limits":                        auth.Requires(auth.PermViewSettings),
+	"PUT /admin/trial/limits":                        auth.Admin,
+	"PUT /admin/trial/restrictions":                  auth.Admin,
+	"GET /admin/trial/presets":                       auth.Requires(auth.PermViewSettings),
+	"POST /admin/trial/presets":                      auth.Admin,
+	"GET /admin/trial/presets/usage":                 auth.Requires(auth.PermViewUsage),
+	"PUT /admin/trial/presets/:name":                 auth.Admin,
+	"DELETE /admin/trial/presets/:name":              auth.Admin,
+	"PUT /admin/trial/presets/:name/restrictions":    auth.Admin,
+	"POST /admin/trial/presets/:name/campaign-codes": auth.Admin,
+	"GET /admin/trial/sources":                       auth.Requires(auth.PermViewSettings),
+	"POST /admin/trial/sources":                      auth.Admin,
+	"DELETE /admin/trial/sources/:name":              auth.Admin,
+	"GET /admin/trial/keys/:id/logs":                 auth.Requires(auth.PermViewLogs),
+	"POST /admin/trial/keys/:id/revoke":              auth.Admin,
+	"POST /admin/trial/keys/:id/unrevoke":            auth.Admin,
+	"DELETE /admin/trial/keys/:id":                   auth.Admin,
+	"POST /admin/trial/cleanup":                      auth.Admin,
+	"POST /admin/limits/simulate":                    auth.Requires(auth.PermViewUsage),
+	"GET /admin/policies":                            auth.Requires(auth.PermViewSettings),
+	"POST /admin/policies":                           auth.Admin,
+	"PUT /admin/policies/:id":                        auth.Admin,
+	"DELETE /admin/policies/:id":                     auth.Admin,
+	"GET /admin/telemetry/errors":                    auth.Requires(auth.PermViewLogs),
+	"PUT /admin/telemetry/errors/:id":                auth.Admin,
+	"GET /admin/audit-events":                        auth.Requires(auth.PermViewAudit),
+	"GET /admin/access-logs":                         auth.Requires(auth.PermViewLogs),
+	"GET /admin/login-events":                        auth.Requires(auth.PermViewAudit),
+	"GET /admin/auth-events":                         auth.Requires(auth.PermViewAudit),
+	"GET /admin/ws/monitor":                          auth.Admin,
+	"GET /admin/tenants":                             auth.Admin,
+	"POST /admin/tenants":                            auth.Admin,
+	"PUT /admin/tenants/:id":                         auth.Admin,
+	"DELETE /admin/tenants/:id":                      auth.Admin,
+	"GET /admin/tenant":                              auth.TenantScoped(auth.Requires(auth.PermViewSettings)),
+	"PUT /admin/tenant":                              auth.TenantScoped(auth.Admin),
+	"GET /admin/tenants/:id/domains":                 auth.Admin,
+	"POST /admin/tenants/:id/domains":                auth.Admin,
+	"POST /admin/tenants/:id/domains/:domain/verify": auth.Admin,
+	"DELETE /admin/tenants/:id/domains/:domain":      auth.Admin,
+	"GET /admin/tenant/domains":                      auth.TenantScoped(auth.Requires(auth.PermViewSettings)),
+	"POST /admin/tenant/domains":                     auth.TenantScoped(auth.Admin),
+	"POST /admin/tenant/domains/:domain/verify":      auth.TenantScoped(auth.Admin),
+	"DELETE /admin/tenant/domains/:domain":           auth.TenantScoped(auth.Admin),
+}
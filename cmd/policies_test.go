@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"testing"
+
+	"hyperwhisper/internal/handlers"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TestAPIPolicies fails when a route is registered without deciding who may
+// call it, before the server refuses to start over it
+func TestAPIPolicies(t *testing.T) {
+	e := echo.New()
+	setupAPIRoutes(e.Group(apiPrefix), handlers.NewAccessLogRecorder(nil), handlers.NewExportHandler(nil))
+
+	if err := apiPolicies.Verify(e.Routes(), apiPrefix); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -12,10 +12,15 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
 	"hyperwhisper/internal/db"
+	"hyperwhisper/internal/encryption"
 	"hyperwhisper/internal/handlers"
+	"hyperwhisper/internal/logging"
+	"hyperwhisper/internal/upgrade"
 	"hyperwhisper/web"
 
 	"github.com/labstack/echo/v4"
@@ -42,6 +47,16 @@ var ServeCommand = &cli.Command{
 			Value: false,
 			Usage: "Run in development mode (starts nuxt dev server)",
 		},
+		&cli.DurationFlag{
+			Name:  "drain-timeout",
+			Value: 5 * time.Minute,
+			Usage: "How long to wait for active transcription sessions to finish on shutdown",
+		},
+		&cli.DurationFlag{
+			Name:  "upgrade-timeout",
+			Value: 30 * time.Second,
+			Usage: "How long to wait for an upgraded process to become ready (SIGHUP)",
+		},
 	},
 	Action: runServe,
 }
@@ -50,15 +65,61 @@ func runServe(ctx context.Context, cmd *cli.Command) error {
 	host := cmd.String("api-host")
 	port := cmd.String("api-port")
 	dev := cmd.Bool("dev")
+	drainTimeout := cmd.Duration("drain-timeout")
+	upgradeTimeout := cmd.Duration("upgrade-timeout")
+
+	// Validate configuration before touching anything else
+	if err := config.Load(); err != nil {
+		return err
+	}
+	if err := logging.Configure(); err != nil {
+		return err
+	}
 
-	// Connect to database
+	// Pick up rotated secrets mounted as files (*_FILE settings)
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	go config.WatchFiles(watchCtx, config.Duration("CONFIG_RELOAD_INTERVAL"))
+
+	// Pick up secrets rotated in the external secret store, if any
+	if config.String("SECRETS_PROVIDER") != "none" {
+		go config.RefreshProvider(watchCtx, config.Duration("SECRETS_REFRESH_INTERVAL"))
+	}
+
+	// Connect to database. An unreachable database is not fatal: the server
+	// starts in degraded mode, answering 503 until the health monitor sees
+	// the database come back.
 	if err := db.Connect(); err != nil {
-		fmt.Printf("Warning: Could not connect to database: %v\n", err)
-	} else {
-		defer db.Close()
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	// Encryption keys are loaded from the database, so while it is down
+	// they are loaded once it comes back
+	db.OnReconnect(func(ctx context.Context) {
+		if encryption.Loaded() {
+			return
+		}
+		if err := db.InitEncryption(ctx); err != nil {
+			fmt.Printf("Warning: Could not load encryption keys after reconnecting: %v\n", err)
+		}
+	})
+	if err := db.Ping(); err != nil {
+		fmt.Printf("Warning: Could not connect to database, starting in degraded mode: %v\n", err)
+	} else if err := db.InitEncryption(ctx); err != nil {
+		// Sensitive columns cannot be read or written without their keys,
+		// so a broken key setup is fatal outside of development
+		if !config.IsDev() {
+			return fmt.Errorf("failed to load encryption keys: %w", err)
+		}
+		fmt.Printf("Warning: Could not load encryption keys: %v\n", err)
+	}
+	if db.Available() {
+		seedTrialPreset(ctx)
 	}
 
 	var nuxtCmd *exec.Cmd
+	drained := make(chan struct{})
 
 	if dev {
 		// Start nuxt dev server
@@ -77,14 +138,36 @@ func runServe(ctx context.Context, cmd *cli.Command) error {
 	// Setup Echo server
 	e := echo.New()
 	e.HideBanner = true
+	e.JSONSerializer = handlers.NewLocalizedJSONSerializer(db.DB)
+	e.HTTPErrorHandler = handlers.HTTPErrorHandler
+	// c.RealIP() only believes forwarding headers from TRUSTED_PROXIES
+	e.IPExtractor = handlers.ClientIP
 
 	// Middleware
-	e.Use(middleware.Logger())
+	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
+		Output: logging.NewWriter(os.Stdout),
+	}))
 	e.Use(middleware.Recover())
 
 	// API routes group
-	api := e.Group("/api/v1")
-	setupAPIRoutes(api)
+	accessLog := handlers.NewAccessLogRecorder(db.DB)
+	go accessLog.Run(watchCtx)
+	go db.RunPartitionMaintenance(watchCtx)
+	go db.RunPoolMonitor(watchCtx)
+	go db.RunHealthMonitor(watchCtx)
+	exportHandler := handlers.NewExportHandler(db.DB)
+	go exportHandler.Run(watchCtx)
+	go handlers.NewAccessDenylistSync(db.DB).Run(watchCtx)
+	go handlers.NewKeyInactivitySweep(db.DB).Run(watchCtx)
+	go handlers.NewSettingsSync(db.DB).Run(watchCtx)
+	go handlers.NewAuthEventWriter(db.DB).Run(watchCtx)
+	go handlers.NewAccountDeletionSweep(db.DB).Run(watchCtx)
+
+	api := e.Group(apiPrefix)
+	setupAPIRoutes(api, accessLog, exportHandler)
+	if err := apiPolicies.Verify(e.Routes(), apiPrefix); err != nil {
+		return err
+	}
 
 	if dev {
 		// Proxy non-API requests to Nuxt dev server
@@ -121,62 +204,164 @@ func runServe(ctx context.Context, cmd *cli.Command) error {
 		})
 	}
 
-	// Handle graceful shutdown
+	addr := fmt.Sprintf("%s:%s", host, port)
+
+	// The listener is either inherited from a parent process (binary upgrade)
+	// or freshly bound with SO_REUSEPORT
+	upgrader, err := upgrade.Listen(addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	e.Listener = upgrader.Listener()
+
+	// Handle graceful shutdown and zero-downtime upgrades
 	go func() {
 		sigChan := make(chan os.Signal, 1)
-		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-		<-sigChan
-
-		fmt.Println("\nShutting down...")
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+		for sig := range sigChan {
+			if sig == syscall.SIGHUP {
+				if dev {
+					fmt.Println("Ignoring SIGHUP: binary upgrades are disabled in dev mode")
+					continue
+				}
+
+				fmt.Println("Received SIGHUP, starting upgraded process...")
+				if err := upgrader.Upgrade(upgradeTimeout); err != nil {
+					fmt.Printf("Upgrade failed, continuing to serve: %v\n", err)
+					continue
+				}
+				fmt.Println("Upgraded process is ready, draining this process...")
+			} else {
+				fmt.Println("\nShutting down...")
+			}
+			break
+		}
 
 		if nuxtCmd != nil && nuxtCmd.Process != nil {
 			nuxtCmd.Process.Signal(syscall.SIGTERM)
 			nuxtCmd.Wait()
 		}
 
+		// Stop accepting new connections; hijacked WebSocket connections are
+		// not covered by this and are drained separately below
 		e.Shutdown(context.Background())
+
+		drainCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+		defer cancel()
+
+		if active := handlers.Sessions.Count(); active > 0 {
+			fmt.Printf("Waiting up to %s for %d active session(s) to finish...\n", drainTimeout, active)
+		}
+		if forced := handlers.Sessions.Drain(drainCtx, handlers.CloseServerRestart); forced > 0 {
+			fmt.Printf("Closed %d session(s) that did not finish in time\n", forced)
+		}
+
+		close(drained)
 	}()
 
-	addr := fmt.Sprintf("%s:%s", host, port)
-	fmt.Printf("Starting API server on %s\n", addr)
+	if upgrader.Inherited() {
+		fmt.Printf("Starting API server on %s (inherited listener)\n", addr)
+	} else {
+		fmt.Printf("Starting API server on %s\n", addr)
+	}
+
+	// Tell the parent process (if any) that it can start draining
+	upgrader.Ready()
 
 	if err := e.Start(addr); err != nil && err != http.ErrServerClosed {
 		return err
 	}
 
+	// Wait for active sessions to drain before returning
+	<-drained
+
 	return nil
 }
 
-func setupAPIRoutes(api *echo.Group) {
+// apiPrefix is where the API is served
+const apiPrefix = "/api/v1"
+
+func setupAPIRoutes(api *echo.Group, accessLog *handlers.AccessLogRecorder, exportHandler *handlers.ExportHandler) {
 	api.GET("/health", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
 	})
 
 	api.GET("/ht", healthCheck)
 
-	// Auth routes (public)
+	// Prometheus usage exporter, authenticated with METRICS_TOKEN by its
+	// handler. Registered with the health checks so frequent scrapes stay
+	// out of the access log and aren't routed to a tenant.
+	api.GET("/metrics/usage", handlers.NewMetricsHandler(db.DB).UsageMetrics, handlers.CompressionMiddleware())
+
+	// Persist access records for everything but the health checks and
+	// metrics above
+	api.Use(accessLog.Middleware())
+	api.Use(handlers.DatabaseAvailabilityMiddleware())
+	// Routes requests to the tenant whose domain they addressed
+	api.Use(handlers.TenantMiddleware(db.DB))
+	api.Use(handlers.CompressionMiddleware())
+	api.Use(handlers.BodyLimitMiddleware("BODY_LIMIT_DEFAULT"))
+	// Access tokens are checked here, per apiPolicies, and nowhere else
+	api.Use(apiPolicies.Middleware(apiPrefix))
+
+	// Auth routes (public), which only take small JSON bodies
 	authHandler := handlers.NewAuthHandler(db.DB)
-	api.POST("/signup", authHandler.SignUp)
-	api.POST("/signin", authHandler.SignIn)
-	api.POST("/token_refresh", authHandler.TokenRefresh)
-	api.POST("/signout", authHandler.SignOut)
-
-	// Protected routes
-	protected := api.Group("")
-	protected.Use(auth.JWTMiddleware())
-	protected.GET("/me", authHandler.Me)
-
-	// Admin routes (protected + admin only)
+	authLimit := handlers.BodyLimitMiddleware("BODY_LIMIT_AUTH")
+	api.POST("/signup", authHandler.SignUp, authLimit, handlers.AuthRateLimiter("signup"))
+	api.GET("/signup/policy", authHandler.GetSignupPolicy)
+	api.POST("/signin", authHandler.SignIn, authLimit, handlers.AuthRateLimiter("signin"))
+	api.POST("/token_refresh", authHandler.TokenRefresh, authLimit, handlers.AuthRateLimiter("token_refresh"), auth.CSRFMiddleware())
+	api.POST("/signout", authHandler.SignOut, authLimit, auth.CSRFMiddleware())
+	api.POST("/password/forgot", authHandler.ForgotPassword, authLimit)
+	api.POST("/password/reset", authHandler.ResetPassword, authLimit)
+	api.GET("/branding", authHandler.GetBranding)
+
+	// Signed-in user routes
+	api.GET("/me", authHandler.Me)
+	api.PATCH("/me", authHandler.UpdateMe)
+	api.PUT("/me/settings", authHandler.UpdateSettings)
+	api.POST("/me/password", authHandler.ChangePassword, authLimit)
+	api.GET("/me/sessions", authHandler.ListSessions)
+	api.DELETE("/me/sessions/:jti", authHandler.RevokeSession)
+	api.GET("/me/security/logins", authHandler.ListLoginEvents)
+	api.POST("/me/export", exportHandler.CreateAccountExport)
+	api.GET("/me/delete", authHandler.GetAccountDeletion)
+	api.POST("/me/delete", authHandler.ScheduleAccountDeletion, authLimit)
+	api.DELETE("/me/delete", authHandler.CancelAccountDeletion)
+
+	// Admin routes
 	admin := api.Group("/admin")
-	admin.Use(auth.JWTMiddleware())
-	admin.Use(auth.AdminMiddleware())
 
 	adminHandler := handlers.NewAdminHandler(db.DB)
 
 	// User management
 	admin.GET("/users", adminHandler.ListUsers)
 	admin.POST("/users", adminHandler.CreateUser)
+	admin.PUT("/users/:id", adminHandler.UpdateUser)
 	admin.DELETE("/users/:id", adminHandler.DeleteUser)
+	admin.POST("/users/merge", adminHandler.MergeUsers)
+	admin.PUT("/users/:id/billing-cycle", adminHandler.SetBillingCycleAnchor)
+	admin.PUT("/users/:id/api-key-limit", adminHandler.SetAPIKeyLimit)
+	admin.POST("/users/:id/security-flag/clear", adminHandler.ClearSecurityFlag)
+	admin.POST("/users/:id/suspend", adminHandler.SuspendUser)
+	admin.POST("/users/:id/unsuspend", adminHandler.UnsuspendUser)
+
+	// Tenants (white-label resellers); tenant admins manage their own
+	admin.GET("/tenants", adminHandler.ListTenants)
+	admin.POST("/tenants", adminHandler.CreateTenant)
+	admin.PUT("/tenants/:id", adminHandler.UpdateTenant)
+	admin.DELETE("/tenants/:id", adminHandler.DeleteTenant)
+	admin.GET("/tenant", adminHandler.GetOwnTenant)
+	admin.PUT("/tenant", adminHandler.UpdateOwnTenant)
+	admin.GET("/tenants/:id/domains", adminHandler.ListTenantDomains)
+	admin.POST("/tenants/:id/domains", adminHandler.AddTenantDomain)
+	admin.POST("/tenants/:id/domains/:domain/verify", adminHandler.VerifyTenantDomain)
+	admin.DELETE("/tenants/:id/domains/:domain", adminHandler.DeleteTenantDomain)
+	admin.GET("/tenant/domains", adminHandler.ListTenantDomains)
+	admin.POST("/tenant/domains", adminHandler.AddTenantDomain)
+	admin.POST("/tenant/domains/:domain/verify", adminHandler.VerifyTenantDomain)
+	admin.DELETE("/tenant/domains/:domain", adminHandler.DeleteTenantDomain)
 
 	// Token management
 	admin.GET("/tokens", adminHandler.ListRefreshTokens)
@@ -184,6 +369,20 @@ func setupAPIRoutes(api *echo.Group) {
 	admin.POST("/tokens/revoke-user/:id", adminHandler.RevokeUserRefreshTokens)
 	admin.POST("/tokens/cleanup", adminHandler.CleanupTokens)
 
+	// Signup policy and invite links
+	admin.GET("/settings", adminHandler.ListRuntimeSettings)
+	admin.PUT("/settings", adminHandler.UpdateRuntimeSettings)
+	admin.GET("/settings/signup", adminHandler.GetSignupPolicy)
+	admin.PUT("/settings/signup", adminHandler.UpdateSignupPolicy)
+	admin.GET("/settings/deepgram", adminHandler.GetDeepgramDefaults)
+	admin.PUT("/settings/deepgram", adminHandler.UpdateDeepgramDefaults)
+	admin.GET("/reports/schedules", adminHandler.ListReportSchedules)
+	admin.POST("/reports/schedules", adminHandler.CreateReportSchedule)
+	admin.DELETE("/reports/schedules/:id", adminHandler.DeleteReportSchedule)
+	admin.GET("/invites", adminHandler.ListInvites)
+	admin.POST("/invites", adminHandler.CreateInvite)
+	admin.DELETE("/invites/:id", adminHandler.RevokeInvite)
+
 	// Trial handler for trial API keys
 	trialHandler := handlers.NewTrialHandler(db.DB)
 
@@ -201,54 +400,164 @@ func setupAPIRoutes(api *echo.Group) {
 
 	// Dashboard WebSocket endpoint (JWT auth via cookie, no API key needed)
 	// This endpoint has a 5-minute session limit and doesn't log to transcription_logs
-	api.GET("/deepgram/dashboard/listen", deepgramHandler.DeepgramProxyDashboard, auth.JWTMiddleware())
+	api.GET("/deepgram/dashboard/listen", deepgramHandler.DeepgramProxyDashboard)
 
-	// API key management (JWT auth required)
+	// API key management
 	deepgram := api.Group("/deepgram")
-	deepgram.Use(auth.JWTMiddleware())
 	deepgram.POST("/keys", deepgramHandler.GenerateAPIKey)
 	deepgram.GET("/keys", deepgramHandler.ListAPIKeys)
 	deepgram.DELETE("/keys/:id", deepgramHandler.RevokeAPIKey)
-	deepgram.GET("/usage", deepgramHandler.GetUsageSummary)
-	deepgram.GET("/logs", deepgramHandler.ListTranscriptionLogs)
-
-	// Trial routes (public, no JWT required)
+	deepgram.POST("/keys/:id/reenable", deepgramHandler.ReenableAPIKey)
+	// Opened from inactivity emails, without signing in
+	deepgram.POST("/keys/reactivate", deepgramHandler.ReactivateAPIKey, authLimit)
+	// Usage and logs take API keys too, for programmatic clients
+	apiKeyAuth := handlers.APIKeyMiddleware(db.DB)
+	deepgram.GET("/usage", deepgramHandler.GetUsageSummary, apiKeyAuth)
+	deepgram.GET("/logs", deepgramHandler.ListTranscriptionLogs, apiKeyAuth)
+	deepgram.GET("/logs/:id", deepgramHandler.GetTranscriptionLog, apiKeyAuth)
+	deepgram.GET("/logs/:id/transcript", deepgramHandler.GetSessionTranscript, apiKeyAuth)
+
+	// Usage statements (e.g. /me/statements/2026-09.pdf)
+	api.GET("/me/statements/:period", deepgramHandler.GetStatement)
+
+	// Third-party applications acting for users (OAuth2)
+	oauthHandler := handlers.NewOAuthHandler(db.DB)
+	api.POST("/oauth/clients", oauthHandler.RegisterClient)
+	api.GET("/oauth/clients", oauthHandler.ListClients)
+	api.DELETE("/oauth/clients/:id", oauthHandler.DeleteClient)
+	api.GET("/oauth/authorize", oauthHandler.GetAuthorization)
+	api.POST("/oauth/authorize", oauthHandler.Authorize)
+	api.POST("/oauth/token", oauthHandler.Token, authLimit)
+	api.POST("/oauth/device/code", oauthHandler.DeviceAuthorization, authLimit)
+	api.GET("/oauth/device", oauthHandler.GetDeviceAuthorization, handlers.AuthRateLimiter("device_verification"))
+	api.POST("/oauth/device", oauthHandler.AnswerDeviceAuthorization, authLimit, handlers.AuthRateLimiter("device_verification"))
+	api.GET("/me/oauth/grants", oauthHandler.ListGrants)
+	api.DELETE("/me/oauth/grants/:id", oauthHandler.RevokeGrant)
+
+	// Organizations and the API keys their members share
+	orgHandler := handlers.NewOrganizationHandler(db.DB)
+	api.POST("/organizations", orgHandler.CreateOrganization)
+	api.GET("/organizations", orgHandler.ListOrganizations)
+	api.GET("/organizations/:id/members", orgHandler.ListMembers)
+	api.PUT("/organizations/:id/members", orgHandler.AddMember)
+	api.DELETE("/organizations/:id/members/:userId", orgHandler.RemoveMember)
+	api.GET("/organizations/:id/keys", orgHandler.ListKeys)
+	api.POST("/organizations/:id/keys", orgHandler.GenerateKey)
+	api.DELETE("/organizations/:id/keys/:keyId", orgHandler.RevokeKey)
+	api.GET("/organizations/:id/usage", orgHandler.GetUsage)
+
+	// Exports, built in the background and downloaded when completed
+	api.POST("/exports", exportHandler.CreateExport)
+	api.GET("/exports", exportHandler.ListExports)
+	api.GET("/exports/:id", exportHandler.GetExport)
+	api.GET("/exports/:id/download", exportHandler.DownloadExport)
+
+	// Trial routes (trial keys, not JWTs)
 	trial := api.Group("/trial")
-	trial.POST("/provision", trialHandler.ProvisionTrialKey)
+	trial.GET("/limits", trialHandler.GetTrialTerms)
+	trial.POST("/provision", trialHandler.ProvisionTrialKey, handlers.AuthRateLimiter("trial_provision"))
+	trial.POST("/rotate", trialHandler.RotateTrialKey)
 	trial.GET("/usage", trialHandler.GetTrialUsage)
 	trial.GET("/status", trialHandler.GetTrialStatus)
+	trial.POST("/grants", trialHandler.IssueGrant)
+	trial.POST("/grants/reconcile", trialHandler.ReconcileGrant)
+
+	// Client error telemetry (public, API key optional, rate-limited per IP)
+	telemetryHandler := handlers.NewTelemetryHandler(db.DB)
+	api.POST("/telemetry/errors", telemetryHandler.ReportError,
+		handlers.BodyLimit(32<<10), handlers.TelemetryRateLimiter())
+
+	// Public usage badge for the marketing site (cached, rate-limited per IP)
+	badgeHandler := handlers.NewUsageBadgeHandler(db.DB)
+	api.GET("/stats/badge", badgeHandler.GetUsageBadge, handlers.UsageBadgeRateLimiter())
 
 	// Admin Deepgram routes
 	admin.GET("/deepgram/logs", adminHandler.ListAllTranscriptionLogs)
+	admin.GET("/deepgram/logs/:id", adminHandler.GetTranscriptionLog)
 	admin.GET("/deepgram/keys", adminHandler.ListAllAPIKeys)
 	admin.GET("/deepgram/usage", adminHandler.GetSystemUsageSummary)
+	admin.PUT("/deepgram/keys/:id/restrictions", adminHandler.UpdateAPIKeyRestrictions)
+	admin.POST("/deepgram/keys/:id/revoke", adminHandler.AdminRevokeAPIKey)
+	admin.POST("/deepgram/keys/:id/unrevoke", adminHandler.AdminUnrevokeAPIKey)
+	admin.POST("/deepgram/keys/:id/reenable", adminHandler.AdminReenableAPIKey)
+	admin.POST("/deepgram/keys/:id/transfer", adminHandler.TransferAPIKey)
+	admin.POST("/statements", adminHandler.GenerateStatements)
+	admin.GET("/deepgram/budget", adminHandler.GetBudgetStatus)
+	admin.GET("/organizations", adminHandler.ListOrganizations)
+	admin.PUT("/organizations/:id/quota", adminHandler.SetOrganizationQuota)
+	admin.PUT("/organizations/:id/data-region", adminHandler.SetOrganizationDataRegion)
 
 	// Admin Trial routes
 	admin.GET("/trial/keys", adminHandler.ListTrialAPIKeys)
 	admin.GET("/trial/usage", adminHandler.GetTrialUsageSummary)
 	admin.GET("/trial/limits", adminHandler.GetTrialLimits)
 	admin.PUT("/trial/limits", adminHandler.UpdateTrialLimits)
+	admin.PUT("/trial/restrictions", adminHandler.UpdateTrialRestrictions)
+	admin.GET("/trial/presets", adminHandler.ListTrialPresets)
+	admin.POST("/trial/presets", adminHandler.CreateTrialPreset)
+	admin.GET("/trial/presets/usage", adminHandler.GetTrialPresetUsage)
+	admin.PUT("/trial/presets/:name", adminHandler.UpdateTrialPreset)
+	admin.DELETE("/trial/presets/:name", adminHandler.DeleteTrialPreset)
+	admin.PUT("/trial/presets/:name/restrictions", adminHandler.UpdateTrialPresetRestrictions)
+	admin.POST("/trial/presets/:name/campaign-codes", adminHandler.CreateCampaignCode)
+	admin.GET("/trial/sources", adminHandler.ListTrialSources)
+	admin.POST("/trial/sources", adminHandler.CreateTrialSource)
+	admin.DELETE("/trial/sources/:name", adminHandler.DeleteTrialSource)
+	admin.GET("/trial/keys/:id/logs", adminHandler.ListTrialKeyLogs)
 	admin.POST("/trial/keys/:id/revoke", adminHandler.RevokeTrialKey)
 	admin.POST("/trial/keys/:id/unrevoke", adminHandler.UnrevokeTrialKey)
 	admin.DELETE("/trial/keys/:id", adminHandler.DeleteTrialKey)
 	admin.POST("/trial/cleanup", adminHandler.CleanupExpiredTrialKeys)
+
+	// Admin limit simulation (what-if for trial and concurrency limits)
+	admin.POST("/limits/simulate", adminHandler.SimulateLimits)
+
+	// Admin session policy routes (forced Deepgram params, e.g. redaction)
+	admin.GET("/policies", adminHandler.ListSessionPolicies)
+	admin.POST("/policies", adminHandler.CreateSessionPolicy)
+	admin.PUT("/policies/:id", adminHandler.UpdateSessionPolicy)
+	admin.DELETE("/policies/:id", adminHandler.DeleteSessionPolicy)
+
+	// Admin client error telemetry triage
+	admin.GET("/telemetry/errors", adminHandler.ListErrorReports)
+	admin.PUT("/telemetry/errors/:id", adminHandler.UpdateErrorReportStatus)
+
+	// Admin audit trail
+	admin.GET("/audit-events", adminHandler.ListAuditEvents)
+
+	// Persisted API access records
+	admin.GET("/access-logs", adminHandler.ListAccessLogs)
+
+	// Sign-ins and token refreshes of all users
+	admin.GET("/login-events", adminHandler.ListLoginEvents)
+	admin.GET("/auth-events", adminHandler.ListAuthEvents)
+
+	// Real-time server events (WebSocket)
+	admin.GET("/ws/monitor", adminHandler.Monitor)
 }
 
 type HealthCheckResponse struct {
-	All bool `json:"all"`
-	DB  bool `json:"db"`
-	API bool `json:"api"`
+	All    bool   `json:"all"`
+	DB     bool   `json:"db"`
+	API    bool   `json:"api"`
+	Status string `json:"status"` // "ok", or "degraded" while the database is down
+	// When the database became unavailable, while degraded
+	DBDownSince string `json:"db_down_since,omitempty"`
 }
 
 func healthCheck(c echo.Context) error {
 	response := HealthCheckResponse{
-		API: true,
-		DB:  false,
-		All: false,
+		API:    true,
+		DB:     false,
+		All:    false,
+		Status: "ok",
 	}
 
 	if err := db.Ping(); err == nil {
 		response.DB = true
+	} else {
+		response.Status = "degraded"
+		response.DBDownSince = db.CurrentStatus().Since.UTC().Format(time.RFC3339)
 	}
 
 	response.All = response.API && response.DB
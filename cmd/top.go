@@ -0,0 +1,368 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/urfave/cli/v3"
+
+	"hyperwhisper/internal/handlers"
+)
+
+// topRecentEvents is how many session and alert events top lists
+const topRecentEvents = 10
+
+// topRequestWindow is the window request rates are computed over
+const topRequestWindow = time.Minute
+
+var TopCommand = &cli.Command{
+	Name:  "top",
+	Usage: "Show live sessions, request rates and database health from a running server",
+	Description: "Connects to the admin API of a running server, so it works over SSH " +
+		"without the web dashboard. Authenticate with an admin access token, or with " +
+		"an admin's username and password (the password is read from " +
+		"HYPERWHISPER_ADMIN_PASSWORD or, with --password-stdin, from standard input).",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "url",
+			Value:   "http://localhost:1323",
+			Usage:   "Base URL of the server",
+			Sources: cli.EnvVars("HYPERWHISPER_URL"),
+		},
+		&cli.StringFlag{
+			Name:    "token",
+			Usage:   "Admin access token",
+			Sources: cli.EnvVars("HYPERWHISPER_ADMIN_TOKEN"),
+		},
+		&cli.StringFlag{
+			Name:    "user",
+			Usage:   "Admin username or email to sign in as",
+			Sources: cli.EnvVars("HYPERWHISPER_ADMIN_USER"),
+		},
+		&cli.BoolFlag{
+			Name:  "password-stdin",
+			Usage: "Read the admin password from standard input",
+		},
+		&cli.DurationFlag{
+			Name:  "interval",
+			Value: 2 * time.Second,
+			Usage: "How often the screen is refreshed",
+		},
+	},
+	Action: runTop,
+}
+
+// topClient talks to the admin API, signing in again when a password-based
+// access token expires
+type topClient struct {
+	baseURL  string
+	http     *http.Client
+	user     string
+	password string
+
+	mu    sync.Mutex
+	token string
+}
+
+// topState is what the screen shows; the monitor connection updates it
+// between refreshes
+type topState struct {
+	mu            sync.Mutex
+	monitor       string // Monitor connection status
+	stats         *handlers.Event
+	events        []handlers.Event
+	sessionErrors int // Session error events seen
+}
+
+func runTop(ctx context.Context, cmd *cli.Command) error {
+	baseURL := strings.TrimRight(cmd.String("url"), "/")
+	if _, err := url.Parse(baseURL); err != nil {
+		return fmt.Errorf("invalid --url: %w", err)
+	}
+
+	client := &topClient{
+		baseURL: baseURL,
+		http:    &http.Client{Timeout: 10 * time.Second},
+		token:   cmd.String("token"),
+		user:    cmd.String("user"),
+	}
+	if client.token == "" {
+		if client.user == "" {
+			return errors.New("--token or --user is required")
+		}
+		client.password = os.Getenv("HYPERWHISPER_ADMIN_PASSWORD")
+		if cmd.Bool("password-stdin") {
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && err != io.EOF {
+				return fmt.Errorf("failed to read password: %w", err)
+			}
+			client.password = strings.TrimRight(line, "\r\n")
+		}
+		if client.password == "" {
+			return errors.New("set HYPERWHISPER_ADMIN_PASSWORD or use --password-stdin")
+		}
+		if err := client.signIn(ctx); err != nil {
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	state := &topState{monitor: "connecting"}
+	go client.followMonitor(ctx, state)
+
+	ticker := time.NewTicker(cmd.Duration("interval"))
+	defer ticker.Stop()
+
+	for {
+		client.draw(ctx, state)
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// signIn exchanges the admin's credentials for an access token
+func (t *topClient) signIn(ctx context.Context) error {
+	body, _ := json.Marshal(map[string]string{"identifier": t.user, "password": t.password})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.baseURL+"/api/v1/signin", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to sign in: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to sign in: %s", resp.Status)
+	}
+
+	var auth handlers.AuthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&auth); err != nil {
+		return fmt.Errorf("failed to sign in: %w", err)
+	}
+	if auth.User.UserType != "admin" {
+		return fmt.Errorf("%s is not an admin", t.user)
+	}
+
+	t.mu.Lock()
+	t.token = auth.AccessToken
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *topClient) bearer() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return "Bearer " + t.token
+}
+
+// get fetches an admin API path into v, signing in again once if the
+// access token has expired
+func (t *topClient) get(ctx context.Context, path string, v any) error {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.baseURL+path, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", t.bearer())
+
+		resp, err := t.http.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && t.password != "" && attempt == 0 {
+			resp.Body.Close()
+			if err := t.signIn(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s: %s", path, resp.Status)
+		}
+		return json.NewDecoder(resp.Body).Decode(v)
+	}
+}
+
+// health reads the public health check, which answers 503 with the same
+// body when the database is down
+func (t *topClient) health(ctx context.Context) (HealthCheckResponse, error) {
+	var health HealthCheckResponse
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.baseURL+"/api/v1/ht", nil)
+	if err != nil {
+		return health, err
+	}
+	resp, err := t.http.Do(req)
+	if err != nil {
+		return health, err
+	}
+	defer resp.Body.Close()
+	err = json.NewDecoder(resp.Body).Decode(&health)
+	return health, err
+}
+
+// followMonitor streams the admin monitor into state, reconnecting until ctx
+// ends
+func (t *topClient) followMonitor(ctx context.Context, state *topState) {
+	wsURL := "ws" + strings.TrimPrefix(t.baseURL, "http") + "/api/v1/admin/ws/monitor"
+
+	for ctx.Err() == nil {
+		header := http.Header{"Authorization": []string{t.bearer()}}
+		conn, resp, err := websocket.DefaultDialer.DialContext(ctx, wsURL, header)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusUnauthorized && t.password != "" {
+				t.signIn(ctx)
+			}
+			state.setMonitor("disconnected: " + err.Error())
+			select {
+			case <-ctx.Done():
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+		state.setMonitor("connected")
+
+		go func() {
+			<-ctx.Done()
+			conn.Close()
+		}()
+		for {
+			var event handlers.Event
+			if err := conn.ReadJSON(&event); err != nil {
+				state.setMonitor("disconnected: " + err.Error())
+				break
+			}
+			state.record(event)
+		}
+		conn.Close()
+	}
+}
+
+func (s *topState) setMonitor(status string) {
+	s.mu.Lock()
+	s.monitor = status
+	s.mu.Unlock()
+}
+
+func (s *topState) record(event handlers.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if event.Type == handlers.EventStats {
+		s.stats = &event
+		return
+	}
+	if event.Type == handlers.EventError {
+		s.sessionErrors++
+	}
+	s.events = append(s.events, event)
+	if len(s.events) > topRecentEvents {
+		s.events = s.events[len(s.events)-topRecentEvents:]
+	}
+}
+
+// draw redraws the whole screen
+func (t *topClient) draw(ctx context.Context, state *topState) {
+	var b strings.Builder
+	b.WriteString("\033[H\033[2J")
+	fmt.Fprintf(&b, "HyperWhisper top - %s - %s (Ctrl-C to quit)\n\n", t.baseURL, time.Now().Format("2006-01-02 15:04:05"))
+
+	started := time.Now()
+	if health, err := t.health(ctx); err != nil {
+		fmt.Fprintf(&b, "HEALTH    unreachable: %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "HEALTH    api %s   db %s   (%s)", okText(health.API), okText(health.DB), time.Since(started).Round(time.Millisecond))
+		if health.Status == "degraded" {
+			fmt.Fprintf(&b, "   degraded since %s", health.DBDownSince)
+		}
+		b.WriteString("\n")
+	}
+
+	state.mu.Lock()
+	monitor, stats, sessionErrors := state.monitor, state.stats, state.sessionErrors
+	events := append([]handlers.Event(nil), state.events...)
+	state.mu.Unlock()
+
+	if stats != nil && stats.Concurrency != nil && stats.BytesPerSecond != nil {
+		fmt.Fprintf(&b, "SESSIONS  live %d   audio %.1f kB/s   session errors %d\n", *stats.Concurrency, *stats.BytesPerSecond/1000, sessionErrors)
+	} else {
+		fmt.Fprintf(&b, "SESSIONS  monitor %s\n", monitor)
+	}
+
+	from := url.QueryEscape(time.Now().Add(-topRequestWindow).UTC().Format(time.RFC3339))
+	var all, failed handlers.PaginatedResponse
+	if err := t.get(ctx, "/api/v1/admin/access-logs?per_page=1&from="+from, &all); err != nil {
+		fmt.Fprintf(&b, "REQUESTS  %v\n", err)
+	} else if err := t.get(ctx, "/api/v1/admin/access-logs?per_page=1&status=5xx&from="+from, &failed); err != nil {
+		fmt.Fprintf(&b, "REQUESTS  %v\n", err)
+	} else {
+		perSecond := float64(all.Total) / topRequestWindow.Seconds()
+		fmt.Fprintf(&b, "REQUESTS  %.2f/s over the last %s   5xx %d\n", perSecond, topRequestWindow, failed.Total)
+	}
+
+	var budget handlers.BudgetStatus
+	if err := t.get(ctx, "/api/v1/admin/deepgram/budget", &budget); err != nil {
+		fmt.Fprintf(&b, "BUDGET    %v\n", err)
+	} else {
+		limit := "no budget"
+		if budget.Budget > 0 {
+			limit = fmt.Sprintf("of %.2f", budget.Budget)
+		}
+		fmt.Fprintf(&b, "BUDGET    %s spend %.2f %s   forecast %.2f\n", budget.Month, budget.EstimatedSpend, limit, budget.ForecastSpend)
+	}
+
+	b.WriteString("\nRECENT EVENTS\n")
+	if len(events) == 0 {
+		b.WriteString("  none yet\n")
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		b.WriteString("  " + describeEvent(events[i]) + "\n")
+	}
+
+	fmt.Print(b.String())
+}
+
+func okText(ok bool) string {
+	if ok {
+		return "ok"
+	}
+	return "DOWN"
+}
+
+func describeEvent(e handlers.Event) string {
+	at := e.Time.Local().Format("15:04:05")
+	switch e.Type {
+	case handlers.EventSessionStarted:
+		return fmt.Sprintf("%s  started  %s %s", at, e.Kind, e.Tag)
+	case handlers.EventSessionEnded:
+		return fmt.Sprintf("%s  ended    %s %s after %.0fs", at, e.Kind, e.Tag, e.DurationSeconds)
+	case handlers.EventError:
+		return fmt.Sprintf("%s  error    %s: %s", at, e.Source, e.Message)
+	case handlers.EventBudgetAlert:
+		return fmt.Sprintf("%s  budget   %d%% of the %s alert budget reached", at, e.Threshold, e.Month)
+	}
+	return fmt.Sprintf("%s  %s", at, e.Type)
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db"
+)
+
+var UsageCommand = &cli.Command{
+	Name:  "usage",
+	Usage: "Usage rollup maintenance",
+	Commands: []*cli.Command{
+		{
+			Name:  "recompute",
+			Usage: "Rebuild the monthly usage rollups of a range of months from the usage logs",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "from",
+					Usage:    "First month to recompute (YYYY-MM, UTC)",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:  "to",
+					Usage: "Last month to recompute (YYYY-MM, UTC); defaults to the current month",
+				},
+				&cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "List the months that would be recomputed without writing anything",
+				},
+			},
+			Action: usageRecompute,
+		},
+	},
+}
+
+func usageRecompute(ctx context.Context, cmd *cli.Command) error {
+	from, err := time.Parse("2006-01", cmd.String("from"))
+	if err != nil {
+		return fmt.Errorf("invalid --from %q: want YYYY-MM", cmd.String("from"))
+	}
+	now := time.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if cmd.String("to") != "" {
+		if to, err = time.Parse("2006-01", cmd.String("to")); err != nil {
+			return fmt.Errorf("invalid --to %q: want YYYY-MM", cmd.String("to"))
+		}
+	}
+	if to.Before(from) {
+		return errors.New("--to is before --from")
+	}
+
+	if err := config.Load(); err != nil {
+		return err
+	}
+	if err := db.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	dryRun := cmd.Bool("dry-run")
+	recomputed := 0
+	for _, table := range db.UsageLogTables {
+		partitioned, err := db.PartitionedMonths(ctx, table)
+		if err != nil {
+			return fmt.Errorf("failed to list %s partitions: %w", table, err)
+		}
+		hasPartition := make(map[time.Time]bool, len(partitioned))
+		for _, month := range partitioned {
+			hasPartition[month] = true
+		}
+
+		for month := from; !month.After(to); month = month.AddDate(0, 1, 0) {
+			label := fmt.Sprintf("%s %s", table, month.Format("2006-01"))
+
+			// An archived month's logs are gone; recomputing it would zero
+			// the totals that outlived them
+			if !hasPartition[month] {
+				fmt.Printf("Skipping %s: no partition (archived or never created), rollup kept.\n", label)
+				continue
+			}
+			if dryRun {
+				fmt.Printf("Would recompute %s.\n", label)
+				continue
+			}
+
+			// Each month is replaced in a single upsert, so an interrupted run
+			// can simply be repeated
+			if err := db.RollupUsageMonth(ctx, table, month); err != nil {
+				return fmt.Errorf("%s: failed to roll up usage: %w", label, err)
+			}
+			fmt.Printf("Recomputed %s.\n", label)
+			recomputed++
+		}
+	}
+
+	if !dryRun {
+		fmt.Printf("Recomputed %d month(s).\n", recomputed)
+	}
+	return nil
+}
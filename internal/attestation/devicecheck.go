@@ -0,0 +1,119 @@
+package attestation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// TypeAppleDeviceCheck is the attestation type of Apple DeviceCheck tokens,
+// which macOS apps generate with DCDevice
+const TypeAppleDeviceCheck = "apple-devicecheck"
+
+// requestTimeout bounds a call to Apple's DeviceCheck API
+const requestTimeout = 10 * time.Second
+
+var (
+	// ErrRejected means the token is not from a genuine install of our app
+	ErrRejected = errors.New("attestation rejected")
+	// ErrUnsupported means the attestation type is not known
+	ErrUnsupported = errors.New("unsupported attestation type")
+)
+
+var httpClient = &http.Client{Timeout: requestTimeout}
+
+// Verify checks an attestation token of the given type. It returns
+// ErrRejected or ErrUnsupported for bad attestations; any other error means
+// the attestation could not be checked.
+func Verify(ctx context.Context, attestationType, token string) error {
+	switch attestationType {
+	case TypeAppleDeviceCheck:
+		return verifyDeviceCheck(ctx, token)
+	default:
+		return ErrUnsupported
+	}
+}
+
+// verifyDeviceCheck asks Apple whether a DeviceCheck token was issued to a
+// genuine Apple device running an app signed by our team
+func verifyDeviceCheck(ctx context.Context, token string) error {
+	if token == "" {
+		return ErrRejected
+	}
+
+	authToken, err := deviceCheckAuthToken(time.Now())
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"device_token":   token,
+		"transaction_id": uuid.New().String(),
+		"timestamp":      time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return err
+	}
+
+	host := "api.devicecheck.apple.com"
+	if config.String("ATTESTATION_APPLE_ENVIRONMENT") == "development" {
+		host = "api.development.devicecheck.apple.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://"+host+"/v1/validate_device_token", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authToken)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("devicecheck request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusBadRequest:
+		// Apple answers 400 for malformed, forged or foreign tokens
+		return ErrRejected
+	default:
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("devicecheck returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+}
+
+// deviceCheckAuthToken signs the ES256 JWT that authenticates us to Apple
+// with the DeviceCheck key of our developer team
+func deviceCheckAuthToken(now time.Time) (string, error) {
+	teamID := config.String("ATTESTATION_APPLE_TEAM_ID")
+	keyID := config.String("ATTESTATION_APPLE_KEY_ID")
+	keyPEM := config.String("ATTESTATION_APPLE_PRIVATE_KEY")
+	if teamID == "" || keyID == "" || keyPEM == "" {
+		return "", errors.New("ATTESTATION_APPLE_TEAM_ID, ATTESTATION_APPLE_KEY_ID and ATTESTATION_APPLE_PRIVATE_KEY are required")
+	}
+
+	key, err := jwt.ParseECPrivateKeyFromPEM([]byte(keyPEM))
+	if err != nil {
+		return "", fmt.Errorf("invalid ATTESTATION_APPLE_PRIVATE_KEY: %w", err)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.RegisteredClaims{
+		Issuer:   teamID,
+		IssuedAt: jwt.NewNumericDate(now),
+	})
+	token.Header["kid"] = keyID
+	return token.SignedString(key)
+}
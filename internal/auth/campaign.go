@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GenerateCampaignCode signs a code that provisions trial keys with the named
+// preset until expiresAt. Codes have the form <preset>.<expiry>.<signature>
+// so they stay short enough to share in a link.
+func GenerateCampaignCode(preset string, expiresAt time.Time) string {
+	payload := preset + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	return payload + "." + campaignSignature(getJWTSecret(), payload)
+}
+
+// ValidateCampaignCode checks a campaign code and returns the preset it
+// selects. Codes signed with a recently rotated JWT_SECRET are accepted.
+func ValidateCampaignCode(code string) (string, error) {
+	parts := strings.Split(code, ".")
+	if len(parts) != 3 {
+		return "", ErrInvalidToken
+	}
+	payload := parts[0] + "." + parts[1]
+
+	valid := false
+	for _, secret := range validationSecrets() {
+		if hmac.Equal([]byte(parts[2]), []byte(campaignSignature(secret, payload))) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return "", ErrInvalidToken
+	}
+
+	expiry, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	if time.Now().Unix() > expiry {
+		return "", ErrExpiredToken
+	}
+	return parts[0], nil
+}
+
+func campaignSignature(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("campaign:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
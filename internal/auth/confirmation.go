@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// AdminConfirmation is the token type of destructive admin operation
+// confirmations
+const AdminConfirmation TokenType = "admin_confirmation"
+
+// ConfirmationClaims binds a dry run's confirmation to the admin who ran it
+// and the operation it previewed
+type ConfirmationClaims struct {
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+	TokenType TokenType `json:"token_type"`
+	jwt.RegisteredClaims
+}
+
+// GenerateConfirmationToken signs the token an admin must present to carry
+// out an operation previewed by a dry run
+func GenerateConfirmationToken(adminID uuid.UUID, action, target string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &ConfirmationClaims{
+		Action:    action,
+		Target:    target,
+		TokenType: AdminConfirmation,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Subject:   adminID.String(),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(getJWTSecret())
+}
+
+// ValidateConfirmationToken checks that a confirmation token was issued to
+// the admin for this action on this target
+func ValidateConfirmationToken(tokenString string, adminID uuid.UUID, action, target string) error {
+	var token *jwt.Token
+	var err error
+	for _, secret := range validationSecrets() {
+		token, err = jwt.ParseWithClaims(tokenString, &ConfirmationClaims{}, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, ErrInvalidToken
+			}
+			return secret, nil
+		})
+		if !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			break
+		}
+	}
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return ErrExpiredToken
+		}
+		return ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*ConfirmationClaims)
+	if !ok || !token.Valid {
+		return ErrInvalidToken
+	}
+	if claims.TokenType != AdminConfirmation {
+		return ErrInvalidTokenType
+	}
+	if claims.Subject != adminID.String() || claims.Action != action || claims.Target != target {
+		return ErrInvalidToken
+	}
+	return nil
+}
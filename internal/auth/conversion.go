@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// TrialUpgrade is the token type of trial upgrade deep links
+const TrialUpgrade TokenType = "trial_upgrade"
+
+// UpgradeClaims identifies the trial key an account signs up from, so the
+// signup can credit the trial's carryover and convert it
+type UpgradeClaims struct {
+	TrialKeyID uuid.UUID `json:"trial_key_id"`
+	TokenType  TokenType `json:"token_type"`
+	jwt.RegisteredClaims
+}
+
+// GenerateTrialUpgradeToken signs the carryover token of a trial key's
+// upgrade link
+func GenerateTrialUpgradeToken(trialKeyID uuid.UUID, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &UpgradeClaims{
+		TrialKeyID: trialKeyID,
+		TokenType:  TrialUpgrade,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Subject:   trialKeyID.String(),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(getJWTSecret())
+}
+
+// ValidateTrialUpgradeToken checks an upgrade link's token and returns the
+// trial key it was issued for
+func ValidateTrialUpgradeToken(tokenString string) (uuid.UUID, error) {
+	var token *jwt.Token
+	var err error
+	for _, secret := range validationSecrets() {
+		token, err = jwt.ParseWithClaims(tokenString, &UpgradeClaims{}, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, ErrInvalidToken
+			}
+			return secret, nil
+		})
+		if !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			break
+		}
+	}
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return uuid.Nil, ErrExpiredToken
+		}
+		return uuid.Nil, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*UpgradeClaims)
+	if !ok || !token.Valid {
+		return uuid.Nil, ErrInvalidToken
+	}
+	if claims.TokenType != TrialUpgrade {
+		return uuid.Nil, ErrInvalidTokenType
+	}
+	return claims.TrialKeyID, nil
+}
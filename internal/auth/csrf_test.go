@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hyperwhisper/internal/config"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestCSRFValid(t *testing.T) {
+	t.Setenv("JWT_SECRET", "csrf-test-secret")
+	// Settings required in production may be missing; they aren't read here
+	_ = config.Load()
+	t.Cleanup(func() { _ = config.Load() })
+
+	token, err := GenerateCSRFToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateCSRFToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Well-formed, but signed with a secret the server doesn't hold, as a
+	// cookie planted from a sibling subdomain would be
+	forged := "00ff" + "." + signCSRFNonce("00ff", []byte("attacker-secret"))
+
+	tests := []struct {
+		name       string
+		method     string
+		bearer     bool
+		authCookie string
+		csrfCookie string
+		csrfHeader string
+		want       bool
+	}{
+		{name: "safe method", method: http.MethodGet, authCookie: "refresh_token", want: true},
+		{name: "bearer token", method: http.MethodPost, bearer: true, authCookie: "access_token", want: true},
+		{name: "no auth cookie", method: http.MethodPost, want: true},
+		{name: "matching token", method: http.MethodPost, authCookie: "refresh_token", csrfCookie: token, csrfHeader: token, want: true},
+		{name: "matching token with access cookie", method: http.MethodDelete, authCookie: "access_token", csrfCookie: token, csrfHeader: token, want: true},
+		{name: "missing header", method: http.MethodPost, authCookie: "refresh_token", csrfCookie: token, want: false},
+		{name: "missing cookie", method: http.MethodPost, authCookie: "refresh_token", csrfHeader: token, want: false},
+		{name: "mismatched tokens", method: http.MethodPost, authCookie: "refresh_token", csrfCookie: token, csrfHeader: other, want: false},
+		{name: "forged signature", method: http.MethodPut, authCookie: "access_token", csrfCookie: forged, csrfHeader: forged, want: false},
+		{name: "unsigned token", method: http.MethodPost, authCookie: "refresh_token", csrfCookie: "nonce", csrfHeader: "nonce", want: false},
+		{name: "empty nonce", method: http.MethodPost, authCookie: "refresh_token", csrfCookie: "." + signCSRFNonce("", getJWTSecret()), csrfHeader: "." + signCSRFNonce("", getJWTSecret()), want: false},
+	}
+
+	e := echo.New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			if tt.bearer {
+				r.Header.Set("Authorization", "Bearer token")
+			}
+			if tt.authCookie != "" {
+				r.AddCookie(&http.Cookie{Name: tt.authCookie, Value: "session"})
+			}
+			if tt.csrfCookie != "" {
+				r.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: tt.csrfCookie})
+			}
+			if tt.csrfHeader != "" {
+				r.Header.Set(CSRFHeaderName, tt.csrfHeader)
+			}
+			c := e.NewContext(r, httptest.NewRecorder())
+			if got := csrfValid(c); got != tt.want {
+				t.Errorf("csrfValid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSRFMiddlewareRefuses(t *testing.T) {
+	t.Setenv("JWT_SECRET", "csrf-test-secret")
+	_ = config.Load()
+	t.Cleanup(func() { _ = config.Load() })
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", nil)
+	r.AddCookie(&http.Cookie{Name: "refresh_token", Value: "session"})
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(r, rec)
+
+	called := false
+	handler := CSRFMiddleware()(func(echo.Context) error {
+		called = true
+		return nil
+	})
+	if err := handler(c); err != nil {
+		t.Fatal(err)
+	}
+	if called {
+		t.Error("handler was called without a CSRF token")
+	}
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Denylist holds access tokens revoked before they expire. Access tokens
+// are checked by signature alone, so without it a signed-out or revoked
+// session would keep working until its access token expired. Entries are
+// only kept while a token they match can still be valid.
+type Denylist struct {
+	mu sync.RWMutex
+	// tokens maps access token and session IDs to when their entry lapses
+	tokens map[string]time.Time
+	users  map[uuid.UUID]userDenial
+}
+
+// userDenial denies every access token of a user issued before a cutoff
+type userDenial struct {
+	issuedBefore time.Time
+	until        time.Time
+}
+
+// AccessDenylist is checked for every request with an access token
+var AccessDenylist = NewDenylist()
+
+// NewDenylist creates an empty denylist
+func NewDenylist() *Denylist {
+	return &Denylist{
+		tokens: make(map[string]time.Time),
+		users:  make(map[uuid.UUID]userDenial),
+	}
+}
+
+// DenyToken denies the access token with the given JTI, or every access
+// token issued with the refresh token with that JTI, until the given time
+func (d *Denylist) DenyToken(jti string, until time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if until.After(d.tokens[jti]) {
+		d.tokens[jti] = until
+	}
+}
+
+// DenyUser denies every access token of a user issued before issuedBefore.
+// Token iat only has second precision, so tokens issued within the same
+// second as the cutoff, such as one issued along with it, stay valid.
+func (d *Denylist) DenyUser(userID uuid.UUID, issuedBefore, until time.Time) {
+	issuedBefore = issuedBefore.Truncate(time.Second)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	denial := d.users[userID]
+	if issuedBefore.After(denial.issuedBefore) {
+		denial.issuedBefore = issuedBefore
+	}
+	if until.After(denial.until) {
+		denial.until = until
+	}
+	d.users[userID] = denial
+}
+
+// Denied reports whether an access token has been denied
+func (d *Denylist) Denied(claims *Claims) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if _, ok := d.tokens[claims.ID]; ok {
+		return true
+	}
+	if _, ok := d.tokens[claims.SessionID]; ok && claims.SessionID != "" {
+		return true
+	}
+	denial, ok := d.users[claims.UserID]
+	return ok && (claims.IssuedAt == nil || claims.IssuedAt.Before(denial.issuedBefore))
+}
+
+// Prune drops entries that lapsed before now
+func (d *Denylist) Prune(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for jti, until := range d.tokens {
+		if until.Before(now) {
+			delete(d.tokens, jti)
+		}
+	}
+	for userID, denial := range d.users {
+		if denial.until.Before(now) {
+			delete(d.users, userID)
+		}
+	}
+}
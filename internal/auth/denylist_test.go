@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func TestDenylistDenied(t *testing.T) {
+	now := time.Now()
+	denied := uuid.New()
+	other := uuid.New()
+
+	d := NewDenylist()
+	d.DenyToken("access-jti", now.Add(time.Hour))
+	d.DenyToken("session-jti", now.Add(time.Hour))
+	d.DenyUser(denied, now, now.Add(time.Hour))
+
+	issued := func(at time.Time) *jwt.NumericDate { return jwt.NewNumericDate(at) }
+
+	tests := []struct {
+		name   string
+		claims Claims
+		want   bool
+	}{
+		{
+			name:   "denied token",
+			claims: Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "access-jti", IssuedAt: issued(now)}, UserID: other},
+			want:   true,
+		},
+		{
+			name:   "token of a denied session",
+			claims: Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "another-jti", IssuedAt: issued(now)}, SessionID: "session-jti", UserID: other},
+			want:   true,
+		},
+		{
+			name:   "unrelated token",
+			claims: Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "another-jti", IssuedAt: issued(now)}, SessionID: "another-session", UserID: other},
+			want:   false,
+		},
+		{
+			name:   "token without a session",
+			claims: Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "another-jti", IssuedAt: issued(now)}, UserID: other},
+			want:   false,
+		},
+		{
+			name:   "user's token issued before the cutoff",
+			claims: Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "old-jti", IssuedAt: issued(now.Add(-time.Minute))}, UserID: denied},
+			want:   true,
+		},
+		{
+			name:   "user's token issued in the cutoff's second",
+			claims: Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "same-second-jti", IssuedAt: issued(now)}, UserID: denied},
+			want:   false,
+		},
+		{
+			name:   "user's token issued after the cutoff",
+			claims: Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "new-jti", IssuedAt: issued(now.Add(time.Minute))}, UserID: denied},
+			want:   false,
+		},
+		{
+			name:   "user's token without iat",
+			claims: Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "no-iat-jti"}, UserID: denied},
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := d.Denied(&tt.claims); got != tt.want {
+				t.Errorf("Denied() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDenylistPrune(t *testing.T) {
+	now := time.Now()
+	lapsedUser := uuid.New()
+	liveUser := uuid.New()
+	old := jwt.NewNumericDate(now.Add(-time.Hour))
+
+	d := NewDenylist()
+	d.DenyToken("lapsed", now.Add(-time.Second))
+	d.DenyToken("live", now.Add(time.Hour))
+	// A later denial of the same token extends it, an earlier one doesn't
+	// shorten it
+	d.DenyToken("extended", now.Add(-time.Second))
+	d.DenyToken("extended", now.Add(time.Hour))
+	d.DenyToken("kept", now.Add(time.Hour))
+	d.DenyToken("kept", now.Add(-time.Second))
+	d.DenyUser(lapsedUser, now, now.Add(-time.Second))
+	d.DenyUser(liveUser, now, now.Add(time.Hour))
+
+	d.Prune(now)
+
+	tests := []struct {
+		name   string
+		claims Claims
+		want   bool
+	}{
+		{name: "lapsed token", claims: Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "lapsed", IssuedAt: old}}, want: false},
+		{name: "live token", claims: Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "live", IssuedAt: old}}, want: true},
+		{name: "extended token", claims: Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "extended", IssuedAt: old}}, want: true},
+		{name: "token not shortened", claims: Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "kept", IssuedAt: old}}, want: true},
+		{name: "lapsed user", claims: Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "a", IssuedAt: old}, UserID: lapsedUser}, want: false},
+		{name: "live user", claims: Claims{RegisteredClaims: jwt.RegisteredClaims{ID: "b", IssuedAt: old}, UserID: liveUser}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := d.Denied(&tt.claims); got != tt.want {
+				t.Errorf("Denied() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
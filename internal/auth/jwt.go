@@ -1,11 +1,14 @@
 package auth
 
 import (
+	"encoding/json"
 	"errors"
-	"os"
-	"strconv"
+	"log"
+	"strings"
 	"time"
 
+	"hyperwhisper/internal/config"
+
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
@@ -30,6 +33,16 @@ type Claims struct {
 	Email     string    `json:"email"`
 	UserType  string    `json:"user_type"`
 	TokenType TokenType `json:"token_type"`
+	// SessionID is the JTI of the refresh token an access token was issued
+	// with, so revoking that session denies the access token too
+	SessionID string `json:"sid,omitempty"`
+	// TenantID is the tenant of the user; zero for the operator's users
+	TenantID uuid.UUID `json:"tenant_id,omitzero"`
+	// ClientID is the third-party application a token was issued to, and
+	// Scope the space-separated scopes the user granted it; both are empty
+	// for the user's own tokens
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -40,46 +53,92 @@ type TokenPair struct {
 	ExpiresIn    int64  `json:"expires_in"` // Access token expiry in seconds
 }
 
-// getJWTSecret returns the JWT secret from environment
+// getJWTSecret returns the JWT signing secret
 func getJWTSecret() []byte {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "hyperwhisper-dev-secret-change-in-production"
-	}
-	return []byte(secret)
+	return []byte(config.String("JWT_SECRET"))
 }
 
 // getAccessTokenExpiry returns access token expiry duration
 func getAccessTokenExpiry() time.Duration {
-	expiryStr := os.Getenv("ACCESS_TOKEN_EXPIRY")
-	if expiryStr == "" {
-		return 5 * time.Minute // Default 5 minutes
-	}
-	expiry, err := strconv.Atoi(expiryStr)
-	if err != nil {
-		return 5 * time.Minute
-	}
-	return time.Duration(expiry) * time.Minute
+	return time.Duration(config.Int("ACCESS_TOKEN_EXPIRY")) * time.Minute
 }
 
 // getRefreshTokenExpiry returns refresh token expiry duration
 func getRefreshTokenExpiry() time.Duration {
-	expiryStr := os.Getenv("REFRESH_TOKEN_EXPIRY")
-	if expiryStr == "" {
-		return 7 * 24 * time.Hour // Default 7 days
+	return time.Duration(config.Int("REFRESH_TOKEN_EXPIRY")) * 24 * time.Hour
+}
+
+// getJWTIssuer returns the iss claim of issued tokens; empty omits it
+func getJWTIssuer() string {
+	return config.String("JWT_ISSUER")
+}
+
+// getJWTAudience returns the aud claim of issued tokens. The first entry
+// names this server and is the audience required at validation; the rest
+// are sibling services that also accept the tokens.
+func getJWTAudience() jwt.ClaimStrings {
+	var audience jwt.ClaimStrings
+	for _, aud := range strings.Split(config.String("JWT_AUDIENCE"), ",") {
+		if aud = strings.TrimSpace(aud); aud != "" {
+			audience = append(audience, aud)
+		}
+	}
+	return audience
+}
+
+// getJWTCustomClaims returns the extra claims added to access tokens
+func getJWTCustomClaims() map[string]interface{} {
+	raw := config.String("JWT_CUSTOM_CLAIMS")
+	if raw == "" {
+		return nil
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &claims); err != nil {
+		log.Printf("[Auth] Ignoring invalid JWT_CUSTOM_CLAIMS: %v", err)
+		return nil
+	}
+	return claims
+}
+
+// signToken signs claims with the current secret. Custom claims are added
+// alongside them but never replace a built-in claim.
+func signToken(claims *Claims, custom map[string]interface{}) (string, error) {
+	secret := getJWTSecret()
+	if len(custom) == 0 {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
 	}
-	expiry, err := strconv.Atoi(expiryStr)
+
+	encoded, err := json.Marshal(claims)
 	if err != nil {
-		return 7 * 24 * time.Hour
+		return "", err
 	}
-	return time.Duration(expiry) * 24 * time.Hour
+	merged := jwt.MapClaims{}
+	if err := json.Unmarshal(encoded, &merged); err != nil {
+		return "", err
+	}
+	for name, value := range custom {
+		if _, builtIn := merged[name]; !builtIn && !registeredClaimNames[name] {
+			merged[name] = value
+		}
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, merged).SignedString(secret)
 }
 
-// GenerateTokenPair generates both access and refresh tokens
-func GenerateTokenPair(userID uuid.UUID, username, email, userType string) (*TokenPair, error) {
+// registeredClaimNames are the standard claims, and built-in claims that
+// can be left out, custom claims may not set even when they are omitted
+// from a token
+var registeredClaimNames = map[string]bool{
+	"iss": true, "sub": true, "aud": true, "exp": true, "nbf": true, "iat": true, "jti": true,
+	"sid": true, "tenant_id": true, "client_id": true, "scope": true,
+}
+
+// GenerateTokenPair generates both access and refresh tokens. tenantID is
+// uuid.Nil for the operator's users.
+func GenerateTokenPair(userID uuid.UUID, username, email, userType string, tenantID uuid.UUID) (*TokenPair, error) {
 	accessExpiry := getAccessTokenExpiry()
 	refreshExpiry := getRefreshTokenExpiry()
-	secret := getJWTSecret()
+	issuer := getJWTIssuer()
+	audience := getJWTAudience()
 	now := time.Now()
 
 	// Generate unique JTI for each token
@@ -93,16 +152,20 @@ func GenerateTokenPair(userID uuid.UUID, username, email, userType string) (*Tok
 		Email:     email,
 		UserType:  userType,
 		TokenType: AccessToken,
+		SessionID: refreshJTI,
+		TenantID:  tenantID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        accessJTI,
+			Issuer:    issuer,
+			Audience:  audience,
 			ExpiresAt: jwt.NewNumericDate(now.Add(accessExpiry)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			Subject:   userID.String(),
 		},
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessTokenString, err := accessToken.SignedString(secret)
+	// Custom claims are only for services consuming access tokens
+	accessTokenString, err := signToken(accessClaims, getJWTCustomClaims())
 	if err != nil {
 		return nil, err
 	}
@@ -114,16 +177,18 @@ func GenerateTokenPair(userID uuid.UUID, username, email, userType string) (*Tok
 		Email:     email,
 		UserType:  userType,
 		TokenType: RefreshToken,
+		TenantID:  tenantID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        refreshJTI,
+			Issuer:    issuer,
+			Audience:  audience,
 			ExpiresAt: jwt.NewNumericDate(now.Add(refreshExpiry)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			Subject:   userID.String(),
 		},
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshTokenString, err := refreshToken.SignedString(secret)
+	refreshTokenString, err := signToken(refreshClaims, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -135,14 +200,36 @@ func GenerateTokenPair(userID uuid.UUID, username, email, userType string) (*Tok
 	}, nil
 }
 
-// ValidateToken validates a token and returns the claims
+// validationOptions requires the configured issuer and this server's
+// audience, if set
+func validationOptions() []jwt.ParserOption {
+	var opts []jwt.ParserOption
+	if issuer := getJWTIssuer(); issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience := getJWTAudience(); len(audience) > 0 {
+		opts = append(opts, jwt.WithAudience(audience[0]))
+	}
+	return opts
+}
+
+// ValidateToken validates a token and returns the claims. Tokens signed with
+// a recently rotated JWT_SECRET are still accepted until they expire.
 func ValidateToken(tokenString string, expectedType TokenType) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
+	var token *jwt.Token
+	var err error
+	opts := validationOptions()
+	for _, secret := range validationSecrets() {
+		token, err = jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, ErrInvalidToken
+			}
+			return secret, nil
+		}, opts...)
+		if !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			break
 		}
-		return getJWTSecret(), nil
-	})
+	}
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
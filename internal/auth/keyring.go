@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"hyperwhisper/internal/config"
+)
+
+// retiredSecret is a previous JWT_SECRET that is still accepted for
+// validation until every token it signed has expired
+type retiredSecret struct {
+	secret    []byte
+	expiresAt time.Time
+}
+
+var (
+	retiredMu      sync.Mutex
+	retiredSecrets []retiredSecret
+)
+
+func init() {
+	config.OnChange(func(name, previous string) {
+		if name != "JWT_SECRET" || previous == "" {
+			return
+		}
+		retireSecret([]byte(previous))
+	})
+}
+
+// retireSecret keeps a rotated-out signing secret around for the lifetime of
+// the longest-lived token it could have signed, so rotating JWT_SECRET does
+// not sign everyone out
+func retireSecret(secret []byte) {
+	retiredMu.Lock()
+	defer retiredMu.Unlock()
+
+	retiredSecrets = append(retiredSecrets, retiredSecret{
+		secret:    secret,
+		expiresAt: time.Now().Add(getRefreshTokenExpiry()),
+	})
+	log.Printf("[Auth] JWT_SECRET rotated; previous secret accepted until %s", retiredSecrets[len(retiredSecrets)-1].expiresAt.Format(time.RFC3339))
+}
+
+// validationSecrets returns the current secret followed by retired secrets
+// that have not expired yet
+func validationSecrets() [][]byte {
+	retiredMu.Lock()
+	defer retiredMu.Unlock()
+
+	now := time.Now()
+	secrets := [][]byte{getJWTSecret()}
+	active := retiredSecrets[:0]
+	for _, r := range retiredSecrets {
+		if now.Before(r.expiresAt) {
+			active = append(active, r)
+			secrets = append(secrets, r.secret)
+		}
+	}
+	retiredSecrets = active
+	return secrets
+}
@@ -1,7 +1,6 @@
 package auth
 
 import (
-	"net/http"
 	"strings"
 
 	"github.com/labstack/echo/v4"
@@ -9,49 +8,79 @@ import (
 
 const UserContextKey = "user"
 
-// JWTMiddleware creates an Echo middleware for JWT authentication
-func JWTMiddleware() echo.MiddlewareFunc {
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			var tokenString string
+// authenticate validates the request's access token, from the
+// Authorization header or the access_token cookie, and stores its claims.
+// It returns why the request was refused, if it was.
+func authenticate(c echo.Context) (string, bool) {
+	tokenString := requestAccessToken(c)
+	if tokenString == "" {
+		return "missing authentication token", false
+	}
+	if strings.HasPrefix(tokenString, apiKeyPrefix) {
+		return "API keys are not accepted by this endpoint", false
+	}
 
-			// Try to get token from Authorization header first
-			authHeader := c.Request().Header.Get("Authorization")
-			if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
-				tokenString = strings.TrimPrefix(authHeader, "Bearer ")
-			}
+	// Validate the token
+	claims, err := ValidateToken(tokenString, AccessToken)
+	if err != nil {
+		return err.Error(), false
+	}
+	if AccessDenylist.Denied(claims) {
+		return "token has been revoked", false
+	}
 
-			// Fall back to cookie if no header
-			if tokenString == "" {
-				cookie, err := c.Cookie("access_token")
-				if err == nil {
-					tokenString = cookie.Value
-				}
-			}
+	// Store claims in context
+	c.Set(UserContextKey, claims)
+	return "", true
+}
 
-			if tokenString == "" {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "missing authentication token",
-				})
-			}
+// requestAccessToken returns the access token a request carries, if any
+func requestAccessToken(c echo.Context) string {
+	// Try to get token from Authorization header first
+	authHeader := c.Request().Header.Get("Authorization")
+	if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
+		return strings.TrimPrefix(authHeader, "Bearer ")
+	}
 
-			// Validate the token
-			claims, err := ValidateToken(tokenString, AccessToken)
-			if err != nil {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": err.Error(),
-				})
-			}
+	// Fall back to cookie if no header
+	if cookie, err := c.Cookie("access_token"); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
 
-			// Store claims in context
-			c.Set(UserContextKey, claims)
+// apiKeyPrefix starts every API and trial key
+const apiKeyPrefix = "hw_"
 
-			return next(c)
-		}
+// RequestAPIKey returns the API key a request carries in the X-API-Key
+// header or as its bearer token, if any. Only routes with AcceptsAPIKeys
+// access take it; the transcription proxies read their keys themselves.
+func RequestAPIKey(c echo.Context) string {
+	if key := c.Request().Header.Get("X-API-Key"); key != "" {
+		return key
 	}
+	if token := requestAccessToken(c); strings.HasPrefix(token, apiKeyPrefix) {
+		return token
+	}
+	return ""
 }
 
-// GetUserFromContext retrieves user claims from Echo context
+// AccessTokenClaims returns the claims of a valid access token the request
+// carries, for public routes that act on it when present, like sign-out
+func AccessTokenClaims(c echo.Context) *Claims {
+	tokenString := requestAccessToken(c)
+	if tokenString == "" {
+		return nil
+	}
+	claims, err := ValidateToken(tokenString, AccessToken)
+	if err != nil {
+		return nil
+	}
+	return claims
+}
+
+// GetUserFromContext retrieves user claims from Echo context. Routes with
+// Authenticated, Admin or Requires access always have them.
 func GetUserFromContext(c echo.Context) *Claims {
 	claims, ok := c.Get(UserContextKey).(*Claims)
 	if !ok {
@@ -60,25 +89,8 @@ func GetUserFromContext(c echo.Context) *Claims {
 	return claims
 }
 
-// AdminMiddleware creates an Echo middleware that ensures user is admin
-// Must be used after JWTMiddleware
-func AdminMiddleware() echo.MiddlewareFunc {
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			claims := GetUserFromContext(c)
-			if claims == nil {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "not authenticated",
-				})
-			}
-
-			if claims.UserType != "admin" {
-				return c.JSON(http.StatusForbidden, map[string]string{
-					"error": "admin access required",
-				})
-			}
-
-			return next(c)
-		}
-	}
+// refuse answers with an error body; the server's serializer turns it into
+// a problem
+func refuse(c echo.Context, status int, msg string) error {
+	return c.JSON(status, map[string]string{"error": msg})
 }
@@ -2,9 +2,10 @@ package auth
 
 import (
 	"errors"
-	"os"
 	"regexp"
 
+	"hyperwhisper/internal/config"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -35,7 +36,7 @@ func CheckPassword(password, hash string) error {
 // In prod mode, strong validation is enforced
 func ValidatePassword(password string) error {
 	// Skip validation in dev mode
-	if os.Getenv("APP_ENV") == "dev" {
+	if config.IsDev() {
 		return nil
 	}
 
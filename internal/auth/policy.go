@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Access is who may call a route
+type Access struct {
+	level      accessLevel
+	permission Permission // Staff routes: what the caller's role must grant
+	// Staff routes: whether tenant staff may call it, their handler
+	// limiting them to their tenant's data
+	tenantScoped bool
+	// Authenticated routes: whether API keys are accepted too
+	apiKeys bool
+	// Authenticated routes: the scope third-party application tokens need
+	scope Scope
+}
+
+type accessLevel int
+
+const (
+	levelPublic accessLevel = iota
+	levelAuthenticated
+	levelStaff
+	levelScopedToken
+)
+
+var (
+	// Public routes need no credentials
+	Public = Access{level: levelPublic}
+	// Authenticated routes need a valid access token, and pass the CSRF
+	// check when it comes from a cookie
+	Authenticated = Access{level: levelAuthenticated}
+	// Admin routes are Authenticated routes for admins only
+	Admin = Access{level: levelStaff}
+	// ScopedToken routes are authenticated by their handler with a
+	// narrower credential: an API key, trial key or trial grant
+	ScopedToken = Access{level: levelScopedToken}
+)
+
+// Requires makes an Authenticated route for admins and the staff roles
+// granted permission
+func Requires(permission Permission) Access {
+	return Access{level: levelStaff, permission: permission}
+}
+
+// TenantScoped lets the staff of tenants call a staff route as well, which
+// otherwise only the operator's staff may. Its handler must limit them to
+// their tenant's data.
+func TenantScoped(a Access) Access {
+	a.tenantScoped = true
+	return a
+}
+
+// AcceptsAPIKeys lets an Authenticated route be called with an API key as
+// well, for programmatic clients. Requests carrying one skip the access
+// token and CSRF checks; the route's key middleware must authenticate them
+// and store the key user's claims.
+func AcceptsAPIKeys(a Access) Access {
+	a.apiKeys = true
+	return a
+}
+
+func (a Access) String() string {
+	switch a.level {
+	case levelPublic:
+		return "public"
+	case levelAuthenticated:
+		name := "authenticated"
+		if a.apiKeys {
+			name += " or API key"
+		}
+		if a.scope != "" {
+			name += " (scope " + string(a.scope) + ")"
+		}
+		return name
+	case levelStaff:
+		name := "admin"
+		if a.permission != "" {
+			name = "requires " + string(a.permission)
+		}
+		if a.tenantScoped {
+			name += " (tenant-scoped)"
+		}
+		return name
+	case levelScopedToken:
+		return "scoped-token"
+	}
+	return fmt.Sprintf("Access(%d)", int(a.level))
+}
+
+// allows reports whether a caller with role may call a staff route
+func (a Access) allows(role string) bool {
+	if a.permission == "" {
+		return role == RoleAdmin
+	}
+	return HasPermission(role, a.permission)
+}
+
+// Policies is the authorization table of a route group: the access of each
+// route, keyed by method and path as registered relative to the group,
+// e.g. "GET /me"
+type Policies map[string]Access
+
+// Middleware enforces the table for the group at prefix. Handlers of
+// Authenticated, Admin and Requires routes can rely on GetUserFromContext. Requests
+// matching no entry are passed on: only the router's not-found and
+// method-not-allowed handlers are reached that way once Verify has passed.
+func (p Policies) Middleware(prefix string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			access, ok := p[c.Request().Method+" "+strings.TrimPrefix(c.Path(), prefix)]
+			if !ok {
+				return next(c)
+			}
+
+			if access.apiKeys && RequestAPIKey(c) != "" {
+				return next(c)
+			}
+
+			switch access.level {
+			case levelAuthenticated, levelStaff:
+				if !csrfValid(c) {
+					return refuse(c, http.StatusForbidden, "invalid CSRF token")
+				}
+				if msg, ok := authenticate(c); !ok {
+					return refuse(c, http.StatusUnauthorized, msg)
+				}
+				// Third-party applications only reach the routes of their scopes
+				if claims := GetUserFromContext(c); claims.ClientID != "" {
+					if access.level == levelStaff || access.scope == "" || !claims.HasScope(access.scope) {
+						return refuse(c, http.StatusForbidden, "insufficient scope")
+					}
+				}
+				if access.level == levelStaff {
+					claims := GetUserFromContext(c)
+					if !access.allows(claims.UserType) {
+						if access.permission == "" {
+							return refuse(c, http.StatusForbidden, "admin access required")
+						}
+						return refuse(c, http.StatusForbidden, "missing permission "+string(access.permission))
+					}
+					if claims.TenantID != uuid.Nil && !access.tenantScoped {
+						return refuse(c, http.StatusForbidden, "not available to tenant staff")
+					}
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// Verify checks that every route registered under prefix has a policy and
+// every policy a route, so a route cannot be added without deciding who may
+// call it
+func (p Policies) Verify(routes []*echo.Route, prefix string) error {
+	seen := make(map[string]bool)
+	var missing []string
+	for _, route := range routes {
+		if route.Method == echo.RouteNotFound || !strings.HasPrefix(route.Path, prefix) {
+			continue
+		}
+		key := route.Method + " " + strings.TrimPrefix(route.Path, prefix)
+		seen[key] = true
+		if _, ok := p[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("routes without an authorization policy: %s", strings.Join(missing, ", "))
+	}
+
+	var stale []string
+	for key := range p {
+		if !seen[key] {
+			stale = append(stale, key)
+		}
+	}
+	if len(stale) > 0 {
+		sort.Strings(stale)
+		return fmt.Errorf("authorization policies without a route: %s", strings.Join(stale, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestPoliciesVerify(t *testing.T) {
+	const prefix = "/api/v1"
+	noop := func(echo.Context) error { return nil }
+
+	tests := []struct {
+		name     string
+		routes   []string // "METHOD path" relative to the root
+		policies Policies
+		wantErr  string // empty when the table is complete
+	}{
+		{
+			name:     "every route has a policy",
+			routes:   []string{"GET /api/v1/me", "POST /api/v1/auth/login", "GET /api/v1/admin/users/:id"},
+			policies: Policies{"GET /me": Authenticated, "POST /auth/login": Public, "GET /admin/users/:id": Admin},
+		},
+		{
+			name:     "route without a policy",
+			routes:   []string{"GET /api/v1/me", "DELETE /api/v1/me"},
+			policies: Policies{"GET /me": Authenticated},
+			wantErr:  "routes without an authorization policy: DELETE /me",
+		},
+		{
+			name:     "method without a policy",
+			routes:   []string{"GET /api/v1/keys", "POST /api/v1/keys"},
+			policies: Policies{"GET /keys": Authenticated, "PUT /keys": Authenticated},
+			wantErr:  "routes without an authorization policy: POST /keys",
+		},
+		{
+			name:     "policy without a route",
+			routes:   []string{"GET /api/v1/me"},
+			policies: Policies{"GET /me": Authenticated, "GET /admin/stats": Admin},
+			wantErr:  "authorization policies without a route: GET /admin/stats",
+		},
+		{
+			name:     "routes outside the prefix are ignored",
+			routes:   []string{"GET /api/v1/me", "GET /health", "GET /favicon.ico"},
+			policies: Policies{"GET /me": Authenticated},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			for _, route := range tt.routes {
+				method, path, _ := strings.Cut(route, " ")
+				e.Add(method, path, noop)
+			}
+			// Registered by the router for unmatched paths, not by a handler
+			e.RouteNotFound(prefix+"/*", noop)
+
+			err := tt.policies.Verify(e.Routes(), prefix)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Verify() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("Verify() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
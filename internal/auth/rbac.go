@@ -0,0 +1,56 @@
+package auth
+
+// Roles, stored as the user_type of a user
+const (
+	RoleUser    = "user"
+	RoleAdmin   = "admin"
+	RoleSupport = "support"
+)
+
+// Permission is a read-only slice of the admin API a staff role may be
+// granted. Routes changing state are for admins only and need no
+// permission.
+type Permission string
+
+const (
+	// PermViewUsers covers users, their sessions and organizations
+	PermViewUsers Permission = "users:read"
+	// PermViewKeys covers API and trial keys
+	PermViewKeys Permission = "keys:read"
+	// PermViewLogs covers transcription logs, client error reports and
+	// access logs
+	PermViewLogs Permission = "logs:read"
+	// PermViewUsage covers usage summaries and the monthly budget
+	PermViewUsage Permission = "usage:read"
+	// PermViewSettings covers signup, trial and session policy settings,
+	// invites, presets and sources
+	PermViewSettings Permission = "settings:read"
+	// PermViewAudit covers the audit trail and login events
+	PermViewAudit Permission = "audit:read"
+)
+
+// rolePermissions is what each staff role other than admin is granted;
+// admins hold every permission
+var rolePermissions = map[string][]Permission{
+	// Support staff look into customer problems without being able to
+	// change anything
+	RoleSupport: {PermViewUsers, PermViewKeys, PermViewLogs, PermViewUsage},
+}
+
+// IsRole reports whether role is a user_type users can have
+func IsRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin || role == RoleSupport
+}
+
+// HasPermission reports whether role grants permission
+func HasPermission(role string, permission Permission) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,334 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind is the type of a setting's value
+type Kind string
+
+const (
+	KindString   Kind = "string"
+	KindInt      Kind = "int"
+	KindFloat    Kind = "float"
+	KindBool     Kind = "bool"
+	KindDuration Kind = "duration"
+)
+
+// Source describes where a setting's effective value came from
+type Source string
+
+const (
+	SourceEnv     Source = "env"
+	SourceFile    Source = "file"
+	SourceSecrets Source = "secrets"
+	SourceDefault Source = "default"
+	SourceUnset   Source = "unset"
+
+	// SourceDatabase marks Runtime settings an admin overrode
+	SourceDatabase Source = "database"
+)
+
+// Setting describes a single configuration value
+type Setting struct {
+	Name           string
+	Kind           Kind
+	Default        string
+	DevDefault     string // Used instead of Default when APP_ENV=dev
+	RequiredInProd bool
+	Secret         bool
+	Description    string
+	Validate       func(value string) error
+	// Runtime settings can be overridden by admins while the server runs
+	// (see ApplyOverrides); the rest only change with the environment
+	Runtime bool
+}
+
+// Entry is a resolved setting as shown by `config show`
+type Entry struct {
+	Setting
+	Value  string
+	Source Source
+	File   string // Path of the *_FILE the value was read from, if any
+
+	modTime time.Time
+}
+
+// Provider supplies setting values from an external secret store such as
+// Vault or AWS Secrets Manager. Fetch returns values keyed by setting name;
+// it may return nil when no store is configured.
+type Provider interface {
+	Name() string
+	Fetch(ctx context.Context) (map[string]string, error)
+}
+
+var (
+	provider Provider
+
+	mu      sync.RWMutex
+	values  map[string]Entry
+	loaded  bool
+	byName  = indexSettings()
+	devMode bool
+)
+
+func indexSettings() map[string]Setting {
+	m := make(map[string]Setting, len(Settings))
+	for _, s := range Settings {
+		m[s.Name] = s
+	}
+	return m
+}
+
+// Load resolves every registered setting from the environment and validates
+// it. All problems are collected and returned together so operators can fix
+// the whole configuration in one pass. Resolved values are kept even when
+// validation fails so `config show` can still print them.
+func Load() error {
+	appEnv := strings.TrimSpace(os.Getenv("APP_ENV"))
+	if appEnv == "" {
+		appEnv = byName["APP_ENV"].Default
+	}
+	dev := appEnv == "dev"
+
+	resolved := make(map[string]Entry, len(Settings))
+	failed := make(map[string]bool)
+	var problems []string
+
+	for _, s := range Settings {
+		entry, err := resolve(s, dev)
+		resolved[s.Name] = entry
+
+		if err != nil {
+			failed[s.Name] = true
+			problems = append(problems, fmt.Sprintf("%s: %v", s.Name, err))
+		}
+	}
+
+	mu.Lock()
+	values = resolved
+	devMode = dev
+	loaded = true
+	mu.Unlock()
+
+	// Secret store values are fetched after the environment is resolved so
+	// the provider can read its own connection settings
+	if provider != nil {
+		if err := applyProvider(context.Background(), false); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", provider.Name(), err))
+		}
+	}
+
+	mu.RLock()
+	for _, s := range Settings {
+		if failed[s.Name] {
+			continue
+		}
+		if err := validate(values[s.Name], dev); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", s.Name, err))
+		}
+	}
+	mu.RUnlock()
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// resolve determines a setting's value. NAME_FILE (the Docker/Kubernetes
+// secrets convention) takes precedence over defaults, and setting both NAME
+// and NAME_FILE is rejected as ambiguous.
+func resolve(s Setting, dev bool) (Entry, error) {
+	value, hasValue := os.LookupEnv(s.Name)
+	hasValue = hasValue && strings.TrimSpace(value) != ""
+	path, hasFile := os.LookupEnv(s.Name + "_FILE")
+	hasFile = hasFile && strings.TrimSpace(path) != ""
+
+	if hasValue && hasFile {
+		return Entry{Setting: s, Source: SourceUnset}, fmt.Errorf("both %s and %s_FILE are set", s.Name, s.Name)
+	}
+
+	if hasFile {
+		entry := Entry{Setting: s, Source: SourceFile, File: strings.TrimSpace(path)}
+		if err := entry.readFile(); err != nil {
+			entry.Source = SourceUnset
+			return entry, err
+		}
+		return entry, nil
+	}
+
+	if hasValue {
+		return Entry{Setting: s, Value: strings.TrimSpace(value), Source: SourceEnv}, nil
+	}
+
+	def := s.Default
+	if dev && s.DevDefault != "" {
+		def = s.DevDefault
+	}
+	if def != "" {
+		return Entry{Setting: s, Value: def, Source: SourceDefault}, nil
+	}
+	return Entry{Setting: s, Source: SourceUnset}, nil
+}
+
+// readFile loads the entry's value from its file, trimming the trailing
+// newline most secret files end with
+func (e *Entry) readFile() error {
+	info, err := os.Stat(e.File)
+	if err != nil {
+		return fmt.Errorf("cannot read %s_FILE: %w", e.Name, err)
+	}
+	data, err := os.ReadFile(e.File)
+	if err != nil {
+		return fmt.Errorf("cannot read %s_FILE: %w", e.Name, err)
+	}
+	e.Value = strings.TrimSpace(string(data))
+	e.modTime = info.ModTime()
+	return nil
+}
+
+func validate(e Entry, dev bool) error {
+	if e.Source == SourceUnset {
+		if e.RequiredInProd && !dev {
+			return errors.New("required in production but not set")
+		}
+		return nil
+	}
+
+	switch e.Kind {
+	case KindInt:
+		if _, err := strconv.Atoi(e.Value); err != nil {
+			return fmt.Errorf("must be an integer, got %q", e.Value)
+		}
+	case KindFloat:
+		if _, err := strconv.ParseFloat(e.Value, 64); err != nil {
+			return fmt.Errorf("must be a number, got %q", e.Value)
+		}
+	case KindBool:
+		if _, err := strconv.ParseBool(e.Value); err != nil {
+			return fmt.Errorf("must be a boolean, got %q", e.Value)
+		}
+	case KindDuration:
+		if _, err := time.ParseDuration(e.Value); err != nil {
+			return fmt.Errorf("must be a duration (e.g. 30s, 5m), got %q", e.Value)
+		}
+	}
+
+	if e.Validate != nil {
+		return e.Validate(e.Value)
+	}
+	return nil
+}
+
+// ensureLoaded performs a best-effort load for code paths (such as the
+// migrate command) that read settings without validating them first
+func ensureLoaded() {
+	mu.RLock()
+	ok := loaded
+	mu.RUnlock()
+	if !ok {
+		_ = Load()
+	}
+}
+
+func lookup(name string) Entry {
+	ensureLoaded()
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	entry, ok := values[name]
+	if !ok {
+		panic(fmt.Sprintf("config: unknown setting %q", name))
+	}
+	return entry
+}
+
+// String returns the value of a setting
+func String(name string) string {
+	return lookup(name).Value
+}
+
+// Int returns the value of an integer setting, falling back to its default
+// if the configured value is invalid
+func Int(name string) int {
+	entry := lookup(name)
+	if v, err := strconv.Atoi(entry.Value); err == nil {
+		return v
+	}
+	v, _ := strconv.Atoi(entry.Default)
+	return v
+}
+
+// Float returns the value of a numeric setting, falling back to its default
+// if the configured value is invalid
+func Float(name string) float64 {
+	entry := lookup(name)
+	if v, err := strconv.ParseFloat(entry.Value, 64); err == nil {
+		return v
+	}
+	v, _ := strconv.ParseFloat(entry.Default, 64)
+	return v
+}
+
+// Bool returns the value of a boolean setting
+func Bool(name string) bool {
+	entry := lookup(name)
+	if v, err := strconv.ParseBool(entry.Value); err == nil {
+		return v
+	}
+	v, _ := strconv.ParseBool(entry.Default)
+	return v
+}
+
+// Duration returns the value of a duration setting
+func Duration(name string) time.Duration {
+	entry := lookup(name)
+	if v, err := time.ParseDuration(entry.Value); err == nil {
+		return v
+	}
+	v, _ := time.ParseDuration(entry.Default)
+	return v
+}
+
+// IsDev reports whether the server runs in development mode (APP_ENV=dev)
+func IsDev() bool {
+	ensureLoaded()
+
+	mu.RLock()
+	defer mu.RUnlock()
+	return devMode
+}
+
+// All returns every resolved setting sorted by name. Secret values are
+// replaced with a redacted marker when redacted is true.
+func All(redacted bool) []Entry {
+	ensureLoaded()
+
+	mu.RLock()
+	entries := make([]Entry, 0, len(values))
+	for _, e := range values {
+		if redacted && e.Secret && e.Value != "" {
+			e.Value = Redact(e.Value)
+		}
+		entries = append(entries, e)
+	}
+	mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// Redact hides a secret value, keeping only its length visible
+func Redact(value string) string {
+	return fmt.Sprintf("<redacted:%d chars>", len(value))
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"sort"
+)
+
+// overridden keeps the entries of Runtime settings an admin overrode, so
+// clearing an override goes back to the environment's value
+var overridden = make(map[string]Entry)
+
+// IsRuntime reports whether name is a Runtime setting
+func IsRuntime(name string) bool {
+	return byName[name].Runtime
+}
+
+// ValidateOverride checks that an admin may set a setting to value at
+// runtime: it must be a Runtime setting, and value valid for it
+func ValidateOverride(name, value string) error {
+	s, ok := byName[name]
+	if !ok {
+		return fmt.Errorf("unknown setting %s", name)
+	}
+	if !s.Runtime {
+		return fmt.Errorf("%s can only be changed in the environment", name)
+	}
+	return validate(Entry{Setting: s, Value: value, Source: SourceDatabase}, IsDev())
+}
+
+// ApplyOverrides makes overrides, keyed by setting name, the values of
+// Runtime settings. Settings missing from overrides go back to the value
+// of the environment. Like reloaded files, changes are announced to
+// OnChange listeners, and invalid values are ignored.
+func ApplyOverrides(overrides map[string]string) {
+	ensureLoaded()
+
+	type change struct{ name, previous, value string }
+	var changes []change
+
+	mu.Lock()
+	dev := devMode
+	for _, s := range Settings {
+		if !s.Runtime {
+			continue
+		}
+		current := values[s.Name]
+		value, ok := overrides[s.Name]
+
+		if !ok {
+			base, wasOverridden := overridden[s.Name]
+			if !wasOverridden {
+				continue
+			}
+			delete(overridden, s.Name)
+			values[s.Name] = base
+			if base.Value != current.Value {
+				changes = append(changes, change{s.Name, current.Value, base.Value})
+			}
+			continue
+		}
+
+		if current.Source == SourceDatabase && current.Value == value {
+			continue
+		}
+		updated := Entry{Setting: s, Value: value, Source: SourceDatabase}
+		if err := validate(updated, dev); err != nil {
+			log.Printf("[Config] Ignoring override of %s: %v", s.Name, err)
+			continue
+		}
+		if _, wasOverridden := overridden[s.Name]; !wasOverridden {
+			overridden[s.Name] = current
+		}
+		values[s.Name] = updated
+		if value != current.Value {
+			changes = append(changes, change{s.Name, current.Value, value})
+		}
+	}
+	mu.Unlock()
+
+	for _, c := range changes {
+		log.Printf("[Config] %s changed to %q at runtime", c.name, c.value)
+		notify(c.name, c.previous)
+	}
+}
+
+// WithoutOverride returns the value of a Runtime setting without its override:
+// what it goes back to once the override is cleared
+func WithoutOverride(name string) Entry {
+	ensureLoaded()
+
+	mu.RLock()
+	defer mu.RUnlock()
+	if base, ok := overridden[name]; ok {
+		return base
+	}
+	entry, ok := values[name]
+	if !ok {
+		panic(fmt.Sprintf("config: unknown setting %q", name))
+	}
+	return entry
+}
+
+// RuntimeSettings returns every Runtime setting as currently resolved,
+// sorted by name
+func RuntimeSettings() []Entry {
+	ensureLoaded()
+
+	mu.RLock()
+	var entries []Entry
+	for _, e := range values {
+		if e.Runtime {
+			entries = append(entries, e)
+		}
+	}
+	mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
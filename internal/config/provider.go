@@ -0,0 +1,93 @@
+package config
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// UseProvider registers the external secret store consulted by Load and
+// RefreshProvider. It must be called before Load.
+func UseProvider(p Provider) {
+	provider = p
+}
+
+// applyProvider fetches values from the secret store and overlays them on
+// settings that were not set explicitly through the environment or a file.
+// When notifyChanges is true, listeners are told about values that changed.
+func applyProvider(ctx context.Context, notifyChanges bool) error {
+	fetched, err := provider.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	var changed []Entry
+	var previous []string
+
+	mu.Lock()
+	dev := devMode
+	for name, value := range fetched {
+		entry, ok := values[name]
+		if !ok {
+			continue
+		}
+		if entry.Source == SourceEnv || entry.Source == SourceFile {
+			log.Printf("[Config] %s is set explicitly; ignoring value from %s", name, provider.Name())
+			continue
+		}
+		if entry.Source == SourceDatabase {
+			log.Printf("[Config] %s is overridden by an admin; ignoring value from %s", name, provider.Name())
+			continue
+		}
+		if entry.Source == SourceSecrets && entry.Value == value {
+			continue
+		}
+
+		updated := entry
+		updated.Value = value
+		updated.Source = SourceSecrets
+		if notifyChanges {
+			if err := validate(updated, dev); err != nil {
+				log.Printf("[Config] Ignoring new value for %s from %s: %v", name, provider.Name(), err)
+				continue
+			}
+		}
+
+		values[name] = updated
+		changed = append(changed, updated)
+		previous = append(previous, entry.Value)
+	}
+	mu.Unlock()
+
+	if notifyChanges {
+		for i, e := range changed {
+			log.Printf("[Config] Rotated %s from %s", e.Name, provider.Name())
+			notify(e.Name, previous[i])
+		}
+	}
+	return nil
+}
+
+// RefreshProvider periodically re-fetches the secret store so rotated
+// secrets take effect without a restart. It returns when ctx is cancelled.
+func RefreshProvider(ctx context.Context, interval time.Duration) {
+	if provider == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			if err := applyProvider(fetchCtx, true); err != nil {
+				log.Printf("[Config] %s refresh failed: %v", provider.Name(), err)
+			}
+			cancel()
+		}
+	}
+}
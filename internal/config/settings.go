@@ -0,0 +1,1088 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Settings is the registry of every configuration value the server reads.
+// Add new settings here rather than calling os.Getenv directly so they are
+// validated at startup and listed by `hyperwhisper config show`. Every
+// setting can also be read from a file by setting NAME_FILE instead of NAME.
+var Settings = []Setting{
+	{
+		Name:        "APP_ENV",
+		Kind:        KindString,
+		Default:     "prod",
+		Description: "Environment: 'dev' relaxes validation and security checks, 'prod' enforces them",
+		Validate:    oneOf("dev", "prod"),
+	},
+	{
+		Name:           "DATABASE_URL",
+		Kind:           KindString,
+		DevDefault:     "postgres://localhost:5432/hyperwhisper?sslmode=disable",
+		RequiredInProd: true,
+		Secret:         true,
+		Description:    "PostgreSQL connection string",
+		Validate:       postgresDSN,
+	},
+	{
+		Name:           "JWT_SECRET",
+		Kind:           KindString,
+		DevDefault:     "hyperwhisper-dev-secret-change-in-production",
+		RequiredInProd: true,
+		Secret:         true,
+		Description:    "JWT signing secret",
+		Validate:       minLength(32),
+	},
+	{
+		Name:        "ACCESS_TOKEN_EXPIRY",
+		Kind:        KindInt,
+		Default:     "5",
+		Description: "Access token expiry in minutes",
+		Validate:    positiveInt,
+	},
+	{
+		Name:        "REFRESH_TOKEN_EXPIRY",
+		Kind:        KindInt,
+		Default:     "7",
+		Description: "Refresh token expiry in days",
+		Validate:    positiveInt,
+	},
+	{
+		Name:        "REFRESH_TOKEN_REUSE_GRACE",
+		Kind:        KindDuration,
+		Default:     "10s",
+		Description: "How long after rotation a refresh token may be replayed (e.g. by concurrent tabs) before it counts as stolen and its family is revoked",
+		Validate:    nonNegativeDuration,
+	},
+	{
+		Name:        "ACCESS_DENYLIST_SYNC_INTERVAL",
+		Kind:        KindDuration,
+		Default:     "2s",
+		Description: "How often access tokens revoked through other server instances are loaded into this one's denylist",
+		Validate:    positiveDuration,
+	},
+	{
+		Name:        "DESKTOP_CLIENT_ID",
+		Kind:        KindString,
+		Default:     "hyperwhisper-desktop",
+		Description: "OAuth client_id the desktop app signs users in with through the browser (authorization code flow with PKCE); empty disables it",
+	},
+	{
+		Name:        "CLI_CLIENT_ID",
+		Kind:        KindString,
+		Default:     "hyperwhisper-cli",
+		Description: "OAuth client_id terminal and headless clients sign users in with through the device flow; empty disables it",
+	},
+	{
+		Name:        "JWT_ISSUER",
+		Kind:        KindString,
+		Description: "iss claim of issued JWTs, required at validation when set",
+	},
+	{
+		Name:        "JWT_AUDIENCE",
+		Kind:        KindString,
+		Description: "Comma-separated aud claim of issued JWTs; the first entry names this server and is required at validation",
+	},
+	{
+		Name:        "JWT_CUSTOM_CLAIMS",
+		Kind:        KindString,
+		Description: "JSON object of extra claims added to access tokens; built-in claims cannot be overridden",
+		Validate:    optional(jsonObject),
+	},
+	{
+		Name:           "DEEPGRAM_API_KEY",
+		Kind:           KindString,
+		RequiredInProd: true,
+		Secret:         true,
+		Description:    "Upstream Deepgram API key used by the transcription proxy",
+	},
+	{
+		Name:        "DEEPGRAM_EU_URL",
+		Kind:        KindString,
+		Default:     "wss://api.eu.deepgram.com/v1/listen",
+		Description: "Deepgram streaming endpoint of sessions bound to the EU data region",
+		Validate:    absoluteURL,
+	},
+	{
+		Name:        "DATABASE_REGION",
+		Kind:        KindString,
+		Description: "Data region the database is hosted in (e.g. 'eu'); transcripts of sessions bound to another region are not saved",
+	},
+	{
+		Name:        "DB_MAX_OPEN_CONNS",
+		Kind:        KindInt,
+		Default:     "25",
+		Description: "Maximum open database connections per process",
+		Validate:    positiveInt,
+	},
+	{
+		Name:        "DB_MAX_IDLE_CONNS",
+		Kind:        KindInt,
+		Default:     "5",
+		Description: "Idle database connections kept open for reuse",
+		Validate:    nonNegativeInt,
+	},
+	{
+		Name:        "DB_CONN_MAX_LIFETIME",
+		Kind:        KindDuration,
+		Default:     "5m",
+		Description: "How long a database connection is reused before it is replaced; 0 keeps connections forever",
+		Validate:    nonNegativeDuration,
+	},
+	{
+		Name:        "DB_CONN_MAX_IDLE_TIME",
+		Kind:        KindDuration,
+		Default:     "0s",
+		Description: "How long a database connection may sit idle before it is closed; 0 keeps idle connections up to DB_MAX_IDLE_CONNS",
+		Validate:    nonNegativeDuration,
+	},
+	{
+		Name:        "DB_POOL_WAIT_WARNING",
+		Kind:        KindDuration,
+		Default:     "100ms",
+		Description: "Log a warning when queries waited longer than this on average for a free connection over a minute; 0 disables",
+		Validate:    nonNegativeDuration,
+	},
+	{
+		Name:        "DB_HEALTH_CHECK_INTERVAL",
+		Kind:        KindDuration,
+		Default:     "10s",
+		Description: "How often the database is pinged to detect outages",
+		Validate:    positiveDuration,
+	},
+	{
+		Name:        "DB_RECONNECT_MAX_BACKOFF",
+		Kind:        KindDuration,
+		Default:     "30s",
+		Description: "Longest pause between reconnection attempts while the database is down; attempts start at 1s and double",
+		Validate:    positiveDuration,
+	},
+	{
+		Name:        "DB_OUTAGE_WAIT",
+		Kind:        KindDuration,
+		Default:     "5s",
+		Description: "How long requests wait for the database to come back during an outage before getting 503; 0 refuses them at once",
+		Validate:    nonNegativeDuration,
+	},
+	{
+		Name:        "APP_BASE_URL",
+		Kind:        KindString,
+		Default:     "https://hyperwhisper.dev",
+		Description: "Public base URL used to build links (e.g. trial upgrade URL)",
+		Validate:    absoluteURL,
+	},
+	{
+		Name:        "ALLOWED_ORIGINS",
+		Kind:        KindString,
+		Default:     "https://hyperwhisper.dev,https://www.hyperwhisper.dev",
+		Description: "Comma-separated origins of web pages that may open transcription WebSockets, besides the dashboard itself; tenants use their domains instead",
+		Validate:    originList,
+		Runtime:     true,
+	},
+	{
+		Name:        "TRUSTED_PROXIES",
+		Kind:        KindString,
+		Default:     "127.0.0.0/8,::1/128,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,fc00::/7",
+		Description: "Comma-separated CIDRs or IPs of reverse proxies whose X-Forwarded-For/-Proto/-Host headers are trusted; \"none\" trusts no proxy",
+		Validate:    optional(cidrList),
+	},
+	{
+		Name:        "SMTP_HOST",
+		Kind:        KindString,
+		Description: "SMTP server for password reset emails; empty drops them (only their subject is logged)",
+	},
+	{
+		Name:        "SMTP_PORT",
+		Kind:        KindString,
+		Default:     "587",
+		Description: "SMTP server port; STARTTLS is used when the server offers it",
+		Validate:    positiveInt,
+	},
+	{
+		Name:        "SMTP_USERNAME",
+		Kind:        KindString,
+		Description: "SMTP username; empty sends without authentication",
+	},
+	{
+		Name:        "SMTP_PASSWORD",
+		Kind:        KindString,
+		Secret:      true,
+		Description: "SMTP password",
+	},
+	{
+		Name:        "MAIL_FROM",
+		Kind:        KindString,
+		Default:     "HyperWhisper <no-reply@hyperwhisper.dev>",
+		Description: "Sender address of emails",
+	},
+	{
+		Name:        "PASSWORD_RESET_TTL",
+		Kind:        KindDuration,
+		Default:     "1h",
+		Description: "How long a password reset link stays valid",
+		Validate:    positiveDuration,
+	},
+	{
+		Name:        "PASSWORD_RESET_MAX_PER_HOUR",
+		Kind:        KindInt,
+		Default:     "3",
+		Description: "Reset links emailed per account per hour; further requests are silently ignored",
+		Validate:    positiveInt,
+	},
+	{
+		Name:        "PASSWORD_HISTORY_COUNT",
+		Kind:        KindInt,
+		Default:     "5",
+		Description: "Recent passwords of a user, the current one included, that changing or resetting the password can't reuse; 0 disables",
+		Validate:    nonNegativeInt,
+		Runtime:     true,
+	},
+	{
+		Name:        "CONFIG_RELOAD_INTERVAL",
+		Kind:        KindDuration,
+		Default:     "30s",
+		Description: "How often *_FILE secrets and admin setting overrides are checked for changes",
+		Validate:    positiveDuration,
+	},
+	{
+		Name:        "TRIAL_EXPIRY_DAYS",
+		Kind:        KindInt,
+		Default:     "90",
+		Description: "Trial key lifetime in days of the default trial preset, applied until an admin edits its limits",
+		Validate:    positiveInt,
+	},
+	{
+		Name:        "TRIAL_MAX_DURATION_SECONDS",
+		Kind:        KindInt,
+		Default:     "3600",
+		Description: "Total transcription seconds per trial key of the default trial preset, applied until an admin edits its limits",
+		Validate:    positiveInt,
+	},
+	{
+		Name:        "TRIAL_MAX_SESSIONS",
+		Kind:        KindInt,
+		Default:     "100",
+		Description: "Sessions per trial key of the default trial preset, applied until an admin edits its limits",
+		Validate:    positiveInt,
+	},
+	{
+		Name:        "TRIAL_MAX_SESSION_DURATION_SECONDS",
+		Kind:        KindInt,
+		Default:     "600",
+		Description: "Longest trial session in seconds of the default trial preset, applied until an admin edits its limits",
+		Validate:    positiveInt,
+	},
+	{
+		Name:        "TRIAL_GRACE_SECONDS",
+		Kind:        KindInt,
+		Default:     "300",
+		Description: "Seconds of offline transcription granted to trial clients that cannot reach the server (0 disables grants)",
+		Validate:    nonNegativeInt,
+	},
+	{
+		Name:        "TRIAL_GRACE_TTL",
+		Kind:        KindDuration,
+		Default:     "24h",
+		Description: "How long an offline grace grant stays usable after it is issued",
+		Validate:    positiveDuration,
+	},
+	{
+		Name:        "TRIAL_UPGRADE_LINK_TTL",
+		Kind:        KindDuration,
+		Default:     "720h",
+		Description: "How long a trial's signed upgrade link can be used to convert it into an account",
+		Validate:    positiveDuration,
+	},
+	{
+		Name:        "TRIAL_CONVERSION_BONUS_SECONDS",
+		Kind:        KindInt,
+		Default:     "0",
+		Description: "Bonus seconds credited to accounts created from a trial upgrade link, on top of the trial's unused quota",
+		Validate:    nonNegativeInt,
+		Runtime:     true,
+	},
+	{
+		Name:        "TRIAL_ATTESTATION",
+		Kind:        KindString,
+		Default:     "off",
+		Description: "Platform attestation on trial provisioning: 'off', 'optional' (verified when sent) or 'required'",
+		Validate:    oneOf("off", "optional", "required"),
+	},
+	{
+		Name:        "TRIAL_MAX_REGENERATIONS",
+		Kind:        KindInt,
+		Default:     "0",
+		Description: "How often provisioning may re-issue the secret of a device's existing trial key, e.g. for reinstalls that lost it (0 never re-issues; clients rotate with POST /api/v1/trial/rotate)",
+		Validate:    nonNegativeInt,
+	},
+	{
+		Name:        "TRIAL_MIN_PROVISION_INTERVAL",
+		Kind:        KindDuration,
+		Default:     "1h",
+		Description: "Minimum time between two provisionings of the same device's trial key (0 disables)",
+		Validate:    nonNegativeDuration,
+		Runtime:     true,
+	},
+	{
+		Name:        "TRIAL_MAX_PER_SUBNET",
+		Kind:        KindInt,
+		Default:     "0",
+		Description: "New trial keys allowed per /24 (IPv4) or /64 (IPv6) network within TRIAL_SUBNET_WINDOW (0 = unlimited)",
+		Validate:    nonNegativeInt,
+		Runtime:     true,
+	},
+	{
+		Name:        "TRIAL_SUBNET_WINDOW",
+		Kind:        KindDuration,
+		Default:     "720h",
+		Description: "Period over which TRIAL_MAX_PER_SUBNET counts new trial keys",
+		Validate:    positiveDuration,
+	},
+	{
+		Name:        "ATTESTATION_APPLE_TEAM_ID",
+		Kind:        KindString,
+		Description: "Apple developer team ID used to verify DeviceCheck tokens",
+	},
+	{
+		Name:        "ATTESTATION_APPLE_KEY_ID",
+		Kind:        KindString,
+		Description: "ID of the DeviceCheck key in the Apple developer account",
+	},
+	{
+		Name:        "ATTESTATION_APPLE_PRIVATE_KEY",
+		Kind:        KindString,
+		Secret:      true,
+		Description: "PEM contents of the DeviceCheck .p8 private key",
+	},
+	{
+		Name:        "ATTESTATION_APPLE_ENVIRONMENT",
+		Kind:        KindString,
+		Default:     "production",
+		Description: "DeviceCheck environment: 'production' or 'development' (debug builds)",
+		Validate:    oneOf("production", "development"),
+	},
+	{
+		Name:        "SECRETS_PROVIDER",
+		Kind:        KindString,
+		Default:     "none",
+		Description: "External secret store: 'none', 'vault' or 'aws' (values set via env or *_FILE take precedence)",
+		Validate:    oneOf("none", "vault", "aws"),
+	},
+	{
+		Name:        "SECRETS_REFRESH_INTERVAL",
+		Kind:        KindDuration,
+		Default:     "5m",
+		Description: "How often secrets are re-fetched from the external secret store",
+		Validate:    positiveDuration,
+	},
+	{
+		Name:        "VAULT_ADDR",
+		Kind:        KindString,
+		Description: "Vault server address (e.g. https://vault.internal:8200)",
+		Validate:    optional(absoluteURL),
+	},
+	{
+		Name:        "VAULT_TOKEN",
+		Kind:        KindString,
+		Secret:      true,
+		Description: "Vault token used to read secrets",
+	},
+	{
+		Name:        "VAULT_NAMESPACE",
+		Kind:        KindString,
+		Description: "Vault Enterprise namespace (optional)",
+	},
+	{
+		Name:        "VAULT_SECRET_PATH",
+		Kind:        KindString,
+		Description: "Vault KV path, including 'data/' for KV v2 mounts (e.g. secret/data/hyperwhisper)",
+	},
+	{
+		Name:        "AWS_REGION",
+		Kind:        KindString,
+		Description: "AWS region for Secrets Manager and KMS",
+	},
+	{
+		Name:        "AWS_ACCESS_KEY_ID",
+		Kind:        KindString,
+		Secret:      true,
+		Description: "AWS access key for Secrets Manager and KMS",
+	},
+	{
+		Name:        "AWS_SECRET_ACCESS_KEY",
+		Kind:        KindString,
+		Secret:      true,
+		Description: "AWS secret key for Secrets Manager and KMS",
+	},
+	{
+		Name:        "AWS_SESSION_TOKEN",
+		Kind:        KindString,
+		Secret:      true,
+		Description: "AWS session token for temporary credentials (optional)",
+	},
+	{
+		Name:        "AWS_SECRET_ID",
+		Kind:        KindString,
+		Description: "Secrets Manager secret name or ARN; its value must be a JSON object keyed by setting name",
+	},
+	{
+		Name:        "ENCRYPTION_KEY_PROVIDER",
+		Kind:        KindString,
+		Default:     "local",
+		Description: "Master key for column encryption: 'local' (ENCRYPTION_MASTER_KEY) or 'aws-kms' (ENCRYPTION_KMS_KEY_ID)",
+		Validate:    oneOf("local", "aws-kms"),
+	},
+	{
+		Name:        "ENCRYPTION_MASTER_KEY",
+		Kind:        KindString,
+		DevDefault:  "aHlwZXJ3aGlzcGVyLWRldi1tYXN0ZXIta2V5LTAwMDA=",
+		Secret:      true,
+		Description: "Base64-encoded 32-byte master key that wraps the column encryption keys (local provider)",
+		Validate:    optional(base64Key),
+	},
+	{
+		Name:        "ENCRYPTION_PREVIOUS_MASTER_KEYS",
+		Kind:        KindString,
+		Secret:      true,
+		Description: "Comma-separated retired master keys, still accepted for unwrapping during a master key rotation",
+	},
+	{
+		Name:        "ENCRYPTION_KMS_KEY_ID",
+		Kind:        KindString,
+		Description: "AWS KMS key ID, ARN or alias that wraps the column encryption keys (aws-kms provider)",
+	},
+	{
+		Name:        "LOG_REDACT",
+		Kind:        KindString,
+		Default:     "all",
+		Description: "Personal data removed from logs: comma-separated transcripts, emails, ips, keys, or 'all'/'none'",
+		Validate:    listOf("all", "none", "transcripts", "emails", "ips", "keys"),
+	},
+	{
+		Name:        "ACCESS_LOG_RETENTION_DAYS",
+		Kind:        KindInt,
+		Default:     "30",
+		Description: "Days HTTP access records are kept in the database for GET /admin/access-logs; 0 disables persistence",
+		Validate:    nonNegativeInt,
+	},
+	{
+		Name:        "LOGIN_EVENT_RETENTION_DAYS",
+		Kind:        KindInt,
+		Default:     "90",
+		Description: "Days sign-ins and token refreshes are kept for users' and admins' login history; 0 disables recording",
+		Validate:    nonNegativeInt,
+	},
+	{
+		Name:        "AUTH_EVENT_RETENTION_DAYS",
+		Kind:        KindInt,
+		Default:     "30",
+		Description: "Days streaming proxy authentication attempts are kept for GET /admin/auth-events; 0 disables recording",
+		Validate:    nonNegativeInt,
+	},
+	{
+		Name:        "NEW_DEVICE_ALERTS",
+		Kind:        KindBool,
+		Default:     "true",
+		Description: "Email users when they sign in from an IP address and user agent not seen before on their account",
+		Runtime:     true,
+	},
+	{
+		Name:        "TRANSCRIPT_RETENTION_DAYS",
+		Kind:        KindInt,
+		Default:     "30",
+		Description: "Days transcripts of sessions opened with save_transcript=true are kept; 0 disables saving",
+		Validate:    nonNegativeInt,
+	},
+	{
+		Name:        "ARCHIVE_AFTER_MONTHS",
+		Kind:        KindInt,
+		Default:     "0",
+		Description: "Full months of transcription and trial usage logs kept in the database; older months are moved to S3 by 'archive usage-logs'. 0 disables archival",
+		Validate:    nonNegativeInt,
+	},
+	{
+		Name:        "ARCHIVE_S3_BUCKET",
+		Kind:        KindString,
+		Description: "S3 bucket that receives archived usage logs (uses the AWS_* credentials)",
+	},
+	{
+		Name:        "ARCHIVE_S3_PREFIX",
+		Kind:        KindString,
+		Default:     "usage-logs",
+		Description: "Key prefix of archived usage logs in the bucket",
+	},
+	{
+		Name:        "ARCHIVE_S3_ENDPOINT",
+		Kind:        KindString,
+		Description: "Endpoint of an S3-compatible store (e.g. MinIO); empty uses AWS S3 in AWS_REGION",
+		Validate:    optional(absoluteURL),
+	},
+	{
+		Name:        "BACKUP_S3_BUCKET",
+		Kind:        KindString,
+		Description: "S3 bucket that receives encrypted backups from 'backup create' (uses the AWS_* credentials)",
+	},
+	{
+		Name:        "BACKUP_S3_PREFIX",
+		Kind:        KindString,
+		Default:     "backups",
+		Description: "Key prefix of backups in the bucket",
+	},
+	{
+		Name:        "BACKUP_S3_ENDPOINT",
+		Kind:        KindString,
+		Description: "Endpoint of an S3-compatible store (e.g. MinIO) for backups; empty uses AWS S3 in AWS_REGION",
+		Validate:    optional(absoluteURL),
+	},
+	{
+		Name:        "SESSION_IDLE_TIMEOUT",
+		Kind:        KindDuration,
+		Default:     "60s",
+		Description: "Close transcription sessions whose client sends nothing (audio or keep-alive) for this long; 0 disables",
+		Validate:    nonNegativeDuration,
+	},
+	{
+		Name:        "SESSION_NONCE_WINDOW",
+		Kind:        KindDuration,
+		Default:     "2m",
+		Description: "How long after a failed connection a retry with the same session_nonce reopens its log instead of adding one; 0 disables",
+		Validate:    nonNegativeDuration,
+	},
+	{
+		Name:        "MIN_CLIENT_PROTOCOL",
+		Kind:        KindInt,
+		Default:     "1",
+		Description: "Oldest client protocol version transcription sessions accept; older clients are refused with 426",
+		Validate:    positiveInt,
+		Runtime:     true,
+	},
+	{
+		Name:        "TELEMETRY_RATE_LIMIT",
+		Kind:        KindInt,
+		Default:     "10",
+		Description: "Client error reports accepted per minute from a single IP",
+		Validate:    positiveInt,
+	},
+	{
+		Name:        "USAGE_BADGE_CACHE_TTL",
+		Kind:        KindDuration,
+		Default:     "15m",
+		Description: "How long the public usage badge's stats are cached, by the server and by clients",
+		Validate:    positiveDuration,
+	},
+	{
+		Name:        "USAGE_BADGE_RATE_LIMIT",
+		Kind:        KindInt,
+		Default:     "30",
+		Description: "Usage badge requests accepted per minute from a single IP",
+		Validate:    positiveInt,
+	},
+	{
+		Name:        "AUTH_RATE_LIMIT_ATTEMPTS",
+		Kind:        KindInt,
+		Default:     "5",
+		Description: "Failed sign-in or token refresh attempts (or any signup or trial provisioning attempts) per account, email, token or device before it is locked out; 0 disables",
+		Validate:    nonNegativeInt,
+	},
+	{
+		Name:        "AUTH_RATE_LIMIT_IP_ATTEMPTS",
+		Kind:        KindInt,
+		Default:     "20",
+		Description: "Like AUTH_RATE_LIMIT_ATTEMPTS, per client IP; 0 disables",
+		Validate:    nonNegativeInt,
+	},
+	{
+		Name:        "AUTH_RATE_LIMIT_BASE_DELAY",
+		Kind:        KindDuration,
+		Default:     "1s",
+		Description: "Lockout after the first attempt past the limit, doubled with each further attempt",
+		Validate:    positiveDuration,
+	},
+	{
+		Name:        "AUTH_RATE_LIMIT_MAX_DELAY",
+		Kind:        KindDuration,
+		Default:     "15m",
+		Description: "Longest lockout of an auth endpoint",
+		Validate:    positiveDuration,
+	},
+	{
+		Name:        "AUTH_RATE_LIMIT_WINDOW",
+		Kind:        KindDuration,
+		Default:     "15m",
+		Description: "How long auth attempts are remembered after the last one",
+		Validate:    positiveDuration,
+	},
+	{
+		Name:        "REDIS_URL",
+		Kind:        KindString,
+		Secret:      true,
+		Description: "Redis (redis:// or rediss://) shared by all instances for auth rate limiting; unset keeps counts in memory",
+		Validate:    optional(redisURL),
+	},
+	{
+		Name:        "CONCURRENCY_LIMIT_TRIAL",
+		Kind:        KindInt,
+		Default:     "1",
+		Description: "Concurrent transcription sessions allowed per trial key (0 = unlimited)",
+		Validate:    nonNegativeInt,
+		Runtime:     true,
+	},
+	{
+		Name:        "CONCURRENCY_LIMIT_USER",
+		Kind:        KindInt,
+		Default:     "5",
+		Description: "Concurrent transcription sessions allowed per user (0 = unlimited)",
+		Validate:    nonNegativeInt,
+		Runtime:     true,
+	},
+	{
+		Name:        "CONCURRENCY_LIMIT_ADMIN",
+		Kind:        KindInt,
+		Default:     "0",
+		Description: "Concurrent transcription sessions allowed per admin user (0 = unlimited)",
+		Validate:    nonNegativeInt,
+		Runtime:     true,
+	},
+	{
+		Name:        "CONCURRENCY_QUEUE_TIMEOUT",
+		Kind:        KindDuration,
+		Default:     "0s",
+		Description: "How long a connection over the concurrency limit waits for a free slot; 0 rejects immediately",
+		Validate:    nonNegativeDuration,
+	},
+	{
+		Name:        "MAX_API_KEYS_PER_USER",
+		Kind:        KindInt,
+		Default:     "25",
+		Description: "Active personal API keys a user may have unless an admin overrides it; 0 = unlimited",
+		Validate:    nonNegativeInt,
+		Runtime:     true,
+	},
+	{
+		Name:        "KEY_LOCKDOWN_USAGE_FACTOR",
+		Kind:        KindFloat,
+		Default:     "10",
+		Description: "Lock an API key whose usage over the last 24 hours exceeds this many times its average daily usage of the 14 days before; 0 disables",
+		Validate:    nonNegativeFloat,
+		Runtime:     true,
+	},
+	{
+		Name:        "KEY_LOCKDOWN_MIN_USAGE",
+		Kind:        KindDuration,
+		Default:     "1h",
+		Description: "Usage over the last 24 hours below which KEY_LOCKDOWN_USAGE_FACTOR never locks a key",
+		Validate:    nonNegativeDuration,
+		Runtime:     true,
+	},
+	{
+		Name:        "KEY_LOCKDOWN_MAX_IPS",
+		Kind:        KindInt,
+		Default:     "20",
+		Description: "Lock an API key used from more than this many distinct client IPs within an hour; 0 disables",
+		Validate:    nonNegativeInt,
+		Runtime:     true,
+	},
+	{
+		Name:        "KEY_INACTIVITY_DAYS",
+		Kind:        KindInt,
+		Default:     "0",
+		Description: "Revoke API keys unused for this many days, after warning their owners; 0 disables",
+		Validate:    nonNegativeInt,
+		Runtime:     true,
+	},
+	{
+		Name:        "KEY_INACTIVITY_WARNING_DAYS",
+		Kind:        KindInt,
+		Default:     "14",
+		Description: "How many days before revoking an unused API key its owner is warned",
+		Validate:    nonNegativeInt,
+		Runtime:     true,
+	},
+	{
+		Name:        "PROXY_TUNING_PROFILE",
+		Kind:        KindString,
+		Default:     "balanced",
+		Description: "WebSocket proxy preset: balanced, low-memory, throughput or legacy (1 KiB buffers, no write deadlines). The PROXY_* settings below override single values",
+		Validate:    oneOf("balanced", "low-memory", "throughput", "legacy"),
+	},
+	{
+		Name:        "PROXY_READ_BUFFER_SIZE",
+		Kind:        KindInt,
+		Default:     "0",
+		Description: "Client and Deepgram connection read buffer in bytes; 0 uses the tuning profile's value",
+		Validate:    nonNegativeInt,
+	},
+	{
+		Name:        "PROXY_WRITE_BUFFER_SIZE",
+		Kind:        KindInt,
+		Default:     "0",
+		Description: "Client and Deepgram connection write buffer in bytes; 0 uses the tuning profile's value",
+		Validate:    nonNegativeInt,
+	},
+	{
+		Name:        "PROXY_UPSTREAM_HANDSHAKE_TIMEOUT",
+		Kind:        KindDuration,
+		Default:     "0s",
+		Description: "How long connecting to Deepgram may take; 0 uses the tuning profile's value",
+		Validate:    nonNegativeDuration,
+	},
+	{
+		Name:        "PROXY_WRITE_TIMEOUT",
+		Kind:        KindDuration,
+		Default:     "0s",
+		Description: "How long forwarding a single message to the client or Deepgram may block; 0 uses the tuning profile's value",
+		Validate:    nonNegativeDuration,
+	},
+	{
+		Name:        "ADAPTATION_WRITE_LATENCY",
+		Kind:        KindDuration,
+		Default:     "250ms",
+		Description: "Average time forwarding audio to Deepgram may take before protocol v3 clients are hinted to send less; 0 ignores write latency",
+		Validate:    nonNegativeDuration,
+	},
+	{
+		Name:        "ADAPTATION_LAG",
+		Kind:        KindDuration,
+		Default:     "1s",
+		Description: "How far behind real time a client's raw audio may arrive before protocol v3 clients are hinted to send less; 0 ignores lag",
+		Validate:    nonNegativeDuration,
+	},
+	{
+		Name:        "ADAPTATION_SUSTAIN",
+		Kind:        KindDuration,
+		Default:     "5s",
+		Description: "How long backpressure must last before an adaptation hint is sent, and calm before it is lifted; 0 disables hints",
+		Validate:    nonNegativeDuration,
+	},
+	{
+		Name:        "RESPONSE_COMPRESSION",
+		Kind:        KindString,
+		Default:     "gzip",
+		Description: "Compression of API responses: brotli (br for clients that accept it, gzip for the rest), gzip or off (e.g. when a reverse proxy already compresses). WebSocket and event streams are never compressed",
+		Validate:    oneOf("brotli", "gzip", "off"),
+	},
+	{
+		Name:        "RESPONSE_COMPRESSION_LEVEL",
+		Kind:        KindInt,
+		Default:     "5",
+		Description: "gzip level and Brotli quality, from 1 (fastest) to 9 (smallest)",
+		Validate:    intBetween(1, 9),
+	},
+	{
+		Name:        "RESPONSE_COMPRESSION_MIN_SIZE",
+		Kind:        KindInt,
+		Default:     "1024",
+		Description: "Responses smaller than this many bytes are sent uncompressed",
+		Validate:    nonNegativeInt,
+	},
+	{
+		Name:        "BODY_LIMIT_AUTH",
+		Kind:        KindInt,
+		Default:     "16384",
+		Description: "Largest request body in bytes accepted by the signup, sign-in, token and password reset endpoints",
+		Validate:    positiveInt,
+	},
+	{
+		Name:        "BODY_LIMIT_DEFAULT",
+		Kind:        KindInt,
+		Default:     "1048576",
+		Description: "Largest request body in bytes accepted by the other API endpoints",
+		Validate:    positiveInt,
+	},
+	{
+		Name:        "DEEPGRAM_MONTHLY_BUDGET",
+		Kind:        KindFloat,
+		Default:     "0",
+		Description: "Estimated Deepgram spend per calendar month (UTC) after which new sessions are refused; 0 disables",
+		Validate:    nonNegativeFloat,
+		Runtime:     true,
+	},
+	{
+		Name:        "DEEPGRAM_COST_PER_MINUTE",
+		Kind:        KindFloat,
+		Default:     "0.0043",
+		Description: "Deepgram price per streamed minute, used to estimate spend against DEEPGRAM_MONTHLY_BUDGET",
+		Validate:    nonNegativeFloat,
+		Runtime:     true,
+	},
+	{
+		Name:        "BUDGET_ALERT_WEBHOOK_URL",
+		Kind:        KindString,
+		Secret:      true,
+		Description: "Webhook (Slack-compatible) notified when DEEPGRAM_MONTHLY_BUDGET is reached or a usage alert threshold is crossed",
+		Validate:    optional(absoluteURL),
+	},
+	{
+		Name:        "DEEPGRAM_ALERT_BUDGET",
+		Kind:        KindFloat,
+		Default:     "0",
+		Description: "Estimated Deepgram spend per calendar month (UTC) that usage alerts are measured against; 0 uses DEEPGRAM_MONTHLY_BUDGET",
+		Validate:    nonNegativeFloat,
+		Runtime:     true,
+	},
+	{
+		Name:        "DEEPGRAM_ALERT_THRESHOLDS",
+		Kind:        KindString,
+		Default:     "50,80,100",
+		Description: "Comma-separated percentages of DEEPGRAM_ALERT_BUDGET at which admins are alerted, once per month each; \"none\" disables",
+		Validate:    optional(percentList),
+		Runtime:     true,
+	},
+	{
+		Name:        "METRICS_TOKEN",
+		Kind:        KindString,
+		Secret:      true,
+		Description: "Bearer token required to scrape /api/v1/metrics/usage; empty disables the endpoint",
+		Validate:    optional(minLength(16)),
+	},
+	{
+		Name:        "USAGE_PRICE_PER_MINUTE",
+		Kind:        KindFloat,
+		Default:     "0",
+		Description: "Price charged per minute of streamed audio on usage statements",
+		Validate:    nonNegativeFloat,
+		Runtime:     true,
+	},
+	{
+		Name:        "STATEMENT_CURRENCY",
+		Kind:        KindString,
+		Default:     "USD",
+		Description: "ISO 4217 currency code printed on usage statements",
+		Validate:    minLength(3),
+	},
+	{
+		Name:        "STATEMENT_ISSUER",
+		Kind:        KindString,
+		Default:     "HyperWhisper",
+		Description: "Company name printed at the top of usage statements",
+	},
+	{
+		Name:        "EXPORT_DIR",
+		Kind:        KindString,
+		Default:     "exports",
+		Description: "Directory generated export files are written to until they expire",
+		Validate:    minLength(1),
+	},
+	{
+		Name:        "EXPORT_TTL",
+		Kind:        KindDuration,
+		Default:     "24h",
+		Description: "How long a finished export can be downloaded before its file is deleted",
+		Validate:    positiveDuration,
+	},
+	{
+		Name:        "ACCOUNT_DELETION_GRACE_DAYS",
+		Kind:        KindInt,
+		Default:     "14",
+		Description: "Days between a user asking to delete their account and its deletion, during which they can cancel",
+		Validate:    nonNegativeInt,
+	},
+}
+
+// optional skips validation for empty values
+func optional(fn func(string) error) func(string) error {
+	return func(value string) error {
+		if value == "" {
+			return nil
+		}
+		return fn(value)
+	}
+}
+
+func oneOf(allowed ...string) func(string) error {
+	return func(value string) error {
+		for _, a := range allowed {
+			if value == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %v, got %q", allowed, value)
+	}
+}
+
+// listOf accepts a comma-separated list of allowed values
+func listOf(allowed ...string) func(string) error {
+	check := oneOf(allowed...)
+	return func(value string) error {
+		for _, part := range strings.Split(value, ",") {
+			if err := check(strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func minLength(n int) func(string) error {
+	return func(value string) error {
+		if len(value) < n {
+			return fmt.Errorf("must be at least %d characters", n)
+		}
+		return nil
+	}
+}
+
+func positiveInt(value string) error {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	if v <= 0 {
+		return errors.New("must be positive")
+	}
+	return nil
+}
+
+func intBetween(min, max int) func(string) error {
+	return func(value string) error {
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		if v < min || v > max {
+			return fmt.Errorf("must be between %d and %d", min, max)
+		}
+		return nil
+	}
+}
+
+func nonNegativeInt(value string) error {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	if v < 0 {
+		return errors.New("must not be negative")
+	}
+	return nil
+}
+
+func nonNegativeFloat(value string) error {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return err
+	}
+	if v < 0 {
+		return errors.New("must not be negative")
+	}
+	return nil
+}
+
+// percentList accepts a comma-separated list of positive percentages
+func percentList(value string) error {
+	if value == "none" {
+		return nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		if err := positiveInt(strings.TrimSpace(part)); err != nil {
+			return fmt.Errorf("must be comma-separated positive percentages: %w", err)
+		}
+	}
+	return nil
+}
+
+// originList accepts a comma-separated list of origins, e.g.
+// https://example.com
+func originList(value string) error {
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		u, err := url.Parse(part)
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+			return fmt.Errorf("must be comma-separated origins like https://example.com, got %q", part)
+		}
+	}
+	return nil
+}
+
+func cidrList(value string) error {
+	if value == "none" {
+		return nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if _, err := netip.ParsePrefix(part); err == nil {
+			continue
+		}
+		if _, err := netip.ParseAddr(part); err != nil {
+			return fmt.Errorf("must be comma-separated CIDRs or IP addresses, got %q", part)
+		}
+	}
+	return nil
+}
+
+func nonNegativeDuration(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if d < 0 {
+		return errors.New("must not be negative")
+	}
+	return nil
+}
+
+func positiveDuration(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if d <= 0 {
+		return errors.New("must be positive")
+	}
+	return nil
+}
+
+func base64Key(value string) error {
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return errors.New("must be base64 encoded")
+	}
+	if len(key) != 32 {
+		return fmt.Errorf("must decode to 32 bytes, got %d", len(key))
+	}
+	return nil
+}
+
+func jsonObject(value string) error {
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &obj); err != nil || obj == nil {
+		return errors.New("must be a JSON object")
+	}
+	return nil
+}
+
+// postgresDSN accepts postgres:// URLs and key=value connection strings.
+// Other databases are refused up front: the schema relies on PostgreSQL
+// partitioning, arrays and advisory locks.
+func postgresDSN(value string) error {
+	scheme, _, ok := strings.Cut(value, "://")
+	if !ok || scheme == "postgres" || scheme == "postgresql" {
+		return nil
+	}
+	return fmt.Errorf("only PostgreSQL is supported, got a %s:// URL", scheme)
+}
+
+func absoluteURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("must be an absolute URL, got %q", value)
+	}
+	return nil
+}
+
+func redisURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Host == "" {
+		return errors.New("must be a redis:// or rediss:// URL")
+	}
+	return nil
+}
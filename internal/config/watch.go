@@ -0,0 +1,105 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	listenersMu sync.Mutex
+	listeners   []func(name, previous string)
+)
+
+// OnChange registers a callback invoked with the setting name and its
+// previous value whenever a file-backed or secret store setting changes
+func OnChange(fn func(name, previous string)) {
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	listeners = append(listeners, fn)
+}
+
+func notify(name, previous string) {
+	listenersMu.Lock()
+	fns := append([]func(string, string){}, listeners...)
+	listenersMu.Unlock()
+
+	for _, fn := range fns {
+		fn(name, previous)
+	}
+}
+
+// WatchFiles polls every file-backed (*_FILE) setting and reloads it when the
+// file changes, so rotated secrets mounted by Docker or Kubernetes take effect
+// without a restart. Values that fail validation are ignored and the previous
+// value is kept. It returns when ctx is cancelled.
+func WatchFiles(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloadFiles()
+		}
+	}
+}
+
+func reloadFiles() {
+	mu.RLock()
+	var fileEntries []Entry
+	for _, e := range values {
+		if e.File != "" {
+			fileEntries = append(fileEntries, e)
+		}
+	}
+	dev := devMode
+	mu.RUnlock()
+
+	for _, old := range fileEntries {
+		info, err := os.Stat(old.File)
+		if err != nil {
+			log.Printf("[Config] Cannot stat %s_FILE: %v", old.Name, err)
+			continue
+		}
+		// Kubernetes swaps secret volumes via symlinks, which may keep the
+		// same mtime on the target, so compare contents as well
+		if info.ModTime().Equal(old.modTime) {
+			data, err := os.ReadFile(old.File)
+			if err != nil || bytes.Equal(bytes.TrimSpace(data), []byte(old.Value)) {
+				continue
+			}
+		}
+
+		updated := old
+		if err := updated.readFile(); err != nil {
+			log.Printf("[Config] Failed to reload %s: %v", old.Name, err)
+			continue
+		}
+		if updated.Value == old.Value {
+			mu.Lock()
+			if cur, ok := values[old.Name]; ok && cur.File == old.File {
+				cur.modTime = updated.modTime
+				values[old.Name] = cur
+			}
+			mu.Unlock()
+			continue
+		}
+		if err := validate(updated, dev); err != nil {
+			log.Printf("[Config] Ignoring new value for %s: %v", old.Name, err)
+			continue
+		}
+
+		mu.Lock()
+		values[old.Name] = updated
+		mu.Unlock()
+
+		log.Printf("[Config] Reloaded %s from %s", old.Name, old.File)
+		notify(old.Name, old.Value)
+	}
+}
@@ -0,0 +1,237 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"hyperwhisper/internal/db/sqlc"
+)
+
+// backupBatchSize bounds how many rows are read or restored per query
+const backupBatchSize = 1000
+
+// backupSkippedTables are never backed up: access logs expire after
+// ACCESS_LOG_RETENTION_DAYS and IP sightings only matter for an hour
+var backupSkippedTables = []string{"access_logs", "api_key_ip_sightings"}
+
+// BackupLogTables hold raw usage logs and what hangs off them. Backups leave
+// them out unless asked to include them.
+var BackupLogTables = []string{"client_error_reports", "session_transcripts", "transcription_logs", "trial_usage"}
+
+// SchemaVersion returns the migration version of the database. Backups
+// only restore into a database at the version they were taken at.
+func SchemaVersion(ctx context.Context) (int64, error) {
+	if DB == nil {
+		return 0, sql.ErrConnDone
+	}
+	v, err := sqlc.New(DB).GetSchemaVersion(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if v.Dirty {
+		return 0, fmt.Errorf("schema version %d is dirty, fix the failed migration first", v.Version)
+	}
+	return v.Version, nil
+}
+
+// CountUsers counts the users of the database, which a restore would
+// replace
+func CountUsers(ctx context.Context) (int64, error) {
+	if DB == nil {
+		return 0, sql.ErrConnDone
+	}
+	return sqlc.New(DB).CountUsers(ctx)
+}
+
+// BackupTables returns the tables a backup holds, every table after the
+// tables it references so they can be restored in order
+func BackupTables(ctx context.Context, includeLogs bool) ([]string, error) {
+	if DB == nil {
+		return nil, sql.ErrConnDone
+	}
+
+	queries := sqlc.New(DB)
+	all, err := queries.ListBackupTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var tables []string
+	for _, table := range all {
+		if slices.Contains(backupSkippedTables, table) || (!includeLogs && slices.Contains(BackupLogTables, table)) {
+			continue
+		}
+		tables = append(tables, table)
+	}
+
+	deps, err := queries.ListForeignKeyDependencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	references := make(map[string][]string)
+	for _, d := range deps {
+		if slices.Contains(tables, d.TableName) && slices.Contains(tables, d.ReferencedTable) {
+			references[d.TableName] = append(references[d.TableName], d.ReferencedTable)
+		}
+	}
+
+	// Take the first table, alphabetically, whose references are all placed
+	ordered := make([]string, 0, len(tables))
+	for len(ordered) < len(tables) {
+		next := ""
+		for _, table := range tables {
+			if slices.Contains(ordered, table) {
+				continue
+			}
+			ready := true
+			for _, ref := range references[table] {
+				if !slices.Contains(ordered, ref) {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = table
+				break
+			}
+		}
+		if next == "" {
+			return nil, errors.New("tables reference each other in a cycle")
+		}
+		ordered = append(ordered, next)
+	}
+	return ordered, nil
+}
+
+// DumpTables calls emit with every row of tables, as JSON objects keyed by
+// column. All tables are read in one snapshot, so the rows are consistent
+// with each other while the server keeps running.
+func DumpTables(ctx context.Context, tables []string, emit func(table string, row json.RawMessage) error) error {
+	if DB == nil {
+		return sql.ErrConnDone
+	}
+
+	tx, err := DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := sqlc.New(DB).WithTx(tx)
+
+	for _, table := range tables {
+		afterTable, afterCtid := int64(0), "(0,0)"
+		for {
+			rows, err := queries.BackupTableRows(ctx, sqlc.BackupTableRowsParams{
+				TableName:  table,
+				AfterTable: afterTable,
+				AfterCtid:  afterCtid,
+				BatchSize:  backupBatchSize,
+			})
+			if err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+			for _, r := range rows {
+				if err := emit(table, r.RowData); err != nil {
+					return err
+				}
+				afterTable, afterCtid = r.TableOid, r.RowCtid
+			}
+			if len(rows) < backupBatchSize {
+				break
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// Restore replaces the rows of backed-up tables in a single transaction, so
+// a failed restore leaves the database as it was
+type Restore struct {
+	tx      *sql.Tx
+	queries *sqlc.Queries
+	tables  []string
+	// oldestLog is the oldest started_at restored into each usage log
+	// table, whose months need partitions
+	oldestLog map[string]time.Time
+}
+
+// BeginRestore empties tables for a restore. TRUNCATE cascades, so tables
+// referencing them are emptied too, including logs the backup left out.
+func BeginRestore(ctx context.Context, tables []string) (*Restore, error) {
+	if DB == nil {
+		return nil, sql.ErrConnDone
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	queries := sqlc.New(DB).WithTx(tx)
+	if err := queries.TruncateTables(ctx, tables); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return &Restore{tx: tx, queries: queries, tables: tables, oldestLog: make(map[string]time.Time)}, nil
+}
+
+// Insert restores a batch of rows of table, as DumpTables emitted them
+func (r *Restore) Insert(ctx context.Context, table string, rows []json.RawMessage) error {
+	if !slices.Contains(r.tables, table) {
+		return fmt.Errorf("%s is not a table of this restore", table)
+	}
+	if slices.Contains(UsageLogTables, table) {
+		for _, row := range rows {
+			var entry struct {
+				StartedAt time.Time `json:"started_at"`
+			}
+			if err := json.Unmarshal(row, &entry); err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+			if oldest, ok := r.oldestLog[table]; !ok || entry.StartedAt.Before(oldest) {
+				r.oldestLog[table] = entry.StartedAt
+			}
+		}
+	}
+
+	payload, err := json.Marshal(rows)
+	if err != nil {
+		return err
+	}
+	if err := r.queries.RestoreTableRows(ctx, sqlc.RestoreTableRowsParams{
+		TableName: table,
+		Rows:      payload,
+	}); err != nil {
+		return fmt.Errorf("%s: %w", table, err)
+	}
+	return nil
+}
+
+// Commit moves restored usage logs from the default partition into monthly
+// ones, moves sequences past the restored IDs and commits the restore
+func (r *Restore) Commit(ctx context.Context) error {
+	now := time.Now().UTC()
+	for table, oldest := range r.oldestLog {
+		if err := r.queries.EnsureMonthlyPartitions(ctx, sqlc.EnsureMonthlyPartitionsParams{
+			Parent:     table,
+			FirstMonth: oldest,
+			LastMonth:  now.AddDate(0, 1, 0),
+		}); err != nil {
+			return fmt.Errorf("%s: %w", table, err)
+		}
+	}
+	for _, table := range r.tables {
+		if err := r.queries.ResetTableSequences(ctx, table); err != nil {
+			return fmt.Errorf("%s: %w", table, err)
+		}
+	}
+	return r.tx.Commit()
+}
+
+// Rollback abandons the restore
+func (r *Restore) Rollback() error {
+	return r.tx.Rollback()
+}
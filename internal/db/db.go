@@ -3,40 +3,46 @@ package db
 import (
 	"context"
 	"database/sql"
-	"os"
 	"time"
 
+	"hyperwhisper/internal/config"
+
 	_ "github.com/lib/pq"
 )
 
 var DB *sql.DB
 
 func Connect() error {
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
-		dsn = "postgres://localhost:5432/hyperwhisper?sslmode=disable"
-	}
-
 	var err error
-	DB, err = sql.Open("postgres", dsn)
+	DB, err = sql.Open("postgres", config.String("DATABASE_URL"))
 	if err != nil {
 		return err
 	}
 
-	DB.SetMaxOpenConns(25)
-	DB.SetMaxIdleConns(5)
-	DB.SetConnMaxLifetime(5 * time.Minute)
+	configurePool()
 
 	return nil
 }
 
+// Ping checks that the database is reachable and records the result in the
+// availability reported by CurrentStatus. When the database just came back,
+// the OnReconnect hooks run in the background.
 func Ping() error {
 	if DB == nil {
 		return sql.ErrConnDone
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
-	return DB.PingContext(ctx)
+	err := DB.PingContext(ctx)
+
+	if hooks := recordPing(err); len(hooks) > 0 {
+		go func() {
+			for _, hook := range hooks {
+				hook(context.Background())
+			}
+		}()
+	}
+	return err
 }
 
 func Close() error {
@@ -0,0 +1,423 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/encryption"
+)
+
+// reencryptBatchSize bounds how many rows are rewritten per query
+const reencryptBatchSize = 500
+
+// InitEncryption unwraps the column encryption keys with the master key and
+// installs them. The first run creates the initial data and index keys.
+func InitEncryption(ctx context.Context) error {
+	if DB == nil {
+		return sql.ErrConnDone
+	}
+
+	master, err := encryption.NewMasterKey()
+	if err != nil {
+		return err
+	}
+
+	queries := sqlc.New(DB)
+	stored, err := queries.ListEncryptionKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load encryption keys: %w", err)
+	}
+
+	hasData, hasIndex := false, false
+	for _, k := range stored {
+		switch encryption.Purpose(k.Purpose) {
+		case encryption.PurposeData:
+			hasData = hasData || !k.RetiredAt.Valid
+		case encryption.PurposeIndex:
+			hasIndex = true
+		}
+	}
+
+	if !hasData {
+		k, err := createKey(ctx, queries, master, encryption.PurposeData)
+		if err != nil {
+			return err
+		}
+		stored = append(stored, k)
+	}
+	if !hasIndex {
+		k, err := createKey(ctx, queries, master, encryption.PurposeIndex)
+		if err != nil {
+			return err
+		}
+		stored = append(stored, k)
+	}
+
+	keys := make([]encryption.Key, 0, len(stored))
+	for _, k := range stored {
+		material, err := master.Unwrap(ctx, k.MasterKeyID, k.WrappedKey)
+		if err != nil {
+			return fmt.Errorf("failed to unwrap encryption key %d: %w", k.ID, err)
+		}
+		keys = append(keys, encryption.Key{
+			ID:       k.ID,
+			Purpose:  encryption.Purpose(k.Purpose),
+			Material: material,
+			Retired:  k.RetiredAt.Valid,
+		})
+	}
+
+	return encryption.Install(keys)
+}
+
+func createKey(ctx context.Context, queries *sqlc.Queries, master encryption.MasterKey, purpose encryption.Purpose) (sqlc.EncryptionKey, error) {
+	material, err := encryption.GenerateKey()
+	if err != nil {
+		return sqlc.EncryptionKey{}, err
+	}
+	wrapped, err := master.Wrap(ctx, material)
+	if err != nil {
+		return sqlc.EncryptionKey{}, fmt.Errorf("failed to wrap new %s key: %w", purpose, err)
+	}
+
+	k, err := queries.CreateEncryptionKey(ctx, sqlc.CreateEncryptionKeyParams{
+		Purpose:     string(purpose),
+		WrappedKey:  wrapped,
+		MasterKeyID: master.ID(),
+	})
+	if err != nil {
+		return sqlc.EncryptionKey{}, fmt.Errorf("failed to store new %s key: %w", purpose, err)
+	}
+
+	log.Printf("[Encryption] Created %s key %d (master key %s)", purpose, k.ID, master.ID())
+	return k, nil
+}
+
+// RotateEncryptionKeys creates a new primary data key, retires the old ones
+// and rewraps every key with the current master key (which completes a
+// master key rotation). Existing values are re-encrypted by Reencrypt.
+func RotateEncryptionKeys(ctx context.Context) error {
+	if err := InitEncryption(ctx); err != nil {
+		return err
+	}
+
+	master, err := encryption.NewMasterKey()
+	if err != nil {
+		return err
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := sqlc.New(DB).WithTx(tx)
+
+	stored, err := queries.ListEncryptionKeys(ctx)
+	if err != nil {
+		return err
+	}
+	for _, k := range stored {
+		if k.MasterKeyID == master.ID() {
+			continue
+		}
+		material, err := master.Unwrap(ctx, k.MasterKeyID, k.WrappedKey)
+		if err != nil {
+			return fmt.Errorf("failed to unwrap encryption key %d: %w", k.ID, err)
+		}
+		wrapped, err := master.Wrap(ctx, material)
+		if err != nil {
+			return fmt.Errorf("failed to rewrap encryption key %d: %w", k.ID, err)
+		}
+		err = queries.UpdateEncryptionKeyWrapping(ctx, sqlc.UpdateEncryptionKeyWrappingParams{
+			ID:          k.ID,
+			WrappedKey:  wrapped,
+			MasterKeyID: master.ID(),
+		})
+		if err != nil {
+			return err
+		}
+		log.Printf("[Encryption] Rewrapped key %d with master key %s", k.ID, master.ID())
+	}
+
+	created, err := createKey(ctx, queries, master, encryption.PurposeData)
+	if err != nil {
+		return err
+	}
+	if err := queries.RetireDataKeys(ctx, created.ID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return InitEncryption(ctx)
+}
+
+// ReencryptStats counts the values rewritten by Reencrypt
+type ReencryptStats struct {
+	Fingerprints     int
+	TranscriptionIPs int
+	TrialUsageIPs    int
+	Transcripts      int
+	APIKeyIPs        int
+	RefreshTokenIPs  int
+	LoginEventIPs    int
+	AuthEventIPs     int
+	TenantAPIKeys    int
+	AccessLogIPs     int
+}
+
+// Reencrypt rewrites values that are still plaintext or encrypted with a
+// retired data key, and backfills missing fingerprint blind indexes
+func Reencrypt(ctx context.Context) (ReencryptStats, error) {
+	var stats ReencryptStats
+
+	prefix, err := encryption.PrimaryPrefix()
+	if err != nil {
+		return stats, err
+	}
+	queries := sqlc.New(DB)
+
+	for {
+		rows, err := queries.ListTrialFingerprintsToReencrypt(ctx, sqlc.ListTrialFingerprintsToReencryptParams{
+			KeyPrefix: prefix,
+			BatchSize: reencryptBatchSize,
+		})
+		if err != nil {
+			return stats, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			hash, err := encryption.BlindIndex(string(row.DeviceFingerprint))
+			if err != nil {
+				return stats, err
+			}
+			err = queries.UpdateTrialFingerprint(ctx, sqlc.UpdateTrialFingerprintParams{
+				ID:                    row.ID,
+				DeviceFingerprint:     row.DeviceFingerprint,
+				DeviceFingerprintHash: sql.NullString{String: hash, Valid: true},
+			})
+			if err != nil {
+				return stats, fmt.Errorf("trial key %s: %w", row.ID, err)
+			}
+			stats.Fingerprints++
+		}
+	}
+
+	for {
+		rows, err := queries.ListTranscriptionLogIPsToReencrypt(ctx, sqlc.ListTranscriptionLogIPsToReencryptParams{
+			KeyPrefix: prefix,
+			BatchSize: reencryptBatchSize,
+		})
+		if err != nil {
+			return stats, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			err := queries.UpdateTranscriptionLogIP(ctx, sqlc.UpdateTranscriptionLogIPParams{
+				ID:       row.ID,
+				ClientIp: row.ClientIp,
+			})
+			if err != nil {
+				return stats, fmt.Errorf("transcription log %s: %w", row.ID, err)
+			}
+			stats.TranscriptionIPs++
+		}
+	}
+
+	for {
+		rows, err := queries.ListTrialUsageIPsToReencrypt(ctx, sqlc.ListTrialUsageIPsToReencryptParams{
+			KeyPrefix: prefix,
+			BatchSize: reencryptBatchSize,
+		})
+		if err != nil {
+			return stats, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			err := queries.UpdateTrialUsageIP(ctx, sqlc.UpdateTrialUsageIPParams{
+				ID:       row.ID,
+				ClientIp: row.ClientIp,
+			})
+			if err != nil {
+				return stats, fmt.Errorf("trial usage %s: %w", row.ID, err)
+			}
+			stats.TrialUsageIPs++
+		}
+	}
+
+	for {
+		rows, err := queries.ListSessionTranscriptsToReencrypt(ctx, sqlc.ListSessionTranscriptsToReencryptParams{
+			KeyPrefix: prefix,
+			BatchSize: reencryptBatchSize,
+		})
+		if err != nil {
+			return stats, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			err := queries.UpdateSessionTranscript(ctx, sqlc.UpdateSessionTranscriptParams{
+				TranscriptionLogID: row.TranscriptionLogID,
+				Transcript:         row.Transcript,
+			})
+			if err != nil {
+				return stats, fmt.Errorf("session transcript %s: %w", row.TranscriptionLogID, err)
+			}
+			stats.Transcripts++
+		}
+	}
+
+	for {
+		rows, err := queries.ListAPIKeyIPsToReencrypt(ctx, sqlc.ListAPIKeyIPsToReencryptParams{
+			KeyPrefix: prefix,
+			BatchSize: reencryptBatchSize,
+		})
+		if err != nil {
+			return stats, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			err := queries.UpdateAPIKeyIP(ctx, sqlc.UpdateAPIKeyIPParams{
+				ID:         row.ID,
+				LastUsedIp: row.LastUsedIp,
+			})
+			if err != nil {
+				return stats, fmt.Errorf("api key %s: %w", row.ID, err)
+			}
+			stats.APIKeyIPs++
+		}
+	}
+
+	for {
+		rows, err := queries.ListRefreshTokenIPsToReencrypt(ctx, sqlc.ListRefreshTokenIPsToReencryptParams{
+			KeyPrefix: prefix,
+			BatchSize: reencryptBatchSize,
+		})
+		if err != nil {
+			return stats, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			err := queries.UpdateRefreshTokenIP(ctx, sqlc.UpdateRefreshTokenIPParams{
+				ID:       row.ID,
+				ClientIp: row.ClientIp,
+			})
+			if err != nil {
+				return stats, fmt.Errorf("refresh token %s: %w", row.ID, err)
+			}
+			stats.RefreshTokenIPs++
+		}
+	}
+
+	for {
+		rows, err := queries.ListLoginEventIPsToReencrypt(ctx, sqlc.ListLoginEventIPsToReencryptParams{
+			KeyPrefix: prefix,
+			BatchSize: reencryptBatchSize,
+		})
+		if err != nil {
+			return stats, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			err := queries.UpdateLoginEventIP(ctx, sqlc.UpdateLoginEventIPParams{
+				ID:       row.ID,
+				ClientIp: row.ClientIp,
+			})
+			if err != nil {
+				return stats, fmt.Errorf("login event %s: %w", row.ID, err)
+			}
+			stats.LoginEventIPs++
+		}
+	}
+
+	for {
+		rows, err := queries.ListAuthEventIPsToReencrypt(ctx, sqlc.ListAuthEventIPsToReencryptParams{
+			KeyPrefix: prefix,
+			BatchSize: reencryptBatchSize,
+		})
+		if err != nil {
+			return stats, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			err := queries.UpdateAuthEventIP(ctx, sqlc.UpdateAuthEventIPParams{
+				ID:       row.ID,
+				ClientIp: row.ClientIp,
+			})
+			if err != nil {
+				return stats, fmt.Errorf("auth event %s: %w", row.ID, err)
+			}
+			stats.AuthEventIPs++
+		}
+	}
+
+	for {
+		rows, err := queries.ListTenantDeepgramKeysToReencrypt(ctx, sqlc.ListTenantDeepgramKeysToReencryptParams{
+			KeyPrefix: prefix,
+			BatchSize: reencryptBatchSize,
+		})
+		if err != nil {
+			return stats, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			err := queries.UpdateTenantDeepgramKey(ctx, sqlc.UpdateTenantDeepgramKeyParams{
+				ID:             row.ID,
+				DeepgramApiKey: row.DeepgramApiKey,
+			})
+			if err != nil {
+				return stats, fmt.Errorf("tenant %s: %w", row.ID, err)
+			}
+			stats.TenantAPIKeys++
+		}
+	}
+
+	for {
+		rows, err := queries.ListAccessLogIPsToReencrypt(ctx, sqlc.ListAccessLogIPsToReencryptParams{
+			KeyPrefix: prefix,
+			BatchSize: reencryptBatchSize,
+		})
+		if err != nil {
+			return stats, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			err := queries.UpdateAccessLogIP(ctx, sqlc.UpdateAccessLogIPParams{
+				ID:        row.ID,
+				CreatedAt: row.CreatedAt,
+				ClientIp:  row.ClientIp,
+			})
+			if err != nil {
+				return stats, fmt.Errorf("access log %s: %w", row.ID, err)
+			}
+			stats.AccessLogIPs++
+		}
+	}
+
+	return stats, nil
+}
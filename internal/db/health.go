@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"hyperwhisper/internal/config"
+)
+
+// Status is the database's availability as last seen by a ping
+type Status struct {
+	Available bool
+	Since     time.Time // When the database last came up or went down
+	Err       error     // Why the last ping failed, while unavailable
+}
+
+// availability tracks Status; recovered is closed and replaced whenever the
+// database comes back, waking WaitAvailable callers
+type availability struct {
+	mu          sync.Mutex
+	status      Status
+	recovered   chan struct{}
+	onReconnect []func(ctx context.Context)
+}
+
+var health = &availability{
+	status:    Status{Available: true, Since: time.Now()},
+	recovered: make(chan struct{}),
+}
+
+// CurrentStatus returns the database's availability
+func CurrentStatus() Status {
+	health.mu.Lock()
+	defer health.mu.Unlock()
+	return health.status
+}
+
+// Available reports whether the last ping reached the database
+func Available() bool {
+	return CurrentStatus().Available
+}
+
+// WaitAvailable waits up to timeout for the database to come back and
+// reports whether it did
+func WaitAvailable(ctx context.Context, timeout time.Duration) bool {
+	health.mu.Lock()
+	if health.status.Available {
+		health.mu.Unlock()
+		return true
+	}
+	recovered := health.recovered
+	health.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-recovered:
+		return true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return false
+}
+
+// OnReconnect registers fn to run each time the database comes back after
+// being unavailable, e.g. to finish startup work that needed it
+func OnReconnect(fn func(ctx context.Context)) {
+	health.mu.Lock()
+	health.onReconnect = append(health.onReconnect, fn)
+	health.mu.Unlock()
+}
+
+// recordPing updates the availability with a ping's result and returns the
+// reconnect hooks to run if the database just came back
+func recordPing(err error) []func(ctx context.Context) {
+	health.mu.Lock()
+	defer health.mu.Unlock()
+
+	wasAvailable := health.status.Available
+	if err != nil {
+		if wasAvailable {
+			log.Printf("[DB] Database unavailable, serving in degraded mode: %v", err)
+			health.status = Status{Since: time.Now()}
+		}
+		health.status.Err = err
+		return nil
+	}
+	if wasAvailable {
+		return nil
+	}
+
+	log.Printf("[DB] Database reachable again after %s", time.Since(health.status.Since).Round(time.Second))
+	health.status = Status{Available: true, Since: time.Now()}
+	close(health.recovered)
+	health.recovered = make(chan struct{})
+	return health.onReconnect
+}
+
+// RunHealthMonitor pings the database every DB_HEALTH_CHECK_INTERVAL. While
+// it is unavailable, pings back off exponentially up to
+// DB_RECONNECT_MAX_BACKOFF; the connection pool reconnects on its own once
+// a ping gets through. It runs until ctx is cancelled.
+func RunHealthMonitor(ctx context.Context) {
+	backoff := time.Second
+	for {
+		wait := config.Duration("DB_HEALTH_CHECK_INTERVAL")
+		if err := Ping(); err != nil {
+			wait = backoff
+			backoff = min(backoff*2, config.Duration("DB_RECONNECT_MAX_BACKOFF"))
+		} else {
+			backoff = time.Second
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
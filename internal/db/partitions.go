@@ -0,0 +1,323 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// partitionMaintenanceInterval is how often upcoming monthly partitions
+	// of the usage logs are created
+	partitionMaintenanceInterval = time.Hour
+	// exportBatchSize bounds how many rows are read per query when exporting
+	exportBatchSize = 5000
+)
+
+// UsageLogTables are the monthly-partitioned usage log tables, in the order
+// they are archived
+var UsageLogTables = []string{"transcription_logs", "trial_usage"}
+
+// RunPartitionMaintenance creates the current and next month's usage log
+// partitions and refreshes the current and previous month's usage rollups,
+// then repeats hourly until ctx is cancelled
+func RunPartitionMaintenance(ctx context.Context) {
+	ticker := time.NewTicker(partitionMaintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := EnsureUsagePartitions(ctx); err != nil {
+			log.Printf("[Partitions] Failed to create usage log partitions: %v", err)
+		}
+		if err := RollupRecentUsage(ctx); err != nil {
+			log.Printf("[Partitions] Failed to roll up usage: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// EnsureUsagePartitions creates the current and next month's partitions of
+// every usage log table
+func EnsureUsagePartitions(ctx context.Context) error {
+	if DB == nil {
+		return sql.ErrConnDone
+	}
+
+	queries := sqlc.New(DB)
+	now := time.Now().UTC()
+	for _, table := range UsageLogTables {
+		if err := queries.EnsureMonthlyPartitions(ctx, sqlc.EnsureMonthlyPartitionsParams{
+			Parent:     table,
+			FirstMonth: now,
+			LastMonth:  now.AddDate(0, 1, 0),
+		}); err != nil {
+			return fmt.Errorf("%s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// RollupRecentUsage refreshes the usage rollups of the current and previous
+// month, which are never archivable, of every usage log table
+func RollupRecentUsage(ctx context.Context) error {
+	now := time.Now().UTC()
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for _, table := range UsageLogTables {
+		for _, month := range []time.Time{current.AddDate(0, -1, 0), current} {
+			if err := RollupUsageMonth(ctx, table, month); err != nil {
+				return fmt.Errorf("%s %s: %w", table, month.Format("2006-01"), err)
+			}
+		}
+	}
+	return nil
+}
+
+// RollupUsageMonth recomputes the usage rollup of table for month (the
+// first day of a UTC month) from its logs. Run it before a month is
+// dropped, and never after.
+func RollupUsageMonth(ctx context.Context, table string, month time.Time) error {
+	if DB == nil {
+		return sql.ErrConnDone
+	}
+
+	queries := sqlc.New(DB)
+	start, end := month, month.AddDate(0, 1, 0)
+	switch table {
+	case "transcription_logs":
+		return queries.RollupTranscriptionLogsMonth(ctx, sqlc.RollupTranscriptionLogsMonthParams{
+			StartDate: start,
+			EndDate:   end,
+		})
+	case "trial_usage":
+		return queries.RollupTrialUsageMonth(ctx, sqlc.RollupTrialUsageMonthParams{
+			StartDate: start,
+			EndDate:   end,
+		})
+	}
+	return fmt.Errorf("%s is not a usage log table", table)
+}
+
+// PartitionedMonths returns the first day (UTC) of each month of table that
+// has its own partition, oldest first. Archived months have none.
+func PartitionedMonths(ctx context.Context, table string) ([]time.Time, error) {
+	if DB == nil {
+		return nil, sql.ErrConnDone
+	}
+
+	partitions, err := sqlc.New(DB).ListMonthlyPartitions(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	var months []time.Time
+	for _, name := range partitions {
+		month, err := time.Parse("2006_01", strings.TrimPrefix(name, table+"_"))
+		if err != nil {
+			continue
+		}
+		months = append(months, month)
+	}
+	return months, nil
+}
+
+// ArchivableMonths returns the first day (UTC) of each partitioned month of
+// table that lies entirely before the last keepMonths full months, oldest
+// first
+func ArchivableMonths(ctx context.Context, table string, keepMonths int, now time.Time) ([]time.Time, error) {
+	partitioned, err := PartitionedMonths(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	now = now.UTC()
+	cutoff := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -keepMonths, 0)
+
+	var months []time.Time
+	for _, month := range partitioned {
+		if month.Before(cutoff) {
+			months = append(months, month)
+		}
+	}
+	return months, nil
+}
+
+// UnexpiredTrialKeysWithUsage counts trial keys that have sessions in month
+// and have not expired. Their quota is computed from their lifetime usage,
+// so the month must stay in the database.
+func UnexpiredTrialKeysWithUsage(ctx context.Context, month time.Time) (int64, error) {
+	if DB == nil {
+		return 0, sql.ErrConnDone
+	}
+	return sqlc.New(DB).CountUnexpiredTrialKeysWithUsage(ctx, sqlc.CountUnexpiredTrialKeysWithUsageParams{
+		StartDate: month,
+		EndDate:   month.AddDate(0, 1, 0),
+	})
+}
+
+// archivedTranscriptionLog is one line of an archived transcription_logs
+// month. Client IPs keep their at-rest encryption.
+type archivedTranscriptionLog struct {
+	ID                 uuid.UUID       `json:"id"`
+	UserID             uuid.UUID       `json:"user_id"`
+	APIKeyID           uuid.UUID       `json:"api_key_id"`
+	StartedAt          time.Time       `json:"started_at"`
+	EndedAt            *time.Time      `json:"ended_at"`
+	DurationSeconds    *string         `json:"duration_seconds"`
+	Status             string          `json:"status"`
+	ErrorMessage       *string         `json:"error_message"`
+	DeepgramParams     json.RawMessage `json:"deepgram_params"`
+	BytesSent          int64           `json:"bytes_sent"`
+	ClientIPCiphertext *string         `json:"client_ip_ciphertext"`
+	DurationEstimated  bool            `json:"duration_estimated"`
+	RedactionAudit     json.RawMessage `json:"redaction_audit"`
+}
+
+// archivedTrialUsage is one line of an archived trial_usage month
+type archivedTrialUsage struct {
+	ID                 uuid.UUID       `json:"id"`
+	TrialKeyID         uuid.UUID       `json:"trial_key_id"`
+	StartedAt          time.Time       `json:"started_at"`
+	EndedAt            *time.Time      `json:"ended_at"`
+	DurationSeconds    *string         `json:"duration_seconds"`
+	Status             string          `json:"status"`
+	ErrorMessage       *string         `json:"error_message"`
+	DeepgramParams     json.RawMessage `json:"deepgram_params"`
+	BytesSent          int64           `json:"bytes_sent"`
+	ClientIPCiphertext *string         `json:"client_ip_ciphertext"`
+	DurationEstimated  bool            `json:"duration_estimated"`
+	RedactionAudit     json.RawMessage `json:"redaction_audit"`
+}
+
+// ExportUsageMonth writes every row of table in month to w as JSON Lines
+// and returns the number of rows written
+func ExportUsageMonth(ctx context.Context, table string, month time.Time, w io.Writer) (int, error) {
+	if DB == nil {
+		return 0, sql.ErrConnDone
+	}
+
+	queries := sqlc.New(DB)
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	start, end := month, month.AddDate(0, 1, 0)
+	afterStartedAt, afterID := start, uuid.Nil
+	total := 0
+
+	for {
+		var n int
+		switch table {
+		case "transcription_logs":
+			rows, err := queries.ExportTranscriptionLogs(ctx, sqlc.ExportTranscriptionLogsParams{
+				StartDate:      start,
+				EndDate:        end,
+				AfterStartedAt: afterStartedAt,
+				AfterID:        afterID,
+				BatchSize:      exportBatchSize,
+			})
+			if err != nil {
+				return total, err
+			}
+			for _, r := range rows {
+				if err := enc.Encode(archivedTranscriptionLog{
+					ID:                 r.ID,
+					UserID:             r.UserID,
+					APIKeyID:           r.ApiKeyID,
+					StartedAt:          r.StartedAt,
+					EndedAt:            nullTimePtr(r.EndedAt),
+					DurationSeconds:    nullStringPtr(r.DurationSeconds),
+					Status:             r.Status,
+					ErrorMessage:       nullStringPtr(r.ErrorMessage),
+					DeepgramParams:     r.DeepgramParams,
+					BytesSent:          r.BytesSent,
+					ClientIPCiphertext: nullStringPtr(r.ClientIpCiphertext),
+					DurationEstimated:  r.DurationEstimated,
+					RedactionAudit:     r.RedactionAudit,
+				}); err != nil {
+					return total, err
+				}
+				afterStartedAt, afterID = r.StartedAt, r.ID
+			}
+			n = len(rows)
+		case "trial_usage":
+			rows, err := queries.ExportTrialUsage(ctx, sqlc.ExportTrialUsageParams{
+				StartDate:      start,
+				EndDate:        end,
+				AfterStartedAt: afterStartedAt,
+				AfterID:        afterID,
+				BatchSize:      exportBatchSize,
+			})
+			if err != nil {
+				return total, err
+			}
+			for _, r := range rows {
+				if err := enc.Encode(archivedTrialUsage{
+					ID:                 r.ID,
+					TrialKeyID:         r.TrialKeyID,
+					StartedAt:          r.StartedAt,
+					EndedAt:            nullTimePtr(r.EndedAt),
+					DurationSeconds:    nullStringPtr(r.DurationSeconds),
+					Status:             r.Status,
+					ErrorMessage:       nullStringPtr(r.ErrorMessage),
+					DeepgramParams:     r.DeepgramParams,
+					BytesSent:          r.BytesSent,
+					ClientIPCiphertext: nullStringPtr(r.ClientIpCiphertext),
+					DurationEstimated:  r.DurationEstimated,
+					RedactionAudit:     r.RedactionAudit,
+				}); err != nil {
+					return total, err
+				}
+				afterStartedAt, afterID = r.StartedAt, r.ID
+			}
+			n = len(rows)
+		default:
+			return 0, fmt.Errorf("%s is not a usage log table", table)
+		}
+
+		total += n
+		if n < exportBatchSize {
+			break
+		}
+	}
+
+	return total, buf.Flush()
+}
+
+// DropUsageMonth drops the partition of table holding month. Only call it
+// once the month has been archived.
+func DropUsageMonth(ctx context.Context, table string, month time.Time) error {
+	if DB == nil {
+		return sql.ErrConnDone
+	}
+	return sqlc.New(DB).DropMonthlyPartition(ctx, sqlc.DropMonthlyPartitionParams{
+		Parent: table,
+		Month:  month,
+	})
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
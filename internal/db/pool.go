@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"hyperwhisper/internal/config"
+)
+
+// poolCheckInterval is how often connection pool waits are checked
+const poolCheckInterval = time.Minute
+
+// configurePool sizes the connection pool from the DB_* settings
+func configurePool() {
+	DB.SetMaxOpenConns(config.Int("DB_MAX_OPEN_CONNS"))
+	DB.SetMaxIdleConns(config.Int("DB_MAX_IDLE_CONNS"))
+	DB.SetConnMaxLifetime(config.Duration("DB_CONN_MAX_LIFETIME"))
+	DB.SetConnMaxIdleTime(config.Duration("DB_CONN_MAX_IDLE_TIME"))
+}
+
+// RunPoolMonitor logs a warning whenever queries waited for a free
+// connection longer than DB_POOL_WAIT_WARNING on average over the last
+// minute, which means the pool is too small for the load. It runs until ctx
+// is cancelled.
+func RunPoolMonitor(ctx context.Context) {
+	ticker := time.NewTicker(poolCheckInterval)
+	defer ticker.Stop()
+
+	last := DB.Stats()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stats := DB.Stats()
+		waits := stats.WaitCount - last.WaitCount
+		waited := stats.WaitDuration - last.WaitDuration
+		last = stats
+
+		threshold := config.Duration("DB_POOL_WAIT_WARNING")
+		if waits == 0 || threshold <= 0 {
+			continue
+		}
+		if average := waited / time.Duration(waits); average > threshold {
+			log.Printf("[DB] %d queries waited %s on average for a connection in the last %s (in use %d of %d); consider raising DB_MAX_OPEN_CONNS",
+				waits, average.Round(time.Millisecond), poolCheckInterval, stats.InUse, stats.MaxOpenConnections)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+-- =====================
+-- ACCESS LOG QUERIES
+-- =====================
+
+-- name: CreateAccessLog :exec
+INSERT INTO access_logs (created_at, method, route, path, status, latency_ms, user_id, client_ip, client_ip_hash, user_agent)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+
+-- name: ListAccessLogs :many
+SELECT l.*, u.username
+FROM access_logs l
+LEFT JOIN users u ON u.id = l.user_id
+WHERE l.created_at >= sqlc.arg(start_date) AND l.created_at < sqlc.arg(end_date)
+  AND (sqlc.narg(user_id)::uuid IS NULL OR l.user_id = sqlc.narg(user_id))
+  AND (sqlc.narg(client_ip_hash)::text IS NULL OR l.client_ip_hash = sqlc.narg(client_ip_hash))
+  AND (sqlc.narg(route)::text IS NULL OR l.route = sqlc.narg(route))
+  AND (sqlc.narg(method)::text IS NULL OR l.method = sqlc.narg(method))
+  AND l.status BETWEEN sqlc.arg(min_status) AND sqlc.arg(max_status)
+  AND l.latency_ms >= sqlc.arg(min_latency_ms)
+ORDER BY l.created_at DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: CountAccessLogs :one
+SELECT COUNT(*) FROM access_logs
+WHERE created_at >= sqlc.arg(start_date) AND created_at < sqlc.arg(end_date)
+  AND (sqlc.narg(user_id)::uuid IS NULL OR user_id = sqlc.narg(user_id))
+  AND (sqlc.narg(client_ip_hash)::text IS NULL OR client_ip_hash = sqlc.narg(client_ip_hash))
+  AND (sqlc.narg(route)::text IS NULL OR route = sqlc.narg(route))
+  AND (sqlc.narg(method)::text IS NULL OR method = sqlc.narg(method))
+  AND status BETWEEN sqlc.arg(min_status) AND sqlc.arg(max_status)
+  AND latency_ms >= sqlc.arg(min_latency_ms);
+
+-- name: MaintainAccessLogPartitions :exec
+SELECT maintain_access_log_partitions(sqlc.arg(retention_days)::int);
@@ -0,0 +1,63 @@
+-- ============================
+-- ACCOUNT DELETION QUERIES
+-- ============================
+
+-- name: ScheduleAccountDeletion :one
+-- Asking again keeps the date of the first request
+INSERT INTO account_deletions (user_id, delete_after)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+RETURNING *;
+
+-- name: GetAccountDeletion :one
+SELECT * FROM account_deletions WHERE user_id = $1;
+
+-- name: CancelAccountDeletion :execrows
+DELETE FROM account_deletions WHERE user_id = $1;
+
+-- name: CountSoleOwnedOrganizations :one
+-- Organizations the user is the last owner of
+SELECT COUNT(*) FROM organization_members m
+WHERE m.user_id = $1 AND m.role = 'owner'
+  AND NOT EXISTS (SELECT 1 FROM organization_members o WHERE o.organization_id = m.organization_id AND o.role = 'owner' AND o.user_id <> m.user_id);
+
+-- name: ListDueAccountDeletions :many
+-- Users who became the last owner of an organization since asking wait
+-- until it has another owner
+SELECT * FROM account_deletions d
+WHERE d.delete_after <= NOW()
+  AND NOT EXISTS (
+    SELECT 1 FROM organization_members m
+    WHERE m.user_id = d.user_id AND m.role = 'owner'
+      AND NOT EXISTS (SELECT 1 FROM organization_members o WHERE o.organization_id = m.organization_id AND o.role = 'owner' AND o.user_id <> m.user_id)
+  )
+ORDER BY d.delete_after
+LIMIT sqlc.arg(batch_size);
+
+-- name: ReassignOrganizationAPIKeys :execrows
+-- Hands the organization keys the user created to the organization's
+-- longest-standing other owner, or admin or member if it has none left, so
+-- they outlive the user. Keys of organizations without other members go
+-- with the user.
+UPDATE api_keys k
+SET user_id = (
+    SELECT o.user_id FROM organization_members o
+    WHERE o.organization_id = k.organization_id AND o.user_id <> k.user_id
+    ORDER BY CASE o.role WHEN 'owner' THEN 0 WHEN 'admin' THEN 1 ELSE 2 END, o.created_at, o.user_id
+    LIMIT 1
+)
+WHERE k.user_id = $1 AND k.organization_id IS NOT NULL
+  AND EXISTS (SELECT 1 FROM organization_members o WHERE o.organization_id = k.organization_id AND o.user_id <> k.user_id);
+
+-- name: DeleteScheduledUser :execrows
+-- Deletes the user only if its deletion is still due, so a request
+-- cancelled in the meantime is honored, and the user isn't the last owner
+-- of an organization
+DELETE FROM users
+WHERE id = $1
+  AND EXISTS (SELECT 1 FROM account_deletions d WHERE d.user_id = users.id AND d.delete_after <= NOW())
+  AND NOT EXISTS (
+    SELECT 1 FROM organization_members m
+    WHERE m.user_id = users.id AND m.role = 'owner'
+      AND NOT EXISTS (SELECT 1 FROM organization_members o WHERE o.organization_id = m.organization_id AND o.role = 'owner' AND o.user_id <> m.user_id)
+  );
@@ -0,0 +1,63 @@
+-- =================================
+-- API KEY INACTIVITY NOTICE QUERIES
+-- =================================
+
+-- name: ListAPIKeysDueInactivityWarning :many
+-- Active keys unused and not reactivated since idle_before without a
+-- pending notice: never warned, used or reactivated since their last
+-- warning, or restored by an admin after being revoked for inactivity
+SELECT k.* FROM api_keys k
+LEFT JOIN api_key_inactivity_notices n ON n.api_key_id = k.id
+WHERE k.revoked_at IS NULL
+    AND GREATEST(k.created_at, k.last_used_at, n.reactivated_at) < sqlc.arg(idle_before)::timestamptz
+    AND (n.api_key_id IS NULL
+        OR n.warned_at < GREATEST(k.created_at, k.last_used_at, n.reactivated_at)
+        OR (n.revoked_at IS NOT NULL AND n.warned_at < sqlc.arg(idle_before)))
+ORDER BY k.id
+LIMIT sqlc.arg(batch_size);
+
+-- name: CreateAPIKeyInactivityNotice :one
+-- Starts a new notice with a fresh reactivation link. Returns
+-- sql.ErrNoRows when another server warned about the key first.
+INSERT INTO api_key_inactivity_notices (api_key_id, token_hash)
+VALUES (sqlc.arg(api_key_id), sqlc.arg(token_hash))
+ON CONFLICT (api_key_id) DO UPDATE
+SET token_hash = EXCLUDED.token_hash, warned_at = NOW(), revoked_at = NULL
+WHERE api_key_inactivity_notices.warned_at < sqlc.arg(idle_before)
+RETURNING *;
+
+-- name: RevokeInactiveAPIKeys :many
+-- Revokes the keys whose notice was sent before warned_before and which
+-- were neither used nor reactivated since
+WITH due AS (
+    UPDATE api_key_inactivity_notices n
+    SET revoked_at = NOW()
+    FROM api_keys k
+    WHERE k.id = n.api_key_id
+        AND k.revoked_at IS NULL
+        AND n.revoked_at IS NULL
+        AND n.warned_at < sqlc.arg(warned_before)
+        AND n.warned_at >= GREATEST(k.created_at, k.last_used_at, n.reactivated_at)
+    RETURNING n.api_key_id
+)
+UPDATE api_keys SET revoked_at = NOW()
+WHERE id IN (SELECT api_key_id FROM due)
+RETURNING *;
+
+-- name: RenewAPIKeyInactivityToken :exec
+UPDATE api_key_inactivity_notices SET token_hash = $2 WHERE api_key_id = $1;
+
+-- name: ReactivateAPIKeyInactivityNotice :one
+-- No row if the token is unknown or its link was already used
+UPDATE api_key_inactivity_notices
+SET reactivated_at = NOW()
+WHERE token_hash = $1 AND (reactivated_at IS NULL OR reactivated_at < warned_at)
+RETURNING *;
+
+-- name: ListUserPendingAPIKeyInactivityNotices :many
+SELECT n.* FROM api_key_inactivity_notices n
+JOIN api_keys k ON k.id = n.api_key_id
+WHERE k.user_id = $1
+    AND k.revoked_at IS NULL
+    AND n.revoked_at IS NULL
+    AND n.warned_at >= GREATEST(k.created_at, k.last_used_at, n.reactivated_at);
@@ -0,0 +1,14 @@
+-- =====================
+-- API KEY LIMIT QUERIES
+-- =====================
+
+-- name: GetAPIKeyLimit :one
+SELECT * FROM api_key_limits WHERE user_id = $1;
+
+-- name: SetAPIKeyLimit :exec
+INSERT INTO api_key_limits (user_id, max_keys)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET max_keys = EXCLUDED.max_keys, updated_at = NOW();
+
+-- name: DeleteAPIKeyLimit :exec
+DELETE FROM api_key_limits WHERE user_id = $1;
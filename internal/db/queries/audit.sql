@@ -0,0 +1,22 @@
+-- ====================
+-- AUDIT EVENT QUERIES
+-- ====================
+
+-- name: CreateAuditEvent :one
+INSERT INTO audit_events (actor_user_id, action, target_type, target_id, reason, metadata)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListAuditEvents :many
+SELECT e.*, u.username AS actor_username
+FROM audit_events e
+LEFT JOIN users u ON u.id = e.actor_user_id
+WHERE (sqlc.narg(target_type)::text IS NULL OR e.target_type = sqlc.narg(target_type))
+  AND (sqlc.narg(target_id)::text IS NULL OR e.target_id = sqlc.narg(target_id))
+ORDER BY e.created_at DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: CountAuditEvents :one
+SELECT COUNT(*) FROM audit_events
+WHERE (sqlc.narg(target_type)::text IS NULL OR target_type = sqlc.narg(target_type))
+  AND (sqlc.narg(target_id)::text IS NULL OR target_id = sqlc.narg(target_id));
@@ -0,0 +1,32 @@
+-- =====================
+-- AUTH EVENT QUERIES
+-- =====================
+
+-- name: CreateAuthEvent :exec
+INSERT INTO auth_events (created_at, key_prefix, outcome, user_id, client_ip, client_ip_hash, user_agent)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: DeleteExpiredAuthEvents :exec
+DELETE FROM auth_events WHERE created_at < sqlc.arg(cutoff);
+
+-- name: ListAuthEvents :many
+SELECT e.*, u.username
+FROM auth_events e
+LEFT JOIN users u ON u.id = e.user_id
+WHERE e.created_at >= sqlc.arg(start_date) AND e.created_at < sqlc.arg(end_date)
+  AND (sqlc.narg(user_id)::uuid IS NULL OR e.user_id = sqlc.narg(user_id))
+  AND (sqlc.narg(client_ip_hash)::text IS NULL OR e.client_ip_hash = sqlc.narg(client_ip_hash))
+  AND (sqlc.narg(key_prefix)::text IS NULL OR e.key_prefix LIKE sqlc.narg(key_prefix) || '%')
+  AND (sqlc.narg(outcome)::text IS NULL OR e.outcome = sqlc.narg(outcome))
+  AND (sqlc.narg(succeeded)::boolean IS NULL OR (e.outcome = 'success') = sqlc.narg(succeeded))
+ORDER BY e.created_at DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: CountAuthEvents :one
+SELECT COUNT(*) FROM auth_events
+WHERE created_at >= sqlc.arg(start_date) AND created_at < sqlc.arg(end_date)
+  AND (sqlc.narg(user_id)::uuid IS NULL OR user_id = sqlc.narg(user_id))
+  AND (sqlc.narg(client_ip_hash)::text IS NULL OR client_ip_hash = sqlc.narg(client_ip_hash))
+  AND (sqlc.narg(key_prefix)::text IS NULL OR key_prefix LIKE sqlc.narg(key_prefix) || '%')
+  AND (sqlc.narg(outcome)::text IS NULL OR outcome = sqlc.narg(outcome))
+  AND (sqlc.narg(succeeded)::boolean IS NULL OR (outcome = 'success') = sqlc.narg(succeeded));
@@ -0,0 +1,40 @@
+-- =====================
+-- BACKUP QUERIES
+-- =====================
+
+-- name: GetSchemaVersion :one
+SELECT version, dirty FROM schema_migrations LIMIT 1;
+
+-- name: ListBackupTables :many
+-- Tables of the current schema a backup can hold; partitions are read
+-- through their parent
+SELECT c.relname::text AS table_name
+FROM pg_class c
+WHERE c.relnamespace = current_schema()::regnamespace
+  AND c.relkind IN ('r', 'p')
+  AND NOT c.relispartition
+  AND c.relname <> 'schema_migrations'
+ORDER BY c.relname;
+
+-- name: ListForeignKeyDependencies :many
+-- Which tables reference which, so backups can be restored in order
+SELECT src.relname::text AS table_name, dst.relname::text AS referenced_table
+FROM pg_constraint k
+JOIN pg_class src ON src.oid = k.conrelid
+JOIN pg_class dst ON dst.oid = k.confrelid
+WHERE k.contype = 'f'
+  AND k.connamespace = current_schema()::regnamespace
+  AND k.conrelid <> k.confrelid;
+
+-- name: BackupTableRows :many
+SELECT table_oid, row_ctid::text AS row_ctid, row_data
+FROM backup_table_rows(sqlc.arg(table_name)::text, sqlc.arg(after_table)::bigint, sqlc.arg(after_ctid)::tid, sqlc.arg(batch_size)::integer);
+
+-- name: TruncateTables :exec
+SELECT truncate_tables(sqlc.arg(table_names)::text[]);
+
+-- name: RestoreTableRows :exec
+SELECT restore_table_rows(sqlc.arg(table_name)::text, sqlc.arg(rows)::jsonb);
+
+-- name: ResetTableSequences :exec
+SELECT reset_table_sequences(sqlc.arg(table_name)::text);
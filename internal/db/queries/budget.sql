@@ -0,0 +1,12 @@
+-- ======================
+-- UPSTREAM BUDGET QUERIES
+-- ======================
+
+-- name: GetUpstreamUsageSeconds :one
+-- Seconds streamed to Deepgram by live and trial sessions in a period
+SELECT (
+    (SELECT COALESCE(SUM(duration_seconds), 0) FROM transcription_logs
+     WHERE transcription_logs.started_at >= sqlc.arg(start_date) AND transcription_logs.started_at < sqlc.arg(end_date)) +
+    (SELECT COALESCE(SUM(duration_seconds), 0) FROM trial_usage
+     WHERE trial_usage.started_at >= sqlc.arg(start_date) AND trial_usage.started_at < sqlc.arg(end_date))
+)::DECIMAL(14,3) AS total_duration_seconds;
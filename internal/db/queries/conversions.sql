@@ -0,0 +1,13 @@
+-- =====================
+-- TRIAL CONVERSION QUERIES
+-- =====================
+
+-- name: CreateTrialConversion :one
+-- Returns no rows if the trial key was already converted
+INSERT INTO trial_conversions (trial_key_id, user_id, carryover_seconds, bonus_seconds)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (trial_key_id) DO NOTHING
+RETURNING *;
+
+-- name: GetTrialConversionByKey :one
+SELECT * FROM trial_conversions WHERE trial_key_id = $1;
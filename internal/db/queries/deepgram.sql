@@ -8,36 +8,100 @@ VALUES ($1, $2, $3, $4)
 RETURNING *;
 
 -- name: GetAPIKeyByHash :one
-SELECT * FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL;
+-- Personal keys of suspended users don't resolve. Organization keys belong
+-- to the organization and outlive their creator's suspension.
+SELECT * FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL
+    AND (organization_id IS NOT NULL
+        OR NOT EXISTS (SELECT 1 FROM users u WHERE u.id = api_keys.user_id AND u.suspended_at IS NOT NULL));
 
 -- name: GetAPIKeyByID :one
 SELECT * FROM api_keys WHERE id = $1;
 
+-- name: GetRefusedAPIKeyByHash :one
+-- Why GetAPIKeyByHash didn't resolve a key: it's revoked or its user suspended
+SELECT k.user_id, k.revoked_at, u.suspended_at
+FROM api_keys k
+JOIN users u ON u.id = k.user_id
+WHERE k.key_hash = $1;
+
 -- name: ListUserAPIKeys :many
-SELECT * FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3;
+-- Personal keys only; organization keys are listed per organization
+SELECT * FROM api_keys WHERE user_id = $1 AND organization_id IS NULL ORDER BY created_at DESC LIMIT $2 OFFSET $3;
+
+-- name: ListUserAPIKeyIDs :many
+-- Every key the user owns, organization keys included
+SELECT id FROM api_keys WHERE user_id = $1;
 
 -- name: CountUserAPIKeys :one
-SELECT COUNT(*) FROM api_keys WHERE user_id = $1;
+SELECT COUNT(*) FROM api_keys WHERE user_id = $1 AND organization_id IS NULL;
 
 -- name: CountActiveUserAPIKeys :one
-SELECT COUNT(*) FROM api_keys WHERE user_id = $1 AND revoked_at IS NULL;
+SELECT COUNT(*) FROM api_keys WHERE user_id = $1 AND organization_id IS NULL AND revoked_at IS NULL;
 
 -- name: RevokeAPIKey :exec
-UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2;
+UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND organization_id IS NULL;
+
+-- name: AdminRevokeAPIKey :exec
+UPDATE api_keys SET revoked_at = NOW() WHERE id = $1;
+
+-- name: UnrevokeAPIKey :exec
+UPDATE api_keys SET revoked_at = NULL WHERE id = $1;
+
+-- name: TransferAPIKey :one
+UPDATE api_keys SET user_id = $2, organization_id = NULL WHERE id = $1
+RETURNING *;
+
+-- name: TransferAPIKeyTranscriptionLogs :execrows
+-- Moves the key's usage history so it counts toward the new owner
+UPDATE transcription_logs SET user_id = $2 WHERE api_key_id = $1;
+
+-- name: UpdateAPIKeyParamRestrictions :one
+UPDATE api_keys SET param_restrictions = $2 WHERE id = $1
+RETURNING *;
 
 -- name: UpdateAPIKeyLastUsed :exec
-UPDATE api_keys SET last_used_at = NOW() WHERE id = $1;
+UPDATE api_keys
+SET last_used_at = NOW(),
+    last_used_ip = $2,
+    last_used_user_agent = $3,
+    use_count = use_count + 1
+WHERE id = $1;
 
 -- name: DeleteAPIKey :exec
-DELETE FROM api_keys WHERE id = $1 AND user_id = $2;
+DELETE FROM api_keys WHERE id = $1 AND user_id = $2 AND organization_id IS NULL;
 
 -- =====================
 -- TRANSCRIPTION LOG QUERIES
 -- =====================
 
 -- name: CreateTranscriptionLog :one
-INSERT INTO transcription_logs (user_id, api_key_id, deepgram_params, client_ip)
-VALUES ($1, $2, $3, $4)
+INSERT INTO transcription_logs (user_id, api_key_id, deepgram_params, client_ip, redaction_audit, session_nonce)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: RetryTranscriptionLog :one
+-- Reopens the log of an earlier attempt of the same client session (same key
+-- and nonce, started since the given time) whose connection failed before
+-- any audio was sent, so a retry does not leave a failed entry behind
+UPDATE transcription_logs
+SET status = 'active',
+    ended_at = NULL,
+    error_message = NULL,
+    error_code = NULL,
+    deepgram_params = sqlc.arg(deepgram_params),
+    client_ip = sqlc.arg(client_ip),
+    redaction_audit = sqlc.arg(redaction_audit)
+WHERE id = (
+    SELECT id FROM transcription_logs
+    WHERE api_key_id = sqlc.arg(api_key_id)
+      AND session_nonce = sqlc.arg(session_nonce)
+      AND started_at >= sqlc.arg(since)
+      AND status = 'error' AND bytes_sent = 0
+    ORDER BY started_at DESC
+    LIMIT 1
+)
+  AND started_at >= sqlc.arg(since)
+  AND status = 'error' AND bytes_sent = 0
 RETURNING *;
 
 -- name: UpdateTranscriptionLogComplete :exec
@@ -49,11 +113,15 @@ SET ended_at = NOW(),
 WHERE id = $1;
 
 -- name: UpdateTranscriptionLogError :exec
+-- Audio streamed before the failure is still billed
 UPDATE transcription_logs
 SET ended_at = NOW(),
     status = 'error',
     error_message = $2,
-    bytes_sent = $3
+    bytes_sent = $3,
+    error_code = $4,
+    duration_seconds = $5,
+    duration_estimated = $6
 WHERE id = $1;
 
 -- name: UpdateTranscriptionLogTimeout :exec
@@ -63,6 +131,16 @@ SET ended_at = NOW(),
     bytes_sent = $2
 WHERE id = $1;
 
+-- name: UpdateTranscriptionLogEstimated :exec
+-- The final metadata never arrived; bill the estimated duration
+UPDATE transcription_logs
+SET ended_at = NOW(),
+    duration_seconds = $2,
+    duration_estimated = TRUE,
+    status = 'timeout',
+    bytes_sent = $3
+WHERE id = $1;
+
 -- name: GetTranscriptionLog :one
 SELECT * FROM transcription_logs WHERE id = $1;
 
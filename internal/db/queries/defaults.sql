@@ -0,0 +1,12 @@
+-- =========================
+-- DEEPGRAM DEFAULTS QUERIES
+-- =========================
+
+-- name: GetDeepgramDefaults :one
+SELECT * FROM deepgram_defaults WHERE id = 1;
+
+-- name: UpdateDeepgramDefaults :one
+UPDATE deepgram_defaults
+SET params = $1, updated_at = NOW()
+WHERE id = 1
+RETURNING *;
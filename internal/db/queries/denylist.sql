@@ -0,0 +1,17 @@
+-- =============================
+-- ACCESS TOKEN DENYLIST QUERIES
+-- =============================
+
+-- name: CreateAccessTokenDenial :one
+INSERT INTO access_token_denials (token_jti, user_id, issued_before, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: DeleteExpiredAccessTokenDenials :exec
+DELETE FROM access_token_denials WHERE expires_at <= NOW();
+
+-- name: ListAccessTokenDenialsSince :many
+-- Denials recorded after the given one that still match valid tokens
+SELECT * FROM access_token_denials
+WHERE id > $1 AND expires_at > NOW()
+ORDER BY id;
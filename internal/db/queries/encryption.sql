@@ -0,0 +1,94 @@
+-- =====================
+-- ENCRYPTION KEY QUERIES
+-- =====================
+
+-- name: ListEncryptionKeys :many
+SELECT * FROM encryption_keys ORDER BY id;
+
+-- name: CreateEncryptionKey :one
+INSERT INTO encryption_keys (purpose, wrapped_key, master_key_id)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: UpdateEncryptionKeyWrapping :exec
+UPDATE encryption_keys SET wrapped_key = $2, master_key_id = $3 WHERE id = $1;
+
+-- name: RetireDataKeys :exec
+UPDATE encryption_keys SET retired_at = NOW()
+WHERE purpose = 'data' AND retired_at IS NULL AND id <> $1;
+
+-- =====================
+-- RE-ENCRYPTION QUERIES
+-- =====================
+
+-- name: ListTrialFingerprintsToReencrypt :many
+SELECT id, device_fingerprint FROM trial_api_keys
+WHERE device_fingerprint_hash IS NULL OR device_fingerprint NOT LIKE sqlc.arg(key_prefix)::text || '%'
+LIMIT sqlc.arg(batch_size);
+
+-- name: UpdateTrialFingerprint :exec
+UPDATE trial_api_keys SET device_fingerprint = $2, device_fingerprint_hash = $3 WHERE id = $1;
+
+-- name: ListTranscriptionLogIPsToReencrypt :many
+SELECT id, client_ip FROM transcription_logs
+WHERE client_ip IS NOT NULL AND client_ip NOT LIKE sqlc.arg(key_prefix)::text || '%'
+LIMIT sqlc.arg(batch_size);
+
+-- name: UpdateTranscriptionLogIP :exec
+UPDATE transcription_logs SET client_ip = $2 WHERE id = $1;
+
+-- name: ListTrialUsageIPsToReencrypt :many
+SELECT id, client_ip FROM trial_usage
+WHERE client_ip IS NOT NULL AND client_ip NOT LIKE sqlc.arg(key_prefix)::text || '%'
+LIMIT sqlc.arg(batch_size);
+
+-- name: UpdateTrialUsageIP :exec
+UPDATE trial_usage SET client_ip = $2 WHERE id = $1;
+
+-- name: ListAPIKeyIPsToReencrypt :many
+SELECT id, last_used_ip FROM api_keys
+WHERE last_used_ip IS NOT NULL AND last_used_ip NOT LIKE sqlc.arg(key_prefix)::text || '%'
+LIMIT sqlc.arg(batch_size);
+
+-- name: UpdateAPIKeyIP :exec
+UPDATE api_keys SET last_used_ip = $2 WHERE id = $1;
+
+-- name: ListRefreshTokenIPsToReencrypt :many
+SELECT id, client_ip FROM tokens
+WHERE client_ip IS NOT NULL AND client_ip NOT LIKE sqlc.arg(key_prefix)::text || '%'
+LIMIT sqlc.arg(batch_size);
+
+-- name: UpdateRefreshTokenIP :exec
+UPDATE tokens SET client_ip = $2 WHERE id = $1;
+
+-- name: ListLoginEventIPsToReencrypt :many
+SELECT id, client_ip FROM login_events
+WHERE client_ip IS NOT NULL AND client_ip NOT LIKE sqlc.arg(key_prefix)::text || '%'
+LIMIT sqlc.arg(batch_size);
+
+-- name: UpdateLoginEventIP :exec
+UPDATE login_events SET client_ip = $2 WHERE id = $1;
+
+-- name: ListAuthEventIPsToReencrypt :many
+SELECT id, client_ip FROM auth_events
+WHERE client_ip IS NOT NULL AND client_ip NOT LIKE sqlc.arg(key_prefix)::text || '%'
+LIMIT sqlc.arg(batch_size);
+
+-- name: UpdateAuthEventIP :exec
+UPDATE auth_events SET client_ip = $2 WHERE id = $1;
+
+-- name: ListTenantDeepgramKeysToReencrypt :many
+SELECT id, deepgram_api_key FROM tenants
+WHERE deepgram_api_key IS NOT NULL AND deepgram_api_key NOT LIKE sqlc.arg(key_prefix)::text || '%'
+LIMIT sqlc.arg(batch_size);
+
+-- name: UpdateTenantDeepgramKey :exec
+UPDATE tenants SET deepgram_api_key = $2 WHERE id = $1;
+
+-- name: ListAccessLogIPsToReencrypt :many
+SELECT id, created_at, client_ip FROM access_logs
+WHERE client_ip IS NOT NULL AND client_ip NOT LIKE sqlc.arg(key_prefix)::text || '%'
+LIMIT sqlc.arg(batch_size);
+
+-- name: UpdateAccessLogIP :exec
+UPDATE access_logs SET client_ip = $3 WHERE id = $1 AND created_at = $2;
@@ -0,0 +1,70 @@
+-- ==================
+-- EXPORT JOB QUERIES
+-- ==================
+
+-- name: CreateExportJob :one
+INSERT INTO export_jobs (user_id, kind, params)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetExportJob :one
+SELECT * FROM export_jobs WHERE id = $1;
+
+-- name: ListUserExportJobs :many
+SELECT * FROM export_jobs WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3;
+
+-- name: ListUserExportJobIDs :many
+SELECT id FROM export_jobs WHERE user_id = $1;
+
+-- name: CountUserExportJobs :one
+SELECT COUNT(*) FROM export_jobs WHERE user_id = $1;
+
+-- name: CountUnfinishedExportJobs :one
+-- Exports of a kind the user is still waiting for
+SELECT COUNT(*) FROM export_jobs
+WHERE user_id = $1 AND kind = $2 AND status IN ('pending', 'running');
+
+-- name: ClaimExportJob :one
+-- Takes the oldest pending job; concurrent workers skip each other's claims
+UPDATE export_jobs
+SET status = 'running', started_at = NOW(), updated_at = NOW()
+WHERE id = (
+    SELECT id FROM export_jobs
+    WHERE status = 'pending'
+    ORDER BY created_at
+    FOR UPDATE SKIP LOCKED
+    LIMIT 1
+)
+RETURNING *;
+
+-- name: UpdateExportJobProgress :exec
+UPDATE export_jobs
+SET rows_written = $2, rows_total = $3, updated_at = NOW()
+WHERE id = $1;
+
+-- name: CompleteExportJob :exec
+UPDATE export_jobs
+SET status = 'completed', file_name = $2, size_bytes = $3, rows_written = $4,
+    completed_at = NOW(), updated_at = NOW(), expires_at = $5
+WHERE id = $1;
+
+-- name: FailExportJob :exec
+UPDATE export_jobs
+SET status = 'failed', error = $2, completed_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: FailStaleExportJobs :many
+-- Running jobs whose worker stopped reporting progress, e.g. because the
+-- server restarted
+UPDATE export_jobs
+SET status = 'failed', error = 'interrupted', completed_at = NOW(), updated_at = NOW()
+WHERE status = 'running' AND updated_at < $1
+RETURNING id;
+
+-- name: ListExpiredExportJobs :many
+SELECT * FROM export_jobs WHERE status = 'completed' AND expires_at <= NOW() ORDER BY expires_at;
+
+-- name: ExpireExportJob :exec
+UPDATE export_jobs
+SET status = 'expired', file_name = NULL, updated_at = NOW()
+WHERE id = $1;
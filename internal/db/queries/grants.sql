@@ -0,0 +1,22 @@
+-- =====================
+-- TRIAL GRANT QUERIES
+-- =====================
+
+-- name: CreateTrialGrant :one
+INSERT INTO trial_grants (trial_key_id, granted_seconds, expires_at)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetOpenTrialGrant :one
+SELECT * FROM trial_grants
+WHERE trial_key_id = $1 AND reconciled_at IS NULL AND expires_at > NOW()
+ORDER BY issued_at DESC
+LIMIT 1;
+
+-- name: ReconcileTrialGrant :one
+-- Returns no rows if the grant was already reconciled
+UPDATE trial_grants
+SET reconciled_at = NOW(),
+    used_seconds = $2
+WHERE id = $1 AND reconciled_at IS NULL
+RETURNING *;
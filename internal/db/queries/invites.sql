@@ -0,0 +1,31 @@
+-- ==============
+-- INVITE QUERIES
+-- ==============
+
+-- name: CreateInvite :one
+INSERT INTO invites (code_hash, code_prefix, note, user_type, max_uses, expires_at, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetInviteByCodeHash :one
+SELECT * FROM invites WHERE code_hash = $1;
+
+-- name: ListInvites :many
+SELECT * FROM invites ORDER BY created_at DESC LIMIT $1 OFFSET $2;
+
+-- name: CountInvites :one
+SELECT COUNT(*) FROM invites;
+
+-- name: RedeemInvite :one
+-- Uses up one redemption; no row if the invite is revoked, expired or used up
+UPDATE invites
+SET uses = uses + 1
+WHERE id = $1
+  AND revoked_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND (max_uses = 0 OR uses < max_uses)
+RETURNING *;
+
+-- name: RevokeInvite :one
+UPDATE invites SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL
+RETURNING *;
@@ -0,0 +1,13 @@
+-- =====================
+-- KNOWN DEVICE QUERIES
+-- =====================
+
+-- name: CountKnownDevices :one
+SELECT COUNT(*) FROM known_devices WHERE user_id = $1;
+
+-- name: RecordKnownDevice :one
+-- Adds the device or marks it seen again; inserted tells which
+INSERT INTO known_devices (user_id, device_hash, user_agent)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, device_hash) DO UPDATE SET last_seen_at = NOW()
+RETURNING (xmax = 0)::boolean AS inserted;
@@ -0,0 +1,46 @@
+-- ============================
+-- API KEY LOCKDOWN QUERIES
+-- ============================
+
+-- name: CreateAPIKeyLockdown :one
+-- Returns sql.ErrNoRows when the key is already locked
+INSERT INTO api_key_lockdowns (api_key_id, reason, details)
+VALUES ($1, $2, $3)
+ON CONFLICT (api_key_id) WHERE lifted_at IS NULL DO NOTHING
+RETURNING *;
+
+-- name: GetLatestAPIKeyLockdown :one
+SELECT * FROM api_key_lockdowns
+WHERE api_key_id = $1
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: LiftAPIKeyLockdown :execrows
+UPDATE api_key_lockdowns
+SET lifted_at = NOW(), lifted_by = $2
+WHERE api_key_id = $1 AND lifted_at IS NULL;
+
+-- name: ListUserActiveAPIKeyLockdowns :many
+SELECT l.* FROM api_key_lockdowns l
+JOIN api_keys k ON k.id = l.api_key_id
+WHERE k.user_id = $1 AND l.lifted_at IS NULL;
+
+-- name: RecordAPIKeyIPSighting :exec
+INSERT INTO api_key_ip_sightings (api_key_id, ip_hash)
+VALUES ($1, $2)
+ON CONFLICT (api_key_id, ip_hash) DO UPDATE SET last_seen_at = NOW();
+
+-- name: DeleteStaleAPIKeyIPSightings :exec
+DELETE FROM api_key_ip_sightings WHERE api_key_id = $1 AND last_seen_at < $2;
+
+-- name: CountAPIKeyIPSightings :one
+SELECT COUNT(*) FROM api_key_ip_sightings WHERE api_key_id = $1 AND last_seen_at >= $2;
+
+-- name: GetAPIKeyUsageBaseline :one
+-- Seconds streamed with a key since recent_start, and in the baseline
+-- period between baseline_start and recent_start
+SELECT
+    COALESCE(SUM(duration_seconds) FILTER (WHERE started_at >= sqlc.arg(recent_start)), 0)::DECIMAL(12,3) as recent_seconds,
+    COALESCE(SUM(duration_seconds) FILTER (WHERE started_at < sqlc.arg(recent_start)), 0)::DECIMAL(12,3) as baseline_seconds
+FROM transcription_logs
+WHERE api_key_id = sqlc.arg(api_key_id) AND started_at >= sqlc.arg(baseline_start);
@@ -0,0 +1,42 @@
+-- =====================
+-- LOGIN EVENT QUERIES
+-- =====================
+
+-- name: CreateLoginEvent :exec
+INSERT INTO login_events (user_id, kind, outcome, client_ip, client_ip_hash, user_agent)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: DeleteExpiredLoginEvents :exec
+DELETE FROM login_events WHERE created_at < sqlc.arg(cutoff);
+
+-- name: ListUserLoginEvents :many
+-- The user's sign-ins and refreshes, newest first
+SELECT * FROM login_events
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountUserLoginEvents :one
+SELECT COUNT(*) FROM login_events WHERE user_id = $1;
+
+-- name: ListLoginEvents :many
+SELECT e.*, u.username
+FROM login_events e
+LEFT JOIN users u ON u.id = e.user_id
+WHERE e.created_at >= sqlc.arg(start_date) AND e.created_at < sqlc.arg(end_date)
+  AND (sqlc.narg(user_id)::uuid IS NULL OR e.user_id = sqlc.narg(user_id))
+  AND (sqlc.narg(client_ip_hash)::text IS NULL OR e.client_ip_hash = sqlc.narg(client_ip_hash))
+  AND (sqlc.narg(kind)::text IS NULL OR e.kind = sqlc.narg(kind))
+  AND (sqlc.narg(outcome)::text IS NULL OR e.outcome = sqlc.narg(outcome))
+  AND (sqlc.narg(succeeded)::boolean IS NULL OR (e.outcome = 'success') = sqlc.narg(succeeded))
+ORDER BY e.created_at DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: CountLoginEvents :one
+SELECT COUNT(*) FROM login_events
+WHERE created_at >= sqlc.arg(start_date) AND created_at < sqlc.arg(end_date)
+  AND (sqlc.narg(user_id)::uuid IS NULL OR user_id = sqlc.narg(user_id))
+  AND (sqlc.narg(client_ip_hash)::text IS NULL OR client_ip_hash = sqlc.narg(client_ip_hash))
+  AND (sqlc.narg(kind)::text IS NULL OR kind = sqlc.narg(kind))
+  AND (sqlc.narg(outcome)::text IS NULL OR outcome = sqlc.narg(outcome))
+  AND (sqlc.narg(succeeded)::boolean IS NULL OR (outcome = 'success') = sqlc.narg(succeeded));
@@ -0,0 +1,121 @@
+-- ======================
+-- ACCOUNT MERGE QUERIES
+-- ======================
+
+-- name: GetUserMergeCounts :one
+-- Rows owned by a user that an account merge moves to the surviving account
+SELECT
+    (SELECT COUNT(*) FROM api_keys WHERE api_keys.user_id = sqlc.arg(user_id)::uuid) AS api_keys,
+    (SELECT COUNT(*) FROM transcription_logs WHERE transcription_logs.user_id = sqlc.arg(user_id)::uuid) AS transcription_logs,
+    (SELECT COUNT(*) FROM tokens WHERE tokens.user_id = sqlc.arg(user_id)::uuid) AS refresh_tokens,
+    (SELECT COUNT(*) FROM client_error_reports WHERE client_error_reports.user_id = sqlc.arg(user_id)::uuid) AS error_reports,
+    (SELECT COUNT(*) FROM session_policies WHERE scope = 'user' AND scope_value = sqlc.arg(user_id)::uuid::text) AS session_policies,
+    (SELECT COUNT(*) FROM audit_events WHERE actor_user_id = sqlc.arg(user_id)::uuid) AS audit_events,
+    (SELECT COUNT(*) FROM session_transcripts WHERE session_transcripts.user_id = sqlc.arg(user_id)::uuid) AS session_transcripts,
+    (SELECT COUNT(*) FROM organization_members WHERE organization_members.user_id = sqlc.arg(user_id)::uuid) AS organization_memberships,
+    (SELECT COUNT(*) FROM trial_conversions WHERE trial_conversions.user_id = sqlc.arg(user_id)::uuid) AS trial_conversions,
+    (SELECT COUNT(*) FROM export_jobs WHERE export_jobs.user_id = sqlc.arg(user_id)::uuid) AS export_jobs,
+    (SELECT COUNT(*) FROM password_history WHERE password_history.user_id = sqlc.arg(user_id)::uuid) AS password_history,
+    (SELECT COUNT(*) FROM known_devices WHERE known_devices.user_id = sqlc.arg(user_id)::uuid) AS known_devices,
+    (SELECT COUNT(*) FROM api_key_limits WHERE api_key_limits.user_id = sqlc.arg(user_id)::uuid) AS api_key_limits,
+    (SELECT COUNT(*) FROM oauth_clients WHERE owner_user_id = sqlc.arg(user_id)::uuid) AS oauth_clients,
+    (SELECT COUNT(*) FROM oauth_grants WHERE oauth_grants.user_id = sqlc.arg(user_id)::uuid) AS oauth_grants,
+    (SELECT COUNT(*) FROM oauth_device_codes WHERE oauth_device_codes.user_id = sqlc.arg(user_id)::uuid) AS oauth_device_codes,
+    (SELECT COUNT(*) FROM login_events WHERE login_events.user_id = sqlc.arg(user_id)::uuid) AS login_events,
+    (SELECT COUNT(*) FROM auth_events WHERE auth_events.user_id = sqlc.arg(user_id)::uuid) AS auth_events;
+
+-- name: MergeUserAPIKeys :execrows
+UPDATE api_keys SET user_id = sqlc.arg(target_id) WHERE user_id = sqlc.arg(source_id);
+
+-- name: MergeUserTranscriptionLogs :execrows
+UPDATE transcription_logs SET user_id = sqlc.arg(target_id) WHERE user_id = sqlc.arg(source_id);
+
+-- name: MergeUserSessionTranscripts :execrows
+UPDATE session_transcripts SET user_id = sqlc.arg(target_id) WHERE user_id = sqlc.arg(source_id);
+
+-- name: MergeUserErrorReports :execrows
+UPDATE client_error_reports SET user_id = sqlc.arg(target_id)::uuid WHERE user_id = sqlc.arg(source_id)::uuid;
+
+-- name: MergeUserSessionPolicies :execrows
+UPDATE session_policies
+SET scope_value = sqlc.arg(target_id)::uuid::text, updated_at = NOW()
+WHERE scope = 'user' AND scope_value = sqlc.arg(source_id)::uuid::text;
+
+-- name: MergeUserAuditEvents :execrows
+UPDATE audit_events SET actor_user_id = sqlc.arg(target_id)::uuid WHERE actor_user_id = sqlc.arg(source_id)::uuid;
+
+-- name: MergeUserOrganizationMemberships :execrows
+-- Where both accounts are members the higher role is kept, so merging an
+-- owner never leaves an organization without one
+INSERT INTO organization_members (organization_id, user_id, role, created_at)
+SELECT organization_id, sqlc.arg(target_id)::uuid, role, created_at
+FROM organization_members
+WHERE user_id = sqlc.arg(source_id)::uuid
+ON CONFLICT (organization_id, user_id) DO UPDATE
+SET role = CASE
+        WHEN 'owner' IN (organization_members.role, EXCLUDED.role) THEN 'owner'
+        WHEN 'admin' IN (organization_members.role, EXCLUDED.role) THEN 'admin'
+        ELSE 'member'
+    END,
+    created_at = LEAST(organization_members.created_at, EXCLUDED.created_at);
+
+-- name: MergeUserTrialConversions :execrows
+UPDATE trial_conversions SET user_id = sqlc.arg(target_id)::uuid WHERE user_id = sqlc.arg(source_id)::uuid;
+
+-- name: MergeUserExportJobs :execrows
+UPDATE export_jobs SET user_id = sqlc.arg(target_id)::uuid WHERE user_id = sqlc.arg(source_id)::uuid;
+
+-- name: MergeUserPasswordHistory :execrows
+UPDATE password_history SET user_id = sqlc.arg(target_id)::uuid WHERE user_id = sqlc.arg(source_id)::uuid;
+
+-- name: MergeUserLoginEvents :execrows
+-- Sign-ins and refreshes, failed ones included, stay in the surviving
+-- account's security history
+UPDATE login_events SET user_id = sqlc.arg(target_id)::uuid WHERE user_id = sqlc.arg(source_id)::uuid;
+
+-- name: MergeUserAuthEvents :execrows
+UPDATE auth_events SET user_id = sqlc.arg(target_id)::uuid WHERE user_id = sqlc.arg(source_id)::uuid;
+
+-- name: MergeUserKnownDevices :execrows
+-- Devices both accounts signed in from are kept once, seen over both spans
+INSERT INTO known_devices (user_id, device_hash, user_agent, first_seen_at, last_seen_at)
+SELECT sqlc.arg(target_id)::uuid, device_hash, user_agent, first_seen_at, last_seen_at
+FROM known_devices
+WHERE user_id = sqlc.arg(source_id)::uuid
+ON CONFLICT (user_id, device_hash) DO UPDATE
+SET user_agent = CASE
+        WHEN EXCLUDED.last_seen_at > known_devices.last_seen_at THEN EXCLUDED.user_agent
+        ELSE known_devices.user_agent
+    END,
+    first_seen_at = LEAST(known_devices.first_seen_at, EXCLUDED.first_seen_at),
+    last_seen_at = GREATEST(known_devices.last_seen_at, EXCLUDED.last_seen_at);
+
+-- name: MergeUserAPIKeyLimits :execrows
+-- The surviving account holds the keys of both, so it keeps the higher limit
+INSERT INTO api_key_limits (user_id, max_keys, updated_at)
+SELECT sqlc.arg(target_id)::uuid, max_keys, NOW()
+FROM api_key_limits
+WHERE user_id = sqlc.arg(source_id)::uuid
+ON CONFLICT (user_id) DO UPDATE
+SET max_keys = GREATEST(api_key_limits.max_keys, EXCLUDED.max_keys),
+    updated_at = NOW();
+
+-- name: MergeUserOAuthClients :execrows
+UPDATE oauth_clients SET owner_user_id = sqlc.arg(target_id)::uuid WHERE owner_user_id = sqlc.arg(source_id)::uuid;
+
+-- name: MergeUserOAuthGrants :execrows
+-- Applications keep their access, now to the surviving account; access
+-- tokens issued under a grant before the merge are denied with the rest
+UPDATE oauth_grants SET user_id = sqlc.arg(target_id)::uuid WHERE user_id = sqlc.arg(source_id)::uuid;
+
+-- name: MergeUserOAuthDeviceCodes :execrows
+UPDATE oauth_device_codes SET user_id = sqlc.arg(target_id)::uuid WHERE user_id = sqlc.arg(source_id)::uuid;
+
+-- name: RevokeMergedUserRefreshTokens :execrows
+-- Refresh tokens aren't moved: they carry the merged account's ID in their
+-- claims, so they are revoked and removed along with the account, and its
+-- devices sign in again as the surviving account
+UPDATE tokens
+SET revoked_at = COALESCE(revoked_at, NOW()),
+    revoked_reason = COALESCE(revoked_reason, 'account merged')
+WHERE user_id = sqlc.arg(source_id);
@@ -0,0 +1,53 @@
+-- ======================
+-- USAGE EXPORTER QUERIES
+-- ======================
+
+-- name: ListUserUsageTotals :many
+-- Users with sessions in a period, for the Prometheus usage exporter
+SELECT
+    u.id,
+    u.username,
+    COUNT(*) as total_sessions,
+    COALESCE(SUM(tl.duration_seconds), 0)::DECIMAL(12,3) as total_duration_seconds
+FROM transcription_logs tl
+JOIN users u ON tl.user_id = u.id
+WHERE tl.started_at >= sqlc.arg(start_date) AND tl.started_at < sqlc.arg(end_date)
+GROUP BY u.id, u.username
+ORDER BY u.username;
+
+-- name: ListAPIKeyUsageTotals :many
+-- API keys with sessions in a period
+SELECT
+    ak.id,
+    ak.key_prefix,
+    ak.name,
+    ak.user_id,
+    ak.organization_id,
+    COUNT(*) as total_sessions,
+    COALESCE(SUM(tl.duration_seconds), 0)::DECIMAL(12,3) as total_duration_seconds
+FROM transcription_logs tl
+JOIN api_keys ak ON tl.api_key_id = ak.id
+WHERE tl.started_at >= sqlc.arg(start_date) AND tl.started_at < sqlc.arg(end_date)
+GROUP BY ak.id
+ORDER BY ak.key_prefix;
+
+-- name: ListTrialFunnelTotals :many
+-- Trial keys per preset at each funnel step: provisioned, used for a first
+-- session, out of preset quota, and converted into an account
+WITH key_usage AS (
+    SELECT trial_key_id, COUNT(*) AS sessions, COALESCE(SUM(duration_seconds), 0) AS duration_seconds
+    FROM trial_usage
+    GROUP BY trial_key_id
+)
+SELECT
+    tp.name AS preset,
+    COUNT(tak.id) AS provisioned,
+    COUNT(ku.trial_key_id) AS first_session,
+    COUNT(CASE WHEN ku.sessions >= tp.max_sessions OR ku.duration_seconds >= tp.max_duration_seconds THEN 1 END) AS quota_exhausted,
+    COUNT(tc.id) AS converted
+FROM trial_presets tp
+LEFT JOIN trial_api_keys tak ON tak.preset = tp.name
+LEFT JOIN key_usage ku ON ku.trial_key_id = tak.id
+LEFT JOIN trial_conversions tc ON tc.trial_key_id = tak.id
+GROUP BY tp.name
+ORDER BY tp.name;
@@ -0,0 +1,60 @@
+-- ============================
+-- OAUTH AUTHORIZATION QUERIES
+-- ============================
+
+-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (client_id, client_secret_hash, name, redirect_uris, scopes, owner_user_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetOAuthClientByClientID :one
+SELECT * FROM oauth_clients WHERE client_id = $1;
+
+-- name: ListUserOAuthClients :many
+SELECT * FROM oauth_clients WHERE owner_user_id = $1 ORDER BY created_at DESC;
+
+-- name: DeleteOAuthClient :execrows
+DELETE FROM oauth_clients WHERE id = $1 AND owner_user_id = $2;
+
+-- name: ListOAuthClientGrantIDs :many
+SELECT id FROM oauth_grants WHERE client_id = $1 AND revoked_at IS NULL;
+
+-- name: CreateOAuthAuthorizationCode :exec
+INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ConsumeOAuthAuthorizationCode :one
+-- No row if the code is unknown, used or expired
+UPDATE oauth_authorization_codes
+SET used_at = NOW()
+WHERE code_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+RETURNING *;
+
+-- name: DeleteExpiredOAuthAuthorizationCodes :exec
+DELETE FROM oauth_authorization_codes WHERE expires_at < NOW();
+
+-- name: CreateOAuthGrant :one
+INSERT INTO oauth_grants (client_id, user_id, scopes, refresh_token_hash, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: RotateOAuthRefreshToken :one
+-- No row if the refresh token is unknown, expired, revoked or another
+-- client's
+UPDATE oauth_grants
+SET refresh_token_hash = sqlc.arg(new_hash), last_used_at = NOW(), expires_at = sqlc.arg(expires_at)
+WHERE refresh_token_hash = sqlc.arg(old_hash)
+    AND client_id = sqlc.arg(client_id)
+    AND revoked_at IS NULL
+    AND expires_at > NOW()
+RETURNING *;
+
+-- name: ListUserOAuthGrants :many
+SELECT g.id, g.scopes, g.created_at, g.last_used_at, c.client_id, c.name AS client_name
+FROM oauth_grants g
+JOIN oauth_clients c ON c.id = g.client_id
+WHERE g.user_id = $1 AND g.revoked_at IS NULL AND g.expires_at > NOW()
+ORDER BY g.created_at DESC;
+
+-- name: RevokeUserOAuthGrant :execrows
+UPDATE oauth_grants SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
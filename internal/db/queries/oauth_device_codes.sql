@@ -0,0 +1,35 @@
+-- ============================
+-- OAUTH DEVICE CODE QUERIES
+-- ============================
+
+-- name: CreateOAuthDeviceCode :exec
+INSERT INTO oauth_device_codes (device_code_hash, user_code, client_id, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: DeleteExpiredOAuthDeviceCodes :exec
+DELETE FROM oauth_device_codes WHERE expires_at < NOW();
+
+-- name: GetPendingOAuthDeviceCode :one
+-- No row if the user code is unknown, expired or already answered
+SELECT * FROM oauth_device_codes
+WHERE user_code = $1 AND status = 'pending' AND expires_at > NOW();
+
+-- name: AnswerOAuthDeviceCode :execrows
+UPDATE oauth_device_codes
+SET status = $1, user_id = $2
+WHERE user_code = $3 AND status = 'pending' AND expires_at > NOW();
+
+-- name: PollOAuthDeviceCode :one
+-- Records a poll, returning the code as it was before it
+UPDATE oauth_device_codes d
+SET last_polled_at = NOW()
+FROM oauth_device_codes prev
+WHERE d.device_code_hash = $1 AND prev.device_code_hash = d.device_code_hash
+RETURNING prev.*;
+
+-- name: RedeemOAuthDeviceCode :one
+-- No row if the code isn't approved, was redeemed already or expired
+UPDATE oauth_device_codes
+SET status = 'redeemed'
+WHERE device_code_hash = $1 AND status = 'approved' AND expires_at > NOW()
+RETURNING *;
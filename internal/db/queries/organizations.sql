@@ -0,0 +1,100 @@
+-- =====================
+-- ORGANIZATION QUERIES
+-- =====================
+
+-- name: CreateOrganization :one
+INSERT INTO organizations (name)
+VALUES ($1)
+RETURNING *;
+
+-- name: GetOrganizationByID :one
+SELECT * FROM organizations WHERE id = $1;
+
+-- name: ListOrganizations :many
+SELECT * FROM organizations ORDER BY created_at DESC LIMIT $1 OFFSET $2;
+
+-- name: CountOrganizations :one
+SELECT COUNT(*) FROM organizations;
+
+-- name: ListUserOrganizations :many
+SELECT o.*, m.role
+FROM organizations o
+JOIN organization_members m ON m.organization_id = o.id
+WHERE m.user_id = $1
+ORDER BY o.name;
+
+-- name: UpdateOrganizationDataRegion :one
+UPDATE organizations
+SET data_region = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateOrganizationQuota :one
+UPDATE organizations
+SET monthly_quota_seconds = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- =====================
+-- MEMBERSHIP QUERIES
+-- =====================
+
+-- name: UpsertOrganizationMember :one
+INSERT INTO organization_members (organization_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (organization_id, user_id) DO UPDATE SET role = EXCLUDED.role
+RETURNING *;
+
+-- name: GetOrganizationMember :one
+SELECT * FROM organization_members WHERE organization_id = $1 AND user_id = $2;
+
+-- name: ListOrganizationMembers :many
+SELECT m.user_id, m.role, m.created_at, u.username, u.email
+FROM organization_members m
+JOIN users u ON u.id = m.user_id
+WHERE m.organization_id = $1
+ORDER BY m.created_at;
+
+-- name: CountOrganizationOwners :one
+SELECT COUNT(*) FROM organization_members WHERE organization_id = $1 AND role = 'owner';
+
+-- name: RemoveOrganizationMember :execrows
+DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2;
+
+-- =====================
+-- ORGANIZATION API KEY QUERIES
+-- =====================
+
+-- name: CreateOrganizationAPIKey :one
+INSERT INTO api_keys (user_id, organization_id, key_hash, key_prefix, name)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListOrganizationAPIKeys :many
+SELECT * FROM api_keys WHERE organization_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3;
+
+-- name: CountOrganizationAPIKeys :one
+SELECT COUNT(*) FROM api_keys WHERE organization_id = $1;
+
+-- name: RevokeOrganizationAPIKey :execrows
+UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND organization_id = $2 AND revoked_at IS NULL;
+
+-- name: TransferAPIKeyToOrganization :one
+-- The key keeps its creator; its usage moves to the organization's pool
+UPDATE api_keys SET organization_id = $2 WHERE id = $1
+RETURNING *;
+
+-- name: GetOrganizationUsage :one
+-- Sessions on the organization's keys in [start_date, end_date), the
+-- shared pool its quota is enforced against
+SELECT
+    COUNT(*) as total_sessions,
+    COALESCE(SUM(tl.duration_seconds), 0)::DECIMAL(12,3) as total_duration_seconds,
+    COALESCE(SUM(tl.bytes_sent), 0) as total_bytes_sent
+FROM transcription_logs tl
+JOIN api_keys k ON k.id = tl.api_key_id
+WHERE k.organization_id = sqlc.arg(organization_id)
+  AND tl.started_at >= sqlc.arg(start_date)
+  AND tl.started_at < sqlc.arg(end_date);
@@ -0,0 +1,48 @@
+-- =====================
+-- USAGE LOG PARTITION QUERIES
+-- =====================
+
+-- name: EnsureMonthlyPartitions :exec
+SELECT ensure_monthly_partitions(sqlc.arg(parent)::text, sqlc.arg(first_month)::timestamptz, sqlc.arg(last_month)::timestamptz);
+
+-- name: DropMonthlyPartition :exec
+SELECT drop_monthly_partition(sqlc.arg(parent)::text, sqlc.arg(month)::timestamptz);
+
+-- name: ListMonthlyPartitions :many
+-- Monthly partitions of a parent table, oldest first
+SELECT c.relname::text AS partition_name
+FROM pg_inherits inh
+JOIN pg_class c ON c.oid = inh.inhrelid
+JOIN pg_class parent ON parent.oid = inh.inhparent
+WHERE parent.relname = sqlc.arg(parent)::text AND c.relname ~ '_[0-9]{4}_[0-9]{2}$'
+ORDER BY c.relname;
+
+-- name: CountUnexpiredTrialKeysWithUsage :one
+-- Trial keys that still count their lifetime usage against the quota and
+-- have sessions in the given range
+SELECT COUNT(DISTINCT tu.trial_key_id) FROM trial_usage tu
+JOIN trial_api_keys tk ON tk.id = tu.trial_key_id
+WHERE tu.started_at >= sqlc.arg(start_date) AND tu.started_at < sqlc.arg(end_date)
+  AND tk.expires_at > NOW();
+
+-- name: ExportTranscriptionLogs :many
+-- Keyset-paginated; client_ip is exported as stored (encrypted)
+SELECT id, user_id, api_key_id, started_at, ended_at, duration_seconds, status, error_message,
+       deepgram_params, bytes_sent, client_ip::text AS client_ip_ciphertext, duration_estimated,
+       redaction_audit
+FROM transcription_logs
+WHERE started_at >= sqlc.arg(start_date) AND started_at < sqlc.arg(end_date)
+  AND (started_at, id) > (sqlc.arg(after_started_at)::timestamptz, sqlc.arg(after_id)::uuid)
+ORDER BY started_at, id
+LIMIT sqlc.arg(batch_size);
+
+-- name: ExportTrialUsage :many
+-- Keyset-paginated; client_ip is exported as stored (encrypted)
+SELECT id, trial_key_id, started_at, ended_at, duration_seconds, status, error_message,
+       deepgram_params, bytes_sent, client_ip::text AS client_ip_ciphertext, duration_estimated,
+       redaction_audit
+FROM trial_usage
+WHERE started_at >= sqlc.arg(start_date) AND started_at < sqlc.arg(end_date)
+  AND (started_at, id) > (sqlc.arg(after_started_at)::timestamptz, sqlc.arg(after_id)::uuid)
+ORDER BY started_at, id
+LIMIT sqlc.arg(batch_size);
@@ -0,0 +1,24 @@
+-- ==========================
+-- PASSWORD HISTORY QUERIES
+-- ==========================
+
+-- name: AddPasswordHistory :exec
+INSERT INTO password_history (user_id, password_hash) VALUES ($1, $2);
+
+-- name: ListPasswordHistory :many
+-- Hashes of the user's newest passwords, newest first
+SELECT password_hash FROM password_history
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: PrunePasswordHistory :exec
+-- Deletes all but the user's newest keep passwords
+DELETE FROM password_history
+WHERE user_id = $1
+  AND id NOT IN (
+    SELECT id FROM password_history
+    WHERE user_id = $1
+    ORDER BY created_at DESC
+    LIMIT sqlc.arg(keep)::integer
+  );
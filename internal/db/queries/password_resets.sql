@@ -0,0 +1,21 @@
+-- ============================
+-- PASSWORD RESET TOKEN QUERIES
+-- ============================
+
+-- name: CreatePasswordResetToken :one
+INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: CountRecentPasswordResetTokens :one
+SELECT COUNT(*) FROM password_reset_tokens WHERE user_id = $1 AND created_at > $2;
+
+-- name: ConsumePasswordResetToken :one
+-- No row if the token is unknown, used or expired
+UPDATE password_reset_tokens
+SET used_at = NOW()
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+RETURNING *;
+
+-- name: InvalidatePasswordResetTokens :exec
+UPDATE password_reset_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL;
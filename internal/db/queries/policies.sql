@@ -0,0 +1,46 @@
+-- =====================
+-- SESSION POLICY QUERIES
+-- =====================
+
+-- name: ListSessionPolicies :many
+SELECT * FROM session_policies ORDER BY created_at;
+
+-- name: GetSessionPolicy :one
+SELECT * FROM session_policies WHERE id = $1;
+
+-- name: CreateSessionPolicy :one
+INSERT INTO session_policies (name, scope, scope_value, params, enabled)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: UpdateSessionPolicy :one
+UPDATE session_policies
+SET name = $2,
+    scope = $3,
+    scope_value = $4,
+    params = $5,
+    enabled = $6,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteSessionPolicy :exec
+DELETE FROM session_policies WHERE id = $1;
+
+-- name: ListMatchingSessionPolicies :many
+-- Ordered from least to most specific so later policies win
+SELECT * FROM session_policies
+WHERE enabled AND (
+    scope = 'all'
+    OR (scope = 'trial' AND sqlc.arg(is_trial)::boolean)
+    OR (scope = 'user_type' AND scope_value = (SELECT user_type FROM users WHERE id = sqlc.arg(user_id)::uuid))
+    OR (scope = 'user' AND scope_value = sqlc.arg(user_id)::uuid::text)
+    OR (scope = 'api_key' AND scope_value = sqlc.arg(api_key_id)::uuid::text)
+)
+ORDER BY CASE scope
+    WHEN 'all' THEN 0
+    WHEN 'trial' THEN 1
+    WHEN 'user_type' THEN 1
+    WHEN 'user' THEN 2
+    ELSE 3
+END, created_at;
@@ -0,0 +1,67 @@
+-- =====================
+-- TRIAL PRESET QUERIES
+-- =====================
+
+-- name: CreateTrialPreset :one
+INSERT INTO trial_presets (name, description, max_duration_seconds, max_sessions, max_session_duration_seconds, expiry_days, param_restrictions)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: DeleteTrialPreset :exec
+DELETE FROM trial_presets WHERE name = $1;
+
+-- name: GetTrialPreset :one
+SELECT * FROM trial_presets WHERE name = $1;
+
+-- name: ListTrialPresets :many
+SELECT * FROM trial_presets ORDER BY name;
+
+-- name: SeedTrialPreset :execrows
+-- Creates the preset, or updates its limits while no admin has edited it
+-- (updated_at is still created_at)
+INSERT INTO trial_presets (name, description, max_duration_seconds, max_sessions, max_session_duration_seconds, expiry_days)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (name) DO UPDATE
+SET max_duration_seconds = EXCLUDED.max_duration_seconds,
+    max_sessions = EXCLUDED.max_sessions,
+    max_session_duration_seconds = EXCLUDED.max_session_duration_seconds,
+    expiry_days = EXCLUDED.expiry_days
+WHERE trial_presets.updated_at = trial_presets.created_at
+    AND (trial_presets.max_duration_seconds, trial_presets.max_sessions, trial_presets.max_session_duration_seconds, trial_presets.expiry_days)
+        IS DISTINCT FROM (EXCLUDED.max_duration_seconds, EXCLUDED.max_sessions, EXCLUDED.max_session_duration_seconds, EXCLUDED.expiry_days);
+
+-- name: UpdateTrialPreset :one
+UPDATE trial_presets
+SET description = $2,
+    max_duration_seconds = $3,
+    max_sessions = $4,
+    max_session_duration_seconds = $5,
+    expiry_days = $6,
+    updated_at = NOW()
+WHERE name = $1
+RETURNING *;
+
+-- name: UpdateTrialPresetParamRestrictions :one
+UPDATE trial_presets
+SET param_restrictions = $2,
+    updated_at = NOW()
+WHERE name = $1
+RETURNING *;
+
+-- name: CountTrialKeysForPreset :one
+SELECT COUNT(*) FROM trial_api_keys WHERE preset = $1;
+
+-- name: GetTrialPresetUsageSummary :many
+-- Keys and usage per preset; usage is limited to the period
+SELECT
+    tp.name,
+    COUNT(DISTINCT tak.id) AS total_trial_keys,
+    COUNT(DISTINCT CASE WHEN tak.revoked_at IS NULL AND tak.expires_at > NOW() THEN tak.id END) AS active_trial_keys,
+    COUNT(tu.id) AS total_sessions,
+    COALESCE(SUM(tu.duration_seconds), 0)::DECIMAL(12,3) AS total_duration_seconds
+FROM trial_presets tp
+LEFT JOIN trial_api_keys tak ON tak.preset = tp.name
+LEFT JOIN trial_usage tu ON tu.trial_key_id = tak.id
+    AND tu.started_at >= sqlc.arg(start_date) AND tu.started_at < sqlc.arg(end_date)
+GROUP BY tp.name
+ORDER BY tp.name;
@@ -0,0 +1,54 @@
+-- ========================
+-- REPORT SCHEDULE QUERIES
+-- ========================
+
+-- name: CreateReportSchedule :one
+INSERT INTO report_schedules (report, channel, target, next_run_at, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListReportSchedules :many
+SELECT * FROM report_schedules ORDER BY created_at;
+
+-- name: DeleteReportSchedule :execrows
+DELETE FROM report_schedules WHERE id = $1;
+
+-- name: ClaimDueReportSchedule :one
+-- Takes a due schedule and holds it for an hour, so concurrent workers skip
+-- it and it is retried if the claiming worker goes away
+UPDATE report_schedules
+SET next_run_at = NOW() + INTERVAL '1 hour'
+WHERE id = (
+    SELECT id FROM report_schedules
+    WHERE next_run_at <= NOW()
+    ORDER BY next_run_at
+    FOR UPDATE SKIP LOCKED
+    LIMIT 1
+)
+RETURNING *;
+
+-- name: FinishReportRun :exec
+UPDATE report_schedules
+SET next_run_at = $2, last_run_at = NOW(), last_error = $3
+WHERE id = $1;
+
+-- name: ListTrialFunnelCohort :many
+-- ListTrialFunnelTotals for the trial keys provisioned in a period
+WITH key_usage AS (
+    SELECT trial_key_id, COUNT(*) AS sessions, COALESCE(SUM(duration_seconds), 0) AS duration_seconds
+    FROM trial_usage
+    GROUP BY trial_key_id
+)
+SELECT
+    tp.name AS preset,
+    COUNT(tak.id) AS provisioned,
+    COUNT(ku.trial_key_id) AS first_session,
+    COUNT(CASE WHEN ku.sessions >= tp.max_sessions OR ku.duration_seconds >= tp.max_duration_seconds THEN 1 END) AS quota_exhausted,
+    COUNT(tc.id) AS converted
+FROM trial_presets tp
+LEFT JOIN trial_api_keys tak ON tak.preset = tp.name
+    AND tak.created_at >= sqlc.arg(start_date) AND tak.created_at < sqlc.arg(end_date)
+LEFT JOIN key_usage ku ON ku.trial_key_id = tak.id
+LEFT JOIN trial_conversions tc ON tc.trial_key_id = tak.id
+GROUP BY tp.name
+ORDER BY tp.name;
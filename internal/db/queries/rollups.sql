@@ -0,0 +1,29 @@
+-- =====================
+-- USAGE ROLLUP QUERIES
+-- =====================
+
+-- name: RollupTranscriptionLogsMonth :exec
+-- Recomputes a month's totals from the logs. Never run it for an archived
+-- month, whose logs are gone.
+INSERT INTO usage_rollups (month, source, sessions, duration_seconds)
+SELECT (sqlc.arg(start_date)::timestamptz AT TIME ZONE 'UTC')::date, 'transcription_logs', COUNT(*), COALESCE(SUM(duration_seconds), 0)
+FROM transcription_logs
+WHERE started_at >= sqlc.arg(start_date) AND started_at < sqlc.arg(end_date)
+ON CONFLICT (month, source) DO UPDATE
+SET sessions = EXCLUDED.sessions, duration_seconds = EXCLUDED.duration_seconds, updated_at = NOW();
+
+-- name: RollupTrialUsageMonth :exec
+-- Like RollupTranscriptionLogsMonth, for trial sessions
+INSERT INTO usage_rollups (month, source, sessions, duration_seconds)
+SELECT (sqlc.arg(start_date)::timestamptz AT TIME ZONE 'UTC')::date, 'trial_usage', COUNT(*), COALESCE(SUM(duration_seconds), 0)
+FROM trial_usage
+WHERE started_at >= sqlc.arg(start_date) AND started_at < sqlc.arg(end_date)
+ON CONFLICT (month, source) DO UPDATE
+SET sessions = EXCLUDED.sessions, duration_seconds = EXCLUDED.duration_seconds, updated_at = NOW();
+
+-- name: GetUsageRollupTotals :one
+-- All-time totals, archived months included
+SELECT
+    COALESCE(SUM(sessions), 0)::bigint AS total_sessions,
+    COALESCE(SUM(duration_seconds), 0)::DECIMAL(16,3) AS total_duration_seconds
+FROM usage_rollups;
@@ -0,0 +1,15 @@
+-- =========================
+-- SETTING OVERRIDE QUERIES
+-- =========================
+
+-- name: ListSettingOverrides :many
+SELECT * FROM setting_overrides ORDER BY name;
+
+-- name: SetSettingOverride :exec
+INSERT INTO setting_overrides (name, value, updated_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (name) DO UPDATE
+SET value = EXCLUDED.value, updated_by = EXCLUDED.updated_by, updated_at = NOW();
+
+-- name: DeleteSettingOverride :exec
+DELETE FROM setting_overrides WHERE name = $1;
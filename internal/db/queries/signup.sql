@@ -0,0 +1,12 @@
+-- ======================
+-- SIGNUP POLICY QUERIES
+-- ======================
+
+-- name: GetSignupPolicy :one
+SELECT * FROM signup_policy WHERE id = 1;
+
+-- name: UpdateSignupPolicy :one
+UPDATE signup_policy
+SET enabled = $1, invite_code = $2, allowed_email_domains = $3, updated_at = NOW()
+WHERE id = 1
+RETURNING *;
@@ -0,0 +1,43 @@
+-- =====================
+-- LIMIT SIMULATION QUERIES
+-- =====================
+
+-- name: ListTrialSessionsForSimulation :many
+-- Sessions of the preset's keys that were used in the period, including
+-- earlier sessions that count toward the keys' quotas, in replay order
+SELECT
+    tu.trial_key_id,
+    tak.created_at AS key_created_at,
+    tu.started_at,
+    COALESCE(tu.duration_seconds, 0)::DECIMAL(12,3) AS duration_seconds
+FROM trial_usage tu
+JOIN trial_api_keys tak ON tak.id = tu.trial_key_id
+WHERE tak.preset = sqlc.arg(preset)
+  AND tu.started_at < sqlc.arg(end_date)
+  AND tu.trial_key_id IN (
+      SELECT trial_key_id FROM trial_usage
+      WHERE started_at >= sqlc.arg(start_date) AND started_at < sqlc.arg(end_date)
+  )
+ORDER BY tu.trial_key_id, tu.started_at;
+
+-- name: ListSessionsForSimulation :many
+-- Trial and user sessions started in the period with the plan whose
+-- concurrency limit they held a slot of, in replay order. Sessions without
+-- an end are assumed to have run for their logged duration.
+SELECT
+    'trial'::text AS plan,
+    trial_key_id AS owner_id,
+    started_at,
+    COALESCE(ended_at, started_at + COALESCE(duration_seconds, 0) * INTERVAL '1 second')::timestamptz AS ended_at
+FROM trial_usage
+WHERE started_at >= sqlc.arg(start_date) AND started_at < sqlc.arg(end_date)
+UNION ALL
+SELECT
+    u.user_type::text AS plan,
+    tl.user_id AS owner_id,
+    tl.started_at,
+    COALESCE(tl.ended_at, tl.started_at + COALESCE(tl.duration_seconds, 0) * INTERVAL '1 second')::timestamptz AS ended_at
+FROM transcription_logs tl
+JOIN users u ON u.id = tl.user_id
+WHERE tl.started_at >= sqlc.arg(start_date) AND tl.started_at < sqlc.arg(end_date)
+ORDER BY started_at;
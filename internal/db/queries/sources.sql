@@ -0,0 +1,40 @@
+-- =====================
+-- TRIAL SOURCE QUERIES
+-- =====================
+
+-- name: CreateTrialSource :one
+INSERT INTO trial_sources (name, description)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: DeleteTrialSource :exec
+DELETE FROM trial_sources WHERE name = $1;
+
+-- name: GetTrialSource :one
+SELECT * FROM trial_sources WHERE name = $1;
+
+-- name: ListTrialSources :many
+SELECT * FROM trial_sources ORDER BY name;
+
+-- name: CountTrialKeysForSource :one
+SELECT COUNT(*) FROM trial_api_keys WHERE source = $1;
+
+-- name: GetTrialSourceUsageSummary :many
+-- Keys and usage per source, plus a row with a NULL source for keys
+-- provisioned without one; usage is limited to the period
+SELECT
+    s.name AS source,
+    COUNT(DISTINCT tak.id) AS total_trial_keys,
+    COUNT(DISTINCT CASE WHEN tak.revoked_at IS NULL AND tak.expires_at > NOW() THEN tak.id END) AS active_trial_keys,
+    COUNT(tu.id) AS total_sessions,
+    COALESCE(SUM(tu.duration_seconds), 0)::DECIMAL(12,3) AS total_duration_seconds
+FROM (
+    SELECT name FROM trial_sources
+    UNION ALL
+    SELECT NULL
+) s
+LEFT JOIN trial_api_keys tak ON tak.source IS NOT DISTINCT FROM s.name
+LEFT JOIN trial_usage tu ON tu.trial_key_id = tak.id
+    AND tu.started_at >= sqlc.arg(start_date) AND tu.started_at < sqlc.arg(end_date)
+GROUP BY s.name
+ORDER BY s.name NULLS LAST;
@@ -0,0 +1,22 @@
+-- =========================
+-- USAGE STATEMENT QUERIES
+-- =========================
+
+-- name: GetUserUsageByAPIKey :many
+-- Per-key usage lines of a user's statement
+SELECT
+    ak.id AS api_key_id,
+    ak.name AS api_key_name,
+    ak.key_prefix,
+    COUNT(tl.id) AS total_sessions,
+    COALESCE(SUM(tl.duration_seconds), 0)::DECIMAL(12,3) AS total_duration_seconds
+FROM transcription_logs tl
+JOIN api_keys ak ON ak.id = tl.api_key_id
+WHERE tl.user_id = sqlc.arg(user_id) AND tl.started_at >= sqlc.arg(start_date) AND tl.started_at < sqlc.arg(end_date)
+GROUP BY ak.id, ak.name, ak.key_prefix
+ORDER BY ak.name, ak.key_prefix;
+
+-- name: ListUsersWithUsage :many
+SELECT DISTINCT user_id FROM transcription_logs
+WHERE started_at >= sqlc.arg(start_date) AND started_at < sqlc.arg(end_date)
+ORDER BY user_id;
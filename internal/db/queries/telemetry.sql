@@ -0,0 +1,46 @@
+-- =========================
+-- CLIENT ERROR REPORT QUERIES
+-- =========================
+
+-- name: CreateClientErrorReport :one
+INSERT INTO client_error_reports (
+    kind, message, signature, close_code, app_version, os, details,
+    user_id, api_key_id, trial_key_id, transcription_log_id, trial_usage_id, occurred_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+RETURNING *;
+
+-- name: GetLatestTranscriptionLogForKey :one
+-- Most recent session started within the window, used to correlate a
+-- client report with the server-side session log
+SELECT id FROM transcription_logs
+WHERE api_key_id = sqlc.arg(api_key_id) AND started_at BETWEEN sqlc.arg(window_start) AND sqlc.arg(window_end)
+ORDER BY started_at DESC
+LIMIT 1;
+
+-- name: GetLatestTrialUsageForKey :one
+SELECT id FROM trial_usage
+WHERE trial_key_id = sqlc.arg(trial_key_id) AND started_at BETWEEN sqlc.arg(window_start) AND sqlc.arg(window_end)
+ORDER BY started_at DESC
+LIMIT 1;
+
+-- name: ListClientErrorReports :many
+SELECT r.*,
+       COALESCE(tl.status, tu.status) AS session_status,
+       COALESCE(tl.error_message, tu.error_message) AS session_error
+FROM client_error_reports r
+LEFT JOIN transcription_logs tl ON tl.id = r.transcription_log_id
+LEFT JOIN trial_usage tu ON tu.id = r.trial_usage_id
+WHERE (sqlc.narg(kind)::text IS NULL OR r.kind = sqlc.narg(kind))
+  AND (sqlc.narg(status)::text IS NULL OR r.status = sqlc.narg(status))
+ORDER BY r.created_at DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: CountClientErrorReports :one
+SELECT COUNT(*) FROM client_error_reports
+WHERE (sqlc.narg(kind)::text IS NULL OR kind = sqlc.narg(kind))
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status));
+
+-- name: UpdateClientErrorReportStatus :one
+UPDATE client_error_reports SET status = $2 WHERE id = $1
+RETURNING *;
@@ -0,0 +1,26 @@
+-- =====================
+-- TENANT DOMAIN QUERIES
+-- =====================
+
+-- name: CreateTenantDomain :one
+INSERT INTO tenant_domains (tenant_id, domain, verification_token)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetTenantDomain :one
+SELECT * FROM tenant_domains WHERE tenant_id = $1 AND domain = $2;
+
+-- name: ListTenantDomains :many
+SELECT * FROM tenant_domains WHERE tenant_id = $1 ORDER BY domain;
+
+-- name: ListVerifiedTenantDomains :many
+-- Verified custom domains of every tenant
+SELECT * FROM tenant_domains WHERE verified_at IS NOT NULL ORDER BY domain;
+
+-- name: MarkTenantDomainVerified :one
+UPDATE tenant_domains SET verified_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteTenantDomain :execrows
+DELETE FROM tenant_domains WHERE tenant_id = $1 AND domain = $2;
@@ -0,0 +1,38 @@
+-- ==============
+-- TENANT QUERIES
+-- ==============
+
+-- name: CreateTenant :one
+INSERT INTO tenants (slug, name, domain, branding, deepgram_api_key, trial_preset, cookie_domain, data_region)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetTenant :one
+SELECT * FROM tenants WHERE id = $1;
+
+-- name: GetTenantByDomain :one
+-- The tenant whose primary or verified custom domain this is
+SELECT * FROM tenants
+WHERE domain = $1
+   OR id = (SELECT tenant_id FROM tenant_domains WHERE tenant_domains.domain = $1 AND verified_at IS NOT NULL);
+
+-- name: ListTenants :many
+SELECT * FROM tenants ORDER BY name;
+
+-- name: UpdateTenant :one
+-- Leaves the Deepgram API key as is unless set_deepgram_api_key; a NULL key
+-- goes back to DEEPGRAM_API_KEY
+UPDATE tenants SET
+    name = sqlc.arg(name),
+    domain = sqlc.arg(domain),
+    branding = sqlc.arg(branding),
+    trial_preset = sqlc.arg(trial_preset),
+    cookie_domain = sqlc.narg(cookie_domain),
+    data_region = sqlc.narg(data_region),
+    deepgram_api_key = CASE WHEN sqlc.arg(set_deepgram_api_key)::boolean THEN sqlc.narg(deepgram_api_key) ELSE deepgram_api_key END,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteTenant :execrows
+DELETE FROM tenants WHERE id = $1;
@@ -0,0 +1,22 @@
+-- =====================
+-- SESSION TRANSCRIPT QUERIES
+-- =====================
+
+-- name: CreateSessionTranscript :exec
+INSERT INTO session_transcripts (transcription_log_id, user_id, transcript, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetSessionTranscript :one
+SELECT * FROM session_transcripts
+WHERE transcription_log_id = $1 AND user_id = $2 AND expires_at > NOW();
+
+-- name: DeleteExpiredSessionTranscripts :exec
+DELETE FROM session_transcripts WHERE expires_at <= NOW();
+
+-- name: ListSessionTranscriptsToReencrypt :many
+SELECT transcription_log_id, transcript FROM session_transcripts
+WHERE transcript NOT LIKE sqlc.arg(key_prefix)::text || '%'
+LIMIT sqlc.arg(batch_size);
+
+-- name: UpdateSessionTranscript :exec
+UPDATE session_transcripts SET transcript = $2 WHERE transcription_log_id = $1;
@@ -3,15 +3,23 @@
 -- =====================
 
 -- name: CreateTrialAPIKey :one
-INSERT INTO trial_api_keys (key_hash, key_prefix, device_fingerprint, expires_at)
-VALUES ($1, $2, $3, $4)
+INSERT INTO trial_api_keys (key_hash, key_prefix, device_fingerprint, device_fingerprint_hash, expires_at, preset, subnet_hash, source, tenant_id, last_provisioned_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
 RETURNING *;
 
 -- name: GetTrialAPIKeyByHash :one
 SELECT * FROM trial_api_keys WHERE key_hash = $1 AND revoked_at IS NULL;
 
+-- name: IsTrialAPIKeyRevoked :one
+SELECT EXISTS(SELECT 1 FROM trial_api_keys WHERE key_hash = $1 AND revoked_at IS NOT NULL);
+
 -- name: GetTrialAPIKeyByFingerprint :one
-SELECT * FROM trial_api_keys WHERE device_fingerprint = $1;
+-- Rows created before encryption have no hash until `encryption reencrypt`
+-- runs, so fall back to comparing the plaintext column for them
+SELECT * FROM trial_api_keys
+WHERE device_fingerprint_hash = sqlc.arg(fingerprint_hash)
+   OR (device_fingerprint_hash IS NULL AND device_fingerprint = sqlc.arg(device_fingerprint)::text)
+LIMIT 1;
 
 -- name: GetTrialAPIKeyByID :one
 SELECT * FROM trial_api_keys WHERE id = $1;
@@ -24,10 +32,21 @@ UPDATE trial_api_keys SET revoked_at = NOW() WHERE id = $1;
 
 -- name: RegenerateTrialAPIKey :one
 UPDATE trial_api_keys
-SET key_hash = $2, key_prefix = $3
+SET key_hash = $2, key_prefix = $3, regenerations = regenerations + 1, last_provisioned_at = NOW()
 WHERE id = $1
 RETURNING *;
 
+-- name: RotateTrialAPIKey :one
+-- Only succeeds while the caller's key is still current, so concurrent
+-- rotations cannot both win
+UPDATE trial_api_keys
+SET key_hash = sqlc.arg(new_key_hash), key_prefix = sqlc.arg(key_prefix)
+WHERE id = sqlc.arg(id) AND key_hash = sqlc.arg(current_key_hash) AND revoked_at IS NULL
+RETURNING *;
+
+-- name: CountRecentTrialAPIKeysBySubnet :one
+SELECT COUNT(*) FROM trial_api_keys WHERE subnet_hash = $1 AND created_at >= $2;
+
 -- name: CountTrialAPIKeys :one
 SELECT COUNT(*) FROM trial_api_keys;
 
@@ -42,8 +61,31 @@ SELECT * FROM trial_api_keys ORDER BY created_at DESC LIMIT $1 OFFSET $2;
 -- =====================
 
 -- name: CreateTrialUsageLog :one
-INSERT INTO trial_usage (trial_key_id, deepgram_params, client_ip)
-VALUES ($1, $2, $3)
+INSERT INTO trial_usage (trial_key_id, deepgram_params, client_ip, redaction_audit, session_nonce)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: RetryTrialUsageLog :one
+-- Reopens the log of an earlier attempt of the same client session, like
+-- RetryTranscriptionLog
+UPDATE trial_usage
+SET status = 'active',
+    ended_at = NULL,
+    error_message = NULL,
+    deepgram_params = sqlc.arg(deepgram_params),
+    client_ip = sqlc.arg(client_ip),
+    redaction_audit = sqlc.arg(redaction_audit)
+WHERE id = (
+    SELECT id FROM trial_usage
+    WHERE trial_key_id = sqlc.arg(trial_key_id)
+      AND session_nonce = sqlc.arg(session_nonce)
+      AND started_at >= sqlc.arg(since)
+      AND status = 'error' AND bytes_sent = 0
+    ORDER BY started_at DESC
+    LIMIT 1
+)
+  AND started_at >= sqlc.arg(since)
+  AND status = 'error' AND bytes_sent = 0
 RETURNING *;
 
 -- name: UpdateTrialUsageComplete :exec
@@ -69,6 +111,16 @@ SET ended_at = NOW(),
     bytes_sent = $2
 WHERE id = $1;
 
+-- name: UpdateTrialUsageEstimated :exec
+-- The final metadata never arrived; bill the estimated duration
+UPDATE trial_usage
+SET ended_at = NOW(),
+    duration_seconds = $2,
+    duration_estimated = TRUE,
+    status = 'timeout',
+    bytes_sent = $3
+WHERE id = $1;
+
 -- name: GetTrialUsageLog :one
 SELECT * FROM trial_usage WHERE id = $1;
 
@@ -97,23 +149,6 @@ SELECT
 FROM trial_usage
 WHERE trial_key_id = $1 AND (status = 'completed' OR status = 'timeout');
 
--- =====================
--- TRIAL LIMITS QUERIES
--- =====================
-
--- name: GetTrialLimits :one
-SELECT * FROM trial_limits WHERE id = 1;
-
--- name: UpdateTrialLimits :one
-UPDATE trial_limits
-SET max_duration_seconds = $1,
-    max_sessions = $2,
-    max_session_duration_seconds = $3,
-    expiry_days = $4,
-    updated_at = NOW()
-WHERE id = 1
-RETURNING *;
-
 -- =====================
 -- ADMIN TRIAL QUERIES
 -- =====================
@@ -162,6 +197,10 @@ SELECT COUNT(*) FROM trial_usage;
 -- name: CleanupExpiredTrialKeys :exec
 UPDATE trial_api_keys SET revoked_at = NOW() WHERE expires_at <= NOW() AND revoked_at IS NULL;
 
+-- name: ListExpiredUnrevokedTrialKeyIDs :many
+-- Preview of CleanupExpiredTrialKeys
+SELECT id FROM trial_api_keys WHERE expires_at <= NOW() AND revoked_at IS NULL ORDER BY expires_at;
+
 -- name: UnrevokeTrialAPIKey :exec
 UPDATE trial_api_keys SET revoked_at = NULL WHERE id = $1;
 
@@ -1,6 +1,6 @@
 -- name: CreateUser :one
-INSERT INTO users (username, email, password_hash, first_name, last_name, user_type)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO users (username, email, password_hash, first_name, last_name, user_type, locale, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING *;
 
 -- name: GetUserByID :one
@@ -24,8 +24,22 @@ SELECT EXISTS(SELECT 1 FROM users WHERE username = $1);
 -- name: CountUsers :one
 SELECT COUNT(*) FROM users;
 
+-- name: LockUserSignups :exec
+-- Serializes signups until the transaction ends, so that two concurrent
+-- first signups cannot both become admin
+SELECT pg_advisory_xact_lock(hashtext('users.signup'));
+
 -- name: ListUsers :many
-SELECT * FROM users ORDER BY created_at ASC LIMIT $1 OFFSET $2;
+-- All users, or only those of a tenant
+SELECT * FROM users
+WHERE sqlc.narg(tenant_id)::uuid IS NULL OR tenant_id = sqlc.narg(tenant_id)
+ORDER BY created_at ASC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: CountListedUsers :one
+-- Counts what ListUsers lists
+SELECT COUNT(*) FROM users
+WHERE sqlc.narg(tenant_id)::uuid IS NULL OR tenant_id = sqlc.narg(tenant_id);
 
 -- name: DeleteUser :exec
 DELETE FROM users WHERE id = $1;
@@ -41,11 +55,55 @@ UPDATE users SET
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateUserBillingCycleAnchor :one
+UPDATE users SET billing_cycle_anchor = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserPassword :exec
+UPDATE users SET password_hash = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateUserPreferences :one
+-- Leaves the timezone as is when it is NULL, and the locale unless
+-- set_locale; a NULL locale follows Accept-Language again
+UPDATE users SET
+    timezone = COALESCE(sqlc.narg(timezone), timezone),
+    locale = CASE WHEN sqlc.arg(set_locale)::boolean THEN sqlc.narg(locale) ELSE locale END,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpdateUserTimezone :one
+UPDATE users SET timezone = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: FlagUserSecurity :exec
+-- Marks the account for review after a sign its credentials were stolen
+UPDATE users SET security_flagged_at = NOW() WHERE id = $1;
+
+-- name: ClearUserSecurityFlag :one
+UPDATE users SET security_flagged_at = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SuspendUser :one
+-- Blocks sign-in, token refresh and API key use until unsuspended
+UPDATE users SET suspended_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UnsuspendUser :one
+UPDATE users SET suspended_at = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
 -- Refresh token queries (only refresh tokens are tracked, access tokens are stateless)
 
 -- name: CreateRefreshToken :one
-INSERT INTO tokens (token_jti, user_id, expires_at)
-VALUES ($1, $2, $3)
+INSERT INTO tokens (token_jti, user_id, expires_at, client_ip, user_agent, family_id)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: IsRefreshTokenRevoked :one
@@ -54,6 +112,17 @@ SELECT EXISTS(SELECT 1 FROM tokens WHERE token_jti = $1 AND revoked_at IS NOT NU
 -- name: RevokeRefreshToken :exec
 UPDATE tokens SET revoked_at = NOW(), revoked_reason = $2 WHERE token_jti = $1;
 
+-- name: RotateRefreshToken :execrows
+-- Revokes a refresh token being exchanged for a new one; no rows means it
+-- was already revoked, e.g. rotated by a concurrent refresh
+UPDATE tokens SET revoked_at = NOW(), revoked_reason = 'refreshed'
+WHERE token_jti = $1 AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenFamily :execrows
+-- Revokes every token rotated from the same sign-in
+UPDATE tokens SET revoked_at = NOW(), revoked_reason = $2
+WHERE family_id = $1 AND revoked_at IS NULL;
+
 -- name: RevokeUserRefreshTokens :exec
 UPDATE tokens SET revoked_at = NOW(), revoked_reason = $2 WHERE user_id = $1 AND revoked_at IS NULL;
 
@@ -61,13 +130,55 @@ UPDATE tokens SET revoked_at = NOW(), revoked_reason = $2 WHERE user_id = $1 AND
 SELECT * FROM tokens WHERE token_jti = $1;
 
 -- name: ListRefreshTokens :many
-SELECT * FROM tokens ORDER BY issued_at DESC LIMIT $1 OFFSET $2;
+-- Status is active, revoked or expired (past expiry and never revoked); sort
+-- is issued_at, expires_at or username
+SELECT t.*, u.username, u.email
+FROM tokens t
+JOIN users u ON u.id = t.user_id
+WHERE (sqlc.narg(user_id)::uuid IS NULL OR t.user_id = sqlc.narg(user_id))
+  AND (sqlc.narg(status)::text IS NULL
+    OR (sqlc.narg(status) = 'active' AND t.revoked_at IS NULL AND t.expires_at > NOW())
+    OR (sqlc.narg(status) = 'revoked' AND t.revoked_at IS NOT NULL)
+    OR (sqlc.narg(status) = 'expired' AND t.revoked_at IS NULL AND t.expires_at <= NOW()))
+  AND (sqlc.narg(search)::text IS NULL OR u.username ILIKE '%' || sqlc.narg(search) || '%' OR u.email ILIKE '%' || sqlc.narg(search) || '%')
+  AND (sqlc.narg(issued_from)::timestamptz IS NULL OR t.issued_at >= sqlc.narg(issued_from))
+  AND (sqlc.narg(issued_to)::timestamptz IS NULL OR t.issued_at < sqlc.narg(issued_to))
+ORDER BY
+  CASE WHEN sqlc.arg(sort)::text = 'issued_at' AND NOT sqlc.arg(descending)::bool THEN t.issued_at END ASC,
+  CASE WHEN sqlc.arg(sort) = 'issued_at' AND sqlc.arg(descending) THEN t.issued_at END DESC,
+  CASE WHEN sqlc.arg(sort) = 'expires_at' AND NOT sqlc.arg(descending) THEN t.expires_at END ASC,
+  CASE WHEN sqlc.arg(sort) = 'expires_at' AND sqlc.arg(descending) THEN t.expires_at END DESC,
+  CASE WHEN sqlc.arg(sort) = 'username' AND NOT sqlc.arg(descending) THEN u.username END ASC,
+  CASE WHEN sqlc.arg(sort) = 'username' AND sqlc.arg(descending) THEN u.username END DESC,
+  t.issued_at DESC, t.id
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: ListUserActiveRefreshTokens :many
+-- The signed-in devices of a user, newest first
+SELECT * FROM tokens
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+ORDER BY issued_at DESC;
+
+-- name: RevokeUserRefreshToken :execrows
+-- Revokes one of the user's own active refresh tokens
+UPDATE tokens SET revoked_at = NOW(), revoked_reason = $3
+WHERE token_jti = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW();
 
 -- name: ListUserRefreshTokens :many
 SELECT * FROM tokens WHERE user_id = $1 ORDER BY issued_at DESC LIMIT $2 OFFSET $3;
 
 -- name: CountRefreshTokens :one
-SELECT COUNT(*) FROM tokens;
+SELECT COUNT(*)
+FROM tokens t
+JOIN users u ON u.id = t.user_id
+WHERE (sqlc.narg(user_id)::uuid IS NULL OR t.user_id = sqlc.narg(user_id))
+  AND (sqlc.narg(status)::text IS NULL
+    OR (sqlc.narg(status) = 'active' AND t.revoked_at IS NULL AND t.expires_at > NOW())
+    OR (sqlc.narg(status) = 'revoked' AND t.revoked_at IS NOT NULL)
+    OR (sqlc.narg(status) = 'expired' AND t.revoked_at IS NULL AND t.expires_at <= NOW()))
+  AND (sqlc.narg(search)::text IS NULL OR u.username ILIKE '%' || sqlc.narg(search) || '%' OR u.email ILIKE '%' || sqlc.narg(search) || '%')
+  AND (sqlc.narg(issued_from)::timestamptz IS NULL OR t.issued_at >= sqlc.narg(issued_from))
+  AND (sqlc.narg(issued_to)::timestamptz IS NULL OR t.issued_at < sqlc.narg(issued_to));
 
 -- name: CountUserRefreshTokens :one
 SELECT COUNT(*) FROM tokens WHERE user_id = $1;
@@ -80,3 +191,11 @@ SELECT * FROM tokens WHERE revoked_at IS NULL AND expires_at > NOW() ORDER BY is
 
 -- name: CleanupExpiredRefreshTokens :exec
 DELETE FROM tokens WHERE expires_at <= NOW();
+
+-- name: ListExpiredRefreshTokenJTIs :many
+-- Preview of CleanupExpiredRefreshTokens
+SELECT token_jti FROM tokens WHERE expires_at <= NOW() ORDER BY expires_at;
+
+-- name: ListUnrevokedUserRefreshTokenJTIs :many
+-- Preview of RevokeUserRefreshTokens
+SELECT token_jti FROM tokens WHERE user_id = $1 AND revoked_at IS NULL ORDER BY issued_at;
@@ -11,11 +11,22 @@ import (
 	"encoding/json"
 	"time"
 
+	"hyperwhisper/internal/encryption"
+
 	"github.com/google/uuid"
 )
 
+const adminRevokeAPIKey = `-- name: AdminRevokeAPIKey :exec
+UPDATE api_keys SET revoked_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) AdminRevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, adminRevokeAPIKey, id)
+	return err
+}
+
 const countActiveUserAPIKeys = `-- name: CountActiveUserAPIKeys :one
-SELECT COUNT(*) FROM api_keys WHERE user_id = $1 AND revoked_at IS NULL
+SELECT COUNT(*) FROM api_keys WHERE user_id = $1 AND organization_id IS NULL AND revoked_at IS NULL
 `
 
 func (q *Queries) CountActiveUserAPIKeys(ctx context.Context, userID uuid.UUID) (int64, error) {
@@ -48,7 +59,7 @@ func (q *Queries) CountAllTranscriptionLogs(ctx context.Context) (int64, error)
 }
 
 const countUserAPIKeys = `-- name: CountUserAPIKeys :one
-SELECT COUNT(*) FROM api_keys WHERE user_id = $1
+SELECT COUNT(*) FROM api_keys WHERE user_id = $1 AND organization_id IS NULL
 `
 
 func (q *Queries) CountUserAPIKeys(ctx context.Context, userID uuid.UUID) (int64, error) {
@@ -73,7 +84,7 @@ const createAPIKey = `-- name: CreateAPIKey :one
 
 INSERT INTO api_keys (user_id, key_hash, key_prefix, name)
 VALUES ($1, $2, $3, $4)
-RETURNING id, user_id, key_hash, key_prefix, name, created_at, last_used_at, revoked_at
+RETURNING id, user_id, key_hash, key_prefix, name, created_at, last_used_at, revoked_at, param_restrictions, last_used_ip, last_used_user_agent, use_count, organization_id
 `
 
 type CreateAPIKeyParams struct {
@@ -103,22 +114,29 @@ func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (Api
 		&i.CreatedAt,
 		&i.LastUsedAt,
 		&i.RevokedAt,
+		&i.ParamRestrictions,
+		&i.LastUsedIp,
+		&i.LastUsedUserAgent,
+		&i.UseCount,
+		&i.OrganizationID,
 	)
 	return i, err
 }
 
 const createTranscriptionLog = `-- name: CreateTranscriptionLog :one
 
-INSERT INTO transcription_logs (user_id, api_key_id, deepgram_params, client_ip)
-VALUES ($1, $2, $3, $4)
-RETURNING id, user_id, api_key_id, started_at, ended_at, duration_seconds, status, error_message, deepgram_params, bytes_sent, client_ip
+INSERT INTO transcription_logs (user_id, api_key_id, deepgram_params, client_ip, redaction_audit, session_nonce)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, api_key_id, started_at, ended_at, duration_seconds, status, error_message, deepgram_params, bytes_sent, client_ip, duration_estimated, redaction_audit, session_nonce, error_code
 `
 
 type CreateTranscriptionLogParams struct {
 	UserID         uuid.UUID
 	ApiKeyID       uuid.UUID
 	DeepgramParams json.RawMessage
-	ClientIp       sql.NullString
+	ClientIp       encryption.NullString
+	RedactionAudit json.RawMessage
+	SessionNonce   sql.NullString
 }
 
 // =====================
@@ -130,6 +148,8 @@ func (q *Queries) CreateTranscriptionLog(ctx context.Context, arg CreateTranscri
 		arg.ApiKeyID,
 		arg.DeepgramParams,
 		arg.ClientIp,
+		arg.RedactionAudit,
+		arg.SessionNonce,
 	)
 	var i TranscriptionLog
 	err := row.Scan(
@@ -144,12 +164,16 @@ func (q *Queries) CreateTranscriptionLog(ctx context.Context, arg CreateTranscri
 		&i.DeepgramParams,
 		&i.BytesSent,
 		&i.ClientIp,
+		&i.DurationEstimated,
+		&i.RedactionAudit,
+		&i.SessionNonce,
+		&i.ErrorCode,
 	)
 	return i, err
 }
 
 const deleteAPIKey = `-- name: DeleteAPIKey :exec
-DELETE FROM api_keys WHERE id = $1 AND user_id = $2
+DELETE FROM api_keys WHERE id = $1 AND user_id = $2 AND organization_id IS NULL
 `
 
 type DeleteAPIKeyParams struct {
@@ -163,9 +187,13 @@ func (q *Queries) DeleteAPIKey(ctx context.Context, arg DeleteAPIKeyParams) erro
 }
 
 const getAPIKeyByHash = `-- name: GetAPIKeyByHash :one
-SELECT id, user_id, key_hash, key_prefix, name, created_at, last_used_at, revoked_at FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL
+SELECT id, user_id, key_hash, key_prefix, name, created_at, last_used_at, revoked_at, param_restrictions, last_used_ip, last_used_user_agent, use_count, organization_id FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL
+    AND (organization_id IS NOT NULL
+        OR NOT EXISTS (SELECT 1 FROM users u WHERE u.id = api_keys.user_id AND u.suspended_at IS NOT NULL))
 `
 
+// Personal keys of suspended users don't resolve. Organization keys belong
+// to the organization and outlive their creator's suspension.
 func (q *Queries) GetAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey, error) {
 	row := q.db.QueryRowContext(ctx, getAPIKeyByHash, keyHash)
 	var i ApiKey
@@ -178,12 +206,17 @@ func (q *Queries) GetAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey,
 		&i.CreatedAt,
 		&i.LastUsedAt,
 		&i.RevokedAt,
+		&i.ParamRestrictions,
+		&i.LastUsedIp,
+		&i.LastUsedUserAgent,
+		&i.UseCount,
+		&i.OrganizationID,
 	)
 	return i, err
 }
 
 const getAPIKeyByID = `-- name: GetAPIKeyByID :one
-SELECT id, user_id, key_hash, key_prefix, name, created_at, last_used_at, revoked_at FROM api_keys WHERE id = $1
+SELECT id, user_id, key_hash, key_prefix, name, created_at, last_used_at, revoked_at, param_restrictions, last_used_ip, last_used_user_agent, use_count, organization_id FROM api_keys WHERE id = $1
 `
 
 func (q *Queries) GetAPIKeyByID(ctx context.Context, id uuid.UUID) (ApiKey, error) {
@@ -198,10 +231,36 @@ func (q *Queries) GetAPIKeyByID(ctx context.Context, id uuid.UUID) (ApiKey, erro
 		&i.CreatedAt,
 		&i.LastUsedAt,
 		&i.RevokedAt,
+		&i.ParamRestrictions,
+		&i.LastUsedIp,
+		&i.LastUsedUserAgent,
+		&i.UseCount,
+		&i.OrganizationID,
 	)
 	return i, err
 }
 
+const getRefusedAPIKeyByHash = `-- name: GetRefusedAPIKeyByHash :one
+SELECT k.user_id, k.revoked_at, u.suspended_at
+FROM api_keys k
+JOIN users u ON u.id = k.user_id
+WHERE k.key_hash = $1
+`
+
+type GetRefusedAPIKeyByHashRow struct {
+	UserID      uuid.UUID
+	RevokedAt   sql.NullTime
+	SuspendedAt sql.NullTime
+}
+
+// Why GetAPIKeyByHash didn't resolve a key: it's revoked or its user suspended
+func (q *Queries) GetRefusedAPIKeyByHash(ctx context.Context, keyHash string) (GetRefusedAPIKeyByHashRow, error) {
+	row := q.db.QueryRowContext(ctx, getRefusedAPIKeyByHash, keyHash)
+	var i GetRefusedAPIKeyByHashRow
+	err := row.Scan(&i.UserID, &i.RevokedAt, &i.SuspendedAt)
+	return i, err
+}
+
 const getSystemUsageSummary = `-- name: GetSystemUsageSummary :one
 SELECT
     COUNT(DISTINCT user_id) as unique_users,
@@ -237,7 +296,7 @@ func (q *Queries) GetSystemUsageSummary(ctx context.Context, arg GetSystemUsageS
 }
 
 const getTranscriptionLog = `-- name: GetTranscriptionLog :one
-SELECT id, user_id, api_key_id, started_at, ended_at, duration_seconds, status, error_message, deepgram_params, bytes_sent, client_ip FROM transcription_logs WHERE id = $1
+SELECT id, user_id, api_key_id, started_at, ended_at, duration_seconds, status, error_message, deepgram_params, bytes_sent, client_ip, duration_estimated, redaction_audit, session_nonce, error_code FROM transcription_logs WHERE id = $1
 `
 
 func (q *Queries) GetTranscriptionLog(ctx context.Context, id uuid.UUID) (TranscriptionLog, error) {
@@ -255,6 +314,10 @@ func (q *Queries) GetTranscriptionLog(ctx context.Context, id uuid.UUID) (Transc
 		&i.DeepgramParams,
 		&i.BytesSent,
 		&i.ClientIp,
+		&i.DurationEstimated,
+		&i.RedactionAudit,
+		&i.SessionNonce,
+		&i.ErrorCode,
 	)
 	return i, err
 }
@@ -333,7 +396,7 @@ func (q *Queries) GetUserUsageSummaryByStatus(ctx context.Context, arg GetUserUs
 }
 
 const listAllAPIKeys = `-- name: ListAllAPIKeys :many
-SELECT ak.id, ak.user_id, ak.key_hash, ak.key_prefix, ak.name, ak.created_at, ak.last_used_at, ak.revoked_at, u.username, u.email
+SELECT ak.id, ak.user_id, ak.key_hash, ak.key_prefix, ak.name, ak.created_at, ak.last_used_at, ak.revoked_at, ak.param_restrictions, ak.last_used_ip, ak.last_used_user_agent, ak.use_count, ak.organization_id, u.username, u.email
 FROM api_keys ak
 JOIN users u ON ak.user_id = u.id
 ORDER BY ak.created_at DESC
@@ -346,16 +409,21 @@ type ListAllAPIKeysParams struct {
 }
 
 type ListAllAPIKeysRow struct {
-	ID         uuid.UUID
-	UserID     uuid.UUID
-	KeyHash    string
-	KeyPrefix  string
-	Name       string
-	CreatedAt  sql.NullTime
-	LastUsedAt sql.NullTime
-	RevokedAt  sql.NullTime
-	Username   string
-	Email      string
+	ID                uuid.UUID
+	UserID            uuid.UUID
+	KeyHash           string
+	KeyPrefix         string
+	Name              string
+	CreatedAt         sql.NullTime
+	LastUsedAt        sql.NullTime
+	RevokedAt         sql.NullTime
+	ParamRestrictions json.RawMessage
+	LastUsedIp        encryption.NullString
+	LastUsedUserAgent sql.NullString
+	UseCount          int64
+	OrganizationID    uuid.NullUUID
+	Username          string
+	Email             string
 }
 
 func (q *Queries) ListAllAPIKeys(ctx context.Context, arg ListAllAPIKeysParams) ([]ListAllAPIKeysRow, error) {
@@ -376,6 +444,11 @@ func (q *Queries) ListAllAPIKeys(ctx context.Context, arg ListAllAPIKeysParams)
 			&i.CreatedAt,
 			&i.LastUsedAt,
 			&i.RevokedAt,
+			&i.ParamRestrictions,
+			&i.LastUsedIp,
+			&i.LastUsedUserAgent,
+			&i.UseCount,
+			&i.OrganizationID,
 			&i.Username,
 			&i.Email,
 		); err != nil {
@@ -394,7 +467,7 @@ func (q *Queries) ListAllAPIKeys(ctx context.Context, arg ListAllAPIKeysParams)
 
 const listAllTranscriptionLogs = `-- name: ListAllTranscriptionLogs :many
 
-SELECT tl.id, tl.user_id, tl.api_key_id, tl.started_at, tl.ended_at, tl.duration_seconds, tl.status, tl.error_message, tl.deepgram_params, tl.bytes_sent, tl.client_ip, u.username, u.email, ak.name as api_key_name
+SELECT tl.id, tl.user_id, tl.api_key_id, tl.started_at, tl.ended_at, tl.duration_seconds, tl.status, tl.error_message, tl.deepgram_params, tl.bytes_sent, tl.client_ip, tl.duration_estimated, tl.redaction_audit, tl.session_nonce, tl.error_code, u.username, u.email, ak.name as api_key_name
 FROM transcription_logs tl
 JOIN users u ON tl.user_id = u.id
 JOIN api_keys ak ON tl.api_key_id = ak.id
@@ -408,20 +481,24 @@ type ListAllTranscriptionLogsParams struct {
 }
 
 type ListAllTranscriptionLogsRow struct {
-	ID              uuid.UUID
-	UserID          uuid.UUID
-	ApiKeyID        uuid.UUID
-	StartedAt       time.Time
-	EndedAt         sql.NullTime
-	DurationSeconds sql.NullString
-	Status          string
-	ErrorMessage    sql.NullString
-	DeepgramParams  json.RawMessage
-	BytesSent       int64
-	ClientIp        sql.NullString
-	Username        string
-	Email           string
-	ApiKeyName      string
+	ID                uuid.UUID
+	UserID            uuid.UUID
+	ApiKeyID          uuid.UUID
+	StartedAt         time.Time
+	EndedAt           sql.NullTime
+	DurationSeconds   sql.NullString
+	Status            string
+	ErrorMessage      sql.NullString
+	DeepgramParams    json.RawMessage
+	BytesSent         int64
+	ClientIp          encryption.NullString
+	DurationEstimated bool
+	RedactionAudit    json.RawMessage
+	SessionNonce      sql.NullString
+	ErrorCode         sql.NullString
+	Username          string
+	Email             string
+	ApiKeyName        string
 }
 
 // =====================
@@ -448,6 +525,10 @@ func (q *Queries) ListAllTranscriptionLogs(ctx context.Context, arg ListAllTrans
 			&i.DeepgramParams,
 			&i.BytesSent,
 			&i.ClientIp,
+			&i.DurationEstimated,
+			&i.RedactionAudit,
+			&i.SessionNonce,
+			&i.ErrorCode,
 			&i.Username,
 			&i.Email,
 			&i.ApiKeyName,
@@ -465,8 +546,36 @@ func (q *Queries) ListAllTranscriptionLogs(ctx context.Context, arg ListAllTrans
 	return items, nil
 }
 
+const listUserAPIKeyIDs = `-- name: ListUserAPIKeyIDs :many
+SELECT id FROM api_keys WHERE user_id = $1
+`
+
+// Every key the user owns, organization keys included
+func (q *Queries) ListUserAPIKeyIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listUserAPIKeyIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listUserAPIKeys = `-- name: ListUserAPIKeys :many
-SELECT id, user_id, key_hash, key_prefix, name, created_at, last_used_at, revoked_at FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3
+SELECT id, user_id, key_hash, key_prefix, name, created_at, last_used_at, revoked_at, param_restrictions, last_used_ip, last_used_user_agent, use_count, organization_id FROM api_keys WHERE user_id = $1 AND organization_id IS NULL ORDER BY created_at DESC LIMIT $2 OFFSET $3
 `
 
 type ListUserAPIKeysParams struct {
@@ -475,6 +584,7 @@ type ListUserAPIKeysParams struct {
 	Offset int32
 }
 
+// Personal keys only; organization keys are listed per organization
 func (q *Queries) ListUserAPIKeys(ctx context.Context, arg ListUserAPIKeysParams) ([]ApiKey, error) {
 	rows, err := q.db.QueryContext(ctx, listUserAPIKeys, arg.UserID, arg.Limit, arg.Offset)
 	if err != nil {
@@ -493,6 +603,11 @@ func (q *Queries) ListUserAPIKeys(ctx context.Context, arg ListUserAPIKeysParams
 			&i.CreatedAt,
 			&i.LastUsedAt,
 			&i.RevokedAt,
+			&i.ParamRestrictions,
+			&i.LastUsedIp,
+			&i.LastUsedUserAgent,
+			&i.UseCount,
+			&i.OrganizationID,
 		); err != nil {
 			return nil, err
 		}
@@ -508,7 +623,7 @@ func (q *Queries) ListUserAPIKeys(ctx context.Context, arg ListUserAPIKeysParams
 }
 
 const listUserTranscriptionLogs = `-- name: ListUserTranscriptionLogs :many
-SELECT id, user_id, api_key_id, started_at, ended_at, duration_seconds, status, error_message, deepgram_params, bytes_sent, client_ip FROM transcription_logs WHERE user_id = $1 ORDER BY started_at DESC LIMIT $2 OFFSET $3
+SELECT id, user_id, api_key_id, started_at, ended_at, duration_seconds, status, error_message, deepgram_params, bytes_sent, client_ip, duration_estimated, redaction_audit, session_nonce, error_code FROM transcription_logs WHERE user_id = $1 ORDER BY started_at DESC LIMIT $2 OFFSET $3
 `
 
 type ListUserTranscriptionLogsParams struct {
@@ -538,6 +653,10 @@ func (q *Queries) ListUserTranscriptionLogs(ctx context.Context, arg ListUserTra
 			&i.DeepgramParams,
 			&i.BytesSent,
 			&i.ClientIp,
+			&i.DurationEstimated,
+			&i.RedactionAudit,
+			&i.SessionNonce,
+			&i.ErrorCode,
 		); err != nil {
 			return nil, err
 		}
@@ -552,8 +671,73 @@ func (q *Queries) ListUserTranscriptionLogs(ctx context.Context, arg ListUserTra
 	return items, nil
 }
 
+const retryTranscriptionLog = `-- name: RetryTranscriptionLog :one
+UPDATE transcription_logs
+SET status = 'active',
+    ended_at = NULL,
+    error_message = NULL,
+    error_code = NULL,
+    deepgram_params = $1,
+    client_ip = $2,
+    redaction_audit = $3
+WHERE id = (
+    SELECT id FROM transcription_logs
+    WHERE api_key_id = $4
+      AND session_nonce = $5
+      AND started_at >= $6
+      AND status = 'error' AND bytes_sent = 0
+    ORDER BY started_at DESC
+    LIMIT 1
+)
+  AND started_at >= $6
+  AND status = 'error' AND bytes_sent = 0
+RETURNING id, user_id, api_key_id, started_at, ended_at, duration_seconds, status, error_message, deepgram_params, bytes_sent, client_ip, duration_estimated, redaction_audit, session_nonce, error_code
+`
+
+type RetryTranscriptionLogParams struct {
+	DeepgramParams json.RawMessage
+	ClientIp       encryption.NullString
+	RedactionAudit json.RawMessage
+	ApiKeyID       uuid.UUID
+	SessionNonce   sql.NullString
+	Since          time.Time
+}
+
+// Reopens the log of an earlier attempt of the same client session (same key
+// and nonce, started since the given time) whose connection failed before
+// any audio was sent, so a retry does not leave a failed entry behind
+func (q *Queries) RetryTranscriptionLog(ctx context.Context, arg RetryTranscriptionLogParams) (TranscriptionLog, error) {
+	row := q.db.QueryRowContext(ctx, retryTranscriptionLog,
+		arg.DeepgramParams,
+		arg.ClientIp,
+		arg.RedactionAudit,
+		arg.ApiKeyID,
+		arg.SessionNonce,
+		arg.Since,
+	)
+	var i TranscriptionLog
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ApiKeyID,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.DurationSeconds,
+		&i.Status,
+		&i.ErrorMessage,
+		&i.DeepgramParams,
+		&i.BytesSent,
+		&i.ClientIp,
+		&i.DurationEstimated,
+		&i.RedactionAudit,
+		&i.SessionNonce,
+		&i.ErrorCode,
+	)
+	return i, err
+}
+
 const revokeAPIKey = `-- name: RevokeAPIKey :exec
-UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2
+UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND organization_id IS NULL
 `
 
 type RevokeAPIKeyParams struct {
@@ -566,15 +750,115 @@ func (q *Queries) RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) erro
 	return err
 }
 
+const transferAPIKey = `-- name: TransferAPIKey :one
+UPDATE api_keys SET user_id = $2, organization_id = NULL WHERE id = $1
+RETURNING id, user_id, key_hash, key_prefix, name, created_at, last_used_at, revoked_at, param_restrictions, last_used_ip, last_used_user_agent, use_count, organization_id
+`
+
+type TransferAPIKeyParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) TransferAPIKey(ctx context.Context, arg TransferAPIKeyParams) (ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, transferAPIKey, arg.ID, arg.UserID)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.KeyHash,
+		&i.KeyPrefix,
+		&i.Name,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.ParamRestrictions,
+		&i.LastUsedIp,
+		&i.LastUsedUserAgent,
+		&i.UseCount,
+		&i.OrganizationID,
+	)
+	return i, err
+}
+
+const transferAPIKeyTranscriptionLogs = `-- name: TransferAPIKeyTranscriptionLogs :execrows
+UPDATE transcription_logs SET user_id = $2 WHERE api_key_id = $1
+`
+
+type TransferAPIKeyTranscriptionLogsParams struct {
+	ApiKeyID uuid.UUID
+	UserID   uuid.UUID
+}
+
+// Moves the key's usage history so it counts toward the new owner
+func (q *Queries) TransferAPIKeyTranscriptionLogs(ctx context.Context, arg TransferAPIKeyTranscriptionLogsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, transferAPIKeyTranscriptionLogs, arg.ApiKeyID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const unrevokeAPIKey = `-- name: UnrevokeAPIKey :exec
+UPDATE api_keys SET revoked_at = NULL WHERE id = $1
+`
+
+func (q *Queries) UnrevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, unrevokeAPIKey, id)
+	return err
+}
+
 const updateAPIKeyLastUsed = `-- name: UpdateAPIKeyLastUsed :exec
-UPDATE api_keys SET last_used_at = NOW() WHERE id = $1
+UPDATE api_keys
+SET last_used_at = NOW(),
+    last_used_ip = $2,
+    last_used_user_agent = $3,
+    use_count = use_count + 1
+WHERE id = $1
 `
 
-func (q *Queries) UpdateAPIKeyLastUsed(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, updateAPIKeyLastUsed, id)
+type UpdateAPIKeyLastUsedParams struct {
+	ID                uuid.UUID
+	LastUsedIp        encryption.NullString
+	LastUsedUserAgent sql.NullString
+}
+
+func (q *Queries) UpdateAPIKeyLastUsed(ctx context.Context, arg UpdateAPIKeyLastUsedParams) error {
+	_, err := q.db.ExecContext(ctx, updateAPIKeyLastUsed, arg.ID, arg.LastUsedIp, arg.LastUsedUserAgent)
 	return err
 }
 
+const updateAPIKeyParamRestrictions = `-- name: UpdateAPIKeyParamRestrictions :one
+UPDATE api_keys SET param_restrictions = $2 WHERE id = $1
+RETURNING id, user_id, key_hash, key_prefix, name, created_at, last_used_at, revoked_at, param_restrictions, last_used_ip, last_used_user_agent, use_count, organization_id
+`
+
+type UpdateAPIKeyParamRestrictionsParams struct {
+	ID                uuid.UUID
+	ParamRestrictions json.RawMessage
+}
+
+func (q *Queries) UpdateAPIKeyParamRestrictions(ctx context.Context, arg UpdateAPIKeyParamRestrictionsParams) (ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, updateAPIKeyParamRestrictions, arg.ID, arg.ParamRestrictions)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.KeyHash,
+		&i.KeyPrefix,
+		&i.Name,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.ParamRestrictions,
+		&i.LastUsedIp,
+		&i.LastUsedUserAgent,
+		&i.UseCount,
+		&i.OrganizationID,
+	)
+	return i, err
+}
+
 const updateTranscriptionLogComplete = `-- name: UpdateTranscriptionLogComplete :exec
 UPDATE transcription_logs
 SET ended_at = NOW(),
@@ -600,18 +884,54 @@ UPDATE transcription_logs
 SET ended_at = NOW(),
     status = 'error',
     error_message = $2,
-    bytes_sent = $3
+    bytes_sent = $3,
+    error_code = $4,
+    duration_seconds = $5,
+    duration_estimated = $6
 WHERE id = $1
 `
 
 type UpdateTranscriptionLogErrorParams struct {
-	ID           uuid.UUID
-	ErrorMessage sql.NullString
-	BytesSent    int64
+	ID                uuid.UUID
+	ErrorMessage      sql.NullString
+	BytesSent         int64
+	ErrorCode         sql.NullString
+	DurationSeconds   sql.NullString
+	DurationEstimated bool
 }
 
+// Audio streamed before the failure is still billed
 func (q *Queries) UpdateTranscriptionLogError(ctx context.Context, arg UpdateTranscriptionLogErrorParams) error {
-	_, err := q.db.ExecContext(ctx, updateTranscriptionLogError, arg.ID, arg.ErrorMessage, arg.BytesSent)
+	_, err := q.db.ExecContext(ctx, updateTranscriptionLogError,
+		arg.ID,
+		arg.ErrorMessage,
+		arg.BytesSent,
+		arg.ErrorCode,
+		arg.DurationSeconds,
+		arg.DurationEstimated,
+	)
+	return err
+}
+
+const updateTranscriptionLogEstimated = `-- name: UpdateTranscriptionLogEstimated :exec
+UPDATE transcription_logs
+SET ended_at = NOW(),
+    duration_seconds = $2,
+    duration_estimated = TRUE,
+    status = 'timeout',
+    bytes_sent = $3
+WHERE id = $1
+`
+
+type UpdateTranscriptionLogEstimatedParams struct {
+	ID              uuid.UUID
+	DurationSeconds sql.NullString
+	BytesSent       int64
+}
+
+// The final metadata never arrived; bill the estimated duration
+func (q *Queries) UpdateTranscriptionLogEstimated(ctx context.Context, arg UpdateTranscriptionLogEstimatedParams) error {
+	_, err := q.db.ExecContext(ctx, updateTranscriptionLogEstimated, arg.ID, arg.DurationSeconds, arg.BytesSent)
 	return err
 }
 
@@ -9,20 +9,341 @@ import (
 	"encoding/json"
 	"time"
 
+	"hyperwhisper/internal/encryption"
+
 	"github.com/google/uuid"
 )
 
+type AccessLog struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	Method       string
+	Route        string
+	Path         string
+	Status       int32
+	LatencyMs    int32
+	UserID       uuid.NullUUID
+	ClientIp     encryption.NullString
+	ClientIpHash sql.NullString
+	UserAgent    string
+}
+
+type AccessTokenDenial struct {
+	ID           int64
+	TokenJti     sql.NullString
+	UserID       uuid.NullUUID
+	IssuedBefore sql.NullTime
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+type AccountDeletion struct {
+	UserID      uuid.UUID
+	RequestedAt time.Time
+	DeleteAfter time.Time
+}
+
 type ApiKey struct {
+	ID                uuid.UUID
+	UserID            uuid.UUID
+	KeyHash           string
+	KeyPrefix         string
+	Name              string
+	CreatedAt         sql.NullTime
+	LastUsedAt        sql.NullTime
+	RevokedAt         sql.NullTime
+	ParamRestrictions json.RawMessage
+	LastUsedIp        encryption.NullString
+	LastUsedUserAgent sql.NullString
+	UseCount          int64
+	OrganizationID    uuid.NullUUID
+}
+
+type ApiKeyInactivityNotice struct {
+	ApiKeyID      uuid.UUID
+	TokenHash     string
+	WarnedAt      time.Time
+	RevokedAt     sql.NullTime
+	ReactivatedAt sql.NullTime
+}
+
+type ApiKeyIpSighting struct {
+	ApiKeyID   uuid.UUID
+	IpHash     string
+	LastSeenAt time.Time
+}
+
+type ApiKeyLimit struct {
+	UserID    uuid.UUID
+	MaxKeys   int32
+	UpdatedAt time.Time
+}
+
+type ApiKeyLockdown struct {
+	ID        uuid.UUID
+	ApiKeyID  uuid.UUID
+	Reason    string
+	Details   json.RawMessage
+	CreatedAt time.Time
+	LiftedAt  sql.NullTime
+	LiftedBy  uuid.NullUUID
+}
+
+type AuditEvent struct {
+	ID          uuid.UUID
+	ActorUserID uuid.NullUUID
+	Action      string
+	TargetType  string
+	TargetID    string
+	Reason      sql.NullString
+	Metadata    json.RawMessage
+	CreatedAt   time.Time
+}
+
+type AuthEvent struct {
+	ID           uuid.UUID
+	KeyPrefix    string
+	Outcome      string
+	UserID       uuid.NullUUID
+	ClientIp     encryption.NullString
+	ClientIpHash sql.NullString
+	UserAgent    string
+	CreatedAt    time.Time
+}
+
+type ClientErrorReport struct {
+	ID                 uuid.UUID
+	Kind               string
+	Message            string
+	Signature          sql.NullString
+	CloseCode          sql.NullInt32
+	AppVersion         sql.NullString
+	Os                 sql.NullString
+	Details            json.RawMessage
+	UserID             uuid.NullUUID
+	ApiKeyID           uuid.NullUUID
+	TrialKeyID         uuid.NullUUID
+	TranscriptionLogID uuid.NullUUID
+	TrialUsageID       uuid.NullUUID
+	Status             string
+	OccurredAt         time.Time
+	CreatedAt          time.Time
+}
+
+type DeepgramDefault struct {
+	ID        int32
+	Params    json.RawMessage
+	UpdatedAt time.Time
+}
+
+type EncryptionKey struct {
+	ID          int32
+	Purpose     string
+	WrappedKey  string
+	MasterKeyID string
+	CreatedAt   time.Time
+	RetiredAt   sql.NullTime
+}
+
+type ExportJob struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Kind        string
+	Params      json.RawMessage
+	Status      string
+	RowsWritten int64
+	RowsTotal   int64
+	FileName    sql.NullString
+	SizeBytes   int64
+	Error       sql.NullString
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	StartedAt   sql.NullTime
+	CompletedAt sql.NullTime
+	ExpiresAt   sql.NullTime
+}
+
+type Invite struct {
 	ID         uuid.UUID
-	UserID     uuid.UUID
-	KeyHash    string
-	KeyPrefix  string
-	Name       string
-	CreatedAt  sql.NullTime
-	LastUsedAt sql.NullTime
+	CodeHash   string
+	CodePrefix string
+	Note       string
+	UserType   string
+	MaxUses    int32
+	Uses       int32
+	ExpiresAt  sql.NullTime
+	CreatedBy  uuid.NullUUID
+	CreatedAt  time.Time
 	RevokedAt  sql.NullTime
 }
 
+type KnownDevice struct {
+	UserID      uuid.UUID
+	DeviceHash  string
+	UserAgent   string
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+}
+
+type LoginEvent struct {
+	ID           uuid.UUID
+	UserID       uuid.NullUUID
+	Kind         string
+	Outcome      string
+	ClientIp     encryption.NullString
+	ClientIpHash sql.NullString
+	UserAgent    string
+	CreatedAt    time.Time
+}
+
+type OauthAuthorizationCode struct {
+	CodeHash      string
+	ClientID      uuid.NullUUID
+	UserID        uuid.UUID
+	RedirectUri   string
+	Scopes        []string
+	CodeChallenge sql.NullString
+	ExpiresAt     time.Time
+	UsedAt        sql.NullTime
+	CreatedAt     time.Time
+}
+
+type OauthClient struct {
+	ID               uuid.UUID
+	ClientID         string
+	ClientSecretHash string
+	Name             string
+	RedirectUris     []string
+	Scopes           []string
+	OwnerUserID      uuid.UUID
+	CreatedAt        time.Time
+}
+
+type OauthDeviceCode struct {
+	DeviceCodeHash string
+	UserCode       string
+	ClientID       string
+	Scopes         []string
+	Status         string
+	UserID         uuid.NullUUID
+	LastPolledAt   sql.NullTime
+	ExpiresAt      time.Time
+	CreatedAt      time.Time
+}
+
+type OauthGrant struct {
+	ID               uuid.UUID
+	ClientID         uuid.UUID
+	UserID           uuid.UUID
+	Scopes           []string
+	RefreshTokenHash string
+	CreatedAt        time.Time
+	LastUsedAt       sql.NullTime
+	ExpiresAt        time.Time
+	RevokedAt        sql.NullTime
+}
+
+type Organization struct {
+	ID                  uuid.UUID
+	Name                string
+	MonthlyQuotaSeconds int32
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	DataRegion          sql.NullString
+}
+
+type OrganizationMember struct {
+	OrganizationID uuid.UUID
+	UserID         uuid.UUID
+	Role           string
+	CreatedAt      time.Time
+}
+
+type PasswordHistory struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	PasswordHash string
+	CreatedAt    time.Time
+}
+
+type PasswordResetToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type ReportSchedule struct {
+	ID        uuid.UUID
+	Report    string
+	Channel   string
+	Target    string
+	NextRunAt time.Time
+	LastRunAt sql.NullTime
+	LastError sql.NullString
+	CreatedBy uuid.NullUUID
+	CreatedAt time.Time
+}
+
+type SessionPolicy struct {
+	ID         uuid.UUID
+	Name       string
+	Scope      string
+	ScopeValue sql.NullString
+	Params     json.RawMessage
+	Enabled    bool
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type SessionTranscript struct {
+	TranscriptionLogID uuid.UUID
+	UserID             uuid.UUID
+	Transcript         encryption.String
+	CreatedAt          time.Time
+	ExpiresAt          time.Time
+}
+
+type SettingOverride struct {
+	Name      string
+	Value     string
+	UpdatedBy uuid.NullUUID
+	UpdatedAt time.Time
+}
+
+type SignupPolicy struct {
+	ID                  int32
+	Enabled             bool
+	InviteCode          sql.NullString
+	AllowedEmailDomains []string
+	UpdatedAt           time.Time
+}
+
+type Tenant struct {
+	ID             uuid.UUID
+	Slug           string
+	Name           string
+	Domain         string
+	Branding       json.RawMessage
+	DeepgramApiKey encryption.NullString
+	TrialPreset    string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	CookieDomain   sql.NullString
+	DataRegion     sql.NullString
+}
+
+type TenantDomain struct {
+	ID                uuid.UUID
+	TenantID          uuid.UUID
+	Domain            string
+	VerificationToken string
+	VerifiedAt        sql.NullTime
+	CreatedAt         time.Time
+}
+
 type Token struct {
 	ID            uuid.UUID
 	TokenJti      string
@@ -31,63 +352,122 @@ type Token struct {
 	ExpiresAt     time.Time
 	RevokedAt     sql.NullTime
 	RevokedReason sql.NullString
+	ClientIp      encryption.NullString
+	UserAgent     sql.NullString
+	FamilyID      uuid.UUID
 }
 
 type TranscriptionLog struct {
-	ID              uuid.UUID
-	UserID          uuid.UUID
-	ApiKeyID        uuid.UUID
-	StartedAt       time.Time
-	EndedAt         sql.NullTime
-	DurationSeconds sql.NullString
-	Status          string
-	ErrorMessage    sql.NullString
-	DeepgramParams  json.RawMessage
-	BytesSent       int64
-	ClientIp        sql.NullString
+	ID                uuid.UUID
+	UserID            uuid.UUID
+	ApiKeyID          uuid.UUID
+	StartedAt         time.Time
+	EndedAt           sql.NullTime
+	DurationSeconds   sql.NullString
+	Status            string
+	ErrorMessage      sql.NullString
+	DeepgramParams    json.RawMessage
+	BytesSent         int64
+	ClientIp          encryption.NullString
+	DurationEstimated bool
+	RedactionAudit    json.RawMessage
+	SessionNonce      sql.NullString
+	ErrorCode         sql.NullString
 }
 
 type TrialApiKey struct {
-	ID                uuid.UUID
-	KeyHash           string
-	KeyPrefix         string
-	DeviceFingerprint string
-	CreatedAt         sql.NullTime
-	ExpiresAt         time.Time
-	LastUsedAt        sql.NullTime
-	RevokedAt         sql.NullTime
+	ID                    uuid.UUID
+	KeyHash               string
+	KeyPrefix             string
+	DeviceFingerprint     encryption.String
+	CreatedAt             sql.NullTime
+	ExpiresAt             time.Time
+	LastUsedAt            sql.NullTime
+	RevokedAt             sql.NullTime
+	DeviceFingerprintHash sql.NullString
+	Preset                string
+	Regenerations         int32
+	LastProvisionedAt     sql.NullTime
+	SubnetHash            sql.NullString
+	Source                sql.NullString
+	TenantID              uuid.NullUUID
+}
+
+type TrialConversion struct {
+	ID               uuid.UUID
+	TrialKeyID       uuid.NullUUID
+	UserID           uuid.UUID
+	CarryoverSeconds int32
+	BonusSeconds     int32
+	ConvertedAt      time.Time
 }
 
-type TrialLimit struct {
-	ID                        int32
+type TrialGrant struct {
+	ID             uuid.UUID
+	TrialKeyID     uuid.UUID
+	GrantedSeconds int32
+	IssuedAt       time.Time
+	ExpiresAt      time.Time
+	ReconciledAt   sql.NullTime
+	UsedSeconds    sql.NullString
+}
+
+type TrialPreset struct {
+	Name                      string
+	Description               string
 	MaxDurationSeconds        int32
 	MaxSessions               int32
 	MaxSessionDurationSeconds int32
 	ExpiryDays                int32
-	UpdatedAt                 sql.NullTime
+	ParamRestrictions         json.RawMessage
+	CreatedAt                 time.Time
+	UpdatedAt                 time.Time
+}
+
+type TrialSource struct {
+	Name        string
+	Description string
+	CreatedAt   time.Time
 }
 
 type TrialUsage struct {
-	ID              uuid.UUID
-	TrialKeyID      uuid.UUID
-	StartedAt       time.Time
-	EndedAt         sql.NullTime
-	DurationSeconds sql.NullString
-	Status          string
-	ErrorMessage    sql.NullString
-	DeepgramParams  json.RawMessage
-	BytesSent       int64
-	ClientIp        sql.NullString
+	ID                uuid.UUID
+	TrialKeyID        uuid.UUID
+	StartedAt         time.Time
+	EndedAt           sql.NullTime
+	DurationSeconds   sql.NullString
+	Status            string
+	ErrorMessage      sql.NullString
+	DeepgramParams    json.RawMessage
+	BytesSent         int64
+	ClientIp          encryption.NullString
+	DurationEstimated bool
+	RedactionAudit    json.RawMessage
+	SessionNonce      sql.NullString
+}
+
+type UsageRollup struct {
+	Month           time.Time
+	Source          string
+	Sessions        int64
+	DurationSeconds string
+	UpdatedAt       time.Time
 }
 
 type User struct {
-	ID           uuid.UUID
-	Username     string
-	Email        string
-	PasswordHash string
-	FirstName    string
-	LastName     string
-	UserType     string
-	CreatedAt    sql.NullTime
-	UpdatedAt    sql.NullTime
+	ID                 uuid.UUID
+	Username           string
+	Email              string
+	PasswordHash       string
+	FirstName          string
+	LastName           string
+	UserType           string
+	CreatedAt          sql.NullTime
+	UpdatedAt          sql.NullTime
+	Timezone           string
+	BillingCycleAnchor sql.NullTime
+	SecurityFlaggedAt  sql.NullTime
+	SuspendedAt        sql.NullTime
+	Locale             sql.NullString
+	TenantID           uuid.NullUUID
 }
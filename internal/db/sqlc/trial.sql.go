@@ -11,6 +11,8 @@ import (
 	"encoding/json"
 	"time"
 
+	"hyperwhisper/internal/encryption"
+
 	"github.com/google/uuid"
 )
 
@@ -45,6 +47,22 @@ func (q *Queries) CountAllTrialUsageLogs(ctx context.Context) (int64, error) {
 	return count, err
 }
 
+const countRecentTrialAPIKeysBySubnet = `-- name: CountRecentTrialAPIKeysBySubnet :one
+SELECT COUNT(*) FROM trial_api_keys WHERE subnet_hash = $1 AND created_at >= $2
+`
+
+type CountRecentTrialAPIKeysBySubnetParams struct {
+	SubnetHash sql.NullString
+	CreatedAt  sql.NullTime
+}
+
+func (q *Queries) CountRecentTrialAPIKeysBySubnet(ctx context.Context, arg CountRecentTrialAPIKeysBySubnetParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countRecentTrialAPIKeysBySubnet, arg.SubnetHash, arg.CreatedAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countTrialAPIKeys = `-- name: CountTrialAPIKeys :one
 SELECT COUNT(*) FROM trial_api_keys
 `
@@ -80,16 +98,21 @@ func (q *Queries) CountTrialUsageLogs(ctx context.Context, trialKeyID uuid.UUID)
 
 const createTrialAPIKey = `-- name: CreateTrialAPIKey :one
 
-INSERT INTO trial_api_keys (key_hash, key_prefix, device_fingerprint, expires_at)
-VALUES ($1, $2, $3, $4)
-RETURNING id, key_hash, key_prefix, device_fingerprint, created_at, expires_at, last_used_at, revoked_at
+INSERT INTO trial_api_keys (key_hash, key_prefix, device_fingerprint, device_fingerprint_hash, expires_at, preset, subnet_hash, source, tenant_id, last_provisioned_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+RETURNING id, key_hash, key_prefix, device_fingerprint, created_at, expires_at, last_used_at, revoked_at, device_fingerprint_hash, preset, regenerations, last_provisioned_at, subnet_hash, source, tenant_id
 `
 
 type CreateTrialAPIKeyParams struct {
-	KeyHash           string
-	KeyPrefix         string
-	DeviceFingerprint string
-	ExpiresAt         time.Time
+	KeyHash               string
+	KeyPrefix             string
+	DeviceFingerprint     encryption.String
+	DeviceFingerprintHash sql.NullString
+	ExpiresAt             time.Time
+	Preset                string
+	SubnetHash            sql.NullString
+	Source                sql.NullString
+	TenantID              uuid.NullUUID
 }
 
 // =====================
@@ -100,7 +123,12 @@ func (q *Queries) CreateTrialAPIKey(ctx context.Context, arg CreateTrialAPIKeyPa
 		arg.KeyHash,
 		arg.KeyPrefix,
 		arg.DeviceFingerprint,
+		arg.DeviceFingerprintHash,
 		arg.ExpiresAt,
+		arg.Preset,
+		arg.SubnetHash,
+		arg.Source,
+		arg.TenantID,
 	)
 	var i TrialApiKey
 	err := row.Scan(
@@ -112,28 +140,43 @@ func (q *Queries) CreateTrialAPIKey(ctx context.Context, arg CreateTrialAPIKeyPa
 		&i.ExpiresAt,
 		&i.LastUsedAt,
 		&i.RevokedAt,
+		&i.DeviceFingerprintHash,
+		&i.Preset,
+		&i.Regenerations,
+		&i.LastProvisionedAt,
+		&i.SubnetHash,
+		&i.Source,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const createTrialUsageLog = `-- name: CreateTrialUsageLog :one
 
-INSERT INTO trial_usage (trial_key_id, deepgram_params, client_ip)
-VALUES ($1, $2, $3)
-RETURNING id, trial_key_id, started_at, ended_at, duration_seconds, status, error_message, deepgram_params, bytes_sent, client_ip
+INSERT INTO trial_usage (trial_key_id, deepgram_params, client_ip, redaction_audit, session_nonce)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, trial_key_id, started_at, ended_at, duration_seconds, status, error_message, deepgram_params, bytes_sent, client_ip, duration_estimated, redaction_audit, session_nonce
 `
 
 type CreateTrialUsageLogParams struct {
 	TrialKeyID     uuid.UUID
 	DeepgramParams json.RawMessage
-	ClientIp       sql.NullString
+	ClientIp       encryption.NullString
+	RedactionAudit json.RawMessage
+	SessionNonce   sql.NullString
 }
 
 // =====================
 // TRIAL USAGE QUERIES
 // =====================
 func (q *Queries) CreateTrialUsageLog(ctx context.Context, arg CreateTrialUsageLogParams) (TrialUsage, error) {
-	row := q.db.QueryRowContext(ctx, createTrialUsageLog, arg.TrialKeyID, arg.DeepgramParams, arg.ClientIp)
+	row := q.db.QueryRowContext(ctx, createTrialUsageLog,
+		arg.TrialKeyID,
+		arg.DeepgramParams,
+		arg.ClientIp,
+		arg.RedactionAudit,
+		arg.SessionNonce,
+	)
 	var i TrialUsage
 	err := row.Scan(
 		&i.ID,
@@ -146,6 +189,9 @@ func (q *Queries) CreateTrialUsageLog(ctx context.Context, arg CreateTrialUsageL
 		&i.DeepgramParams,
 		&i.BytesSent,
 		&i.ClientIp,
+		&i.DurationEstimated,
+		&i.RedactionAudit,
+		&i.SessionNonce,
 	)
 	return i, err
 }
@@ -198,11 +244,21 @@ func (q *Queries) GetAllTrialUsageSummary(ctx context.Context, arg GetAllTrialUs
 }
 
 const getTrialAPIKeyByFingerprint = `-- name: GetTrialAPIKeyByFingerprint :one
-SELECT id, key_hash, key_prefix, device_fingerprint, created_at, expires_at, last_used_at, revoked_at FROM trial_api_keys WHERE device_fingerprint = $1
+SELECT id, key_hash, key_prefix, device_fingerprint, created_at, expires_at, last_used_at, revoked_at, device_fingerprint_hash, preset, regenerations, last_provisioned_at, subnet_hash, source, tenant_id FROM trial_api_keys
+WHERE device_fingerprint_hash = $1
+   OR (device_fingerprint_hash IS NULL AND device_fingerprint = $2::text)
+LIMIT 1
 `
 
-func (q *Queries) GetTrialAPIKeyByFingerprint(ctx context.Context, deviceFingerprint string) (TrialApiKey, error) {
-	row := q.db.QueryRowContext(ctx, getTrialAPIKeyByFingerprint, deviceFingerprint)
+type GetTrialAPIKeyByFingerprintParams struct {
+	FingerprintHash   sql.NullString
+	DeviceFingerprint string
+}
+
+// Rows created before encryption have no hash until `encryption reencrypt`
+// runs, so fall back to comparing the plaintext column for them
+func (q *Queries) GetTrialAPIKeyByFingerprint(ctx context.Context, arg GetTrialAPIKeyByFingerprintParams) (TrialApiKey, error) {
+	row := q.db.QueryRowContext(ctx, getTrialAPIKeyByFingerprint, arg.FingerprintHash, arg.DeviceFingerprint)
 	var i TrialApiKey
 	err := row.Scan(
 		&i.ID,
@@ -213,12 +269,19 @@ func (q *Queries) GetTrialAPIKeyByFingerprint(ctx context.Context, deviceFingerp
 		&i.ExpiresAt,
 		&i.LastUsedAt,
 		&i.RevokedAt,
+		&i.DeviceFingerprintHash,
+		&i.Preset,
+		&i.Regenerations,
+		&i.LastProvisionedAt,
+		&i.SubnetHash,
+		&i.Source,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const getTrialAPIKeyByHash = `-- name: GetTrialAPIKeyByHash :one
-SELECT id, key_hash, key_prefix, device_fingerprint, created_at, expires_at, last_used_at, revoked_at FROM trial_api_keys WHERE key_hash = $1 AND revoked_at IS NULL
+SELECT id, key_hash, key_prefix, device_fingerprint, created_at, expires_at, last_used_at, revoked_at, device_fingerprint_hash, preset, regenerations, last_provisioned_at, subnet_hash, source, tenant_id FROM trial_api_keys WHERE key_hash = $1 AND revoked_at IS NULL
 `
 
 func (q *Queries) GetTrialAPIKeyByHash(ctx context.Context, keyHash string) (TrialApiKey, error) {
@@ -233,12 +296,19 @@ func (q *Queries) GetTrialAPIKeyByHash(ctx context.Context, keyHash string) (Tri
 		&i.ExpiresAt,
 		&i.LastUsedAt,
 		&i.RevokedAt,
+		&i.DeviceFingerprintHash,
+		&i.Preset,
+		&i.Regenerations,
+		&i.LastProvisionedAt,
+		&i.SubnetHash,
+		&i.Source,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const getTrialAPIKeyByID = `-- name: GetTrialAPIKeyByID :one
-SELECT id, key_hash, key_prefix, device_fingerprint, created_at, expires_at, last_used_at, revoked_at FROM trial_api_keys WHERE id = $1
+SELECT id, key_hash, key_prefix, device_fingerprint, created_at, expires_at, last_used_at, revoked_at, device_fingerprint_hash, preset, regenerations, last_provisioned_at, subnet_hash, source, tenant_id FROM trial_api_keys WHERE id = $1
 `
 
 func (q *Queries) GetTrialAPIKeyByID(ctx context.Context, id uuid.UUID) (TrialApiKey, error) {
@@ -253,34 +323,19 @@ func (q *Queries) GetTrialAPIKeyByID(ctx context.Context, id uuid.UUID) (TrialAp
 		&i.ExpiresAt,
 		&i.LastUsedAt,
 		&i.RevokedAt,
-	)
-	return i, err
-}
-
-const getTrialLimits = `-- name: GetTrialLimits :one
-
-SELECT id, max_duration_seconds, max_sessions, max_session_duration_seconds, expiry_days, updated_at FROM trial_limits WHERE id = 1
-`
-
-// =====================
-// TRIAL LIMITS QUERIES
-// =====================
-func (q *Queries) GetTrialLimits(ctx context.Context) (TrialLimit, error) {
-	row := q.db.QueryRowContext(ctx, getTrialLimits)
-	var i TrialLimit
-	err := row.Scan(
-		&i.ID,
-		&i.MaxDurationSeconds,
-		&i.MaxSessions,
-		&i.MaxSessionDurationSeconds,
-		&i.ExpiryDays,
-		&i.UpdatedAt,
+		&i.DeviceFingerprintHash,
+		&i.Preset,
+		&i.Regenerations,
+		&i.LastProvisionedAt,
+		&i.SubnetHash,
+		&i.Source,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const getTrialUsageLog = `-- name: GetTrialUsageLog :one
-SELECT id, trial_key_id, started_at, ended_at, duration_seconds, status, error_message, deepgram_params, bytes_sent, client_ip FROM trial_usage WHERE id = $1
+SELECT id, trial_key_id, started_at, ended_at, duration_seconds, status, error_message, deepgram_params, bytes_sent, client_ip, duration_estimated, redaction_audit, session_nonce FROM trial_usage WHERE id = $1
 `
 
 func (q *Queries) GetTrialUsageLog(ctx context.Context, id uuid.UUID) (TrialUsage, error) {
@@ -297,6 +352,9 @@ func (q *Queries) GetTrialUsageLog(ctx context.Context, id uuid.UUID) (TrialUsag
 		&i.DeepgramParams,
 		&i.BytesSent,
 		&i.ClientIp,
+		&i.DurationEstimated,
+		&i.RedactionAudit,
+		&i.SessionNonce,
 	)
 	return i, err
 }
@@ -345,10 +403,21 @@ func (q *Queries) GetTrialUsageSummaryActive(ctx context.Context, trialKeyID uui
 	return i, err
 }
 
+const isTrialAPIKeyRevoked = `-- name: IsTrialAPIKeyRevoked :one
+SELECT EXISTS(SELECT 1 FROM trial_api_keys WHERE key_hash = $1 AND revoked_at IS NOT NULL)
+`
+
+func (q *Queries) IsTrialAPIKeyRevoked(ctx context.Context, keyHash string) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isTrialAPIKeyRevoked, keyHash)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const listAllTrialAPIKeys = `-- name: ListAllTrialAPIKeys :many
 
 SELECT
-    tak.id, tak.key_hash, tak.key_prefix, tak.device_fingerprint, tak.created_at, tak.expires_at, tak.last_used_at, tak.revoked_at,
+    tak.id, tak.key_hash, tak.key_prefix, tak.device_fingerprint, tak.created_at, tak.expires_at, tak.last_used_at, tak.revoked_at, tak.device_fingerprint_hash, tak.preset, tak.regenerations, tak.last_provisioned_at, tak.subnet_hash, tak.source, tak.tenant_id,
     COALESCE(usage_stats.total_sessions, 0)::bigint as total_sessions,
     COALESCE(usage_stats.total_duration_seconds, 0)::DECIMAL(12,3) as total_duration_seconds
 FROM trial_api_keys tak
@@ -370,16 +439,23 @@ type ListAllTrialAPIKeysParams struct {
 }
 
 type ListAllTrialAPIKeysRow struct {
-	ID                   uuid.UUID
-	KeyHash              string
-	KeyPrefix            string
-	DeviceFingerprint    string
-	CreatedAt            sql.NullTime
-	ExpiresAt            time.Time
-	LastUsedAt           sql.NullTime
-	RevokedAt            sql.NullTime
-	TotalSessions        int64
-	TotalDurationSeconds string
+	ID                    uuid.UUID
+	KeyHash               string
+	KeyPrefix             string
+	DeviceFingerprint     encryption.String
+	CreatedAt             sql.NullTime
+	ExpiresAt             time.Time
+	LastUsedAt            sql.NullTime
+	RevokedAt             sql.NullTime
+	DeviceFingerprintHash sql.NullString
+	Preset                string
+	Regenerations         int32
+	LastProvisionedAt     sql.NullTime
+	SubnetHash            sql.NullString
+	Source                sql.NullString
+	TenantID              uuid.NullUUID
+	TotalSessions         int64
+	TotalDurationSeconds  string
 }
 
 // =====================
@@ -403,6 +479,13 @@ func (q *Queries) ListAllTrialAPIKeys(ctx context.Context, arg ListAllTrialAPIKe
 			&i.ExpiresAt,
 			&i.LastUsedAt,
 			&i.RevokedAt,
+			&i.DeviceFingerprintHash,
+			&i.Preset,
+			&i.Regenerations,
+			&i.LastProvisionedAt,
+			&i.SubnetHash,
+			&i.Source,
+			&i.TenantID,
 			&i.TotalSessions,
 			&i.TotalDurationSeconds,
 		); err != nil {
@@ -421,7 +504,7 @@ func (q *Queries) ListAllTrialAPIKeys(ctx context.Context, arg ListAllTrialAPIKe
 
 const listAllTrialUsageLogs = `-- name: ListAllTrialUsageLogs :many
 SELECT
-    tu.id, tu.trial_key_id, tu.started_at, tu.ended_at, tu.duration_seconds, tu.status, tu.error_message, tu.deepgram_params, tu.bytes_sent, tu.client_ip,
+    tu.id, tu.trial_key_id, tu.started_at, tu.ended_at, tu.duration_seconds, tu.status, tu.error_message, tu.deepgram_params, tu.bytes_sent, tu.client_ip, tu.duration_estimated, tu.redaction_audit, tu.session_nonce,
     tak.key_prefix,
     tak.device_fingerprint
 FROM trial_usage tu
@@ -445,9 +528,12 @@ type ListAllTrialUsageLogsRow struct {
 	ErrorMessage      sql.NullString
 	DeepgramParams    json.RawMessage
 	BytesSent         int64
-	ClientIp          sql.NullString
+	ClientIp          encryption.NullString
+	DurationEstimated bool
+	RedactionAudit    json.RawMessage
+	SessionNonce      sql.NullString
 	KeyPrefix         string
-	DeviceFingerprint string
+	DeviceFingerprint encryption.String
 }
 
 func (q *Queries) ListAllTrialUsageLogs(ctx context.Context, arg ListAllTrialUsageLogsParams) ([]ListAllTrialUsageLogsRow, error) {
@@ -470,6 +556,9 @@ func (q *Queries) ListAllTrialUsageLogs(ctx context.Context, arg ListAllTrialUsa
 			&i.DeepgramParams,
 			&i.BytesSent,
 			&i.ClientIp,
+			&i.DurationEstimated,
+			&i.RedactionAudit,
+			&i.SessionNonce,
 			&i.KeyPrefix,
 			&i.DeviceFingerprint,
 		); err != nil {
@@ -486,8 +575,36 @@ func (q *Queries) ListAllTrialUsageLogs(ctx context.Context, arg ListAllTrialUsa
 	return items, nil
 }
 
+const listExpiredUnrevokedTrialKeyIDs = `-- name: ListExpiredUnrevokedTrialKeyIDs :many
+SELECT id FROM trial_api_keys WHERE expires_at <= NOW() AND revoked_at IS NULL ORDER BY expires_at
+`
+
+// Preview of CleanupExpiredTrialKeys
+func (q *Queries) ListExpiredUnrevokedTrialKeyIDs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listExpiredUnrevokedTrialKeyIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTrialAPIKeys = `-- name: ListTrialAPIKeys :many
-SELECT id, key_hash, key_prefix, device_fingerprint, created_at, expires_at, last_used_at, revoked_at FROM trial_api_keys ORDER BY created_at DESC LIMIT $1 OFFSET $2
+SELECT id, key_hash, key_prefix, device_fingerprint, created_at, expires_at, last_used_at, revoked_at, device_fingerprint_hash, preset, regenerations, last_provisioned_at, subnet_hash, source, tenant_id FROM trial_api_keys ORDER BY created_at DESC LIMIT $1 OFFSET $2
 `
 
 type ListTrialAPIKeysParams struct {
@@ -513,6 +630,13 @@ func (q *Queries) ListTrialAPIKeys(ctx context.Context, arg ListTrialAPIKeysPara
 			&i.ExpiresAt,
 			&i.LastUsedAt,
 			&i.RevokedAt,
+			&i.DeviceFingerprintHash,
+			&i.Preset,
+			&i.Regenerations,
+			&i.LastProvisionedAt,
+			&i.SubnetHash,
+			&i.Source,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -528,7 +652,7 @@ func (q *Queries) ListTrialAPIKeys(ctx context.Context, arg ListTrialAPIKeysPara
 }
 
 const listTrialUsageLogs = `-- name: ListTrialUsageLogs :many
-SELECT id, trial_key_id, started_at, ended_at, duration_seconds, status, error_message, deepgram_params, bytes_sent, client_ip FROM trial_usage WHERE trial_key_id = $1 ORDER BY started_at DESC LIMIT $2 OFFSET $3
+SELECT id, trial_key_id, started_at, ended_at, duration_seconds, status, error_message, deepgram_params, bytes_sent, client_ip, duration_estimated, redaction_audit, session_nonce FROM trial_usage WHERE trial_key_id = $1 ORDER BY started_at DESC LIMIT $2 OFFSET $3
 `
 
 type ListTrialUsageLogsParams struct {
@@ -557,6 +681,9 @@ func (q *Queries) ListTrialUsageLogs(ctx context.Context, arg ListTrialUsageLogs
 			&i.DeepgramParams,
 			&i.BytesSent,
 			&i.ClientIp,
+			&i.DurationEstimated,
+			&i.RedactionAudit,
+			&i.SessionNonce,
 		); err != nil {
 			return nil, err
 		}
@@ -573,9 +700,9 @@ func (q *Queries) ListTrialUsageLogs(ctx context.Context, arg ListTrialUsageLogs
 
 const regenerateTrialAPIKey = `-- name: RegenerateTrialAPIKey :one
 UPDATE trial_api_keys
-SET key_hash = $2, key_prefix = $3
+SET key_hash = $2, key_prefix = $3, regenerations = regenerations + 1, last_provisioned_at = NOW()
 WHERE id = $1
-RETURNING id, key_hash, key_prefix, device_fingerprint, created_at, expires_at, last_used_at, revoked_at
+RETURNING id, key_hash, key_prefix, device_fingerprint, created_at, expires_at, last_used_at, revoked_at, device_fingerprint_hash, preset, regenerations, last_provisioned_at, subnet_hash, source, tenant_id
 `
 
 type RegenerateTrialAPIKeyParams struct {
@@ -596,6 +723,74 @@ func (q *Queries) RegenerateTrialAPIKey(ctx context.Context, arg RegenerateTrial
 		&i.ExpiresAt,
 		&i.LastUsedAt,
 		&i.RevokedAt,
+		&i.DeviceFingerprintHash,
+		&i.Preset,
+		&i.Regenerations,
+		&i.LastProvisionedAt,
+		&i.SubnetHash,
+		&i.Source,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const retryTrialUsageLog = `-- name: RetryTrialUsageLog :one
+UPDATE trial_usage
+SET status = 'active',
+    ended_at = NULL,
+    error_message = NULL,
+    deepgram_params = $1,
+    client_ip = $2,
+    redaction_audit = $3
+WHERE id = (
+    SELECT id FROM trial_usage
+    WHERE trial_key_id = $4
+      AND session_nonce = $5
+      AND started_at >= $6
+      AND status = 'error' AND bytes_sent = 0
+    ORDER BY started_at DESC
+    LIMIT 1
+)
+  AND started_at >= $6
+  AND status = 'error' AND bytes_sent = 0
+RETURNING id, trial_key_id, started_at, ended_at, duration_seconds, status, error_message, deepgram_params, bytes_sent, client_ip, duration_estimated, redaction_audit, session_nonce
+`
+
+type RetryTrialUsageLogParams struct {
+	DeepgramParams json.RawMessage
+	ClientIp       encryption.NullString
+	RedactionAudit json.RawMessage
+	TrialKeyID     uuid.UUID
+	SessionNonce   sql.NullString
+	Since          time.Time
+}
+
+// Reopens the log of an earlier attempt of the same client session, like
+// RetryTranscriptionLog
+func (q *Queries) RetryTrialUsageLog(ctx context.Context, arg RetryTrialUsageLogParams) (TrialUsage, error) {
+	row := q.db.QueryRowContext(ctx, retryTrialUsageLog,
+		arg.DeepgramParams,
+		arg.ClientIp,
+		arg.RedactionAudit,
+		arg.TrialKeyID,
+		arg.SessionNonce,
+		arg.Since,
+	)
+	var i TrialUsage
+	err := row.Scan(
+		&i.ID,
+		&i.TrialKeyID,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.DurationSeconds,
+		&i.Status,
+		&i.ErrorMessage,
+		&i.DeepgramParams,
+		&i.BytesSent,
+		&i.ClientIp,
+		&i.DurationEstimated,
+		&i.RedactionAudit,
+		&i.SessionNonce,
 	)
 	return i, err
 }
@@ -609,6 +804,50 @@ func (q *Queries) RevokeTrialAPIKey(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const rotateTrialAPIKey = `-- name: RotateTrialAPIKey :one
+UPDATE trial_api_keys
+SET key_hash = $1, key_prefix = $2
+WHERE id = $3 AND key_hash = $4 AND revoked_at IS NULL
+RETURNING id, key_hash, key_prefix, device_fingerprint, created_at, expires_at, last_used_at, revoked_at, device_fingerprint_hash, preset, regenerations, last_provisioned_at, subnet_hash, source, tenant_id
+`
+
+type RotateTrialAPIKeyParams struct {
+	NewKeyHash     string
+	KeyPrefix      string
+	ID             uuid.UUID
+	CurrentKeyHash string
+}
+
+// Only succeeds while the caller's key is still current, so concurrent
+// rotations cannot both win
+func (q *Queries) RotateTrialAPIKey(ctx context.Context, arg RotateTrialAPIKeyParams) (TrialApiKey, error) {
+	row := q.db.QueryRowContext(ctx, rotateTrialAPIKey,
+		arg.NewKeyHash,
+		arg.KeyPrefix,
+		arg.ID,
+		arg.CurrentKeyHash,
+	)
+	var i TrialApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.KeyHash,
+		&i.KeyPrefix,
+		&i.DeviceFingerprint,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.DeviceFingerprintHash,
+		&i.Preset,
+		&i.Regenerations,
+		&i.LastProvisionedAt,
+		&i.SubnetHash,
+		&i.Source,
+		&i.TenantID,
+	)
+	return i, err
+}
+
 const unrevokeTrialAPIKey = `-- name: UnrevokeTrialAPIKey :exec
 UPDATE trial_api_keys SET revoked_at = NULL WHERE id = $1
 `
@@ -627,43 +866,6 @@ func (q *Queries) UpdateTrialAPIKeyLastUsed(ctx context.Context, id uuid.UUID) e
 	return err
 }
 
-const updateTrialLimits = `-- name: UpdateTrialLimits :one
-UPDATE trial_limits
-SET max_duration_seconds = $1,
-    max_sessions = $2,
-    max_session_duration_seconds = $3,
-    expiry_days = $4,
-    updated_at = NOW()
-WHERE id = 1
-RETURNING id, max_duration_seconds, max_sessions, max_session_duration_seconds, expiry_days, updated_at
-`
-
-type UpdateTrialLimitsParams struct {
-	MaxDurationSeconds        int32
-	MaxSessions               int32
-	MaxSessionDurationSeconds int32
-	ExpiryDays                int32
-}
-
-func (q *Queries) UpdateTrialLimits(ctx context.Context, arg UpdateTrialLimitsParams) (TrialLimit, error) {
-	row := q.db.QueryRowContext(ctx, updateTrialLimits,
-		arg.MaxDurationSeconds,
-		arg.MaxSessions,
-		arg.MaxSessionDurationSeconds,
-		arg.ExpiryDays,
-	)
-	var i TrialLimit
-	err := row.Scan(
-		&i.ID,
-		&i.MaxDurationSeconds,
-		&i.MaxSessions,
-		&i.MaxSessionDurationSeconds,
-		&i.ExpiryDays,
-		&i.UpdatedAt,
-	)
-	return i, err
-}
-
 const updateTrialUsageComplete = `-- name: UpdateTrialUsageComplete :exec
 UPDATE trial_usage
 SET ended_at = NOW(),
@@ -704,6 +906,28 @@ func (q *Queries) UpdateTrialUsageError(ctx context.Context, arg UpdateTrialUsag
 	return err
 }
 
+const updateTrialUsageEstimated = `-- name: UpdateTrialUsageEstimated :exec
+UPDATE trial_usage
+SET ended_at = NOW(),
+    duration_seconds = $2,
+    duration_estimated = TRUE,
+    status = 'timeout',
+    bytes_sent = $3
+WHERE id = $1
+`
+
+type UpdateTrialUsageEstimatedParams struct {
+	ID              uuid.UUID
+	DurationSeconds sql.NullString
+	BytesSent       int64
+}
+
+// The final metadata never arrived; bill the estimated duration
+func (q *Queries) UpdateTrialUsageEstimated(ctx context.Context, arg UpdateTrialUsageEstimatedParams) error {
+	_, err := q.db.ExecContext(ctx, updateTrialUsageEstimated, arg.ID, arg.DurationSeconds, arg.BytesSent)
+	return err
+}
+
 const updateTrialUsageTimeout = `-- name: UpdateTrialUsageTimeout :exec
 UPDATE trial_usage
 SET ended_at = NOW(),
@@ -10,6 +10,8 @@ import (
 	"database/sql"
 	"time"
 
+	"hyperwhisper/internal/encryption"
+
 	"github.com/google/uuid"
 )
 
@@ -44,6 +46,35 @@ func (q *Queries) CleanupExpiredRefreshTokens(ctx context.Context) error {
 	return err
 }
 
+const clearUserSecurityFlag = `-- name: ClearUserSecurityFlag :one
+UPDATE users SET security_flagged_at = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at, timezone, billing_cycle_anchor, security_flagged_at, suspended_at, locale, tenant_id
+`
+
+func (q *Queries) ClearUserSecurityFlag(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, clearUserSecurityFlag, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.PasswordHash,
+		&i.FirstName,
+		&i.LastName,
+		&i.UserType,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Timezone,
+		&i.BillingCycleAnchor,
+		&i.SecurityFlaggedAt,
+		&i.SuspendedAt,
+		&i.Locale,
+		&i.TenantID,
+	)
+	return i, err
+}
+
 const countActiveRefreshTokens = `-- name: CountActiveRefreshTokens :one
 SELECT COUNT(*) FROM tokens WHERE revoked_at IS NULL AND expires_at > NOW()
 `
@@ -55,12 +86,49 @@ func (q *Queries) CountActiveRefreshTokens(ctx context.Context) (int64, error) {
 	return count, err
 }
 
+const countListedUsers = `-- name: CountListedUsers :one
+SELECT COUNT(*) FROM users
+WHERE $1::uuid IS NULL OR tenant_id = $1
+`
+
+// Counts what ListUsers lists
+func (q *Queries) CountListedUsers(ctx context.Context, tenantID uuid.NullUUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countListedUsers, tenantID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countRefreshTokens = `-- name: CountRefreshTokens :one
-SELECT COUNT(*) FROM tokens
+SELECT COUNT(*)
+FROM tokens t
+JOIN users u ON u.id = t.user_id
+WHERE ($1::uuid IS NULL OR t.user_id = $1)
+  AND ($2::text IS NULL
+    OR ($2 = 'active' AND t.revoked_at IS NULL AND t.expires_at > NOW())
+    OR ($2 = 'revoked' AND t.revoked_at IS NOT NULL)
+    OR ($2 = 'expired' AND t.revoked_at IS NULL AND t.expires_at <= NOW()))
+  AND ($3::text IS NULL OR u.username ILIKE '%' || $3 || '%' OR u.email ILIKE '%' || $3 || '%')
+  AND ($4::timestamptz IS NULL OR t.issued_at >= $4)
+  AND ($5::timestamptz IS NULL OR t.issued_at < $5)
 `
 
-func (q *Queries) CountRefreshTokens(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countRefreshTokens)
+type CountRefreshTokensParams struct {
+	UserID     uuid.NullUUID
+	Status     sql.NullString
+	Search     sql.NullString
+	IssuedFrom sql.NullTime
+	IssuedTo   sql.NullTime
+}
+
+func (q *Queries) CountRefreshTokens(ctx context.Context, arg CountRefreshTokensParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countRefreshTokens,
+		arg.UserID,
+		arg.Status,
+		arg.Search,
+		arg.IssuedFrom,
+		arg.IssuedTo,
+	)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -90,20 +158,30 @@ func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
 
 const createRefreshToken = `-- name: CreateRefreshToken :one
 
-INSERT INTO tokens (token_jti, user_id, expires_at)
-VALUES ($1, $2, $3)
-RETURNING id, token_jti, user_id, issued_at, expires_at, revoked_at, revoked_reason
+INSERT INTO tokens (token_jti, user_id, expires_at, client_ip, user_agent, family_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, token_jti, user_id, issued_at, expires_at, revoked_at, revoked_reason, client_ip, user_agent, family_id
 `
 
 type CreateRefreshTokenParams struct {
 	TokenJti  string
 	UserID    uuid.UUID
 	ExpiresAt time.Time
+	ClientIp  encryption.NullString
+	UserAgent sql.NullString
+	FamilyID  uuid.UUID
 }
 
 // Refresh token queries (only refresh tokens are tracked, access tokens are stateless)
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (Token, error) {
-	row := q.db.QueryRowContext(ctx, createRefreshToken, arg.TokenJti, arg.UserID, arg.ExpiresAt)
+	row := q.db.QueryRowContext(ctx, createRefreshToken,
+		arg.TokenJti,
+		arg.UserID,
+		arg.ExpiresAt,
+		arg.ClientIp,
+		arg.UserAgent,
+		arg.FamilyID,
+	)
 	var i Token
 	err := row.Scan(
 		&i.ID,
@@ -113,14 +191,17 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 		&i.ExpiresAt,
 		&i.RevokedAt,
 		&i.RevokedReason,
+		&i.ClientIp,
+		&i.UserAgent,
+		&i.FamilyID,
 	)
 	return i, err
 }
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (username, email, password_hash, first_name, last_name, user_type)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at
+INSERT INTO users (username, email, password_hash, first_name, last_name, user_type, locale, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at, timezone, billing_cycle_anchor, security_flagged_at, suspended_at, locale, tenant_id
 `
 
 type CreateUserParams struct {
@@ -130,6 +211,8 @@ type CreateUserParams struct {
 	FirstName    string
 	LastName     string
 	UserType     string
+	Locale       sql.NullString
+	TenantID     uuid.NullUUID
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -140,6 +223,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.FirstName,
 		arg.LastName,
 		arg.UserType,
+		arg.Locale,
+		arg.TenantID,
 	)
 	var i User
 	err := row.Scan(
@@ -152,6 +237,12 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.UserType,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Timezone,
+		&i.BillingCycleAnchor,
+		&i.SecurityFlaggedAt,
+		&i.SuspendedAt,
+		&i.Locale,
+		&i.TenantID,
 	)
 	return i, err
 }
@@ -165,8 +256,18 @@ func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const flagUserSecurity = `-- name: FlagUserSecurity :exec
+UPDATE users SET security_flagged_at = NOW() WHERE id = $1
+`
+
+// Marks the account for review after a sign its credentials were stolen
+func (q *Queries) FlagUserSecurity(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, flagUserSecurity, id)
+	return err
+}
+
 const getRefreshTokenByJTI = `-- name: GetRefreshTokenByJTI :one
-SELECT id, token_jti, user_id, issued_at, expires_at, revoked_at, revoked_reason FROM tokens WHERE token_jti = $1
+SELECT id, token_jti, user_id, issued_at, expires_at, revoked_at, revoked_reason, client_ip, user_agent, family_id FROM tokens WHERE token_jti = $1
 `
 
 func (q *Queries) GetRefreshTokenByJTI(ctx context.Context, tokenJti string) (Token, error) {
@@ -180,12 +281,15 @@ func (q *Queries) GetRefreshTokenByJTI(ctx context.Context, tokenJti string) (To
 		&i.ExpiresAt,
 		&i.RevokedAt,
 		&i.RevokedReason,
+		&i.ClientIp,
+		&i.UserAgent,
+		&i.FamilyID,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at FROM users WHERE email = $1
+SELECT id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at, timezone, billing_cycle_anchor, security_flagged_at, suspended_at, locale, tenant_id FROM users WHERE email = $1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -201,12 +305,18 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.UserType,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Timezone,
+		&i.BillingCycleAnchor,
+		&i.SecurityFlaggedAt,
+		&i.SuspendedAt,
+		&i.Locale,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const getUserByEmailOrUsername = `-- name: GetUserByEmailOrUsername :one
-SELECT id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at FROM users WHERE email = $1 OR username = $1
+SELECT id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at, timezone, billing_cycle_anchor, security_flagged_at, suspended_at, locale, tenant_id FROM users WHERE email = $1 OR username = $1
 `
 
 func (q *Queries) GetUserByEmailOrUsername(ctx context.Context, email string) (User, error) {
@@ -222,12 +332,18 @@ func (q *Queries) GetUserByEmailOrUsername(ctx context.Context, email string) (U
 		&i.UserType,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Timezone,
+		&i.BillingCycleAnchor,
+		&i.SecurityFlaggedAt,
+		&i.SuspendedAt,
+		&i.Locale,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at FROM users WHERE id = $1
+SELECT id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at, timezone, billing_cycle_anchor, security_flagged_at, suspended_at, locale, tenant_id FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
@@ -243,12 +359,18 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.UserType,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Timezone,
+		&i.BillingCycleAnchor,
+		&i.SecurityFlaggedAt,
+		&i.SuspendedAt,
+		&i.Locale,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at FROM users WHERE username = $1
+SELECT id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at, timezone, billing_cycle_anchor, security_flagged_at, suspended_at, locale, tenant_id FROM users WHERE username = $1
 `
 
 func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User, error) {
@@ -264,6 +386,12 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.UserType,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Timezone,
+		&i.BillingCycleAnchor,
+		&i.SecurityFlaggedAt,
+		&i.SuspendedAt,
+		&i.Locale,
+		&i.TenantID,
 	)
 	return i, err
 }
@@ -280,7 +408,7 @@ func (q *Queries) IsRefreshTokenRevoked(ctx context.Context, tokenJti string) (b
 }
 
 const listActiveRefreshTokens = `-- name: ListActiveRefreshTokens :many
-SELECT id, token_jti, user_id, issued_at, expires_at, revoked_at, revoked_reason FROM tokens WHERE revoked_at IS NULL AND expires_at > NOW() ORDER BY issued_at DESC LIMIT $1 OFFSET $2
+SELECT id, token_jti, user_id, issued_at, expires_at, revoked_at, revoked_reason, client_ip, user_agent, family_id FROM tokens WHERE revoked_at IS NULL AND expires_at > NOW() ORDER BY issued_at DESC LIMIT $1 OFFSET $2
 `
 
 type ListActiveRefreshTokensParams struct {
@@ -305,6 +433,9 @@ func (q *Queries) ListActiveRefreshTokens(ctx context.Context, arg ListActiveRef
 			&i.ExpiresAt,
 			&i.RevokedAt,
 			&i.RevokedReason,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.FamilyID,
 		); err != nil {
 			return nil, err
 		}
@@ -319,17 +450,169 @@ func (q *Queries) ListActiveRefreshTokens(ctx context.Context, arg ListActiveRef
 	return items, nil
 }
 
+const listExpiredRefreshTokenJTIs = `-- name: ListExpiredRefreshTokenJTIs :many
+SELECT token_jti FROM tokens WHERE expires_at <= NOW() ORDER BY expires_at
+`
+
+// Preview of CleanupExpiredRefreshTokens
+func (q *Queries) ListExpiredRefreshTokenJTIs(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listExpiredRefreshTokenJTIs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var token_jti string
+		if err := rows.Scan(&token_jti); err != nil {
+			return nil, err
+		}
+		items = append(items, token_jti)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listRefreshTokens = `-- name: ListRefreshTokens :many
-SELECT id, token_jti, user_id, issued_at, expires_at, revoked_at, revoked_reason FROM tokens ORDER BY issued_at DESC LIMIT $1 OFFSET $2
+SELECT t.id, t.token_jti, t.user_id, t.issued_at, t.expires_at, t.revoked_at, t.revoked_reason, t.client_ip, t.user_agent, t.family_id, u.username, u.email
+FROM tokens t
+JOIN users u ON u.id = t.user_id
+WHERE ($1::uuid IS NULL OR t.user_id = $1)
+  AND ($2::text IS NULL
+    OR ($2 = 'active' AND t.revoked_at IS NULL AND t.expires_at > NOW())
+    OR ($2 = 'revoked' AND t.revoked_at IS NOT NULL)
+    OR ($2 = 'expired' AND t.revoked_at IS NULL AND t.expires_at <= NOW()))
+  AND ($3::text IS NULL OR u.username ILIKE '%' || $3 || '%' OR u.email ILIKE '%' || $3 || '%')
+  AND ($4::timestamptz IS NULL OR t.issued_at >= $4)
+  AND ($5::timestamptz IS NULL OR t.issued_at < $5)
+ORDER BY
+  CASE WHEN $6::text = 'issued_at' AND NOT $7::bool THEN t.issued_at END ASC,
+  CASE WHEN $6 = 'issued_at' AND $7 THEN t.issued_at END DESC,
+  CASE WHEN $6 = 'expires_at' AND NOT $7 THEN t.expires_at END ASC,
+  CASE WHEN $6 = 'expires_at' AND $7 THEN t.expires_at END DESC,
+  CASE WHEN $6 = 'username' AND NOT $7 THEN u.username END ASC,
+  CASE WHEN $6 = 'username' AND $7 THEN u.username END DESC,
+  t.issued_at DESC, t.id
+LIMIT $8 OFFSET $9
 `
 
 type ListRefreshTokensParams struct {
-	Limit  int32
-	Offset int32
+	UserID     uuid.NullUUID
+	Status     sql.NullString
+	Search     sql.NullString
+	IssuedFrom sql.NullTime
+	IssuedTo   sql.NullTime
+	Sort       string
+	Descending bool
+	PageLimit  int32
+	PageOffset int32
+}
+
+type ListRefreshTokensRow struct {
+	ID            uuid.UUID
+	TokenJti      string
+	UserID        uuid.UUID
+	IssuedAt      sql.NullTime
+	ExpiresAt     time.Time
+	RevokedAt     sql.NullTime
+	RevokedReason sql.NullString
+	ClientIp      encryption.NullString
+	UserAgent     sql.NullString
+	FamilyID      uuid.UUID
+	Username      string
+	Email         string
 }
 
-func (q *Queries) ListRefreshTokens(ctx context.Context, arg ListRefreshTokensParams) ([]Token, error) {
-	rows, err := q.db.QueryContext(ctx, listRefreshTokens, arg.Limit, arg.Offset)
+// Status is active, revoked or expired (past expiry and never revoked); sort
+// is issued_at, expires_at or username
+func (q *Queries) ListRefreshTokens(ctx context.Context, arg ListRefreshTokensParams) ([]ListRefreshTokensRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRefreshTokens,
+		arg.UserID,
+		arg.Status,
+		arg.Search,
+		arg.IssuedFrom,
+		arg.IssuedTo,
+		arg.Sort,
+		arg.Descending,
+		arg.PageLimit,
+		arg.PageOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRefreshTokensRow
+	for rows.Next() {
+		var i ListRefreshTokensRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TokenJti,
+			&i.UserID,
+			&i.IssuedAt,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.RevokedReason,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.FamilyID,
+			&i.Username,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnrevokedUserRefreshTokenJTIs = `-- name: ListUnrevokedUserRefreshTokenJTIs :many
+SELECT token_jti FROM tokens WHERE user_id = $1 AND revoked_at IS NULL ORDER BY issued_at
+`
+
+// Preview of RevokeUserRefreshTokens
+func (q *Queries) ListUnrevokedUserRefreshTokenJTIs(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listUnrevokedUserRefreshTokenJTIs, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var token_jti string
+		if err := rows.Scan(&token_jti); err != nil {
+			return nil, err
+		}
+		items = append(items, token_jti)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserActiveRefreshTokens = `-- name: ListUserActiveRefreshTokens :many
+SELECT id, token_jti, user_id, issued_at, expires_at, revoked_at, revoked_reason, client_ip, user_agent, family_id FROM tokens
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+ORDER BY issued_at DESC
+`
+
+// The signed-in devices of a user, newest first
+func (q *Queries) ListUserActiveRefreshTokens(ctx context.Context, userID uuid.UUID) ([]Token, error) {
+	rows, err := q.db.QueryContext(ctx, listUserActiveRefreshTokens, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -345,6 +628,9 @@ func (q *Queries) ListRefreshTokens(ctx context.Context, arg ListRefreshTokensPa
 			&i.ExpiresAt,
 			&i.RevokedAt,
 			&i.RevokedReason,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.FamilyID,
 		); err != nil {
 			return nil, err
 		}
@@ -360,7 +646,7 @@ func (q *Queries) ListRefreshTokens(ctx context.Context, arg ListRefreshTokensPa
 }
 
 const listUserRefreshTokens = `-- name: ListUserRefreshTokens :many
-SELECT id, token_jti, user_id, issued_at, expires_at, revoked_at, revoked_reason FROM tokens WHERE user_id = $1 ORDER BY issued_at DESC LIMIT $2 OFFSET $3
+SELECT id, token_jti, user_id, issued_at, expires_at, revoked_at, revoked_reason, client_ip, user_agent, family_id FROM tokens WHERE user_id = $1 ORDER BY issued_at DESC LIMIT $2 OFFSET $3
 `
 
 type ListUserRefreshTokensParams struct {
@@ -386,6 +672,9 @@ func (q *Queries) ListUserRefreshTokens(ctx context.Context, arg ListUserRefresh
 			&i.ExpiresAt,
 			&i.RevokedAt,
 			&i.RevokedReason,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.FamilyID,
 		); err != nil {
 			return nil, err
 		}
@@ -401,16 +690,21 @@ func (q *Queries) ListUserRefreshTokens(ctx context.Context, arg ListUserRefresh
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at FROM users ORDER BY created_at ASC LIMIT $1 OFFSET $2
+SELECT id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at, timezone, billing_cycle_anchor, security_flagged_at, suspended_at, locale, tenant_id FROM users
+WHERE $1::uuid IS NULL OR tenant_id = $1
+ORDER BY created_at ASC
+LIMIT $2 OFFSET $3
 `
 
 type ListUsersParams struct {
-	Limit  int32
-	Offset int32
+	TenantID   uuid.NullUUID
+	PageLimit  int32
+	PageOffset int32
 }
 
+// All users, or only those of a tenant
 func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
-	rows, err := q.db.QueryContext(ctx, listUsers, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listUsers, arg.TenantID, arg.PageLimit, arg.PageOffset)
 	if err != nil {
 		return nil, err
 	}
@@ -428,6 +722,12 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.UserType,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Timezone,
+			&i.BillingCycleAnchor,
+			&i.SecurityFlaggedAt,
+			&i.SuspendedAt,
+			&i.Locale,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -442,6 +742,17 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 	return items, nil
 }
 
+const lockUserSignups = `-- name: LockUserSignups :exec
+SELECT pg_advisory_xact_lock(hashtext('users.signup'))
+`
+
+// Serializes signups until the transaction ends, so that two concurrent
+// first signups cannot both become admin
+func (q *Queries) LockUserSignups(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, lockUserSignups)
+	return err
+}
+
 const revokeRefreshToken = `-- name: RevokeRefreshToken :exec
 UPDATE tokens SET revoked_at = NOW(), revoked_reason = $2 WHERE token_jti = $1
 `
@@ -456,6 +767,45 @@ func (q *Queries) RevokeRefreshToken(ctx context.Context, arg RevokeRefreshToken
 	return err
 }
 
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :execrows
+UPDATE tokens SET revoked_at = NOW(), revoked_reason = $2
+WHERE family_id = $1 AND revoked_at IS NULL
+`
+
+type RevokeRefreshTokenFamilyParams struct {
+	FamilyID      uuid.UUID
+	RevokedReason sql.NullString
+}
+
+// Revokes every token rotated from the same sign-in
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, arg RevokeRefreshTokenFamilyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily, arg.FamilyID, arg.RevokedReason)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeUserRefreshToken = `-- name: RevokeUserRefreshToken :execrows
+UPDATE tokens SET revoked_at = NOW(), revoked_reason = $3
+WHERE token_jti = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW()
+`
+
+type RevokeUserRefreshTokenParams struct {
+	TokenJti      string
+	UserID        uuid.UUID
+	RevokedReason sql.NullString
+}
+
+// Revokes one of the user's own active refresh tokens
+func (q *Queries) RevokeUserRefreshToken(ctx context.Context, arg RevokeUserRefreshTokenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeUserRefreshToken, arg.TokenJti, arg.UserID, arg.RevokedReason)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :exec
 UPDATE tokens SET revoked_at = NOW(), revoked_reason = $2 WHERE user_id = $1 AND revoked_at IS NULL
 `
@@ -470,6 +820,80 @@ func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, arg RevokeUserRef
 	return err
 }
 
+const rotateRefreshToken = `-- name: RotateRefreshToken :execrows
+UPDATE tokens SET revoked_at = NOW(), revoked_reason = 'refreshed'
+WHERE token_jti = $1 AND revoked_at IS NULL
+`
+
+// Revokes a refresh token being exchanged for a new one; no rows means it
+// was already revoked, e.g. rotated by a concurrent refresh
+func (q *Queries) RotateRefreshToken(ctx context.Context, tokenJti string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rotateRefreshToken, tokenJti)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const suspendUser = `-- name: SuspendUser :one
+UPDATE users SET suspended_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at, timezone, billing_cycle_anchor, security_flagged_at, suspended_at, locale, tenant_id
+`
+
+// Blocks sign-in, token refresh and API key use until unsuspended
+func (q *Queries) SuspendUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, suspendUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.PasswordHash,
+		&i.FirstName,
+		&i.LastName,
+		&i.UserType,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Timezone,
+		&i.BillingCycleAnchor,
+		&i.SecurityFlaggedAt,
+		&i.SuspendedAt,
+		&i.Locale,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const unsuspendUser = `-- name: UnsuspendUser :one
+UPDATE users SET suspended_at = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at, timezone, billing_cycle_anchor, security_flagged_at, suspended_at, locale, tenant_id
+`
+
+func (q *Queries) UnsuspendUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, unsuspendUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.PasswordHash,
+		&i.FirstName,
+		&i.LastName,
+		&i.UserType,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Timezone,
+		&i.BillingCycleAnchor,
+		&i.SecurityFlaggedAt,
+		&i.SuspendedAt,
+		&i.Locale,
+		&i.TenantID,
+	)
+	return i, err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users SET
     username = COALESCE(NULLIF($2, ''), username),
@@ -479,7 +903,7 @@ UPDATE users SET
     user_type = COALESCE(NULLIF($6, ''), user_type),
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at
+RETURNING id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at, timezone, billing_cycle_anchor, security_flagged_at, suspended_at, locale, tenant_id
 `
 
 type UpdateUserParams struct {
@@ -511,6 +935,141 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.UserType,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Timezone,
+		&i.BillingCycleAnchor,
+		&i.SecurityFlaggedAt,
+		&i.SuspendedAt,
+		&i.Locale,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const updateUserBillingCycleAnchor = `-- name: UpdateUserBillingCycleAnchor :one
+UPDATE users SET billing_cycle_anchor = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at, timezone, billing_cycle_anchor, security_flagged_at, suspended_at, locale, tenant_id
+`
+
+type UpdateUserBillingCycleAnchorParams struct {
+	ID                 uuid.UUID
+	BillingCycleAnchor sql.NullTime
+}
+
+func (q *Queries) UpdateUserBillingCycleAnchor(ctx context.Context, arg UpdateUserBillingCycleAnchorParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserBillingCycleAnchor, arg.ID, arg.BillingCycleAnchor)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.PasswordHash,
+		&i.FirstName,
+		&i.LastName,
+		&i.UserType,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Timezone,
+		&i.BillingCycleAnchor,
+		&i.SecurityFlaggedAt,
+		&i.SuspendedAt,
+		&i.Locale,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users SET password_hash = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID           uuid.UUID
+	PasswordHash string
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.ID, arg.PasswordHash)
+	return err
+}
+
+const updateUserPreferences = `-- name: UpdateUserPreferences :one
+UPDATE users SET
+    timezone = COALESCE($1, timezone),
+    locale = CASE WHEN $2::boolean THEN $3 ELSE locale END,
+    updated_at = NOW()
+WHERE id = $4
+RETURNING id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at, timezone, billing_cycle_anchor, security_flagged_at, suspended_at, locale, tenant_id
+`
+
+type UpdateUserPreferencesParams struct {
+	Timezone  sql.NullString
+	SetLocale bool
+	Locale    sql.NullString
+	ID        uuid.UUID
+}
+
+// Leaves the timezone as is when it is NULL, and the locale unless
+// set_locale; a NULL locale follows Accept-Language again
+func (q *Queries) UpdateUserPreferences(ctx context.Context, arg UpdateUserPreferencesParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPreferences,
+		arg.Timezone,
+		arg.SetLocale,
+		arg.Locale,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.PasswordHash,
+		&i.FirstName,
+		&i.LastName,
+		&i.UserType,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Timezone,
+		&i.BillingCycleAnchor,
+		&i.SecurityFlaggedAt,
+		&i.SuspendedAt,
+		&i.Locale,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const updateUserTimezone = `-- name: UpdateUserTimezone :one
+UPDATE users SET timezone = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, username, email, password_hash, first_name, last_name, user_type, created_at, updated_at, timezone, billing_cycle_anchor, security_flagged_at, suspended_at, locale, tenant_id
+`
+
+type UpdateUserTimezoneParams struct {
+	ID       uuid.UUID
+	Timezone string
+}
+
+func (q *Queries) UpdateUserTimezone(ctx context.Context, arg UpdateUserTimezoneParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserTimezone, arg.ID, arg.Timezone)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.PasswordHash,
+		&i.FirstName,
+		&i.LastName,
+		&i.UserType,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Timezone,
+		&i.BillingCycleAnchor,
+		&i.SecurityFlaggedAt,
+		&i.SuspendedAt,
+		&i.Locale,
+		&i.TenantID,
 	)
 	return i, err
 }
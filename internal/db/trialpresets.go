@@ -0,0 +1,28 @@
+package db
+
+import (
+	"context"
+
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+)
+
+// DefaultTrialPreset is the limits preset of trial keys provisioned without
+// a campaign code
+const DefaultTrialPreset = "default"
+
+// SeedTrialPreset creates the default trial preset from the TRIAL_* settings
+// when the database has none, and keeps the preset the migrations created in
+// line with them until an admin edits it; from then on the admin's limits
+// stand. It reports whether the preset was created or changed.
+func SeedTrialPreset(ctx context.Context) (bool, error) {
+	created, err := sqlc.New(DB).SeedTrialPreset(ctx, sqlc.SeedTrialPresetParams{
+		Name:                      DefaultTrialPreset,
+		Description:               "Standard trial",
+		MaxDurationSeconds:        int32(config.Int("TRIAL_MAX_DURATION_SECONDS")),
+		MaxSessions:               int32(config.Int("TRIAL_MAX_SESSIONS")),
+		MaxSessionDurationSeconds: int32(config.Int("TRIAL_MAX_SESSION_DURATION_SECONDS")),
+		ExpiryDays:                int32(config.Int("TRIAL_EXPIRY_DAYS")),
+	})
+	return created > 0, err
+}
@@ -0,0 +1,197 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Values are stored as "enc:v1:<data key id>:<base64(nonce || ciphertext)>".
+// Anything without the prefix is treated as legacy plaintext so rows written
+// before encryption was enabled stay readable until they are re-encrypted.
+const prefix = "enc:v1:"
+
+// Purpose distinguishes data keys (which encrypt column values and can be
+// rotated) from the index key (which computes blind indexes for lookups)
+type Purpose string
+
+const (
+	PurposeData  Purpose = "data"
+	PurposeIndex Purpose = "index"
+)
+
+var (
+	ErrNotInitialized = errors.New("encryption keys not loaded")
+	ErrUnknownKey     = errors.New("value encrypted with unknown key")
+	ErrMalformed      = errors.New("malformed encrypted value")
+)
+
+// Key is an unwrapped data or index key
+type Key struct {
+	ID       int32
+	Purpose  Purpose
+	Material []byte
+	Retired  bool
+}
+
+type keyring struct {
+	primary  int32
+	ciphers  map[int32]cipher.AEAD
+	indexKey []byte
+}
+
+var (
+	mu   sync.RWMutex
+	ring *keyring
+)
+
+// GenerateKey returns a new random 256-bit key
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Install replaces the active keyring. The newest non-retired data key
+// becomes the primary key used for new values; retired keys are kept so
+// existing values can still be decrypted.
+func Install(keys []Key) error {
+	r := &keyring{ciphers: make(map[int32]cipher.AEAD)}
+
+	for _, k := range keys {
+		switch k.Purpose {
+		case PurposeIndex:
+			r.indexKey = k.Material
+		case PurposeData:
+			block, err := aes.NewCipher(k.Material)
+			if err != nil {
+				return fmt.Errorf("data key %d: %w", k.ID, err)
+			}
+			gcm, err := cipher.NewGCM(block)
+			if err != nil {
+				return fmt.Errorf("data key %d: %w", k.ID, err)
+			}
+			r.ciphers[k.ID] = gcm
+			if !k.Retired && k.ID > r.primary {
+				r.primary = k.ID
+			}
+		}
+	}
+
+	if r.primary == 0 {
+		return errors.New("no active data key")
+	}
+	if r.indexKey == nil {
+		return errors.New("no index key")
+	}
+
+	mu.Lock()
+	ring = r
+	mu.Unlock()
+	return nil
+}
+
+// Loaded reports whether Install has installed a keyring
+func Loaded() bool {
+	_, err := current()
+	return err == nil
+}
+
+func current() (*keyring, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if ring == nil {
+		return nil, ErrNotInitialized
+	}
+	return ring, nil
+}
+
+// Encrypt encrypts plaintext with the primary data key
+func Encrypt(plaintext string) (string, error) {
+	r, err := current()
+	if err != nil {
+		return "", err
+	}
+
+	gcm := r.ciphers[r.primary]
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return fmt.Sprintf("%s%d:%s", prefix, r.primary, base64.RawStdEncoding.EncodeToString(sealed)), nil
+}
+
+// Decrypt reverses Encrypt. Values that are not encrypted are returned as is.
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	r, err := current()
+	if err != nil {
+		return "", err
+	}
+
+	idPart, data, ok := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	if !ok {
+		return "", ErrMalformed
+	}
+	id, err := strconv.ParseInt(idPart, 10, 32)
+	if err != nil {
+		return "", ErrMalformed
+	}
+	gcm, ok := r.ciphers[int32(id)]
+	if !ok {
+		return "", fmt.Errorf("%w %d", ErrUnknownKey, id)
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(data)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", ErrMalformed
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value with key %d: %w", id, err)
+	}
+	return string(plaintext), nil
+}
+
+// IsEncrypted reports whether value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// PrimaryPrefix returns the prefix of values encrypted with the primary data
+// key. Values without it need re-encrypting after a rotation.
+func PrimaryPrefix() (string, error) {
+	r, err := current()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%d:", prefix, r.primary), nil
+}
+
+// BlindIndex returns a keyed hash of value so encrypted columns can still be
+// looked up by equality without storing the plaintext
+func BlindIndex(value string) (string, error) {
+	r, err := current()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, r.indexKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,225 @@
+package encryption
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// installTestKeys installs data keys 1 (retired) and 2 and an index key
+func installTestKeys(t *testing.T) {
+	t.Helper()
+	keys := []Key{{ID: 3, Purpose: PurposeIndex, Material: make([]byte, 32)}}
+	for id, retired := range map[int32]bool{1: true, 2: false} {
+		material, err := GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, Key{ID: id, Purpose: PurposeData, Material: material, Retired: retired})
+	}
+	if err := Install(keys); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	installTestKeys(t)
+
+	for _, plaintext := range []string{"", "203.0.113.7", "2001:db8::1", strings.Repeat("ü", 1000)} {
+		encrypted, err := Encrypt(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(encrypted, "enc:v1:2:") {
+			t.Errorf("Encrypt(%q) = %q, want the primary key's prefix", plaintext, encrypted)
+		}
+		if plaintext != "" && strings.Contains(encrypted, plaintext) {
+			t.Errorf("Encrypt(%q) leaks the plaintext", plaintext)
+		}
+		decrypted, err := Decrypt(encrypted)
+		if err != nil {
+			t.Fatalf("Decrypt(%q): %v", encrypted, err)
+		}
+		if decrypted != plaintext {
+			t.Errorf("Decrypt(Encrypt(%q)) = %q", plaintext, decrypted)
+		}
+	}
+
+	a, _ := Encrypt("same")
+	b, _ := Encrypt("same")
+	if a == b {
+		t.Error("Encrypt returned the same ciphertext twice")
+	}
+}
+
+func TestPrimaryPrefix(t *testing.T) {
+	installTestKeys(t)
+
+	got, err := PrimaryPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "enc:v1:2:" {
+		t.Errorf("PrimaryPrefix() = %q, want %q", got, "enc:v1:2:")
+	}
+}
+
+func TestDecryptKeyIDs(t *testing.T) {
+	installTestKeys(t)
+
+	valid, err := Encrypt("203.0.113.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := strings.TrimPrefix(valid, "enc:v1:2:")
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr error
+	}{
+		{name: "legacy plaintext", value: "203.0.113.7", want: "203.0.113.7"},
+		{name: "primary key", value: valid, want: "203.0.113.7"},
+		{name: "unknown key", value: "enc:v1:9:" + data, wantErr: ErrUnknownKey},
+		{name: "key ID not a number", value: "enc:v1:two:" + data, wantErr: ErrMalformed},
+		{name: "key ID out of range", value: "enc:v1:4294967298:" + data, wantErr: ErrMalformed},
+		{name: "no key ID", value: "enc:v1:" + data, wantErr: ErrMalformed},
+		{name: "bad base64", value: "enc:v1:2:!!!", wantErr: ErrMalformed},
+		{name: "shorter than a nonce", value: "enc:v1:2:AAAA", wantErr: ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decrypt(tt.value)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Decrypt(%q) error = %v, want %v", tt.value, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decrypt(%q): %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("Decrypt(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecryptWithRetiredKey(t *testing.T) {
+	material, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := Key{ID: 3, Purpose: PurposeIndex, Material: make([]byte, 32)}
+	if err := Install([]Key{{ID: 1, Purpose: PurposeData, Material: material}, index}); err != nil {
+		t.Fatal(err)
+	}
+	old, err := Encrypt("203.0.113.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rotate: key 1 is retired, values written with it stay readable
+	newer, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []Key{
+		{ID: 1, Purpose: PurposeData, Material: material, Retired: true},
+		{ID: 2, Purpose: PurposeData, Material: newer},
+		index,
+	}
+	if err := Install(keys); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Decrypt(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "203.0.113.7" {
+		t.Errorf("Decrypt = %q, want %q", got, "203.0.113.7")
+	}
+	prefix, _ := PrimaryPrefix()
+	if strings.HasPrefix(old, prefix) {
+		t.Errorf("value %q still has the primary prefix %q after rotation", old, prefix)
+	}
+}
+
+func TestInstallRequiresKeys(t *testing.T) {
+	material, _ := GenerateKey()
+	tests := []struct {
+		name string
+		keys []Key
+	}{
+		{"no keys", nil},
+		{"only retired data keys", []Key{
+			{ID: 1, Purpose: PurposeData, Material: material, Retired: true},
+			{ID: 2, Purpose: PurposeIndex, Material: material},
+		}},
+		{"no index key", []Key{{ID: 1, Purpose: PurposeData, Material: material}}},
+		{"bad key size", []Key{
+			{ID: 1, Purpose: PurposeData, Material: material[:7]},
+			{ID: 2, Purpose: PurposeIndex, Material: material},
+		}},
+	}
+
+	for _, tt := range tests {
+		if err := Install(tt.keys); err == nil {
+			t.Errorf("%s: Install succeeded", tt.name)
+		}
+	}
+}
+
+func TestNullString(t *testing.T) {
+	installTestKeys(t)
+
+	value, err := NullString{String: "203.0.113.7", Valid: true}.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(value.(string)) {
+		t.Fatalf("Value() = %q, want an encrypted value", value)
+	}
+
+	var ns NullString
+	if err := ns.Scan(value); err != nil {
+		t.Fatal(err)
+	}
+	if !ns.Valid || ns.String != "203.0.113.7" {
+		t.Errorf("Scan = %+v, want the plaintext", ns)
+	}
+
+	if v, err := (NullString{}).Value(); err != nil || v != nil {
+		t.Errorf("Value() of NULL = %v, %v, want nil", v, err)
+	}
+	if err := ns.Scan(nil); err != nil || ns.Valid {
+		t.Errorf("Scan(nil) = %+v, %v, want NULL", ns, err)
+	}
+	if err := ns.Scan([]byte("198.51.100.1")); err != nil || ns.String != "198.51.100.1" {
+		t.Errorf("Scan of legacy plaintext = %+v, %v", ns, err)
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	installTestKeys(t)
+
+	a, err := BlindIndex("fingerprint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := BlindIndex("fingerprint")
+	c, _ := BlindIndex("other")
+	if a != b {
+		t.Error("BlindIndex is not deterministic")
+	}
+	if a == c {
+		t.Error("BlindIndex returned the same index for different values")
+	}
+	if len(a) != 64 {
+		t.Errorf("len(BlindIndex) = %d, want 64", len(a))
+	}
+}
@@ -0,0 +1,68 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/awsapi"
+	"hyperwhisper/internal/config"
+)
+
+// kmsMasterKey wraps data keys with an AWS KMS key. KMS records which key
+// encrypted a blob, so unwrapping works across KMS key rotations.
+type kmsMasterKey struct {
+	client *awsapi.Client
+	keyID  string
+}
+
+func newKMSMasterKey() (*kmsMasterKey, error) {
+	keyID := config.String("ENCRYPTION_KMS_KEY_ID")
+	if keyID == "" {
+		return nil, errors.New("ENCRYPTION_KMS_KEY_ID is required when ENCRYPTION_KEY_PROVIDER=aws-kms")
+	}
+
+	client, err := awsapi.NewFromConfig(15 * time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &kmsMasterKey{client: client, keyID: keyID}, nil
+}
+
+func (m *kmsMasterKey) ID() string {
+	return "aws-kms:" + m.keyID
+}
+
+func (m *kmsMasterKey) Wrap(ctx context.Context, key []byte) (string, error) {
+	var out struct {
+		CiphertextBlob string `json:"CiphertextBlob"`
+	}
+	err := m.client.Call(ctx, "kms", "TrentService.Encrypt", map[string]string{
+		"KeyId":     m.keyID,
+		"Plaintext": base64.StdEncoding.EncodeToString(key),
+	}, &out)
+	if err != nil {
+		return "", err
+	}
+	return out.CiphertextBlob, nil
+}
+
+func (m *kmsMasterKey) Unwrap(ctx context.Context, masterKeyID, wrapped string) ([]byte, error) {
+	if !strings.HasPrefix(masterKeyID, "aws-kms:") {
+		return nil, fmt.Errorf("key was wrapped by %s, not AWS KMS", masterKeyID)
+	}
+
+	var out struct {
+		Plaintext string `json:"Plaintext"`
+	}
+	err := m.client.Call(ctx, "kms", "TrentService.Decrypt", map[string]string{
+		"CiphertextBlob": wrapped,
+	}, &out)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(out.Plaintext)
+}
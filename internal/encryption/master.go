@@ -0,0 +1,121 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"hyperwhisper/internal/config"
+)
+
+// MasterKey wraps and unwraps data keys (envelope encryption). Only wrapped
+// data keys are stored in the database; the master key lives in the
+// environment or in a KMS.
+type MasterKey interface {
+	// ID identifies the master key that produced a wrapped key
+	ID() string
+	Wrap(ctx context.Context, key []byte) (string, error)
+	Unwrap(ctx context.Context, masterKeyID, wrapped string) ([]byte, error)
+}
+
+// NewMasterKey returns the master key selected by ENCRYPTION_KEY_PROVIDER
+func NewMasterKey() (MasterKey, error) {
+	switch config.String("ENCRYPTION_KEY_PROVIDER") {
+	case "local":
+		return newLocalMasterKey()
+	case "aws-kms":
+		return newKMSMasterKey()
+	default:
+		return nil, fmt.Errorf("unknown encryption key provider %q", config.String("ENCRYPTION_KEY_PROVIDER"))
+	}
+}
+
+// localMasterKey wraps data keys with ENCRYPTION_MASTER_KEY. Keys listed in
+// ENCRYPTION_PREVIOUS_MASTER_KEYS can still unwrap, so the master key can be
+// rotated by moving the old one there and running `encryption rotate`.
+type localMasterKey struct {
+	current string
+	ciphers map[string]cipher.AEAD
+}
+
+func newLocalMasterKey() (*localMasterKey, error) {
+	raw := config.String("ENCRYPTION_MASTER_KEY")
+	if raw == "" {
+		return nil, errors.New("ENCRYPTION_MASTER_KEY is required when ENCRYPTION_KEY_PROVIDER=local")
+	}
+
+	m := &localMasterKey{ciphers: make(map[string]cipher.AEAD)}
+	id, err := m.add(raw)
+	if err != nil {
+		return nil, fmt.Errorf("ENCRYPTION_MASTER_KEY: %w", err)
+	}
+	m.current = id
+
+	for _, prev := range strings.Split(config.String("ENCRYPTION_PREVIOUS_MASTER_KEYS"), ",") {
+		if prev = strings.TrimSpace(prev); prev == "" {
+			continue
+		}
+		if _, err := m.add(prev); err != nil {
+			return nil, fmt.Errorf("ENCRYPTION_PREVIOUS_MASTER_KEYS: %w", err)
+		}
+	}
+	return m, nil
+}
+
+func (m *localMasterKey) add(encoded string) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.New("must be base64 encoded")
+	}
+	if len(key) != 32 {
+		return "", fmt.Errorf("must decode to 32 bytes, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	// The ID is a fingerprint of the key, so it never reveals key material
+	sum := sha256.Sum256(key)
+	id := "local:" + hex.EncodeToString(sum[:4])
+	m.ciphers[id] = gcm
+	return id, nil
+}
+
+func (m *localMasterKey) ID() string {
+	return m.current
+}
+
+func (m *localMasterKey) Wrap(ctx context.Context, key []byte) (string, error) {
+	gcm := m.ciphers[m.current]
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, key, nil)), nil
+}
+
+func (m *localMasterKey) Unwrap(ctx context.Context, masterKeyID, wrapped string) ([]byte, error) {
+	gcm, ok := m.ciphers[masterKeyID]
+	if !ok {
+		return nil, fmt.Errorf("master key %s is not configured (add it to ENCRYPTION_PREVIOUS_MASTER_KEYS)", masterKeyID)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return nil, ErrMalformed
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+}
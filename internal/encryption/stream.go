@@ -0,0 +1,159 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Streams (backups) are encrypted in chunks of up to streamChunkSize bytes,
+// each sealed with AES-GCM as [flag byte][uint32 length][ciphertext]. The
+// nonce is the chunk's index plus the flag, which marks the last chunk, so
+// chunks can't be reordered, dropped or cut off at the end unnoticed.
+const (
+	streamChunkSize = 64 << 10
+	streamLastChunk = 1
+)
+
+// ErrTruncated is returned when a stream ends before its last chunk
+var ErrTruncated = errors.New("encrypted stream is truncated")
+
+func newStreamCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func streamNonce(gcm cipher.AEAD, index uint64, flag byte) []byte {
+	nonce := make([]byte, gcm.NonceSize())
+	binary.BigEndian.PutUint64(nonce, index)
+	nonce[len(nonce)-1] = flag
+	return nonce
+}
+
+type streamWriter struct {
+	w     io.Writer
+	gcm   cipher.AEAD
+	aad   []byte
+	buf   []byte
+	index uint64
+}
+
+// NewStreamWriter encrypts what is written to it into w with key, which
+// must be used for a single stream. aad, e.g. a header stored in the clear
+// beside the stream, is authenticated with every chunk. Close writes the
+// last chunk; a stream that wasn't closed can't be decrypted.
+func NewStreamWriter(w io.Writer, key, aad []byte) (io.WriteCloser, error) {
+	gcm, err := newStreamCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &streamWriter{w: w, gcm: gcm, aad: aad, buf: make([]byte, 0, streamChunkSize)}, nil
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		// A full buffer is only sealed once more data arrives, so the
+		// last chunk is never an empty one following a full one
+		if len(s.buf) == streamChunkSize {
+			if err := s.seal(0); err != nil {
+				return written, err
+			}
+		}
+		n := copy(s.buf[len(s.buf):streamChunkSize], p)
+		s.buf = s.buf[:len(s.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (s *streamWriter) Close() error {
+	return s.seal(streamLastChunk)
+}
+
+func (s *streamWriter) seal(flag byte) error {
+	sealed := s.gcm.Seal(nil, streamNonce(s.gcm, s.index, flag), s.buf, s.aad)
+	header := make([]byte, 5)
+	header[0] = flag
+	binary.BigEndian.PutUint32(header[1:], uint32(len(sealed)))
+	if _, err := s.w.Write(header); err != nil {
+		return err
+	}
+	if _, err := s.w.Write(sealed); err != nil {
+		return err
+	}
+	s.index++
+	s.buf = s.buf[:0]
+	return nil
+}
+
+type streamReader struct {
+	r     io.Reader
+	gcm   cipher.AEAD
+	aad   []byte
+	buf   []byte
+	index uint64
+	done  bool
+}
+
+// NewStreamReader decrypts a stream NewStreamWriter wrote with the same key
+// and aad. Reads fail once a chunk doesn't authenticate, and with
+// ErrTruncated when the stream ends before its last chunk.
+func NewStreamReader(r io.Reader, key, aad []byte) (io.Reader, error) {
+	gcm, err := newStreamCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &streamReader{r: r, gcm: gcm, aad: aad}, nil
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	for len(s.buf) == 0 {
+		if s.done {
+			return 0, io.EOF
+		}
+		if err := s.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	return n, nil
+}
+
+func (s *streamReader) open() error {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(s.r, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrTruncated
+		}
+		return err
+	}
+	flag, size := header[0], binary.BigEndian.Uint32(header[1:])
+	if flag > streamLastChunk || size > streamChunkSize+uint32(s.gcm.Overhead()) {
+		return ErrMalformed
+	}
+
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(s.r, sealed); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrTruncated
+		}
+		return err
+	}
+	plaintext, err := s.gcm.Open(nil, streamNonce(s.gcm, s.index, flag), sealed, s.aad)
+	if err != nil {
+		return fmt.Errorf("chunk %d: %w", s.index, ErrMalformed)
+	}
+	s.index++
+	s.buf = plaintext
+	s.done = flag == streamLastChunk
+	return nil
+}
@@ -0,0 +1,107 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+)
+
+// sealStream encrypts plaintext as a whole stream
+func sealStream(t *testing.T, key, aad, plaintext []byte) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	w, err := NewStreamWriter(&out, key, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Odd-sized writes so chunks don't line up with them
+	for len(plaintext) > 0 {
+		n := min(len(plaintext), 10007)
+		if _, err := w.Write(plaintext[:n]); err != nil {
+			t.Fatal(err)
+		}
+		plaintext = plaintext[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func openStream(key, aad, sealed []byte) ([]byte, error) {
+	r, err := NewStreamReader(bytes.NewReader(sealed), key, aad)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	aad := []byte("hyperwhisper-backup\n{}\n")
+
+	for _, size := range []int{0, 1, streamChunkSize - 1, streamChunkSize, streamChunkSize + 1, 3*streamChunkSize + 17} {
+		plaintext := make([]byte, size)
+		rand.Read(plaintext)
+
+		sealed := sealStream(t, key, aad, plaintext)
+		got, err := openStream(key, aad, sealed)
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("size %d: decrypted stream differs from the plaintext", size)
+		}
+	}
+}
+
+func TestStreamRejectsTampering(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	aad := []byte("header")
+	plaintext := make([]byte, 2*streamChunkSize+100)
+	rand.Read(plaintext)
+	sealed := sealStream(t, key, aad, plaintext)
+	chunk := 5 + streamChunkSize + 16 // header, ciphertext and GCM tag of a full chunk
+
+	otherKey, _ := GenerateKey()
+	flipped := bytes.Clone(sealed)
+	flipped[chunk+100] ^= 1
+	swapped := append(append(bytes.Clone(sealed[chunk:2*chunk]), sealed[:chunk]...), sealed[2*chunk:]...)
+	lastMarked := bytes.Clone(sealed[:chunk])
+	lastMarked[0] = streamLastChunk
+
+	tests := []struct {
+		name    string
+		key     []byte
+		aad     []byte
+		sealed  []byte
+		wantErr error
+	}{
+		{"flipped bit", key, aad, flipped, ErrMalformed},
+		{"chunks reordered", key, aad, swapped, ErrMalformed},
+		{"different header", key, []byte("other header"), sealed, ErrMalformed},
+		{"different key", otherKey, aad, sealed, ErrMalformed},
+		{"first chunk marked last", key, aad, lastMarked, ErrMalformed},
+		{"last chunk dropped", key, aad, sealed[:2*chunk], ErrTruncated},
+		{"cut inside a chunk", key, aad, sealed[:chunk+10], ErrTruncated},
+		{"cut inside a chunk header", key, aad, sealed[:chunk+3], ErrTruncated},
+		{"empty", key, aad, nil, ErrTruncated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := openStream(tt.key, tt.aad, tt.sealed)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package encryption
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// String is a column value that is encrypted when written and decrypted when
+// read. sqlc maps encrypted columns to this type (see sqlc.yaml), so queries
+// and handlers work with plaintext.
+type String string
+
+// Scan implements sql.Scanner
+func (s *String) Scan(src interface{}) error {
+	var raw string
+	switch v := src.(type) {
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	case nil:
+		*s = ""
+		return nil
+	default:
+		return fmt.Errorf("encryption: cannot scan %T into String", src)
+	}
+
+	plaintext, err := Decrypt(raw)
+	if err != nil {
+		return err
+	}
+	*s = String(plaintext)
+	return nil
+}
+
+// Value implements driver.Valuer
+func (s String) Value() (driver.Value, error) {
+	return Encrypt(string(s))
+}
+
+// NullString is the nullable variant of String
+type NullString struct {
+	String string
+	Valid  bool
+}
+
+// Scan implements sql.Scanner
+func (ns *NullString) Scan(src interface{}) error {
+	if src == nil {
+		ns.String, ns.Valid = "", false
+		return nil
+	}
+
+	var s String
+	if err := s.Scan(src); err != nil {
+		return err
+	}
+	ns.String, ns.Valid = string(s), true
+	return nil
+}
+
+// Value implements driver.Valuer
+func (ns NullString) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return Encrypt(ns.String)
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/encryption"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== ACCESS LOGS ==========
+
+const (
+	// accessLogQueueSize bounds the records waiting to be written; when the
+	// database falls behind, further records are dropped
+	accessLogQueueSize = 1024
+	// accessLogMaintenanceInterval is how often partitions are created
+	// ahead and expired ones dropped
+	accessLogMaintenanceInterval = time.Hour
+	// accessLogUserKey lets handlers that authenticate without a JWT (API
+	// key proxies) attribute the request to a user
+	accessLogUserKey = "access_log_user_id"
+)
+
+// AccessLogRecorder persists API access records to the partitioned
+// access_logs table. Requests only enqueue a record; a single writer
+// goroutine inserts them, so a slow database never delays responses.
+type AccessLogRecorder struct {
+	queries *sqlc.Queries
+	records chan sqlc.CreateAccessLogParams
+	dropped atomic.Int64
+}
+
+// NewAccessLogRecorder creates a recorder; Run must be started to write
+// records
+func NewAccessLogRecorder(db *sql.DB) *AccessLogRecorder {
+	return &AccessLogRecorder{
+		queries: sqlc.New(db),
+		records: make(chan sqlc.CreateAccessLogParams, accessLogQueueSize),
+	}
+}
+
+// Middleware records every request it wraps. Health checks should be
+// registered outside of it.
+func (r *AccessLogRecorder) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Int("ACCESS_LOG_RETENTION_DAYS") <= 0 {
+				return next(c)
+			}
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				// Let the error handler write the response so its status
+				// is recorded
+				c.Error(err)
+			}
+
+			r.enqueue(c, start)
+			return err
+		}
+	}
+}
+
+// enqueue builds the access record of a finished request
+func (r *AccessLogRecorder) enqueue(c echo.Context, start time.Time) {
+	req := c.Request()
+
+	record := sqlc.CreateAccessLogParams{
+		CreatedAt: start,
+		Method:    req.Method,
+		Route:     c.Path(),
+		Path:      req.URL.Path,
+		Status:    int32(c.Response().Status),
+		LatencyMs: int32(time.Since(start).Milliseconds()),
+		UserAgent: req.UserAgent(),
+	}
+
+	if claims := auth.GetUserFromContext(c); claims != nil {
+		record.UserID = uuid.NullUUID{UUID: claims.UserID, Valid: true}
+	} else if id, ok := c.Get(accessLogUserKey).(uuid.UUID); ok {
+		record.UserID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	// IPs are encrypted at rest; the blind index makes them filterable
+	if ip := c.RealIP(); ip != "" {
+		record.ClientIp = encryption.NullString{String: ip, Valid: true}
+		if hash, err := encryption.BlindIndex(ip); err == nil {
+			record.ClientIpHash = sql.NullString{String: hash, Valid: true}
+		}
+	}
+
+	select {
+	case r.records <- record:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// Run writes queued records and maintains the table's partitions until ctx
+// is cancelled
+func (r *AccessLogRecorder) Run(ctx context.Context) {
+	r.maintain(ctx)
+	ticker := time.NewTicker(accessLogMaintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case record := <-r.records:
+			if err := r.queries.CreateAccessLog(ctx, record); err != nil {
+				log.Printf("[Access Log] Failed to write access record: %v", err)
+			}
+		case <-ticker.C:
+			r.maintain(ctx)
+			if n := r.dropped.Swap(0); n > 0 {
+				log.Printf("[Access Log] Dropped %d access records, the database could not keep up", n)
+			}
+		}
+	}
+}
+
+// maintain creates upcoming monthly partitions and drops expired ones
+func (r *AccessLogRecorder) maintain(ctx context.Context) {
+	retention := config.Int("ACCESS_LOG_RETENTION_DAYS")
+	if retention <= 0 {
+		return
+	}
+	if err := r.queries.MaintainAccessLogPartitions(ctx, int32(retention)); err != nil {
+		log.Printf("[Access Log] Failed to maintain partitions: %v", err)
+	}
+}
+
+// AccessLogResponse is one persisted API request
+type AccessLogResponse struct {
+	ID        string  `json:"id"`
+	CreatedAt string  `json:"created_at"`
+	Method    string  `json:"method"`
+	Route     string  `json:"route"`
+	Path      string  `json:"path"`
+	Status    int     `json:"status"`
+	LatencyMs int     `json:"latency_ms"`
+	UserID    *string `json:"user_id"`
+	Username  *string `json:"username"`
+	ClientIP  *string `json:"client_ip"`
+	UserAgent string  `json:"user_agent"`
+}
+
+// ListAccessLogs returns persisted API requests, newest first (admin only).
+// Filters: from/to (RFC 3339, default the last 24 hours), user_id, ip,
+// route (the route pattern, e.g. /api/v1/admin/users/:id), method, status
+// (a code like 429 or a class like 5xx) and min_latency_ms.
+func (h *AdminHandler) ListAccessLogs(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	perPage, _ := strconv.Atoi(c.QueryParam("per_page"))
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	now := time.Now()
+	start, end := now.Add(-24*time.Hour), now
+	if v := c.QueryParam("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return apiError(http.StatusBadRequest, "invalid from, expected RFC 3339")
+		}
+		start = t
+	}
+	if v := c.QueryParam("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return apiError(http.StatusBadRequest, "invalid to, expected RFC 3339")
+		}
+		end = t
+	}
+
+	var userID uuid.NullUUID
+	if v := c.QueryParam("user_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return apiError(http.StatusBadRequest, "invalid user ID")
+		}
+		userID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	var ipHash sql.NullString
+	if v := c.QueryParam("ip"); v != "" {
+		hash, err := encryption.BlindIndex(v)
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "encryption not configured")
+		}
+		ipHash = sql.NullString{String: hash, Valid: true}
+	}
+
+	minStatus, maxStatus, ok := parseStatusFilter(c.QueryParam("status"))
+	if !ok {
+		return apiError(http.StatusBadRequest, "invalid status, expected a code like 429 or a class like 4xx")
+	}
+
+	minLatency, _ := strconv.Atoi(c.QueryParam("min_latency_ms"))
+	route := c.QueryParam("route")
+	method := c.QueryParam("method")
+
+	offset := (page - 1) * perPage
+	ctx := context.Background()
+
+	total, err := h.queries.CountAccessLogs(ctx, sqlc.CountAccessLogsParams{
+		StartDate:    start,
+		EndDate:      end,
+		UserID:       userID,
+		ClientIpHash: ipHash,
+		Route:        sql.NullString{String: route, Valid: route != ""},
+		Method:       sql.NullString{String: method, Valid: method != ""},
+		MinStatus:    int32(minStatus),
+		MaxStatus:    int32(maxStatus),
+		MinLatencyMs: int32(minLatency),
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	logs, err := h.queries.ListAccessLogs(ctx, sqlc.ListAccessLogsParams{
+		StartDate:    start,
+		EndDate:      end,
+		UserID:       userID,
+		ClientIpHash: ipHash,
+		Route:        sql.NullString{String: route, Valid: route != ""},
+		Method:       sql.NullString{String: method, Valid: method != ""},
+		MinStatus:    int32(minStatus),
+		MaxStatus:    int32(maxStatus),
+		MinLatencyMs: int32(minLatency),
+		PageLimit:    int32(perPage),
+		PageOffset:   int32(offset),
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	responses := make([]AccessLogResponse, len(logs))
+	for i, l := range logs {
+		responses[i] = toAccessLogResponse(l)
+	}
+
+	totalPages := int(total) / perPage
+	if int(total)%perPage > 0 {
+		totalPages++
+	}
+
+	return c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       responses,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: totalPages,
+	})
+}
+
+// parseStatusFilter turns "", "404" or "4xx" into an inclusive status range
+func parseStatusFilter(s string) (int, int, bool) {
+	if s == "" {
+		return 0, 999, true
+	}
+	if len(s) == 3 && s[1:] == "xx" && s[0] >= '1' && s[0] <= '5' {
+		class := int(s[0]-'0') * 100
+		return class, class + 99, true
+	}
+	code, err := strconv.Atoi(s)
+	if err != nil || code < 100 || code > 599 {
+		return 0, 0, false
+	}
+	return code, code, true
+}
+
+func toAccessLogResponse(l sqlc.ListAccessLogsRow) AccessLogResponse {
+	resp := AccessLogResponse{
+		ID:        l.ID.String(),
+		CreatedAt: l.CreatedAt.Format(time.RFC3339),
+		Method:    l.Method,
+		Route:     l.Route,
+		Path:      l.Path,
+		Status:    int(l.Status),
+		LatencyMs: int(l.LatencyMs),
+		UserID:    nullUUIDPtr(l.UserID),
+		Username:  nullStringPtr(l.Username),
+		UserAgent: l.UserAgent,
+	}
+	if l.ClientIp.Valid {
+		resp.ClientIP = &l.ClientIp.String
+	}
+	return resp
+}
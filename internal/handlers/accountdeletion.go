@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/i18n"
+	"hyperwhisper/internal/mail"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== ACCOUNT DELETION ==========
+
+const (
+	// accountDeletionInterval is how often due deletions are carried out
+	accountDeletionInterval = time.Hour
+	// accountDeletionBatchSize is how many accounts are deleted per query
+	accountDeletionBatchSize = 100
+)
+
+// ScheduleAccountDeletionRequest asks to delete the caller's account; the
+// password confirms it's the user and not a borrowed session
+type ScheduleAccountDeletionRequest struct {
+	Password string `json:"password"`
+}
+
+// AccountDeletionResponse is a pending deletion of the caller's account
+type AccountDeletionResponse struct {
+	RequestedAt string `json:"requested_at"`
+	DeleteAfter string `json:"delete_after"`
+}
+
+// ScheduleAccountDeletion schedules the deletion of the caller's account
+// ACCOUNT_DELETION_GRACE_DAYS from now. The account keeps working until
+// then, so the user can still download an export, and the deletion can be
+// cancelled. Asking again returns the existing schedule. Admins are deleted
+// by another admin instead, so the last one can't lock everyone out, and
+// the last owner of an organization must hand it over first.
+func (h *AuthHandler) ScheduleAccountDeletion(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	var req ScheduleAccountDeletionRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if claims.UserType == "admin" {
+		return apiError(http.StatusForbidden, "admins can't delete their own account")
+	}
+
+	ctx := context.Background()
+	user, err := h.queries.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "user not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	// 403 rather than 401, which clients take as an expired access token
+	if err := auth.CheckPassword(req.Password, user.PasswordHash); err != nil {
+		log.Printf("[Auth] Account deletion of user %s refused: wrong password", user.ID)
+		return apiError(http.StatusForbidden, "password is incorrect")
+	}
+
+	soleOwned, err := h.queries.CountSoleOwnedOrganizations(ctx, user.ID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if soleOwned > 0 {
+		return apiError(http.StatusConflict, "an organization must keep at least one owner")
+	}
+
+	// A repeated request changes nothing and isn't announced again
+	if existing, err := h.queries.GetAccountDeletion(ctx, user.ID); err == nil {
+		return c.JSON(http.StatusAccepted, toAccountDeletionResponse(existing))
+	} else if err != sql.ErrNoRows {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	deletion, err := h.queries.ScheduleAccountDeletion(ctx, sqlc.ScheduleAccountDeletionParams{
+		UserID:      user.ID,
+		DeleteAfter: time.Now().AddDate(0, 0, config.Int("ACCOUNT_DELETION_GRACE_DAYS")),
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to schedule account deletion")
+	}
+
+	recordAuditEvent(ctx, h.queries, c, auditUserDeleteSched, "user", user.ID.String(), "",
+		map[string]string{"delete_after": deletion.DeleteAfter.Format(time.RFC3339)})
+	log.Printf("[Auth] User %s scheduled the deletion of their account for %s", user.ID, deletion.DeleteAfter.Format(time.RFC3339))
+
+	lang := localeOr(user, requestLanguage(c))
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: i18n.Translate(lang, i18n.AccountDeletionSubject),
+		Body: fmt.Sprintf(i18n.Translate(lang, i18n.AccountDeletionBody),
+			user.Username,
+			deletion.DeleteAfter.UTC().Format(time.DateOnly),
+			appBaseURL(ctx, h.queries, user.TenantID)+"/dashboard",
+		),
+	}
+	go func() {
+		if err := h.mailer.Send(context.Background(), msg); err != nil {
+			log.Printf("[Auth] Failed to send account deletion email to user %s: %v", user.ID, err)
+		}
+	}()
+
+	return c.JSON(http.StatusAccepted, toAccountDeletionResponse(deletion))
+}
+
+// GetAccountDeletion returns the pending deletion of the caller's account
+func (h *AuthHandler) GetAccountDeletion(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	deletion, err := h.queries.GetAccountDeletion(context.Background(), claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "no account deletion scheduled")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	return c.JSON(http.StatusOK, toAccountDeletionResponse(deletion))
+}
+
+// CancelAccountDeletion keeps the caller's account
+func (h *AuthHandler) CancelAccountDeletion(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	ctx := context.Background()
+	cancelled, err := h.queries.CancelAccountDeletion(ctx, claims.UserID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if cancelled == 0 {
+		return apiError(http.StatusNotFound, "no account deletion scheduled")
+	}
+
+	recordAuditEvent(ctx, h.queries, c, auditUserDeleteCancel, "user", claims.UserID.String(), "", nil)
+	log.Printf("[Auth] User %s cancelled the deletion of their account", claims.UserID)
+	return c.JSON(http.StatusOK, map[string]string{"message": "account deletion cancelled"})
+}
+
+func toAccountDeletionResponse(d sqlc.AccountDeletion) AccountDeletionResponse {
+	return AccountDeletionResponse{
+		RequestedAt: d.RequestedAt.Format(time.RFC3339),
+		DeleteAfter: d.DeleteAfter.Format(time.RFC3339),
+	}
+}
+
+// AccountDeletionSweep deletes the accounts whose grace period ran out.
+// Organization keys the user created are handed to another member of the
+// organization first, owners preferred. Deleting a user cascades to everything else that
+// references it: personal API keys, refresh tokens, transcription logs and
+// saved transcripts, login history, export jobs and OAuth grants. Export
+// files are removed from EXPORT_DIR.
+type AccountDeletionSweep struct {
+	db      *sql.DB
+	queries *sqlc.Queries
+}
+
+// NewAccountDeletionSweep creates a sweep; Run must be started to delete
+// accounts
+func NewAccountDeletionSweep(db *sql.DB) *AccountDeletionSweep {
+	return &AccountDeletionSweep{db: db, queries: sqlc.New(db)}
+}
+
+// Run deletes due accounts every accountDeletionInterval until ctx is
+// cancelled
+func (s *AccountDeletionSweep) Run(ctx context.Context) {
+	s.sweep(ctx)
+	ticker := time.NewTicker(accountDeletionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+// sweep deletes every account whose deletion is due
+func (s *AccountDeletionSweep) sweep(ctx context.Context) {
+	for ctx.Err() == nil {
+		due, err := s.queries.ListDueAccountDeletions(ctx, accountDeletionBatchSize)
+		if err != nil {
+			log.Printf("[Account Deletion] Failed to list due deletions: %v", err)
+			return
+		}
+
+		for _, d := range due {
+			if !s.delete(ctx, d) {
+				return
+			}
+		}
+
+		if len(due) < accountDeletionBatchSize {
+			return
+		}
+	}
+}
+
+// delete deletes one account, reporting whether the sweep can go on
+func (s *AccountDeletionSweep) delete(ctx context.Context, d sqlc.AccountDeletion) bool {
+	// The jobs go with the user, so their files are looked up first
+	exports, err := s.queries.ListUserExportJobIDs(ctx, d.UserID)
+	if err != nil {
+		log.Printf("[Account Deletion] Failed to list exports of user %s: %v", d.UserID, err)
+		return false
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[Account Deletion] Failed to begin transaction: %v", err)
+		return false
+	}
+	defer tx.Rollback()
+	queries := s.queries.WithTx(tx)
+
+	// The organization's keys would otherwise cascade with their creator
+	reassigned, err := queries.ReassignOrganizationAPIKeys(ctx, d.UserID)
+	if err != nil {
+		log.Printf("[Account Deletion] Failed to reassign organization keys of user %s: %v", d.UserID, err)
+		return false
+	}
+	deleted, err := queries.DeleteScheduledUser(ctx, d.UserID)
+	if err != nil {
+		log.Printf("[Account Deletion] Failed to delete user %s: %v", d.UserID, err)
+		return false
+	}
+	// Cancelled meanwhile, deleted by another server, or the user became an
+	// organization's last owner; the rollback keeps the keys theirs
+	if deleted == 0 {
+		return true
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("[Account Deletion] Failed to delete user %s: %v", d.UserID, err)
+		return false
+	}
+
+	denyUserAccessTokens(ctx, s.queries, d.UserID)
+	for _, id := range exports {
+		removeExportFiles(id)
+	}
+	recordAuditEvent(ctx, s.queries, nil, auditUserDelete, "user", d.UserID.String(), "account deletion requested by the user",
+		map[string]string{
+			"requested_at":      d.RequestedAt.Format(time.RFC3339),
+			"organization_keys": strconv.FormatInt(reassigned, 10),
+		})
+	log.Printf("[Account Deletion] Deleted user %s as requested on %s", d.UserID, d.RequestedAt.Format(time.RFC3339))
+	return true
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"hyperwhisper/internal/config"
+
+	"github.com/gorilla/websocket"
+)
+
+// ========== ADAPTATION HINTS ==========
+
+const (
+	// adaptationHintRepeat is how often a hint is repeated while the
+	// pressure lasts
+	adaptationHintRepeat = 30 * time.Second
+	// adaptationPauseGap restarts the lag measurement when audio stops for
+	// this long, e.g. a client that only streams while someone speaks
+	adaptationPauseGap = 3 * time.Second
+	// adaptationMinSampleRate is the lowest sample rate hints recommend
+	adaptationMinSampleRate = 8000
+)
+
+// adaptationHintMessage asks a ProtocolV3 client to change how it streams.
+// Reason is upstream_backpressure when forwarding audio to Deepgram is slow,
+// client_lag when the client's audio arrives well behind real time, or
+// recovered when a hinted session has been fine for a while again.
+type adaptationHintMessage struct {
+	Type                  string `json:"type"`
+	Reason                string `json:"reason"`
+	Action                string `json:"action"` // reduce_bitrate or restore
+	WriteLatencyMs        int64  `json:"write_latency_ms"`
+	LagMs                 int64  `json:"lag_ms"`
+	RecommendedSampleRate int    `json:"recommended_sample_rate,omitempty"`
+}
+
+// pacingMonitor watches how a session's audio flows to Deepgram and decides
+// when the client should be hinted to send less. It is only used by the
+// session's client read loop.
+type pacingMonitor struct {
+	// bytesPerSecond is the byte rate of raw audio; 0 for encodings without
+	// one, whose lag is not measured
+	bytesPerSecond float64
+	sampleRate     int
+
+	first   time.Time     // Start of the current lag measurement
+	last    time.Time     // When the last audio message arrived
+	audio   float64       // Seconds of audio received since first
+	latency time.Duration // Moving average of upstream write latency
+
+	pressuredSince time.Time // Zero while there is no pressure
+	hintedAt       time.Time // Zero until a hint is sent, and once recovered
+	calmSince      time.Time // Since when a hinted session is fine again
+}
+
+// newPacingMonitor returns the monitor of a session, or nil when the client
+// doesn't understand hints or ADAPTATION_SUSTAIN disables them
+func newPacingMonitor(protocol ClientProtocol, params map[string]string) *pacingMonitor {
+	if protocol < ProtocolV3 || config.Duration("ADAPTATION_SUSTAIN") <= 0 {
+		return nil
+	}
+	clock := newAudioClock(params)
+	m := &pacingMonitor{sampleRate: clock.sampleRate}
+	if width, ok := rawBytesPerSample[clock.encoding]; ok && clock.sampleRate > 0 {
+		m.bytesPerSecond = float64(clock.sampleRate * clock.channels * width)
+	}
+	return m
+}
+
+// observe records an audio message of n bytes received at now whose
+// forwarding to Deepgram took writeLatency. It returns the hint to send, if
+// any; pressure must last ADAPTATION_SUSTAIN before the client is told.
+func (m *pacingMonitor) observe(n int, now time.Time, writeLatency time.Duration) *adaptationHintMessage {
+	if m == nil {
+		return nil
+	}
+
+	if m.first.IsZero() || now.Sub(m.last) >= adaptationPauseGap {
+		m.first, m.audio = now, 0
+	}
+	m.last = now
+	var lag time.Duration
+	if m.bytesPerSecond > 0 {
+		m.audio += float64(n) / m.bytesPerSecond
+		lag = now.Sub(m.first) - time.Duration(m.audio*float64(time.Second))
+	}
+	m.latency += (writeLatency - m.latency) / 5
+
+	reason := ""
+	if limit := config.Duration("ADAPTATION_WRITE_LATENCY"); limit > 0 && m.latency >= limit {
+		reason = "upstream_backpressure"
+	} else if limit := config.Duration("ADAPTATION_LAG"); limit > 0 && lag >= limit {
+		reason = "client_lag"
+	}
+	sustain := config.Duration("ADAPTATION_SUSTAIN")
+
+	if reason == "" {
+		m.pressuredSince = time.Time{}
+		if m.hintedAt.IsZero() {
+			return nil
+		}
+		if m.calmSince.IsZero() {
+			m.calmSince = now
+		}
+		if now.Sub(m.calmSince) < sustain {
+			return nil
+		}
+		m.hintedAt, m.calmSince = time.Time{}, time.Time{}
+		return m.hint("recovered", "restore", lag, 0)
+	}
+
+	m.calmSince = time.Time{}
+	if m.pressuredSince.IsZero() {
+		m.pressuredSince = now
+	}
+	if now.Sub(m.pressuredSince) < sustain {
+		return nil
+	}
+	if !m.hintedAt.IsZero() && now.Sub(m.hintedAt) < adaptationHintRepeat {
+		return nil
+	}
+	m.hintedAt = now
+	return m.hint(reason, "reduce_bitrate", lag, m.recommendedSampleRate())
+}
+
+func (m *pacingMonitor) hint(reason, action string, lag time.Duration, sampleRate int) *adaptationHintMessage {
+	return &adaptationHintMessage{
+		Type:                  "AdaptationHint",
+		Reason:                reason,
+		Action:                action,
+		WriteLatencyMs:        m.latency.Milliseconds(),
+		LagMs:                 max(lag, 0).Milliseconds(),
+		RecommendedSampleRate: sampleRate,
+	}
+}
+
+// recommendedSampleRate halves the session's sample rate, down to 8 kHz; 0
+// when it is unknown or already that low
+func (m *pacingMonitor) recommendedSampleRate() int {
+	if m.sampleRate <= adaptationMinSampleRate {
+		return 0
+	}
+	return max(m.sampleRate/2, adaptationMinSampleRate)
+}
+
+// sendAdaptationHint writes a hint in the background so a slow client
+// doesn't hold up the audio it is sending. clientMu serializes the writes
+// to the session's client connection.
+func sendAdaptationHint(clientConn *websocket.Conn, clientMu *sync.Mutex, hint *adaptationHintMessage) {
+	go func() {
+		clientMu.Lock()
+		defer clientMu.Unlock()
+		_ = writeMessageJSON(clientConn, hint)
+	}()
+}
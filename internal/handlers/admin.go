@@ -3,12 +3,20 @@ package handlers
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
 	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/i18n"
+	"hyperwhisper/internal/logging"
+	"hyperwhisper/internal/mail"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -16,13 +24,17 @@ import (
 
 // AdminHandler handles admin endpoints
 type AdminHandler struct {
+	db      *sql.DB
 	queries *sqlc.Queries
+	mailer  mail.Sender
 }
 
 // NewAdminHandler creates a new admin handler
 func NewAdminHandler(db *sql.DB) *AdminHandler {
 	return &AdminHandler{
+		db:      db,
 		queries: sqlc.New(db),
+		mailer:  mail.NewFromConfig(),
 	}
 }
 
@@ -34,6 +46,23 @@ type CreateUserRequest struct {
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	UserType  string `json:"user_type"`
+	// TenantID puts the user in a tenant (operator admins only); users
+	// created by tenant admins always join theirs
+	TenantID string `json:"tenant_id"`
+}
+
+// UpdateUserRequest changes a user (admin only). Empty fields keep their
+// current value.
+type UpdateUserRequest struct {
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	UserType  string `json:"user_type"`
+	// ForcePasswordReset makes the current password stop working and emails
+	// the user a reset link
+	ForcePasswordReset bool   `json:"force_password_reset"`
+	Reason             string `json:"reason"`
 }
 
 type RevokeTokenRequest struct {
@@ -41,6 +70,17 @@ type RevokeTokenRequest struct {
 	Reason   string `json:"reason"`
 }
 
+type APIKeyRevocationRequest struct {
+	Reason string `json:"reason"`
+}
+
+type APIKeyTransferRequest struct {
+	UserID         string `json:"user_id"`
+	OrganizationID string `json:"organization_id"`
+	IncludeHistory bool   `json:"include_history"`
+	Reason         string `json:"reason"`
+}
+
 // Response types
 type PaginatedResponse struct {
 	Data       interface{} `json:"data"`
@@ -53,7 +93,10 @@ type PaginatedResponse struct {
 type TokenResponse struct {
 	ID            string  `json:"id"`
 	TokenJTI      string  `json:"token_jti"`
+	FamilyID      string  `json:"family_id"` // Shared by the tokens rotated from one sign-in
 	UserID        string  `json:"user_id"`
+	Username      string  `json:"username"`
+	Email         string  `json:"email"`
 	IssuedAt      string  `json:"issued_at"`
 	ExpiresAt     string  `json:"expires_at"`
 	RevokedAt     *string `json:"revoked_at"`
@@ -62,7 +105,8 @@ type TokenResponse struct {
 
 // ========== USER MANAGEMENT ==========
 
-// ListUsers returns a paginated list of users
+// ListUsers returns a paginated list of users. Tenant staff only see their
+// tenant's; operator staff may filter by tenant_id.
 func (h *AdminHandler) ListUsers(c echo.Context) error {
 	page, _ := strconv.Atoi(c.QueryParam("page"))
 	if page < 1 {
@@ -77,19 +121,29 @@ func (h *AdminHandler) ListUsers(c echo.Context) error {
 	offset := (page - 1) * perPage
 	ctx := context.Background()
 
+	tenant := callerTenant(c)
+	if v := c.QueryParam("tenant_id"); v != "" && !tenant.Valid {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return apiError(http.StatusBadRequest, "invalid tenant ID")
+		}
+		tenant = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
 	// Get total count
-	total, err := h.queries.CountUsers(ctx)
+	total, err := h.queries.CountListedUsers(ctx, tenant)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	// Get users
 	users, err := h.queries.ListUsers(ctx, sqlc.ListUsersParams{
-		Limit:  int32(perPage),
-		Offset: int32(offset),
+		TenantID:   tenant,
+		PageLimit:  int32(perPage),
+		PageOffset: int32(offset),
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	// Convert to response format
@@ -116,25 +170,25 @@ func (h *AdminHandler) ListUsers(c echo.Context) error {
 func (h *AdminHandler) CreateUser(c echo.Context) error {
 	var req CreateUserRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+		return apiError(http.StatusBadRequest, "invalid request body")
 	}
 
 	// Validate required fields
 	if req.Username == "" || req.Email == "" || req.Password == "" {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "username, email, and password are required"})
+		return apiError(http.StatusBadRequest, "username, email, and password are required")
 	}
 
 	// Validate user type
 	if req.UserType == "" {
 		req.UserType = "user"
 	}
-	if req.UserType != "user" && req.UserType != "admin" {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "user_type must be 'user' or 'admin'"})
+	if !auth.IsRole(req.UserType) {
+		return apiError(http.StatusBadRequest, "user_type must be 'user', 'support' or 'admin'")
 	}
 
 	// Validate password
 	if err := auth.ValidatePassword(req.Password); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
+		return newAPIError(http.StatusBadRequest, ErrorResponse{
 			Error:   "password validation failed",
 			Details: map[string]string{"password": err.Error()},
 		})
@@ -142,34 +196,43 @@ func (h *AdminHandler) CreateUser(c echo.Context) error {
 
 	ctx := context.Background()
 
+	tenant := callerTenant(c)
+	if req.TenantID != "" && !tenant.Valid {
+		id, err := uuid.Parse(req.TenantID)
+		if err != nil {
+			return apiError(http.StatusBadRequest, "invalid tenant ID")
+		}
+		if _, err := h.queries.GetTenant(ctx, id); err != nil {
+			if err == sql.ErrNoRows {
+				return apiError(http.StatusBadRequest, "tenant not found")
+			}
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+		tenant = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
 	// Check if email exists
 	emailExists, err := h.queries.CheckEmailExists(ctx, req.Email)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 	if emailExists {
-		return c.JSON(http.StatusConflict, ErrorResponse{
-			Error:   "email already taken",
-			Details: map[string]string{"email": "this email is already registered"},
-		})
+		return userConflictError(c, "email")
 	}
 
 	// Check if username exists
 	usernameExists, err := h.queries.CheckUsernameExists(ctx, req.Username)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 	if usernameExists {
-		return c.JSON(http.StatusConflict, ErrorResponse{
-			Error:   "username already taken",
-			Details: map[string]string{"username": "this username is already taken"},
-		})
+		return userConflictError(c, "username")
 	}
 
 	// Hash password
 	passwordHash, err := auth.HashPassword(req.Password)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to process password"})
+		return apiError(http.StatusInternalServerError, "failed to process password")
 	}
 
 	// Create user
@@ -180,77 +243,541 @@ func (h *AdminHandler) CreateUser(c echo.Context) error {
 		FirstName:    req.FirstName,
 		LastName:     req.LastName,
 		UserType:     req.UserType,
+		TenantID:     tenant,
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to create user"})
+		if field, ok := userUniqueViolation(err); ok {
+			return userConflictError(c, field)
+		}
+		return apiError(http.StatusInternalServerError, "failed to create user")
+	}
+	if err := recordPassword(ctx, h.queries, user.ID, passwordHash); err != nil {
+		log.Printf("[Admin] Failed to record password history of user %s: %v", user.ID, err)
 	}
 
 	return c.JSON(http.StatusCreated, toUserResponse(user))
 }
 
-// DeleteUser deletes a user by ID
+// UpdateUser changes a user's email, username, names or role, with the
+// checks CreateUser makes (admin only). Changing the role revokes the
+// user's tokens, which carry it, so it applies at their next sign-in. With
+// force_password_reset the password is replaced by an unusable one, the
+// user is signed out everywhere and emailed a reset link.
+func (h *AdminHandler) UpdateUser(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	var req UpdateUserRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if req.UserType != "" && !auth.IsRole(req.UserType) {
+		return apiError(http.StatusBadRequest, "user_type must be 'user', 'support' or 'admin'")
+	}
+	// An admin demoting themselves could leave no one to undo it
+	claims := auth.GetUserFromContext(c)
+	if claims != nil && claims.UserID == userID && req.UserType != "" && req.UserType != auth.RoleAdmin {
+		return apiError(http.StatusBadRequest, "cannot change your own role")
+	}
+
+	ctx := context.Background()
+
+	existing, err := h.managedUser(c, ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "user not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	if req.Email != "" && req.Email != existing.Email {
+		emailExists, err := h.queries.CheckEmailExists(ctx, req.Email)
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+		if emailExists {
+			return userConflictError(c, "email")
+		}
+	}
+	if req.Username != "" && req.Username != existing.Username {
+		usernameExists, err := h.queries.CheckUsernameExists(ctx, req.Username)
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+		if usernameExists {
+			return userConflictError(c, "username")
+		}
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	defer tx.Rollback()
+	queries := h.queries.WithTx(tx)
+
+	user, err := queries.UpdateUser(ctx, sqlc.UpdateUserParams{
+		ID:      userID,
+		Column2: req.Username,
+		Column3: req.Email,
+		Column4: req.FirstName,
+		Column5: req.LastName,
+		Column6: req.UserType,
+	})
+	if err != nil {
+		if field, ok := userUniqueViolation(err); ok {
+			return userConflictError(c, field)
+		}
+		return apiError(http.StatusInternalServerError, "failed to update user")
+	}
+
+	changes := map[string]string{}
+	for field, values := range map[string][2]string{
+		"username":   {existing.Username, user.Username},
+		"email":      {existing.Email, user.Email},
+		"first_name": {existing.FirstName, user.FirstName},
+		"last_name":  {existing.LastName, user.LastName},
+		"user_type":  {existing.UserType, user.UserType},
+	} {
+		if values[0] != values[1] {
+			changes[field] = values[1]
+		}
+	}
+
+	var resetToken string
+	revokedReason := ""
+	if req.ForcePasswordReset {
+		// Nobody knows this password, so only the reset link gets the
+		// user back in
+		unusable, err := newRandomToken()
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "failed to process password")
+		}
+		passwordHash, err := auth.HashPassword(unusable)
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "failed to process password")
+		}
+		if err := queries.UpdateUserPassword(ctx, sqlc.UpdateUserPasswordParams{
+			ID:           userID,
+			PasswordHash: passwordHash,
+		}); err != nil {
+			return apiError(http.StatusInternalServerError, "failed to update password")
+		}
+
+		if resetToken, err = newRandomToken(); err != nil {
+			return apiError(http.StatusInternalServerError, "failed to generate reset link")
+		}
+		if err := queries.InvalidatePasswordResetTokens(ctx, userID); err != nil {
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+		if _, err := queries.CreatePasswordResetToken(ctx, sqlc.CreatePasswordResetTokenParams{
+			UserID:    userID,
+			TokenHash: hashAPIKey(resetToken),
+			ExpiresAt: time.Now().Add(config.Duration("PASSWORD_RESET_TTL")),
+		}); err != nil {
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+		changes["force_password_reset"] = "true"
+		revokedReason = "password_reset_forced"
+	} else if _, ok := changes["user_type"]; ok {
+		revokedReason = "role_changed"
+	}
+
+	if revokedReason != "" {
+		if err := queries.RevokeUserRefreshTokens(ctx, sqlc.RevokeUserRefreshTokensParams{
+			UserID:        userID,
+			RevokedReason: sql.NullString{String: revokedReason, Valid: true},
+		}); err != nil {
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to update user")
+	}
+
+	if revokedReason != "" {
+		denyUserAccessTokens(ctx, h.queries, userID)
+	}
+	if resetToken != "" {
+		h.sendForcedPasswordReset(user, resetToken)
+	}
+
+	log.Printf("[Admin] Updated user %s: %v", userID, changes)
+	recordAuditEvent(ctx, h.queries, c, auditUserUpdate, "user", userID.String(), strings.TrimSpace(req.Reason), changes)
+
+	return c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// sendForcedPasswordReset emails the reset link of a password reset an
+// admin forced
+func (h *AdminHandler) sendForcedPasswordReset(user sqlc.User, token string) {
+	ttl := config.Duration("PASSWORD_RESET_TTL")
+	// The admin's Accept-Language says nothing about the user's language
+	lang := localeOr(user, i18n.English)
+	resetURL := getPasswordResetURL(appBaseURL(context.Background(), h.queries, user.TenantID), token)
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: i18n.Translate(lang, i18n.ForcedPasswordResetSubject),
+		Body:    fmt.Sprintf(i18n.Translate(lang, i18n.ForcedPasswordResetBody), user.Username, ttl, resetURL),
+	}
+	go func() {
+		if err := h.mailer.Send(context.Background(), msg); err != nil {
+			log.Printf("[Admin] Failed to send password reset email to user %s: %v", user.ID, err)
+		}
+	}()
+}
+
+// DeleteUser deletes a user by ID. With dry_run=true it previews what the
+// deletion removes and issues the confirmation token the real run needs.
 func (h *AdminHandler) DeleteUser(c echo.Context) error {
 	userIDStr := c.Param("id")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid user ID"})
+		return apiError(http.StatusBadRequest, "invalid user ID")
 	}
 
 	// Prevent self-deletion
 	claims := auth.GetUserFromContext(c)
 	if claims != nil && claims.UserID == userID {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "cannot delete your own account"})
+		return apiError(http.StatusBadRequest, "cannot delete your own account")
 	}
 
 	ctx := context.Background()
 
 	// Check if user exists
-	_, err = h.queries.GetUserByID(ctx, userID)
+	_, err = h.managedUser(c, ctx, userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return c.JSON(http.StatusNotFound, ErrorResponse{Error: "user not found"})
+			return apiError(http.StatusNotFound, "user not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	if isDryRun(c) {
+		counts, err := h.queries.GetUserMergeCounts(ctx, userID)
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "database error")
 		}
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return dryRun(c, actionUserDelete, userID.String(), map[string]int64{
+			"users":               1,
+			"api_keys":            counts.ApiKeys,
+			"transcription_logs":  counts.TranscriptionLogs,
+			"refresh_tokens":      counts.RefreshTokens,
+			"error_reports":       counts.ErrorReports,
+			"session_policies":    counts.SessionPolicies,
+			"session_transcripts": counts.SessionTranscripts,
+		}, []string{userID.String()})
+	}
+	if errResp := requireConfirmation(c, actionUserDelete, userID.String()); errResp != nil {
+		return newAPIError(http.StatusPreconditionRequired, *errResp)
 	}
 
 	// Delete user
 	if err := h.queries.DeleteUser(ctx, userID); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to delete user"})
+		return apiError(http.StatusInternalServerError, "failed to delete user")
 	}
+	denyUserAccessTokens(ctx, h.queries, userID)
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "user deleted successfully"})
 }
 
+// BillingCycleAnchorRequest sets when a user's billing cycle starts. A null
+// anchor resets the cycle to the signup date.
+type BillingCycleAnchorRequest struct {
+	Anchor *string `json:"anchor"`
+	Reason string  `json:"reason"`
+}
+
+// SetBillingCycleAnchor moves the day a user's monthly quota window renews
+// (admin only)
+func (h *AdminHandler) SetBillingCycleAnchor(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	var req BillingCycleAnchorRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	var anchor sql.NullTime
+	if req.Anchor != nil {
+		t, err := time.Parse(time.RFC3339, *req.Anchor)
+		if err != nil {
+			return newAPIError(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid anchor",
+				Details: map[string]string{"anchor": "must be an RFC 3339 timestamp"},
+			})
+		}
+		anchor = sql.NullTime{Time: t, Valid: true}
+	}
+
+	ctx := context.Background()
+	user, err := h.queries.UpdateUserBillingCycleAnchor(ctx, sqlc.UpdateUserBillingCycleAnchorParams{
+		ID:                 userID,
+		BillingCycleAnchor: anchor,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "user not found")
+		}
+		return apiError(http.StatusInternalServerError, "failed to update billing cycle")
+	}
+
+	resp := toUserResponse(user)
+	recordAuditEvent(ctx, h.queries, c, auditUserCycle, "user", userID.String(), strings.TrimSpace(req.Reason), map[string]string{
+		"billing_cycle_anchor": resp.BillingCycleAnchor,
+	})
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// ClearSecurityFlagRequest is the request for clearing a user's security flag
+type ClearSecurityFlagRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ClearSecurityFlag marks a user flagged for refresh token reuse as
+// reviewed (admin only)
+func (h *AdminHandler) ClearSecurityFlag(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	var req ClearSecurityFlagRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	ctx := context.Background()
+	user, err := h.queries.ClearUserSecurityFlag(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "user not found")
+		}
+		return apiError(http.StatusInternalServerError, "failed to clear security flag")
+	}
+
+	recordAuditEvent(ctx, h.queries, c, auditUserSecurityFlag, "user", userID.String(), strings.TrimSpace(req.Reason), nil)
+
+	return c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// UserSuspensionRequest is the request for suspending or unsuspending a user
+type UserSuspensionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// SuspendUser disables a user without deleting anything: their refresh
+// tokens are revoked, their access tokens denied and their live sessions
+// ended, and they can't sign in or use their API keys until unsuspended
+// (admin only)
+func (h *AdminHandler) SuspendUser(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	var req UserSuspensionRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return apiError(http.StatusBadRequest, "reason is required")
+	}
+
+	claims := auth.GetUserFromContext(c)
+	if claims != nil && claims.UserID == userID {
+		return apiError(http.StatusBadRequest, "cannot suspend your own account")
+	}
+
+	ctx := context.Background()
+
+	existing, err := h.managedUser(c, ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "user not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if existing.SuspendedAt.Valid {
+		return apiError(http.StatusBadRequest, "user is already suspended")
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	defer tx.Rollback()
+	queries := h.queries.WithTx(tx)
+
+	user, err := queries.SuspendUser(ctx, userID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to suspend user")
+	}
+	if err := queries.RevokeUserRefreshTokens(ctx, sqlc.RevokeUserRefreshTokensParams{
+		UserID:        userID,
+		RevokedReason: sql.NullString{String: "suspended", Valid: true},
+	}); err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if err := tx.Commit(); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to suspend user")
+	}
+
+	denyUserAccessTokens(ctx, h.queries, userID)
+
+	// API key sessions are tracked per key, dashboard sessions per user
+	terminated := Sessions.CloseTagged("user:"+userID.String(), CloseAdminTerminated)
+	keyIDs, err := h.queries.ListUserAPIKeyIDs(ctx, userID)
+	if err != nil {
+		log.Printf("[Admin] Failed to list API keys of suspended user %s: %v", userID, err)
+	}
+	for _, keyID := range keyIDs {
+		terminated += Sessions.CloseTagged(apiKeySessionTag(keyID), CloseAdminTerminated)
+	}
+	log.Printf("[Admin] Suspended user %s, terminated %d live sessions", userID, terminated)
+
+	recordAuditEvent(ctx, h.queries, c, auditUserSuspend, "user", userID.String(), reason, map[string]string{
+		"sessions_terminated": strconv.Itoa(terminated),
+	})
+
+	return c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// UnsuspendUser lifts a user's suspension (admin only). Tokens revoked by
+// the suspension stay revoked; the user signs in again.
+func (h *AdminHandler) UnsuspendUser(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	// The reason is optional when lifting a suspension
+	var req UserSuspensionRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	ctx := context.Background()
+
+	existing, err := h.managedUser(c, ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "user not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if !existing.SuspendedAt.Valid {
+		return apiError(http.StatusBadRequest, "user is not suspended")
+	}
+
+	user, err := h.queries.UnsuspendUser(ctx, userID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to unsuspend user")
+	}
+
+	log.Printf("[Admin] Unsuspended user %s", userID)
+	recordAuditEvent(ctx, h.queries, c, auditUserUnsuspend, "user", userID.String(), strings.TrimSpace(req.Reason), map[string]string{
+		"suspended_at": existing.SuspendedAt.Time.Format(time.RFC3339),
+	})
+
+	return c.JSON(http.StatusOK, toUserResponse(user))
+}
+
 // ========== TOKEN MANAGEMENT ==========
 
-// ListRefreshTokens returns a paginated list of all tokens
+// tokenSorts are the columns the token list can be sorted by
+var tokenSorts = []string{"issued_at", "expires_at", "username"}
+
+// ListRefreshTokens returns a paginated list of all tokens with their
+// owners. Filters: status (active, revoked or expired), user_id, q (matches
+// username or email), from/to (RFC 3339, on issued_at), sort (issued_at,
+// expires_at or username) and order (asc or desc, default desc).
 func (h *AdminHandler) ListRefreshTokens(c echo.Context) error {
-	page, _ := strconv.Atoi(c.QueryParam("page"))
-	if page < 1 {
-		page = 1
+	page, perPage, offset := getPaginationParams(c)
+
+	var status sql.NullString
+	if v := c.QueryParam("status"); v != "" {
+		if !contains([]string{"active", "revoked", "expired"}, v) {
+			return apiError(http.StatusBadRequest, "invalid status, expected active, revoked or expired")
+		}
+		status = sql.NullString{String: v, Valid: true}
 	}
 
-	perPage, _ := strconv.Atoi(c.QueryParam("per_page"))
-	if perPage < 1 || perPage > 100 {
-		perPage = 20
+	var userID uuid.NullUUID
+	if v := c.QueryParam("user_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return apiError(http.StatusBadRequest, "invalid user ID")
+		}
+		userID = uuid.NullUUID{UUID: id, Valid: true}
 	}
 
-	offset := (page - 1) * perPage
+	var issuedFrom, issuedTo sql.NullTime
+	if v := c.QueryParam("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return apiError(http.StatusBadRequest, "invalid from, expected RFC 3339")
+		}
+		issuedFrom = sql.NullTime{Time: t, Valid: true}
+	}
+	if v := c.QueryParam("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return apiError(http.StatusBadRequest, "invalid to, expected RFC 3339")
+		}
+		issuedTo = sql.NullTime{Time: t, Valid: true}
+	}
+
+	sort := c.QueryParam("sort")
+	if sort == "" {
+		sort = "issued_at"
+	}
+	if !contains(tokenSorts, sort) {
+		return apiError(http.StatusBadRequest, "invalid sort, expected issued_at, expires_at or username")
+	}
+	order := c.QueryParam("order")
+	if order != "" && order != "asc" && order != "desc" {
+		return apiError(http.StatusBadRequest, "invalid order, expected asc or desc")
+	}
+
+	search := strings.TrimSpace(c.QueryParam("q"))
 	ctx := context.Background()
 
 	// Get total count
-	total, err := h.queries.CountRefreshTokens(ctx)
+	total, err := h.queries.CountRefreshTokens(ctx, sqlc.CountRefreshTokensParams{
+		UserID:     userID,
+		Status:     status,
+		Search:     sql.NullString{String: search, Valid: search != ""},
+		IssuedFrom: issuedFrom,
+		IssuedTo:   issuedTo,
+	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	// Get tokens
 	tokens, err := h.queries.ListRefreshTokens(ctx, sqlc.ListRefreshTokensParams{
-		Limit:  int32(perPage),
-		Offset: int32(offset),
+		UserID:     userID,
+		Status:     status,
+		Search:     sql.NullString{String: search, Valid: search != ""},
+		IssuedFrom: issuedFrom,
+		IssuedTo:   issuedTo,
+		Sort:       sort,
+		Descending: order != "asc",
+		PageLimit:  int32(perPage),
+		PageOffset: int32(offset),
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	// Convert to response format
@@ -259,17 +786,12 @@ func (h *AdminHandler) ListRefreshTokens(c echo.Context) error {
 		tokenResponses[i] = toTokenResponse(token)
 	}
 
-	totalPages := int(total) / perPage
-	if int(total)%perPage > 0 {
-		totalPages++
-	}
-
 	return c.JSON(http.StatusOK, PaginatedResponse{
 		Data:       tokenResponses,
 		Total:      total,
 		Page:       page,
 		PerPage:    perPage,
-		TotalPages: totalPages,
+		TotalPages: calculateTotalPages(total, perPage),
 	})
 }
 
@@ -277,11 +799,11 @@ func (h *AdminHandler) ListRefreshTokens(c echo.Context) error {
 func (h *AdminHandler) RevokeToken(c echo.Context) error {
 	var req RevokeTokenRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+		return apiError(http.StatusBadRequest, "invalid request body")
 	}
 
 	if req.TokenJTI == "" {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "token_jti is required"})
+		return apiError(http.StatusBadRequest, "token_jti is required")
 	}
 
 	reason := req.Reason
@@ -295,14 +817,14 @@ func (h *AdminHandler) RevokeToken(c echo.Context) error {
 	token, err := h.queries.GetRefreshTokenByJTI(ctx, req.TokenJTI)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return c.JSON(http.StatusNotFound, ErrorResponse{Error: "token not found"})
+			return apiError(http.StatusNotFound, "token not found")
 		}
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	// Check if already revoked
 	if token.RevokedAt.Valid {
-		return c.JSON(http.StatusConflict, ErrorResponse{Error: "token already revoked"})
+		return apiError(http.StatusConflict, "token already revoked")
 	}
 
 	// Revoke the token
@@ -311,18 +833,20 @@ func (h *AdminHandler) RevokeToken(c echo.Context) error {
 		RevokedReason: sql.NullString{String: reason, Valid: true},
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to revoke token"})
+		return apiError(http.StatusInternalServerError, "failed to revoke token")
 	}
+	denyAccessToken(ctx, h.queries, req.TokenJTI)
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "token revoked successfully"})
 }
 
-// RevokeUserRefreshTokens revokes all tokens for a user
+// RevokeUserRefreshTokens revokes all tokens for a user. Supports dry_run and
+// requires a confirmation token, like DeleteUser.
 func (h *AdminHandler) RevokeUserRefreshTokens(c echo.Context) error {
 	userIDStr := c.Param("id")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid user ID"})
+		return apiError(http.StatusBadRequest, "invalid user ID")
 	}
 
 	ctx := context.Background()
@@ -331,9 +855,20 @@ func (h *AdminHandler) RevokeUserRefreshTokens(c echo.Context) error {
 	_, err = h.queries.GetUserByID(ctx, userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return c.JSON(http.StatusNotFound, ErrorResponse{Error: "user not found"})
+			return apiError(http.StatusNotFound, "user not found")
 		}
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	if isDryRun(c) {
+		jtis, err := h.queries.ListUnrevokedUserRefreshTokenJTIs(ctx, userID)
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+		return dryRun(c, actionUserRevokeTokens, userID.String(), map[string]int64{"refresh_tokens": int64(len(jtis))}, jtis)
+	}
+	if errResp := requireConfirmation(c, actionUserRevokeTokens, userID.String()); errResp != nil {
+		return newAPIError(http.StatusPreconditionRequired, *errResp)
 	}
 
 	// Revoke all tokens for user
@@ -342,18 +877,31 @@ func (h *AdminHandler) RevokeUserRefreshTokens(c echo.Context) error {
 		RevokedReason: sql.NullString{String: "admin", Valid: true},
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to revoke tokens"})
+		return apiError(http.StatusInternalServerError, "failed to revoke tokens")
 	}
+	denyUserAccessTokens(ctx, h.queries, userID)
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "user tokens revoked successfully"})
 }
 
-// CleanupTokens removes expired tokens
+// CleanupTokens removes expired tokens. Supports dry_run and requires a
+// confirmation token, like DeleteUser.
 func (h *AdminHandler) CleanupTokens(c echo.Context) error {
 	ctx := context.Background()
 
+	if isDryRun(c) {
+		jtis, err := h.queries.ListExpiredRefreshTokenJTIs(ctx)
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+		return dryRun(c, actionTokensCleanup, "", map[string]int64{"refresh_tokens": int64(len(jtis))}, jtis)
+	}
+	if errResp := requireConfirmation(c, actionTokensCleanup, ""); errResp != nil {
+		return newAPIError(http.StatusPreconditionRequired, *errResp)
+	}
+
 	if err := h.queries.CleanupExpiredRefreshTokens(ctx); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to cleanup tokens"})
+		return apiError(http.StatusInternalServerError, "failed to cleanup tokens")
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "expired tokens cleaned up successfully"})
@@ -363,17 +911,20 @@ func (h *AdminHandler) CleanupTokens(c echo.Context) error {
 
 // AdminTranscriptionLogResponse extends TranscriptionLogResponse with user info
 type AdminTranscriptionLogResponse struct {
-	ID              string  `json:"id"`
-	UserID          string  `json:"user_id"`
-	Username        string  `json:"username"`
-	Email           string  `json:"email"`
-	APIKeyName      string  `json:"api_key_name"`
-	StartedAt       string  `json:"started_at"`
-	EndedAt         *string `json:"ended_at"`
-	DurationSeconds *string `json:"duration_seconds"`
-	Status          string  `json:"status"`
-	ErrorMessage    *string `json:"error_message,omitempty"`
-	BytesSent       int64   `json:"bytes_sent"`
+	ID                string          `json:"id"`
+	UserID            string          `json:"user_id"`
+	Username          string          `json:"username"`
+	Email             string          `json:"email"`
+	APIKeyName        string          `json:"api_key_name"`
+	StartedAt         string          `json:"started_at"`
+	EndedAt           *string         `json:"ended_at"`
+	DurationSeconds   *string         `json:"duration_seconds"`
+	DurationEstimated bool            `json:"duration_estimated"`
+	Status            string          `json:"status"`
+	ErrorMessage      *string         `json:"error_message,omitempty"`
+	ErrorCode         *string         `json:"error_code,omitempty"`
+	BytesSent         int64           `json:"bytes_sent"`
+	Redaction         *RedactionAudit `json:"redaction"`
 }
 
 // AdminAPIKeyResponse extends APIKeyResponse with user info
@@ -387,6 +938,15 @@ type AdminAPIKeyResponse struct {
 	CreatedAt string  `json:"created_at"`
 	LastUsed  *string `json:"last_used_at"`
 	RevokedAt *string `json:"revoked_at,omitempty"`
+
+	LastUsedIP        *string `json:"last_used_ip"`
+	LastUsedUserAgent *string `json:"last_used_user_agent"`
+	UseCount          int64   `json:"use_count"`
+
+	// Set when the key belongs to an organization; UserID is its creator
+	OrganizationID *string `json:"organization_id"`
+
+	ParamRestrictions ParamRestrictions `json:"param_restrictions"`
 }
 
 // SystemUsageSummaryResponse is the response for system-wide usage
@@ -395,8 +955,10 @@ type SystemUsageSummaryResponse struct {
 	TotalSessions        int64   `json:"total_sessions"`
 	TotalDurationSeconds float64 `json:"total_duration_seconds"`
 	TotalBytesSent       int64   `json:"total_bytes_sent"`
+	Period               string  `json:"period"`
 	PeriodStart          string  `json:"period_start"`
 	PeriodEnd            string  `json:"period_end"`
+	Timezone             string  `json:"timezone"`
 }
 
 // ListAllTranscriptionLogs returns all transcription logs (admin only)
@@ -416,7 +978,7 @@ func (h *AdminHandler) ListAllTranscriptionLogs(c echo.Context) error {
 
 	total, err := h.queries.CountAllTranscriptionLogs(ctx)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	logs, err := h.queries.ListAllTranscriptionLogs(ctx, sqlc.ListAllTranscriptionLogsParams{
@@ -424,7 +986,7 @@ func (h *AdminHandler) ListAllTranscriptionLogs(c echo.Context) error {
 		Offset: int32(offset),
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	responses := make([]AdminTranscriptionLogResponse, len(logs))
@@ -446,6 +1008,25 @@ func (h *AdminHandler) ListAllTranscriptionLogs(c echo.Context) error {
 	})
 }
 
+// GetTranscriptionLog returns any user's session with its Deepgram params
+// and the redactions it ran with (admin only)
+func (h *AdminHandler) GetTranscriptionLog(c echo.Context) error {
+	logID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid log ID")
+	}
+
+	txLog, err := h.queries.GetTranscriptionLog(context.Background(), logID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "session not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	return c.JSON(http.StatusOK, toTranscriptionLogResponse(txLog))
+}
+
 // ListAllAPIKeys returns all API keys with user info (admin only)
 func (h *AdminHandler) ListAllAPIKeys(c echo.Context) error {
 	page, _ := strconv.Atoi(c.QueryParam("page"))
@@ -463,7 +1044,7 @@ func (h *AdminHandler) ListAllAPIKeys(c echo.Context) error {
 
 	total, err := h.queries.CountAllAPIKeys(ctx)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	keys, err := h.queries.ListAllAPIKeys(ctx, sqlc.ListAllAPIKeysParams{
@@ -471,7 +1052,7 @@ func (h *AdminHandler) ListAllAPIKeys(c echo.Context) error {
 		Offset: int32(offset),
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	responses := make([]AdminAPIKeyResponse, len(keys))
@@ -493,31 +1074,197 @@ func (h *AdminHandler) ListAllAPIKeys(c echo.Context) error {
 	})
 }
 
-// GetSystemUsageSummary returns system-wide usage statistics (admin only)
-func (h *AdminHandler) GetSystemUsageSummary(c echo.Context) error {
-	now := time.Now()
-	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
-	endOfMonth := startOfMonth.AddDate(0, 1, 0)
+// AdminRevokeAPIKey revokes any user's API key and ends its live sessions (admin only)
+func (h *AdminHandler) AdminRevokeAPIKey(c echo.Context) error {
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid key ID")
+	}
+
+	var req APIKeyRevocationRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return apiError(http.StatusBadRequest, "reason is required")
+	}
+
+	ctx := context.Background()
+
+	// Check if key exists
+	key, err := h.queries.GetAPIKeyByID(ctx, keyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "API key not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if key.RevokedAt.Valid {
+		return apiError(http.StatusBadRequest, "API key is already revoked")
+	}
+
+	if err := h.queries.AdminRevokeAPIKey(ctx, keyID); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to revoke key")
+	}
+
+	terminated := Sessions.CloseTagged(apiKeySessionTag(keyID), CloseAdminTerminated)
+	log.Printf("[Admin] Revoked API key %s (%s), terminated %d live sessions", keyID, logging.StoredKeyID(key.KeyPrefix, key.KeyHash), terminated)
+
+	recordAuditEvent(ctx, h.queries, c, auditAPIKeyRevoke, "api_key", keyID.String(), reason, map[string]string{
+		"user_id":             key.UserID.String(),
+		"key_prefix":          key.KeyPrefix,
+		"sessions_terminated": strconv.Itoa(terminated),
+	})
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":             "API key revoked",
+		"sessions_terminated": terminated,
+	})
+}
+
+// AdminUnrevokeAPIKey restores a revoked API key (admin only)
+func (h *AdminHandler) AdminUnrevokeAPIKey(c echo.Context) error {
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid key ID")
+	}
+
+	// The reason is optional when restoring a key
+	var req APIKeyRevocationRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	ctx := context.Background()
+
+	// Check if key exists
+	key, err := h.queries.GetAPIKeyByID(ctx, keyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "API key not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if !key.RevokedAt.Valid {
+		return apiError(http.StatusBadRequest, "API key is not revoked")
+	}
+
+	if err := h.queries.UnrevokeAPIKey(ctx, keyID); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to unrevoke key")
+	}
+
+	recordAuditEvent(ctx, h.queries, c, auditAPIKeyUnrevoke, "api_key", keyID.String(), strings.TrimSpace(req.Reason), map[string]string{
+		"user_id":    key.UserID.String(),
+		"key_prefix": key.KeyPrefix,
+		"revoked_at": key.RevokedAt.Time.Format(time.RFC3339),
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "API key unrevoked"})
+}
 
-	if startParam := c.QueryParam("start"); startParam != "" {
-		if t, err := time.Parse(time.RFC3339, startParam); err == nil {
-			startOfMonth = t
+// TransferAPIKey moves an API key to another user, optionally moving its
+// usage history with it, or into an organization. Moving an organization's
+// key to a user takes it out of the organization (admin only).
+func (h *AdminHandler) TransferAPIKey(c echo.Context) error {
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid key ID")
+	}
+
+	var req APIKeyTransferRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.OrganizationID != "" {
+		return h.transferAPIKeyToOrganization(c, keyID, req)
+	}
+	newOwnerID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	ctx := context.Background()
+
+	key, err := h.queries.GetAPIKeyByID(ctx, keyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "API key not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if key.UserID == newOwnerID && !key.OrganizationID.Valid {
+		return apiError(http.StatusBadRequest, "API key already belongs to this user")
+	}
+	if _, err := h.queries.GetUserByID(ctx, newOwnerID); err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "user not found")
 		}
+		return apiError(http.StatusInternalServerError, "database error")
 	}
-	if endParam := c.QueryParam("end"); endParam != "" {
-		if t, err := time.Parse(time.RFC3339, endParam); err == nil {
-			endOfMonth = t
+
+	// Move the key and its history together so usage is never split
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	defer tx.Rollback()
+	queries := h.queries.WithTx(tx)
+
+	transferred, err := queries.TransferAPIKey(ctx, sqlc.TransferAPIKeyParams{
+		ID:     keyID,
+		UserID: newOwnerID,
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to transfer key")
+	}
+
+	var logsMoved int64
+	if req.IncludeHistory {
+		logsMoved, err = queries.TransferAPIKeyTranscriptionLogs(ctx, sqlc.TransferAPIKeyTranscriptionLogsParams{
+			ApiKeyID: keyID,
+			UserID:   newOwnerID,
+		})
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "failed to transfer usage history")
 		}
 	}
 
+	if err := tx.Commit(); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to transfer key")
+	}
+	log.Printf("[Admin] Transferred API key %s (%s) from %s to %s, %d logs moved", keyID, logging.StoredKeyID(key.KeyPrefix, key.KeyHash), key.UserID, newOwnerID, logsMoved)
+
+	recordAuditEvent(ctx, h.queries, c, auditAPIKeyTransfer, "api_key", keyID.String(), strings.TrimSpace(req.Reason), map[string]string{
+		"from_user_id":    key.UserID.String(),
+		"to_user_id":      newOwnerID.String(),
+		"key_prefix":      key.KeyPrefix,
+		"include_history": strconv.FormatBool(req.IncludeHistory),
+		"logs_moved":      strconv.FormatInt(logsMoved, 10),
+	})
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":    "API key transferred",
+		"user_id":    transferred.UserID.String(),
+		"logs_moved": logsMoved,
+	})
+}
+
+// GetSystemUsageSummary returns system-wide usage statistics (admin only)
+func (h *AdminHandler) GetSystemUsageSummary(c echo.Context) error {
+	period, err := resolveUsagePeriod(c, "UTC", time.Time{})
+	if err != nil {
+		return apiError(http.StatusBadRequest, err.Error())
+	}
+
 	ctx := context.Background()
 
 	summary, err := h.queries.GetSystemUsageSummary(ctx, sqlc.GetSystemUsageSummaryParams{
-		StartDate: startOfMonth,
-		EndDate:   endOfMonth,
+		StartDate: period.Start,
+		EndDate:   period.End,
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	// Convert decimal string to float64
@@ -529,22 +1276,26 @@ func (h *AdminHandler) GetSystemUsageSummary(c echo.Context) error {
 		TotalSessions:        summary.TotalSessions,
 		TotalDurationSeconds: durationFloat,
 		TotalBytesSent:       bytesSent,
-		PeriodStart:          startOfMonth.Format(time.RFC3339),
-		PeriodEnd:            endOfMonth.Format(time.RFC3339),
+		PeriodStart:          period.Start.Format(time.RFC3339),
+		PeriodEnd:            period.End.Format(time.RFC3339),
+		Period:               period.Kind,
+		Timezone:             period.Timezone,
 	})
 }
 
 // Helper function for admin transcription logs
 func toAdminTranscriptionLogResponse(log sqlc.ListAllTranscriptionLogsRow) AdminTranscriptionLogResponse {
 	resp := AdminTranscriptionLogResponse{
-		ID:         log.ID.String(),
-		UserID:     log.UserID.String(),
-		Username:   log.Username,
-		Email:      log.Email,
-		APIKeyName: log.ApiKeyName,
-		StartedAt:  log.StartedAt.Format(time.RFC3339),
-		Status:     log.Status,
-		BytesSent:  log.BytesSent,
+		ID:                log.ID.String(),
+		UserID:            log.UserID.String(),
+		Username:          log.Username,
+		Email:             log.Email,
+		APIKeyName:        log.ApiKeyName,
+		StartedAt:         log.StartedAt.Format(time.RFC3339),
+		DurationEstimated: log.DurationEstimated,
+		Status:            log.Status,
+		BytesSent:         log.BytesSent,
+		Redaction:         parseRedactionAudit(log.RedactionAudit),
 	}
 
 	if log.EndedAt.Valid {
@@ -561,6 +1312,10 @@ func toAdminTranscriptionLogResponse(log sqlc.ListAllTranscriptionLogsRow) Admin
 		resp.ErrorMessage = &log.ErrorMessage.String
 	}
 
+	if log.ErrorCode.Valid {
+		resp.ErrorCode = &log.ErrorCode.String
+	}
+
 	return resp
 }
 
@@ -574,12 +1329,25 @@ func toAdminAPIKeyResponse(key sqlc.ListAllAPIKeysRow) AdminAPIKeyResponse {
 		Name:      key.Name,
 		KeyPrefix: key.KeyPrefix,
 		CreatedAt: key.CreatedAt.Time.Format(time.RFC3339),
+		UseCount:  key.UseCount,
+
+		ParamRestrictions: parseParamRestrictions(key.ParamRestrictions),
 	}
 
 	if key.LastUsedAt.Valid {
 		t := key.LastUsedAt.Time.Format(time.RFC3339)
 		resp.LastUsed = &t
 	}
+	if key.LastUsedIp.Valid {
+		resp.LastUsedIP = &key.LastUsedIp.String
+	}
+	if key.LastUsedUserAgent.Valid {
+		resp.LastUsedUserAgent = &key.LastUsedUserAgent.String
+	}
+	if key.OrganizationID.Valid {
+		id := key.OrganizationID.UUID.String()
+		resp.OrganizationID = &id
+	}
 
 	if key.RevokedAt.Valid {
 		t := key.RevokedAt.Time.Format(time.RFC3339)
@@ -590,7 +1358,7 @@ func toAdminAPIKeyResponse(key sqlc.ListAllAPIKeysRow) AdminAPIKeyResponse {
 }
 
 // Helper functions
-func toTokenResponse(token sqlc.Token) TokenResponse {
+func toTokenResponse(token sqlc.ListRefreshTokensRow) TokenResponse {
 	issuedAt := ""
 	if token.IssuedAt.Valid {
 		issuedAt = token.IssuedAt.Time.Format(time.RFC3339)
@@ -610,7 +1378,10 @@ func toTokenResponse(token sqlc.Token) TokenResponse {
 	return TokenResponse{
 		ID:            token.ID.String(),
 		TokenJTI:      token.TokenJti,
+		FamilyID:      token.FamilyID.String(),
 		UserID:        token.UserID.String(),
+		Username:      token.Username,
+		Email:         token.Email,
 		IssuedAt:      issuedAt,
 		ExpiresAt:     token.ExpiresAt.Format(time.RFC3339),
 		RevokedAt:     revokedAt,
@@ -659,6 +1430,24 @@ type TrialAPIKeyResponse struct {
 	RevokedAt            *string `json:"revoked_at"`
 	TotalSessions        int64   `json:"total_sessions"`
 	TotalDurationSeconds float64 `json:"total_duration_seconds"`
+	Preset               string  `json:"preset"`
+	Source               *string `json:"source"`
+	Regenerations        int32   `json:"regenerations"`
+}
+
+// TrialUsageLogResponse is one session in a trial key's history
+type TrialUsageLogResponse struct {
+	ID                string          `json:"id"`
+	StartedAt         string          `json:"started_at"`
+	EndedAt           *string         `json:"ended_at"`
+	DurationSeconds   *float64        `json:"duration_seconds"`
+	DurationEstimated bool            `json:"duration_estimated"`
+	Status            string          `json:"status"`
+	ErrorMessage      *string         `json:"error_message,omitempty"`
+	DeepgramParams    json.RawMessage `json:"deepgram_params"`
+	BytesSent         int64           `json:"bytes_sent"`
+	ClientIP          *string         `json:"client_ip"`
+	Redaction         *RedactionAudit `json:"redaction"`
 }
 
 // TrialUsageSummaryResponse is the response for trial usage summary
@@ -668,17 +1457,25 @@ type TrialUsageSummaryResponse struct {
 	TotalSessions        int64   `json:"total_sessions"`
 	TotalDurationSeconds float64 `json:"total_duration_seconds"`
 	TotalBytesSent       int64   `json:"total_bytes_sent"`
+	Period               string  `json:"period"`
 	PeriodStart          string  `json:"period_start"`
 	PeriodEnd            string  `json:"period_end"`
+	Timezone             string  `json:"timezone"`
+
+	BySource []TrialSourceUsageResponse `json:"by_source"`
 }
 
 // TrialLimitsResponse is the response for trial limits
 type TrialLimitsResponse struct {
+	Name                      string `json:"name"`
+	Description               string `json:"description"`
 	MaxDurationSeconds        int    `json:"max_duration_seconds"`
 	MaxSessions               int    `json:"max_sessions"`
 	MaxSessionDurationSeconds int    `json:"max_session_duration_seconds"`
 	ExpiryDays                int    `json:"expiry_days"`
 	UpdatedAt                 string `json:"updated_at"`
+
+	ParamRestrictions ParamRestrictions `json:"param_restrictions"`
 }
 
 // UpdateTrialLimitsRequest is the request for updating trial limits
@@ -706,7 +1503,7 @@ func (h *AdminHandler) ListTrialAPIKeys(c echo.Context) error {
 
 	total, err := h.queries.CountTrialAPIKeys(ctx)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	keys, err := h.queries.ListAllTrialAPIKeys(ctx, sqlc.ListAllTrialAPIKeysParams{
@@ -714,7 +1511,7 @@ func (h *AdminHandler) ListTrialAPIKeys(c echo.Context) error {
 		Offset: int32(offset),
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	responses := make([]TrialAPIKeyResponse, len(keys))
@@ -738,29 +1535,27 @@ func (h *AdminHandler) ListTrialAPIKeys(c echo.Context) error {
 
 // GetTrialUsageSummary returns system-wide trial usage statistics (admin only)
 func (h *AdminHandler) GetTrialUsageSummary(c echo.Context) error {
-	now := time.Now()
-	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
-	endOfMonth := startOfMonth.AddDate(0, 1, 0)
-
-	if startParam := c.QueryParam("start"); startParam != "" {
-		if t, err := time.Parse(time.RFC3339, startParam); err == nil {
-			startOfMonth = t
-		}
-	}
-	if endParam := c.QueryParam("end"); endParam != "" {
-		if t, err := time.Parse(time.RFC3339, endParam); err == nil {
-			endOfMonth = t
-		}
+	period, err := resolveUsagePeriod(c, "UTC", time.Time{})
+	if err != nil {
+		return apiError(http.StatusBadRequest, err.Error())
 	}
 
 	ctx := context.Background()
 
 	summary, err := h.queries.GetAllTrialUsageSummary(ctx, sqlc.GetAllTrialUsageSummaryParams{
-		StartDate: startOfMonth,
-		EndDate:   endOfMonth,
+		StartDate: period.Start,
+		EndDate:   period.End,
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	sources, err := h.queries.GetTrialSourceUsageSummary(ctx, sqlc.GetTrialSourceUsageSummaryParams{
+		StartDate: period.Start,
+		EndDate:   period.End,
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	durationFloat := parseDecimalStringAdmin(summary.TotalDurationSeconds)
@@ -772,69 +1567,67 @@ func (h *AdminHandler) GetTrialUsageSummary(c echo.Context) error {
 		TotalSessions:        summary.TotalSessions,
 		TotalDurationSeconds: durationFloat,
 		TotalBytesSent:       bytesSent,
-		PeriodStart:          startOfMonth.Format(time.RFC3339),
-		PeriodEnd:            endOfMonth.Format(time.RFC3339),
+		PeriodStart:          period.Start.Format(time.RFC3339),
+		PeriodEnd:            period.End.Format(time.RFC3339),
+		Period:               period.Kind,
+		Timezone:             period.Timezone,
+		BySource:             toTrialSourceUsageResponses(sources),
 	})
 }
 
-// GetTrialLimits returns the current trial limits (admin only)
+// GetTrialLimits returns the limits of the default trial preset (admin only)
 func (h *AdminHandler) GetTrialLimits(c echo.Context) error {
 	ctx := context.Background()
 
-	limits, err := h.queries.GetTrialLimits(ctx)
+	limits, err := h.queries.GetTrialPreset(ctx, defaultTrialPreset)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
-	return c.JSON(http.StatusOK, TrialLimitsResponse{
-		MaxDurationSeconds:        int(limits.MaxDurationSeconds),
-		MaxSessions:               int(limits.MaxSessions),
-		MaxSessionDurationSeconds: int(limits.MaxSessionDurationSeconds),
-		ExpiryDays:                int(limits.ExpiryDays),
-		UpdatedAt:                 limits.UpdatedAt.Time.Format(time.RFC3339),
-	})
+	return c.JSON(http.StatusOK, toTrialLimitsResponse(limits))
 }
 
-// UpdateTrialLimits updates the trial limits (admin only)
+// UpdateTrialLimits updates the limits of the default trial preset (admin only)
 func (h *AdminHandler) UpdateTrialLimits(c echo.Context) error {
 	var req UpdateTrialLimitsRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+		return apiError(http.StatusBadRequest, "invalid request body")
 	}
 
 	// Validate limits
 	if req.MaxDurationSeconds <= 0 {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "max_duration_seconds must be positive"})
+		return apiError(http.StatusBadRequest, "max_duration_seconds must be positive")
 	}
 	if req.MaxSessions <= 0 {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "max_sessions must be positive"})
+		return apiError(http.StatusBadRequest, "max_sessions must be positive")
 	}
 	if req.MaxSessionDurationSeconds <= 0 {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "max_session_duration_seconds must be positive"})
+		return apiError(http.StatusBadRequest, "max_session_duration_seconds must be positive")
 	}
 	if req.ExpiryDays <= 0 {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "expiry_days must be positive"})
+		return apiError(http.StatusBadRequest, "expiry_days must be positive")
 	}
 
 	ctx := context.Background()
 
-	limits, err := h.queries.UpdateTrialLimits(ctx, sqlc.UpdateTrialLimitsParams{
+	current, err := h.queries.GetTrialPreset(ctx, defaultTrialPreset)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	limits, err := h.queries.UpdateTrialPreset(ctx, sqlc.UpdateTrialPresetParams{
+		Name:                      current.Name,
+		Description:               current.Description,
 		MaxDurationSeconds:        int32(req.MaxDurationSeconds),
 		MaxSessions:               int32(req.MaxSessions),
 		MaxSessionDurationSeconds: int32(req.MaxSessionDurationSeconds),
 		ExpiryDays:                int32(req.ExpiryDays),
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to update limits"})
+		return apiError(http.StatusInternalServerError, "failed to update limits")
 	}
 
-	return c.JSON(http.StatusOK, TrialLimitsResponse{
-		MaxDurationSeconds:        int(limits.MaxDurationSeconds),
-		MaxSessions:               int(limits.MaxSessions),
-		MaxSessionDurationSeconds: int(limits.MaxSessionDurationSeconds),
-		ExpiryDays:                int(limits.ExpiryDays),
-		UpdatedAt:                 limits.UpdatedAt.Time.Format(time.RFC3339),
-	})
+	return c.JSON(http.StatusOK, toTrialLimitsResponse(limits))
 }
 
 // RevokeTrialKey revokes a trial API key (admin only)
@@ -842,7 +1635,7 @@ func (h *AdminHandler) RevokeTrialKey(c echo.Context) error {
 	keyIDStr := c.Param("id")
 	keyID, err := uuid.Parse(keyIDStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid key ID"})
+		return apiError(http.StatusBadRequest, "invalid key ID")
 	}
 
 	ctx := context.Background()
@@ -851,25 +1644,41 @@ func (h *AdminHandler) RevokeTrialKey(c echo.Context) error {
 	_, err = h.queries.GetTrialAPIKeyByID(ctx, keyID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return c.JSON(http.StatusNotFound, ErrorResponse{Error: "trial key not found"})
+			return apiError(http.StatusNotFound, "trial key not found")
 		}
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	// Revoke the key
 	if err := h.queries.RevokeTrialAPIKey(ctx, keyID); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to revoke key"})
+		return apiError(http.StatusInternalServerError, "failed to revoke key")
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "trial key revoked"})
 }
 
-// CleanupExpiredTrialKeys revokes all expired trial keys (admin only)
+// CleanupExpiredTrialKeys revokes all expired trial keys. Supports dry_run
+// and requires a confirmation token, like DeleteUser (admin only).
 func (h *AdminHandler) CleanupExpiredTrialKeys(c echo.Context) error {
 	ctx := context.Background()
 
+	if isDryRun(c) {
+		keyIDs, err := h.queries.ListExpiredUnrevokedTrialKeyIDs(ctx)
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+		ids := make([]string, len(keyIDs))
+		for i, id := range keyIDs {
+			ids[i] = id.String()
+		}
+		return dryRun(c, actionTrialKeysCleanup, "", map[string]int64{"trial_keys": int64(len(ids))}, ids)
+	}
+	if errResp := requireConfirmation(c, actionTrialKeysCleanup, ""); errResp != nil {
+		return newAPIError(http.StatusPreconditionRequired, *errResp)
+	}
+
 	if err := h.queries.CleanupExpiredTrialKeys(ctx); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to cleanup expired keys"})
+		return apiError(http.StatusInternalServerError, "failed to cleanup expired keys")
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "expired trial keys cleaned up"})
@@ -880,7 +1689,7 @@ func (h *AdminHandler) UnrevokeTrialKey(c echo.Context) error {
 	keyIDStr := c.Param("id")
 	keyID, err := uuid.Parse(keyIDStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid key ID"})
+		return apiError(http.StatusBadRequest, "invalid key ID")
 	}
 
 	ctx := context.Background()
@@ -889,30 +1698,31 @@ func (h *AdminHandler) UnrevokeTrialKey(c echo.Context) error {
 	key, err := h.queries.GetTrialAPIKeyByID(ctx, keyID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return c.JSON(http.StatusNotFound, ErrorResponse{Error: "trial key not found"})
+			return apiError(http.StatusNotFound, "trial key not found")
 		}
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	// Check if key is actually revoked
 	if !key.RevokedAt.Valid {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "trial key is not revoked"})
+		return apiError(http.StatusBadRequest, "trial key is not revoked")
 	}
 
 	// Unrevoke the key
 	if err := h.queries.UnrevokeTrialAPIKey(ctx, keyID); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to unrevoke key"})
+		return apiError(http.StatusInternalServerError, "failed to unrevoke key")
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "trial key unrevoked"})
 }
 
-// DeleteTrialKey permanently deletes a trial API key (admin only)
+// DeleteTrialKey permanently deletes a trial API key. Supports dry_run and
+// requires a confirmation token, like DeleteUser (admin only).
 func (h *AdminHandler) DeleteTrialKey(c echo.Context) error {
 	keyIDStr := c.Param("id")
 	keyID, err := uuid.Parse(keyIDStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid key ID"})
+		return apiError(http.StatusBadRequest, "invalid key ID")
 	}
 
 	ctx := context.Background()
@@ -921,29 +1731,153 @@ func (h *AdminHandler) DeleteTrialKey(c echo.Context) error {
 	_, err = h.queries.GetTrialAPIKeyByID(ctx, keyID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return c.JSON(http.StatusNotFound, ErrorResponse{Error: "trial key not found"})
+			return apiError(http.StatusNotFound, "trial key not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	if isDryRun(c) {
+		summary, err := h.queries.GetTrialUsageSummary(ctx, keyID)
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "database error")
 		}
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return dryRun(c, actionTrialKeyDelete, keyID.String(), map[string]int64{
+			"trial_keys":       1,
+			"trial_usage_logs": summary.TotalSessions,
+		}, []string{keyID.String()})
+	}
+	if errResp := requireConfirmation(c, actionTrialKeyDelete, keyID.String()); errResp != nil {
+		return newAPIError(http.StatusPreconditionRequired, *errResp)
 	}
 
 	// Delete the key (cascade will delete usage logs)
 	if err := h.queries.DeleteTrialAPIKey(ctx, keyID); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to delete key"})
+		return apiError(http.StatusInternalServerError, "failed to delete key")
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "trial key deleted"})
 }
 
+// ListTrialKeyLogs returns the paginated session history of one trial key,
+// newest first (admin only)
+func (h *AdminHandler) ListTrialKeyLogs(c echo.Context) error {
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid key ID")
+	}
+
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	perPage, _ := strconv.Atoi(c.QueryParam("per_page"))
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	offset := (page - 1) * perPage
+	ctx := context.Background()
+
+	if _, err := h.queries.GetTrialAPIKeyByID(ctx, keyID); err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "trial key not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	total, err := h.queries.CountTrialUsageLogs(ctx, keyID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	logs, err := h.queries.ListTrialUsageLogs(ctx, sqlc.ListTrialUsageLogsParams{
+		TrialKeyID: keyID,
+		Limit:      int32(perPage),
+		Offset:     int32(offset),
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	responses := make([]TrialUsageLogResponse, len(logs))
+	for i, log := range logs {
+		responses[i] = toTrialUsageLogResponse(log)
+	}
+
+	totalPages := int(total) / perPage
+	if int(total)%perPage > 0 {
+		totalPages++
+	}
+
+	return c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       responses,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: totalPages,
+	})
+}
+
+// Helper function for trial usage log response
+func toTrialUsageLogResponse(log sqlc.TrialUsage) TrialUsageLogResponse {
+	resp := TrialUsageLogResponse{
+		ID:                log.ID.String(),
+		StartedAt:         log.StartedAt.Format(time.RFC3339),
+		DurationEstimated: log.DurationEstimated,
+		Status:            log.Status,
+		DeepgramParams:    log.DeepgramParams,
+		BytesSent:         log.BytesSent,
+		Redaction:         parseRedactionAudit(log.RedactionAudit),
+	}
+
+	if log.EndedAt.Valid {
+		t := log.EndedAt.Time.Format(time.RFC3339)
+		resp.EndedAt = &t
+	}
+
+	if log.DurationSeconds.Valid {
+		d := parseDecimalStringAdmin(log.DurationSeconds.String)
+		resp.DurationSeconds = &d
+	}
+
+	if log.ErrorMessage.Valid {
+		resp.ErrorMessage = &log.ErrorMessage.String
+	}
+
+	if log.ClientIp.Valid {
+		resp.ClientIP = &log.ClientIp.String
+	}
+
+	return resp
+}
+
+// Helper function for trial limits response
+func toTrialLimitsResponse(limits sqlc.TrialPreset) TrialLimitsResponse {
+	return TrialLimitsResponse{
+		Name:                      limits.Name,
+		Description:               limits.Description,
+		MaxDurationSeconds:        int(limits.MaxDurationSeconds),
+		MaxSessions:               int(limits.MaxSessions),
+		MaxSessionDurationSeconds: int(limits.MaxSessionDurationSeconds),
+		ExpiryDays:                int(limits.ExpiryDays),
+		UpdatedAt:                 limits.UpdatedAt.Format(time.RFC3339),
+		ParamRestrictions:         parseParamRestrictions(limits.ParamRestrictions),
+	}
+}
+
 // Helper function for trial API key response
 func toTrialAPIKeyResponse(key sqlc.ListAllTrialAPIKeysRow) TrialAPIKeyResponse {
 	resp := TrialAPIKeyResponse{
 		ID:                   key.ID.String(),
 		KeyPrefix:            key.KeyPrefix,
-		DeviceFingerprint:    key.DeviceFingerprint,
+		DeviceFingerprint:    string(key.DeviceFingerprint),
 		CreatedAt:            key.CreatedAt.Time.Format(time.RFC3339),
 		ExpiresAt:            key.ExpiresAt.Format(time.RFC3339),
 		TotalSessions:        key.TotalSessions,
 		TotalDurationSeconds: parseDecimalStringAdmin(key.TotalDurationSeconds),
+		Preset:               key.Preset,
+		Regenerations:        key.Regenerations,
 	}
 
 	if key.LastUsedAt.Valid {
@@ -956,5 +1890,9 @@ func toTrialAPIKeyResponse(key sqlc.ListAllTrialAPIKeysRow) TrialAPIKeyResponse
 		resp.RevokedAt = &t
 	}
 
+	if key.Source.Valid {
+		resp.Source = &key.Source.String
+	}
+
 	return resp
 }
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/encryption"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// getTrialKeyLogs requests GET /admin/trial/keys/:id/logs from a handler on db
+func getTrialKeyLogs(t *testing.T, db *sql.DB, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.GET("/admin/trial/keys/:id/logs", NewAdminHandler(db).ListTrialKeyLogs)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestListTrialKeyLogs(t *testing.T) {
+	fake, db := newFakeDB(t)
+	keyID := uuid.New()
+	started := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	fake.returns("GetTrialAPIKeyByID", sqlc.TrialApiKey{ID: keyID, KeyPrefix: "hw_trial_abc"})
+	fake.returns("CountTrialUsageLogs", int64(45))
+	fake.returns("ListTrialUsageLogs",
+		sqlc.TrialUsage{
+			ID:              uuid.New(),
+			TrialKeyID:      keyID,
+			StartedAt:       started,
+			EndedAt:         sql.NullTime{Time: started.Add(90 * time.Second), Valid: true},
+			DurationSeconds: sql.NullString{String: "90.5", Valid: true},
+			Status:          "completed",
+			DeepgramParams:  json.RawMessage(`{"model":"nova-3"}`),
+			BytesSent:       1440000,
+			ClientIp:        encryption.NullString{String: "203.0.113.7", Valid: true},
+		},
+		sqlc.TrialUsage{
+			ID:           uuid.New(),
+			TrialKeyID:   keyID,
+			StartedAt:    started.Add(time.Hour),
+			Status:       "error",
+			ErrorMessage: sql.NullString{String: "upstream closed", Valid: true},
+		},
+	)
+
+	rec := getTrialKeyLogs(t, db, "/admin/trial/keys/"+keyID.String()+"/logs?page=3&per_page=10")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	var resp struct {
+		Data       []TrialUsageLogResponse `json:"data"`
+		Total      int64                   `json:"total"`
+		Page       int                     `json:"page"`
+		PerPage    int                     `json:"per_page"`
+		TotalPages int                     `json:"total_pages"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 45 || resp.Page != 3 || resp.PerPage != 10 || resp.TotalPages != 5 {
+		t.Errorf("pagination = %d total, page %d, %d per page, %d pages; want 45, 3, 10, 5",
+			resp.Total, resp.Page, resp.PerPage, resp.TotalPages)
+	}
+	if len(resp.Data) != 2 {
+		t.Fatalf("got %d logs, want 2", len(resp.Data))
+	}
+
+	first := resp.Data[0]
+	if first.Status != "completed" || first.BytesSent != 1440000 || string(first.DeepgramParams) != `{"model":"nova-3"}` {
+		t.Errorf("first log = %+v", first)
+	}
+	if first.ClientIP == nil || *first.ClientIP != "203.0.113.7" {
+		t.Errorf("client_ip = %v, want 203.0.113.7", first.ClientIP)
+	}
+	if first.DurationSeconds == nil || *first.DurationSeconds != 90.5 {
+		t.Errorf("duration_seconds = %v, want 90.5", first.DurationSeconds)
+	}
+	if first.EndedAt == nil || *first.EndedAt != "2026-03-01T12:01:30Z" {
+		t.Errorf("ended_at = %v, want 2026-03-01T12:01:30Z", first.EndedAt)
+	}
+
+	second := resp.Data[1]
+	if second.ErrorMessage == nil || *second.ErrorMessage != "upstream closed" {
+		t.Errorf("error_message = %v, want upstream closed", second.ErrorMessage)
+	}
+	if second.EndedAt != nil || second.DurationSeconds != nil || second.ClientIP != nil {
+		t.Errorf("second log = %+v, want no end, duration or IP", second)
+	}
+
+	calls := fake.called("ListTrialUsageLogs")
+	if len(calls) != 1 {
+		t.Fatalf("ListTrialUsageLogs ran %d times, want 1", len(calls))
+	}
+	if args := calls[0].args; args[0] != keyID.String() || args[1] != int64(10) || args[2] != int64(20) {
+		t.Errorf("ListTrialUsageLogs args = %v, want the key, limit 10 and offset 20", args)
+	}
+}
+
+func TestListTrialKeyLogsPaging(t *testing.T) {
+	tests := []struct {
+		query      string
+		wantPage   int
+		wantLimit  int64
+		wantOffset int64
+	}{
+		{"", 1, 20, 0},
+		{"?page=0&per_page=0", 1, 20, 0},
+		{"?page=-2&per_page=500", 1, 20, 0},
+		{"?page=2&per_page=100", 2, 100, 100},
+		{"?page=abc&per_page=xyz", 1, 20, 0},
+	}
+
+	for _, tt := range tests {
+		fake, db := newFakeDB(t)
+		keyID := uuid.New()
+		fake.returns("GetTrialAPIKeyByID", sqlc.TrialApiKey{ID: keyID})
+		fake.returns("CountTrialUsageLogs", int64(0))
+
+		rec := getTrialKeyLogs(t, db, "/admin/trial/keys/"+keyID.String()+"/logs"+tt.query)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%q: status = %d, want 200", tt.query, rec.Code)
+		}
+		var resp PaginatedResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Page != tt.wantPage || resp.PerPage != int(tt.wantLimit) || resp.TotalPages != 0 {
+			t.Errorf("%q: page %d, %d per page, %d pages; want %d, %d, 0", tt.query, resp.Page, resp.PerPage, resp.TotalPages, tt.wantPage, tt.wantLimit)
+		}
+		if data, ok := resp.Data.([]any); !ok || len(data) != 0 {
+			t.Errorf("%q: data = %#v, want an empty list", tt.query, resp.Data)
+		}
+
+		calls := fake.called("ListTrialUsageLogs")
+		if len(calls) != 1 || calls[0].args[1] != tt.wantLimit || calls[0].args[2] != tt.wantOffset {
+			t.Errorf("%q: ListTrialUsageLogs calls = %v, want limit %d and offset %d", tt.query, calls, tt.wantLimit, tt.wantOffset)
+		}
+	}
+}
+
+func TestListTrialKeyLogsErrors(t *testing.T) {
+	keyID := uuid.New()
+	tests := []struct {
+		name       string
+		id         string
+		script     func(*fakeDB)
+		wantStatus int
+		wantError  string
+	}{
+		{
+			name:       "invalid ID",
+			id:         "not-a-uuid",
+			script:     func(*fakeDB) {},
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid key ID",
+		},
+		{
+			name:       "unknown key",
+			id:         keyID.String(),
+			script:     func(*fakeDB) {},
+			wantStatus: http.StatusNotFound,
+			wantError:  "trial key not found",
+		},
+		{
+			name: "key lookup fails",
+			id:   keyID.String(),
+			script: func(f *fakeDB) {
+				f.fails("GetTrialAPIKeyByID", errors.New("connection reset"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "database error",
+		},
+		{
+			name: "count fails",
+			id:   keyID.String(),
+			script: func(f *fakeDB) {
+				f.returns("GetTrialAPIKeyByID", sqlc.TrialApiKey{ID: keyID})
+				f.fails("CountTrialUsageLogs", errors.New("connection reset"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "database error",
+		},
+		{
+			name: "listing fails",
+			id:   keyID.String(),
+			script: func(f *fakeDB) {
+				f.returns("GetTrialAPIKeyByID", sqlc.TrialApiKey{ID: keyID})
+				f.fails("ListTrialUsageLogs", errors.New("connection reset"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "database error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, db := newFakeDB(t)
+			tt.script(fake)
+
+			rec := getTrialKeyLogs(t, db, "/admin/trial/keys/"+tt.id+"/logs")
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var resp ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Error != tt.wantError {
+				t.Errorf("error = %q, want %q", resp.Error, tt.wantError)
+			}
+		})
+	}
+}
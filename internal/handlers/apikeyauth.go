@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/encryption"
+	"hyperwhisper/internal/logging"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== API KEY AUTHENTICATION ==========
+
+// APIKeyMiddleware authenticates requests to routes with auth.AcceptsAPIKeys
+// access that carry an API key (X-API-Key or a bearer hw_live_/hw_test_
+// key) instead of an access token. The key's user is stored as the
+// request's claims, so handlers read it with auth.GetUserFromContext like
+// for a signed-in user. Requests without a key were already authenticated
+// by the policy middleware and pass through.
+func APIKeyMiddleware(db *sql.DB) echo.MiddlewareFunc {
+	queries := sqlc.New(db)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			apiKey := auth.RequestAPIKey(c)
+			if apiKey == "" {
+				return next(c)
+			}
+			if err := checkKeyFormat(apiKey); err != nil {
+				return err
+			}
+			// Trial keys only stream
+			if IsTrialKey(apiKey) {
+				return apiError(http.StatusUnauthorized, "API keys are not accepted by this endpoint")
+			}
+
+			ctx := context.Background()
+			key, err := queries.GetAPIKeyByHash(ctx, hashAPIKey(apiKey))
+			if err != nil {
+				if err == sql.ErrNoRows {
+					log.Printf("[APIKey] Invalid API key %s", logging.KeyID(apiKey))
+					return apiError(http.StatusUnauthorized, "invalid API key")
+				}
+				log.Printf("[APIKey] Database error: %v", err)
+				return apiError(http.StatusInternalServerError, "database error")
+			}
+
+			// Locked keys are refused everywhere until re-enabled
+			lockdown, err := queries.GetLatestAPIKeyLockdown(ctx, key.ID)
+			switch {
+			case err == nil && !lockdown.LiftedAt.Valid:
+				return keyLockedError(lockdown)
+			case err != nil && err != sql.ErrNoRows:
+				log.Printf("[APIKey] Failed to get lockdown of API key %s: %v", key.ID, err)
+				return apiError(http.StatusInternalServerError, "database error")
+			}
+
+			user, err := queries.GetUserByID(ctx, key.UserID)
+			if err != nil {
+				log.Printf("[APIKey] Failed to get user %s: %v", key.UserID, err)
+				return apiError(http.StatusInternalServerError, "database error")
+			}
+			// Organization keys of suspended members still stream, but the
+			// request would act as the suspended user here
+			if user.SuspendedAt.Valid {
+				log.Printf("[APIKey] API key %s of suspended user %s refused", logging.KeyID(apiKey), user.ID)
+				return apiError(http.StatusUnauthorized, "invalid API key")
+			}
+
+			c.Set(auth.UserContextKey, &auth.Claims{
+				UserID:    user.ID,
+				Username:  user.Username,
+				Email:     user.Email,
+				UserType:  user.UserType,
+				TokenType: auth.AccessToken,
+				TenantID:  user.TenantID.UUID,
+			})
+			c.Set(accessLogUserKey, user.ID)
+
+			lastUsed := sqlc.UpdateAPIKeyLastUsedParams{
+				ID:                key.ID,
+				LastUsedIp:        encryption.NullString{String: c.RealIP(), Valid: c.RealIP() != ""},
+				LastUsedUserAgent: sql.NullString{String: c.Request().UserAgent(), Valid: c.Request().UserAgent() != ""},
+			}
+			go func() {
+				_ = queries.UpdateAPIKeyLastUsed(context.Background(), lastUsed)
+			}()
+
+			return next(c)
+		}
+	}
+}
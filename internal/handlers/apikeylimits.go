@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== API KEY LIMITS ==========
+
+// apiKeyLimit returns how many active personal API keys a user may have:
+// their override if an admin set one, else MAX_API_KEYS_PER_USER. 0 means
+// unlimited.
+func apiKeyLimit(ctx context.Context, queries *sqlc.Queries, userID uuid.UUID) (int, error) {
+	override, err := queries.GetAPIKeyLimit(ctx, userID)
+	if err == sql.ErrNoRows {
+		return config.Int("MAX_API_KEYS_PER_USER"), nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int(override.MaxKeys), nil
+}
+
+// checkAPIKeyLimit refuses creating another key for a user who has as many
+// active personal keys as their limit allows. Revoking one makes room.
+func checkAPIKeyLimit(ctx context.Context, queries *sqlc.Queries, userID uuid.UUID) error {
+	limit, err := apiKeyLimit(ctx, queries, userID)
+	if err != nil {
+		log.Printf("[Deepgram] Failed to get API key limit of user %s: %v", userID, err)
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if limit == 0 {
+		return nil
+	}
+
+	active, err := queries.CountActiveUserAPIKeys(ctx, userID)
+	if err != nil {
+		log.Printf("[Deepgram] Failed to count API keys of user %s: %v", userID, err)
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if active < int64(limit) {
+		return nil
+	}
+
+	log.Printf("[Deepgram] User %s reached the API key limit (%d active of %d)", userID, active, limit)
+	return newAPIError(http.StatusForbidden, ErrorResponse{
+		Error: "API key limit reached",
+		Details: map[string]string{
+			"active_keys": strconv.FormatInt(active, 10),
+			"limit":       strconv.Itoa(limit),
+		},
+	})
+}
+
+// APIKeyLimitRequest overrides a user's API key limit. A null limit resets
+// it to MAX_API_KEYS_PER_USER; 0 lifts it.
+type APIKeyLimitRequest struct {
+	Limit  *int   `json:"limit"`
+	Reason string `json:"reason"`
+}
+
+// APIKeyLimitResponse is a user's effective API key limit
+type APIKeyLimitResponse struct {
+	UserID     string `json:"user_id"`
+	Limit      int    `json:"limit"` // 0 = unlimited
+	Override   bool   `json:"override"`
+	ActiveKeys int64  `json:"active_keys"`
+}
+
+// SetAPIKeyLimit overrides how many active API keys a user may have (admin
+// only). Keys beyond a lowered limit keep working; only new ones are refused.
+func (h *AdminHandler) SetAPIKeyLimit(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	var req APIKeyLimitRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.Limit != nil && *req.Limit < 0 {
+		return newAPIError(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid limit",
+			Details: map[string]string{"limit": "must not be negative"},
+		})
+	}
+
+	ctx := context.Background()
+	if _, err := h.queries.GetUserByID(ctx, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "user not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	if req.Limit == nil {
+		err = h.queries.DeleteAPIKeyLimit(ctx, userID)
+	} else {
+		err = h.queries.SetAPIKeyLimit(ctx, sqlc.SetAPIKeyLimitParams{
+			UserID:  userID,
+			MaxKeys: int32(*req.Limit),
+		})
+	}
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to update API key limit")
+	}
+
+	limit, err := apiKeyLimit(ctx, h.queries, userID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	active, err := h.queries.CountActiveUserAPIKeys(ctx, userID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	recordAuditEvent(ctx, h.queries, c, auditUserKeyLimit, "user", userID.String(), strings.TrimSpace(req.Reason), map[string]string{
+		"limit":    strconv.Itoa(limit),
+		"override": strconv.FormatBool(req.Limit != nil),
+	})
+
+	return c.JSON(http.StatusOK, APIKeyLimitResponse{
+		UserID:     userID.String(),
+		Limit:      limit,
+		Override:   req.Limit != nil,
+		ActiveKeys: active,
+	})
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"hyperwhisper/internal/attestation"
+	"hyperwhisper/internal/config"
+)
+
+// attestationFailure is why a trial provisioning request was refused
+type attestationFailure struct {
+	status  int
+	message string
+}
+
+// verifyTrialAttestation enforces TRIAL_ATTESTATION. In optional mode a
+// sent attestation must still be valid, but Apple being unreachable does
+// not block provisioning.
+func verifyTrialAttestation(ctx context.Context, a *TrialAttestation) *attestationFailure {
+	mode := config.String("TRIAL_ATTESTATION")
+	if mode == "off" {
+		return nil
+	}
+	if a == nil || a.Token == "" {
+		if mode == "required" {
+			return &attestationFailure{http.StatusForbidden, "attestation required"}
+		}
+		return nil
+	}
+
+	err := attestation.Verify(ctx, a.Type, a.Token)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, attestation.ErrUnsupported):
+		return &attestationFailure{http.StatusBadRequest, "unsupported attestation type"}
+	case errors.Is(err, attestation.ErrRejected):
+		log.Printf("[Trial] Rejected %s attestation", a.Type)
+		return &attestationFailure{http.StatusForbidden, "attestation rejected"}
+	default:
+		log.Printf("[Trial] Failed to verify %s attestation: %v", a.Type, err)
+		if mode == "required" {
+			return &attestationFailure{http.StatusServiceUnavailable, "attestation unavailable"}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== AUDIT EVENTS ==========
+
+// Audit actions
+const (
+	auditAPIKeyRevoke     = "api_key.revoke"
+	auditAPIKeyUnrevoke   = "api_key.unrevoke"
+	auditAPIKeyTransfer   = "api_key.transfer"
+	auditAPIKeyLockdown   = "api_key.lockdown"
+	auditAPIKeyReenable   = "api_key.reenable"
+	auditAPIKeyInactive   = "api_key.inactivity_revoke"
+	auditAPIKeyReactivate = "api_key.reactivate"
+	auditUserUpdate       = "user.update"
+	auditUserMerge        = "user.merge"
+	auditUserCycle        = "user.billing_cycle_anchor"
+	auditUserKeyLimit     = "user.api_key_limit"
+	auditUserDeleteSched  = "user.deletion.schedule"
+	auditUserDeleteCancel = "user.deletion.cancel"
+	auditUserDelete       = "user.delete"
+	auditOrgQuota         = "organization.quota"
+	auditOrgDataRegion    = "organization.data_region"
+	auditSignupPolicy     = "settings.signup"
+	auditInviteCreate     = "invite.create"
+	auditInviteRevoke     = "invite.revoke"
+	auditDataExport       = "data.export"
+	auditDeepgramDefaults = "settings.deepgram"
+	auditRuntimeSettings  = "settings.runtime"
+	auditTokenReuse       = "token.reuse_detected"
+	auditUserSecurityFlag = "user.security_flag.clear"
+	auditUserSuspend      = "user.suspend"
+	auditUserUnsuspend    = "user.unsuspend"
+	auditReportCreate     = "report_schedule.create"
+	auditReportDelete     = "report_schedule.delete"
+	auditTenantCreate     = "tenant.create"
+	auditTenantUpdate     = "tenant.update"
+	auditTenantDelete     = "tenant.delete"
+	auditDomainAdd        = "tenant.domain.add"
+	auditDomainVerify     = "tenant.domain.verify"
+	auditDomainDelete     = "tenant.domain.delete"
+	auditOAuthAuthorize   = "oauth.authorize"
+	auditOAuthRevoke      = "oauth.revoke"
+)
+
+// AuditEventResponse is an audit event as returned to admins
+type AuditEventResponse struct {
+	ID            string            `json:"id"`
+	ActorUserID   *string           `json:"actor_user_id"`
+	ActorUsername *string           `json:"actor_username"`
+	Action        string            `json:"action"`
+	TargetType    string            `json:"target_type"`
+	TargetID      string            `json:"target_id"`
+	Reason        *string           `json:"reason"`
+	Metadata      map[string]string `json:"metadata"`
+	CreatedAt     string            `json:"created_at"`
+}
+
+// recordAuditEvent stores an admin action, or one the server took on its
+// own when c is nil. Failures are logged rather than returned because the
+// action itself has already been applied.
+func recordAuditEvent(ctx context.Context, queries *sqlc.Queries, c echo.Context, action, targetType, targetID, reason string, metadata map[string]string) {
+	var actor uuid.NullUUID
+	if c != nil {
+		if claims := auth.GetUserFromContext(c); claims != nil {
+			actor = uuid.NullUUID{UUID: claims.UserID, Valid: true}
+		}
+	}
+
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+
+	_, err := queries.CreateAuditEvent(ctx, sqlc.CreateAuditEventParams{
+		ActorUserID: actor,
+		Action:      action,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Reason:      sql.NullString{String: reason, Valid: reason != ""},
+		Metadata:    metadataJSON,
+	})
+	if err != nil {
+		log.Printf("[Audit] Failed to record %s on %s %s: %v", action, targetType, targetID, err)
+	}
+}
+
+// ListAuditEvents returns audit events, newest first, optionally filtered
+// by target_type and target_id (admin only)
+func (h *AdminHandler) ListAuditEvents(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	perPage, _ := strconv.Atoi(c.QueryParam("per_page"))
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	targetType := c.QueryParam("target_type")
+	targetID := c.QueryParam("target_id")
+
+	offset := (page - 1) * perPage
+	ctx := context.Background()
+
+	total, err := h.queries.CountAuditEvents(ctx, sqlc.CountAuditEventsParams{
+		TargetType: sql.NullString{String: targetType, Valid: targetType != ""},
+		TargetID:   sql.NullString{String: targetID, Valid: targetID != ""},
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	events, err := h.queries.ListAuditEvents(ctx, sqlc.ListAuditEventsParams{
+		TargetType: sql.NullString{String: targetType, Valid: targetType != ""},
+		TargetID:   sql.NullString{String: targetID, Valid: targetID != ""},
+		PageLimit:  int32(perPage),
+		PageOffset: int32(offset),
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	responses := make([]AuditEventResponse, len(events))
+	for i, e := range events {
+		responses[i] = toAuditEventResponse(e)
+	}
+
+	totalPages := int(total) / perPage
+	if int(total)%perPage > 0 {
+		totalPages++
+	}
+
+	return c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       responses,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: totalPages,
+	})
+}
+
+func toAuditEventResponse(e sqlc.ListAuditEventsRow) AuditEventResponse {
+	resp := AuditEventResponse{
+		ID:            e.ID.String(),
+		ActorUserID:   nullUUIDPtr(e.ActorUserID),
+		ActorUsername: nullStringPtr(e.ActorUsername),
+		Action:        e.Action,
+		TargetType:    e.TargetType,
+		TargetID:      e.TargetID,
+		Reason:        nullStringPtr(e.Reason),
+		CreatedAt:     e.CreatedAt.Format(time.RFC3339),
+	}
+	_ = json.Unmarshal(e.Metadata, &resp.Metadata)
+	return resp
+}
@@ -3,17 +3,25 @@ package handlers
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
-	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
 	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/encryption"
+	"hyperwhisper/internal/i18n"
+	"hyperwhisper/internal/logging"
+	"hyperwhisper/internal/mail"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
 )
 
 // Request types
@@ -23,6 +31,18 @@ type SignUpRequest struct {
 	Password  string `json:"password"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
+
+	// TrialToken is the carryover token of a trial upgrade link; the trial
+	// is converted into the new account
+	TrialToken string `json:"trial_token,omitempty"`
+
+	// InviteCode is the code of an invite link, or the signup policy's
+	// invite code while it sets one
+	InviteCode string `json:"invite_code,omitempty"`
+
+	// Locale is the language of the user's emails and error messages;
+	// without one they follow each request's Accept-Language
+	Locale string `json:"locale,omitempty"`
 }
 
 type SignInRequest struct {
@@ -42,139 +62,306 @@ type UserResponse struct {
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	UserType  string `json:"user_type"`
+	Timezone  string `json:"timezone"`
 	CreatedAt string `json:"created_at"`
+	// BillingCycleAnchor is when the user's current billing cycles are
+	// counted from; it defaults to the signup time
+	BillingCycleAnchor string `json:"billing_cycle_anchor"`
+	// SecurityFlaggedAt is when a leaked refresh token of the user was last
+	// replayed, until an admin clears the flag
+	SecurityFlaggedAt *string `json:"security_flagged_at"`
+	// SuspendedAt is set while an admin has suspended the user
+	SuspendedAt *string `json:"suspended_at"`
+	// Locale is the language the user chose, or null to follow
+	// Accept-Language
+	Locale *string `json:"locale"`
+	// TenantID is the tenant the user belongs to, or null for the
+	// operator's own users
+	TenantID *string `json:"tenant_id"`
+}
+
+// UserSettingsRequest is the request body for updating the current user's settings
+type UserSettingsRequest struct {
+	Timezone string `json:"timezone"`
+}
+
+// UpdateMeRequest is the request body for PATCH /me; omitted fields are
+// left as they are, and an empty locale follows Accept-Language again
+type UpdateMeRequest struct {
+	Timezone *string `json:"timezone"`
+	Locale   *string `json:"locale"`
 }
 
 type AuthResponse struct {
 	User        UserResponse `json:"user"`
 	AccessToken string       `json:"access_token"`
 	ExpiresIn   int64        `json:"expires_in"`
+
+	TrialConversion *TrialConversionResponse `json:"trial_conversion,omitempty"`
+}
+
+// TrialConversionResponse is what a signup from a trial upgrade link was
+// credited with
+type TrialConversionResponse struct {
+	TrialKeyID       string `json:"trial_key_id"`
+	CarryoverSeconds int    `json:"carryover_seconds"`
+	BonusSeconds     int    `json:"bonus_seconds"`
 }
 
 type ErrorResponse struct {
 	Error   string            `json:"error"`
 	Details map[string]string `json:"details,omitempty"`
+
+	// Code and Message are filled in when the response is serialized:
+	// a stable machine-readable code and Error in the user's locale or
+	// the request's Accept-Language (see LocalizedJSONSerializer)
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
 }
 
 // AuthHandler handles authentication endpoints
 type AuthHandler struct {
+	db      *sql.DB
 	queries *sqlc.Queries
+	mailer  mail.Sender
 }
 
 // NewAuthHandler creates a new auth handler
 func NewAuthHandler(db *sql.DB) *AuthHandler {
 	return &AuthHandler{
+		db:      db,
 		queries: sqlc.New(db),
+		mailer:  mail.NewFromConfig(),
 	}
 }
 
+// SetMailer replaces the sender of password reset and new device emails
+func (h *AuthHandler) SetMailer(mailer mail.Sender) {
+	h.mailer = mailer
+}
+
 // SignUp handles user registration
 func (h *AuthHandler) SignUp(c echo.Context) error {
 	var req SignUpRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+		return apiError(http.StatusBadRequest, "invalid request body")
 	}
 
 	// Validate required fields
 	if req.Username == "" || req.Email == "" || req.Password == "" {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "username, email, and password are required"})
+		return apiError(http.StatusBadRequest, "username, email, and password are required")
+	}
+
+	// Validate the trial upgrade link before creating anything
+	var trialKeyID uuid.UUID
+	if req.TrialToken != "" {
+		id, err := auth.ValidateTrialUpgradeToken(req.TrialToken)
+		if err != nil {
+			return newAPIError(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid trial upgrade link",
+				Details: map[string]string{"trial_token": err.Error()},
+			})
+		}
+		trialKeyID = id
 	}
 
 	// Validate password
 	if err := auth.ValidatePassword(req.Password); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
+		return newAPIError(http.StatusBadRequest, ErrorResponse{
 			Error:   "password validation failed",
 			Details: map[string]string{"password": err.Error()},
 		})
 	}
 
+	locale, ok := parseLocale(req.Locale)
+	if !ok {
+		return unsupportedLocaleError()
+	}
+
 	ctx := context.Background()
 
+	// An invite link lets its holder past the signup policy
+	invite, err := findInvite(ctx, h.queries, req.InviteCode)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if invite == nil {
+		refusal, err := checkSignupPolicy(ctx, h.queries, req.Email, req.InviteCode)
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+		if refusal != nil {
+			return newAPIError(http.StatusForbidden, *refusal)
+		}
+	} else if !inviteUsable(*invite, time.Now()) {
+		return apiError(http.StatusForbidden, "invite link is no longer valid")
+	}
+
 	// Check if email exists
 	emailExists, err := h.queries.CheckEmailExists(ctx, req.Email)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 	if emailExists {
-		return c.JSON(http.StatusConflict, ErrorResponse{
-			Error:   "email already taken",
-			Details: map[string]string{"email": "this email is already registered"},
-		})
+		return userConflictError(c, "email")
 	}
 
 	// Check if username exists
 	usernameExists, err := h.queries.CheckUsernameExists(ctx, req.Username)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 	if usernameExists {
-		return c.JSON(http.StatusConflict, ErrorResponse{
-			Error:   "username already taken",
-			Details: map[string]string{"username": "this username is already taken"},
-		})
+		return userConflictError(c, "username")
 	}
 
 	// Hash password
 	passwordHash, err := auth.HashPassword(req.Password)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to process password"})
+		return apiError(http.StatusInternalServerError, "failed to process password")
+	}
+
+	// The checks above only catch the common case: concurrent signups are
+	// settled by the unique constraints, and the signup lock keeps two of
+	// them from both seeing an empty users table
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	defer tx.Rollback()
+	queries := h.queries.WithTx(tx)
+
+	if err := queries.LockUserSignups(ctx); err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	// Check if this is the first user (make them admin)
-	userCount, err := h.queries.CountUsers(ctx)
+	userCount, err := queries.CountUsers(ctx)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	userType := "user"
+	if invite != nil {
+		userType = invite.UserType
+	}
 	if userCount == 0 {
 		userType = "admin"
 	}
 
+	// Redeem the invite with the account, so a used-up invite creates none
+	if invite != nil {
+		if _, err := queries.RedeemInvite(ctx, invite.ID); err != nil {
+			if err == sql.ErrNoRows {
+				return apiError(http.StatusForbidden, "invite link is no longer valid")
+			}
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+	}
+
 	// Create user
-	user, err := h.queries.CreateUser(ctx, sqlc.CreateUserParams{
+	user, err := queries.CreateUser(ctx, sqlc.CreateUserParams{
 		Username:     req.Username,
 		Email:        req.Email,
 		PasswordHash: passwordHash,
 		FirstName:    req.FirstName,
 		LastName:     req.LastName,
 		UserType:     userType,
+		Locale:       locale,
+		TenantID:     tenantID(requestTenant(c)), // Signups through a tenant's domain join it
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to create user"})
+		if field, ok := userUniqueViolation(err); ok {
+			return userConflictError(c, field)
+		}
+		return apiError(http.StatusInternalServerError, "failed to create user")
+	}
+	if err := recordPassword(ctx, queries, user.ID, passwordHash); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to create user")
+	}
+	if err := tx.Commit(); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to create user")
 	}
 
 	// Generate tokens
-	tokens, err := auth.GenerateTokenPair(user.ID, user.Username, user.Email, user.UserType)
+	tokens, err := auth.GenerateTokenPair(user.ID, user.Username, user.Email, user.UserType, user.TenantID.UUID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to generate tokens"})
+		return apiError(http.StatusInternalServerError, "failed to generate tokens")
 	}
 
 	// Store tokens in database
-	if err := h.storeRefreshToken(ctx, user.ID, tokens); err != nil {
-		// Log error but don't fail - tokens are still valid
-		// In production, you might want to handle this differently
+	// An untracked refresh token can't be rotated, so the session would end
+	// at the first refresh. The account exists; the user can sign in.
+	if err := h.storeRefreshToken(c, ctx, user.ID, uuid.New(), tokens); err != nil {
+		log.Printf("[Auth] Failed to store refresh token of new user %s: %v", user.ID, err)
+		return apiError(http.StatusInternalServerError, "failed to create session")
 	}
 
 	// Set cookies
 	setAuthCookies(c, tokens)
 
-	return c.JSON(http.StatusCreated, AuthResponse{
+	resp := AuthResponse{
 		User:        toUserResponse(user),
 		AccessToken: tokens.AccessToken,
 		ExpiresIn:   tokens.ExpiresIn,
+	}
+	if trialKeyID != uuid.Nil {
+		resp.TrialConversion = h.convertTrial(ctx, trialKeyID, user.ID)
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// convertTrial credits a new account with its trial's unused quota plus
+// TRIAL_CONVERSION_BONUS_SECONDS. It returns nil if the trial is gone or was
+// already converted; the signup itself succeeds either way.
+func (h *AuthHandler) convertTrial(ctx context.Context, trialKeyID, userID uuid.UUID) *TrialConversionResponse {
+	trialKey, err := h.queries.GetTrialAPIKeyByID(ctx, trialKeyID)
+	if err != nil {
+		log.Printf("[Auth] Trial %s not converted: %v", trialKeyID, err)
+		return nil
+	}
+
+	carryover := 0
+	if !trialKey.RevokedAt.Valid {
+		remaining, err := remainingTrialSeconds(ctx, h.queries, trialKey)
+		if err != nil {
+			log.Printf("[Auth] Trial %s not converted: %v", trialKeyID, err)
+			return nil
+		}
+		carryover = int(remaining)
+	}
+
+	conversion, err := h.queries.CreateTrialConversion(ctx, sqlc.CreateTrialConversionParams{
+		TrialKeyID:       uuid.NullUUID{UUID: trialKeyID, Valid: true},
+		UserID:           userID,
+		CarryoverSeconds: int32(carryover),
+		BonusSeconds:     int32(config.Int("TRIAL_CONVERSION_BONUS_SECONDS")),
 	})
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("[Auth] Failed to convert trial %s: %v", trialKeyID, err)
+		}
+		return nil
+	}
+
+	log.Printf("[Auth] Converted trial %s (%s) into user %s", trialKeyID, logging.StoredKeyID(trialKey.KeyPrefix, trialKey.KeyHash), userID)
+	return &TrialConversionResponse{
+		TrialKeyID:       trialKeyID.String(),
+		CarryoverSeconds: int(conversion.CarryoverSeconds),
+		BonusSeconds:     int(conversion.BonusSeconds),
+	}
 }
 
 // SignIn handles user login
 func (h *AuthHandler) SignIn(c echo.Context) error {
 	var req SignInRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+		return apiError(http.StatusBadRequest, "invalid request body")
 	}
 
 	if req.Identifier == "" || req.Password == "" {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "identifier and password are required"})
+		return apiError(http.StatusBadRequest, "identifier and password are required")
 	}
 
 	ctx := context.Background()
@@ -183,29 +370,49 @@ func (h *AuthHandler) SignIn(c echo.Context) error {
 	user, err := h.queries.GetUserByEmailOrUsername(ctx, req.Identifier)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "invalid credentials"})
+			recordLoginEvent(ctx, h.queries, c, uuid.NullUUID{}, loginSignIn, loginUnknownAccount)
+			return apiError(http.StatusUnauthorized, "invalid credentials")
 		}
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
+	userID := uuid.NullUUID{UUID: user.ID, Valid: true}
 
 	// Verify password
 	if err := auth.CheckPassword(req.Password, user.PasswordHash); err != nil {
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "invalid credentials"})
+		recordLoginEvent(ctx, h.queries, c, userID, loginSignIn, loginInvalidPassword)
+		return apiError(http.StatusUnauthorized, "invalid credentials")
+	}
+	// Accounts only work through their tenant's domain
+	if !userOfRequestTenant(c, user) {
+		recordLoginEvent(ctx, h.queries, c, userID, loginSignIn, loginWrongTenant)
+		return apiError(http.StatusUnauthorized, "invalid credentials")
+	}
+	// Only told once the password checks out, so it reveals nothing about
+	// accounts to others
+	if user.SuspendedAt.Valid {
+		log.Printf("[Auth] Sign-in of suspended user %s refused", user.ID)
+		recordLoginEvent(ctx, h.queries, c, userID, loginSignIn, loginSuspended)
+		return apiError(http.StatusForbidden, "account suspended")
 	}
 
 	// Generate tokens
-	tokens, err := auth.GenerateTokenPair(user.ID, user.Username, user.Email, user.UserType)
+	tokens, err := auth.GenerateTokenPair(user.ID, user.Username, user.Email, user.UserType, user.TenantID.UUID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to generate tokens"})
+		return apiError(http.StatusInternalServerError, "failed to generate tokens")
 	}
 
 	// Store tokens in database
-	if err := h.storeRefreshToken(ctx, user.ID, tokens); err != nil {
-		// Log error but don't fail - tokens are still valid
+	// An untracked refresh token can't be rotated, so the session would end
+	// at the first refresh
+	if err := h.storeRefreshToken(c, ctx, user.ID, uuid.New(), tokens); err != nil {
+		log.Printf("[Auth] Failed to store refresh token of user %s: %v", user.ID, err)
+		return apiError(http.StatusInternalServerError, "failed to create session")
 	}
 
 	// Set cookies
 	setAuthCookies(c, tokens)
+	recordLoginEvent(ctx, h.queries, c, userID, loginSignIn, loginSuccess)
+	h.checkNewDevice(ctx, c, user)
 
 	return c.JSON(http.StatusOK, AuthResponse{
 		User:        toUserResponse(user),
@@ -243,131 +450,419 @@ func (h *AuthHandler) TokenRefresh(c echo.Context) error {
 	}
 
 	if refreshToken == "" {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "refresh token required"})
+		return apiError(http.StatusBadRequest, "refresh token required")
 	}
 
+	tokens, err := h.rotateRefreshToken(c, context.Background(), refreshToken)
+	if err != nil {
+		// Only a refused token ends the session. After a server error the
+		// old token is still good for a retry, and a refresh that lost the
+		// race to another tab would clear the cookies the winner just set.
+		if refreshTokenRefused(err) {
+			clearAuthCookies(c)
+		}
+		return err
+	}
+
+	// Set new cookies
+	setAuthCookies(c, tokens)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"access_token": tokens.AccessToken,
+		"expires_in":   tokens.ExpiresIn,
+	})
+}
+
+// rotateRefreshToken exchanges a refresh token for a new token pair of the
+// same family. The user is reloaded, so suspensions and role or tenant
+// changes apply from the next refresh. Refused tokens are answered with 401.
+func (h *AuthHandler) rotateRefreshToken(c echo.Context, ctx context.Context, refreshToken string) (*auth.TokenPair, error) {
 	// Validate refresh token
 	claims, err := auth.ValidateToken(refreshToken, auth.RefreshToken)
 	if err != nil {
-		clearAuthCookies(c)
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+		recordLoginEvent(ctx, h.queries, c, uuid.NullUUID{}, loginTokenRefresh, loginInvalidToken)
+		return nil, apiError(http.StatusUnauthorized, err.Error())
 	}
+	userID := uuid.NullUUID{UUID: claims.UserID, Valid: true}
 
-	ctx := context.Background()
+	// Only tracked tokens can be revoked, so untracked ones are refused
+	stored, err := h.queries.GetRefreshTokenByJTI(ctx, claims.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			recordLoginEvent(ctx, h.queries, c, userID, loginTokenRefresh, loginRevokedToken)
+			return nil, apiError(http.StatusUnauthorized, "token has been revoked")
+		}
+		return nil, apiError(http.StatusInternalServerError, "database error")
+	}
+	if stored.RevokedAt.Valid {
+		if stored.RevokedReason.String == "refreshed" {
+			return nil, h.refreshTokenReused(c, ctx, stored)
+		}
+		recordLoginEvent(ctx, h.queries, c, userID, loginTokenRefresh, loginRevokedToken)
+		return nil, apiError(http.StatusUnauthorized, "token has been revoked")
+	}
+
+	user, err := h.queries.GetUserByID(ctx, stored.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			recordLoginEvent(ctx, h.queries, c, uuid.NullUUID{}, loginTokenRefresh, loginUnknownAccount)
+			return nil, apiError(http.StatusUnauthorized, "user not found")
+		}
+		return nil, apiError(http.StatusInternalServerError, "database error")
+	}
+	if user.SuspendedAt.Valid {
+		log.Printf("[Auth] Token refresh of suspended user %s refused", user.ID)
+		recordLoginEvent(ctx, h.queries, c, userID, loginTokenRefresh, loginSuspended)
+		return nil, apiError(http.StatusUnauthorized, "account suspended")
+	}
 
-	// Check if refresh token is revoked
-	isRevoked, err := h.queries.IsRefreshTokenRevoked(ctx, claims.ID)
-	if err == nil && isRevoked {
-		clearAuthCookies(c)
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "token has been revoked"})
+	tokens, err := auth.GenerateTokenPair(user.ID, user.Username, user.Email, user.UserType, user.TenantID.UUID)
+	if err != nil {
+		return nil, apiError(http.StatusInternalServerError, "failed to generate tokens")
 	}
 
-	// Generate new token pair
-	tokens, err := auth.GenerateTokenPair(claims.UserID, claims.Username, claims.Email, claims.UserType)
+	// The old token is only spent if its successor is stored, so a failed
+	// refresh can be retried instead of tripping reuse detection
+	tx, err := h.db.BeginTx(ctx, nil)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to generate tokens"})
+		return nil, apiError(http.StatusInternalServerError, "database error")
 	}
+	defer tx.Rollback()
+	queries := h.queries.WithTx(tx)
 
-	// Revoke the old refresh token (single-use)
-	_ = h.queries.RevokeRefreshToken(ctx, sqlc.RevokeRefreshTokenParams{
-		TokenJti:      claims.ID,
-		RevokedReason: sql.NullString{String: "refreshed", Valid: true},
-	})
+	// Revoke the old refresh token (single-use). Losing the race to a
+	// concurrent refresh with the same token is reuse within the grace
+	// period.
+	rotated, err := queries.RotateRefreshToken(ctx, claims.ID)
+	if err != nil {
+		log.Printf("[Auth] Failed to rotate refresh token %s: %v", claims.ID, err)
+		return nil, apiError(http.StatusInternalServerError, "database error")
+	}
+	if rotated == 0 {
+		stored.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		return nil, h.refreshTokenReused(c, ctx, stored)
+	}
 
-	// Store new tokens in database
-	if err := h.storeRefreshToken(ctx, claims.UserID, tokens); err != nil {
-		// Log error but don't fail
+	if err := createRefreshToken(ctx, queries, c, user.ID, stored.FamilyID, tokens); err != nil {
+		log.Printf("[Auth] Failed to store refresh token of user %s: %v", user.ID, err)
+		return nil, apiError(http.StatusInternalServerError, "database error")
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("[Auth] Failed to rotate refresh token %s: %v", claims.ID, err)
+		return nil, apiError(http.StatusInternalServerError, "database error")
 	}
 
-	// Set new cookies
-	setAuthCookies(c, tokens)
+	recordLoginEvent(ctx, h.queries, c, userID, loginTokenRefresh, loginSuccess)
+	return tokens, nil
+}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"access_token": tokens.AccessToken,
-		"expires_in":   tokens.ExpiresIn,
+// concurrentRefresh refuses a refresh that lost the race to another one with
+// the same token within REFRESH_TOKEN_REUSE_GRACE, e.g. from a second tab
+type concurrentRefresh struct {
+	*APIError
+}
+
+func (e concurrentRefresh) Unwrap() error { return e.APIError }
+
+// refreshTokenRefused reports whether err refuses a refresh token for good:
+// it's invalid, revoked, untracked or replayed outside the grace period, or
+// its user can't sign in anymore
+func refreshTokenRefused(err error) bool {
+	var race concurrentRefresh
+	if errors.As(err, &race) {
+		return false
+	}
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Status == http.StatusUnauthorized
+}
+
+// refreshTokenReused answers a refresh with a token that was already
+// rotated. Outside the grace period for clients racing themselves, e.g. two
+// tabs refreshing at once, the token must have leaked: every token of its
+// family is revoked, signing out both the thief and the victim, and the
+// account is flagged for review.
+func (h *AuthHandler) refreshTokenReused(c echo.Context, ctx context.Context, token sqlc.Token) error {
+	userID := uuid.NullUUID{UUID: token.UserID, Valid: true}
+	if time.Since(token.RevokedAt.Time) < config.Duration("REFRESH_TOKEN_REUSE_GRACE") {
+		recordLoginEvent(ctx, h.queries, c, userID, loginTokenRefresh, loginRevokedToken)
+		return concurrentRefresh{apiError(http.StatusUnauthorized, "token has been revoked")}
+	}
+
+	revoked, err := h.queries.RevokeRefreshTokenFamily(ctx, sqlc.RevokeRefreshTokenFamilyParams{
+		FamilyID:      token.FamilyID,
+		RevokedReason: sql.NullString{String: "reuse_detected", Valid: true},
 	})
+	if err != nil {
+		log.Printf("[Auth] Failed to revoke token family %s: %v", token.FamilyID, err)
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	// Access tokens don't name their family, so every one of the user's is
+	// denied; other devices of the user just refresh
+	denyUserAccessTokens(ctx, h.queries, token.UserID)
+	if err := h.queries.FlagUserSecurity(ctx, token.UserID); err != nil {
+		log.Printf("[Auth] Failed to flag user %s: %v", token.UserID, err)
+	}
+
+	recordLoginEvent(ctx, h.queries, c, userID, loginTokenRefresh, loginTokenReuse)
+	log.Printf("[Auth] Refresh token %s of user %s reused after rotation, revoked %d tokens of family %s",
+		token.TokenJti, token.UserID, revoked, token.FamilyID)
+	recordAuditEvent(ctx, h.queries, c, auditTokenReuse, "user", token.UserID.String(), "", map[string]string{
+		"token_jti":      token.TokenJti,
+		"family_id":      token.FamilyID.String(),
+		"revoked_tokens": strconv.FormatInt(revoked, 10),
+		"client_ip":      c.RealIP(),
+	})
+
+	return apiError(http.StatusUnauthorized, "refresh token reuse detected")
 }
 
-// SignOut handles logout
+// SignOut handles logout. The session's refresh token is revoked and its
+// access tokens denied, so copies of them stop working along with the
+// cleared cookies.
 func (h *AuthHandler) SignOut(c echo.Context) error {
+	ctx := context.Background()
+
+	access := auth.AccessTokenClaims(c)
+	if access != nil && access.ClientID != "" {
+		// Applications end their access by having the user revoke the grant
+		access = nil
+	}
+	if access != nil && access.SessionID == "" {
+		// Issued before access tokens named their session
+		denyAccessToken(ctx, h.queries, access.ID)
+	}
+	if userID, sessionID := signOutSession(c, access); sessionID != "" {
+		if _, err := h.queries.RevokeUserRefreshToken(ctx, sqlc.RevokeUserRefreshTokenParams{
+			TokenJti:      sessionID,
+			UserID:        userID,
+			RevokedReason: sql.NullString{String: "signed_out", Valid: true},
+		}); err != nil {
+			log.Printf("[Auth] Failed to revoke refresh token %s on sign-out: %v", sessionID, err)
+		}
+		denyAccessToken(ctx, h.queries, sessionID)
+	}
+
 	clearAuthCookies(c)
 	return c.JSON(http.StatusOK, map[string]string{"message": "signed out successfully"})
 }
 
+// signOutSession identifies the session being signed out by its refresh
+// token cookie, or else by the access token
+func signOutSession(c echo.Context, access *auth.Claims) (uuid.UUID, string) {
+	if cookie, err := c.Cookie("refresh_token"); err == nil {
+		if claims, err := auth.ValidateToken(cookie.Value, auth.RefreshToken); err == nil {
+			return claims.UserID, claims.ID
+		}
+	}
+	if access != nil {
+		return access.UserID, access.SessionID
+	}
+	return uuid.Nil, ""
+}
+
 // Me returns current user info
 func (h *AuthHandler) Me(c echo.Context) error {
 	claims := auth.GetUserFromContext(c)
-	if claims == nil {
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "not authenticated"})
-	}
 
 	ctx := context.Background()
 	user, err := h.queries.GetUserByID(ctx, claims.UserID)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "user not found"})
+		return apiError(http.StatusNotFound, "user not found")
+	}
+
+	return c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// UpdateSettings updates the current user's settings
+func (h *AuthHandler) UpdateSettings(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	var req UserSettingsRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if !validTimezone(req.Timezone) {
+		return newAPIError(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid timezone",
+			Details: map[string]string{"timezone": "must be an IANA time zone name, e.g. Europe/Berlin"},
+		})
+	}
+
+	ctx := context.Background()
+	user, err := h.queries.UpdateUserTimezone(ctx, sqlc.UpdateUserTimezoneParams{
+		ID:       claims.UserID,
+		Timezone: req.Timezone,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "user not found")
+		}
+		return apiError(http.StatusInternalServerError, "failed to update settings")
+	}
+
+	return c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// UpdateMe updates the current user's preferences: the fields the body sets
+func (h *AuthHandler) UpdateMe(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	var req UpdateMeRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	params := sqlc.UpdateUserPreferencesParams{ID: claims.UserID}
+	if req.Timezone != nil {
+		if !validTimezone(*req.Timezone) {
+			return newAPIError(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid timezone",
+				Details: map[string]string{"timezone": "must be an IANA time zone name, e.g. Europe/Berlin"},
+			})
+		}
+		params.Timezone = sql.NullString{String: *req.Timezone, Valid: true}
+	}
+	if req.Locale != nil {
+		locale, ok := parseLocale(*req.Locale)
+		if !ok {
+			return unsupportedLocaleError()
+		}
+		params.SetLocale, params.Locale = true, locale
+	}
+
+	ctx := context.Background()
+	user, err := h.queries.UpdateUserPreferences(ctx, params)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "user not found")
+		}
+		return apiError(http.StatusInternalServerError, "failed to update settings")
 	}
 
 	return c.JSON(http.StatusOK, toUserResponse(user))
 }
 
 // Helper functions
+
+// parseLocale validates a requested locale; "" chooses none
+func parseLocale(raw string) (sql.NullString, bool) {
+	locale := strings.ToLower(strings.TrimSpace(raw))
+	if locale == "" {
+		return sql.NullString{}, true
+	}
+	if !i18n.IsSupported(locale) {
+		return sql.NullString{}, false
+	}
+	return sql.NullString{String: locale, Valid: true}, true
+}
+
+func unsupportedLocaleError() error {
+	return newAPIError(http.StatusBadRequest, ErrorResponse{
+		Error:   "unsupported locale",
+		Details: map[string]string{"locale": "must be one of " + strings.Join(i18n.Supported(), ", ")},
+	})
+}
+
+// userUniqueViolation reports whether err is an insert or update of users
+// losing a race on the unique email or username, and which field it was
+func userUniqueViolation(err error) (string, bool) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+		return "", false
+	}
+	switch pqErr.Constraint {
+	case "users_email_key":
+		return "email", true
+	case "users_username_key":
+		return "username", true
+	}
+	return "", false
+}
+
+// userConflictError rejects a signup whose email or username is taken
+func userConflictError(c echo.Context, field string) error {
+	if field == "email" {
+		return newAPIError(http.StatusConflict, ErrorResponse{
+			Error:   "email already taken",
+			Details: map[string]string{"email": "this email is already registered"},
+		})
+	}
+	return newAPIError(http.StatusConflict, ErrorResponse{
+		Error:   "username already taken",
+		Details: map[string]string{"username": "this username is already taken"},
+	})
+}
+
 func toUserResponse(user sqlc.User) UserResponse {
 	createdAt := ""
 	if user.CreatedAt.Valid {
 		createdAt = user.CreatedAt.Time.Format(time.RFC3339)
 	}
 
-	return UserResponse{
+	anchor := ""
+	if t := billingCycleAnchor(user); !t.IsZero() {
+		anchor = t.Format(time.RFC3339)
+	}
+
+	resp := UserResponse{
 		ID:        user.ID.String(),
 		Username:  user.Username,
 		Email:     user.Email,
 		FirstName: user.FirstName,
 		LastName:  user.LastName,
 		UserType:  user.UserType,
+		Timezone:  user.Timezone,
 		CreatedAt: createdAt,
+
+		BillingCycleAnchor: anchor,
+	}
+	if user.SecurityFlaggedAt.Valid {
+		flaggedAt := user.SecurityFlaggedAt.Time.Format(time.RFC3339)
+		resp.SecurityFlaggedAt = &flaggedAt
+	}
+	if user.SuspendedAt.Valid {
+		suspendedAt := user.SuspendedAt.Time.Format(time.RFC3339)
+		resp.SuspendedAt = &suspendedAt
+	}
+	if user.Locale.Valid {
+		resp.Locale = &user.Locale.String
 	}
+	if user.TenantID.Valid {
+		tenantID := user.TenantID.UUID.String()
+		resp.TenantID = &tenantID
+	}
+	return resp
 }
 
-func isSecureMode() bool {
-	return os.Getenv("APP_ENV") != "dev"
+// isSecureMode reports whether cookies must be Secure: always in
+// production, and in development when the client connected over HTTPS
+func isSecureMode(c echo.Context) bool {
+	return !config.IsDev() || requestScheme(c.Request()) == "https"
 }
 
 func getRefreshTokenExpiryDays() int {
-	expiryStr := os.Getenv("REFRESH_TOKEN_EXPIRY")
-	if expiryStr == "" {
-		return 7
-	}
-	expiry, err := strconv.Atoi(expiryStr)
-	if err != nil {
-		return 7
-	}
-	return expiry
+	return config.Int("REFRESH_TOKEN_EXPIRY")
 }
 
 func getAccessTokenExpiryMinutes() int {
-	expiryStr := os.Getenv("ACCESS_TOKEN_EXPIRY")
-	if expiryStr == "" {
-		return 5
-	}
-	expiry, err := strconv.Atoi(expiryStr)
-	if err != nil {
-		return 5
-	}
-	return expiry
+	return config.Int("ACCESS_TOKEN_EXPIRY")
 }
 
 func setAuthCookies(c echo.Context, tokens *auth.TokenPair) {
-	secure := isSecureMode()
+	secure := isSecureMode(c)
 	sameSite := http.SameSiteLaxMode
 	if secure {
 		sameSite = http.SameSiteStrictMode
 	}
+	// Shared by the hosts within the tenant's cookie domain, if it has one
+	domain := cookieDomain(c)
 
 	// Access token cookie (backup, primary is in response body)
 	c.SetCookie(&http.Cookie{
 		Name:     "access_token",
 		Value:    tokens.AccessToken,
 		Path:     "/",
+		Domain:   domain,
 		HttpOnly: true,
 		Secure:   secure,
 		SameSite: sameSite,
@@ -379,33 +874,77 @@ func setAuthCookies(c echo.Context, tokens *auth.TokenPair) {
 		Name:     "refresh_token",
 		Value:    tokens.RefreshToken,
 		Path:     "/api/v1",
+		Domain:   domain,
 		HttpOnly: true,
 		Secure:   secure,
 		SameSite: sameSite,
 		MaxAge:   getRefreshTokenExpiryDays() * 24 * 60 * 60,
 	})
-}
 
-func clearAuthCookies(c echo.Context) {
+	// CSRF token, echoed by the dashboard in the X-CSRF-Token header
+	// (readable by scripts, unlike the tokens above)
+	csrfToken, err := auth.GenerateCSRFToken()
+	if err != nil {
+		log.Printf("[Auth] Failed to generate CSRF token: %v", err)
+		return
+	}
 	c.SetCookie(&http.Cookie{
-		Name:     "access_token",
-		Value:    "",
+		Name:     auth.CSRFCookieName,
+		Value:    csrfToken,
 		Path:     "/",
-		HttpOnly: true,
-		MaxAge:   -1,
+		Domain:   domain,
+		Secure:   secure,
+		SameSite: sameSite,
+		MaxAge:   getRefreshTokenExpiryDays() * 24 * 60 * 60,
 	})
+}
 
-	c.SetCookie(&http.Cookie{
-		Name:     "refresh_token",
-		Value:    "",
-		Path:     "/api/v1",
-		HttpOnly: true,
-		MaxAge:   -1,
-	})
+// clearAuthCookies removes the auth cookies of the request's host, and
+// those shared across its tenant's cookie domain
+func clearAuthCookies(c echo.Context) {
+	domains := []string{""}
+	if domain := cookieDomain(c); domain != "" {
+		domains = append(domains, domain)
+	}
+	for _, domain := range domains {
+		c.SetCookie(&http.Cookie{
+			Name:     "access_token",
+			Value:    "",
+			Path:     "/",
+			Domain:   domain,
+			HttpOnly: true,
+			MaxAge:   -1,
+		})
+
+		c.SetCookie(&http.Cookie{
+			Name:     "refresh_token",
+			Value:    "",
+			Path:     "/api/v1",
+			Domain:   domain,
+			HttpOnly: true,
+			MaxAge:   -1,
+		})
+
+		c.SetCookie(&http.Cookie{
+			Name:   auth.CSRFCookieName,
+			Value:  "",
+			Path:   "/",
+			Domain: domain,
+			MaxAge: -1,
+		})
+	}
+}
+
+// storeRefreshToken saves the refresh token to the database for tracking,
+// along with the client it was issued to. Sign-ins start a new family;
+// refreshes keep the family of the token they rotate.
+func (h *AuthHandler) storeRefreshToken(c echo.Context, ctx context.Context, userID, familyID uuid.UUID, tokens *auth.TokenPair) error {
+	return createRefreshToken(ctx, h.queries, c, userID, familyID, tokens)
 }
 
-// storeRefreshToken saves the refresh token to the database for tracking
-func (h *AuthHandler) storeRefreshToken(ctx context.Context, userID uuid.UUID, tokens *auth.TokenPair) error {
+// createRefreshToken stores a refresh token with queries, which may be bound
+// to a transaction
+func createRefreshToken(ctx context.Context, queries *sqlc.Queries, c echo.Context, userID, familyID uuid.UUID, tokens *auth.TokenPair) error {
 	// Parse refresh token to get JTI and expiry
 	refreshClaims, err := auth.ValidateToken(tokens.RefreshToken, auth.RefreshToken)
 	if err != nil {
@@ -413,10 +952,13 @@ func (h *AuthHandler) storeRefreshToken(ctx context.Context, userID uuid.UUID, t
 	}
 
 	// Store refresh token
-	_, err = h.queries.CreateRefreshToken(ctx, sqlc.CreateRefreshTokenParams{
+	_, err = queries.CreateRefreshToken(ctx, sqlc.CreateRefreshTokenParams{
 		TokenJti:  refreshClaims.ID,
 		UserID:    userID,
 		ExpiresAt: refreshClaims.ExpiresAt.Time,
+		ClientIp:  encryption.NullString{String: c.RealIP(), Valid: c.RealIP() != ""},
+		UserAgent: sql.NullString{String: c.Request().UserAgent(), Valid: c.Request().UserAgent() != ""},
+		FamilyID:  familyID,
 	})
 	if err != nil {
 		return err
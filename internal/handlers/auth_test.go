@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// withRefreshConfig loads the configuration for signing tokens, with a 10s
+// reuse grace period
+func withRefreshConfig(t *testing.T) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "refresh-test-secret")
+	t.Setenv("REFRESH_TOKEN_REUSE_GRACE", "10s")
+	t.Setenv("LOGIN_EVENT_RETENTION_DAYS", "0")
+	// Settings required in production may be missing; they aren't read here
+	_ = config.Load()
+	t.Cleanup(func() { _ = config.Load() })
+}
+
+// postTokenRefresh posts refreshToken as the refresh_token cookie to a
+// TokenRefresh handler on db
+func postTokenRefresh(t *testing.T, db *sql.DB, refreshToken string) *httptest.ResponseRecorder {
+	t.Helper()
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.POST("/api/v1/auth/refresh", NewAuthHandler(db).TokenRefresh)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+// clearedCookies returns the names of the cookies rec deletes
+func clearedCookies(rec *httptest.ResponseRecorder) []string {
+	var names []string
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.MaxAge < 0 {
+			names = append(names, cookie.Name)
+		}
+	}
+	return names
+}
+
+func TestTokenRefreshReuse(t *testing.T) {
+	withRefreshConfig(t)
+
+	userID := uuid.New()
+	familyID := uuid.New()
+	user := sqlc.User{ID: userID, Username: "ada", Email: "ada@example.com", UserType: "user"}
+
+	tests := []struct {
+		name string
+		// revokedAgo is how long ago the presented token was revoked, or 0
+		// if it is still active
+		revokedAgo    time.Duration
+		revokedReason string
+		// rotated is how many rows the rotation updates, for active tokens
+		rotated       int64
+		wantStatus    int
+		wantError     string
+		wantFamilyCut bool
+		wantCleared   bool
+	}{
+		{
+			name:       "active token is rotated",
+			rotated:    1,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "replayed outside the grace period",
+			revokedAgo:    time.Minute,
+			revokedReason: "refreshed",
+			wantStatus:    http.StatusUnauthorized,
+			wantError:     "refresh token reuse detected",
+			wantFamilyCut: true,
+			wantCleared:   true,
+		},
+		{
+			name:          "replayed within the grace period",
+			revokedAgo:    2 * time.Second,
+			revokedReason: "refreshed",
+			wantStatus:    http.StatusUnauthorized,
+			wantError:     "token has been revoked",
+		},
+		{
+			name:       "lost the race to a concurrent refresh",
+			rotated:    0,
+			wantStatus: http.StatusUnauthorized,
+			wantError:  "token has been revoked",
+		},
+		{
+			name:          "signed out",
+			revokedAgo:    time.Hour,
+			revokedReason: "signed_out",
+			wantStatus:    http.StatusUnauthorized,
+			wantError:     "token has been revoked",
+			wantCleared:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, db := newFakeDB(t)
+			tokens, err := auth.GenerateTokenPair(userID, user.Username, user.Email, user.UserType, uuid.Nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := auth.ValidateToken(tokens.RefreshToken, auth.RefreshToken)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stored := sqlc.Token{ID: uuid.New(), TokenJti: claims.ID, UserID: userID, ExpiresAt: claims.ExpiresAt.Time, FamilyID: familyID}
+			if tt.revokedAgo > 0 {
+				stored.RevokedAt = sql.NullTime{Time: time.Now().Add(-tt.revokedAgo), Valid: true}
+				stored.RevokedReason = sql.NullString{String: tt.revokedReason, Valid: true}
+			}
+			fake.returns("GetRefreshTokenByJTI", stored)
+			fake.returns("GetUserByID", user)
+			fake.affects("RotateRefreshToken", tt.rotated)
+			fake.returns("CreateRefreshToken", sqlc.Token{ID: uuid.New(), UserID: userID, FamilyID: familyID})
+			fake.affects("RevokeRefreshTokenFamily", 3)
+
+			rec := postTokenRefresh(t, db, tokens.RefreshToken)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantError != "" {
+				var resp ErrorResponse
+				if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+					t.Fatal(err)
+				}
+				if resp.Error != tt.wantError {
+					t.Errorf("error = %q, want %q", resp.Error, tt.wantError)
+				}
+			}
+
+			revocations := fake.called("RevokeRefreshTokenFamily")
+			flags := fake.called("FlagUserSecurity")
+			if tt.wantFamilyCut {
+				if len(revocations) != 1 || revocations[0].args[0] != familyID.String() || revocations[0].args[1] != "reuse_detected" {
+					t.Errorf("RevokeRefreshTokenFamily calls = %v, want family %s revoked for reuse", revocations, familyID)
+				}
+				if len(flags) != 1 || flags[0].args[0] != userID.String() {
+					t.Errorf("FlagUserSecurity calls = %v, want user %s flagged", flags, userID)
+				}
+			} else if len(revocations) != 0 || len(flags) != 0 {
+				t.Errorf("family revoked (%v) or user flagged (%v), want neither", revocations, flags)
+			}
+
+			cleared := clearedCookies(rec)
+			if tt.wantCleared != (len(cleared) > 0) {
+				t.Errorf("cleared cookies = %v, want cleared: %v", cleared, tt.wantCleared)
+			}
+
+			if tt.wantStatus == http.StatusOK {
+				created := fake.called("CreateRefreshToken")
+				if len(created) != 1 || created[0].args[5] != familyID.String() {
+					t.Errorf("CreateRefreshToken calls = %v, want the successor in family %s", created, familyID)
+				}
+				if len(fake.called("COMMIT")) != 1 {
+					t.Error("rotation was not committed")
+				}
+			}
+		})
+	}
+}
+
+func TestTokenRefreshKeepsCookiesOnServerError(t *testing.T) {
+	withRefreshConfig(t)
+
+	fake, db := newFakeDB(t)
+	tokens, err := auth.GenerateTokenPair(uuid.New(), "ada", "ada@example.com", "user", uuid.Nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.fails("GetRefreshTokenByJTI", errors.New("connection reset"))
+
+	rec := postTokenRefresh(t, db, tokens.RefreshToken)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+	if cleared := clearedCookies(rec); len(cleared) != 0 {
+		t.Errorf("cleared cookies %v after a server error", cleared)
+	}
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/encryption"
+	"hyperwhisper/internal/logging"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== PROXY AUTHENTICATION EVENTS ==========
+
+// Outcomes of key authentication at the streaming proxy. Clients still get
+// the proxy's usual errors; revoked keys and keys of suspended users are
+// told apart only here.
+const (
+	keyAuthSuccess       = "success"
+	keyAuthMissing       = "missing_key"    // No key was given
+	keyAuthMalformed     = "malformed_key"  // The key can't be one the server issued
+	keyAuthInvalid       = "invalid_key"    // No key has the hash
+	keyAuthRevoked       = "revoked"        // The key was revoked
+	keyAuthSuspended     = "suspended"      // The key's user is suspended
+	keyAuthLocked        = "locked"         // The key is locked after unusual activity
+	keyAuthExpired       = "expired"        // The trial key expired
+	keyAuthQuotaExceeded = "quota_exceeded" // The trial's or organization's quota is used up
+)
+
+const (
+	// authEventQueueSize bounds the events waiting to be written; when the
+	// database falls behind, e.g. during a flood of guessed keys, further
+	// events are dropped rather than slowing the proxy down
+	authEventQueueSize = 1024
+	// authEventPrunePeriod is how often expired events are deleted
+	authEventPrunePeriod = time.Hour
+)
+
+// authEvents queues the events AuthEventWriter persists
+var authEvents = make(chan sqlc.CreateAuthEventParams, authEventQueueSize)
+
+// authEventsDropped counts events dropped since the last report
+var authEventsDropped atomic.Int64
+
+// recordAuthEvent queues the outcome of authenticating a proxy connection
+// with the display prefix of its key and the client it came from. userID is
+// set once the key resolved to its owner. Never blocks the request.
+func recordAuthEvent(c echo.Context, apiKey, outcome string, userID uuid.NullUUID) {
+	if config.Int("AUTH_EVENT_RETENTION_DAYS") <= 0 {
+		return
+	}
+
+	event := sqlc.CreateAuthEventParams{
+		CreatedAt: time.Now(),
+		KeyPrefix: logging.KeyPrefix(apiKey),
+		Outcome:   outcome,
+		UserID:    userID,
+		UserAgent: c.Request().UserAgent(),
+	}
+	// IPs are encrypted at rest; the blind index makes them filterable
+	if ip := c.RealIP(); ip != "" {
+		event.ClientIp = encryption.NullString{String: ip, Valid: true}
+		if hash, err := encryption.BlindIndex(ip); err == nil {
+			event.ClientIpHash = sql.NullString{String: hash, Valid: true}
+		}
+	}
+
+	select {
+	case authEvents <- event:
+	default:
+		authEventsDropped.Add(1)
+	}
+}
+
+// refusedKeyOutcome tells why GetAPIKeyByHash didn't resolve a live or
+// sandbox key, and whose key it is. The extra lookup is skipped while
+// events aren't recorded.
+func refusedKeyOutcome(ctx context.Context, queries *sqlc.Queries, keyHash string) (string, uuid.NullUUID) {
+	if config.Int("AUTH_EVENT_RETENTION_DAYS") <= 0 {
+		return keyAuthInvalid, uuid.NullUUID{}
+	}
+	key, err := queries.GetRefusedAPIKeyByHash(ctx, keyHash)
+	if err != nil {
+		return keyAuthInvalid, uuid.NullUUID{}
+	}
+	owner := uuid.NullUUID{UUID: key.UserID, Valid: true}
+	if key.RevokedAt.Valid {
+		return keyAuthRevoked, owner
+	}
+	return keyAuthSuspended, owner
+}
+
+// AuthEventWriter persists queued proxy authentication events to the
+// auth_events table and deletes them after AUTH_EVENT_RETENTION_DAYS
+type AuthEventWriter struct {
+	queries *sqlc.Queries
+}
+
+// NewAuthEventWriter creates a writer; Run must be started to write events
+func NewAuthEventWriter(db *sql.DB) *AuthEventWriter {
+	return &AuthEventWriter{queries: sqlc.New(db)}
+}
+
+// Run writes queued events and prunes expired ones until ctx is cancelled
+func (w *AuthEventWriter) Run(ctx context.Context) {
+	w.prune(ctx)
+	ticker := time.NewTicker(authEventPrunePeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-authEvents:
+			if err := w.queries.CreateAuthEvent(ctx, event); err != nil {
+				log.Printf("[Auth Events] Failed to write %s event: %v", event.Outcome, err)
+			}
+		case <-ticker.C:
+			w.prune(ctx)
+			if n := authEventsDropped.Swap(0); n > 0 {
+				log.Printf("[Auth Events] Dropped %d authentication events, the database could not keep up", n)
+			}
+		}
+	}
+}
+
+// prune deletes events older than the retention
+func (w *AuthEventWriter) prune(ctx context.Context) {
+	retention := config.Int("AUTH_EVENT_RETENTION_DAYS")
+	if retention <= 0 {
+		return
+	}
+	cutoff := time.Now().AddDate(0, 0, -retention)
+	if err := w.queries.DeleteExpiredAuthEvents(ctx, cutoff); err != nil {
+		log.Printf("[Auth Events] Failed to delete expired events: %v", err)
+	}
+}
+
+// AuthEventResponse is an authentication attempt at the streaming proxy
+type AuthEventResponse struct {
+	ID        string  `json:"id"`
+	CreatedAt string  `json:"created_at"`
+	KeyPrefix string  `json:"key_prefix"`
+	Outcome   string  `json:"outcome"`
+	UserID    *string `json:"user_id"`
+	Username  *string `json:"username"`
+	ClientIP  *string `json:"client_ip"`
+	UserAgent string  `json:"user_agent"`
+}
+
+// ListAuthEvents returns authentication attempts at the streaming proxy,
+// newest first (admin only). Filters: from/to (RFC 3339, default everything
+// retained), user_id, ip, key_prefix (matches prefixes starting with it, so
+// hw_trial_ lists trial keys), outcome, and succeeded (true or false).
+func (h *AdminHandler) ListAuthEvents(c echo.Context) error {
+	page, perPage, offset := getPaginationParams(c)
+
+	var start time.Time
+	end := time.Now()
+	if v := c.QueryParam("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return apiError(http.StatusBadRequest, "invalid from, expected RFC 3339")
+		}
+		start = t
+	}
+	if v := c.QueryParam("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return apiError(http.StatusBadRequest, "invalid to, expected RFC 3339")
+		}
+		end = t
+	}
+
+	var userID uuid.NullUUID
+	if v := c.QueryParam("user_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return apiError(http.StatusBadRequest, "invalid user ID")
+		}
+		userID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	var ipHash sql.NullString
+	if v := c.QueryParam("ip"); v != "" {
+		hash, err := encryption.BlindIndex(v)
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "encryption not configured")
+		}
+		ipHash = sql.NullString{String: hash, Valid: true}
+	}
+
+	var succeeded sql.NullBool
+	switch c.QueryParam("succeeded") {
+	case "":
+	case "true":
+		succeeded = sql.NullBool{Bool: true, Valid: true}
+	case "false":
+		succeeded = sql.NullBool{Bool: false, Valid: true}
+	default:
+		return apiError(http.StatusBadRequest, "invalid succeeded, expected true or false")
+	}
+	keyPrefix := c.QueryParam("key_prefix")
+	outcome := c.QueryParam("outcome")
+
+	ctx := context.Background()
+	total, err := h.queries.CountAuthEvents(ctx, sqlc.CountAuthEventsParams{
+		StartDate:    start,
+		EndDate:      end,
+		UserID:       userID,
+		ClientIpHash: ipHash,
+		KeyPrefix:    sql.NullString{String: keyPrefix, Valid: keyPrefix != ""},
+		Outcome:      sql.NullString{String: outcome, Valid: outcome != ""},
+		Succeeded:    succeeded,
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	events, err := h.queries.ListAuthEvents(ctx, sqlc.ListAuthEventsParams{
+		StartDate:    start,
+		EndDate:      end,
+		UserID:       userID,
+		ClientIpHash: ipHash,
+		KeyPrefix:    sql.NullString{String: keyPrefix, Valid: keyPrefix != ""},
+		Outcome:      sql.NullString{String: outcome, Valid: outcome != ""},
+		Succeeded:    succeeded,
+		PageLimit:    int32(perPage),
+		PageOffset:   int32(offset),
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	responses := make([]AuthEventResponse, len(events))
+	for i, e := range events {
+		responses[i] = AuthEventResponse{
+			ID:        e.ID.String(),
+			CreatedAt: e.CreatedAt.Format(time.RFC3339),
+			KeyPrefix: e.KeyPrefix,
+			Outcome:   e.Outcome,
+			UserID:    nullUUIDPtr(e.UserID),
+			Username:  nullStringPtr(e.Username),
+			UserAgent: e.UserAgent,
+		}
+		if e.ClientIp.Valid {
+			responses[i].ClientIP = &e.ClientIp.String
+		}
+	}
+
+	return c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       responses,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: calculateTotalPages(total, perPage),
+	})
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+)
+
+// ========== PUBLIC USAGE BADGE ==========
+
+// badgeMinutesGranularity is what the badge's minutes are rounded down to,
+// so it can't be used to watch individual sessions
+const badgeMinutesGranularity = 100
+
+// processStarted is when this server process started, for the badge's
+// uptime
+var processStarted = time.Now()
+
+// UsageBadgeResponse is the public usage badge
+type UsageBadgeResponse struct {
+	MinutesTranscribed int64  `json:"minutes_transcribed"` // Rounded down to hundreds
+	UptimeHours        int64  `json:"uptime_hours"`        // Of the answering instance
+	UpdatedAt          string `json:"updated_at"`
+}
+
+// UsageBadgeHandler serves coarse aggregate stats for the marketing site
+type UsageBadgeHandler struct {
+	queries *sqlc.Queries
+
+	// The stats are computed at most once per USAGE_BADGE_CACHE_TTL;
+	// requests arriving meanwhile wait for the one computing them
+	mu        sync.Mutex
+	badge     UsageBadgeResponse
+	expiresAt time.Time
+}
+
+// NewUsageBadgeHandler creates a new usage badge handler
+func NewUsageBadgeHandler(db *sql.DB) *UsageBadgeHandler {
+	return &UsageBadgeHandler{queries: sqlc.New(db)}
+}
+
+// UsageBadgeRateLimiter limits badge requests per client IP to
+// USAGE_BADGE_RATE_LIMIT per minute
+func UsageBadgeRateLimiter() echo.MiddlewareFunc {
+	perMinute := config.Int("USAGE_BADGE_RATE_LIMIT")
+	return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Store: middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Limit(float64(perMinute) / 60),
+			Burst:     perMinute,
+			ExpiresIn: 10 * time.Minute,
+		}),
+		IdentifierExtractor: func(c echo.Context) (string, error) {
+			return c.RealIP(), nil
+		},
+		DenyHandler: func(c echo.Context, identifier string, err error) error {
+			return apiError(http.StatusTooManyRequests, "too many requests, slow down")
+		},
+	})
+}
+
+// GetUsageBadge returns the total minutes transcribed and the uptime,
+// readable from any origin. Minutes come from the monthly usage rollups, so
+// a refresh costs one small query; in between the cached stats are served,
+// and kept when refreshing fails.
+func (h *UsageBadgeHandler) GetUsageBadge(c echo.Context) error {
+	ttl := config.Duration("USAGE_BADGE_CACHE_TTL")
+
+	h.mu.Lock()
+	if time.Now().After(h.expiresAt) {
+		if err := h.refresh(ttl); err != nil && h.badge.UpdatedAt == "" {
+			h.mu.Unlock()
+			log.Printf("[Badge] Failed to compute usage badge: %v", err)
+			return apiError(http.StatusServiceUnavailable, "usage stats unavailable")
+		} else if err != nil {
+			log.Printf("[Badge] Failed to refresh usage badge, serving the cached one: %v", err)
+		}
+	}
+	badge := h.badge
+	h.mu.Unlock()
+
+	c.Response().Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(ttl.Seconds())))
+	c.Response().Header().Set("Access-Control-Allow-Origin", "*")
+	return c.JSON(http.StatusOK, badge)
+}
+
+// refresh recomputes the badge; the caller holds h.mu
+func (h *UsageBadgeHandler) refresh(ttl time.Duration) error {
+	// Retry failures after a minute rather than on every request
+	h.expiresAt = time.Now().Add(min(ttl, time.Minute))
+
+	totals, err := h.queries.GetUsageRollupTotals(context.Background())
+	if err != nil {
+		return err
+	}
+
+	minutes := int64(parseDecimalString(totals.TotalDurationSeconds) / 60)
+	h.badge = UsageBadgeResponse{
+		MinutesTranscribed: minutes - minutes%badgeMinutesGranularity,
+		UptimeHours:        int64(time.Since(processStarted).Hours()),
+		UpdatedAt:          time.Now().UTC().Format(time.RFC3339),
+	}
+	h.expiresAt = time.Now().Add(ttl)
+	return nil
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+
+	"hyperwhisper/internal/config"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== REQUEST BODY LIMITS ==========
+
+// BodyLimitMiddleware caps request bodies at the number of bytes in the named
+// setting (e.g. BODY_LIMIT_AUTH), read per request so a changed limit applies
+// without a restart. Where limits are stacked, the smallest wins.
+func BodyLimitMiddleware(setting string) echo.MiddlewareFunc {
+	return limitBody(func() int64 { return int64(config.Int(setting)) })
+}
+
+// BodyLimit caps request bodies at a fixed number of bytes
+func BodyLimit(maxBytes int64) echo.MiddlewareFunc {
+	return limitBody(func() int64 { return maxBytes })
+}
+
+// limitBody answers oversized bodies with 413 before the handler runs, so
+// handlers never see a truncated body they would report as malformed. A
+// declared Content-Length is checked up front (the HTTP server enforces it
+// while reading); bodies of unknown length are buffered up to the limit.
+func limitBody(limit func() int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.Body == nil || req.Body == http.NoBody {
+				return next(c)
+			}
+
+			maxBytes := limit()
+			if req.ContentLength > maxBytes {
+				return bodyTooLarge(c, maxBytes)
+			}
+			if req.ContentLength < 0 {
+				body, err := io.ReadAll(io.LimitReader(req.Body, maxBytes+1))
+				req.Body.Close()
+				if err != nil {
+					return apiError(http.StatusBadRequest, "invalid request body")
+				}
+				if int64(len(body)) > maxBytes {
+					return bodyTooLarge(c, maxBytes)
+				}
+				req.Body = io.NopCloser(bytes.NewReader(body))
+				req.ContentLength = int64(len(body))
+			}
+			return next(c)
+		}
+	}
+}
+
+func bodyTooLarge(c echo.Context, maxBytes int64) error {
+	// The rest of the body is not read, so don't keep the connection
+	c.Response().Header().Set(echo.HeaderConnection, "close")
+	return newAPIError(http.StatusRequestEntityTooLarge, ErrorResponse{
+		Error:   "request body too large",
+		Details: map[string]string{"max_bytes": strconv.FormatInt(maxBytes, 10)},
+	})
+}
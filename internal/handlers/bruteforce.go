@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/redis"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== BRUTE-FORCE PROTECTION ==========
+
+// Failed attempts on the auth endpoints are counted per client IP and per
+// identifier (the account, email, refresh token or device named in the
+// request, or the signed-in user guessing device user codes). Past the free
+// attempts, each further failure locks the key out for twice as long as the
+// one before, up to AUTH_RATE_LIMIT_MAX_DELAY.
+// Counts live in memory, or in Redis when REDIS_URL is set so every
+// instance shares them.
+
+// authRateLimitRule says how an endpoint's attempts are identified and
+// counted
+type authRateLimitRule struct {
+	// identifier returns what the request names, or "" if nothing
+	identifier func(c echo.Context, body []byte) string
+	// countSuccess counts every attempt, for endpoints that create things;
+	// otherwise only failures count and a success clears the identifier's
+	countSuccess bool
+}
+
+// authRateLimitRules are the endpoints AuthRateLimiter can guard
+var authRateLimitRules = map[string]authRateLimitRule{
+	"signin":          {identifier: jsonField("identifier")},
+	"signup":          {identifier: jsonField("email"), countSuccess: true},
+	"token_refresh":   {identifier: refreshTokenIdentifier},
+	"trial_provision": {identifier: jsonField("device_fingerprint"), countSuccess: true},
+	// Unknown user codes fail, so guessing another device's is throttled
+	"device_verification": {identifier: callerIdentifier},
+}
+
+// attemptStore keeps failure counts and lockouts, which expire on their own
+type attemptStore interface {
+	// lockedFor returns how much longer key is locked out
+	lockedFor(ctx context.Context, key string) (time.Duration, error)
+	// fail counts a failure of key, forgotten after window, and returns
+	// the failures within it
+	fail(ctx context.Context, key string, window time.Duration) (int64, error)
+	// lock locks key out for d
+	lock(ctx context.Context, key string, d time.Duration) error
+	// reset forgets the failures of key
+	reset(ctx context.Context, key string) error
+}
+
+var authAttempts struct {
+	once  sync.Once
+	store attemptStore
+}
+
+// authAttemptStore returns the Redis store when REDIS_URL is set, and
+// otherwise the in-memory one. It is chosen once, at first use.
+func authAttemptStore() attemptStore {
+	authAttempts.once.Do(func() {
+		memory := newMemoryAttempts()
+		authAttempts.store = memory
+		if url := config.String("REDIS_URL"); url != "" {
+			client, err := redis.New(url, 2*time.Second)
+			if err != nil {
+				log.Printf("[RateLimit] Invalid REDIS_URL, counting attempts in memory: %v", err)
+				return
+			}
+			authAttempts.store = &redisAttempts{client: client, fallback: memory}
+		}
+	})
+	return authAttempts.store
+}
+
+// attemptKey is a counter checked for a request, with its free attempts
+type attemptKey struct {
+	key  string
+	free int
+}
+
+// AuthRateLimiter guards an auth endpoint ("signin", "signup",
+// "token_refresh", "trial_provision" or "device_verification") against brute
+// force. Locked-out requests get 429 with Retry-After.
+func AuthRateLimiter(endpoint string) echo.MiddlewareFunc {
+	rule, ok := authRateLimitRules[endpoint]
+	if !ok {
+		panic(fmt.Sprintf("handlers: no rate limit rule for %q", endpoint))
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			perIP := config.Int("AUTH_RATE_LIMIT_IP_ATTEMPTS")
+			perIdentifier := config.Int("AUTH_RATE_LIMIT_ATTEMPTS")
+			if perIP == 0 && perIdentifier == 0 {
+				return next(c)
+			}
+
+			var keys []attemptKey
+			var identifierKey string
+			if perIP > 0 {
+				keys = append(keys, attemptKey{key: "auth:" + endpoint + ":ip:" + c.RealIP(), free: perIP})
+			}
+			if perIdentifier > 0 {
+				// The handler reads the body again
+				body, err := io.ReadAll(c.Request().Body)
+				if err != nil {
+					return apiError(http.StatusBadRequest, "invalid request body")
+				}
+				c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+				if id := rule.identifier(c, body); id != "" {
+					// Hashed, so emails and tokens aren't kept in the store
+					identifierKey = "auth:" + endpoint + ":id:" + hashAPIKey(strings.ToLower(id))
+					keys = append(keys, attemptKey{key: identifierKey, free: perIdentifier})
+				}
+			}
+
+			ctx := c.Request().Context()
+			store := authAttemptStore()
+
+			var wait time.Duration
+			for _, k := range keys {
+				d, err := store.lockedFor(ctx, k.key)
+				if err != nil {
+					log.Printf("[RateLimit] Failed to check %s: %v", endpoint, err)
+					continue
+				}
+				wait = max(wait, d)
+			}
+			if wait > 0 {
+				log.Printf("[RateLimit] %s from %s refused, locked out for %v", endpoint, c.RealIP(), wait.Round(time.Second))
+				c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				return apiError(http.StatusTooManyRequests, "too many attempts, try again later")
+			}
+
+			err := next(c)
+
+			status := c.Response().Status
+			var apiErr *APIError
+			var httpErr *echo.HTTPError
+			switch {
+			case errors.As(err, &apiErr):
+				status = apiErr.Status
+			case errors.As(err, &httpErr):
+				status = httpErr.Code
+			case err != nil:
+				status = http.StatusInternalServerError
+			}
+
+			// Server errors say nothing about the attempt
+			switch {
+			case status >= 500:
+				return err
+			case status < 400 && !rule.countSuccess:
+				if identifierKey != "" {
+					if rerr := store.reset(ctx, identifierKey); rerr != nil {
+						log.Printf("[RateLimit] Failed to reset %s: %v", endpoint, rerr)
+					}
+				}
+				return err
+			}
+
+			window := config.Duration("AUTH_RATE_LIMIT_WINDOW")
+			for _, k := range keys {
+				failures, ferr := store.fail(ctx, k.key, window)
+				if ferr != nil {
+					log.Printf("[RateLimit] Failed to count %s attempt: %v", endpoint, ferr)
+					continue
+				}
+				if failures <= int64(k.free) {
+					continue
+				}
+				if lerr := store.lock(ctx, k.key, authBackoff(failures-int64(k.free))); lerr != nil {
+					log.Printf("[RateLimit] Failed to lock out %s: %v", endpoint, lerr)
+				}
+			}
+			return err
+		}
+	}
+}
+
+// authBackoff is the lockout after the nth attempt past the free ones:
+// AUTH_RATE_LIMIT_BASE_DELAY, doubled with every further attempt
+func authBackoff(n int64) time.Duration {
+	base := config.Duration("AUTH_RATE_LIMIT_BASE_DELAY")
+	limit := config.Duration("AUTH_RATE_LIMIT_MAX_DELAY")
+	if n > 32 {
+		return limit
+	}
+	return min(base*time.Duration(1<<(n-1)), limit)
+}
+
+// jsonField identifies requests by a string field of their JSON body
+func jsonField(name string) func(echo.Context, []byte) string {
+	return func(c echo.Context, body []byte) string {
+		var fields map[string]any
+		if json.Unmarshal(body, &fields) != nil {
+			return ""
+		}
+		value, _ := fields[name].(string)
+		return strings.TrimSpace(value)
+	}
+}
+
+// refreshTokenIdentifier identifies refreshes by their token, taken from the
+// cookie or body like TokenRefresh does
+func refreshTokenIdentifier(c echo.Context, body []byte) string {
+	if cookie, err := c.Cookie("refresh_token"); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	return jsonField("refresh_token")(c, body)
+}
+
+// callerIdentifier identifies requests by the signed-in user
+func callerIdentifier(c echo.Context, _ []byte) string {
+	if claims := auth.GetUserFromContext(c); claims != nil {
+		return claims.UserID.String()
+	}
+	return ""
+}
+
+// ========== ATTEMPT STORES ==========
+
+// memoryAttempts keeps attempts in this process
+type memoryAttempts struct {
+	mu      sync.Mutex
+	entries map[string]*attemptEntry
+	swept   time.Time
+}
+
+type attemptEntry struct {
+	failures    int64
+	forgetAt    time.Time // When the failures expire
+	lockedUntil time.Time
+}
+
+// memorySweepInterval is how often expired entries are dropped
+const memorySweepInterval = time.Minute
+
+func newMemoryAttempts() *memoryAttempts {
+	return &memoryAttempts{entries: make(map[string]*attemptEntry)}
+}
+
+func (m *memoryAttempts) lockedFor(ctx context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.entries[key]; ok {
+		return max(time.Until(e.lockedUntil), 0), nil
+	}
+	return 0, nil
+}
+
+func (m *memoryAttempts) fail(ctx context.Context, key string, window time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.sweep(now)
+	e, ok := m.entries[key]
+	if !ok {
+		e = &attemptEntry{}
+		m.entries[key] = e
+	}
+	if now.After(e.forgetAt) {
+		e.failures = 0
+	}
+	e.failures++
+	e.forgetAt = now.Add(window)
+	return e.failures, nil
+}
+
+func (m *memoryAttempts) lock(ctx context.Context, key string, d time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.entries[key]; ok {
+		e.lockedUntil = time.Now().Add(d)
+	}
+	return nil
+}
+
+func (m *memoryAttempts) reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+// sweep drops entries whose failures and lockout have expired; the caller
+// holds m.mu
+func (m *memoryAttempts) sweep(now time.Time) {
+	if now.Sub(m.swept) < memorySweepInterval {
+		return
+	}
+	m.swept = now
+	for key, e := range m.entries {
+		if now.After(e.forgetAt) && now.After(e.lockedUntil) {
+			delete(m.entries, key)
+		}
+	}
+}
+
+// redisAttempts keeps attempts in Redis, shared by every instance. While
+// Redis can't be reached, attempts are counted in memory instead.
+type redisAttempts struct {
+	client   *redis.Client
+	fallback *memoryAttempts
+}
+
+// redisFailScript counts a failure and restarts its expiry atomically
+const redisFailScript = `local n = redis.call('INCR', KEYS[1]) redis.call('PEXPIRE', KEYS[1], ARGV[1]) return n`
+
+func (r *redisAttempts) lockedFor(ctx context.Context, key string) (time.Duration, error) {
+	reply, err := r.client.Do(ctx, "PTTL", key+":lock")
+	if err != nil {
+		log.Printf("[RateLimit] Redis unavailable, checking in memory: %v", err)
+		return r.fallback.lockedFor(ctx, key)
+	}
+	ms, _ := reply.(int64)
+	return time.Duration(max(ms, 0)) * time.Millisecond, nil
+}
+
+func (r *redisAttempts) fail(ctx context.Context, key string, window time.Duration) (int64, error) {
+	reply, err := r.client.Do(ctx, "EVAL", redisFailScript, "1", key+":failures", strconv.FormatInt(window.Milliseconds(), 10))
+	if err != nil {
+		log.Printf("[RateLimit] Redis unavailable, counting in memory: %v", err)
+		return r.fallback.fail(ctx, key, window)
+	}
+	failures, _ := reply.(int64)
+	return failures, nil
+}
+
+func (r *redisAttempts) lock(ctx context.Context, key string, d time.Duration) error {
+	if _, err := r.client.Do(ctx, "SET", key+":lock", "1", "PX", strconv.FormatInt(max(d.Milliseconds(), 1), 10)); err != nil {
+		log.Printf("[RateLimit] Redis unavailable, locking in memory: %v", err)
+		return r.fallback.lock(ctx, key, d)
+	}
+	return nil
+}
+
+func (r *redisAttempts) reset(ctx context.Context, key string) error {
+	if _, err := r.client.Do(ctx, "DEL", key+":failures"); err != nil {
+		return err
+	}
+	return r.fallback.reset(ctx, key)
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hyperwhisper/internal/config"
+
+	"github.com/labstack/echo/v4"
+)
+
+// withAuthRateLimits loads the configuration with the given free attempts
+// per identifier and per client IP
+func withAuthRateLimits(t *testing.T, attempts, ipAttempts string) {
+	t.Helper()
+	t.Setenv("AUTH_RATE_LIMIT_ATTEMPTS", attempts)
+	t.Setenv("AUTH_RATE_LIMIT_IP_ATTEMPTS", ipAttempts)
+	t.Setenv("AUTH_RATE_LIMIT_BASE_DELAY", "1m")
+	t.Setenv("AUTH_RATE_LIMIT_MAX_DELAY", "15m")
+	t.Setenv("AUTH_RATE_LIMIT_WINDOW", "15m")
+	t.Setenv("REDIS_URL", "")
+	// Settings required in production may be missing; they aren't read here
+	_ = config.Load()
+	resetAuthAttempts()
+	t.Cleanup(func() {
+		_ = config.Load()
+		resetAuthAttempts()
+	})
+}
+
+// resetAuthAttempts forgets every attempt, and the store they were kept in
+func resetAuthAttempts() {
+	authAttempts.once = sync.Once{}
+	authAttempts.store = nil
+}
+
+// newRateLimitedServer guards a handler that answers status with endpoint's
+// rule. The handler sees the body, which the limiter read first.
+func newRateLimitedServer(t *testing.T, endpoint string, status func(body string) int) *echo.Echo {
+	t.Helper()
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.POST("/", func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		if code := status(string(body)); code >= 400 {
+			return apiError(code, http.StatusText(code))
+		}
+		return c.NoContent(http.StatusOK)
+	}, AuthRateLimiter(endpoint))
+	return e
+}
+
+// attempt posts body from ip and returns the response
+func attempt(e *echo.Echo, ip, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderXRealIP, ip)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthBackoff(t *testing.T) {
+	withAuthRateLimits(t, "5", "20")
+
+	tests := []struct {
+		n    int64
+		want time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{5, 15 * time.Minute},
+		{33, 15 * time.Minute},
+		{100, 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := authBackoff(tt.n); got != tt.want {
+			t.Errorf("authBackoff(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestAuthRateLimiterLocksOutIdentifier(t *testing.T) {
+	withAuthRateLimits(t, "3", "0")
+	e := newRateLimitedServer(t, "signin", func(body string) int {
+		if strings.Contains(body, `"password":"right"`) {
+			return http.StatusOK
+		}
+		return http.StatusUnauthorized
+	})
+
+	wrong := `{"identifier":"lockout@example.com","password":"wrong"}`
+	for i := 1; i <= 4; i++ {
+		if rec := attempt(e, "203.0.113.1", wrong); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status = %d, want 401", i, rec.Code)
+		}
+	}
+
+	// The fourth failure was past the free attempts, so even the right
+	// password is refused now, from any IP and however the email is written
+	rec := attempt(e, "198.51.100.1", `{"identifier":" Lockout@Example.com ","password":"right"}`)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, want %q", got, "60")
+	}
+
+	if rec := attempt(e, "203.0.113.1", `{"identifier":"other@example.com","password":"right"}`); rec.Code != http.StatusOK {
+		t.Errorf("other account: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestAuthRateLimiterSuccessResets(t *testing.T) {
+	withAuthRateLimits(t, "3", "0")
+	e := newRateLimitedServer(t, "signin", func(body string) int {
+		if strings.Contains(body, `"password":"right"`) {
+			return http.StatusOK
+		}
+		return http.StatusUnauthorized
+	})
+
+	wrong := `{"identifier":"reset@example.com","password":"wrong"}`
+	for range 3 {
+		attempt(e, "203.0.113.2", wrong)
+	}
+	if rec := attempt(e, "203.0.113.2", `{"identifier":"reset@example.com","password":"right"}`); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	for i := 1; i <= 3; i++ {
+		if rec := attempt(e, "203.0.113.2", wrong); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d after a success: status = %d, want 401", i, rec.Code)
+		}
+	}
+}
+
+func TestAuthRateLimiterIgnoresServerErrors(t *testing.T) {
+	withAuthRateLimits(t, "1", "1")
+	e := newRateLimitedServer(t, "signin", func(string) int { return http.StatusServiceUnavailable })
+
+	for i := 1; i <= 5; i++ {
+		if rec := attempt(e, "203.0.113.3", `{"identifier":"outage@example.com"}`); rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("attempt %d: status = %d, want 503", i, rec.Code)
+		}
+	}
+}
+
+func TestAuthRateLimiterCountsSuccesses(t *testing.T) {
+	withAuthRateLimits(t, "0", "2")
+	e := newRateLimitedServer(t, "signup", func(string) int { return http.StatusOK })
+
+	for i := 1; i <= 3; i++ {
+		body := `{"email":"user` + string(rune('0'+i)) + `@example.com"}`
+		if rec := attempt(e, "203.0.113.4", body); rec.Code != http.StatusOK {
+			t.Fatalf("signup %d: status = %d, want 200", i, rec.Code)
+		}
+	}
+	if rec := attempt(e, "203.0.113.4", `{"email":"user4@example.com"}`); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", rec.Code)
+	}
+	if rec := attempt(e, "203.0.113.5", `{"email":"user5@example.com"}`); rec.Code != http.StatusOK {
+		t.Errorf("other IP: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestMemoryAttempts(t *testing.T) {
+	ctx := t.Context()
+	m := newMemoryAttempts()
+
+	for want := int64(1); want <= 3; want++ {
+		if got, _ := m.fail(ctx, "k", time.Hour); got != want {
+			t.Fatalf("fail = %d, want %d", got, want)
+		}
+	}
+
+	// Failures older than the window are forgotten
+	m.entries["k"].forgetAt = time.Now().Add(-time.Second)
+	if got, _ := m.fail(ctx, "k", time.Hour); got != 1 {
+		t.Errorf("fail after the window = %d, want 1", got)
+	}
+
+	if d, _ := m.lockedFor(ctx, "k"); d != 0 {
+		t.Errorf("lockedFor before lock = %v, want 0", d)
+	}
+	_ = m.lock(ctx, "k", time.Minute)
+	if d, _ := m.lockedFor(ctx, "k"); d <= 59*time.Second || d > time.Minute {
+		t.Errorf("lockedFor = %v, want about a minute", d)
+	}
+
+	_ = m.reset(ctx, "k")
+	if d, _ := m.lockedFor(ctx, "k"); d != 0 {
+		t.Errorf("lockedFor after reset = %v, want 0", d)
+	}
+
+	// Expired entries are swept
+	_, _ = m.fail(ctx, "old", time.Hour)
+	m.entries["old"].forgetAt = time.Now().Add(-time.Second)
+	m.swept = time.Time{}
+	_, _ = m.fail(ctx, "new", time.Hour)
+	if _, ok := m.entries["old"]; ok {
+		t.Error("expired entry was not swept")
+	}
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/labstack/echo/v4"
+)
+
+// budgetRefreshInterval is how long the month's spend estimate is cached
+// before the usage tables are summed again
+const budgetRefreshInterval = 30 * time.Second
+
+// SpendGuard enforces DEEPGRAM_MONTHLY_BUDGET on the shared Deepgram account
+// and raises soft usage alerts at DEEPGRAM_ALERT_THRESHOLDS of
+// DEEPGRAM_ALERT_BUDGET. Spend is estimated from the duration of finished
+// sessions (live and trial) in the current UTC month at
+// DEEPGRAM_COST_PER_MINUTE, so sessions still in progress can overshoot the
+// budget slightly.
+type SpendGuard struct {
+	mu        sync.Mutex
+	status    BudgetStatus
+	checkedAt time.Time
+	alerted   string       // Month the operators were last alerted for
+	warned    map[int]bool // Alert thresholds already announced for status.Month
+}
+
+// BudgetStatus is the current month's upstream spend against the budget
+type BudgetStatus struct {
+	Month          string  `json:"month"`
+	Budget         float64 `json:"budget"`
+	EstimatedSpend float64 `json:"estimated_spend"`
+	UsageSeconds   float64 `json:"usage_seconds"`
+	CostPerMinute  float64 `json:"cost_per_minute"`
+	Exceeded       bool    `json:"exceeded"`
+	ResetsAt       string  `json:"resets_at"`
+
+	// Soft usage alerts; the forecast extrapolates the month-to-date burn
+	// rate to the whole month
+	AlertBudget       float64 `json:"alert_budget"`
+	BurnRatePerDay    float64 `json:"burn_rate_per_day"`
+	ForecastSpend     float64 `json:"forecast_spend"`
+	ThresholdsCrossed []int   `json:"thresholds_crossed"`
+}
+
+// Budget is the process-wide spend guard used by all proxy handlers
+var Budget = &SpendGuard{}
+
+// Exceeded reports whether new sessions on the shared Deepgram key must be
+// refused. A zero budget disables the cut-off; database errors fail open so
+// an outage of the usage tables does not stop transcription. The checks also
+// keep the usage alerts current, so they run while only an alert budget is
+// set.
+func (g *SpendGuard) Exceeded(queries *sqlc.Queries) (BudgetStatus, bool) {
+	if config.Float("DEEPGRAM_MONTHLY_BUDGET") <= 0 && alertBudget() <= 0 {
+		return BudgetStatus{}, false
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now().UTC()
+	if now.Sub(g.checkedAt) >= budgetRefreshInterval || g.status.Month != now.Format("2006-01") {
+		if err := g.refresh(context.Background(), queries, now); err != nil {
+			log.Printf("[Budget] Failed to estimate upstream spend: %v", err)
+			return g.status, false
+		}
+	}
+	return g.status, g.status.Exceeded
+}
+
+// Status recomputes and returns the current month's spend
+func (g *SpendGuard) Status(ctx context.Context, queries *sqlc.Queries) (BudgetStatus, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	err := g.refresh(ctx, queries, time.Now().UTC())
+	return g.status, err
+}
+
+// refresh sums the month's usage and alerts operators the first time the
+// budget or an alert threshold is crossed in a month. Callers hold g.mu.
+func (g *SpendGuard) refresh(ctx context.Context, queries *sqlc.Queries, now time.Time) error {
+	period := monthPeriod(now, time.UTC)
+
+	total, err := queries.GetUpstreamUsageSeconds(ctx, sqlc.GetUpstreamUsageSecondsParams{
+		StartDate: period.Start,
+		EndDate:   period.End,
+	})
+	if err != nil {
+		return err
+	}
+
+	seconds := parseDecimalString(total)
+	budget := config.Float("DEEPGRAM_MONTHLY_BUDGET")
+	costPerMinute := config.Float("DEEPGRAM_COST_PER_MINUTE")
+	spend := seconds / 60 * costPerMinute
+
+	var burnRate, forecast float64
+	if elapsed := now.Sub(period.Start); elapsed > 0 {
+		burnRate = spend / elapsed.Hours() * 24
+		forecast = spend / elapsed.Hours() * period.End.Sub(period.Start).Hours()
+	}
+
+	alertAt := alertBudget()
+	crossed := []int{}
+	if alertAt > 0 {
+		for _, pct := range alertThresholds() {
+			if spend >= alertAt*float64(pct)/100 {
+				crossed = append(crossed, pct)
+			}
+		}
+	}
+
+	previousMonth := g.status.Month
+	g.status = BudgetStatus{
+		Month:          period.Start.Format("2006-01"),
+		Budget:         budget,
+		EstimatedSpend: spend,
+		UsageSeconds:   seconds,
+		CostPerMinute:  costPerMinute,
+		Exceeded:       budget > 0 && spend >= budget,
+		ResetsAt:       period.End.Format(time.RFC3339),
+
+		AlertBudget:       alertAt,
+		BurnRatePerDay:    burnRate,
+		ForecastSpend:     forecast,
+		ThresholdsCrossed: crossed,
+	}
+	g.checkedAt = now
+
+	if g.status.Exceeded && g.alerted != g.status.Month {
+		g.alerted = g.status.Month
+		alertBudgetExceeded(g.status)
+	}
+
+	// Announce only the highest newly crossed threshold, so a restart late in
+	// the month does not replay every lower one
+	if g.warned == nil || previousMonth != g.status.Month {
+		g.warned = make(map[int]bool)
+	}
+	highest := 0
+	for _, pct := range crossed {
+		if !g.warned[pct] {
+			g.warned[pct] = true
+			highest = pct
+		}
+	}
+	if highest > 0 {
+		alertUsageThreshold(g.status, highest)
+	}
+	return nil
+}
+
+// alertBudget is the monthly spend the usage alerts are measured against
+func alertBudget() float64 {
+	if budget := config.Float("DEEPGRAM_ALERT_BUDGET"); budget > 0 {
+		return budget
+	}
+	return config.Float("DEEPGRAM_MONTHLY_BUDGET")
+}
+
+// alertThresholds parses DEEPGRAM_ALERT_THRESHOLDS into ascending percentages
+func alertThresholds() []int {
+	var thresholds []int
+	for _, part := range strings.Split(config.String("DEEPGRAM_ALERT_THRESHOLDS"), ",") {
+		if pct, err := strconv.Atoi(strings.TrimSpace(part)); err == nil && pct > 0 {
+			thresholds = append(thresholds, pct)
+		}
+	}
+	sort.Ints(thresholds)
+	return thresholds
+}
+
+// alertBudgetExceeded logs the cut-off and posts it to the alert webhook
+func alertBudgetExceeded(status BudgetStatus) {
+	text := fmt.Sprintf("HyperWhisper: Deepgram budget for %s exhausted (estimated %.2f of %.2f). New transcription sessions are refused until %s.",
+		status.Month, status.EstimatedSpend, status.Budget, status.ResetsAt)
+	log.Printf("[Budget] ALERT: %s", text)
+	postBudgetWebhook(text)
+}
+
+// alertUsageThreshold announces a crossed soft alert threshold on the event
+// bus, the log and BUDGET_ALERT_WEBHOOK_URL. Sessions are not affected.
+func alertUsageThreshold(status BudgetStatus, pct int) {
+	text := fmt.Sprintf("HyperWhisper: Deepgram usage for %s reached %d%% of the alert budget (estimated %.2f of %.2f, %.2f/day, forecast %.2f for the month).",
+		status.Month, pct, status.EstimatedSpend, status.AlertBudget, status.BurnRatePerDay, status.ForecastSpend)
+	log.Printf("[Budget] ALERT: %s", text)
+
+	Events.Publish(Event{
+		Type:      EventBudgetAlert,
+		Month:     status.Month,
+		Threshold: pct,
+		Spend:     &status.EstimatedSpend,
+		Budget:    &status.AlertBudget,
+		Forecast:  &status.ForecastSpend,
+	})
+	postBudgetWebhook(text)
+}
+
+// postBudgetWebhook posts an alert to BUDGET_ALERT_WEBHOOK_URL
+// (Slack-compatible {"text": ...} payload) if one is configured
+func postBudgetWebhook(text string) {
+	url := config.String("BUDGET_ALERT_WEBHOOK_URL")
+	if url == "" {
+		return
+	}
+	go func() {
+		body, _ := json.Marshal(map[string]string{"text": text})
+		client := &http.Client{Timeout: 10 * time.Second}
+		resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("[Budget] Failed to send alert webhook: %v", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("[Budget] Alert webhook returned %s", resp.Status)
+		}
+	}()
+}
+
+// budgetExceededError rejects a session because the monthly upstream budget
+// is exhausted
+func budgetExceededError(c echo.Context, status BudgetStatus) error {
+	return newAPIError(http.StatusServiceUnavailable, ErrorResponse{
+		Error: "transcription is temporarily unavailable: monthly usage budget reached",
+		Details: map[string]string{
+			"month":     status.Month,
+			"resets_at": status.ResetsAt,
+		},
+	})
+}
+
+// GetBudgetStatus returns the current month's estimated upstream spend
+// against DEEPGRAM_MONTHLY_BUDGET and DEEPGRAM_ALERT_BUDGET, with the burn
+// rate and month-end forecast (admin only)
+func (h *AdminHandler) GetBudgetStatus(c echo.Context) error {
+	status, err := Budget.Status(context.Background(), h.queries)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	return c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/i18n"
+
+	"github.com/gorilla/websocket"
+)
+
+// CloseCode is a WebSocket close code sent to proxy clients. Every proxy
+// session type closes the client with one of these codes and its standard
+// reason so the desktop app can react without parsing free text. Codes in
+// the 4000-4999 range are reserved for applications by RFC 6455.
+type CloseCode int
+
+const (
+	// CloseNormal ends a session the client or Deepgram finished cleanly
+	CloseNormal CloseCode = websocket.CloseNormalClosure
+	// CloseServerRestart asks the client to reconnect after a deploy
+	CloseServerRestart CloseCode = websocket.CloseServiceRestart
+
+	// CloseSessionLimit is sent when the per-session time limit is reached
+	CloseSessionLimit CloseCode = 4000
+	// CloseQuotaExceeded is sent when the key's usage quota runs out
+	CloseQuotaExceeded CloseCode = 4001
+	// CloseTrialExpired is sent when a trial key expires mid-session
+	CloseTrialExpired CloseCode = 4002
+	// CloseIdleTimeout is sent when the client stops sending audio or
+	// keep-alives for longer than SESSION_IDLE_TIMEOUT
+	CloseIdleTimeout CloseCode = 4003
+	// CloseAdminTerminated is sent when an administrator ends the session
+	CloseAdminTerminated CloseCode = 4004
+	// CloseUpstreamFailure is sent when Deepgram cannot be reached or drops
+	// the connection
+	CloseUpstreamFailure CloseCode = 4005
+	// CloseKeyLocked is sent when the API key is locked after unusual
+	// activity
+	CloseKeyLocked CloseCode = 4006
+	// CloseConcurrencyLimit is sent when a queued session gives up waiting
+	// for a concurrency slot
+	CloseConcurrencyLimit CloseCode = 4429
+)
+
+var closeReasons = map[CloseCode]string{
+	CloseNormal:           "session ended",
+	CloseServerRestart:    "server is restarting, please reconnect",
+	CloseSessionLimit:     "session time limit reached",
+	CloseQuotaExceeded:    "quota exceeded",
+	CloseTrialExpired:     "trial expired",
+	CloseIdleTimeout:      "idle timeout",
+	CloseAdminTerminated:  "terminated by administrator",
+	CloseUpstreamFailure:  "transcription service unavailable",
+	CloseKeyLocked:        "API key locked",
+	CloseConcurrencyLimit: "concurrent session limit reached",
+}
+
+// Reason returns the standard close reason for the code
+func (c CloseCode) Reason() string {
+	return closeReasons[c]
+}
+
+// LocalizedReason returns the close reason translated into lang. The code
+// itself is the machine-readable part and never changes with the language.
+func (c CloseCode) LocalizedReason(lang string) string {
+	return i18n.Translate(lang, c.Reason())
+}
+
+// closeClient sends a close frame with the code's reason in the client's
+// language. It uses WriteControl, which is safe to call concurrently with
+// the proxy loops.
+func closeClient(conn *websocket.Conn, code CloseCode, lang string) {
+	_ = conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(int(code), code.LocalizedReason(lang)), time.Now().Add(time.Second))
+}
+
+// upstreamCloseCode maps a Deepgram read error to the code sent to the client
+func upstreamCloseCode(err error) CloseCode {
+	if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		return CloseNormal
+	}
+	return CloseUpstreamFailure
+}
+
+// extendIdleDeadline pushes the client's read deadline out by
+// SESSION_IDLE_TIMEOUT; a zero timeout disables the deadline
+func extendIdleDeadline(conn *websocket.Conn) {
+	idle := config.Duration("SESSION_IDLE_TIMEOUT")
+	if idle <= 0 {
+		_ = conn.SetReadDeadline(time.Time{})
+		return
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(idle))
+}
+
+// isIdleTimeout reports whether a client read failed because the idle
+// deadline passed
+func isIdleTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"hyperwhisper/internal/config"
+
+	"github.com/andybalholm/brotli"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== RESPONSE COMPRESSION ==========
+
+// compressor is the part of gzip.Writer and brotli.Writer the middleware uses
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// CompressionMiddleware compresses responses for clients that accept it, per
+// RESPONSE_COMPRESSION: "brotli" sends br to clients that list it in
+// Accept-Encoding and gzip to the rest, "gzip" only ever sends gzip. Settings
+// are read once, when the routes are set up. WebSocket upgrades and event
+// streams are never compressed: buffering them would hold back messages, and
+// upgrades need the raw connection.
+func CompressionMiddleware() echo.MiddlewareFunc {
+	mode := config.String("RESPONSE_COMPRESSION")
+	if mode == "off" {
+		return func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	}
+	level := config.Int("RESPONSE_COMPRESSION_LEVEL")
+	minLength := config.Int("RESPONSE_COMPRESSION_MIN_SIZE")
+
+	pools := map[string]*sync.Pool{
+		"gzip": {New: func() any {
+			w, _ := gzip.NewWriterLevel(io.Discard, level)
+			return w
+		}},
+	}
+	if mode == "brotli" {
+		pools["br"] = &sync.Pool{New: func() any {
+			return brotli.NewWriterLevel(io.Discard, level)
+		}}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if skipCompression(c) {
+				return next(c)
+			}
+
+			res := c.Response()
+			res.Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+			encoding := negotiateEncoding(c.Request().Header.Get(echo.HeaderAcceptEncoding), mode == "brotli")
+			if encoding == "" {
+				return next(c)
+			}
+
+			pool := pools[encoding]
+			cw := &compressWriter{
+				ResponseWriter: res.Writer,
+				encoding:       encoding,
+				pool:           pool,
+				minLength:      minLength,
+				code:           http.StatusOK,
+			}
+			res.Writer = cw
+			defer func() {
+				cw.finish()
+				res.Writer = cw.ResponseWriter
+			}()
+			return next(c)
+		}
+	}
+}
+
+// skipCompression exempts requests whose response is known to be a stream
+// before the handler runs. Responses that turn out to be event streams are
+// caught by compressWriter once their Content-Type is set.
+func skipCompression(c echo.Context) bool {
+	r := c.Request()
+	if strings.EqualFold(r.Header.Get(echo.HeaderUpgrade), "websocket") {
+		return true
+	}
+	return strings.Contains(r.Header.Get(echo.HeaderAccept), "text/event-stream")
+}
+
+// negotiateEncoding picks the response encoding from an Accept-Encoding
+// header: br when allowed and accepted, else gzip, else "" (identity). An
+// encoding listed with q=0 is refused; "*" accepts whatever is not listed.
+func negotiateEncoding(header string, allowBrotli bool) string {
+	accepted := map[string]bool{}
+	wildcard, hasWildcard := false, false
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		ok := true
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				q, err := strconv.ParseFloat(value, 64)
+				ok = err == nil && q > 0
+			}
+		}
+		if name == "*" {
+			wildcard, hasWildcard = ok, true
+			continue
+		}
+		accepted[name] = ok
+	}
+
+	acceptable := func(name string) bool {
+		if ok, listed := accepted[name]; listed {
+			return ok
+		}
+		return hasWildcard && wildcard
+	}
+	if allowBrotli && acceptable("br") {
+		return "br"
+	}
+	if acceptable("gzip") {
+		return "gzip"
+	}
+	return ""
+}
+
+// compressWriter holds back the status line and the first minLength bytes of
+// the body, then decides: responses that are event streams or already encoded
+// are passed through untouched, short responses are sent as they are and the
+// rest are compressed.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding  string
+	pool      *sync.Pool
+	minLength int
+
+	code        int
+	wroteHeader bool
+	buffer      bytes.Buffer
+	decided     bool
+	compressor  compressor
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.code = code
+	w.wroteHeader = true
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.decided {
+		if w.compressor != nil {
+			return w.compressor.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	if w.Header().Get(echo.HeaderContentType) == "" {
+		w.Header().Set(echo.HeaderContentType, http.DetectContentType(b))
+	}
+	if w.passThrough() {
+		w.decide(false)
+		return w.ResponseWriter.Write(b)
+	}
+
+	n, _ := w.buffer.Write(b)
+	if w.buffer.Len() >= w.minLength {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// Flush compresses whatever has been buffered, since no more may come for a
+// while, unless the response is an event stream
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(!w.passThrough())
+	}
+	if w.compressor != nil {
+		_ = w.compressor.Flush()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// passThrough reports whether the response must not be compressed whatever
+// its size
+func (w *compressWriter) passThrough() bool {
+	header := w.Header()
+	if header.Get(echo.HeaderContentEncoding) != "" {
+		return true
+	}
+	if w.code == http.StatusNoContent || w.code == http.StatusNotModified {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(header.Get(echo.HeaderContentType))
+	return mediaType == "text/event-stream"
+}
+
+// decide writes the held-back status line and body, compressed or not
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	if compress {
+		w.Header().Set(echo.HeaderContentEncoding, w.encoding)
+		w.Header().Del(echo.HeaderContentLength)
+		w.compressor = w.pool.Get().(compressor)
+		w.compressor.Reset(w.ResponseWriter)
+	}
+	if w.wroteHeader {
+		w.ResponseWriter.WriteHeader(w.code)
+	}
+	if w.buffer.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.compressor != nil {
+		_, err = w.compressor.Write(w.buffer.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buffer.Bytes())
+	}
+	w.buffer.Reset()
+	return err
+}
+
+// finish sends a response that never reached minLength as it is and closes
+// the compressed stream of one that did
+func (w *compressWriter) finish() {
+	if !w.decided {
+		if !w.wroteHeader && w.buffer.Len() == 0 {
+			return
+		}
+		_ = w.decide(false)
+		return
+	}
+	if w.compressor != nil {
+		_ = w.compressor.Close()
+		w.compressor.Reset(io.Discard)
+		w.pool.Put(w.compressor)
+	}
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"hyperwhisper/internal/config"
+
+	"github.com/andybalholm/brotli"
+	"github.com/labstack/echo/v4"
+)
+
+// withCompression loads the configuration with RESPONSE_COMPRESSION set
+func withCompression(t *testing.T, mode string) {
+	t.Helper()
+	t.Setenv("RESPONSE_COMPRESSION", mode)
+	t.Setenv("RESPONSE_COMPRESSION_MIN_SIZE", "16")
+	// Settings required in production may be missing; they aren't read here
+	_ = config.Load()
+	t.Cleanup(func() { _ = config.Load() })
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header      string
+		allowBrotli bool
+		want        string
+	}{
+		{"", true, ""},
+		{"gzip", true, "gzip"},
+		{"gzip, deflate, br", true, "br"},
+		{"gzip, deflate, br", false, "gzip"},
+		{"br", false, ""},
+		{"BR;q=0.5, gzip;q=1.0", true, "br"},
+		{"br;q=0, gzip", true, "gzip"},
+		{"gzip;q=0", true, ""},
+		{"*", true, "br"},
+		{"*, br;q=0", true, "gzip"},
+		{"*;q=0", true, ""},
+		{"identity", true, ""},
+	}
+
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.header, tt.allowBrotli); got != tt.want {
+			t.Errorf("negotiateEncoding(%q, %v) = %q, want %q", tt.header, tt.allowBrotli, got, tt.want)
+		}
+	}
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	body := strings.Repeat(`{"id":"00000000-0000-0000-0000-000000000000"}`, 50)
+
+	tests := []struct {
+		name           string
+		mode           string
+		acceptEncoding string
+		contentType    string
+		body           string
+		wantEncoding   string
+	}{
+		{"brotli to a client that accepts it", "brotli", "gzip, br", echo.MIMEApplicationJSON, body, "br"},
+		{"gzip fallback in brotli mode", "brotli", "gzip", echo.MIMEApplicationJSON, body, "gzip"},
+		{"gzip mode ignores br", "gzip", "gzip, br", echo.MIMEApplicationJSON, body, "gzip"},
+		{"client accepts neither", "brotli", "identity", echo.MIMEApplicationJSON, body, ""},
+		{"below the minimum size", "brotli", "gzip, br", echo.MIMEApplicationJSON, "{}", ""},
+		{"event stream", "brotli", "gzip, br", "text/event-stream", strings.Repeat("data: x\n\n", 50), ""},
+		{"event stream with charset", "gzip", "gzip", "text/event-stream; charset=utf-8", strings.Repeat("data: x\n\n", 50), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withCompression(t, tt.mode)
+
+			e := echo.New()
+			e.Use(CompressionMiddleware())
+			e.GET("/", func(c echo.Context) error {
+				c.Response().Header().Set(echo.HeaderContentType, tt.contentType)
+				c.Response().WriteHeader(http.StatusOK)
+				if strings.HasPrefix(tt.contentType, "text/event-stream") {
+					// Streams flush before there is anything to buffer
+					c.Response().Flush()
+				}
+				_, err := io.WriteString(c.Response(), tt.body)
+				return err
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(echo.HeaderAcceptEncoding, tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get(echo.HeaderContentEncoding); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			var r io.Reader = rec.Body
+			switch tt.wantEncoding {
+			case "br":
+				r = brotli.NewReader(rec.Body)
+			case "gzip":
+				gr, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r = gr
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.body {
+				t.Errorf("body = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestCompressionMiddlewareBuffersShortResponses(t *testing.T) {
+	withCompression(t, "brotli")
+
+	e := echo.New()
+	e.Use(CompressionMiddleware())
+	e.GET("/", func(c echo.Context) error {
+		return c.JSON(http.StatusCreated, map[string]string{"ok": "yes"})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "br")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if got := rec.Header().Get(echo.HeaderContentEncoding); got != "" {
+		t.Errorf("Content-Encoding = %q, want none", got)
+	}
+	if got := rec.Header().Get(echo.HeaderVary); got != echo.HeaderAcceptEncoding {
+		t.Errorf("Vary = %q, want %q", got, echo.HeaderAcceptEncoding)
+	}
+}
@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"hyperwhisper/internal/config"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+)
+
+// queueUpdateInterval is how often a queued client is re-sent its position,
+// which also detects clients that disconnected while waiting
+const queueUpdateInterval = 5 * time.Second
+
+// ConcurrencyLimiter caps the number of live sessions per owner (a user or a
+// trial key). Owners at their limit can wait in a FIFO queue; a released slot
+// is handed straight to the first waiter so later arrivals cannot jump ahead.
+type ConcurrencyLimiter struct {
+	mu     sync.Mutex
+	owners map[string]*slotQueue
+}
+
+type slotQueue struct {
+	active  int
+	waiters []*slotWaiter
+}
+
+type slotWaiter struct {
+	ready     chan struct{}
+	positions chan int
+}
+
+// Concurrency is the process-wide limiter used by all proxy handlers
+var Concurrency = NewConcurrencyLimiter()
+
+// NewConcurrencyLimiter creates an empty concurrency limiter
+func NewConcurrencyLimiter() *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		owners: make(map[string]*slotQueue),
+	}
+}
+
+// TryAcquire takes a slot for owner if one is free and nobody is queued.
+// A limit of zero or less means unlimited.
+func (l *ConcurrencyLimiter) TryAcquire(owner string, limit int) (func(), bool) {
+	if limit <= 0 {
+		return func() {}, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	q := l.queue(owner)
+	if q.active >= limit || len(q.waiters) > 0 {
+		return nil, false
+	}
+	q.active++
+	return l.releaser(owner), true
+}
+
+// Wait queues for a slot until one is handed over or ctx is done.
+// onPosition is called with the 1-based queue position whenever it changes.
+func (l *ConcurrencyLimiter) Wait(ctx context.Context, owner string, limit int, onPosition func(int)) (func(), error) {
+	if release, ok := l.TryAcquire(owner, limit); ok {
+		return release, nil
+	}
+
+	w := &slotWaiter{
+		ready:     make(chan struct{}),
+		positions: make(chan int, 1),
+	}
+
+	l.mu.Lock()
+	q := l.queue(owner)
+	q.waiters = append(q.waiters, w)
+	w.positions <- len(q.waiters)
+	l.mu.Unlock()
+
+	for {
+		select {
+		case <-w.ready:
+			return l.releaser(owner), nil
+		case position := <-w.positions:
+			onPosition(position)
+		case <-ctx.Done():
+			if l.abandon(owner, w) {
+				return nil, ctx.Err()
+			}
+			// The slot was handed over while we were giving up; pass it on
+			l.releaser(owner)()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Active returns the number of slots held by owner
+func (l *ConcurrencyLimiter) Active(owner string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if q, ok := l.owners[owner]; ok {
+		return q.active
+	}
+	return 0
+}
+
+func (l *ConcurrencyLimiter) queue(owner string) *slotQueue {
+	q, ok := l.owners[owner]
+	if !ok {
+		q = &slotQueue{}
+		l.owners[owner] = q
+	}
+	return q
+}
+
+// releaser returns a function that frees the slot exactly once
+func (l *ConcurrencyLimiter) releaser(owner string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { l.release(owner) })
+	}
+}
+
+func (l *ConcurrencyLimiter) release(owner string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	q, ok := l.owners[owner]
+	if !ok {
+		return
+	}
+
+	if len(q.waiters) > 0 {
+		next := q.waiters[0]
+		q.waiters = q.waiters[1:]
+		close(next.ready)
+		q.notifyPositions()
+		return
+	}
+
+	q.active--
+	if q.active <= 0 {
+		delete(l.owners, owner)
+	}
+}
+
+// abandon removes a waiter from the queue. It returns false if the waiter
+// was already handed a slot.
+func (l *ConcurrencyLimiter) abandon(owner string, w *slotWaiter) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	q, ok := l.owners[owner]
+	if !ok {
+		return false
+	}
+	for i, waiter := range q.waiters {
+		if waiter == w {
+			q.waiters = append(q.waiters[:i], q.waiters[i+1:]...)
+			q.notifyPositions()
+			if q.active <= 0 && len(q.waiters) == 0 {
+				delete(l.owners, owner)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// notifyPositions sends every waiter its current position, replacing any
+// update it has not read yet
+func (q *slotQueue) notifyPositions() {
+	for i, w := range q.waiters {
+		select {
+		case <-w.positions:
+		default:
+		}
+		w.positions <- i + 1
+	}
+}
+
+// ========== SESSION SLOTS ==========
+
+// sessionSlot is a concurrency reservation for one proxy session. A slot
+// without a release function still has to wait in the queue.
+type sessionSlot struct {
+	owner   string
+	plan    string
+	limit   int
+	release func()
+}
+
+// planConcurrencyLimit returns the concurrent session limit for a plan
+// ("trial", "user" or "admin"); zero means unlimited
+func planConcurrencyLimit(plan string) int {
+	switch plan {
+	case "trial":
+		return config.Int("CONCURRENCY_LIMIT_TRIAL")
+	case "admin":
+		return config.Int("CONCURRENCY_LIMIT_ADMIN")
+	default:
+		return config.Int("CONCURRENCY_LIMIT_USER")
+	}
+}
+
+// reserveSessionSlot takes a concurrency slot for owner if one is free. When
+// the plan is at its limit and queueing is disabled, ok is false and the
+// connection should be rejected; otherwise the caller must call wait after
+// upgrading the WebSocket.
+func reserveSessionSlot(plan, owner string) (slot *sessionSlot, ok bool) {
+	slot = &sessionSlot{
+		owner: plan + ":" + owner,
+		plan:  plan,
+		limit: planConcurrencyLimit(plan),
+	}
+	if release, acquired := Concurrency.TryAcquire(slot.owner, slot.limit); acquired {
+		slot.release = release
+		return slot, true
+	}
+	if config.Duration("CONCURRENCY_QUEUE_TIMEOUT") <= 0 {
+		return nil, false
+	}
+	return slot, true
+}
+
+// queueStatusMessage is sent to queued clients while they wait for a slot
+type queueStatusMessage struct {
+	Type     string `json:"type"`
+	Position int    `json:"position"`
+	Limit    int    `json:"limit"`
+}
+
+// errQueueTimeout is returned when no slot frees up within the queue timeout
+var errQueueTimeout = errors.New("concurrency queue timeout")
+
+// wait holds an upgraded connection in the queue, sending position updates,
+// until a slot frees up. On timeout the client is closed with
+// CloseConcurrencyLimit, with the reason in lang.
+func (s *sessionSlot) wait(conn *websocket.Conn, logTag, lang string) error {
+	if s.release != nil {
+		return nil
+	}
+
+	timeout := config.Duration("CONCURRENCY_QUEUE_TIMEOUT")
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	log.Printf("[%s] Concurrency limit (%d) reached for %s, queueing for up to %v", logTag, s.limit, s.plan, timeout)
+
+	var mu sync.Mutex
+	position := 0
+	stopped := false
+	send := func(p int) {
+		mu.Lock()
+		defer mu.Unlock()
+		if stopped {
+			return
+		}
+		position = p
+		err := conn.WriteJSON(queueStatusMessage{Type: "QueueStatus", Position: p, Limit: s.limit})
+		if err != nil {
+			// Client went away; stop waiting on its behalf
+			cancel()
+		}
+	}
+
+	// Repeat the current position periodically so disconnected clients
+	// are noticed before the queue timeout
+	ticker := time.NewTicker(queueUpdateInterval)
+	defer ticker.Stop()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				mu.Lock()
+				p := position
+				mu.Unlock()
+				send(p)
+			}
+		}
+	}()
+
+	release, err := Concurrency.Wait(ctx, s.owner, s.limit, send)
+	if err != nil {
+		log.Printf("[%s] No concurrency slot freed up for %s within %v", logTag, s.plan, timeout)
+		mu.Lock()
+		stopped = true
+		closeClient(conn, CloseConcurrencyLimit, lang)
+		mu.Unlock()
+		return errQueueTimeout
+	}
+
+	// Stop position updates before the session takes over the connection
+	mu.Lock()
+	stopped = true
+	mu.Unlock()
+	s.release = release
+	return nil
+}
+
+// Release frees the slot, handing it to the next queued session if any
+func (s *sessionSlot) Release() {
+	if s.release != nil {
+		s.release()
+	}
+}
+
+// concurrencyLimitError rejects a connection over the plan's concurrent
+// session limit when queueing is disabled
+func concurrencyLimitError(c echo.Context, plan string) error {
+	return newAPIError(http.StatusTooManyRequests, ErrorResponse{
+		Error: "concurrent session limit reached",
+		Details: map[string]string{
+			"plan":  plan,
+			"limit": strconv.Itoa(planConcurrencyLimit(plan)),
+		},
+	})
+}
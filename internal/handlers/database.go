@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== DATABASE OUTAGES ==========
+
+// DatabaseAvailabilityMiddleware holds requests while the database is
+// unavailable. Each request, proxy connections included, waits up to
+// DB_OUTAGE_WAIT for it to come back and is then refused with 503 and a
+// Retry-After header, instead of failing in the handler with a 500.
+func DatabaseAvailabilityMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if db.Available() {
+				return next(c)
+			}
+			if wait := config.Duration("DB_OUTAGE_WAIT"); wait > 0 && db.WaitAvailable(c.Request().Context(), wait) {
+				return next(c)
+			}
+
+			retryAfter := config.Duration("DB_RECONNECT_MAX_BACKOFF")
+			c.Response().Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+			return apiError(http.StatusServiceUnavailable, "database unavailable, please retry shortly")
+		}
+	}
+}
@@ -7,17 +7,22 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
-	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
 	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/encryption"
+	"hyperwhisper/internal/logging"
+	"hyperwhisper/internal/mail"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
@@ -28,23 +33,15 @@ import (
 type DeepgramHandler struct {
 	queries  *sqlc.Queries
 	upgrader websocket.Upgrader
+	mailer   mail.Sender
 }
 
 // NewDeepgramHandler creates a new Deepgram handler
 func NewDeepgramHandler(db *sql.DB) *DeepgramHandler {
 	return &DeepgramHandler{
-		queries: sqlc.New(db),
-		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				// Allow all origins in dev, restrict in production
-				if os.Getenv("APP_ENV") == "dev" {
-					return true
-				}
-				return checkAllowedOrigin(r)
-			},
-			ReadBufferSize:  1024,
-			WriteBufferSize: 1024,
-		},
+		queries:  sqlc.New(db),
+		upgrader: newProxyUpgrader(),
+		mailer:   mail.NewFromConfig(),
 	}
 }
 
@@ -53,6 +50,9 @@ func NewDeepgramHandler(db *sql.DB) *DeepgramHandler {
 // CreateAPIKeyRequest is the request body for creating an API key
 type CreateAPIKeyRequest struct {
 	Name string `json:"name"`
+	// Sandbox creates a hw_test_ key, whose sessions stream to the mock
+	// upstream for free
+	Sandbox bool `json:"sandbox"`
 }
 
 // APIKeyResponse is the response for API key operations
@@ -60,9 +60,27 @@ type APIKeyResponse struct {
 	ID        string  `json:"id"`
 	Name      string  `json:"name"`
 	KeyPrefix string  `json:"key_prefix"`
+	Sandbox   bool    `json:"sandbox"` // hw_test_ key
 	CreatedAt string  `json:"created_at"`
 	LastUsed  *string `json:"last_used_at"`
 	RevokedAt *string `json:"revoked_at,omitempty"`
+
+	// Where the key was last used from and how often it has been used
+	LastUsedIP        *string `json:"last_used_ip"`
+	LastUsedUserAgent *string `json:"last_used_user_agent"`
+	UseCount          int64   `json:"use_count"`
+
+	// Set on keys owned by an organization
+	OrganizationID *string `json:"organization_id,omitempty"`
+
+	ParamRestrictions ParamRestrictions `json:"param_restrictions"`
+
+	// Set while the key is locked after unusual activity; only in the
+	// owner's key list
+	Lockdown *APIKeyLockdownResponse `json:"lockdown,omitempty"`
+	// Set while the key is flagged for going unused; only in the owner's
+	// key list
+	InactivityRevokesAt *string `json:"inactivity_revokes_at,omitempty"`
 }
 
 // APIKeyCreatedResponse includes the full key (only shown once)
@@ -76,20 +94,25 @@ type UsageSummaryResponse struct {
 	TotalSessions        int64   `json:"total_sessions"`
 	TotalDurationSeconds float64 `json:"total_duration_seconds"`
 	TotalBytesSent       int64   `json:"total_bytes_sent"`
+	Period               string  `json:"period"`
 	PeriodStart          string  `json:"period_start"`
 	PeriodEnd            string  `json:"period_end"`
+	Timezone             string  `json:"timezone"`
 }
 
 // TranscriptionLogResponse is the response for transcription logs
 type TranscriptionLogResponse struct {
-	ID              string          `json:"id"`
-	StartedAt       string          `json:"started_at"`
-	EndedAt         *string         `json:"ended_at"`
-	DurationSeconds *float64        `json:"duration_seconds"`
-	Status          string          `json:"status"`
-	ErrorMessage    *string         `json:"error_message,omitempty"`
-	DeepgramParams  json.RawMessage `json:"deepgram_params"`
-	BytesSent       int64           `json:"bytes_sent"`
+	ID                string          `json:"id"`
+	StartedAt         string          `json:"started_at"`
+	EndedAt           *string         `json:"ended_at"`
+	DurationSeconds   *float64        `json:"duration_seconds"`
+	DurationEstimated bool            `json:"duration_estimated"`
+	Status            string          `json:"status"`
+	ErrorMessage      *string         `json:"error_message,omitempty"`
+	ErrorCode         *string         `json:"error_code,omitempty"`
+	DeepgramParams    json.RawMessage `json:"deepgram_params"`
+	BytesSent         int64           `json:"bytes_sent"`
+	Redaction         *RedactionAudit `json:"redaction"`
 }
 
 // ========== API KEY MANAGEMENT ==========
@@ -97,34 +120,34 @@ type TranscriptionLogResponse struct {
 // GenerateAPIKey creates a new API key for the authenticated user
 func (h *DeepgramHandler) GenerateAPIKey(c echo.Context) error {
 	claims := auth.GetUserFromContext(c)
-	if claims == nil {
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "not authenticated"})
-	}
 
 	var req CreateAPIKeyRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+		return apiError(http.StatusBadRequest, "invalid request body")
 	}
 
 	if req.Name == "" {
 		req.Name = "Default Key"
 	}
 
-	// Generate random API key: hw_live_<32 random hex chars>
+	ctx := context.Background()
+	if err := checkAPIKeyLimit(ctx, h.queries, claims.UserID); err != nil {
+		return err
+	}
+
+	// Generate random API key: hw_live_ (or hw_test_) <32 random hex chars>
 	randomBytes := make([]byte, 16)
 	if _, err := rand.Read(randomBytes); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to generate key"})
+		return apiError(http.StatusInternalServerError, "failed to generate key")
 	}
 
 	keyRandom := hex.EncodeToString(randomBytes)
-	fullKey := fmt.Sprintf("hw_live_%s", keyRandom)
-	keyPrefix := fullKey[:12] // "hw_live_abcd"
+	fullKey := apiKeyPrefix(req.Sandbox) + keyRandom
+	keyPrefix := logging.KeyPrefix(fullKey) // "hw_live_abcd"
 
 	// Hash the key for storage
 	keyHash := hashAPIKey(fullKey)
 
-	ctx := context.Background()
-
 	apiKey, err := h.queries.CreateAPIKey(ctx, sqlc.CreateAPIKeyParams{
 		UserID:    claims.UserID,
 		KeyHash:   keyHash,
@@ -132,7 +155,7 @@ func (h *DeepgramHandler) GenerateAPIKey(c echo.Context) error {
 		Name:      req.Name,
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to create API key"})
+		return apiError(http.StatusInternalServerError, "failed to create API key")
 	}
 
 	return c.JSON(http.StatusCreated, APIKeyCreatedResponse{
@@ -144,16 +167,13 @@ func (h *DeepgramHandler) GenerateAPIKey(c echo.Context) error {
 // ListAPIKeys returns all API keys for the authenticated user
 func (h *DeepgramHandler) ListAPIKeys(c echo.Context) error {
 	claims := auth.GetUserFromContext(c)
-	if claims == nil {
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "not authenticated"})
-	}
 
 	page, perPage, offset := getPaginationParams(c)
 	ctx := context.Background()
 
 	total, err := h.queries.CountUserAPIKeys(ctx, claims.UserID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	keys, err := h.queries.ListUserAPIKeys(ctx, sqlc.ListUserAPIKeysParams{
@@ -162,12 +182,32 @@ func (h *DeepgramHandler) ListAPIKeys(c echo.Context) error {
 		Offset: int32(offset),
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	lockdowns, err := h.queries.ListUserActiveAPIKeyLockdowns(ctx, claims.UserID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	locked := make(map[uuid.UUID]sqlc.ApiKeyLockdown, len(lockdowns))
+	for _, lockdown := range lockdowns {
+		locked[lockdown.ApiKeyID] = lockdown
+	}
+	revocations, err := inactivityRevocations(ctx, h.queries, claims.UserID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	responses := make([]APIKeyResponse, len(keys))
 	for i, key := range keys {
 		responses[i] = toAPIKeyResponse(key)
+		if lockdown, ok := locked[key.ID]; ok {
+			responses[i].Lockdown = toAPIKeyLockdownResponse(lockdown)
+		}
+		if revokesAt, ok := revocations[key.ID]; ok {
+			formatted := revokesAt.Format(time.RFC3339)
+			responses[i].InactivityRevokesAt = &formatted
+		}
 	}
 
 	return c.JSON(http.StatusOK, PaginatedResponse{
@@ -182,13 +222,10 @@ func (h *DeepgramHandler) ListAPIKeys(c echo.Context) error {
 // RevokeAPIKey revokes an API key
 func (h *DeepgramHandler) RevokeAPIKey(c echo.Context) error {
 	claims := auth.GetUserFromContext(c)
-	if claims == nil {
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "not authenticated"})
-	}
 
 	keyID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid key ID"})
+		return apiError(http.StatusBadRequest, "invalid key ID")
 	}
 
 	ctx := context.Background()
@@ -198,7 +235,7 @@ func (h *DeepgramHandler) RevokeAPIKey(c echo.Context) error {
 		UserID: claims.UserID,
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to revoke key"})
+		return apiError(http.StatusInternalServerError, "failed to revoke key")
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "API key revoked"})
@@ -209,36 +246,29 @@ func (h *DeepgramHandler) RevokeAPIKey(c echo.Context) error {
 // GetUsageSummary returns usage statistics for the authenticated user
 func (h *DeepgramHandler) GetUsageSummary(c echo.Context) error {
 	claims := auth.GetUserFromContext(c)
-	if claims == nil {
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "not authenticated"})
-	}
 
-	// Default to current month
-	now := time.Now()
-	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
-	endOfMonth := startOfMonth.AddDate(0, 1, 0)
+	ctx := context.Background()
 
-	// Allow custom date range via query params
-	if startParam := c.QueryParam("start"); startParam != "" {
-		if t, err := time.Parse(time.RFC3339, startParam); err == nil {
-			startOfMonth = t
-		}
-	}
-	if endParam := c.QueryParam("end"); endParam != "" {
-		if t, err := time.Parse(time.RFC3339, endParam); err == nil {
-			endOfMonth = t
-		}
+	user, err := h.queries.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
-	ctx := context.Background()
+	// Default to the current month in the user's timezone; period=current_cycle
+	// follows the user's billing cycle instead, and custom date ranges and a
+	// tz override are accepted via query params
+	period, err := resolveUsagePeriod(c, user.Timezone, billingCycleAnchor(user))
+	if err != nil {
+		return apiError(http.StatusBadRequest, err.Error())
+	}
 
 	summary, err := h.queries.GetUserUsageSummary(ctx, sqlc.GetUserUsageSummaryParams{
 		UserID:    claims.UserID,
-		StartDate: startOfMonth,
-		EndDate:   endOfMonth,
+		StartDate: period.Start,
+		EndDate:   period.End,
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	// Convert decimal string to float64
@@ -249,24 +279,23 @@ func (h *DeepgramHandler) GetUsageSummary(c echo.Context) error {
 		TotalSessions:        summary.TotalSessions,
 		TotalDurationSeconds: durationFloat,
 		TotalBytesSent:       bytesSent,
-		PeriodStart:          startOfMonth.Format(time.RFC3339),
-		PeriodEnd:            endOfMonth.Format(time.RFC3339),
+		PeriodStart:          period.Start.Format(time.RFC3339),
+		PeriodEnd:            period.End.Format(time.RFC3339),
+		Period:               period.Kind,
+		Timezone:             period.Timezone,
 	})
 }
 
 // ListTranscriptionLogs returns usage logs for the authenticated user
 func (h *DeepgramHandler) ListTranscriptionLogs(c echo.Context) error {
 	claims := auth.GetUserFromContext(c)
-	if claims == nil {
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "not authenticated"})
-	}
 
 	page, perPage, offset := getPaginationParams(c)
 	ctx := context.Background()
 
 	total, err := h.queries.CountUserTranscriptionLogs(ctx, claims.UserID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	logs, err := h.queries.ListUserTranscriptionLogs(ctx, sqlc.ListUserTranscriptionLogsParams{
@@ -275,7 +304,7 @@ func (h *DeepgramHandler) ListTranscriptionLogs(c echo.Context) error {
 		Offset: int32(offset),
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	responses := make([]TranscriptionLogResponse, len(logs))
@@ -292,10 +321,32 @@ func (h *DeepgramHandler) ListTranscriptionLogs(c echo.Context) error {
 	})
 }
 
+// GetTranscriptionLog returns one of the user's sessions, including the
+// redactions it ran with
+func (h *DeepgramHandler) GetTranscriptionLog(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	logID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid log ID")
+	}
+
+	txLog, err := h.queries.GetTranscriptionLog(context.Background(), logID)
+	if err == sql.ErrNoRows || (err == nil && txLog.UserID != claims.UserID) {
+		return apiError(http.StatusNotFound, "session not found")
+	}
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	return c.JSON(http.StatusOK, toTranscriptionLogResponse(txLog))
+}
+
 // ========== WEBSOCKET PROXY ==========
 
 // DeepgramProxy handles WebSocket connections and proxies to Deepgram
-// This endpoint handles both regular API keys (hw_live_) and trial keys (hw_trial_)
+// This endpoint handles regular API keys (hw_live_), sandbox keys (hw_test_)
+// and trial keys (hw_trial_)
 func (h *DeepgramHandler) DeepgramProxy(c echo.Context) error {
 	// Extract API key from query param or header
 	apiKey := c.QueryParam("api_key")
@@ -304,7 +355,12 @@ func (h *DeepgramHandler) DeepgramProxy(c echo.Context) error {
 	}
 	if apiKey == "" {
 		log.Printf("[Deepgram] No API key provided")
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "API key required"})
+		recordAuthEvent(c, apiKey, keyAuthMissing, uuid.NullUUID{})
+		return apiError(http.StatusUnauthorized, "API key required")
+	}
+	if err := checkKeyFormat(apiKey); err != nil {
+		recordAuthEvent(c, apiKey, keyAuthMalformed, uuid.NullUUID{})
+		return err
 	}
 
 	// Check if this is a trial key - use the trial handler stored in context
@@ -313,12 +369,30 @@ func (h *DeepgramHandler) DeepgramProxy(c echo.Context) error {
 		trialHandler := c.Get("trial_handler")
 		if trialHandler == nil {
 			log.Printf("[Deepgram] Trial handler not configured")
-			return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "trial handler not configured"})
+			return apiError(http.StatusInternalServerError, "trial handler not configured")
 		}
 		return trialHandler.(*TrialHandler).TrialDeepgramProxy(c)
 	}
 
-	log.Printf("[Deepgram] API key received (prefix: %s...)", apiKey[:12])
+	protocol, err := negotiateProtocol(c)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[Deepgram] API key received (%s)", requestKeyID(c))
+
+	if Sessions.Draining() {
+		return apiError(http.StatusServiceUnavailable, "server is restarting, please reconnect")
+	}
+
+	// All sessions run on the shared Deepgram key, so all count against
+	// the monthly budget; sandbox sessions never reach Deepgram
+	if !IsSandboxKey(apiKey) {
+		if status, exceeded := Budget.Exceeded(h.queries); exceeded {
+			log.Printf("[Deepgram] Monthly budget reached, refusing session")
+			return budgetExceededError(c, status)
+		}
+	}
 
 	// Validate API key and get user
 	ctx := context.Background()
@@ -328,62 +402,163 @@ func (h *DeepgramHandler) DeepgramProxy(c echo.Context) error {
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("[Deepgram] Invalid API key - not found in database")
-			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "invalid API key"})
+			outcome, owner := refusedKeyOutcome(ctx, h.queries, keyHash)
+			recordAuthEvent(c, apiKey, outcome, owner)
+			return apiError(http.StatusUnauthorized, "invalid API key")
 		}
 		log.Printf("[Deepgram] Database error: %v", err)
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 	log.Printf("[Deepgram] API key validated, user: %s", apiKeyRecord.UserID)
+	c.Set(accessLogUserKey, apiKeyRecord.UserID)
+	owner := uuid.NullUUID{UUID: apiKeyRecord.UserID, Valid: true}
 
-	// Update last used timestamp (async, don't block)
+	// Record when, where from and with what client the key was used
+	// (async, don't block)
+	lastUsed := sqlc.UpdateAPIKeyLastUsedParams{
+		ID:                apiKeyRecord.ID,
+		LastUsedIp:        encryption.NullString{String: c.RealIP(), Valid: c.RealIP() != ""},
+		LastUsedUserAgent: sql.NullString{String: c.Request().UserAgent(), Valid: c.Request().UserAgent() != ""},
+	}
 	go func() {
-		_ = h.queries.UpdateAPIKeyLastUsed(context.Background(), apiKeyRecord.ID)
+		_ = h.queries.UpdateAPIKeyLastUsed(context.Background(), lastUsed)
 	}()
 
-	// Extract Deepgram params from query string
-	deepgramParams := extractDeepgramParams(c.Request().URL.Query())
+	// Keys locked after unusual activity are refused until re-enabled
+	if err := h.checkKeyLockdown(ctx, c, apiKeyRecord); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusForbidden {
+			recordAuthEvent(c, apiKey, keyAuthLocked, owner)
+		}
+		return err
+	}
+
+	// Sandbox keys stream to the mock upstream, without quotas or logs
+	if IsSandboxKey(apiKey) {
+		recordAuthEvent(c, apiKey, keyAuthSuccess, owner)
+		return h.sandboxProxy(c, protocol, apiKeyRecord)
+	}
+
+	// Organization keys draw from the organization's shared monthly pool
+	if apiKeyRecord.OrganizationID.Valid {
+		if status, errResp := checkOrganizationQuota(ctx, h.queries, apiKeyRecord.OrganizationID.UUID, "Deepgram"); errResp != nil {
+			if status == http.StatusForbidden {
+				recordAuthEvent(c, apiKey, keyAuthQuotaExceeded, owner)
+			}
+			return newAPIError(status, *errResp)
+		}
+	}
+	recordAuthEvent(c, apiKey, keyAuthSuccess, owner)
+
+	// Extract Deepgram params from query string, applying session policies
+	policy, err := enforceSessionPolicy(ctx, h.queries, sessionTarget{
+		userID:   apiKeyRecord.UserID,
+		apiKeyID: apiKeyRecord.ID,
+	}, "Deepgram")
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to resolve session policy")
+	}
+	// Reject params this key may not use before anything reaches Deepgram
+	restrictions := parseParamRestrictions(apiKeyRecord.ParamRestrictions)
+	if violations := restrictions.violations(c.Request().URL.Query()); violations != nil {
+		log.Printf("[Deepgram] Rejected restricted params: %v", violations)
+		return paramRestrictionError(c, violations)
+	}
 
-	// Get Deepgram API key from environment
-	deepgramAPIKey := os.Getenv("DEEPGRAM_API_KEY")
+	defaults := restrictions.permitted(loadDeepgramDefaults(ctx, h.queries, "Deepgram"))
+	deepgramParams := extractDeepgramParams(c.Request().URL.Query(), defaults, policy.params)
+	restrictions.applyDefaults(deepgramParams)
+	redactionAudit := auditRedactions(c.Request().URL.Query(), policy, restrictions, deepgramParams)
+
+	user, err := h.queries.GetUserByID(ctx, apiKeyRecord.UserID)
+	if err != nil {
+		log.Printf("[Deepgram] Failed to get user: %v", err)
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	useUserLanguage(c, user)
+
+	// Tenants' users stream with the tenant's key, if it has one
+	deepgramAPIKey, err := upstreamAPIKey(ctx, h.queries, user.TenantID)
+	if err != nil {
+		log.Printf("[Deepgram] Failed to get tenant of user %s: %v", user.ID, err)
+		return apiError(http.StatusInternalServerError, "database error")
+	}
 	if deepgramAPIKey == "" {
 		log.Printf("[Deepgram] ERROR: DEEPGRAM_API_KEY not set in environment")
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Deepgram not configured"})
+		return apiError(http.StatusInternalServerError, "Deepgram not configured")
 	}
 	log.Printf("[Deepgram] API key configured (length: %d)", len(deepgramAPIKey))
 
-	// Create transcription log
+	// Sessions stay in the data region of the key's organization or the
+	// user's tenant
+	region, err := sessionRegion(ctx, h.queries, apiKeyRecord.OrganizationID, user.TenantID)
+	if err != nil {
+		log.Printf("[Deepgram] Failed to resolve data region of key %s: %v", requestKeyID(c), err)
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if wantsSavedTranscript(c) && !transcriptsStorable(region) {
+		return apiError(http.StatusForbidden, "transcripts can't be saved in your data region")
+	}
+
+	// Enforce the plan's concurrent session limit
+	slot, ok := reserveSessionSlot(user.UserType, user.ID.String())
+	if !ok {
+		log.Printf("[Deepgram] Concurrent session limit reached for user %s", user.ID)
+		return concurrencyLimitError(c, user.UserType)
+	}
+	defer slot.Release()
+
+	// Create the transcription log, or reopen the one a failed attempt of
+	// the same session left
+	nonce, ok := sessionNonce(c)
+	if !ok {
+		return apiError(http.StatusBadRequest, "session_nonce is too long")
+	}
 	paramsJSON, _ := json.Marshal(deepgramParams)
 	clientIP := c.RealIP()
 
-	txLog, err := h.queries.CreateTranscriptionLog(ctx, sqlc.CreateTranscriptionLogParams{
+	txLog, err := openTranscriptionLog(ctx, h.queries, sqlc.CreateTranscriptionLogParams{
 		UserID:         apiKeyRecord.UserID,
 		ApiKeyID:       apiKeyRecord.ID,
 		DeepgramParams: paramsJSON,
-		ClientIp:       sql.NullString{String: clientIP, Valid: clientIP != ""},
+		ClientIp:       encryption.NullString{String: clientIP, Valid: clientIP != ""},
+		RedactionAudit: redactionAudit,
+		SessionNonce:   nonce,
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to create log"})
+		return apiError(http.StatusInternalServerError, "failed to create log")
 	}
 
 	// Upgrade to WebSocket
+	lang := requestLanguage(c)
 	clientConn, err := h.upgrader.Upgrade(c.Response(), c.Request(), nil)
 	if err != nil {
 		_ = h.queries.UpdateTranscriptionLogError(ctx, sqlc.UpdateTranscriptionLogErrorParams{
 			ID:           txLog.ID,
 			ErrorMessage: sql.NullString{String: "websocket upgrade failed", Valid: true},
 			BytesSent:    0,
+			ErrorCode:    sql.NullString{String: errorCodeUpgradeFailed, Valid: true},
 		})
 		return err
 	}
 	defer clientConn.Close()
 
+	// Wait for a concurrency slot if the plan is at its limit
+	if err := slot.wait(clientConn, "Deepgram", lang); err != nil {
+		_ = h.queries.UpdateTranscriptionLogError(ctx, sqlc.UpdateTranscriptionLogErrorParams{
+			ID:           txLog.ID,
+			ErrorMessage: sql.NullString{String: err.Error(), Valid: true},
+			BytesSent:    0,
+			ErrorCode:    sql.NullString{String: errorCodeConcurrencyLimit, Valid: true},
+		})
+		return nil
+	}
+
 	// Connect to Deepgram
-	deepgramURL := buildDeepgramURL(deepgramParams)
+	deepgramURL := buildDeepgramURL(region, deepgramParams)
 	log.Printf("[Deepgram] Connecting to: %s", deepgramURL)
 
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
+	dialer := newUpstreamDialer()
 
 	headers := http.Header{}
 	headers.Set("Authorization", fmt.Sprintf("Token %s", deepgramAPIKey))
@@ -391,16 +566,18 @@ func (h *DeepgramHandler) DeepgramProxy(c echo.Context) error {
 	deepgramConn, resp, err := dialer.Dial(deepgramURL, headers)
 	if err != nil {
 		log.Printf("[Deepgram] Connection failed: %v", err)
+		publishSessionError("deepgram", fmt.Sprintf("deepgram connection failed: %v", err))
 		if resp != nil {
 			log.Printf("[Deepgram] Response status: %d", resp.StatusCode)
 		}
+		failure := upstreamDialFailure(err, resp)
 		_ = h.queries.UpdateTranscriptionLogError(ctx, sqlc.UpdateTranscriptionLogErrorParams{
 			ID:           txLog.ID,
-			ErrorMessage: sql.NullString{String: fmt.Sprintf("deepgram connection failed: %v", err), Valid: true},
+			ErrorMessage: sql.NullString{String: failure.message, Valid: true},
 			BytesSent:    0,
+			ErrorCode:    sql.NullString{String: failure.code, Valid: true},
 		})
-		_ = clientConn.WriteMessage(websocket.CloseMessage,
-			websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "Failed to connect to Deepgram"))
+		closeClient(clientConn, CloseUpstreamFailure, lang)
 		return nil
 	}
 	defer deepgramConn.Close()
@@ -410,11 +587,21 @@ func (h *DeepgramHandler) DeepgramProxy(c echo.Context) error {
 	session := &proxySession{
 		clientConn:   clientConn,
 		deepgramConn: deepgramConn,
+		lang:         lang,
 		logID:        txLog.ID,
+		apiKeyID:     apiKeyRecord.ID,
 		queries:      h.queries,
 		bytesSent:    0,
 		duration:     0,
+		audio:        newAudioClock(deepgramParams),
+		userID:       apiKeyRecord.UserID,
+		pacing:       newPacingMonitor(protocol, deepgramParams),
 	}
+	if wantsSavedTranscript(c) {
+		session.transcript = &transcriptBuffer{}
+	}
+
+	_ = sendSessionStarted(clientConn, protocol, txLog.ID.String(), 0)
 
 	// Start bidirectional proxy
 	session.run()
@@ -428,23 +615,60 @@ func (h *DeepgramHandler) DeepgramProxy(c echo.Context) error {
 func (h *DeepgramHandler) DeepgramProxyDashboard(c echo.Context) error {
 	// Get user from JWT (set by middleware)
 	claims := auth.GetUserFromContext(c)
-	if claims == nil {
-		log.Printf("[Deepgram Dashboard] No JWT claims found")
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "authentication required"})
-	}
 	log.Printf("[Deepgram Dashboard] User authenticated: %s", claims.UserID)
 
-	// Extract Deepgram params from query string
-	deepgramParams := extractDeepgramParams(c.Request().URL.Query())
+	protocol, err := negotiateProtocol(c)
+	if err != nil {
+		return err
+	}
+
+	if Sessions.Draining() {
+		return apiError(http.StatusServiceUnavailable, "server is restarting, please reconnect")
+	}
+
+	// All sessions run on the shared Deepgram key, so all count against
+	// the monthly budget
+	if status, exceeded := Budget.Exceeded(h.queries); exceeded {
+		log.Printf("[Deepgram Dashboard] Monthly budget reached, refusing session")
+		return budgetExceededError(c, status)
+	}
+
+	// Extract Deepgram params from query string, applying session policies
+	policy, err := enforceSessionPolicy(context.Background(), h.queries, sessionTarget{
+		userID: claims.UserID,
+	}, "Deepgram Dashboard")
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to resolve session policy")
+	}
+	defaults := loadDeepgramDefaults(context.Background(), h.queries, "Deepgram Dashboard")
+	deepgramParams := extractDeepgramParams(c.Request().URL.Query(), defaults, policy.params)
 
-	// Get Deepgram API key from environment
-	deepgramAPIKey := os.Getenv("DEEPGRAM_API_KEY")
+	// Tenants' users stream with the tenant's key, if it has one
+	deepgramAPIKey, err := upstreamAPIKey(context.Background(), h.queries, callerTenant(c))
+	if err != nil {
+		log.Printf("[Deepgram Dashboard] Failed to get tenant of user %s: %v", claims.UserID, err)
+		return apiError(http.StatusInternalServerError, "database error")
+	}
 	if deepgramAPIKey == "" {
 		log.Printf("[Deepgram Dashboard] ERROR: DEEPGRAM_API_KEY not set in environment")
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Deepgram not configured"})
+		return apiError(http.StatusInternalServerError, "Deepgram not configured")
+	}
+	region, err := sessionRegion(context.Background(), h.queries, uuid.NullUUID{}, callerTenant(c))
+	if err != nil {
+		log.Printf("[Deepgram Dashboard] Failed to resolve data region of user %s: %v", claims.UserID, err)
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
+	// Dashboard sessions count toward the user's concurrent session limit
+	slot, ok := reserveSessionSlot(claims.UserType, claims.UserID.String())
+	if !ok {
+		log.Printf("[Deepgram Dashboard] Concurrent session limit reached for user %s", claims.UserID)
+		return concurrencyLimitError(c, claims.UserType)
+	}
+	defer slot.Release()
+
 	// Upgrade to WebSocket
+	lang := userLanguage(c, h.queries, claims.UserID)
 	clientConn, err := h.upgrader.Upgrade(c.Response(), c.Request(), nil)
 	if err != nil {
 		log.Printf("[Deepgram Dashboard] WebSocket upgrade failed: %v", err)
@@ -452,13 +676,16 @@ func (h *DeepgramHandler) DeepgramProxyDashboard(c echo.Context) error {
 	}
 	defer clientConn.Close()
 
+	// Wait for a concurrency slot if the plan is at its limit
+	if err := slot.wait(clientConn, "Deepgram Dashboard", lang); err != nil {
+		return nil
+	}
+
 	// Connect to Deepgram
-	deepgramURL := buildDeepgramURL(deepgramParams)
+	deepgramURL := buildDeepgramURL(region, deepgramParams)
 	log.Printf("[Deepgram Dashboard] Connecting to: %s", deepgramURL)
 
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
+	dialer := newUpstreamDialer()
 
 	headers := http.Header{}
 	headers.Set("Authorization", fmt.Sprintf("Token %s", deepgramAPIKey))
@@ -466,11 +693,11 @@ func (h *DeepgramHandler) DeepgramProxyDashboard(c echo.Context) error {
 	deepgramConn, resp, err := dialer.Dial(deepgramURL, headers)
 	if err != nil {
 		log.Printf("[Deepgram Dashboard] Connection failed: %v", err)
+		publishSessionError("dashboard", fmt.Sprintf("deepgram connection failed: %v", err))
 		if resp != nil {
 			log.Printf("[Deepgram Dashboard] Response status: %d", resp.StatusCode)
 		}
-		_ = clientConn.WriteMessage(websocket.CloseMessage,
-			websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "Failed to connect to Deepgram"))
+		closeClient(clientConn, CloseUpstreamFailure, lang)
 		return nil
 	}
 	defer deepgramConn.Close()
@@ -480,11 +707,14 @@ func (h *DeepgramHandler) DeepgramProxyDashboard(c echo.Context) error {
 	dashboardSession := &dashboardProxySession{
 		clientConn:   clientConn,
 		deepgramConn: deepgramConn,
+		lang:         lang,
 		userID:       claims.UserID.String(),
 		maxDuration:  5 * time.Minute, // Max 5 minutes per session
 		startTime:    time.Now(),
 	}
 
+	_ = sendSessionStarted(clientConn, protocol, "", dashboardSession.maxDuration)
+
 	// Start bidirectional proxy
 	dashboardSession.run()
 
@@ -495,6 +725,7 @@ func (h *DeepgramHandler) DeepgramProxyDashboard(c echo.Context) error {
 type dashboardProxySession struct {
 	clientConn   *websocket.Conn
 	deepgramConn *websocket.Conn
+	lang         string // Language of close reasons
 	userID       string
 	maxDuration  time.Duration
 	startTime    time.Time
@@ -504,6 +735,12 @@ type dashboardProxySession struct {
 }
 
 func (s *dashboardProxySession) run() {
+	id, ok := Sessions.Add("user:"+s.userID, s.shutdown)
+	if !ok {
+		s.shutdown(CloseServerRestart)
+	}
+	defer Sessions.Remove(id)
+
 	var wg sync.WaitGroup
 	wg.Add(2)
 
@@ -531,14 +768,22 @@ func (s *dashboardProxySession) run() {
 
 func (s *dashboardProxySession) proxyClientToDeepgram() {
 	for {
+		extendIdleDeadline(s.clientConn)
 		messageType, data, err := s.clientConn.ReadMessage()
 		if err != nil {
 			log.Printf("[Deepgram Dashboard] Client read error: %v", err)
-			_ = s.deepgramConn.WriteMessage(websocket.TextMessage, []byte(`{"type":"CloseStream"}`))
+			if isIdleTimeout(err) {
+				closeClient(s.clientConn, CloseIdleTimeout, s.lang)
+			}
+			_ = writeMessage(s.deepgramConn, websocket.TextMessage, []byte(`{"type":"CloseStream"}`))
 			return
 		}
 
-		if err := s.deepgramConn.WriteMessage(messageType, data); err != nil {
+		if messageType == websocket.BinaryMessage {
+			Events.AddBytes(len(data))
+		}
+
+		if err := writeMessage(s.deepgramConn, messageType, data); err != nil {
 			log.Printf("[Deepgram Dashboard] Error forwarding to Deepgram: %v", err)
 			return
 		}
@@ -550,10 +795,12 @@ func (s *dashboardProxySession) proxyDeepgramToClient() {
 		messageType, data, err := s.deepgramConn.ReadMessage()
 		if err != nil {
 			log.Printf("[Deepgram Dashboard] Deepgram read error: %v", err)
+			closeClient(s.clientConn, upstreamCloseCode(err), s.lang)
+			s.clientConn.Close()
 			return
 		}
 
-		if err := s.clientConn.WriteMessage(messageType, data); err != nil {
+		if err := writeMessage(s.clientConn, messageType, data); err != nil {
 			log.Printf("[Deepgram Dashboard] Error forwarding to client: %v", err)
 			return
 		}
@@ -569,26 +816,48 @@ func (s *dashboardProxySession) close() {
 	}
 	s.closed = true
 
-	_ = s.clientConn.WriteMessage(websocket.CloseMessage,
-		websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Session time limit reached"))
+	closeClient(s.clientConn, CloseSessionLimit, s.lang)
 	s.clientConn.Close()
 	s.deepgramConn.Close()
 }
 
+// shutdown closes the client connection with the given code; the proxy
+// loops then wind down on their own
+func (s *dashboardProxySession) shutdown(code CloseCode) {
+	closeClient(s.clientConn, code, s.lang)
+	s.clientConn.Close()
+}
+
 // proxySession manages a single WebSocket proxy session
 type proxySession struct {
 	clientConn   *websocket.Conn
 	deepgramConn *websocket.Conn
+	lang         string // Language of close reasons
 	logID        uuid.UUID
+	apiKeyID     uuid.UUID
+	userID       uuid.UUID
 	queries      *sqlc.Queries
+	transcript   *transcriptBuffer // nil unless the client asked to save it
+	pacing       *pacingMonitor    // nil unless the client takes adaptation hints
 
+	// clientMu serializes writes to clientConn
+	clientMu  sync.Mutex
 	mu        sync.Mutex
 	bytesSent int64
 	duration  float64
+	audio     audioClock
 	closed    bool
+	failure   *upstreamFailure // Why Deepgram failed the session, if it did
+	sandbox   bool             // Streams to the mock upstream and has no log
 }
 
 func (s *proxySession) run() {
+	id, ok := Sessions.Add(apiKeySessionTag(s.apiKeyID), s.shutdown)
+	if !ok {
+		s.shutdown(CloseServerRestart)
+	}
+	defer Sessions.Remove(id)
+
 	var wg sync.WaitGroup
 	wg.Add(2)
 
@@ -612,11 +881,15 @@ func (s *proxySession) run() {
 
 func (s *proxySession) proxyClientToDeepgram() {
 	for {
+		extendIdleDeadline(s.clientConn)
 		messageType, data, err := s.clientConn.ReadMessage()
 		if err != nil {
 			log.Printf("[Deepgram] Client read error: %v", err)
+			if isIdleTimeout(err) {
+				closeClient(s.clientConn, CloseIdleTimeout, s.lang)
+			}
 			// Client disconnected - send CloseStream to Deepgram
-			_ = s.deepgramConn.WriteMessage(websocket.TextMessage, []byte(`{"type":"CloseStream"}`))
+			_ = writeMessage(s.deepgramConn, websocket.TextMessage, []byte(`{"type":"CloseStream"}`))
 			return
 		}
 
@@ -624,20 +897,38 @@ func (s *proxySession) proxyClientToDeepgram() {
 		if messageType == websocket.BinaryMessage {
 			s.mu.Lock()
 			s.bytesSent += int64(len(data))
+			s.audio.add(len(data), time.Now())
 			s.mu.Unlock()
+			Events.AddBytes(len(data))
 			log.Printf("[Deepgram] Sent %d bytes of audio to Deepgram (total: %d)", len(data), s.bytesSent)
 		} else {
 			log.Printf("[Deepgram] Client sent text message: %s", string(data))
 		}
 
 		// Forward to Deepgram
-		if err := s.deepgramConn.WriteMessage(messageType, data); err != nil {
+		start := time.Now()
+		if err := writeMessage(s.deepgramConn, messageType, data); err != nil {
 			log.Printf("[Deepgram] Error forwarding to Deepgram: %v", err)
 			return
 		}
+		if messageType == websocket.BinaryMessage {
+			if hint := s.pacing.observe(len(data), start, time.Since(start)); hint != nil {
+				log.Printf("[Deepgram] Adaptation hint for session %s: %s (write latency %dms, lag %dms)",
+					s.logID, hint.Reason, hint.WriteLatencyMs, hint.LagMs)
+				sendAdaptationHint(s.clientConn, &s.clientMu, hint)
+			}
+		}
 	}
 }
 
+// writeClient forwards a message to the client; adaptation hints are
+// written from another goroutine
+func (s *proxySession) writeClient(messageType int, data []byte) error {
+	s.clientMu.Lock()
+	defer s.clientMu.Unlock()
+	return writeMessage(s.clientConn, messageType, data)
+}
+
 func (s *proxySession) proxyDeepgramToClient() {
 	clientClosed := false
 
@@ -645,13 +936,22 @@ func (s *proxySession) proxyDeepgramToClient() {
 		messageType, data, err := s.deepgramConn.ReadMessage()
 		if err != nil {
 			log.Printf("[Deepgram] Deepgram read error: %v", err)
+			s.recordFailure(upstreamCloseFailure(err))
+			if !clientClosed {
+				closeClient(s.clientConn, upstreamCloseCode(err), s.lang)
+				s.clientConn.Close()
+			}
 			return
 		}
 
 		// Parse Deepgram response to extract duration from final metadata
 		if messageType == websocket.TextMessage {
 			log.Printf("[Deepgram] Received from Deepgram: %s", string(data))
+			s.recordFailure(upstreamErrorFrame(data))
 			s.extractDurationFromResponse(data)
+			if s.transcript != nil {
+				s.transcript.add(data)
+			}
 
 			// Check if this is the final metadata (Deepgram closes after this)
 			var msg struct {
@@ -661,7 +961,7 @@ func (s *proxySession) proxyDeepgramToClient() {
 				// This could be the final metadata after CloseStream
 				// Try to forward but don't exit if it fails
 				if !clientClosed {
-					if err := s.clientConn.WriteMessage(messageType, data); err != nil {
+					if err := s.writeClient(messageType, data); err != nil {
 						log.Printf("[Deepgram] Client closed, but captured final metadata")
 						clientClosed = true
 					}
@@ -672,7 +972,7 @@ func (s *proxySession) proxyDeepgramToClient() {
 
 		// Forward to client (if still connected)
 		if !clientClosed {
-			if err := s.clientConn.WriteMessage(messageType, data); err != nil {
+			if err := s.writeClient(messageType, data); err != nil {
 				log.Printf("[Deepgram] Error forwarding to client: %v", err)
 				clientClosed = true
 				// Don't return - keep reading from Deepgram to get final metadata
@@ -710,6 +1010,28 @@ func (s *proxySession) extractDurationFromResponse(data []byte) {
 	}
 }
 
+// recordFailure keeps the first error Deepgram reports; the close that
+// usually follows an error message says less about what went wrong
+func (s *proxySession) recordFailure(failure *upstreamFailure) {
+	if failure == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failure == nil {
+		s.failure = failure
+		publishSessionError("deepgram", failure.message)
+	}
+}
+
+// shutdown closes the client connection with the given code. The client
+// read loop then sends CloseStream so Deepgram still delivers the final
+// metadata and the session is logged with its real duration.
+func (s *proxySession) shutdown(code CloseCode) {
+	closeClient(s.clientConn, code, s.lang)
+	s.clientConn.Close()
+}
+
 func (s *proxySession) finalize() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -719,11 +1041,35 @@ func (s *proxySession) finalize() {
 	}
 	s.closed = true
 
+	if s.sandbox {
+		log.Printf("[Sandbox] Session %s ended - duration: %.3f, bytes: %d", s.logID, s.duration, s.bytesSent)
+		return
+	}
+
 	log.Printf("[Deepgram] Finalizing session - duration: %.3f, bytes: %d", s.duration, s.bytesSent)
 
 	ctx := context.Background()
 
-	if s.duration > 0 {
+	if s.failure != nil {
+		// Bill what Deepgram transcribed before failing, or else what was
+		// streamed, like sessions that time out
+		duration, estimated := s.duration, false
+		if duration <= 0 {
+			duration, estimated = s.audio.estimate(), true
+		}
+		log.Printf("[Deepgram] Updating log as error (%s): %s", s.failure.code, s.failure.message)
+		params := sqlc.UpdateTranscriptionLogErrorParams{
+			ID:           s.logID,
+			ErrorMessage: sql.NullString{String: s.failure.message, Valid: true},
+			BytesSent:    s.bytesSent,
+			ErrorCode:    sql.NullString{String: s.failure.code, Valid: true},
+		}
+		if duration > 0 {
+			params.DurationSeconds = stringToNumeric(fmt.Sprintf("%.3f", duration))
+			params.DurationEstimated = estimated
+		}
+		_ = s.queries.UpdateTranscriptionLogError(ctx, params)
+	} else if s.duration > 0 {
 		// Convert float64 to pgtype.Numeric
 		durationStr := fmt.Sprintf("%.3f", s.duration)
 		log.Printf("[Deepgram] Updating log as completed with duration: %s", durationStr)
@@ -732,14 +1078,28 @@ func (s *proxySession) finalize() {
 			DurationSeconds: stringToNumeric(durationStr),
 			BytesSent:       s.bytesSent,
 		})
+	} else if estimate := s.audio.estimate(); estimate > 0 {
+		// The final metadata never arrived (e.g. the connection dropped);
+		// bill what was streamed so aborting cannot bypass the quota
+		durationStr := fmt.Sprintf("%.3f", estimate)
+		log.Printf("[Deepgram] Updating log as timeout with estimated duration: %s", durationStr)
+		_ = s.queries.UpdateTranscriptionLogEstimated(ctx, sqlc.UpdateTranscriptionLogEstimatedParams{
+			ID:              s.logID,
+			DurationSeconds: stringToNumeric(durationStr),
+			BytesSent:       s.bytesSent,
+		})
 	} else {
-		// No duration means possibly a timeout or error
+		// No audio was streamed
 		log.Printf("[Deepgram] Updating log as timeout (no duration captured)")
 		_ = s.queries.UpdateTranscriptionLogTimeout(ctx, sqlc.UpdateTranscriptionLogTimeoutParams{
 			ID:        s.logID,
 			BytesSent: s.bytesSent,
 		})
 	}
+
+	if s.transcript != nil {
+		s.transcript.save(ctx, s.queries, s.logID, s.userID)
+	}
 }
 
 // ========== HELPER FUNCTIONS ==========
@@ -749,31 +1109,82 @@ func hashAPIKey(key string) string {
 	return hex.EncodeToString(hash[:])
 }
 
-func extractDeepgramParams(query url.Values) map[string]string {
-	params := make(map[string]string)
+// keyIDContextKey caches the request's key ID in the echo context
+const keyIDContextKey = "key_id"
 
-	// Whitelist of allowed Deepgram parameters
-	allowedParams := []string{
-		"model", "language", "encoding", "sample_rate", "channels",
-		"punctuate", "diarize", "smart_format", "interim_results",
-		"utterances", "vad_events", "filler_words", "multichannel",
-		"alternatives", "numerals", "profanity_filter", "redact",
-		"search", "replace", "keywords", "endpointing", "tier",
-		"detect_entities", "dictation", "utterance_end_ms", "version",
+// requestKeyID returns the logging.KeyID of the API key the request carries
+// in api_key or X-API-Key, or "" if it carries none. Raw keys are never
+// logged; log this instead.
+func requestKeyID(c echo.Context) string {
+	if id, ok := c.Get(keyIDContextKey).(string); ok {
+		return id
+	}
+	apiKey := c.QueryParam("api_key")
+	if apiKey == "" {
+		apiKey = c.Request().Header.Get("X-API-Key")
 	}
+	id := ""
+	if apiKey != "" {
+		id = logging.KeyID(apiKey)
+	}
+	c.Set(keyIDContextKey, id)
+	return id
+}
+
+// Whitelist of allowed Deepgram parameters
+var allowedDeepgramParams = []string{
+	"model", "language", "encoding", "sample_rate", "channels",
+	"punctuate", "diarize", "smart_format", "interim_results",
+	"utterances", "vad_events", "filler_words", "multichannel",
+	"alternatives", "numerals", "profanity_filter", "redact",
+	"search", "replace", "keywords", "endpointing", "tier",
+	"detect_entities", "dictation", "utterance_end_ms", "version",
+}
 
-	for _, param := range allowedParams {
+func isAllowedDeepgramParam(name string) bool {
+	for _, param := range allowedDeepgramParams {
+		if param == name {
+			return true
+		}
+	}
+	return false
+}
+
+// extractDeepgramParams picks the allowed Deepgram params from the query
+// string, fills the ones the client omitted from the server-side defaults,
+// then applies params enforced by session policies. Enforced values
+// override the client's, except redact which is merged so clients can add
+// redactions but never drop enforced ones.
+func extractDeepgramParams(query url.Values, defaults, enforced map[string]string) map[string]string {
+	params := make(map[string]string)
+
+	for _, param := range allowedDeepgramParams {
 		if value := query.Get(param); value != "" {
 			params[param] = value
+		} else if value := defaults[param]; value != "" {
+			params[param] = value
+		}
+	}
+
+	for k, v := range enforced {
+		if k == "redact" {
+			params[k] = mergeRedact(v, params[k])
+		} else {
+			params[k] = v
 		}
 	}
 
 	return params
 }
 
-func buildDeepgramURL(params map[string]string) string {
-	base := "wss://api.deepgram.com/v1/listen"
+// buildDeepgramURL returns the streaming URL of a session, on the endpoint
+// of its data region
+func buildDeepgramURL(region string, params map[string]string) string {
+	return withDeepgramParams(deepgramEndpoint(region), params)
+}
 
+// withDeepgramParams adds a session's params to a streaming endpoint
+func withDeepgramParams(base string, params map[string]string) string {
 	if len(params) == 0 {
 		return base
 	}
@@ -786,6 +1197,9 @@ func buildDeepgramURL(params map[string]string) string {
 	return base + "?" + query.Encode()
 }
 
+// checkAllowedOrigin accepts WebSocket connections from non-browser
+// clients, same-origin pages and the origins of the host's tenant: its
+// domains, or HyperWhisper's sites and APP_BASE_URL on the operator's hosts
 func checkAllowedOrigin(r *http.Request) bool {
 	origin := r.Header.Get("Origin")
 
@@ -794,18 +1208,28 @@ func checkAllowedOrigin(r *http.Request) bool {
 		return true
 	}
 
-	// Add your allowed origins here
-	allowedOrigins := []string{
-		"https://hyperwhisper.dev",
-		"https://www.hyperwhisper.dev",
+	allowedOrigins := strings.Split(config.String("ALLOWED_ORIGINS"), ",")
+	route := routeOf(r)
+	if route.tenant != nil {
+		allowedOrigins = route.origins
 	}
 
 	for _, allowed := range allowedOrigins {
-		if origin == allowed {
+		if strings.EqualFold(origin, strings.TrimSuffix(strings.TrimSpace(allowed), "/")) {
 			return true
 		}
 	}
-	return false
+
+	// Same-origin pages, including the dashboard served from APP_BASE_URL or
+	// behind a trusted proxy that rewrites the host and scheme
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if base, err := url.Parse(config.String("APP_BASE_URL")); err == nil && route.tenant == nil && u.Scheme == base.Scheme && strings.EqualFold(u.Host, base.Host) {
+		return true
+	}
+	return u.Scheme == requestScheme(r) && strings.EqualFold(u.Host, requestHost(r))
 }
 
 func getPaginationParams(c echo.Context) (page, perPage, offset int) {
@@ -836,13 +1260,27 @@ func toAPIKeyResponse(key sqlc.ApiKey) APIKeyResponse {
 		ID:        key.ID.String(),
 		Name:      key.Name,
 		KeyPrefix: key.KeyPrefix,
+		Sandbox:   IsSandboxKey(key.KeyPrefix),
 		CreatedAt: key.CreatedAt.Time.Format(time.RFC3339),
+		UseCount:  key.UseCount,
+
+		ParamRestrictions: parseParamRestrictions(key.ParamRestrictions),
 	}
 
 	if key.LastUsedAt.Valid {
 		t := key.LastUsedAt.Time.Format(time.RFC3339)
 		resp.LastUsed = &t
 	}
+	if key.LastUsedIp.Valid {
+		resp.LastUsedIP = &key.LastUsedIp.String
+	}
+	if key.LastUsedUserAgent.Valid {
+		resp.LastUsedUserAgent = &key.LastUsedUserAgent.String
+	}
+	if key.OrganizationID.Valid {
+		id := key.OrganizationID.UUID.String()
+		resp.OrganizationID = &id
+	}
 
 	if key.RevokedAt.Valid {
 		t := key.RevokedAt.Time.Format(time.RFC3339)
@@ -854,11 +1292,13 @@ func toAPIKeyResponse(key sqlc.ApiKey) APIKeyResponse {
 
 func toTranscriptionLogResponse(log sqlc.TranscriptionLog) TranscriptionLogResponse {
 	resp := TranscriptionLogResponse{
-		ID:             log.ID.String(),
-		StartedAt:      log.StartedAt.Format(time.RFC3339),
-		Status:         log.Status,
-		DeepgramParams: log.DeepgramParams,
-		BytesSent:      log.BytesSent,
+		ID:                log.ID.String(),
+		StartedAt:         log.StartedAt.Format(time.RFC3339),
+		DurationEstimated: log.DurationEstimated,
+		Status:            log.Status,
+		DeepgramParams:    log.DeepgramParams,
+		BytesSent:         log.BytesSent,
+		Redaction:         parseRedactionAudit(log.RedactionAudit),
 	}
 
 	if log.EndedAt.Valid {
@@ -875,6 +1315,10 @@ func toTranscriptionLogResponse(log sqlc.TranscriptionLog) TranscriptionLogRespo
 		resp.ErrorMessage = &log.ErrorMessage.String
 	}
 
+	if log.ErrorCode.Valid {
+		resp.ErrorCode = &log.ErrorCode.String
+	}
+
 	return resp
 }
 
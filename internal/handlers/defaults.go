@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== DEEPGRAM DEFAULTS ==========
+
+// DeepgramDefaultsResponse is the server-side Deepgram defaults as shown to
+// admins
+type DeepgramDefaultsResponse struct {
+	Params    map[string]string `json:"params"`
+	UpdatedAt string            `json:"updated_at"`
+}
+
+// UpdateDeepgramDefaultsRequest replaces the server-side Deepgram defaults.
+// Empty params clear them, leaving omitted params to Deepgram.
+type UpdateDeepgramDefaultsRequest struct {
+	Params map[string]string `json:"params"`
+	Reason string            `json:"reason"`
+}
+
+// validate checks the request and returns a user-facing error message
+func (r *UpdateDeepgramDefaultsRequest) validate() string {
+	for k, v := range r.Params {
+		if !isAllowedDeepgramParam(k) {
+			return fmt.Sprintf("unsupported Deepgram parameter %q", k)
+		}
+		// A default the client can drop is no place for a compliance setting
+		if k == "redact" {
+			return "redact can only be enforced through session policies"
+		}
+		if strings.TrimSpace(v) == "" {
+			return fmt.Sprintf("params.%s must not be empty", k)
+		}
+	}
+	return ""
+}
+
+// loadDeepgramDefaults returns the server-side defaults for the params a
+// client omits. Sessions go ahead without them if they can't be loaded.
+func loadDeepgramDefaults(ctx context.Context, queries *sqlc.Queries, logTag string) map[string]string {
+	row, err := queries.GetDeepgramDefaults(ctx)
+	if err != nil {
+		log.Printf("[%s] Failed to load Deepgram defaults: %v", logTag, err)
+		return nil
+	}
+	var defaults map[string]string
+	if err := json.Unmarshal(row.Params, &defaults); err != nil {
+		log.Printf("[%s] Invalid Deepgram defaults: %v", logTag, err)
+		return nil
+	}
+	return defaults
+}
+
+// GetDeepgramDefaults returns the server-side Deepgram defaults (admin only)
+func (h *AdminHandler) GetDeepgramDefaults(c echo.Context) error {
+	defaults, err := h.queries.GetDeepgramDefaults(context.Background())
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	return c.JSON(http.StatusOK, toDeepgramDefaultsResponse(defaults))
+}
+
+// UpdateDeepgramDefaults replaces the server-side Deepgram defaults (admin
+// only). They apply to sessions started afterwards.
+func (h *AdminHandler) UpdateDeepgramDefaults(c echo.Context) error {
+	var req UpdateDeepgramDefaultsRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.Params == nil {
+		req.Params = map[string]string{}
+	}
+	if msg := req.validate(); msg != "" {
+		return apiError(http.StatusBadRequest, msg)
+	}
+
+	params, _ := json.Marshal(req.Params)
+	ctx := context.Background()
+
+	defaults, err := h.queries.UpdateDeepgramDefaults(ctx, params)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to update Deepgram defaults")
+	}
+
+	recordAuditEvent(ctx, h.queries, c, auditDeepgramDefaults, "settings", "deepgram", strings.TrimSpace(req.Reason), req.Params)
+
+	log.Printf("[Admin] Deepgram defaults set to %s", params)
+	return c.JSON(http.StatusOK, toDeepgramDefaultsResponse(defaults))
+}
+
+func toDeepgramDefaultsResponse(defaults sqlc.DeepgramDefault) DeepgramDefaultsResponse {
+	resp := DeepgramDefaultsResponse{
+		Params:    map[string]string{},
+		UpdatedAt: defaults.UpdatedAt.Format(time.RFC3339),
+	}
+	_ = json.Unmarshal(defaults.Params, &resp.Params)
+	return resp
+}
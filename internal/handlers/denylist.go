@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+// ========== ACCESS TOKEN DENYLIST ==========
+
+// AccessDenylistSync loads access token denials recorded by every server
+// process into auth.AccessDenylist, and deletes them once they lapse
+type AccessDenylistSync struct {
+	queries *sqlc.Queries
+	// lastID is the newest denial loaded so far
+	lastID int64
+}
+
+// NewAccessDenylistSync creates a sync; Run must be started to load
+// denials
+func NewAccessDenylistSync(db *sql.DB) *AccessDenylistSync {
+	return &AccessDenylistSync{queries: sqlc.New(db)}
+}
+
+// Run loads new denials every ACCESS_DENYLIST_SYNC_INTERVAL until ctx is
+// cancelled
+func (s *AccessDenylistSync) Run(ctx context.Context) {
+	s.load(ctx)
+	ticker := time.NewTicker(config.Duration("ACCESS_DENYLIST_SYNC_INTERVAL"))
+	defer ticker.Stop()
+	pruned := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.load(ctx)
+			if time.Since(pruned) >= time.Minute {
+				s.prune(ctx)
+				pruned = time.Now()
+			}
+		}
+	}
+}
+
+// load applies the denials recorded since the last load
+func (s *AccessDenylistSync) load(ctx context.Context) {
+	denials, err := s.queries.ListAccessTokenDenialsSince(ctx, s.lastID)
+	if err != nil {
+		log.Printf("[Denylist] Failed to load access token denials: %v", err)
+		return
+	}
+	for _, denial := range denials {
+		applyAccessTokenDenial(denial)
+		s.lastID = denial.ID
+	}
+}
+
+// prune drops lapsed denials from memory and the database
+func (s *AccessDenylistSync) prune(ctx context.Context) {
+	auth.AccessDenylist.Prune(time.Now())
+	if err := s.queries.DeleteExpiredAccessTokenDenials(ctx); err != nil {
+		log.Printf("[Denylist] Failed to delete expired access token denials: %v", err)
+	}
+}
+
+// accessDenialExpiry is when a denial recorded now lapses: no access token
+// issued before it is valid any longer
+func accessDenialExpiry() time.Time {
+	return time.Now().Add(time.Duration(getAccessTokenExpiryMinutes()) * time.Minute)
+}
+
+// denyAccessToken denies the access token with the given JTI, or every
+// access token of the session when given a refresh token JTI. This process
+// applies it at once, others on their next sync.
+func denyAccessToken(ctx context.Context, queries *sqlc.Queries, jti string) {
+	recordAccessTokenDenial(ctx, queries, sqlc.CreateAccessTokenDenialParams{
+		TokenJti:  sql.NullString{String: jti, Valid: true},
+		ExpiresAt: accessDenialExpiry(),
+	})
+}
+
+// denyUserAccessTokens denies every access token of a user issued so far
+func denyUserAccessTokens(ctx context.Context, queries *sqlc.Queries, userID uuid.UUID) {
+	recordAccessTokenDenial(ctx, queries, sqlc.CreateAccessTokenDenialParams{
+		UserID:       uuid.NullUUID{UUID: userID, Valid: true},
+		IssuedBefore: sql.NullTime{Time: time.Now(), Valid: true},
+		ExpiresAt:    accessDenialExpiry(),
+	})
+}
+
+func recordAccessTokenDenial(ctx context.Context, queries *sqlc.Queries, arg sqlc.CreateAccessTokenDenialParams) {
+	denial, err := queries.CreateAccessTokenDenial(ctx, arg)
+	if err != nil {
+		// Still denied here; other processes honour the token until it expires
+		log.Printf("[Denylist] Failed to record access token denial: %v", err)
+		denial = sqlc.AccessTokenDenial{
+			TokenJti:     arg.TokenJti,
+			UserID:       arg.UserID,
+			IssuedBefore: arg.IssuedBefore,
+			ExpiresAt:    arg.ExpiresAt,
+		}
+	}
+	applyAccessTokenDenial(denial)
+}
+
+func applyAccessTokenDenial(denial sqlc.AccessTokenDenial) {
+	if denial.TokenJti.Valid {
+		auth.AccessDenylist.DenyToken(denial.TokenJti.String, denial.ExpiresAt)
+	}
+	if denial.UserID.Valid && denial.IssuedBefore.Valid {
+		auth.AccessDenylist.DenyUser(denial.UserID.UUID, denial.IssuedBefore.Time, denial.ExpiresAt)
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// TestApplyAccessTokenDenial checks that denials loaded by the sync, as
+// recorded by any server process, are enforced here
+func TestApplyAccessTokenDenial(t *testing.T) {
+	now := time.Now()
+	userID := uuid.New()
+	jti := uuid.NewString()
+	sessionJTI := uuid.NewString()
+
+	applyAccessTokenDenial(sqlc.AccessTokenDenial{
+		TokenJti:  sql.NullString{String: jti, Valid: true},
+		ExpiresAt: now.Add(time.Hour),
+	})
+	applyAccessTokenDenial(sqlc.AccessTokenDenial{
+		TokenJti:  sql.NullString{String: sessionJTI, Valid: true},
+		ExpiresAt: now.Add(time.Hour),
+	})
+	applyAccessTokenDenial(sqlc.AccessTokenDenial{
+		UserID:       uuid.NullUUID{UUID: userID, Valid: true},
+		IssuedBefore: sql.NullTime{Time: now, Valid: true},
+		ExpiresAt:    now.Add(time.Hour),
+	})
+
+	claims := func(id, session string, user uuid.UUID, issuedAt time.Time) *auth.Claims {
+		return &auth.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{ID: id, IssuedAt: jwt.NewNumericDate(issuedAt)},
+			SessionID:        session,
+			UserID:           user,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		claims *auth.Claims
+		want   bool
+	}{
+		{name: "denied access token", claims: claims(jti, "", uuid.New(), now), want: true},
+		{name: "token of a denied session", claims: claims(uuid.NewString(), sessionJTI, uuid.New(), now), want: true},
+		{name: "user's earlier token", claims: claims(uuid.NewString(), "", userID, now.Add(-time.Minute)), want: true},
+		{name: "user's later token", claims: claims(uuid.NewString(), "", userID, now.Add(time.Minute)), want: false},
+		{name: "other user's token", claims: claims(uuid.NewString(), uuid.NewString(), uuid.New(), now.Add(-time.Minute)), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := auth.AccessDenylist.Denied(tt.claims); got != tt.want {
+				t.Errorf("Denied() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Denials lapse with the longest-lived access token they could match
+	auth.AccessDenylist.Prune(now.Add(2 * time.Hour))
+	if auth.AccessDenylist.Denied(claims(jti, "", uuid.New(), now)) {
+		t.Error("denial still applies after it lapsed")
+	}
+}
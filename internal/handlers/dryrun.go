@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"hyperwhisper/internal/auth"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== DRY RUNS ==========
+
+// confirmationTTL is how long a dry run's confirmation token stays valid
+const confirmationTTL = 5 * time.Minute
+
+// maxDryRunIDs caps the IDs listed in a dry run response; the counts are
+// always complete
+const maxDryRunIDs = 100
+
+// Destructive admin operations that support dry runs
+const (
+	actionUserDelete       = "user.delete"
+	actionUserRevokeTokens = "user.revoke_tokens"
+	actionTokensCleanup    = "tokens.cleanup"
+	actionTrialKeyDelete   = "trial_key.delete"
+	actionTrialKeysCleanup = "trial_keys.cleanup"
+)
+
+// DryRunResponse previews a destructive operation. Running it for real
+// requires passing ConfirmationToken as the confirmation_token query
+// parameter before ExpiresAt.
+type DryRunResponse struct {
+	DryRun            bool             `json:"dry_run"`
+	Action            string           `json:"action"`
+	Target            string           `json:"target,omitempty"`
+	Affected          map[string]int64 `json:"affected"`
+	IDs               []string         `json:"ids"`
+	IDsTruncated      bool             `json:"ids_truncated"`
+	ConfirmationToken string           `json:"confirmation_token"`
+	ExpiresAt         string           `json:"expires_at"`
+}
+
+// isDryRun reports whether the request only previews the operation
+func isDryRun(c echo.Context) bool {
+	return c.QueryParam("dry_run") == "true"
+}
+
+// dryRun responds with what an operation would affect and a confirmation
+// token for running it
+func dryRun(c echo.Context, action, target string, affected map[string]int64, ids []string) error {
+	adminID := currentAdminID(c)
+	token, err := auth.GenerateConfirmationToken(adminID, action, target, confirmationTTL)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to generate confirmation token")
+	}
+
+	resp := DryRunResponse{
+		DryRun:            true,
+		Action:            action,
+		Target:            target,
+		Affected:          affected,
+		IDs:               ids,
+		ConfirmationToken: token,
+		ExpiresAt:         time.Now().Add(confirmationTTL).UTC().Format(time.RFC3339),
+	}
+	if len(resp.IDs) > maxDryRunIDs {
+		resp.IDs = resp.IDs[:maxDryRunIDs]
+		resp.IDsTruncated = true
+	}
+	if resp.IDs == nil {
+		resp.IDs = []string{}
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// requireConfirmation checks the confirmation token of a destructive
+// operation's real run. It returns the error to respond with 428
+// Precondition Required, or nil when the run may go ahead.
+func requireConfirmation(c echo.Context, action, target string) *ErrorResponse {
+	token := c.QueryParam("confirmation_token")
+	if token == "" {
+		return &ErrorResponse{Error: "confirmation token required"}
+	}
+
+	err := auth.ValidateConfirmationToken(token, currentAdminID(c), action, target)
+	if errors.Is(err, auth.ErrExpiredToken) {
+		return &ErrorResponse{Error: "confirmation token expired"}
+	}
+	if err != nil {
+		return &ErrorResponse{Error: "invalid confirmation token"}
+	}
+	return nil
+}
+
+// currentAdminID is the user ID of the authenticated admin
+func currentAdminID(c echo.Context) uuid.UUID {
+	if claims := auth.GetUserFromContext(c); claims != nil {
+		return claims.UserID
+	}
+	return uuid.Nil
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+)
+
+// rawBytesPerSample is the sample width of the raw encodings Deepgram
+// accepts. Compressed or containerized audio has no fixed byte rate.
+var rawBytesPerSample = map[string]int{
+	"linear16": 2,
+	"linear32": 4,
+	"mulaw":    1,
+	"alaw":     1,
+}
+
+// audioClock tracks the audio a session streams so its duration can be
+// estimated when Deepgram's final metadata never arrives (a dropped
+// connection). Without it such sessions would be logged with no duration
+// and escape quota accounting.
+type audioClock struct {
+	encoding   string
+	sampleRate int
+	channels   int
+
+	bytes int64
+	first time.Time
+	last  time.Time
+}
+
+// newAudioClock reads the audio format from the session's Deepgram params
+func newAudioClock(params map[string]string) audioClock {
+	a := audioClock{encoding: params["encoding"], channels: 1}
+	if v, err := strconv.Atoi(params["sample_rate"]); err == nil && v > 0 {
+		a.sampleRate = v
+	}
+	if v, err := strconv.Atoi(params["channels"]); err == nil && v > 0 {
+		a.channels = v
+	}
+	return a
+}
+
+// add records an audio message of n bytes received at now
+func (a *audioClock) add(n int, now time.Time) {
+	if a.first.IsZero() {
+		a.first = now
+	}
+	a.last = now
+	a.bytes += int64(n)
+}
+
+// estimate returns the streamed duration in seconds. Raw audio is measured
+// from its byte count; other encodings fall back to the wall clock between
+// the first and last audio message.
+func (a *audioClock) estimate() float64 {
+	if a.bytes == 0 {
+		return 0
+	}
+	if width, ok := rawBytesPerSample[a.encoding]; ok && a.sampleRate > 0 {
+		return float64(a.bytes) / float64(a.sampleRate*a.channels*width)
+	}
+	return a.last.Sub(a.first).Seconds()
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// eventBufferSize is how many events a subscriber may fall behind before
+// further events are dropped for it
+const eventBufferSize = 256
+
+// Event types published on the event bus
+const (
+	EventSessionStarted = "session_started"
+	EventSessionEnded   = "session_ended"
+	EventError          = "error"
+	EventStats          = "stats"
+	EventBudgetAlert    = "budget_alert"
+)
+
+// Event is a server event as streamed to the admin monitor. Fields that do
+// not apply to the event type are omitted.
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+
+	// Session events
+	Kind            string  `json:"kind,omitempty"` // "api_key", "trial_key" or "user"
+	Tag             string  `json:"tag,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+
+	// Error events
+	Source  string `json:"source,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// Stats events
+	Concurrency    *int     `json:"concurrency,omitempty"`
+	BytesPerSecond *float64 `json:"bytes_per_second,omitempty"`
+
+	// Budget alert events
+	Month     string   `json:"month,omitempty"`
+	Threshold int      `json:"threshold,omitempty"` // Percent of the alert budget
+	Spend     *float64 `json:"spend,omitempty"`
+	Budget    *float64 `json:"budget,omitempty"`
+	Forecast  *float64 `json:"forecast,omitempty"`
+}
+
+// EventBus fans server events out to subscribers. Publishing never blocks:
+// a subscriber that falls behind misses events rather than stalling a proxy.
+type EventBus struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+	bytes       atomic.Int64
+}
+
+// Events is the process-wide event bus
+var Events = NewEventBus()
+
+// NewEventBus creates an event bus without subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving every event published from now on
+// and a function that ends the subscription
+func (b *EventBus) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, eventBufferSize)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+		})
+	}
+}
+
+// Publish sends an event to every subscriber
+func (b *EventBus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// AddBytes counts audio forwarded to Deepgram
+func (b *EventBus) AddBytes(n int) {
+	b.bytes.Add(int64(n))
+}
+
+// Bytes returns the audio forwarded to Deepgram since the server started
+func (b *EventBus) Bytes() int64 {
+	return b.bytes.Load()
+}
+
+// publishSessionError reports a failed session on the event bus
+func publishSessionError(source, message string) {
+	Events.Publish(Event{Type: EventError, Source: source, Message: message})
+}
+
+// sessionKind is the kind of owner encoded in a session tracker tag
+func sessionKind(tag string) string {
+	kind, _, _ := strings.Cut(tag, ":")
+	return kind
+}
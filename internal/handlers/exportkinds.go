@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/db/sqlc"
+)
+
+// ========== EXPORT KINDS ==========
+
+// exportBatchSize is how many rows an export reads per query
+const exportBatchSize = 500
+
+var errUnknownExportKind = errors.New("unknown export kind")
+
+// exportKind is something that can be exported. parseParams validates the
+// params of a request and returns them normalized, as they are stored on
+// the job; write produces the file from them.
+type exportKind struct {
+	adminOnly   bool
+	ext         string
+	parseParams func(raw json.RawMessage) (json.RawMessage, error)
+	write       func(ctx context.Context, queries *sqlc.Queries, job sqlc.ExportJob, w io.Writer, progress *exportProgress) error
+}
+
+// exportKinds are the exports users can request, by the kind they name
+var exportKinds = map[string]exportKind{
+	// Everything stored about the caller, for data portability requests
+	"account": {
+		ext:         ".json",
+		parseParams: noExportParams,
+		write:       writeAccountExport,
+	},
+	// Every transcription session of every user
+	"transcription_logs": {
+		adminOnly:   true,
+		ext:         ".csv",
+		parseParams: noExportParams,
+		write:       writeTranscriptionLogsExport,
+	},
+	// Sessions and seconds transcribed per user in a calendar month (UTC)
+	"usage": {
+		adminOnly:   true,
+		ext:         ".csv",
+		parseParams: parseUsageExportParams,
+		write:       writeUsageExport,
+	},
+}
+
+// noExportParams accepts only empty params
+func noExportParams(raw json.RawMessage) (json.RawMessage, error) {
+	var params map[string]json.RawMessage
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, errors.New("params must be an object")
+		}
+	}
+	for name := range params {
+		return nil, fmt.Errorf("unknown param %q", name)
+	}
+	return json.RawMessage(`{}`), nil
+}
+
+// ---------- account ----------
+
+// AccountExport is the file of an account export
+type AccountExport struct {
+	ExportedAt        string                     `json:"exported_at"`
+	User              UserResponse               `json:"user"`
+	APIKeys           []APIKeyResponse           `json:"api_keys"`
+	Organizations     []OrganizationResponse     `json:"organizations"`
+	Sessions          []UserSessionResponse      `json:"sessions"`
+	TranscriptionLogs []TranscriptionLogResponse `json:"transcription_logs"`
+}
+
+func writeAccountExport(ctx context.Context, queries *sqlc.Queries, job sqlc.ExportJob, w io.Writer, progress *exportProgress) error {
+	user, err := queries.GetUserByID(ctx, job.UserID)
+	if err != nil {
+		return err
+	}
+	totalLogs, err := queries.CountUserTranscriptionLogs(ctx, job.UserID)
+	if err != nil {
+		return err
+	}
+	progress.setTotal(ctx, totalLogs)
+
+	export := AccountExport{
+		ExportedAt:        time.Now().UTC().Format(time.RFC3339),
+		User:              toUserResponse(user),
+		APIKeys:           []APIKeyResponse{},
+		Organizations:     []OrganizationResponse{},
+		Sessions:          []UserSessionResponse{},
+		TranscriptionLogs: []TranscriptionLogResponse{},
+	}
+
+	for offset := int32(0); ; offset += exportBatchSize {
+		keys, err := queries.ListUserAPIKeys(ctx, sqlc.ListUserAPIKeysParams{
+			UserID: job.UserID,
+			Limit:  exportBatchSize,
+			Offset: offset,
+		})
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			export.APIKeys = append(export.APIKeys, toAPIKeyResponse(key))
+		}
+		if len(keys) < exportBatchSize {
+			break
+		}
+	}
+
+	orgs, err := queries.ListUserOrganizations(ctx, job.UserID)
+	if err != nil {
+		return err
+	}
+	for _, o := range orgs {
+		org := toOrganizationResponse(sqlc.Organization{
+			ID:                  o.ID,
+			Name:                o.Name,
+			MonthlyQuotaSeconds: o.MonthlyQuotaSeconds,
+			CreatedAt:           o.CreatedAt,
+		})
+		org.Role = o.Role
+		export.Organizations = append(export.Organizations, org)
+	}
+
+	tokens, err := queries.ListUserActiveRefreshTokens(ctx, job.UserID)
+	if err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		export.Sessions = append(export.Sessions, toUserSessionResponse(token, ""))
+	}
+
+	for offset := int32(0); ; offset += exportBatchSize {
+		logs, err := queries.ListUserTranscriptionLogs(ctx, sqlc.ListUserTranscriptionLogsParams{
+			UserID: job.UserID,
+			Limit:  exportBatchSize,
+			Offset: offset,
+		})
+		if err != nil {
+			return err
+		}
+		for _, log := range logs {
+			export.TranscriptionLogs = append(export.TranscriptionLogs, toTranscriptionLogResponse(log))
+		}
+		progress.add(ctx, len(logs))
+		if len(logs) < exportBatchSize {
+			break
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(export)
+}
+
+// ---------- transcription_logs ----------
+
+func writeTranscriptionLogsExport(ctx context.Context, queries *sqlc.Queries, job sqlc.ExportJob, w io.Writer, progress *exportProgress) error {
+	total, err := queries.CountAllTranscriptionLogs(ctx)
+	if err != nil {
+		return err
+	}
+	progress.setTotal(ctx, total)
+
+	out := newExportCSV(w)
+	out.write("id", "started_at", "ended_at", "duration_seconds", "duration_estimated", "status",
+		"error_message", "error_code", "bytes_sent", "user_id", "username", "email", "api_key_id", "api_key_name")
+
+	for offset := int32(0); ; offset += exportBatchSize {
+		logs, err := queries.ListAllTranscriptionLogs(ctx, sqlc.ListAllTranscriptionLogsParams{
+			Limit:  exportBatchSize,
+			Offset: offset,
+		})
+		if err != nil {
+			return err
+		}
+		for _, l := range logs {
+			endedAt := ""
+			if l.EndedAt.Valid {
+				endedAt = l.EndedAt.Time.UTC().Format(time.RFC3339)
+			}
+			out.write(l.ID.String(), l.StartedAt.UTC().Format(time.RFC3339), endedAt,
+				l.DurationSeconds.String, strconv.FormatBool(l.DurationEstimated), l.Status,
+				l.ErrorMessage.String, l.ErrorCode.String, strconv.FormatInt(l.BytesSent, 10), l.UserID.String(),
+				l.Username, l.Email, l.ApiKeyID.String(), l.ApiKeyName)
+		}
+		if err := out.flush(); err != nil {
+			return err
+		}
+		progress.add(ctx, len(logs))
+		if len(logs) < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+// ---------- usage ----------
+
+// UsageExportParams picks the month of a usage export
+type UsageExportParams struct {
+	Month string `json:"month"` // YYYY-MM, the current month when empty
+}
+
+func parseUsageExportParams(raw json.RawMessage) (json.RawMessage, error) {
+	var params UsageExportParams
+	if len(raw) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&params); err != nil {
+			return nil, errors.New("params must be an object with an optional month")
+		}
+	}
+	if params.Month == "" {
+		params.Month = time.Now().UTC().Format("2006-01")
+	}
+	if _, err := time.Parse("2006-01", params.Month); err != nil {
+		return nil, errors.New("month must be formatted as YYYY-MM")
+	}
+	return json.Marshal(params)
+}
+
+func writeUsageExport(ctx context.Context, queries *sqlc.Queries, job sqlc.ExportJob, w io.Writer, progress *exportProgress) error {
+	var params UsageExportParams
+	if err := json.Unmarshal(job.Params, &params); err != nil {
+		return err
+	}
+	start, err := time.Parse("2006-01", params.Month)
+	if err != nil {
+		return err
+	}
+
+	users, err := queries.ListUserUsageTotals(ctx, sqlc.ListUserUsageTotalsParams{
+		StartDate: start,
+		EndDate:   start.AddDate(0, 1, 0),
+	})
+	if err != nil {
+		return err
+	}
+	progress.setTotal(ctx, int64(len(users)))
+
+	out := newExportCSV(w)
+	out.write("month", "user_id", "username", "sessions", "duration_seconds")
+	for _, u := range users {
+		out.write(params.Month, u.ID.String(), u.Username,
+			strconv.FormatInt(u.TotalSessions, 10), u.TotalDurationSeconds)
+	}
+	progress.add(ctx, len(users))
+	return out.flush()
+}
+
+// ---------- CSV ----------
+
+// exportCSV writes CSV rows, keeping cells that spreadsheets would run as
+// formulas as text
+type exportCSV struct {
+	buf *bufio.Writer
+	csv *csv.Writer
+	err error
+}
+
+func newExportCSV(w io.Writer) *exportCSV {
+	buf := bufio.NewWriter(w)
+	return &exportCSV{buf: buf, csv: csv.NewWriter(buf)}
+}
+
+func (e *exportCSV) write(cells ...string) {
+	if e.err != nil {
+		return
+	}
+	for i, cell := range cells {
+		if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+			cells[i] = "'" + cell
+		}
+	}
+	e.err = e.csv.Write(cells)
+}
+
+func (e *exportCSV) flush() error {
+	if e.err != nil {
+		return e.err
+	}
+	e.csv.Flush()
+	if err := e.csv.Error(); err != nil {
+		return err
+	}
+	return e.buf.Flush()
+}
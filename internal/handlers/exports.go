@@ -0,0 +1,501 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/mail"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== EXPORT JOBS ==========
+
+const (
+	// exportPollInterval is how often workers look for pending jobs when
+	// they have not been woken up
+	exportPollInterval = 5 * time.Second
+	// exportMaintenanceInterval is how often expired files are deleted
+	exportMaintenanceInterval = time.Minute
+	// exportStaleAfter fails running jobs that reported no progress for this
+	// long, e.g. because the server was restarted while they ran
+	exportStaleAfter = 10 * time.Minute
+	// exportProgressInterval throttles progress updates
+	exportProgressInterval = time.Second
+)
+
+// Export job statuses
+const (
+	exportPending   = "pending"
+	exportRunning   = "running"
+	exportCompleted = "completed"
+	exportFailed    = "failed"
+	exportExpired   = "expired"
+)
+
+// CreateExportRequest starts an export
+type CreateExportRequest struct {
+	Kind   string          `json:"kind"`
+	Params json.RawMessage `json:"params"`
+}
+
+// ExportJobResponse is an export job. Progress is the share of rows written
+// (0 to 1) once the total is known; download_url is set while the file can
+// be downloaded.
+type ExportJobResponse struct {
+	ID          string          `json:"id"`
+	Kind        string          `json:"kind"`
+	Params      json.RawMessage `json:"params"`
+	Status      string          `json:"status"`
+	RowsWritten int64           `json:"rows_written"`
+	RowsTotal   int64           `json:"rows_total"`
+	Progress    *float64        `json:"progress"`
+	SizeBytes   int64           `json:"size_bytes"`
+	Error       *string         `json:"error,omitempty"`
+	CreatedAt   string          `json:"created_at"`
+	StartedAt   *string         `json:"started_at"`
+	CompletedAt *string         `json:"completed_at"`
+	ExpiresAt   *string         `json:"expires_at"`
+	DownloadURL *string         `json:"download_url"`
+}
+
+// ExportHandler queues export jobs and, through Run, builds their files and
+// sends scheduled reports
+type ExportHandler struct {
+	queries *sqlc.Queries
+	mailer  mail.Sender
+	wake    chan struct{}
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(db *sql.DB) *ExportHandler {
+	return &ExportHandler{
+		queries: sqlc.New(db),
+		mailer:  mail.NewFromConfig(),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// CreateExport queues an export of one of the exportKinds for the caller.
+// Admin-only kinds are audited. A user has at most one unfinished export
+// of each kind.
+func (h *ExportHandler) CreateExport(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	var req CreateExportRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	kind, ok := exportKinds[req.Kind]
+	if !ok {
+		names := make([]string, 0, len(exportKinds))
+		for name := range exportKinds {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return newAPIError(http.StatusBadRequest, ErrorResponse{
+			Error:   "unknown export kind",
+			Details: map[string]string{"kind": "must be one of " + strings.Join(names, ", ")},
+		})
+	}
+	if kind.adminOnly && claims.UserType != "admin" {
+		return apiError(http.StatusForbidden, "admin access required")
+	}
+	// Admin exports span every tenant's users
+	if kind.adminOnly && claims.TenantID != uuid.Nil {
+		return apiError(http.StatusForbidden, "not available to tenant staff")
+	}
+
+	params, err := kind.parseParams(req.Params)
+	if err != nil {
+		return newAPIError(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid export params",
+			Details: map[string]string{"params": err.Error()},
+		})
+	}
+
+	return h.queue(c, req.Kind, params)
+}
+
+// CreateAccountExport queues an export of everything stored about the
+// caller (the account kind), for data portability requests
+func (h *ExportHandler) CreateAccountExport(c echo.Context) error {
+	params, _ := noExportParams(nil)
+	return h.queue(c, "account", params)
+}
+
+// queue creates an export job of the caller and wakes the worker
+func (h *ExportHandler) queue(c echo.Context, kindName string, params json.RawMessage) error {
+	claims := auth.GetUserFromContext(c)
+	kind := exportKinds[kindName]
+
+	ctx := context.Background()
+	unfinished, err := h.queries.CountUnfinishedExportJobs(ctx, sqlc.CountUnfinishedExportJobsParams{
+		UserID: claims.UserID,
+		Kind:   kindName,
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if unfinished > 0 {
+		return apiError(http.StatusConflict, "an export of this kind is already in progress")
+	}
+
+	job, err := h.queries.CreateExportJob(ctx, sqlc.CreateExportJobParams{
+		UserID: claims.UserID,
+		Kind:   kindName,
+		Params: params,
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to create export")
+	}
+
+	if kind.adminOnly {
+		recordAuditEvent(ctx, h.queries, c, auditDataExport, "export", job.ID.String(), "",
+			map[string]string{"kind": job.Kind, "params": string(job.Params)})
+	}
+
+	// Wake the worker rather than waiting for its next poll
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+
+	log.Printf("[Export] User %s queued %s export %s", claims.UserID, job.Kind, job.ID)
+	return c.JSON(http.StatusAccepted, toExportJobResponse(job))
+}
+
+// ListExports returns the caller's exports, newest first
+func (h *ExportHandler) ListExports(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	page, perPage, offset := getPaginationParams(c)
+	ctx := context.Background()
+
+	jobs, err := h.queries.ListUserExportJobs(ctx, sqlc.ListUserExportJobsParams{
+		UserID: claims.UserID,
+		Limit:  int32(perPage),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	total, err := h.queries.CountUserExportJobs(ctx, claims.UserID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	responses := make([]ExportJobResponse, len(jobs))
+	for i, job := range jobs {
+		responses[i] = toExportJobResponse(job)
+	}
+
+	return c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       responses,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: calculateTotalPages(total, perPage),
+	})
+}
+
+// GetExport returns one of the caller's exports, for polling its progress
+func (h *ExportHandler) GetExport(c echo.Context) error {
+	job, err := h.ownExport(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, toExportJobResponse(job))
+}
+
+// DownloadExport sends the file of one of the caller's completed exports
+func (h *ExportHandler) DownloadExport(c echo.Context) error {
+	job, err := h.ownExport(c)
+	if err != nil {
+		return err
+	}
+
+	switch job.Status {
+	case exportCompleted:
+	case exportExpired:
+		return apiError(http.StatusGone, "export has expired")
+	default:
+		return apiError(http.StatusConflict, "export is not ready")
+	}
+
+	path := filepath.Join(config.String("EXPORT_DIR"), job.FileName.String)
+	if _, err := os.Stat(path); err != nil {
+		log.Printf("[Export] File of export %s is missing: %v", job.ID, err)
+		return apiError(http.StatusGone, "export has expired")
+	}
+
+	name := "hyperwhisper-" + job.Kind + "-" + job.CreatedAt.UTC().Format("2006-01-02") + exportKinds[job.Kind].ext
+	return c.Attachment(path, name)
+}
+
+// ownExport loads the export in the id parameter; other users' exports are
+// indistinguishable from unknown ones
+func (h *ExportHandler) ownExport(c echo.Context) (sqlc.ExportJob, error) {
+	claims := auth.GetUserFromContext(c)
+
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return sqlc.ExportJob{}, apiError(http.StatusBadRequest, "invalid export ID")
+	}
+
+	job, err := h.queries.GetExportJob(context.Background(), jobID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return job, apiError(http.StatusNotFound, "export not found")
+		}
+		return job, apiError(http.StatusInternalServerError, "database error")
+	}
+	if job.UserID != claims.UserID {
+		return job, apiError(http.StatusNotFound, "export not found")
+	}
+	return job, nil
+}
+
+// Run builds pending exports one at a time until ctx ends, deletes the
+// files of expired ones and sends scheduled reports once their period has
+// ended. Jobs are claimed in the database, so several servers can run
+// workers; each serves downloads from its own EXPORT_DIR, which they should
+// share.
+func (h *ExportHandler) Run(ctx context.Context) {
+	h.maintain(ctx)
+	h.sendDueReports(ctx)
+	poll := time.NewTicker(exportPollInterval)
+	defer poll.Stop()
+	maintenance := time.NewTicker(exportMaintenanceInterval)
+	defer maintenance.Stop()
+
+	for {
+		for ctx.Err() == nil && h.runNext(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.wake:
+		case <-poll.C:
+		case <-maintenance.C:
+			h.maintain(ctx)
+			h.sendDueReports(ctx)
+		}
+	}
+}
+
+// runNext claims and builds the oldest pending export, reporting whether
+// there was one
+func (h *ExportHandler) runNext(ctx context.Context) bool {
+	job, err := h.queries.ClaimExportJob(ctx)
+	if err != nil {
+		if err != sql.ErrNoRows && ctx.Err() == nil {
+			log.Printf("[Export] Failed to claim export: %v", err)
+		}
+		return false
+	}
+
+	started := time.Now()
+	rows, size, err := h.build(ctx, job)
+	if err != nil {
+		log.Printf("[Export] %s export %s failed: %v", job.Kind, job.ID, err)
+		msg := "export failed"
+		if ctx.Err() != nil {
+			msg = "interrupted"
+		}
+		if err := h.queries.FailExportJob(context.Background(), sqlc.FailExportJobParams{
+			ID:    job.ID,
+			Error: sql.NullString{String: msg, Valid: true},
+		}); err != nil {
+			log.Printf("[Export] Failed to mark export %s as failed: %v", job.ID, err)
+		}
+		return true
+	}
+
+	err = h.queries.CompleteExportJob(context.Background(), sqlc.CompleteExportJobParams{
+		ID:          job.ID,
+		FileName:    sql.NullString{String: exportFileName(job), Valid: true},
+		SizeBytes:   size,
+		RowsWritten: rows,
+		ExpiresAt:   sql.NullTime{Time: time.Now().Add(config.Duration("EXPORT_TTL")), Valid: true},
+	})
+	if err != nil {
+		log.Printf("[Export] Failed to complete export %s: %v", job.ID, err)
+		removeExportFiles(job.ID)
+		return true
+	}
+
+	log.Printf("[Export] %s export %s finished: %d rows, %d bytes in %s",
+		job.Kind, job.ID, rows, size, time.Since(started).Round(time.Millisecond))
+	return true
+}
+
+// build writes an export's file, returning the rows written and its size.
+// Nothing is left behind when it fails.
+func (h *ExportHandler) build(ctx context.Context, job sqlc.ExportJob) (int64, int64, error) {
+	kind, ok := exportKinds[job.Kind]
+	if !ok {
+		return 0, 0, errUnknownExportKind
+	}
+
+	dir := config.String("EXPORT_DIR")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return 0, 0, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, exportFileName(job)), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	progress := &exportProgress{queries: h.queries, jobID: job.ID}
+	err = kind.write(ctx, h.queries, job, f, progress)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		removeExportFiles(job.ID)
+		return 0, 0, err
+	}
+
+	info, err := os.Stat(f.Name())
+	if err != nil {
+		removeExportFiles(job.ID)
+		return 0, 0, err
+	}
+	return progress.written, info.Size(), nil
+}
+
+// maintain fails exports whose worker went away and deletes the files of
+// expired ones
+func (h *ExportHandler) maintain(ctx context.Context) {
+	stale, err := h.queries.FailStaleExportJobs(ctx, time.Now().Add(-exportStaleAfter))
+	if err != nil {
+		log.Printf("[Export] Failed to fail stale exports: %v", err)
+	}
+	for _, id := range stale {
+		removeExportFiles(id)
+		log.Printf("[Export] Export %s was interrupted", id)
+	}
+
+	expired, err := h.queries.ListExpiredExportJobs(ctx)
+	if err != nil {
+		log.Printf("[Export] Failed to list expired exports: %v", err)
+		return
+	}
+	for _, job := range expired {
+		removeExportFiles(job.ID)
+		if err := h.queries.ExpireExportJob(ctx, job.ID); err != nil {
+			log.Printf("[Export] Failed to expire export %s: %v", job.ID, err)
+		}
+	}
+	if len(expired) > 0 {
+		log.Printf("[Export] Deleted %d expired export(s)", len(expired))
+	}
+}
+
+// exportFileName is where an export's file is kept in EXPORT_DIR
+func exportFileName(job sqlc.ExportJob) string {
+	return job.ID.String() + exportKinds[job.Kind].ext
+}
+
+// removeExportFiles deletes whatever file an export left in EXPORT_DIR
+func removeExportFiles(id uuid.UUID) {
+	matches, _ := filepath.Glob(filepath.Join(config.String("EXPORT_DIR"), id.String()+".*"))
+	for _, path := range matches {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("[Export] Failed to delete %s: %v", path, err)
+		}
+	}
+}
+
+// exportProgress counts the rows an export has written and reports them,
+// at most once per exportProgressInterval, which also shows the job is
+// still alive
+type exportProgress struct {
+	queries  *sqlc.Queries
+	jobID    uuid.UUID
+	written  int64
+	total    int64
+	reported time.Time
+}
+
+// setTotal records how many rows the export will write
+func (p *exportProgress) setTotal(ctx context.Context, total int64) {
+	p.total = total
+	p.report(ctx)
+}
+
+// add counts written rows
+func (p *exportProgress) add(ctx context.Context, rows int) {
+	p.written += int64(rows)
+	if time.Since(p.reported) >= exportProgressInterval {
+		p.report(ctx)
+	}
+}
+
+func (p *exportProgress) report(ctx context.Context) {
+	p.reported = time.Now()
+	if err := p.queries.UpdateExportJobProgress(ctx, sqlc.UpdateExportJobProgressParams{
+		ID:          p.jobID,
+		RowsWritten: p.written,
+		RowsTotal:   p.total,
+	}); err != nil {
+		log.Printf("[Export] Failed to update progress of export %s: %v", p.jobID, err)
+	}
+}
+
+func toExportJobResponse(job sqlc.ExportJob) ExportJobResponse {
+	resp := ExportJobResponse{
+		ID:          job.ID.String(),
+		Kind:        job.Kind,
+		Params:      job.Params,
+		Status:      job.Status,
+		RowsWritten: job.RowsWritten,
+		RowsTotal:   job.RowsTotal,
+		SizeBytes:   job.SizeBytes,
+		CreatedAt:   job.CreatedAt.Format(time.RFC3339),
+	}
+	if job.Status == exportCompleted {
+		done := 1.0
+		resp.Progress = &done
+		url := "/api/v1/exports/" + job.ID.String() + "/download"
+		resp.DownloadURL = &url
+	} else if job.RowsTotal > 0 {
+		share := float64(job.RowsWritten) / float64(job.RowsTotal)
+		if share > 1 {
+			share = 1
+		}
+		resp.Progress = &share
+	}
+	if job.Error.Valid {
+		resp.Error = &job.Error.String
+	}
+	if job.StartedAt.Valid {
+		t := job.StartedAt.Time.Format(time.RFC3339)
+		resp.StartedAt = &t
+	}
+	if job.CompletedAt.Valid {
+		t := job.CompletedAt.Time.Format(time.RFC3339)
+		resp.CompletedAt = &t
+	}
+	if job.ExpiresAt.Valid {
+		t := job.ExpiresAt.Time.Format(time.RFC3339)
+		resp.ExpiresAt = &t
+	}
+	return resp
+}
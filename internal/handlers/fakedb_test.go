@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"hyperwhisper/internal/encryption"
+
+	"github.com/lib/pq"
+)
+
+// fakeDB is a database/sql driver for handler tests. It answers the queries
+// sqlc generates by the name in their "-- name:" comment, with results
+// scripted by the test, and records every call. Queries nothing was scripted
+// for return no rows, and statements affect none.
+type fakeDB struct {
+	mu      sync.Mutex
+	results map[string][]fakeResult
+	calls   []fakeCall
+}
+
+type fakeResult struct {
+	rows     [][]driver.Value
+	affected int64
+	err      error
+}
+
+// fakeCall is a query or statement run against a fakeDB. Transactions are
+// recorded as the calls "BEGIN", "COMMIT" and "ROLLBACK".
+type fakeCall struct {
+	name string
+	args []any
+}
+
+// newFakeDB returns a fakeDB and a *sql.DB connected to it
+func newFakeDB(t *testing.T) (*fakeDB, *sql.DB) {
+	t.Helper()
+	f := &fakeDB{results: make(map[string][]fakeResult)}
+	db := sql.OpenDB(f)
+	t.Cleanup(func() { db.Close() })
+	return f, db
+}
+
+// returns queues the rows the next run of query returns. Rows are sqlc
+// models or row structs, whose fields are the columns in order, or single
+// values for one-column queries.
+func (f *fakeDB) returns(query string, rows ...any) {
+	result := fakeResult{affected: int64(len(rows))}
+	for _, row := range rows {
+		result.rows = append(result.rows, fakeColumns(row))
+	}
+	f.queue(query, result)
+}
+
+// affects queues how many rows the next run of statement affects
+func (f *fakeDB) affects(statement string, n int64) {
+	f.queue(statement, fakeResult{affected: n})
+}
+
+// fails queues err as the result of the next run of query
+func (f *fakeDB) fails(query string, err error) {
+	f.queue(query, fakeResult{err: err})
+}
+
+func (f *fakeDB) queue(name string, result fakeResult) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results[name] = append(f.results[name], result)
+}
+
+// called returns the runs of query, oldest first
+func (f *fakeDB) called(query string) []fakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var calls []fakeCall
+	for _, call := range f.calls {
+		if call.name == query {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// run records a call and pops its scripted result
+func (f *fakeDB) run(query string, args []driver.NamedValue) fakeResult {
+	name := fakeQueryName(query)
+	call := fakeCall{name: name}
+	for _, arg := range args {
+		call.args = append(call.args, arg.Value)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+	queued := f.results[name]
+	if len(queued) == 0 {
+		return fakeResult{}
+	}
+	f.results[name] = queued[1:]
+	return queued[0]
+}
+
+// fakeQueryName returns the sqlc name of query, or its first line
+func fakeQueryName(query string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(query), "\n")
+	if name, ok := strings.CutPrefix(line, "-- name: "); ok {
+		name, _, _ = strings.Cut(name, " ")
+		return name
+	}
+	return strings.TrimSpace(line)
+}
+
+// fakeColumns turns a scripted row into column values
+func fakeColumns(row any) []driver.Value {
+	v := reflect.ValueOf(row)
+	if v.Kind() != reflect.Struct || v.Type().Implements(reflect.TypeFor[driver.Valuer]()) {
+		return []driver.Value{fakeValue(row)}
+	}
+	columns := make([]driver.Value, v.NumField())
+	for i := range columns {
+		columns[i] = fakeValue(v.Field(i).Interface())
+	}
+	return columns
+}
+
+// fakeValue converts a Go value the way the real driver would store it.
+// Encrypted columns are stored in plaintext, which they read back as, so
+// tests needn't install keys.
+func fakeValue(value any) driver.Value {
+	switch v := value.(type) {
+	case encryption.String:
+		return string(v)
+	case encryption.NullString:
+		if !v.Valid {
+			return nil
+		}
+		return v.String
+	case []string:
+		value = pq.Array(v)
+	}
+	converted, err := driver.DefaultParameterConverter.ConvertValue(value)
+	if err != nil {
+		panic(fmt.Sprintf("fakeDB: can't store %T: %v", value, err))
+	}
+	return converted
+}
+
+// Connect implements driver.Connector
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{db: f}, nil
+}
+
+// Driver implements driver.Connector
+func (f *fakeDB) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("fakeDB: open it with sql.OpenDB")
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("fakeDB: prepared statements are not supported")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.db.run("BEGIN", nil)
+	return fakeTx{db: c.db}, nil
+}
+
+// CheckNamedValue stores encrypted arguments in plaintext, like fakeValue
+func (c *fakeConn) CheckNamedValue(nv *driver.NamedValue) error {
+	switch nv.Value.(type) {
+	case encryption.String, encryption.NullString:
+		nv.Value = fakeValue(nv.Value)
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result := c.db.run(query, args)
+	if result.err != nil {
+		return nil, result.err
+	}
+	return &fakeRows{rows: result.rows}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result := c.db.run(query, args)
+	if result.err != nil {
+		return nil, result.err
+	}
+	return driver.RowsAffected(result.affected), nil
+}
+
+type fakeTx struct {
+	db *fakeDB
+}
+
+func (tx fakeTx) Commit() error {
+	tx.db.run("COMMIT", nil)
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.db.run("ROLLBACK", nil)
+	return nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	columns := make([]string, len(r.rows[0]))
+	for i := range columns {
+		columns[i] = fmt.Sprintf("column%d", i+1)
+	}
+	return columns
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// shapeList applies the list options every paginated endpoint accepts:
+//
+//   - fields=id,started_at keeps only the named top-level fields of each item
+//   - envelope=false returns the bare item array, with the pagination
+//     moved to X-Total-Count, X-Page, X-Per-Page and X-Total-Pages headers
+//
+// Unknown field names are ignored so clients can ask for fields that only
+// newer servers return.
+func shapeList(c echo.Context, resp PaginatedResponse) interface{} {
+	if fields := parseFieldList(c.QueryParam("fields")); fields != nil {
+		if data, err := selectFields(resp.Data, fields); err == nil {
+			resp.Data = data
+		}
+	}
+
+	if c.QueryParam("envelope") != "false" {
+		return resp
+	}
+
+	h := c.Response().Header()
+	h.Set("X-Total-Count", strconv.FormatInt(resp.Total, 10))
+	h.Set("X-Page", strconv.Itoa(resp.Page))
+	h.Set("X-Per-Page", strconv.Itoa(resp.PerPage))
+	h.Set("X-Total-Pages", strconv.Itoa(resp.TotalPages))
+	return resp.Data
+}
+
+// parseFieldList parses a comma-separated fields parameter; nil means all
+// fields
+func parseFieldList(value string) map[string]bool {
+	fields := make(map[string]bool)
+	for _, f := range strings.Split(value, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields[f] = true
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// selectFields re-encodes a list of items keeping only the given fields
+func selectFields(data interface{}, fields map[string]bool) ([]map[string]json.RawMessage, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &items); err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		for name := range item {
+			if !fields[name] {
+				delete(item, name)
+			}
+		}
+	}
+	if items == nil {
+		items = []map[string]json.RawMessage{}
+	}
+	return items, nil
+}
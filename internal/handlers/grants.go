@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/encryption"
+	"hyperwhisper/internal/logging"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== OFFLINE GRACE GRANTS ==========
+
+// TrialGrantResponse is a signed offline grace grant. The client caches it
+// and may transcribe locally for up to Seconds until ExpiresAt while the
+// server is unreachable.
+type TrialGrantResponse struct {
+	Grant     string `json:"grant"`
+	GrantID   string `json:"grant_id"`
+	Seconds   int    `json:"seconds"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// ReconcileGrantRequest reports how much of a grant was used offline
+type ReconcileGrantRequest struct {
+	Grant       string  `json:"grant"`
+	UsedSeconds float64 `json:"used_seconds"`
+}
+
+// ReconcileGrantResponse is the trial's quota after offline usage is recorded
+type ReconcileGrantResponse struct {
+	GrantID                  string  `json:"grant_id"`
+	RecordedSeconds          float64 `json:"recorded_seconds"`
+	RemainingDurationSeconds float64 `json:"remaining_duration_seconds"`
+}
+
+// remainingTrialSeconds returns the trial's unused duration quota
+func remainingTrialSeconds(ctx context.Context, queries *sqlc.Queries, trialKey sqlc.TrialApiKey) (float64, error) {
+	limits, err := queries.GetTrialPreset(ctx, trialKey.Preset)
+	if err != nil {
+		return 0, err
+	}
+	summary, err := queries.GetTrialUsageSummary(ctx, trialKey.ID)
+	if err != nil {
+		return 0, err
+	}
+	return math.Max(0, float64(limits.MaxDurationSeconds)-parseDecimalString(summary.TotalDurationSeconds)), nil
+}
+
+// IssueGrant hands a trial client a signed offline grace grant of up to
+// TRIAL_GRACE_SECONDS, capped by its remaining quota. A key has at most one
+// open grant; asking again returns the same grant re-signed. Offline usage
+// is only counted on reconciliation, so a trial can overrun its quota by at
+// most one grant.
+func (h *TrialHandler) IssueGrant(c echo.Context) error {
+	graceSeconds := config.Int("TRIAL_GRACE_SECONDS")
+	if graceSeconds <= 0 {
+		return apiError(http.StatusNotFound, "offline grants are disabled")
+	}
+
+	apiKey := c.QueryParam("api_key")
+	if apiKey == "" {
+		apiKey = c.Request().Header.Get("X-API-Key")
+	}
+	if apiKey == "" {
+		return apiError(http.StatusBadRequest, "api_key required")
+	}
+	if err := checkKeyFormat(apiKey); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	trialKey, err := h.queries.GetTrialAPIKeyByHash(ctx, hashTrialAPIKey(apiKey))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusUnauthorized, "invalid trial key")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	if time.Now().After(trialKey.ExpiresAt) {
+		return newAPIError(http.StatusForbidden, ErrorResponse{
+			Error:   "trial key expired",
+			Details: map[string]string{"upgrade_url": getUpgradeURL(trialKey.ID)},
+		})
+	}
+	if trialKey.RevokedAt.Valid {
+		return newAPIError(http.StatusForbidden, ErrorResponse{
+			Error:   "trial key revoked",
+			Details: map[string]string{"upgrade_url": getUpgradeURL(trialKey.ID)},
+		})
+	}
+
+	grant, err := h.queries.GetOpenTrialGrant(ctx, trialKey.ID)
+	if err == sql.ErrNoRows {
+		remaining, err := remainingTrialSeconds(ctx, h.queries, trialKey)
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "failed to get usage")
+		}
+		seconds := min(graceSeconds, int(remaining))
+		if seconds <= 0 {
+			return newAPIError(http.StatusForbidden, ErrorResponse{
+				Error:   "trial quota exceeded",
+				Details: map[string]string{"upgrade_url": getUpgradeURL(trialKey.ID)},
+			})
+		}
+
+		expiresAt := time.Now().Add(config.Duration("TRIAL_GRACE_TTL"))
+		if trialKey.ExpiresAt.Before(expiresAt) {
+			expiresAt = trialKey.ExpiresAt
+		}
+
+		grant, err = h.queries.CreateTrialGrant(ctx, sqlc.CreateTrialGrantParams{
+			TrialKeyID:     trialKey.ID,
+			GrantedSeconds: int32(seconds),
+			ExpiresAt:      expiresAt,
+		})
+		if err != nil {
+			log.Printf("[Trial] Failed to create grant: %v", err)
+			return apiError(http.StatusInternalServerError, "failed to create grant")
+		}
+		log.Printf("[Trial] Issued offline grant %s (%ds) for key %s", grant.ID, seconds, logging.StoredKeyID(trialKey.KeyPrefix, trialKey.KeyHash))
+	} else if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	token, err := auth.GenerateTrialGrant(grant.ID, trialKey.ID, int(grant.GrantedSeconds), grant.IssuedAt, grant.ExpiresAt)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to sign grant")
+	}
+
+	return c.JSON(http.StatusOK, TrialGrantResponse{
+		Grant:     token,
+		GrantID:   grant.ID.String(),
+		Seconds:   int(grant.GrantedSeconds),
+		ExpiresAt: grant.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+// ReconcileGrant records the offline usage of a grant once the client is
+// back online. Usage is capped at the granted seconds and stored as a
+// completed trial session, so it counts against the quota like any other.
+// Each grant can be reconciled once.
+func (h *TrialHandler) ReconcileGrant(c echo.Context) error {
+	var req ReconcileGrantRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.UsedSeconds < 0 || math.IsNaN(req.UsedSeconds) {
+		return apiError(http.StatusBadRequest, "used_seconds must not be negative")
+	}
+
+	apiKey := c.QueryParam("api_key")
+	if apiKey == "" {
+		apiKey = c.Request().Header.Get("X-API-Key")
+	}
+	if apiKey == "" {
+		return apiError(http.StatusBadRequest, "api_key required")
+	}
+	if err := checkKeyFormat(apiKey); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	trialKey, err := h.queries.GetTrialAPIKeyByHash(ctx, hashTrialAPIKey(apiKey))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusUnauthorized, "invalid trial key")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	claims, err := auth.ValidateTrialGrant(req.Grant)
+	if err != nil || claims.TrialKeyID != trialKey.ID {
+		return apiError(http.StatusBadRequest, "invalid grant")
+	}
+	grantID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid grant")
+	}
+
+	used := math.Min(req.UsedSeconds, float64(claims.Seconds))
+	usedStr := fmt.Sprintf("%.3f", used)
+
+	grant, err := h.queries.ReconcileTrialGrant(ctx, sqlc.ReconcileTrialGrantParams{
+		ID:          grantID,
+		UsedSeconds: stringToNumeric(usedStr),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusConflict, "grant already reconciled")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	if used > 0 {
+		params, _ := json.Marshal(map[string]string{"offline_grant": grant.ID.String()})
+		usage, err := h.queries.CreateTrialUsageLog(ctx, sqlc.CreateTrialUsageLogParams{
+			TrialKeyID:     trialKey.ID,
+			DeepgramParams: params,
+			ClientIp:       encryption.NullString{},
+			RedactionAudit: json.RawMessage(`{}`),
+		})
+		if err == nil {
+			err = h.queries.UpdateTrialUsageComplete(ctx, sqlc.UpdateTrialUsageCompleteParams{
+				ID:              usage.ID,
+				DurationSeconds: stringToNumeric(usedStr),
+			})
+		}
+		if err != nil {
+			log.Printf("[Trial] Failed to record offline usage for grant %s: %v", grant.ID, err)
+			return apiError(http.StatusInternalServerError, "failed to record usage")
+		}
+	}
+	log.Printf("[Trial] Reconciled offline grant %s: %.3fs of %ds used", grant.ID, used, grant.GrantedSeconds)
+
+	remaining, err := remainingTrialSeconds(ctx, h.queries, trialKey)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to get usage")
+	}
+
+	return c.JSON(http.StatusOK, ReconcileGrantResponse{
+		GrantID:                  grant.ID.String(),
+		RecordedSeconds:          used,
+		RemainingDurationSeconds: remaining,
+	})
+}
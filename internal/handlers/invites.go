@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== INVITE LINKS ==========
+
+// CreateInviteRequest is the request for creating an invite link
+type CreateInviteRequest struct {
+	UserType      string `json:"user_type"`       // "user" (default), "support" or "admin"
+	MaxUses       *int   `json:"max_uses"`        // Default 1; 0 = unlimited
+	ExpiresInDays int    `json:"expires_in_days"` // Default 14
+	Note          string `json:"note"`
+}
+
+// InviteResponse is an invite as shown to admins. Code and URL are only
+// set when the invite is created.
+type InviteResponse struct {
+	ID         string  `json:"id"`
+	CodePrefix string  `json:"code_prefix"`
+	Code       string  `json:"code,omitempty"`
+	URL        string  `json:"url,omitempty"`
+	Note       string  `json:"note"`
+	UserType   string  `json:"user_type"`
+	MaxUses    int     `json:"max_uses"`
+	Uses       int     `json:"uses"`
+	ExpiresAt  *string `json:"expires_at"`
+	CreatedBy  *string `json:"created_by"`
+	CreatedAt  string  `json:"created_at"`
+	RevokedAt  *string `json:"revoked_at"`
+	Usable     bool    `json:"usable"`
+}
+
+// findInvite returns the invite a signup's code names, or nil if it names
+// none
+func findInvite(ctx context.Context, queries *sqlc.Queries, code string) (*sqlc.Invite, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, nil
+	}
+
+	invite, err := queries.GetInviteByCodeHash(ctx, hashAPIKey(code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// inviteUsable reports whether an invite can still be redeemed
+func inviteUsable(invite sqlc.Invite, now time.Time) bool {
+	switch {
+	case invite.RevokedAt.Valid:
+		return false
+	case invite.ExpiresAt.Valid && !invite.ExpiresAt.Time.After(now):
+		return false
+	case invite.MaxUses > 0 && invite.Uses >= invite.MaxUses:
+		return false
+	}
+	return true
+}
+
+// newInviteCode generates an invite code
+func newInviteCode() (code, codePrefix, codeHash string, err error) {
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", "", "", err
+	}
+
+	code = fmt.Sprintf("hw_invite_%s", hex.EncodeToString(randomBytes))
+	return code, code[:18], hashAPIKey(code), nil
+}
+
+// getInviteURL returns the signup link of an invite code
+func getInviteURL(code string) string {
+	return config.String("APP_BASE_URL") + "/signup?invite=" + url.QueryEscape(code)
+}
+
+// ListInvites returns invite links, newest first (admin only)
+func (h *AdminHandler) ListInvites(c echo.Context) error {
+	page, perPage, offset := getPaginationParams(c)
+	ctx := context.Background()
+
+	total, err := h.queries.CountInvites(ctx)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	invites, err := h.queries.ListInvites(ctx, sqlc.ListInvitesParams{
+		Limit:  int32(perPage),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	now := time.Now()
+	responses := make([]InviteResponse, len(invites))
+	for i, invite := range invites {
+		responses[i] = toInviteResponse(invite, now)
+	}
+
+	return c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       responses,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: calculateTotalPages(total, perPage),
+	})
+}
+
+// CreateInvite creates an invite link. The code is only returned here.
+func (h *AdminHandler) CreateInvite(c echo.Context) error {
+	var req CreateInviteRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if req.UserType == "" {
+		req.UserType = "user"
+	}
+	if !auth.IsRole(req.UserType) {
+		return apiError(http.StatusBadRequest, "user_type must be 'user', 'support' or 'admin'")
+	}
+
+	maxUses := 1
+	if req.MaxUses != nil {
+		maxUses = *req.MaxUses
+	}
+	if maxUses < 0 {
+		return apiError(http.StatusBadRequest, "max_uses must not be negative")
+	}
+
+	if req.ExpiresInDays == 0 {
+		req.ExpiresInDays = 14
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > 365 {
+		return apiError(http.StatusBadRequest, "expires_in_days must be between 1 and 365")
+	}
+
+	note := strings.TrimSpace(req.Note)
+	if len(note) > 255 {
+		return apiError(http.StatusBadRequest, "note must be at most 255 characters")
+	}
+
+	code, codePrefix, codeHash, err := newInviteCode()
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to generate invite code")
+	}
+
+	var createdBy uuid.NullUUID
+	if claims := auth.GetUserFromContext(c); claims != nil {
+		createdBy = uuid.NullUUID{UUID: claims.UserID, Valid: true}
+	}
+
+	ctx := context.Background()
+	invite, err := h.queries.CreateInvite(ctx, sqlc.CreateInviteParams{
+		CodeHash:   codeHash,
+		CodePrefix: codePrefix,
+		Note:       note,
+		UserType:   req.UserType,
+		MaxUses:    int32(maxUses),
+		ExpiresAt:  sql.NullTime{Time: time.Now().AddDate(0, 0, req.ExpiresInDays).Truncate(time.Second), Valid: true},
+		CreatedBy:  createdBy,
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to create invite")
+	}
+
+	recordAuditEvent(ctx, h.queries, c, auditInviteCreate, "invite", invite.ID.String(), "", map[string]string{
+		"user_type": invite.UserType,
+		"max_uses":  strconv.Itoa(int(invite.MaxUses)),
+	})
+
+	resp := toInviteResponse(invite, time.Now())
+	resp.Code = code
+	resp.URL = getInviteURL(code)
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// RevokeInvite stops an invite link from being redeemed (admin only)
+func (h *AdminHandler) RevokeInvite(c echo.Context) error {
+	inviteID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid invite ID")
+	}
+
+	ctx := context.Background()
+	invite, err := h.queries.RevokeInvite(ctx, inviteID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "invite not found or already revoked")
+		}
+		return apiError(http.StatusInternalServerError, "failed to revoke invite")
+	}
+
+	recordAuditEvent(ctx, h.queries, c, auditInviteRevoke, "invite", invite.ID.String(), "", nil)
+
+	return c.JSON(http.StatusOK, toInviteResponse(invite, time.Now()))
+}
+
+func toInviteResponse(invite sqlc.Invite, now time.Time) InviteResponse {
+	resp := InviteResponse{
+		ID:         invite.ID.String(),
+		CodePrefix: invite.CodePrefix,
+		Note:       invite.Note,
+		UserType:   invite.UserType,
+		MaxUses:    int(invite.MaxUses),
+		Uses:       int(invite.Uses),
+		CreatedAt:  invite.CreatedAt.Format(time.RFC3339),
+		Usable:     inviteUsable(invite, now),
+	}
+	if invite.ExpiresAt.Valid {
+		t := invite.ExpiresAt.Time.Format(time.RFC3339)
+		resp.ExpiresAt = &t
+	}
+	if invite.CreatedBy.Valid {
+		id := invite.CreatedBy.UUID.String()
+		resp.CreatedBy = &id
+	}
+	if invite.RevokedAt.Valid {
+		t := invite.RevokedAt.Time.Format(time.RFC3339)
+		resp.RevokedAt = &t
+	}
+	return resp
+}
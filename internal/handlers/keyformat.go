@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ========== KEY FORMAT ==========
+
+// trialKeyPrefix starts every trial key
+const trialKeyPrefix = "hw_trial_"
+
+// keySecretLength is how many lowercase hex digits follow the prefix of
+// every generated key (16 random bytes)
+const keySecretLength = 32
+
+// checkKeyFormat refuses a key that can't be one the server issued (an
+// unknown prefix, or a secret of the wrong length or characters) with 400
+// and the malformed_api_key code, so it's turned away before any lookup.
+// details.reason says what's wrong: prefix, length or charset.
+func checkKeyFormat(apiKey string) error {
+	var secret string
+	var ok bool
+	for _, prefix := range []string{liveKeyPrefix, sandboxKeyPrefix, trialKeyPrefix} {
+		if secret, ok = strings.CutPrefix(apiKey, prefix); ok {
+			break
+		}
+	}
+
+	reason := ""
+	switch {
+	case !ok:
+		reason = "prefix"
+	case len(secret) != keySecretLength:
+		reason = "length"
+	case strings.Trim(secret, "0123456789abcdef") != "":
+		reason = "charset"
+	default:
+		return nil
+	}
+	return newAPIError(http.StatusBadRequest, ErrorResponse{
+		Error:   "malformed API key",
+		Details: map[string]string{"reason": reason},
+	})
+}
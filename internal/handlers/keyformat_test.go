@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCheckKeyFormat(t *testing.T) {
+	secret := strings.Repeat("0123456789abcdef", 2)
+
+	tests := []struct {
+		name   string
+		key    string
+		reason string // empty when the key is well-formed
+	}{
+		{name: "live key", key: liveKeyPrefix + secret},
+		{name: "sandbox key", key: sandboxKeyPrefix + secret},
+		{name: "trial key", key: trialKeyPrefix + secret},
+		{name: "empty", key: "", reason: "prefix"},
+		{name: "unknown prefix", key: "hw_prod_" + secret, reason: "prefix"},
+		{name: "bare secret", key: secret, reason: "prefix"},
+		{name: "prefix only", key: liveKeyPrefix, reason: "length"},
+		{name: "short secret", key: liveKeyPrefix + secret[:31], reason: "length"},
+		{name: "long secret", key: trialKeyPrefix + secret + "0", reason: "length"},
+		{name: "uppercase hex", key: liveKeyPrefix + strings.ToUpper(secret), reason: "charset"},
+		{name: "non-hex characters", key: sandboxKeyPrefix + secret[:30] + "zz", reason: "charset"},
+		{name: "trailing whitespace", key: liveKeyPrefix + secret[:31] + " ", reason: "charset"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkKeyFormat(tt.key)
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("checkKeyFormat() = %v, want nil", err)
+				}
+				return
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("checkKeyFormat() = %v, want an *APIError", err)
+			}
+			if apiErr.Status != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", apiErr.Status, http.StatusBadRequest)
+			}
+			if got := apiErr.Response.Details["reason"]; got != tt.reason {
+				t.Errorf("reason = %q, want %q", got, tt.reason)
+			}
+		})
+	}
+}
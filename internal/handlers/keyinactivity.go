@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/i18n"
+	"hyperwhisper/internal/logging"
+	"hyperwhisper/internal/mail"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== API KEY INACTIVITY ==========
+
+const (
+	// keyInactivityInterval is how often keys are checked for inactivity
+	keyInactivityInterval = time.Hour
+	// keyInactivityBatchSize is how many keys are warned about per query
+	keyInactivityBatchSize = 100
+)
+
+// KeyInactivitySweep revokes API keys unused for KEY_INACTIVITY_DAYS so
+// forgotten keys can't leak. Owners are emailed a reactivation link
+// KEY_INACTIVITY_WARNING_DAYS before, and again when the key is revoked;
+// using the key or opening the link keeps it. Notices are claimed in the
+// database, so several servers can run sweeps.
+type KeyInactivitySweep struct {
+	queries *sqlc.Queries
+	mailer  mail.Sender
+}
+
+// NewKeyInactivitySweep creates a sweep; Run must be started to check keys
+func NewKeyInactivitySweep(db *sql.DB) *KeyInactivitySweep {
+	return &KeyInactivitySweep{
+		queries: sqlc.New(db),
+		mailer:  mail.NewFromConfig(),
+	}
+}
+
+// Run checks keys every keyInactivityInterval until ctx is cancelled
+func (s *KeyInactivitySweep) Run(ctx context.Context) {
+	s.sweep(ctx)
+	ticker := time.NewTicker(keyInactivityInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+// sweep revokes the keys whose warning ran out, then warns about the keys
+// that went unused for long enough
+func (s *KeyInactivitySweep) sweep(ctx context.Context) {
+	days := config.Int("KEY_INACTIVITY_DAYS")
+	if days == 0 {
+		return
+	}
+	warning := min(config.Int("KEY_INACTIVITY_WARNING_DAYS"), days)
+	now := time.Now()
+
+	s.revoke(ctx, now.AddDate(0, 0, -warning), days)
+	s.warn(ctx, now.AddDate(0, 0, warning-days), days-warning, warning)
+}
+
+// warn emails the owners of keys idle since idleBefore that they will be
+// revoked in warning days
+func (s *KeyInactivitySweep) warn(ctx context.Context, idleBefore time.Time, idleDays, warning int) {
+	for ctx.Err() == nil {
+		keys, err := s.queries.ListAPIKeysDueInactivityWarning(ctx, sqlc.ListAPIKeysDueInactivityWarningParams{
+			IdleBefore: idleBefore,
+			BatchSize:  keyInactivityBatchSize,
+		})
+		if err != nil {
+			log.Printf("[Inactivity] Failed to list unused API keys: %v", err)
+			return
+		}
+
+		for _, key := range keys {
+			token, err := newRandomToken()
+			if err != nil {
+				log.Printf("[Inactivity] Failed to generate reactivation link: %v", err)
+				return
+			}
+			notice, err := s.queries.CreateAPIKeyInactivityNotice(ctx, sqlc.CreateAPIKeyInactivityNoticeParams{
+				ApiKeyID:   key.ID,
+				TokenHash:  hashAPIKey(token),
+				IdleBefore: idleBefore,
+			})
+			if err == sql.ErrNoRows {
+				continue
+			}
+			if err != nil {
+				log.Printf("[Inactivity] Failed to flag API key %s: %v", key.ID, err)
+				return
+			}
+
+			revokesAt := notice.WarnedAt.AddDate(0, 0, warning)
+			log.Printf("[Inactivity] Flagged API key %s (%s), revoked on %s unless used",
+				key.ID, logging.StoredKeyID(key.KeyPrefix, key.KeyHash), revokesAt.Format(time.DateOnly))
+			s.notify(ctx, key, token, func(lang, link string) (string, string) {
+				return i18n.Translate(lang, i18n.KeyInactiveSubject),
+					fmt.Sprintf(i18n.Translate(lang, i18n.KeyInactiveBody), key.Name, key.KeyPrefix, idleDays, revokesAt.Format(time.DateOnly), link)
+			})
+		}
+
+		if len(keys) < keyInactivityBatchSize {
+			return
+		}
+	}
+}
+
+// revoke revokes the keys warned about before warnedBefore that stayed
+// unused, and emails their owners a fresh reactivation link
+func (s *KeyInactivitySweep) revoke(ctx context.Context, warnedBefore time.Time, days int) {
+	keys, err := s.queries.RevokeInactiveAPIKeys(ctx, warnedBefore)
+	if err != nil {
+		log.Printf("[Inactivity] Failed to revoke unused API keys: %v", err)
+		return
+	}
+
+	for _, key := range keys {
+		log.Printf("[Inactivity] Revoked API key %s (%s) after %d days unused",
+			key.ID, logging.StoredKeyID(key.KeyPrefix, key.KeyHash), days)
+		metadata := map[string]string{
+			"user_id":    key.UserID.String(),
+			"key_prefix": key.KeyPrefix,
+		}
+		if key.LastUsedAt.Valid {
+			metadata["last_used_at"] = key.LastUsedAt.Time.Format(time.RFC3339)
+		}
+		recordAuditEvent(ctx, s.queries, nil, auditAPIKeyInactive, "api_key", key.ID.String(), "", metadata)
+
+		// The warning's link is replaced, so only the newest email works
+		token, err := newRandomToken()
+		if err == nil {
+			err = s.queries.RenewAPIKeyInactivityToken(ctx, sqlc.RenewAPIKeyInactivityTokenParams{
+				ApiKeyID:  key.ID,
+				TokenHash: hashAPIKey(token),
+			})
+		}
+		if err != nil {
+			log.Printf("[Inactivity] Failed to renew reactivation link of API key %s: %v", key.ID, err)
+			continue
+		}
+		s.notify(ctx, key, token, func(lang, link string) (string, string) {
+			return i18n.Translate(lang, i18n.KeyRevokedInactiveSubject),
+				fmt.Sprintf(i18n.Translate(lang, i18n.KeyRevokedInactiveBody), key.Name, key.KeyPrefix, days, link)
+		})
+	}
+}
+
+// notify emails the owner of key the subject and body compose returns for
+// their language and the reactivation link of token
+func (s *KeyInactivitySweep) notify(ctx context.Context, key sqlc.ApiKey, token string, compose func(lang, link string) (string, string)) {
+	owner, err := s.queries.GetUserByID(ctx, key.UserID)
+	if err != nil {
+		log.Printf("[Inactivity] Failed to get owner of API key %s: %v", key.ID, err)
+		return
+	}
+
+	link := getKeyReactivationURL(appBaseURL(ctx, s.queries, owner.TenantID), token)
+	subject, body := compose(localeOr(owner, i18n.English), link)
+	msg := mail.Message{
+		To:      owner.Email,
+		Subject: subject,
+		Body:    body,
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		log.Printf("[Inactivity] Failed to email owner of API key %s: %v", key.ID, err)
+	}
+}
+
+// getKeyReactivationURL returns the dashboard page a reactivation link
+// opens, on the owner's appBaseURL
+func getKeyReactivationURL(baseURL, token string) string {
+	return baseURL + "/reactivate-key?token=" + url.QueryEscape(token)
+}
+
+// ReactivateAPIKeyRequest keeps or restores a key with the token from an
+// inactivity email
+type ReactivateAPIKeyRequest struct {
+	Token string `json:"token"`
+}
+
+// ReactivateAPIKey keeps a key flagged for inactivity, or restores one
+// revoked for it, with the single-use token of the emailed link. It needs
+// no sign-in, so the link works in one click.
+func (h *DeepgramHandler) ReactivateAPIKey(c echo.Context) error {
+	var req ReactivateAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	token := strings.TrimSpace(req.Token)
+	if token == "" {
+		return apiError(http.StatusBadRequest, "invalid or used reactivation link")
+	}
+
+	ctx := context.Background()
+	notice, err := h.queries.ReactivateAPIKeyInactivityNotice(ctx, hashAPIKey(token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusBadRequest, "invalid or used reactivation link")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	key, err := h.queries.GetAPIKeyByID(ctx, notice.ApiKeyID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if key.RevokedAt.Valid {
+		// Keys revoked by their owner or an admin stay revoked; the sweep
+		// revokes the key and its notice at the same time
+		if !notice.RevokedAt.Valid || !key.RevokedAt.Time.Equal(notice.RevokedAt.Time) {
+			return apiError(http.StatusConflict, "API key has been revoked")
+		}
+		if err := h.queries.UnrevokeAPIKey(ctx, key.ID); err != nil {
+			return apiError(http.StatusInternalServerError, "failed to reactivate key")
+		}
+	}
+
+	log.Printf("[Inactivity] API key %s (%s) reactivated by its owner", key.ID, logging.StoredKeyID(key.KeyPrefix, key.KeyHash))
+	recordAuditEvent(ctx, h.queries, c, auditAPIKeyReactivate, "api_key", key.ID.String(), "", map[string]string{
+		"user_id":    key.UserID.String(),
+		"key_prefix": key.KeyPrefix,
+		"restored":   strconv.FormatBool(key.RevokedAt.Valid),
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message":    "API key reactivated",
+		"name":       key.Name,
+		"key_prefix": key.KeyPrefix,
+	})
+}
+
+// inactivityRevocations returns when each of a user's keys flagged for
+// inactivity will be revoked
+func inactivityRevocations(ctx context.Context, queries *sqlc.Queries, userID uuid.UUID) (map[uuid.UUID]time.Time, error) {
+	days := config.Int("KEY_INACTIVITY_DAYS")
+	if days == 0 {
+		return nil, nil
+	}
+	notices, err := queries.ListUserPendingAPIKeyInactivityNotices(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	warning := min(config.Int("KEY_INACTIVITY_WARNING_DAYS"), days)
+	revocations := make(map[uuid.UUID]time.Time, len(notices))
+	for _, notice := range notices {
+		revocations[notice.ApiKeyID] = notice.WarnedAt.AddDate(0, 0, warning)
+	}
+	return revocations, nil
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/encryption"
+	"hyperwhisper/internal/i18n"
+	"hyperwhisper/internal/mail"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== NEW DEVICE ALERTS ==========
+
+// checkNewDevice records the device (client IP and user agent) a user just
+// signed in from, and emails them when it's one the account hasn't signed in
+// from before. The first device of an account is recorded silently. Like
+// recordLoginEvent, failing never fails the sign-in.
+func (h *AuthHandler) checkNewDevice(ctx context.Context, c echo.Context, user sqlc.User) {
+	if !config.Bool("NEW_DEVICE_ALERTS") {
+		return
+	}
+
+	ip := c.RealIP()
+	userAgent := c.Request().UserAgent()
+	// Only a keyed hash of the pair is stored, not the IP address
+	deviceHash, err := encryption.BlindIndex(ip + "\n" + userAgent)
+	if err != nil {
+		log.Printf("[Auth] Failed to identify device of user %s: %v", user.ID, err)
+		return
+	}
+
+	known, err := h.queries.CountKnownDevices(ctx, user.ID)
+	if err != nil {
+		log.Printf("[Auth] Failed to count devices of user %s: %v", user.ID, err)
+		return
+	}
+	inserted, err := h.queries.RecordKnownDevice(ctx, sqlc.RecordKnownDeviceParams{
+		UserID:     user.ID,
+		DeviceHash: deviceHash,
+		UserAgent:  userAgent,
+	})
+	if err != nil {
+		log.Printf("[Auth] Failed to record device of user %s: %v", user.ID, err)
+		return
+	}
+	if !inserted || known == 0 {
+		return
+	}
+
+	lang := localeOr(user, requestLanguage(c))
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: i18n.Translate(lang, i18n.NewDeviceSubject),
+		Body: fmt.Sprintf(i18n.Translate(lang, i18n.NewDeviceBody),
+			user.Username,
+			time.Now().UTC().Format("2006-01-02 15:04 MST"),
+			ip,
+			userAgent,
+			appBaseURL(ctx, h.queries, user.TenantID)+"/dashboard",
+		),
+	}
+	go func() {
+		if err := h.mailer.Send(context.Background(), msg); err != nil {
+			log.Printf("[Auth] Failed to send new device email to user %s: %v", user.ID, err)
+		}
+	}()
+	log.Printf("[Auth] User %s signed in from a new device", user.ID)
+}
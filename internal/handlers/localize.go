@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"database/sql"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/i18n"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// languageContextKey caches the language of a request once a handler knows
+// whose it is
+const languageContextKey = "language"
+
+// LocalizedJSONSerializer is the server's JSON serializer. It adds a stable
+// code and a message in the client's language to every error body and
+// serves it as an RFC 7807 problem, so handlers keep returning plain
+// English ErrorResponses. The English error field is left untouched for
+// clients that match on it. Paginated lists are shaped by the fields and
+// envelope query parameters.
+type LocalizedJSONSerializer struct {
+	echo.DefaultJSONSerializer
+	queries *sqlc.Queries
+}
+
+// NewLocalizedJSONSerializer creates the serializer; it looks up the locale
+// of signed-in users in db
+func NewLocalizedJSONSerializer(db *sql.DB) LocalizedJSONSerializer {
+	return LocalizedJSONSerializer{queries: sqlc.New(db)}
+}
+
+// Serialize localizes error bodies before encoding them
+func (s LocalizedJSONSerializer) Serialize(c echo.Context, i interface{}, indent string) error {
+	switch v := i.(type) {
+	case ErrorResponse:
+		s.resolveLanguage(c)
+		i = problem(c, v)
+	case *ErrorResponse:
+		s.resolveLanguage(c)
+		i = problem(c, *v)
+	case PaginatedResponse:
+		i = shapeList(c, v)
+	case map[string]string:
+		// Middleware outside this package answers with {"error": ...}
+		if msg, ok := v["error"]; ok && len(v) == 1 {
+			s.resolveLanguage(c)
+			i = problem(c, ErrorResponse{Error: msg})
+		}
+	}
+	return s.DefaultJSONSerializer.Serialize(c, i, indent)
+}
+
+// resolveLanguage switches errors of signed-in users to their locale.
+// Errors are rare enough to look it up only for them.
+func (s LocalizedJSONSerializer) resolveLanguage(c echo.Context) {
+	if s.queries == nil || c.Get(languageContextKey) != nil {
+		return
+	}
+	if claims := auth.GetUserFromContext(c); claims != nil {
+		userLanguage(c, s.queries, claims.UserID)
+	}
+}
+
+// problem localizes an error body and switches the response to
+// problem+json; nothing has been written yet when the serializer runs
+func problem(c echo.Context, resp ErrorResponse) ProblemDetails {
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+	return toProblem(c, localizeError(c, resp))
+}
+
+func localizeError(c echo.Context, resp ErrorResponse) ErrorResponse {
+	if resp.Code == "" {
+		resp.Code = i18n.Code(resp.Error)
+	}
+	if resp.Message == "" {
+		resp.Message = i18n.Translate(requestLanguage(c), resp.Error)
+	}
+	return resp
+}
+
+// requestLanguage is the language of the request's user when a handler
+// resolved it, or else the supported language that best matches the
+// request's Accept-Language header
+func requestLanguage(c echo.Context) string {
+	if lang, ok := c.Get(languageContextKey).(string); ok {
+		return lang
+	}
+	return i18n.Negotiate(c.Request().Header.Get("Accept-Language"))
+}
+
+// userLanguage resolves the language of a request made by or for userID,
+// so its errors and close reasons follow the user's locale
+func userLanguage(c echo.Context, queries *sqlc.Queries, userID uuid.UUID) string {
+	user, err := queries.GetUserByID(c.Request().Context(), userID)
+	if err != nil {
+		return requestLanguage(c)
+	}
+	return useUserLanguage(c, user)
+}
+
+// useUserLanguage is userLanguage for handlers that already loaded the user
+func useUserLanguage(c echo.Context, user sqlc.User) string {
+	lang := localeOr(user, requestLanguage(c))
+	c.Set(languageContextKey, lang)
+	return lang
+}
+
+// localeOr is the locale user chose, or fallback when they chose none
+func localeOr(user sqlc.User, fallback string) string {
+	if user.Locale.Valid && i18n.IsSupported(user.Locale.String) {
+		return user.Locale.String
+	}
+	return fallback
+}
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/encryption"
+	"hyperwhisper/internal/i18n"
+	"hyperwhisper/internal/logging"
+	"hyperwhisper/internal/mail"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== API KEY LOCKDOWN ==========
+
+const (
+	// lockdownIPWindow is how far back the distinct client IPs of a key are
+	// counted
+	lockdownIPWindow = time.Hour
+	// lockdownUsageWindow is the recent usage compared against the key's
+	// baseline. Keys re-enabled within it are not checked again, or the
+	// spike that locked them would lock them straight away.
+	lockdownUsageWindow = 24 * time.Hour
+	// lockdownBaselineDays is how many days before the recent window make up
+	// the baseline
+	lockdownBaselineDays = 14
+)
+
+// APIKeyLockdownResponse tells the owner of a locked key why it was locked
+type APIKeyLockdownResponse struct {
+	Reason   string            `json:"reason"` // usage_spike or ip_spread
+	LockedAt string            `json:"locked_at"`
+	Details  map[string]string `json:"details"`
+}
+
+func toAPIKeyLockdownResponse(lockdown sqlc.ApiKeyLockdown) *APIKeyLockdownResponse {
+	details := map[string]string{}
+	_ = json.Unmarshal(lockdown.Details, &details)
+	return &APIKeyLockdownResponse{
+		Reason:   lockdown.Reason,
+		LockedAt: lockdown.CreatedAt.Format(time.RFC3339),
+		Details:  details,
+	}
+}
+
+// keyLockedError is returned for sessions of a locked API key
+func keyLockedError(lockdown sqlc.ApiKeyLockdown) error {
+	return newAPIError(http.StatusForbidden, ErrorResponse{
+		Error: "API key locked after unusual activity",
+		Details: map[string]string{
+			"reason":    lockdown.Reason,
+			"locked_at": lockdown.CreatedAt.Format(time.RFC3339),
+		},
+	})
+}
+
+// checkKeyLockdown refuses sessions of a locked API key, and locks the key
+// when it shows unusual activity: more distinct client IPs within an hour
+// than KEY_LOCKDOWN_MAX_IPS, or more usage over the last day than
+// KEY_LOCKDOWN_USAGE_FACTOR times its average daily usage of the two weeks
+// before. Locking ends the key's live sessions and emails its owner; the key
+// stays locked until the owner or an admin re-enables it. Failures to
+// measure are logged and let the session through.
+func (h *DeepgramHandler) checkKeyLockdown(ctx context.Context, c echo.Context, key sqlc.ApiKey) error {
+	now := time.Now()
+
+	latest, err := h.queries.GetLatestAPIKeyLockdown(ctx, key.ID)
+	switch {
+	case err == nil && !latest.LiftedAt.Valid:
+		log.Printf("[Lockdown] Refused session of locked API key %s", key.ID)
+		return keyLockedError(latest)
+	case err == nil && now.Sub(latest.LiftedAt.Time) < lockdownUsageWindow:
+		return nil
+	case err != nil && err != sql.ErrNoRows:
+		log.Printf("[Lockdown] Failed to get lockdown of API key %s: %v", key.ID, err)
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	reason, details := h.detectKeyAnomaly(ctx, key, c.RealIP(), now)
+	if reason == "" {
+		return nil
+	}
+
+	detailsJSON, _ := json.Marshal(details)
+	lockdown, err := h.queries.CreateAPIKeyLockdown(ctx, sqlc.CreateAPIKeyLockdownParams{
+		ApiKeyID: key.ID,
+		Reason:   reason,
+		Details:  detailsJSON,
+	})
+	if err == sql.ErrNoRows {
+		// A concurrent session locked the key first and told the owner
+		if lockdown, err = h.queries.GetLatestAPIKeyLockdown(ctx, key.ID); err != nil {
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+		return keyLockedError(lockdown)
+	}
+	if err != nil {
+		log.Printf("[Lockdown] Failed to lock API key %s: %v", key.ID, err)
+		return nil
+	}
+
+	terminated := Sessions.CloseTagged(apiKeySessionTag(key.ID), CloseKeyLocked)
+	log.Printf("[Lockdown] Locked API key %s (%s) for %s %v, terminated %d live sessions",
+		key.ID, key.KeyPrefix, reason, details, terminated)
+
+	metadata := map[string]string{
+		"user_id":             key.UserID.String(),
+		"key_prefix":          key.KeyPrefix,
+		"sessions_terminated": strconv.Itoa(terminated),
+	}
+	for k, v := range details {
+		metadata[k] = v
+	}
+	recordAuditEvent(ctx, h.queries, c, auditAPIKeyLockdown, "api_key", key.ID.String(), reason, metadata)
+
+	h.notifyKeyLockdown(ctx, key, reason, details)
+	return keyLockedError(lockdown)
+}
+
+// detectKeyAnomaly returns why key should be locked after a connection from
+// ip, with the measurements behind it, or "" when it looks normal
+func (h *DeepgramHandler) detectKeyAnomaly(ctx context.Context, key sqlc.ApiKey, ip string, now time.Time) (string, map[string]string) {
+	if limit := config.Int("KEY_LOCKDOWN_MAX_IPS"); limit > 0 && ip != "" {
+		ips, err := h.countKeyIPs(ctx, key.ID, ip, now)
+		if err != nil {
+			log.Printf("[Lockdown] Failed to count IPs of API key %s: %v", key.ID, err)
+		} else if ips > int64(limit) {
+			return "ip_spread", map[string]string{
+				"distinct_ips": strconv.FormatInt(ips, 10),
+				"limit":        strconv.Itoa(limit),
+			}
+		}
+	}
+
+	if factor := config.Float("KEY_LOCKDOWN_USAGE_FACTOR"); factor > 0 {
+		recent, average, err := h.keyUsageBaseline(ctx, key, now)
+		if err != nil {
+			log.Printf("[Lockdown] Failed to get usage baseline of API key %s: %v", key.ID, err)
+		} else if average > 0 && recent >= config.Duration("KEY_LOCKDOWN_MIN_USAGE").Seconds() && recent > factor*average {
+			return "usage_spike", map[string]string{
+				"recent_seconds":        strconv.FormatFloat(recent, 'f', 0, 64),
+				"daily_average_seconds": strconv.FormatFloat(average, 'f', 0, 64),
+				"factor":                strconv.FormatFloat(factor, 'f', -1, 64),
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// countKeyIPs records that key connected from ip and returns how many
+// distinct IPs it connected from within lockdownIPWindow. IPs are kept by
+// their blind index only.
+func (h *DeepgramHandler) countKeyIPs(ctx context.Context, keyID uuid.UUID, ip string, now time.Time) (int64, error) {
+	hash, err := encryption.BlindIndex(ip)
+	if err != nil {
+		return 0, err
+	}
+	if err := h.queries.RecordAPIKeyIPSighting(ctx, sqlc.RecordAPIKeyIPSightingParams{
+		ApiKeyID: keyID,
+		IpHash:   hash,
+	}); err != nil {
+		return 0, err
+	}
+
+	since := now.Add(-lockdownIPWindow)
+	if err := h.queries.DeleteStaleAPIKeyIPSightings(ctx, sqlc.DeleteStaleAPIKeyIPSightingsParams{
+		ApiKeyID:   keyID,
+		LastSeenAt: since,
+	}); err != nil {
+		return 0, err
+	}
+	return h.queries.CountAPIKeyIPSightings(ctx, sqlc.CountAPIKeyIPSightingsParams{
+		ApiKeyID:   keyID,
+		LastSeenAt: since,
+	})
+}
+
+// keyUsageBaseline returns the seconds key streamed within
+// lockdownUsageWindow and its average daily seconds over the days before.
+// Keys too new to have a full day before the window have no baseline and
+// an average of 0.
+func (h *DeepgramHandler) keyUsageBaseline(ctx context.Context, key sqlc.ApiKey, now time.Time) (float64, float64, error) {
+	days := lockdownBaselineDays
+	if key.CreatedAt.Valid {
+		days = min(days, int(now.Sub(key.CreatedAt.Time)/lockdownUsageWindow)-1)
+	}
+	if days < 1 {
+		return 0, 0, nil
+	}
+
+	recentStart := now.Add(-lockdownUsageWindow)
+	usage, err := h.queries.GetAPIKeyUsageBaseline(ctx, sqlc.GetAPIKeyUsageBaselineParams{
+		RecentStart:   recentStart,
+		ApiKeyID:      key.ID,
+		BaselineStart: recentStart.AddDate(0, 0, -days),
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	recent, _ := strconv.ParseFloat(usage.RecentSeconds, 64)
+	baseline, _ := strconv.ParseFloat(usage.BaselineSeconds, 64)
+	return recent, baseline / float64(days), nil
+}
+
+// notifyKeyLockdown emails the owner of a key that was just locked. It is
+// sent in the background so the refused client isn't held up.
+func (h *DeepgramHandler) notifyKeyLockdown(ctx context.Context, key sqlc.ApiKey, reason string, details map[string]string) {
+	owner, err := h.queries.GetUserByID(ctx, key.UserID)
+	if err != nil {
+		log.Printf("[Lockdown] Failed to get owner of API key %s: %v", key.ID, err)
+		return
+	}
+
+	// The session that tripped the lockdown may not be the owner's
+	lang := localeOr(owner, i18n.English)
+	var what string
+	switch reason {
+	case "ip_spread":
+		what = fmt.Sprintf(i18n.Translate(lang, i18n.KeyLockedIPSpread), details["distinct_ips"])
+	case "usage_spike":
+		recent, _ := strconv.ParseFloat(details["recent_seconds"], 64)
+		average, _ := strconv.ParseFloat(details["daily_average_seconds"], 64)
+		what = fmt.Sprintf(i18n.Translate(lang, i18n.KeyLockedUsageSpike), recent/60, average/60)
+	}
+
+	msg := mail.Message{
+		To:      owner.Email,
+		Subject: i18n.Translate(lang, i18n.KeyLockedSubject),
+		Body:    fmt.Sprintf(i18n.Translate(lang, i18n.KeyLockedBody), key.Name, key.KeyPrefix, what),
+	}
+	go func() {
+		if err := h.mailer.Send(context.Background(), msg); err != nil {
+			log.Printf("[Lockdown] Failed to send lockdown email for API key %s: %v", key.ID, err)
+		}
+	}()
+}
+
+// ReenableAPIKey lifts the lockdown of one of the caller's API keys
+func (h *DeepgramHandler) ReenableAPIKey(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid key ID")
+	}
+
+	ctx := context.Background()
+
+	key, err := h.queries.GetAPIKeyByID(ctx, keyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "API key not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if key.UserID != claims.UserID {
+		return apiError(http.StatusNotFound, "API key not found")
+	}
+
+	return liftKeyLockdown(ctx, h.queries, c, key, "")
+}
+
+// AdminReenableAPIKey lifts the lockdown of any user's API key (admin only)
+func (h *AdminHandler) AdminReenableAPIKey(c echo.Context) error {
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid key ID")
+	}
+
+	// The reason is optional when re-enabling a key
+	var req APIKeyRevocationRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	ctx := context.Background()
+
+	key, err := h.queries.GetAPIKeyByID(ctx, keyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "API key not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	return liftKeyLockdown(ctx, h.queries, c, key, strings.TrimSpace(req.Reason))
+}
+
+// liftKeyLockdown re-enables a locked key on behalf of the caller
+func liftKeyLockdown(ctx context.Context, queries *sqlc.Queries, c echo.Context, key sqlc.ApiKey, reason string) error {
+	claims := auth.GetUserFromContext(c)
+
+	lifted, err := queries.LiftAPIKeyLockdown(ctx, sqlc.LiftAPIKeyLockdownParams{
+		ApiKeyID: key.ID,
+		LiftedBy: uuid.NullUUID{UUID: claims.UserID, Valid: true},
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to re-enable key")
+	}
+	if lifted == 0 {
+		return apiError(http.StatusBadRequest, "API key is not locked")
+	}
+
+	log.Printf("[Lockdown] API key %s (%s) re-enabled by user %s", key.ID, logging.StoredKeyID(key.KeyPrefix, key.KeyHash), claims.UserID)
+	recordAuditEvent(ctx, queries, c, auditAPIKeyReenable, "api_key", key.ID.String(), reason, map[string]string{
+		"user_id":    key.UserID.String(),
+		"key_prefix": key.KeyPrefix,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "API key re-enabled"})
+}
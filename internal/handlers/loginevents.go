@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/encryption"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== LOGIN EVENTS ==========
+
+// Kinds of login events
+const (
+	loginSignIn       = "sign_in"
+	loginTokenRefresh = "token_refresh"
+)
+
+// Outcomes of login events. Failures are recorded against the account they
+// targeted, when there is one, so users see attempts on their account.
+const (
+	loginSuccess         = "success"
+	loginUnknownAccount  = "unknown_account"  // No account has the identifier
+	loginInvalidPassword = "invalid_password" // The account's password was wrong
+	loginWrongTenant     = "wrong_tenant"     // Signed in through another tenant's domain
+	loginSuspended       = "suspended"        // The account is suspended
+	loginInvalidToken    = "invalid_token"    // The refresh token was malformed or expired
+	loginRevokedToken    = "revoked_token"    // The refresh token was revoked
+	loginTokenReuse      = "reuse_detected"   // A rotated refresh token was replayed
+)
+
+// loginEventPrunePeriod is how often expired events are deleted
+const loginEventPrunePeriod = time.Hour
+
+// loginEventsPrunedAt is when this process last deleted expired events
+// (Unix seconds)
+var loginEventsPrunedAt atomic.Int64
+
+// recordLoginEvent saves the outcome of a sign-in or token refresh along
+// with the client it came from. Failing to record never fails the request.
+func recordLoginEvent(ctx context.Context, queries *sqlc.Queries, c echo.Context, userID uuid.NullUUID, kind, outcome string) {
+	retention := config.Int("LOGIN_EVENT_RETENTION_DAYS")
+	if retention <= 0 {
+		return
+	}
+
+	event := sqlc.CreateLoginEventParams{
+		UserID:    userID,
+		Kind:      kind,
+		Outcome:   outcome,
+		UserAgent: c.Request().UserAgent(),
+	}
+	// IPs are encrypted at rest; the blind index makes them filterable
+	if ip := c.RealIP(); ip != "" {
+		event.ClientIp = encryption.NullString{String: ip, Valid: true}
+		if hash, err := encryption.BlindIndex(ip); err == nil {
+			event.ClientIpHash = sql.NullString{String: hash, Valid: true}
+		}
+	}
+	if err := queries.CreateLoginEvent(ctx, event); err != nil {
+		log.Printf("[Auth] Failed to record %s login event: %v", kind, err)
+	}
+
+	// Expired events are removed at most hourly, while recording new ones
+	now := time.Now()
+	last := loginEventsPrunedAt.Load()
+	if now.Unix()-last < int64(loginEventPrunePeriod.Seconds()) || !loginEventsPrunedAt.CompareAndSwap(last, now.Unix()) {
+		return
+	}
+	cutoff := now.AddDate(0, 0, -retention)
+	if err := queries.DeleteExpiredLoginEvents(ctx, cutoff); err != nil {
+		log.Printf("[Auth] Failed to delete expired login events: %v", err)
+	}
+}
+
+// LoginEventResponse is a sign-in or token refresh
+type LoginEventResponse struct {
+	ID        string  `json:"id"`
+	CreatedAt string  `json:"created_at"`
+	Kind      string  `json:"kind"`
+	Outcome   string  `json:"outcome"`
+	ClientIP  *string `json:"client_ip"`
+	UserAgent string  `json:"user_agent"`
+
+	// Only in the admin view
+	UserID   *string `json:"user_id,omitempty"`
+	Username *string `json:"username,omitempty"`
+}
+
+// ListLoginEvents returns the caller's sign-ins and token refreshes,
+// including failed attempts on the account, newest first
+func (h *AuthHandler) ListLoginEvents(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	page, perPage, offset := getPaginationParams(c)
+	ctx := context.Background()
+	userID := uuid.NullUUID{UUID: claims.UserID, Valid: true}
+
+	total, err := h.queries.CountUserLoginEvents(ctx, userID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	events, err := h.queries.ListUserLoginEvents(ctx, sqlc.ListUserLoginEventsParams{
+		UserID: userID,
+		Limit:  int32(perPage),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	responses := make([]LoginEventResponse, len(events))
+	for i, e := range events {
+		responses[i] = LoginEventResponse{
+			ID:        e.ID.String(),
+			CreatedAt: e.CreatedAt.Format(time.RFC3339),
+			Kind:      e.Kind,
+			Outcome:   e.Outcome,
+			UserAgent: e.UserAgent,
+		}
+		if e.ClientIp.Valid {
+			responses[i].ClientIP = &e.ClientIp.String
+		}
+	}
+
+	return c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       responses,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: calculateTotalPages(total, perPage),
+	})
+}
+
+// ListLoginEvents returns sign-ins and token refreshes of all users, newest
+// first (admin only). Filters: from/to (RFC 3339, default everything
+// retained), user_id, ip, kind (sign_in or token_refresh), outcome, and
+// succeeded (true or false).
+func (h *AdminHandler) ListLoginEvents(c echo.Context) error {
+	page, perPage, offset := getPaginationParams(c)
+
+	var start time.Time
+	end := time.Now()
+	if v := c.QueryParam("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return apiError(http.StatusBadRequest, "invalid from, expected RFC 3339")
+		}
+		start = t
+	}
+	if v := c.QueryParam("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return apiError(http.StatusBadRequest, "invalid to, expected RFC 3339")
+		}
+		end = t
+	}
+
+	var userID uuid.NullUUID
+	if v := c.QueryParam("user_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return apiError(http.StatusBadRequest, "invalid user ID")
+		}
+		userID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	var ipHash sql.NullString
+	if v := c.QueryParam("ip"); v != "" {
+		hash, err := encryption.BlindIndex(v)
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "encryption not configured")
+		}
+		ipHash = sql.NullString{String: hash, Valid: true}
+	}
+
+	kind := c.QueryParam("kind")
+	if kind != "" && kind != loginSignIn && kind != loginTokenRefresh {
+		return apiError(http.StatusBadRequest, "invalid kind, expected sign_in or token_refresh")
+	}
+
+	var succeeded sql.NullBool
+	switch c.QueryParam("succeeded") {
+	case "":
+	case "true":
+		succeeded = sql.NullBool{Bool: true, Valid: true}
+	case "false":
+		succeeded = sql.NullBool{Bool: false, Valid: true}
+	default:
+		return apiError(http.StatusBadRequest, "invalid succeeded, expected true or false")
+	}
+	outcome := c.QueryParam("outcome")
+
+	ctx := context.Background()
+	total, err := h.queries.CountLoginEvents(ctx, sqlc.CountLoginEventsParams{
+		StartDate:    start,
+		EndDate:      end,
+		UserID:       userID,
+		ClientIpHash: ipHash,
+		Kind:         sql.NullString{String: kind, Valid: kind != ""},
+		Outcome:      sql.NullString{String: outcome, Valid: outcome != ""},
+		Succeeded:    succeeded,
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	events, err := h.queries.ListLoginEvents(ctx, sqlc.ListLoginEventsParams{
+		StartDate:    start,
+		EndDate:      end,
+		UserID:       userID,
+		ClientIpHash: ipHash,
+		Kind:         sql.NullString{String: kind, Valid: kind != ""},
+		Outcome:      sql.NullString{String: outcome, Valid: outcome != ""},
+		Succeeded:    succeeded,
+		PageLimit:    int32(perPage),
+		PageOffset:   int32(offset),
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	responses := make([]LoginEventResponse, len(events))
+	for i, e := range events {
+		responses[i] = LoginEventResponse{
+			ID:        e.ID.String(),
+			CreatedAt: e.CreatedAt.Format(time.RFC3339),
+			Kind:      e.Kind,
+			Outcome:   e.Outcome,
+			UserAgent: e.UserAgent,
+			UserID:    nullUUIDPtr(e.UserID),
+			Username:  nullStringPtr(e.Username),
+		}
+		if e.ClientIp.Valid {
+			responses[i].ClientIP = &e.ClientIp.String
+		}
+	}
+
+	return c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       responses,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: calculateTotalPages(total, perPage),
+	})
+}
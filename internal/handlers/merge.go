@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== ACCOUNT MERGE ==========
+
+// MergeUsersRequest is the request body for merging two user accounts. The
+// source account is folded into the target and then deleted.
+type MergeUsersRequest struct {
+	SourceUserID string `json:"source_user_id"`
+	TargetUserID string `json:"target_user_id"`
+	DryRun       bool   `json:"dry_run"`
+	Reason       string `json:"reason"`
+}
+
+// MergeCounts lists how many rows move to the surviving account; refresh
+// tokens are revoked instead
+type MergeCounts struct {
+	APIKeys            int64 `json:"api_keys"`
+	TranscriptionLogs  int64 `json:"transcription_logs"`
+	RefreshTokens      int64 `json:"refresh_tokens"`
+	ErrorReports       int64 `json:"error_reports"`
+	SessionPolicies    int64 `json:"session_policies"`
+	AuditEvents        int64 `json:"audit_events"`
+	SessionTranscripts int64 `json:"session_transcripts"`
+	// Organization memberships where both accounts were members keep the
+	// higher role and count once
+	OrganizationMemberships int64 `json:"organization_memberships"`
+	TrialConversions        int64 `json:"trial_conversions"`
+	ExportJobs              int64 `json:"export_jobs"`
+	PasswordHistory         int64 `json:"password_history"`
+	KnownDevices            int64 `json:"known_devices"`
+	APIKeyLimits            int64 `json:"api_key_limits"`
+	OAuthClients            int64 `json:"oauth_clients"`
+	OAuthGrants             int64 `json:"oauth_grants"`
+	OAuthDeviceCodes        int64 `json:"oauth_device_codes"`
+	LoginEvents             int64 `json:"login_events"`
+	AuthEvents              int64 `json:"auth_events"`
+}
+
+// MergeUsersResponse reports what a merge moved, or would move on a dry run
+type MergeUsersResponse struct {
+	SourceUserID string      `json:"source_user_id"`
+	TargetUserID string      `json:"target_user_id"`
+	DryRun       bool        `json:"dry_run"`
+	Moved        MergeCounts `json:"moved"`
+}
+
+// MergeUsers folds a duplicate account into another: API keys, usage logs,
+// saved transcripts, error reports, user-scoped session policies, audit
+// attribution, organization memberships, trial conversions, exports,
+// password history, known devices, the API key limit, OAuth applications
+// and grants, sign-in history and proxy authentication events move to the
+// target, then the source account is deleted, all in one transaction. Its
+// password reset links are deleted with it. The source's sessions end: its
+// refresh tokens are revoked and its access tokens denied. With dry_run set
+// nothing changes and the response previews what would move (admin only).
+func (h *AdminHandler) MergeUsers(c echo.Context) error {
+	var req MergeUsersRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	sourceID, err := uuid.Parse(req.SourceUserID)
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid source user ID")
+	}
+	targetID, err := uuid.Parse(req.TargetUserID)
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid target user ID")
+	}
+	if sourceID == targetID {
+		return apiError(http.StatusBadRequest, "source and target must be different users")
+	}
+	if claims := auth.GetUserFromContext(c); claims != nil && claims.UserID == sourceID {
+		return apiError(http.StatusBadRequest, "cannot merge away your own account")
+	}
+
+	ctx := context.Background()
+
+	var tenants []uuid.NullUUID
+	for _, id := range []uuid.UUID{sourceID, targetID} {
+		user, err := h.queries.GetUserByID(ctx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return newAPIError(http.StatusNotFound, ErrorResponse{Error: "user not found", Details: map[string]string{"user_id": id.String()}})
+			}
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+		tenants = append(tenants, user.TenantID)
+	}
+	// Tenant membership never changes, so accounts only merge within one
+	if tenants[0] != tenants[1] {
+		return apiError(http.StatusBadRequest, "users belong to different tenants")
+	}
+
+	resp := MergeUsersResponse{
+		SourceUserID: sourceID.String(),
+		TargetUserID: targetID.String(),
+		DryRun:       req.DryRun,
+	}
+
+	if req.DryRun {
+		counts, err := h.queries.GetUserMergeCounts(ctx, sourceID)
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+		resp.Moved = MergeCounts{
+			APIKeys:            counts.ApiKeys,
+			TranscriptionLogs:  counts.TranscriptionLogs,
+			RefreshTokens:      counts.RefreshTokens,
+			ErrorReports:       counts.ErrorReports,
+			SessionPolicies:    counts.SessionPolicies,
+			AuditEvents:        counts.AuditEvents,
+			SessionTranscripts: counts.SessionTranscripts,
+
+			OrganizationMemberships: counts.OrganizationMemberships,
+			TrialConversions:        counts.TrialConversions,
+			ExportJobs:              counts.ExportJobs,
+			PasswordHistory:         counts.PasswordHistory,
+			KnownDevices:            counts.KnownDevices,
+			APIKeyLimits:            counts.ApiKeyLimits,
+			OAuthClients:            counts.OauthClients,
+			OAuthGrants:             counts.OauthGrants,
+			OAuthDeviceCodes:        counts.OauthDeviceCodes,
+			LoginEvents:             counts.LoginEvents,
+			AuthEvents:              counts.AuthEvents,
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+
+	moved, err := h.mergeUsers(ctx, sourceID, targetID)
+	if err != nil {
+		log.Printf("[Admin] Account merge %s -> %s failed: %v", sourceID, targetID, err)
+		return apiError(http.StatusInternalServerError, "failed to merge accounts")
+	}
+	resp.Moved = moved
+
+	// Access tokens and dashboard sessions still run as the deleted account
+	denyUserAccessTokens(ctx, h.queries, sourceID)
+	Sessions.CloseTagged("user:"+sourceID.String(), CloseAdminTerminated)
+	log.Printf("[Admin] Merged account %s into %s", sourceID, targetID)
+
+	recordAuditEvent(ctx, h.queries, c, auditUserMerge, "user", targetID.String(), strings.TrimSpace(req.Reason), map[string]string{
+		"source_user_id":      sourceID.String(),
+		"api_keys":            strconv.FormatInt(moved.APIKeys, 10),
+		"transcription_logs":  strconv.FormatInt(moved.TranscriptionLogs, 10),
+		"refresh_tokens":      strconv.FormatInt(moved.RefreshTokens, 10),
+		"error_reports":       strconv.FormatInt(moved.ErrorReports, 10),
+		"session_policies":    strconv.FormatInt(moved.SessionPolicies, 10),
+		"audit_events":        strconv.FormatInt(moved.AuditEvents, 10),
+		"session_transcripts": strconv.FormatInt(moved.SessionTranscripts, 10),
+
+		"organization_memberships": strconv.FormatInt(moved.OrganizationMemberships, 10),
+		"trial_conversions":        strconv.FormatInt(moved.TrialConversions, 10),
+		"export_jobs":              strconv.FormatInt(moved.ExportJobs, 10),
+		"password_history":         strconv.FormatInt(moved.PasswordHistory, 10),
+		"known_devices":            strconv.FormatInt(moved.KnownDevices, 10),
+		"api_key_limits":           strconv.FormatInt(moved.APIKeyLimits, 10),
+		"oauth_clients":            strconv.FormatInt(moved.OAuthClients, 10),
+		"oauth_grants":             strconv.FormatInt(moved.OAuthGrants, 10),
+		"oauth_device_codes":       strconv.FormatInt(moved.OAuthDeviceCodes, 10),
+		"login_events":             strconv.FormatInt(moved.LoginEvents, 10),
+		"auth_events":              strconv.FormatInt(moved.AuthEvents, 10),
+	})
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// mergeUsers moves everything owned by source to target and deletes source
+// in a single transaction
+func (h *AdminHandler) mergeUsers(ctx context.Context, sourceID, targetID uuid.UUID) (MergeCounts, error) {
+	var moved MergeCounts
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return moved, err
+	}
+	defer tx.Rollback()
+	queries := h.queries.WithTx(tx)
+
+	if moved.APIKeys, err = queries.MergeUserAPIKeys(ctx, sqlc.MergeUserAPIKeysParams{TargetID: targetID, SourceID: sourceID}); err != nil {
+		return moved, err
+	}
+	if moved.TranscriptionLogs, err = queries.MergeUserTranscriptionLogs(ctx, sqlc.MergeUserTranscriptionLogsParams{TargetID: targetID, SourceID: sourceID}); err != nil {
+		return moved, err
+	}
+	if moved.SessionTranscripts, err = queries.MergeUserSessionTranscripts(ctx, sqlc.MergeUserSessionTranscriptsParams{TargetID: targetID, SourceID: sourceID}); err != nil {
+		return moved, err
+	}
+	if moved.RefreshTokens, err = queries.RevokeMergedUserRefreshTokens(ctx, sourceID); err != nil {
+		return moved, err
+	}
+	if moved.ErrorReports, err = queries.MergeUserErrorReports(ctx, sqlc.MergeUserErrorReportsParams{TargetID: targetID, SourceID: sourceID}); err != nil {
+		return moved, err
+	}
+	if moved.SessionPolicies, err = queries.MergeUserSessionPolicies(ctx, sqlc.MergeUserSessionPoliciesParams{TargetID: targetID, SourceID: sourceID}); err != nil {
+		return moved, err
+	}
+	if moved.AuditEvents, err = queries.MergeUserAuditEvents(ctx, sqlc.MergeUserAuditEventsParams{TargetID: targetID, SourceID: sourceID}); err != nil {
+		return moved, err
+	}
+	if moved.OrganizationMemberships, err = queries.MergeUserOrganizationMemberships(ctx, sqlc.MergeUserOrganizationMembershipsParams{TargetID: targetID, SourceID: sourceID}); err != nil {
+		return moved, err
+	}
+	if moved.TrialConversions, err = queries.MergeUserTrialConversions(ctx, sqlc.MergeUserTrialConversionsParams{TargetID: targetID, SourceID: sourceID}); err != nil {
+		return moved, err
+	}
+	if moved.ExportJobs, err = queries.MergeUserExportJobs(ctx, sqlc.MergeUserExportJobsParams{TargetID: targetID, SourceID: sourceID}); err != nil {
+		return moved, err
+	}
+	if moved.PasswordHistory, err = queries.MergeUserPasswordHistory(ctx, sqlc.MergeUserPasswordHistoryParams{TargetID: targetID, SourceID: sourceID}); err != nil {
+		return moved, err
+	}
+	if moved.KnownDevices, err = queries.MergeUserKnownDevices(ctx, sqlc.MergeUserKnownDevicesParams{TargetID: targetID, SourceID: sourceID}); err != nil {
+		return moved, err
+	}
+	if moved.APIKeyLimits, err = queries.MergeUserAPIKeyLimits(ctx, sqlc.MergeUserAPIKeyLimitsParams{TargetID: targetID, SourceID: sourceID}); err != nil {
+		return moved, err
+	}
+	if moved.OAuthClients, err = queries.MergeUserOAuthClients(ctx, sqlc.MergeUserOAuthClientsParams{TargetID: targetID, SourceID: sourceID}); err != nil {
+		return moved, err
+	}
+	if moved.OAuthGrants, err = queries.MergeUserOAuthGrants(ctx, sqlc.MergeUserOAuthGrantsParams{TargetID: targetID, SourceID: sourceID}); err != nil {
+		return moved, err
+	}
+	if moved.OAuthDeviceCodes, err = queries.MergeUserOAuthDeviceCodes(ctx, sqlc.MergeUserOAuthDeviceCodesParams{TargetID: targetID, SourceID: sourceID}); err != nil {
+		return moved, err
+	}
+	if moved.LoginEvents, err = queries.MergeUserLoginEvents(ctx, sqlc.MergeUserLoginEventsParams{TargetID: targetID, SourceID: sourceID}); err != nil {
+		return moved, err
+	}
+	if moved.AuthEvents, err = queries.MergeUserAuthEvents(ctx, sqlc.MergeUserAuthEventsParams{TargetID: targetID, SourceID: sourceID}); err != nil {
+		return moved, err
+	}
+	// Memberships, devices and limits were copied; the source's rows go
+	// with it
+	if err := queries.DeleteUser(ctx, sourceID); err != nil {
+		return moved, err
+	}
+
+	return moved, tx.Commit()
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== USAGE EXPORTER ==========
+
+// MetricsHandler exports usage in the Prometheus text format
+type MetricsHandler struct {
+	db      *sql.DB
+	queries *sqlc.Queries
+}
+
+// NewMetricsHandler creates a new metrics handler
+func NewMetricsHandler(db *sql.DB) *MetricsHandler {
+	return &MetricsHandler{
+		db:      db,
+		queries: sqlc.New(db),
+	}
+}
+
+// promLabelEscaper escapes label values per the Prometheus text format
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// UsageMetrics exports the current UTC month's minutes and sessions per user
+// and per API key as gauges, followed by the trial funnel counters and the
+// database connection pool's statistics. It needs METRICS_TOKEN as a bearer token and is not served
+// while that is unset. Users and keys without sessions this month are
+// omitted.
+func (h *MetricsHandler) UsageMetrics(c echo.Context) error {
+	token := config.String("METRICS_TOKEN")
+	if token == "" {
+		return apiError(http.StatusNotFound, "not found")
+	}
+	presented, ok := strings.CutPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+		return apiError(http.StatusUnauthorized, "invalid metrics token")
+	}
+
+	ctx := context.Background()
+	period := monthPeriod(time.Now(), time.UTC)
+
+	users, err := h.queries.ListUserUsageTotals(ctx, sqlc.ListUserUsageTotalsParams{
+		StartDate: period.Start,
+		EndDate:   period.End,
+	})
+	if err != nil {
+		log.Printf("[Metrics] Failed to list user usage: %v", err)
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	keys, err := h.queries.ListAPIKeyUsageTotals(ctx, sqlc.ListAPIKeyUsageTotalsParams{
+		StartDate: period.Start,
+		EndDate:   period.End,
+	})
+	if err != nil {
+		log.Printf("[Metrics] Failed to list API key usage: %v", err)
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	funnel, err := h.queries.ListTrialFunnelTotals(ctx)
+	if err != nil {
+		log.Printf("[Metrics] Failed to list trial funnel: %v", err)
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	var b strings.Builder
+
+	writeMetricHeader(&b, "hyperwhisper_user_usage_minutes", "Minutes transcribed this calendar month (UTC), per user", "gauge")
+	for _, u := range users {
+		writeSample(&b, "hyperwhisper_user_usage_minutes", userLabels(u), parseDecimalString(u.TotalDurationSeconds)/60)
+	}
+	writeMetricHeader(&b, "hyperwhisper_user_usage_sessions", "Sessions started this calendar month (UTC), per user", "gauge")
+	for _, u := range users {
+		writeSample(&b, "hyperwhisper_user_usage_sessions", userLabels(u), float64(u.TotalSessions))
+	}
+
+	writeMetricHeader(&b, "hyperwhisper_api_key_usage_minutes", "Minutes transcribed this calendar month (UTC), per API key", "gauge")
+	for _, k := range keys {
+		writeSample(&b, "hyperwhisper_api_key_usage_minutes", apiKeyLabels(k), parseDecimalString(k.TotalDurationSeconds)/60)
+	}
+	writeMetricHeader(&b, "hyperwhisper_api_key_usage_sessions", "Sessions started this calendar month (UTC), per API key", "gauge")
+	for _, k := range keys {
+		writeSample(&b, "hyperwhisper_api_key_usage_sessions", apiKeyLabels(k), float64(k.TotalSessions))
+	}
+
+	writeTrialFunnelMetrics(&b, funnel)
+	writePoolMetrics(&b, h.db.Stats())
+
+	return c.Blob(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
+
+// writeTrialFunnelMetrics exports how many trial keys of each preset
+// reached each funnel step. Campaign codes select the preset, so the preset
+// label tells campaigns apart. The counts come from the database and only
+// drop when trial keys are deleted or a preset's quota is raised.
+func writeTrialFunnelMetrics(b *strings.Builder, funnel []sqlc.ListTrialFunnelTotalsRow) {
+	steps := []struct {
+		name, help string
+		value      func(sqlc.ListTrialFunnelTotalsRow) int64
+	}{
+		{"hyperwhisper_trial_provisioned_total", "Trial keys provisioned, per preset",
+			func(r sqlc.ListTrialFunnelTotalsRow) int64 { return r.Provisioned }},
+		{"hyperwhisper_trial_first_session_total", "Trial keys that started at least one session, per preset",
+			func(r sqlc.ListTrialFunnelTotalsRow) int64 { return r.FirstSession }},
+		{"hyperwhisper_trial_quota_exhausted_total", "Trial keys that used up their preset's minutes or sessions, per preset",
+			func(r sqlc.ListTrialFunnelTotalsRow) int64 { return r.QuotaExhausted }},
+		{"hyperwhisper_trial_converted_total", "Trial keys converted into an account, per preset",
+			func(r sqlc.ListTrialFunnelTotalsRow) int64 { return r.Converted }},
+	}
+	for _, step := range steps {
+		writeMetricHeader(b, step.name, step.help, "counter")
+		for _, r := range funnel {
+			writeSample(b, step.name, [][2]string{{"preset", r.Preset}}, float64(step.value(r)))
+		}
+	}
+}
+
+// writePoolMetrics exports the database connection pool's statistics
+func writePoolMetrics(b *strings.Builder, stats sql.DBStats) {
+	gauges := []struct {
+		name, help string
+		value      float64
+	}{
+		{"hyperwhisper_db_max_open_connections", "Maximum open database connections (DB_MAX_OPEN_CONNS)", float64(stats.MaxOpenConnections)},
+		{"hyperwhisper_db_open_connections", "Open database connections", float64(stats.OpenConnections)},
+		{"hyperwhisper_db_in_use_connections", "Database connections in use", float64(stats.InUse)},
+		{"hyperwhisper_db_idle_connections", "Idle database connections", float64(stats.Idle)},
+	}
+	for _, g := range gauges {
+		writeMetricHeader(b, g.name, g.help, "gauge")
+		writeSample(b, g.name, nil, g.value)
+	}
+
+	counters := []struct {
+		name, help string
+		value      float64
+	}{
+		{"hyperwhisper_db_wait_count_total", "Queries that waited for a free database connection", float64(stats.WaitCount)},
+		{"hyperwhisper_db_wait_duration_seconds_total", "Time spent waiting for a free database connection", stats.WaitDuration.Seconds()},
+		{"hyperwhisper_db_max_idle_closed_total", "Connections closed because of DB_MAX_IDLE_CONNS", float64(stats.MaxIdleClosed)},
+		{"hyperwhisper_db_max_idle_time_closed_total", "Connections closed because of DB_CONN_MAX_IDLE_TIME", float64(stats.MaxIdleTimeClosed)},
+		{"hyperwhisper_db_max_lifetime_closed_total", "Connections closed because of DB_CONN_MAX_LIFETIME", float64(stats.MaxLifetimeClosed)},
+	}
+	for _, c := range counters {
+		writeMetricHeader(b, c.name, c.help, "counter")
+		writeSample(b, c.name, nil, c.value)
+	}
+}
+
+// userLabels are the labels of a user's usage gauges
+func userLabels(u sqlc.ListUserUsageTotalsRow) [][2]string {
+	return [][2]string{
+		{"user_id", u.ID.String()},
+		{"username", u.Username},
+	}
+}
+
+// apiKeyLabels are the labels of an API key's usage gauges; organization_id
+// is empty for personal keys
+func apiKeyLabels(k sqlc.ListAPIKeyUsageTotalsRow) [][2]string {
+	orgID := ""
+	if k.OrganizationID.Valid {
+		orgID = k.OrganizationID.UUID.String()
+	}
+	return [][2]string{
+		{"key_id", k.ID.String()},
+		{"key_prefix", k.KeyPrefix},
+		{"key_name", k.Name},
+		{"user_id", k.UserID.String()},
+		{"organization_id", orgID},
+	}
+}
+
+func writeMetricHeader(b *strings.Builder, name, help, metricType string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func writeSample(b *strings.Builder, name string, labels [][2]string, value float64) {
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, `%s="%s"`, l[0], promLabelEscaper.Replace(l[1]))
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	b.WriteByte('\n')
+}
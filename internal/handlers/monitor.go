@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"hyperwhisper/internal/config"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+)
+
+// monitorStatsInterval is how often the monitor sends concurrency and
+// throughput figures
+const monitorStatsInterval = time.Second
+
+// monitorUpgrader upgrades admin monitor connections
+var monitorUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool {
+		if config.IsDev() {
+			return true
+		}
+		return checkAllowedOrigin(r)
+	},
+}
+
+// Monitor streams server events to the admin dashboard: sessions starting
+// and ending, session errors, and every second the number of live sessions
+// and the audio throughput. Messages are Event objects.
+func (h *AdminHandler) Monitor(c echo.Context) error {
+	conn, err := monitorUpgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		log.Printf("[Monitor] WebSocket upgrade failed: %v", err)
+		return err
+	}
+	defer conn.Close()
+
+	events, unsubscribe := Events.Subscribe()
+	defer unsubscribe()
+
+	// The monitor only sends; reading detects the admin going away
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(monitorStatsInterval)
+	defer ticker.Stop()
+
+	lastBytes, lastTick := Events.Bytes(), time.Now()
+	if err := writeMessageJSON(conn, monitorStats(0)); err != nil {
+		return nil
+	}
+
+	for {
+		var e Event
+		select {
+		case <-done:
+			return nil
+		case e = <-events:
+		case now := <-ticker.C:
+			bytes := Events.Bytes()
+			e = monitorStats(float64(bytes-lastBytes) / now.Sub(lastTick).Seconds())
+			lastBytes, lastTick = bytes, now
+		}
+
+		if err := writeMessageJSON(conn, e); err != nil {
+			log.Printf("[Monitor] Write failed: %v", err)
+			return nil
+		}
+	}
+}
+
+// monitorStats is a stats event with the current number of live sessions
+func monitorStats(bytesPerSecond float64) Event {
+	concurrency := Sessions.Count()
+	return Event{
+		Type:           EventStats,
+		Time:           time.Now(),
+		Concurrency:    &concurrency,
+		BytesPerSecond: &bytesPerSecond,
+	}
+}
+
+// writeMessageJSON sends v as JSON, bounded by the proxy write timeout so a
+// stalled admin cannot hold the monitor open
+func writeMessageJSON(conn *websocket.Conn, v interface{}) error {
+	if timeout := currentProxyTuning().WriteTimeout; timeout > 0 {
+		_ = conn.SetWriteDeadline(time.Now().Add(timeout))
+	}
+	return conn.WriteJSON(v)
+}
@@ -0,0 +1,814 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== OAUTH2 AUTHORIZATION SERVER ==========
+
+const (
+	// oauthCodeExpiry is how long an authorization code can be exchanged
+	oauthCodeExpiry = 10 * time.Minute
+	// maxOAuthRedirectURIs is how many redirect URIs a client may register
+	maxOAuthRedirectURIs = 10
+	// maxOAuthClientNameLength matches oauth_clients.name
+	maxOAuthClientNameLength = 100
+	// desktopClientName and cliClientName are the first-party apps' names on
+	// the consent screen
+	desktopClientName = "HyperWhisper for desktop"
+	cliClientName     = "HyperWhisper CLI"
+)
+
+// OAuthHandler lets users register third-party applications and authorize
+// them to call the API on their behalf, limited to the scopes they grant
+// (authorization code grant with optional PKCE, RFC 6749 and 7636, or the
+// device flow, RFC 8628). First-party apps sign users in through the same
+// flows, see firstPartyClient.
+type OAuthHandler struct {
+	db      *sql.DB
+	queries *sqlc.Queries
+	// sessions issues first-party apps' tokens like a sign-in does
+	sessions *AuthHandler
+}
+
+// NewOAuthHandler creates a new OAuth handler
+func NewOAuthHandler(db *sql.DB) *OAuthHandler {
+	return &OAuthHandler{
+		db:       db,
+		queries:  sqlc.New(db),
+		sessions: NewAuthHandler(db),
+	}
+}
+
+// firstPartyClient returns the first-party app clientID names: the desktop
+// app (DESKTOP_CLIENT_ID) or the CLI (CLI_CLIENT_ID). First-party apps are
+// public clients: they have no row in oauth_clients and no secret, must use
+// PKCE or the device flow, may only redirect to a loopback address, and get
+// the user's own tokens instead of scoped ones.
+func firstPartyClient(clientID string) (sqlc.OauthClient, bool) {
+	for _, app := range []struct{ setting, name string }{
+		{"DESKTOP_CLIENT_ID", desktopClientName},
+		{"CLI_CLIENT_ID", cliClientName},
+	} {
+		if id := config.String(app.setting); id != "" && clientID == id {
+			return sqlc.OauthClient{ClientID: id, Name: app.name}, true
+		}
+	}
+	return sqlc.OauthClient{}, false
+}
+
+// oauthClientRef is how authorization codes refer to client: not at all for
+// first-party apps
+func oauthClientRef(client sqlc.OauthClient) uuid.NullUUID {
+	return uuid.NullUUID{UUID: client.ID, Valid: client.ID != uuid.Nil}
+}
+
+// RegisterOAuthClientRequest registers a third-party application
+type RegisterOAuthClientRequest struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+}
+
+// OAuthClientResponse is a registered application. ClientSecret is only
+// set when it is registered.
+type OAuthClientResponse struct {
+	ID           string   `json:"id"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	CreatedAt    string   `json:"created_at"`
+}
+
+func toOAuthClientResponse(client sqlc.OauthClient) OAuthClientResponse {
+	return OAuthClientResponse{
+		ID:           client.ID.String(),
+		ClientID:     client.ClientID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectUris,
+		Scopes:       client.Scopes,
+		CreatedAt:    client.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// RegisterClient registers a third-party application owned by the user.
+// The client secret is returned once and only its hash is stored.
+func (h *OAuthHandler) RegisterClient(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	var req RegisterOAuthClientRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxOAuthClientNameLength {
+		return newAPIError(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid application name",
+			Details: map[string]string{"name": "must be 1 to 100 characters"},
+		})
+	}
+	if len(req.RedirectURIs) == 0 || len(req.RedirectURIs) > maxOAuthRedirectURIs {
+		return newAPIError(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid redirect URIs",
+			Details: map[string]string{"redirect_uris": "must list 1 to 10 URIs"},
+		})
+	}
+	var redirectURIs []string
+	for _, raw := range req.RedirectURIs {
+		if !validOAuthRedirectURI(raw) {
+			return newAPIError(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid redirect URIs",
+				Details: map[string]string{raw: "must be an https URL, or http on a loopback address, without a fragment"},
+			})
+		}
+		if !slices.Contains(redirectURIs, raw) {
+			redirectURIs = append(redirectURIs, raw)
+		}
+	}
+	scopes, ok := parseOAuthScopes(req.Scopes)
+	if !ok || len(scopes) == 0 {
+		return apiError(http.StatusBadRequest, "invalid scope")
+	}
+
+	clientID, err := newRandomToken()
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to register application")
+	}
+	clientID = clientID[:32]
+	secret, err := newRandomToken()
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to register application")
+	}
+
+	ctx := context.Background()
+	client, err := h.queries.CreateOAuthClient(ctx, sqlc.CreateOAuthClientParams{
+		ClientID:         clientID,
+		ClientSecretHash: hashAPIKey(secret),
+		Name:             name,
+		RedirectUris:     redirectURIs,
+		Scopes:           scopes,
+		OwnerUserID:      claims.UserID,
+	})
+	if err != nil {
+		log.Printf("[OAuth] Failed to register application of user %s: %v", claims.UserID, err)
+		return apiError(http.StatusInternalServerError, "failed to register application")
+	}
+
+	log.Printf("[OAuth] User %s registered application %s (%s)", claims.UserID, client.ClientID, client.Name)
+	resp := toOAuthClientResponse(client)
+	resp.ClientSecret = secret
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// ListClients lists the applications the user registered
+func (h *OAuthHandler) ListClients(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	clients, err := h.queries.ListUserOAuthClients(context.Background(), claims.UserID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	resp := make([]OAuthClientResponse, 0, len(clients))
+	for _, client := range clients {
+		resp = append(resp, toOAuthClientResponse(client))
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// DeleteClient deletes an application the user registered, with every
+// grant users gave it. Its access tokens stop working at once.
+func (h *OAuthHandler) DeleteClient(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid application ID")
+	}
+
+	ctx := context.Background()
+	grantIDs, err := h.queries.ListOAuthClientGrantIDs(ctx, id)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	deleted, err := h.queries.DeleteOAuthClient(ctx, sqlc.DeleteOAuthClientParams{
+		ID:          id,
+		OwnerUserID: claims.UserID,
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to delete application")
+	}
+	if deleted == 0 {
+		return apiError(http.StatusNotFound, "application not found")
+	}
+
+	for _, grantID := range grantIDs {
+		denyAccessToken(ctx, h.queries, grantID.String())
+	}
+
+	log.Printf("[OAuth] User %s deleted application %s, revoking %d grants", claims.UserID, id, len(grantIDs))
+	return c.JSON(http.StatusOK, map[string]string{"message": "application deleted"})
+}
+
+// authorizationRequest is the authorization request of a client, as sent
+// to the consent screen (RFC 6749 section 4.1.1)
+type authorizationRequest struct {
+	ResponseType        string `json:"response_type" query:"response_type"`
+	ClientID            string `json:"client_id" query:"client_id"`
+	RedirectURI         string `json:"redirect_uri" query:"redirect_uri"`
+	Scope               string `json:"scope" query:"scope"`
+	State               string `json:"state" query:"state"`
+	CodeChallenge       string `json:"code_challenge" query:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method" query:"code_challenge_method"`
+}
+
+// validate returns the client of the request and the scopes it asks for.
+// No scope asks for every scope the client registered. First-party apps ask
+// for no scopes, as they act as the user.
+func (r authorizationRequest) validate(ctx context.Context, queries *sqlc.Queries) (sqlc.OauthClient, []string, error) {
+	if r.ResponseType != "code" {
+		return sqlc.OauthClient{}, nil, apiError(http.StatusBadRequest, "unsupported response type")
+	}
+
+	// Only S256: plain challenges give no protection over TLS
+	if r.CodeChallenge != "" || r.CodeChallengeMethod != "" {
+		if r.CodeChallengeMethod != "S256" || len(r.CodeChallenge) < 43 || len(r.CodeChallenge) > 128 {
+			return sqlc.OauthClient{}, nil, apiError(http.StatusBadRequest, "unsupported code challenge method")
+		}
+	}
+
+	if client, ok := firstPartyClient(r.ClientID); ok {
+		// Without a secret, the code verifier is what keeps an intercepted
+		// code from being redeemed (RFC 8252 section 8.1)
+		if r.CodeChallenge == "" {
+			return sqlc.OauthClient{}, nil, apiError(http.StatusBadRequest, "code challenge required")
+		}
+		if !validLoopbackRedirectURI(r.RedirectURI) {
+			return sqlc.OauthClient{}, nil, apiError(http.StatusBadRequest, "redirect URI not registered")
+		}
+		return client, nil, nil
+	}
+
+	client, err := queries.GetOAuthClientByClientID(ctx, r.ClientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return sqlc.OauthClient{}, nil, apiError(http.StatusBadRequest, "unknown application")
+		}
+		return sqlc.OauthClient{}, nil, apiError(http.StatusInternalServerError, "database error")
+	}
+	if !slices.Contains(client.RedirectUris, r.RedirectURI) {
+		return sqlc.OauthClient{}, nil, apiError(http.StatusBadRequest, "redirect URI not registered")
+	}
+
+	scopes, ok := requestedScopes(client, r.Scope)
+	if !ok {
+		return sqlc.OauthClient{}, nil, apiError(http.StatusBadRequest, "invalid scope")
+	}
+	return client, scopes, nil
+}
+
+// requestedScopes returns the scopes a request of client asks for with raw,
+// space-separated, or every scope the client registered if raw is empty.
+// False if raw names none or one the client didn't register.
+func requestedScopes(client sqlc.OauthClient, raw string) ([]string, bool) {
+	if raw == "" {
+		return client.Scopes, true
+	}
+	scopes, ok := parseOAuthScopes(strings.Fields(raw))
+	if !ok || len(scopes) == 0 {
+		return nil, false
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(client.Scopes, scope) {
+			return nil, false
+		}
+	}
+	return scopes, true
+}
+
+// OAuthScopeDescription is a scope as shown on the consent screen
+type OAuthScopeDescription struct {
+	Scope       string `json:"scope"`
+	Description string `json:"description"`
+}
+
+// AuthorizationResponse tells the consent screen what the application asks
+// for. FirstParty is set for the desktop app and CLI, which ask to sign the
+// user in rather than for scopes. The device flow has no redirect URI.
+type AuthorizationResponse struct {
+	ClientID    string                  `json:"client_id"`
+	ClientName  string                  `json:"client_name"`
+	FirstParty  bool                    `json:"first_party"`
+	RedirectURI string                  `json:"redirect_uri,omitempty"`
+	Scopes      []OAuthScopeDescription `json:"scopes"`
+	State       string                  `json:"state,omitempty"`
+}
+
+func toAuthorizationResponse(client sqlc.OauthClient, scopes []string) AuthorizationResponse {
+	descriptions := make([]OAuthScopeDescription, 0, len(scopes))
+	for _, scope := range scopes {
+		descriptions = append(descriptions, OAuthScopeDescription{
+			Scope:       scope,
+			Description: auth.Scopes[auth.Scope(scope)],
+		})
+	}
+	return AuthorizationResponse{
+		ClientID:   client.ClientID,
+		ClientName: client.Name,
+		FirstParty: !oauthClientRef(client).Valid,
+		Scopes:     descriptions,
+	}
+}
+
+// GetAuthorization validates an authorization request for the consent
+// screen. Invalid requests are shown to the user rather than sent back to
+// the application, as its redirect URI can't be trusted before it checked
+// out.
+func (h *OAuthHandler) GetAuthorization(c echo.Context) error {
+	var req authorizationRequest
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid authorization request")
+	}
+
+	client, scopes, err := req.validate(context.Background(), h.queries)
+	if err != nil {
+		return err
+	}
+
+	resp := toAuthorizationResponse(client, scopes)
+	resp.RedirectURI = req.RedirectURI
+	resp.State = req.State
+	return c.JSON(http.StatusOK, resp)
+}
+
+// AuthorizeRequest is the user's answer on the consent screen
+type AuthorizeRequest struct {
+	authorizationRequest
+	Approve bool `json:"approve"`
+}
+
+// Authorize records the user's consent. The response names where the
+// consent screen sends the user back to: the application's redirect URI
+// with an authorization code, or with access_denied if they declined.
+func (h *OAuthHandler) Authorize(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	var req AuthorizeRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	ctx := context.Background()
+	client, scopes, err := req.validate(ctx, h.queries)
+	if err != nil {
+		return err
+	}
+
+	params := url.Values{}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	if !req.Approve {
+		params.Set("error", "access_denied")
+		return c.JSON(http.StatusOK, map[string]string{"redirect_to": withQuery(req.RedirectURI, params)})
+	}
+
+	code, err := newRandomToken()
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to authorize application")
+	}
+
+	// Expired codes are removed whenever a new one is issued
+	if err := h.queries.DeleteExpiredOAuthAuthorizationCodes(ctx); err != nil {
+		log.Printf("[OAuth] Failed to delete expired authorization codes: %v", err)
+	}
+	err = h.queries.CreateOAuthAuthorizationCode(ctx, sqlc.CreateOAuthAuthorizationCodeParams{
+		CodeHash:      hashAPIKey(code),
+		ClientID:      oauthClientRef(client),
+		UserID:        claims.UserID,
+		RedirectUri:   req.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: sql.NullString{String: req.CodeChallenge, Valid: req.CodeChallenge != ""},
+		ExpiresAt:     time.Now().Add(oauthCodeExpiry),
+	})
+	if err != nil {
+		log.Printf("[OAuth] Failed to store authorization code of user %s: %v", claims.UserID, err)
+		return apiError(http.StatusInternalServerError, "failed to authorize application")
+	}
+
+	recordAuditEvent(ctx, h.queries, c, auditOAuthAuthorize, "oauth_client", client.ClientID, "", map[string]string{
+		"client_name": client.Name,
+		"scopes":      strings.Join(scopes, " "),
+	})
+
+	params.Set("code", code)
+	return c.JSON(http.StatusOK, map[string]string{"redirect_to": withQuery(req.RedirectURI, params)})
+}
+
+// OAuthTokenResponse is a successful token response (RFC 6749 section 5.1)
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// OAuthErrorResponse is a token endpoint error (RFC 6749 section 5.2).
+// Clients expect this shape, so it is not turned into a problem.
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// oauthError answers the token endpoint with an RFC 6749 error
+func oauthError(c echo.Context, status int, code, description string) error {
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(status, OAuthErrorResponse{Error: code, ErrorDescription: description})
+}
+
+// oauthFailure is an RFC 6749 error found by a helper, for its caller to
+// answer with
+type oauthFailure struct {
+	status      int
+	code        string
+	description string
+}
+
+func (f *oauthFailure) answer(c echo.Context) error {
+	return oauthError(c, f.status, f.code, f.description)
+}
+
+var errOAuthServer = &oauthFailure{status: http.StatusInternalServerError, code: "server_error"}
+
+// authenticateClient returns the client of a token or device authorization
+// request. Registered clients authenticate with HTTP Basic or client_id and
+// client_secret form fields; first-party apps only send their client_id.
+func (h *OAuthHandler) authenticateClient(ctx context.Context, c echo.Context) (sqlc.OauthClient, *oauthFailure) {
+	clientID, secret, basic := c.Request().BasicAuth()
+	if basic {
+		// RFC 6749 section 2.3.1: credentials are form-encoded first
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = c.FormValue("client_id"), c.FormValue("client_secret")
+	}
+	if client, ok := firstPartyClient(clientID); ok {
+		return client, nil
+	}
+
+	client, err := h.queries.GetOAuthClientByClientID(ctx, clientID)
+	if err != nil && err != sql.ErrNoRows {
+		return sqlc.OauthClient{}, errOAuthServer
+	}
+	if err != nil || subtle.ConstantTimeCompare([]byte(hashAPIKey(secret)), []byte(client.ClientSecretHash)) != 1 {
+		if basic {
+			c.Response().Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		return sqlc.OauthClient{}, &oauthFailure{http.StatusUnauthorized, "invalid_client", "client authentication failed"}
+	}
+	return client, nil
+}
+
+// Token exchanges an authorization code, device code or refresh token for
+// tokens. Refresh tokens are rotated on every use.
+func (h *OAuthHandler) Token(c echo.Context) error {
+	ctx := context.Background()
+
+	client, failure := h.authenticateClient(ctx, c)
+	if failure != nil {
+		return failure.answer(c)
+	}
+
+	switch c.FormValue("grant_type") {
+	case "authorization_code":
+		return h.exchangeCode(ctx, c, client)
+	case deviceCodeGrantType:
+		return h.exchangeDeviceCode(ctx, c, client)
+	case "refresh_token":
+		return h.refresh(ctx, c, client)
+	case "":
+		return oauthError(c, http.StatusBadRequest, "invalid_request", "grant_type is required")
+	}
+	return oauthError(c, http.StatusBadRequest, "unsupported_grant_type", "")
+}
+
+// exchangeCode redeems an authorization code, starting a grant
+func (h *OAuthHandler) exchangeCode(ctx context.Context, c echo.Context, client sqlc.OauthClient) error {
+	code := c.FormValue("code")
+	if code == "" {
+		return oauthError(c, http.StatusBadRequest, "invalid_request", "code is required")
+	}
+
+	// Codes are single use, so one replayed or sent by the wrong client is
+	// spent as well
+	authCode, err := h.queries.ConsumeOAuthAuthorizationCode(ctx, hashAPIKey(code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return oauthError(c, http.StatusBadRequest, "invalid_grant", "invalid, used or expired code")
+		}
+		return oauthError(c, http.StatusInternalServerError, "server_error", "")
+	}
+	if authCode.ClientID != oauthClientRef(client) || authCode.RedirectUri != c.FormValue("redirect_uri") {
+		return oauthError(c, http.StatusBadRequest, "invalid_grant", "code was issued to another client or redirect URI")
+	}
+	if authCode.CodeChallenge.Valid {
+		sum := sha256.Sum256([]byte(c.FormValue("code_verifier")))
+		challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+		if subtle.ConstantTimeCompare([]byte(challenge), []byte(authCode.CodeChallenge.String)) != 1 {
+			return oauthError(c, http.StatusBadRequest, "invalid_grant", "code_verifier does not match the code challenge")
+		}
+	}
+
+	return h.startGrant(ctx, c, client, authCode.UserID, authCode.Scopes)
+}
+
+// startGrant answers a client a user authorized with its first tokens: the
+// user's own for first-party apps, and a new grant's for the rest
+func (h *OAuthHandler) startGrant(ctx context.Context, c echo.Context, client sqlc.OauthClient, userID uuid.UUID, scopes []string) error {
+	user, failure := h.grantUser(ctx, userID)
+	if failure != nil {
+		return failure.answer(c)
+	}
+	if !oauthClientRef(client).Valid {
+		return h.signInFirstParty(ctx, c, client, user)
+	}
+
+	refreshToken, err := newRandomToken()
+	if err != nil {
+		return oauthError(c, http.StatusInternalServerError, "server_error", "")
+	}
+	grant, err := h.queries.CreateOAuthGrant(ctx, sqlc.CreateOAuthGrantParams{
+		ClientID:         client.ID,
+		UserID:           user.ID,
+		Scopes:           scopes,
+		RefreshTokenHash: hashAPIKey(refreshToken),
+		ExpiresAt:        oauthGrantExpiry(),
+	})
+	if err != nil {
+		log.Printf("[OAuth] Failed to create grant of user %s for %s: %v", user.ID, client.ClientID, err)
+		return oauthError(c, http.StatusInternalServerError, "server_error", "")
+	}
+
+	log.Printf("[OAuth] User %s granted %s (%s) scopes %s", user.ID, client.ClientID, client.Name, strings.Join(grant.Scopes, " "))
+	return h.issueTokens(c, client, user, grant, refreshToken)
+}
+
+// refresh rotates a grant's refresh token, pushing back its expiry
+func (h *OAuthHandler) refresh(ctx context.Context, c echo.Context, client sqlc.OauthClient) error {
+	oldToken := c.FormValue("refresh_token")
+	if oldToken == "" {
+		return oauthError(c, http.StatusBadRequest, "invalid_request", "refresh_token is required")
+	}
+	// First-party apps' refresh tokens are the user's own, rotated like the
+	// dashboard's
+	if !oauthClientRef(client).Valid {
+		tokens, err := h.sessions.rotateRefreshToken(c, ctx, oldToken)
+		if err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.Status == http.StatusUnauthorized {
+				return oauthError(c, http.StatusBadRequest, "invalid_grant", apiErr.Response.Error)
+			}
+			return oauthError(c, http.StatusInternalServerError, "server_error", "")
+		}
+		return firstPartyTokens(c, tokens)
+	}
+
+	refreshToken, err := newRandomToken()
+	if err != nil {
+		return oauthError(c, http.StatusInternalServerError, "server_error", "")
+	}
+	grant, err := h.queries.RotateOAuthRefreshToken(ctx, sqlc.RotateOAuthRefreshTokenParams{
+		NewHash:   hashAPIKey(refreshToken),
+		ExpiresAt: oauthGrantExpiry(),
+		OldHash:   hashAPIKey(oldToken),
+		ClientID:  client.ID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return oauthError(c, http.StatusBadRequest, "invalid_grant", "invalid, revoked or expired refresh token")
+		}
+		return oauthError(c, http.StatusInternalServerError, "server_error", "")
+	}
+
+	user, failure := h.grantUser(ctx, grant.UserID)
+	if failure != nil {
+		return failure.answer(c)
+	}
+	return h.issueTokens(c, client, user, grant, refreshToken)
+}
+
+// grantUser returns the user a grant acts for, refusing suspended users
+func (h *OAuthHandler) grantUser(ctx context.Context, userID uuid.UUID) (sqlc.User, *oauthFailure) {
+	user, err := h.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return sqlc.User{}, &oauthFailure{http.StatusBadRequest, "invalid_grant", "user not found"}
+		}
+		return sqlc.User{}, errOAuthServer
+	}
+	if user.SuspendedAt.Valid {
+		return sqlc.User{}, &oauthFailure{http.StatusBadRequest, "invalid_grant", "account suspended"}
+	}
+	return user, nil
+}
+
+// issueTokens answers with an access token for the grant and its current
+// refresh token
+func (h *OAuthHandler) issueTokens(c echo.Context, client sqlc.OauthClient, user sqlc.User, grant sqlc.OauthGrant, refreshToken string) error {
+	accessToken, expiresIn, err := auth.GenerateOAuthAccessToken(user.ID, user.UserType, user.TenantID.UUID, grant.ID, client.ClientID, grant.Scopes)
+	if err != nil {
+		return oauthError(c, http.StatusInternalServerError, "server_error", "")
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, OAuthTokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    expiresIn,
+		RefreshToken: refreshToken,
+		Scope:        strings.Join(grant.Scopes, " "),
+	})
+}
+
+// signInFirstParty answers a first-party app with the user's own tokens,
+// starting a session the user can see and revoke like one of a password
+// sign-in
+func (h *OAuthHandler) signInFirstParty(ctx context.Context, c echo.Context, client sqlc.OauthClient, user sqlc.User) error {
+	tokens, err := auth.GenerateTokenPair(user.ID, user.Username, user.Email, user.UserType, user.TenantID.UUID)
+	if err != nil {
+		return oauthError(c, http.StatusInternalServerError, "server_error", "")
+	}
+	if err := h.sessions.storeRefreshToken(c, ctx, user.ID, uuid.New(), tokens); err != nil {
+		log.Printf("[OAuth] Failed to store %s refresh token of user %s: %v", client.ClientID, user.ID, err)
+		return oauthError(c, http.StatusInternalServerError, "server_error", "")
+	}
+
+	recordLoginEvent(ctx, h.queries, c, uuid.NullUUID{UUID: user.ID, Valid: true}, loginSignIn, loginSuccess)
+	h.sessions.checkNewDevice(ctx, c, user)
+	log.Printf("[OAuth] User %s signed in to %s", user.ID, client.Name)
+	return firstPartyTokens(c, tokens)
+}
+
+// firstPartyTokens answers a first-party app with a token pair
+func firstPartyTokens(c echo.Context, tokens *auth.TokenPair) error {
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, OAuthTokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    tokens.ExpiresIn,
+		RefreshToken: tokens.RefreshToken,
+	})
+}
+
+// OAuthGrantResponse is an application the user authorized
+type OAuthGrantResponse struct {
+	ID         string   `json:"id"`
+	ClientID   string   `json:"client_id"`
+	ClientName string   `json:"client_name"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  string   `json:"created_at"`
+	LastUsedAt *string  `json:"last_used_at"`
+}
+
+// ListGrants lists the applications the user authorized
+func (h *OAuthHandler) ListGrants(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	grants, err := h.queries.ListUserOAuthGrants(context.Background(), claims.UserID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	resp := make([]OAuthGrantResponse, 0, len(grants))
+	for _, grant := range grants {
+		item := OAuthGrantResponse{
+			ID:         grant.ID.String(),
+			ClientID:   grant.ClientID,
+			ClientName: grant.ClientName,
+			Scopes:     grant.Scopes,
+			CreatedAt:  grant.CreatedAt.Format(time.RFC3339),
+		}
+		if grant.LastUsedAt.Valid {
+			t := grant.LastUsedAt.Time.Format(time.RFC3339)
+			item.LastUsedAt = &t
+		}
+		resp = append(resp, item)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// RevokeGrant revokes an application's access to the user's account. Its
+// refresh token and access tokens stop working at once.
+func (h *OAuthHandler) RevokeGrant(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid grant ID")
+	}
+
+	ctx := context.Background()
+	revoked, err := h.queries.RevokeUserOAuthGrant(ctx, sqlc.RevokeUserOAuthGrantParams{
+		ID:     id,
+		UserID: claims.UserID,
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to revoke grant")
+	}
+	if revoked == 0 {
+		return apiError(http.StatusNotFound, "grant not found")
+	}
+	denyAccessToken(ctx, h.queries, id.String())
+
+	recordAuditEvent(ctx, h.queries, c, auditOAuthRevoke, "oauth_grant", id.String(), "", nil)
+	return c.JSON(http.StatusOK, map[string]string{"message": "access revoked"})
+}
+
+// oauthGrantExpiry is when a grant lapses unless refreshed again, after
+// REFRESH_TOKEN_EXPIRY days like the user's own sessions
+func oauthGrantExpiry() time.Time {
+	return time.Now().AddDate(0, 0, config.Int("REFRESH_TOKEN_EXPIRY"))
+}
+
+// parseOAuthScopes returns scopes sorted and without duplicates, or false
+// if one is unknown
+func parseOAuthScopes(raw []string) ([]string, bool) {
+	var scopes []string
+	for _, scope := range raw {
+		if _, ok := auth.Scopes[auth.Scope(scope)]; !ok {
+			return nil, false
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	slices.Sort(scopes)
+	return scopes, true
+}
+
+// validOAuthRedirectURI reports whether a client may register raw: an
+// https URL, or http on a loopback address for native apps (RFC 8252)
+func validOAuthRedirectURI(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.Fragment != "" || u.User != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		host := u.Hostname()
+		if host == "localhost" {
+			return true
+		}
+		ip := net.ParseIP(host)
+		return ip != nil && ip.IsLoopback()
+	}
+	return false
+}
+
+// validLoopbackRedirectURI reports whether a first-party app may be sent to
+// raw: http on a loopback address, on whatever port the app listens on
+// (RFC 8252 section 7.3)
+func validLoopbackRedirectURI(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && u.Scheme == "http" && validOAuthRedirectURI(raw)
+}
+
+// withQuery adds params to the query of a registered redirect URI, keeping
+// the query it already has
+func withQuery(redirectURI string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	query := u.Query()
+	for name, values := range params {
+		query[name] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
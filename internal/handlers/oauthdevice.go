@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== OAUTH2 DEVICE AUTHORIZATION ==========
+
+const (
+	// deviceCodeGrantType is the grant_type of device code exchanges
+	deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+	// oauthDeviceCodeExpiry is how long the user has to enter a user code
+	oauthDeviceCodeExpiry = 15 * time.Minute
+	// oauthDevicePollInterval is how often a client may poll for the tokens
+	oauthDevicePollInterval = 5 * time.Second
+	// userCodeAlphabet has no vowels, so user codes can't spell words, and
+	// no digits to be confused with letters (RFC 8628 section 6.1)
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+	// userCodeLength gives 20^8 user codes, too many to guess within
+	// oauthDeviceCodeExpiry past the verification rate limit
+	userCodeLength = 8
+)
+
+// Statuses of device codes
+const (
+	deviceCodePending  = "pending"
+	deviceCodeApproved = "approved"
+	deviceCodeDenied   = "denied"
+	deviceCodeRedeemed = "redeemed"
+)
+
+// DeviceAuthorizationResponse tells a client which code the user enters
+// where (RFC 8628 section 3.2)
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+// DeviceAuthorization starts the device flow of a client that can't open a
+// browser on the user's machine, such as a CLI over SSH. The user enters the
+// returned user code on the dashboard from any device while the client polls
+// the token endpoint with the device code. Clients authenticate like at the
+// token endpoint and name scopes like in an authorization request.
+func (h *OAuthHandler) DeviceAuthorization(c echo.Context) error {
+	ctx := context.Background()
+
+	client, failure := h.authenticateClient(ctx, c)
+	if failure != nil {
+		return failure.answer(c)
+	}
+	var scopes []string
+	if oauthClientRef(client).Valid {
+		var ok bool
+		if scopes, ok = requestedScopes(client, c.FormValue("scope")); !ok {
+			return oauthError(c, http.StatusBadRequest, "invalid_scope", "")
+		}
+	}
+
+	deviceCode, err := newRandomToken()
+	if err != nil {
+		return oauthError(c, http.StatusInternalServerError, "server_error", "")
+	}
+	userCode, err := newUserCode()
+	if err != nil {
+		return oauthError(c, http.StatusInternalServerError, "server_error", "")
+	}
+
+	// Expired codes are removed whenever a new one is issued, which also
+	// frees their user codes
+	if err := h.queries.DeleteExpiredOAuthDeviceCodes(ctx); err != nil {
+		log.Printf("[OAuth] Failed to delete expired device codes: %v", err)
+	}
+	err = h.queries.CreateOAuthDeviceCode(ctx, sqlc.CreateOAuthDeviceCodeParams{
+		DeviceCodeHash: hashAPIKey(deviceCode),
+		UserCode:       userCode,
+		ClientID:       client.ClientID,
+		Scopes:         scopes,
+		ExpiresAt:      time.Now().Add(oauthDeviceCodeExpiry),
+	})
+	if err != nil {
+		log.Printf("[OAuth] Failed to store device code of %s: %v", client.ClientID, err)
+		return oauthError(c, http.StatusInternalServerError, "server_error", "")
+	}
+
+	// Users of a tenant verify on its dashboard
+	verificationURI := appBaseURL(ctx, h.queries, tenantID(requestTenant(c))) + "/device"
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, DeviceAuthorizationResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                formatUserCode(userCode),
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?user_code=" + url.QueryEscape(formatUserCode(userCode)),
+		ExpiresIn:               int64(oauthDeviceCodeExpiry.Seconds()),
+		Interval:                int64(oauthDevicePollInterval.Seconds()),
+	})
+}
+
+// pendingDeviceCode returns the pending request a user code names, and the
+// client that made it
+func (h *OAuthHandler) pendingDeviceCode(ctx context.Context, rawUserCode string) (sqlc.OauthDeviceCode, sqlc.OauthClient, error) {
+	userCode := normalizeUserCode(rawUserCode)
+	if len(userCode) != userCodeLength {
+		return sqlc.OauthDeviceCode{}, sqlc.OauthClient{}, apiError(http.StatusNotFound, "invalid or expired user code")
+	}
+
+	code, err := h.queries.GetPendingOAuthDeviceCode(ctx, userCode)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return sqlc.OauthDeviceCode{}, sqlc.OauthClient{}, apiError(http.StatusNotFound, "invalid or expired user code")
+		}
+		return sqlc.OauthDeviceCode{}, sqlc.OauthClient{}, apiError(http.StatusInternalServerError, "database error")
+	}
+
+	if client, ok := firstPartyClient(code.ClientID); ok {
+		return code, client, nil
+	}
+	client, err := h.queries.GetOAuthClientByClientID(ctx, code.ClientID)
+	if err != nil {
+		// The application was deleted since
+		if err == sql.ErrNoRows {
+			return sqlc.OauthDeviceCode{}, sqlc.OauthClient{}, apiError(http.StatusNotFound, "invalid or expired user code")
+		}
+		return sqlc.OauthDeviceCode{}, sqlc.OauthClient{}, apiError(http.StatusInternalServerError, "database error")
+	}
+	return code, client, nil
+}
+
+// GetDeviceAuthorization tells the consent screen which application a user
+// code belongs to and what it asks for
+func (h *OAuthHandler) GetDeviceAuthorization(c echo.Context) error {
+	code, client, err := h.pendingDeviceCode(context.Background(), c.QueryParam("user_code"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, toAuthorizationResponse(client, code.Scopes))
+}
+
+// AnswerDeviceAuthorizationRequest is the user's answer to a device's
+// request
+type AnswerDeviceAuthorizationRequest struct {
+	UserCode string `json:"user_code"`
+	Approve  bool   `json:"approve"`
+}
+
+// AnswerDeviceAuthorization records the user's answer to a device's
+// request. The device gets its tokens, or access_denied, on its next poll.
+func (h *OAuthHandler) AnswerDeviceAuthorization(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	var req AnswerDeviceAuthorizationRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	ctx := context.Background()
+	code, client, err := h.pendingDeviceCode(ctx, req.UserCode)
+	if err != nil {
+		return err
+	}
+
+	status := deviceCodeDenied
+	if req.Approve {
+		status = deviceCodeApproved
+	}
+	answered, err := h.queries.AnswerOAuthDeviceCode(ctx, sqlc.AnswerOAuthDeviceCodeParams{
+		Status:   status,
+		UserID:   uuid.NullUUID{UUID: claims.UserID, Valid: true},
+		UserCode: code.UserCode,
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to authorize application")
+	}
+	// Answered or expired in the meantime
+	if answered == 0 {
+		return apiError(http.StatusNotFound, "invalid or expired user code")
+	}
+
+	if !req.Approve {
+		return c.JSON(http.StatusOK, map[string]string{"message": "request denied"})
+	}
+	recordAuditEvent(ctx, h.queries, c, auditOAuthAuthorize, "oauth_client", client.ClientID, "", map[string]string{
+		"client_name": client.Name,
+		"scopes":      strings.Join(code.Scopes, " "),
+		"flow":        "device",
+	})
+	return c.JSON(http.StatusOK, map[string]string{"message": "device authorized"})
+}
+
+// exchangeDeviceCode answers a client polling with its device code: with
+// tokens once the user approved, and until then with authorization_pending
+// (RFC 8628 section 3.5)
+func (h *OAuthHandler) exchangeDeviceCode(ctx context.Context, c echo.Context, client sqlc.OauthClient) error {
+	deviceCode := c.FormValue("device_code")
+	if deviceCode == "" {
+		return oauthError(c, http.StatusBadRequest, "invalid_request", "device_code is required")
+	}
+
+	code, err := h.queries.PollOAuthDeviceCode(ctx, hashAPIKey(deviceCode))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return oauthError(c, http.StatusBadRequest, "invalid_grant", "invalid device code")
+		}
+		return oauthError(c, http.StatusInternalServerError, "server_error", "")
+	}
+	if code.ClientID != client.ClientID {
+		return oauthError(c, http.StatusBadRequest, "invalid_grant", "device code was issued to another client")
+	}
+	if !time.Now().Before(code.ExpiresAt) {
+		return oauthError(c, http.StatusBadRequest, "expired_token", "")
+	}
+	// A second of slack for polls delayed on their way
+	if code.LastPolledAt.Valid && time.Since(code.LastPolledAt.Time) < oauthDevicePollInterval-time.Second {
+		return oauthError(c, http.StatusBadRequest, "slow_down", "")
+	}
+
+	switch code.Status {
+	case deviceCodePending:
+		return oauthError(c, http.StatusBadRequest, "authorization_pending", "")
+	case deviceCodeDenied:
+		return oauthError(c, http.StatusBadRequest, "access_denied", "")
+	case deviceCodeRedeemed:
+		return oauthError(c, http.StatusBadRequest, "invalid_grant", "device code was already used")
+	}
+
+	// Device codes are single use; a concurrent poll may have won
+	redeemed, err := h.queries.RedeemOAuthDeviceCode(ctx, code.DeviceCodeHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return oauthError(c, http.StatusBadRequest, "invalid_grant", "device code was already used")
+		}
+		return oauthError(c, http.StatusInternalServerError, "server_error", "")
+	}
+	return h.startGrant(ctx, c, client, redeemed.UserID.UUID, redeemed.Scopes)
+}
+
+// newUserCode returns a random user code of userCodeLength characters of
+// userCodeAlphabet
+func newUserCode() (string, error) {
+	code := make([]byte, userCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(userCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = userCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// formatUserCode shows a user code as two groups, e.g. WDJB-MJHT
+func formatUserCode(code string) string {
+	return code[:userCodeLength/2] + "-" + code[userCodeLength/2:]
+}
+
+// normalizeUserCode undoes what users may type differently: case, dashes
+// and spaces
+func normalizeUserCode(raw string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, raw)
+}
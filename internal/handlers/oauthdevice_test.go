@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+func TestOAuthTokenDeviceCodePolling(t *testing.T) {
+	withOAuthConfig(t)
+	userID := uuid.New()
+	user := sqlc.User{ID: userID, Username: "ada", Email: "ada@example.com", UserType: "user"}
+	form := url.Values{
+		"grant_type":  {deviceCodeGrantType},
+		"client_id":   {"hyperwhisper-cli"},
+		"device_code": {"the-device-code"},
+	}
+
+	tests := []struct {
+		name     string
+		form     url.Values
+		clientID string
+		status   string
+		// polledAgo is how long before this poll the last one was, or 0 if
+		// this is the first
+		polledAgo time.Duration
+		expiresIn time.Duration
+		// redeemed is whether RedeemOAuthDeviceCode wins the code, for
+		// approved codes
+		redeemed  bool
+		wantError string
+	}{
+		{
+			name:      "approved",
+			status:    deviceCodeApproved,
+			polledAgo: oauthDevicePollInterval,
+			expiresIn: time.Minute,
+			redeemed:  true,
+		},
+		{
+			name:      "first poll",
+			status:    deviceCodePending,
+			expiresIn: time.Minute,
+			wantError: "authorization_pending",
+		},
+		{
+			name:      "pending",
+			status:    deviceCodePending,
+			polledAgo: oauthDevicePollInterval,
+			expiresIn: time.Minute,
+			wantError: "authorization_pending",
+		},
+		{
+			name:      "polled with a second of slack",
+			status:    deviceCodePending,
+			polledAgo: oauthDevicePollInterval - 900*time.Millisecond,
+			expiresIn: time.Minute,
+			wantError: "authorization_pending",
+		},
+		{
+			name:      "polled too soon",
+			status:    deviceCodePending,
+			polledAgo: time.Second,
+			expiresIn: time.Minute,
+			wantError: "slow_down",
+		},
+		{
+			name:      "approved but polled too soon",
+			status:    deviceCodeApproved,
+			polledAgo: time.Second,
+			expiresIn: time.Minute,
+			wantError: "slow_down",
+		},
+		{
+			name:      "denied",
+			status:    deviceCodeDenied,
+			expiresIn: time.Minute,
+			wantError: "access_denied",
+		},
+		{
+			name:      "expired",
+			status:    deviceCodePending,
+			expiresIn: -time.Second,
+			wantError: "expired_token",
+		},
+		{
+			name:      "expired after approval",
+			status:    deviceCodeApproved,
+			expiresIn: -time.Second,
+			wantError: "expired_token",
+		},
+		{
+			name:      "already redeemed",
+			status:    deviceCodeRedeemed,
+			expiresIn: time.Minute,
+			wantError: "invalid_grant",
+		},
+		{
+			name:      "redeemed by a concurrent poll",
+			status:    deviceCodeApproved,
+			expiresIn: time.Minute,
+			wantError: "invalid_grant",
+		},
+		{
+			name:      "issued to another client",
+			clientID:  "hyperwhisper-desktop",
+			status:    deviceCodeApproved,
+			expiresIn: time.Minute,
+			redeemed:  true,
+			wantError: "invalid_grant",
+		},
+		{
+			name:      "no device code",
+			form:      withValues(form, "device_code", ""),
+			wantError: "invalid_request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, db := newFakeDB(t)
+			code := sqlc.OauthDeviceCode{
+				DeviceCodeHash: hashAPIKey("the-device-code"),
+				UserCode:       "WDJBMJHT",
+				ClientID:       "hyperwhisper-cli",
+				Status:         tt.status,
+				ExpiresAt:      time.Now().Add(tt.expiresIn),
+			}
+			if tt.clientID != "" {
+				code.ClientID = tt.clientID
+			}
+			if tt.polledAgo > 0 {
+				code.LastPolledAt = sql.NullTime{Time: time.Now().Add(-tt.polledAgo), Valid: true}
+			}
+			if code.Status == deviceCodeApproved {
+				code.UserID = uuid.NullUUID{UUID: userID, Valid: true}
+			}
+			fake.returns("PollOAuthDeviceCode", code)
+			if tt.redeemed {
+				redeemed := code
+				redeemed.Status = deviceCodeRedeemed
+				fake.returns("RedeemOAuthDeviceCode", redeemed)
+			}
+			fake.returns("GetUserByID", user)
+			fake.returns("CreateRefreshToken", sqlc.Token{ID: uuid.New(), UserID: userID})
+
+			f := form
+			if tt.form != nil {
+				f = tt.form
+			}
+			rec := postOAuthToken(t, db, f)
+			if tt.wantError != "" {
+				if rec.Code != http.StatusBadRequest || oauthErrorCode(t, rec) != tt.wantError {
+					t.Fatalf("response = %d %s, want 400 %s", rec.Code, rec.Body, tt.wantError)
+				}
+				if created := fake.called("CreateRefreshToken"); len(created) != 0 {
+					t.Errorf("session started for a refused poll: %v", created)
+				}
+				return
+			}
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			var resp OAuthTokenResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.AccessToken == "" || resp.RefreshToken == "" {
+				t.Errorf("token response = %+v, want a token pair", resp)
+			}
+			redeems := fake.called("RedeemOAuthDeviceCode")
+			if len(redeems) != 1 || redeems[0].args[0] != hashAPIKey("the-device-code") {
+				t.Errorf("RedeemOAuthDeviceCode calls = %v, want the device code's hash", redeems)
+			}
+			if created := fake.called("CreateRefreshToken"); len(created) != 1 || created[0].args[1] != userID.String() {
+				t.Errorf("CreateRefreshToken calls = %v, want a session of user %s", created, userID)
+			}
+		})
+	}
+}
+
+func TestOAuthTokenDeviceCodeGrant(t *testing.T) {
+	withOAuthConfig(t)
+	fake, db := newFakeDB(t)
+	client := testOAuthClient()
+	userID := uuid.New()
+
+	fake.returns("GetOAuthClientByClientID", client)
+	code := sqlc.OauthDeviceCode{
+		DeviceCodeHash: hashAPIKey("the-device-code"),
+		UserCode:       "WDJBMJHT",
+		ClientID:       client.ClientID,
+		Scopes:         []string{"usage:read"},
+		Status:         deviceCodeApproved,
+		UserID:         uuid.NullUUID{UUID: userID, Valid: true},
+		ExpiresAt:      time.Now().Add(time.Minute),
+	}
+	fake.returns("PollOAuthDeviceCode", code)
+	code.Status = deviceCodeRedeemed
+	fake.returns("RedeemOAuthDeviceCode", code)
+	fake.returns("GetUserByID", sqlc.User{ID: userID, Username: "ada", Email: "ada@example.com", UserType: "user"})
+	fake.returns("CreateOAuthGrant", sqlc.OauthGrant{
+		ID:        uuid.New(),
+		ClientID:  client.ID,
+		UserID:    userID,
+		Scopes:    []string{"usage:read"},
+		ExpiresAt: time.Now().Add(24 * time.Hour),
+	})
+
+	rec := postOAuthToken(t, db, url.Values{
+		"grant_type":  {deviceCodeGrantType},
+		"device_code": {"the-device-code"},
+	}, client.ClientID, "s3cret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var resp OAuthTokenResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Scope != "usage:read" {
+		t.Errorf("scope = %q, want usage:read", resp.Scope)
+	}
+	grants := fake.called("CreateOAuthGrant")
+	if len(grants) != 1 || grants[0].args[0] != client.ID.String() || grants[0].args[1] != userID.String() {
+		t.Errorf("CreateOAuthGrant calls = %v, want a grant of user %s to %s", grants, userID, client.ClientID)
+	}
+}
+
+func TestNormalizeUserCode(t *testing.T) {
+	tests := []struct {
+		raw, want string
+	}{
+		{"WDJB-MJHT", "WDJBMJHT"},
+		{"wdjb-mjht", "WDJBMJHT"},
+		{" wdjb mjht\n", "WDJBMJHT"},
+		{"WDJBMJHT", "WDJBMJHT"},
+	}
+	for _, tt := range tests {
+		if got := normalizeUserCode(tt.raw); got != tt.want {
+			t.Errorf("normalizeUserCode(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+	if got := formatUserCode("WDJBMJHT"); got != "WDJB-MJHT" {
+		t.Errorf("formatUserCode = %q, want WDJB-MJHT", got)
+	}
+}
@@ -0,0 +1,741 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/logging"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== ORGANIZATIONS ==========
+
+// Organization roles. Owners and admins manage members and keys; members
+// can view the organization's keys and usage.
+const (
+	orgRoleOwner  = "owner"
+	orgRoleAdmin  = "admin"
+	orgRoleMember = "member"
+)
+
+var orgRoles = []string{orgRoleOwner, orgRoleAdmin, orgRoleMember}
+
+// OrganizationHandler manages organizations, their members and the API
+// keys they share
+type OrganizationHandler struct {
+	db      *sql.DB
+	queries *sqlc.Queries
+}
+
+// NewOrganizationHandler creates a new organization handler
+func NewOrganizationHandler(db *sql.DB) *OrganizationHandler {
+	return &OrganizationHandler{
+		db:      db,
+		queries: sqlc.New(db),
+	}
+}
+
+// CreateOrganizationRequest is the request body for creating an organization
+type CreateOrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+// OrganizationResponse is an organization as seen by one of its members.
+// A zero MonthlyQuotaSeconds means the pool is unlimited.
+type OrganizationResponse struct {
+	ID                  string  `json:"id"`
+	Name                string  `json:"name"`
+	MonthlyQuotaSeconds int32   `json:"monthly_quota_seconds"`
+	DataRegion          *string `json:"data_region"`
+	Role                string  `json:"role,omitempty"`
+	CreatedAt           string  `json:"created_at"`
+}
+
+// OrganizationMemberRequest adds a user to an organization, or changes the
+// role of an existing member
+type OrganizationMemberRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// OrganizationMemberResponse is a member of an organization
+type OrganizationMemberResponse struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	JoinedAt string `json:"joined_at"`
+}
+
+// OrganizationUsageResponse is the organization's usage of its shared pool
+// in the current UTC month
+type OrganizationUsageResponse struct {
+	OrganizationID       string   `json:"organization_id"`
+	TotalSessions        int64    `json:"total_sessions"`
+	TotalDurationSeconds float64  `json:"total_duration_seconds"`
+	TotalBytesSent       int64    `json:"total_bytes_sent"`
+	MonthlyQuotaSeconds  int32    `json:"monthly_quota_seconds"`
+	RemainingSeconds     *float64 `json:"remaining_seconds"` // nil when unlimited
+	QuotaExceeded        bool     `json:"quota_exceeded"`
+	PeriodStart          string   `json:"period_start"`
+	PeriodEnd            string   `json:"period_end"`
+}
+
+// orgAccessFailure is why a request on an organization was refused
+type orgAccessFailure struct {
+	status  int
+	message string
+}
+
+// membership loads the caller's membership of the organization in the :id
+// path parameter. Non-members get 404 so organization IDs cannot be probed.
+// With manage set, only owners and admins are let through.
+func (h *OrganizationHandler) membership(c echo.Context, manage bool) (sqlc.OrganizationMember, *orgAccessFailure) {
+	claims := auth.GetUserFromContext(c)
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return sqlc.OrganizationMember{}, &orgAccessFailure{http.StatusBadRequest, "invalid organization ID"}
+	}
+
+	member, err := h.queries.GetOrganizationMember(context.Background(), sqlc.GetOrganizationMemberParams{
+		OrganizationID: orgID,
+		UserID:         claims.UserID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return member, &orgAccessFailure{http.StatusNotFound, "organization not found"}
+		}
+		return member, &orgAccessFailure{http.StatusInternalServerError, "database error"}
+	}
+	if manage && member.Role == orgRoleMember {
+		return member, &orgAccessFailure{http.StatusForbidden, "organization owner or admin required"}
+	}
+	return member, nil
+}
+
+// CreateOrganization creates an organization with the caller as its owner
+func (h *OrganizationHandler) CreateOrganization(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	var req CreateOrganizationRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return apiError(http.StatusBadRequest, "name is required")
+	}
+
+	ctx := context.Background()
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	defer tx.Rollback()
+	queries := h.queries.WithTx(tx)
+
+	org, err := queries.CreateOrganization(ctx, req.Name)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to create organization")
+	}
+	if _, err := queries.UpsertOrganizationMember(ctx, sqlc.UpsertOrganizationMemberParams{
+		OrganizationID: org.ID,
+		UserID:         claims.UserID,
+		Role:           orgRoleOwner,
+	}); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to create organization")
+	}
+	if err := tx.Commit(); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to create organization")
+	}
+
+	resp := toOrganizationResponse(org)
+	resp.Role = orgRoleOwner
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// ListOrganizations returns the organizations the caller belongs to
+func (h *OrganizationHandler) ListOrganizations(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	orgs, err := h.queries.ListUserOrganizations(context.Background(), claims.UserID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	responses := make([]OrganizationResponse, len(orgs))
+	for i, o := range orgs {
+		responses[i] = OrganizationResponse{
+			ID:                  o.ID.String(),
+			Name:                o.Name,
+			MonthlyQuotaSeconds: o.MonthlyQuotaSeconds,
+			Role:                o.Role,
+			CreatedAt:           o.CreatedAt.Format(time.RFC3339),
+		}
+		if o.DataRegion.Valid {
+			responses[i].DataRegion = &o.DataRegion.String
+		}
+	}
+	return c.JSON(http.StatusOK, responses)
+}
+
+// ListMembers returns the members of an organization (members only)
+func (h *OrganizationHandler) ListMembers(c echo.Context) error {
+	member, failure := h.membership(c, false)
+	if failure != nil {
+		return apiError(failure.status, failure.message)
+	}
+
+	members, err := h.queries.ListOrganizationMembers(context.Background(), member.OrganizationID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	responses := make([]OrganizationMemberResponse, len(members))
+	for i, m := range members {
+		responses[i] = OrganizationMemberResponse{
+			UserID:   m.UserID.String(),
+			Username: m.Username,
+			Email:    m.Email,
+			Role:     m.Role,
+			JoinedAt: m.CreatedAt.Format(time.RFC3339),
+		}
+	}
+	return c.JSON(http.StatusOK, responses)
+}
+
+// AddMember adds a user to an organization by email, or changes an existing
+// member's role. Only owners may grant or take away the owner role (owners
+// and admins only).
+func (h *OrganizationHandler) AddMember(c echo.Context) error {
+	caller, failure := h.membership(c, true)
+	if failure != nil {
+		return apiError(failure.status, failure.message)
+	}
+
+	var req OrganizationMemberRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.Role == "" {
+		req.Role = orgRoleMember
+	}
+	if !contains(orgRoles, req.Role) {
+		return apiError(http.StatusBadRequest, fmt.Sprintf("role must be one of %v", orgRoles))
+	}
+
+	ctx := context.Background()
+
+	user, err := h.queries.GetUserByEmail(ctx, strings.TrimSpace(req.Email))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "user not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	// Users of other tenants can't be found, nor added
+	if user.TenantID != callerTenant(c) {
+		return apiError(http.StatusNotFound, "user not found")
+	}
+
+	existing, err := h.queries.GetOrganizationMember(ctx, sqlc.GetOrganizationMemberParams{
+		OrganizationID: caller.OrganizationID,
+		UserID:         user.ID,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	changesOwner := req.Role == orgRoleOwner || (err == nil && existing.Role == orgRoleOwner)
+	if changesOwner && caller.Role != orgRoleOwner {
+		return apiError(http.StatusForbidden, "organization owner required")
+	}
+	if err == nil && existing.Role == orgRoleOwner && req.Role != orgRoleOwner {
+		if failure := h.checkNotLastOwner(ctx, caller.OrganizationID); failure != nil {
+			return apiError(failure.status, failure.message)
+		}
+	}
+
+	member, err := h.queries.UpsertOrganizationMember(ctx, sqlc.UpsertOrganizationMemberParams{
+		OrganizationID: caller.OrganizationID,
+		UserID:         user.ID,
+		Role:           req.Role,
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to add member")
+	}
+	log.Printf("[Organizations] %s set %s as %s of %s", caller.UserID, user.ID, member.Role, caller.OrganizationID)
+
+	return c.JSON(http.StatusOK, OrganizationMemberResponse{
+		UserID:   user.ID.String(),
+		Username: user.Username,
+		Email:    user.Email,
+		Role:     member.Role,
+		JoinedAt: member.CreatedAt.Format(time.RFC3339),
+	})
+}
+
+// RemoveMember removes a user from an organization. Members may remove
+// themselves; removing anyone else takes an owner or admin, and removing an
+// owner takes an owner. The last owner cannot leave.
+func (h *OrganizationHandler) RemoveMember(c echo.Context) error {
+	caller, failure := h.membership(c, false)
+	if failure != nil {
+		return apiError(failure.status, failure.message)
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	ctx := context.Background()
+
+	target, err := h.queries.GetOrganizationMember(ctx, sqlc.GetOrganizationMemberParams{
+		OrganizationID: caller.OrganizationID,
+		UserID:         userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "member not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	if userID != caller.UserID {
+		if caller.Role == orgRoleMember {
+			return apiError(http.StatusForbidden, "organization owner or admin required")
+		}
+		if target.Role == orgRoleOwner && caller.Role != orgRoleOwner {
+			return apiError(http.StatusForbidden, "organization owner required")
+		}
+	}
+	if target.Role == orgRoleOwner {
+		if failure := h.checkNotLastOwner(ctx, caller.OrganizationID); failure != nil {
+			return apiError(failure.status, failure.message)
+		}
+	}
+
+	if _, err := h.queries.RemoveOrganizationMember(ctx, sqlc.RemoveOrganizationMemberParams{
+		OrganizationID: caller.OrganizationID,
+		UserID:         userID,
+	}); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to remove member")
+	}
+	log.Printf("[Organizations] %s removed %s from %s", caller.UserID, userID, caller.OrganizationID)
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "member removed"})
+}
+
+// checkNotLastOwner refuses to leave an organization without an owner
+func (h *OrganizationHandler) checkNotLastOwner(ctx context.Context, orgID uuid.UUID) *orgAccessFailure {
+	owners, err := h.queries.CountOrganizationOwners(ctx, orgID)
+	if err != nil {
+		return &orgAccessFailure{http.StatusInternalServerError, "database error"}
+	}
+	if owners <= 1 {
+		return &orgAccessFailure{http.StatusConflict, "an organization must keep at least one owner"}
+	}
+	return nil
+}
+
+// ListKeys returns the organization's API keys (members only)
+func (h *OrganizationHandler) ListKeys(c echo.Context) error {
+	member, failure := h.membership(c, false)
+	if failure != nil {
+		return apiError(failure.status, failure.message)
+	}
+
+	page, perPage, offset := getPaginationParams(c)
+	ctx := context.Background()
+	orgID := uuid.NullUUID{UUID: member.OrganizationID, Valid: true}
+
+	total, err := h.queries.CountOrganizationAPIKeys(ctx, orgID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	keys, err := h.queries.ListOrganizationAPIKeys(ctx, sqlc.ListOrganizationAPIKeysParams{
+		OrganizationID: orgID,
+		Limit:          int32(perPage),
+		Offset:         int32(offset),
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	responses := make([]APIKeyResponse, len(keys))
+	for i, key := range keys {
+		responses[i] = toAPIKeyResponse(key)
+	}
+
+	return c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       responses,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: calculateTotalPages(total, perPage),
+	})
+}
+
+// GenerateKey creates an API key owned by the organization (owners and
+// admins only)
+func (h *OrganizationHandler) GenerateKey(c echo.Context) error {
+	member, failure := h.membership(c, true)
+	if failure != nil {
+		return apiError(failure.status, failure.message)
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.Name == "" {
+		req.Name = "Organization Key"
+	}
+
+	// Same format as personal keys: hw_live_ (or hw_test_) <32 random hex chars>
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to generate key")
+	}
+	fullKey := apiKeyPrefix(req.Sandbox) + hex.EncodeToString(randomBytes)
+
+	apiKey, err := h.queries.CreateOrganizationAPIKey(context.Background(), sqlc.CreateOrganizationAPIKeyParams{
+		UserID:         member.UserID,
+		OrganizationID: uuid.NullUUID{UUID: member.OrganizationID, Valid: true},
+		KeyHash:        hashAPIKey(fullKey),
+		KeyPrefix:      logging.KeyPrefix(fullKey),
+		Name:           req.Name,
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to create API key")
+	}
+
+	return c.JSON(http.StatusCreated, APIKeyCreatedResponse{
+		APIKeyResponse: toAPIKeyResponse(apiKey),
+		Key:            fullKey, // Only time the full key is returned
+	})
+}
+
+// RevokeKey revokes one of the organization's API keys (owners and admins
+// only)
+func (h *OrganizationHandler) RevokeKey(c echo.Context) error {
+	member, failure := h.membership(c, true)
+	if failure != nil {
+		return apiError(failure.status, failure.message)
+	}
+
+	keyID, err := uuid.Parse(c.Param("keyId"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid key ID")
+	}
+
+	revoked, err := h.queries.RevokeOrganizationAPIKey(context.Background(), sqlc.RevokeOrganizationAPIKeyParams{
+		ID:             keyID,
+		OrganizationID: uuid.NullUUID{UUID: member.OrganizationID, Valid: true},
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to revoke key")
+	}
+	if revoked == 0 {
+		return apiError(http.StatusNotFound, "API key not found")
+	}
+	log.Printf("[Organizations] %s revoked key %s of %s", member.UserID, keyID, member.OrganizationID)
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "API key revoked"})
+}
+
+// GetUsage returns the organization's usage of its shared pool this month
+// (members only)
+func (h *OrganizationHandler) GetUsage(c echo.Context) error {
+	member, failure := h.membership(c, false)
+	if failure != nil {
+		return apiError(failure.status, failure.message)
+	}
+
+	ctx := context.Background()
+	org, err := h.queries.GetOrganizationByID(ctx, member.OrganizationID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	usage, err := organizationUsage(ctx, h.queries, org)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	return c.JSON(http.StatusOK, usage)
+}
+
+// organizationUsage sums the sessions on an organization's keys in the
+// current UTC month. Like the upstream budget, only finished sessions count,
+// so sessions in progress can overshoot the quota slightly.
+func organizationUsage(ctx context.Context, queries *sqlc.Queries, org sqlc.Organization) (OrganizationUsageResponse, error) {
+	period := monthPeriod(time.Now().UTC(), time.UTC)
+
+	summary, err := queries.GetOrganizationUsage(ctx, sqlc.GetOrganizationUsageParams{
+		OrganizationID: uuid.NullUUID{UUID: org.ID, Valid: true},
+		StartDate:      period.Start,
+		EndDate:        period.End,
+	})
+	if err != nil {
+		return OrganizationUsageResponse{}, err
+	}
+
+	used := parseDecimalString(summary.TotalDurationSeconds)
+	resp := OrganizationUsageResponse{
+		OrganizationID:       org.ID.String(),
+		TotalSessions:        summary.TotalSessions,
+		TotalDurationSeconds: used,
+		TotalBytesSent:       parseBytesSent(summary.TotalBytesSent),
+		MonthlyQuotaSeconds:  org.MonthlyQuotaSeconds,
+		PeriodStart:          period.Start.Format(time.RFC3339),
+		PeriodEnd:            period.End.Format(time.RFC3339),
+	}
+	if org.MonthlyQuotaSeconds > 0 {
+		remaining := float64(org.MonthlyQuotaSeconds) - used
+		resp.QuotaExceeded = remaining <= 0
+		resp.RemainingSeconds = &remaining
+	}
+	return resp, nil
+}
+
+// checkOrganizationQuota refuses sessions on an organization's keys once
+// its shared pool for the month is used up. It returns the status and error
+// to reject the session with, or nil when the session may start.
+func checkOrganizationQuota(ctx context.Context, queries *sqlc.Queries, orgID uuid.UUID, logTag string) (int, *ErrorResponse) {
+	org, err := queries.GetOrganizationByID(ctx, orgID)
+	if err == nil && org.MonthlyQuotaSeconds == 0 {
+		return 0, nil
+	}
+	var usage OrganizationUsageResponse
+	if err == nil {
+		usage, err = organizationUsage(ctx, queries, org)
+	}
+	if err != nil {
+		log.Printf("[%s] Failed to check organization quota: %v", logTag, err)
+		return http.StatusInternalServerError, &ErrorResponse{Error: "database error"}
+	}
+
+	if usage.QuotaExceeded {
+		log.Printf("[%s] Organization %s quota exceeded (%.0f of %d seconds)", logTag, orgID, usage.TotalDurationSeconds, usage.MonthlyQuotaSeconds)
+		return http.StatusForbidden, &ErrorResponse{
+			Error: "organization quota exceeded",
+			Details: map[string]string{
+				"organization_id": orgID.String(),
+				"resets_at":       usage.PeriodEnd,
+			},
+		}
+	}
+	return 0, nil
+}
+
+func toOrganizationResponse(org sqlc.Organization) OrganizationResponse {
+	resp := OrganizationResponse{
+		ID:                  org.ID.String(),
+		Name:                org.Name,
+		MonthlyQuotaSeconds: org.MonthlyQuotaSeconds,
+		CreatedAt:           org.CreatedAt.Format(time.RFC3339),
+	}
+	if org.DataRegion.Valid {
+		resp.DataRegion = &org.DataRegion.String
+	}
+	return resp
+}
+
+// ========== ADMIN ==========
+
+// OrganizationQuotaRequest sets an organization's monthly pool
+type OrganizationQuotaRequest struct {
+	MonthlyQuotaSeconds int32  `json:"monthly_quota_seconds"`
+	Reason              string `json:"reason"`
+}
+
+// OrganizationDataRegionRequest binds the sessions on an organization's keys
+// to a data region; an empty data_region lifts the requirement
+type OrganizationDataRegionRequest struct {
+	DataRegion string `json:"data_region"`
+	Reason     string `json:"reason"`
+}
+
+// ListOrganizations returns all organizations (admin only)
+func (h *AdminHandler) ListOrganizations(c echo.Context) error {
+	page, perPage, offset := getPaginationParams(c)
+	ctx := context.Background()
+
+	total, err := h.queries.CountOrganizations(ctx)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	orgs, err := h.queries.ListOrganizations(ctx, sqlc.ListOrganizationsParams{
+		Limit:  int32(perPage),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	responses := make([]OrganizationResponse, len(orgs))
+	for i, org := range orgs {
+		responses[i] = toOrganizationResponse(org)
+	}
+
+	return c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       responses,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: calculateTotalPages(total, perPage),
+	})
+}
+
+// SetOrganizationQuota sets the monthly seconds shared by an organization's
+// keys; 0 removes the limit (admin only)
+func (h *AdminHandler) SetOrganizationQuota(c echo.Context) error {
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid organization ID")
+	}
+
+	var req OrganizationQuotaRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.MonthlyQuotaSeconds < 0 {
+		return apiError(http.StatusBadRequest, "monthly_quota_seconds must not be negative")
+	}
+
+	ctx := context.Background()
+
+	before, err := h.queries.GetOrganizationByID(ctx, orgID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "organization not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	org, err := h.queries.UpdateOrganizationQuota(ctx, sqlc.UpdateOrganizationQuotaParams{
+		ID:                  orgID,
+		MonthlyQuotaSeconds: req.MonthlyQuotaSeconds,
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to update quota")
+	}
+
+	recordAuditEvent(ctx, h.queries, c, auditOrgQuota, "organization", orgID.String(), strings.TrimSpace(req.Reason), map[string]string{
+		"from_seconds": strconv.Itoa(int(before.MonthlyQuotaSeconds)),
+		"to_seconds":   strconv.Itoa(int(org.MonthlyQuotaSeconds)),
+	})
+
+	return c.JSON(http.StatusOK, toOrganizationResponse(org))
+}
+
+// SetOrganizationDataRegion sets the data region the sessions on an
+// organization's keys must stay in, which overrides the tenant's (admin
+// only)
+func (h *AdminHandler) SetOrganizationDataRegion(c echo.Context) error {
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid organization ID")
+	}
+
+	var req OrganizationDataRegionRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	region, err := parseDataRegion(req.DataRegion)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	before, err := h.queries.GetOrganizationByID(ctx, orgID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "organization not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	org, err := h.queries.UpdateOrganizationDataRegion(ctx, sqlc.UpdateOrganizationDataRegionParams{
+		ID:         orgID,
+		DataRegion: region,
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to update data region")
+	}
+
+	recordAuditEvent(ctx, h.queries, c, auditOrgDataRegion, "organization", orgID.String(), strings.TrimSpace(req.Reason), map[string]string{
+		"from": before.DataRegion.String,
+		"to":   org.DataRegion.String,
+	})
+
+	return c.JSON(http.StatusOK, toOrganizationResponse(org))
+}
+
+// transferAPIKeyToOrganization moves an API key into an organization. The
+// key keeps its creator and usage history; sessions from now on draw from
+// the organization's pool.
+func (h *AdminHandler) transferAPIKeyToOrganization(c echo.Context, keyID uuid.UUID, req APIKeyTransferRequest) error {
+	orgID, err := uuid.Parse(req.OrganizationID)
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid organization ID")
+	}
+
+	ctx := context.Background()
+
+	key, err := h.queries.GetAPIKeyByID(ctx, keyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "API key not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if key.OrganizationID.Valid && key.OrganizationID.UUID == orgID {
+		return apiError(http.StatusBadRequest, "API key already belongs to this organization")
+	}
+	if _, err := h.queries.GetOrganizationByID(ctx, orgID); err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "organization not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	if _, err := h.queries.TransferAPIKeyToOrganization(ctx, sqlc.TransferAPIKeyToOrganizationParams{
+		ID:             keyID,
+		OrganizationID: uuid.NullUUID{UUID: orgID, Valid: true},
+	}); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to transfer key")
+	}
+	log.Printf("[Admin] Transferred API key %s (%s) to organization %s", keyID, logging.StoredKeyID(key.KeyPrefix, key.KeyHash), orgID)
+
+	metadata := map[string]string{
+		"to_organization_id": orgID.String(),
+		"key_prefix":         key.KeyPrefix,
+	}
+	if key.OrganizationID.Valid {
+		metadata["from_organization_id"] = key.OrganizationID.UUID.String()
+	} else {
+		metadata["from_user_id"] = key.UserID.String()
+	}
+	recordAuditEvent(ctx, h.queries, c, auditAPIKeyTransfer, "api_key", keyID.String(), strings.TrimSpace(req.Reason), metadata)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":         "API key transferred",
+		"organization_id": orgID.String(),
+	})
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== PASSWORD CHANGE ==========
+
+// ChangePasswordRequest sets a new password for the signed-in user
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// ChangePassword replaces the caller's password after checking the current
+// one. Every token of the user is revoked, signing their other devices out,
+// and the caller gets a new token pair so this session carries on.
+func (h *AuthHandler) ChangePassword(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	var req ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		return apiError(http.StatusBadRequest, "current_password and new_password are required")
+	}
+
+	if err := auth.ValidatePassword(req.NewPassword); err != nil {
+		return newAPIError(http.StatusBadRequest, ErrorResponse{
+			Error:   "password validation failed",
+			Details: map[string]string{"new_password": err.Error()},
+		})
+	}
+
+	ctx := context.Background()
+
+	user, err := h.queries.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "user not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	// 403 rather than 401, which clients take as an expired access token
+	if err := auth.CheckPassword(req.CurrentPassword, user.PasswordHash); err != nil {
+		log.Printf("[Auth] Password change of user %s refused: wrong current password", user.ID)
+		return apiError(http.StatusForbidden, "current password is incorrect")
+	}
+	if auth.CheckPassword(req.NewPassword, user.PasswordHash) == nil {
+		return apiError(http.StatusBadRequest, "new password must differ from the current one")
+	}
+	reused, err := passwordReused(ctx, h.queries, user.ID, user.PasswordHash, req.NewPassword)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if reused {
+		return apiError(http.StatusBadRequest, "password was used recently")
+	}
+
+	passwordHash, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to process password")
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	defer tx.Rollback()
+	queries := h.queries.WithTx(tx)
+
+	if err := queries.UpdateUserPassword(ctx, sqlc.UpdateUserPasswordParams{
+		ID:           user.ID,
+		PasswordHash: passwordHash,
+	}); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to update password")
+	}
+	if err := recordPassword(ctx, queries, user.ID, passwordHash); err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	// Reset links sent before the change would undo it
+	if err := queries.InvalidatePasswordResetTokens(ctx, user.ID); err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if err := queries.RevokeUserRefreshTokens(ctx, sqlc.RevokeUserRefreshTokensParams{
+		UserID:        user.ID,
+		RevokedReason: sql.NullString{String: "password_changed", Valid: true},
+	}); err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to update password")
+	}
+
+	// The token pair issued below is not covered
+	denyUserAccessTokens(ctx, h.queries, user.ID)
+	log.Printf("[Auth] Password of user %s changed", user.ID)
+
+	tokens, err := auth.GenerateTokenPair(user.ID, user.Username, user.Email, user.UserType, user.TenantID.UUID)
+	if err != nil {
+		clearAuthCookies(c)
+		return apiError(http.StatusInternalServerError, "failed to generate tokens")
+	}
+	if err := h.storeRefreshToken(c, ctx, user.ID, uuid.New(), tokens); err != nil {
+		log.Printf("[Auth] Failed to store refresh token of user %s: %v", user.ID, err)
+		clearAuthCookies(c)
+		return apiError(http.StatusInternalServerError, "failed to create session")
+	}
+	setAuthCookies(c, tokens)
+
+	return c.JSON(http.StatusOK, AuthResponse{
+		User:        toUserResponse(user),
+		AccessToken: tokens.AccessToken,
+		ExpiresIn:   tokens.ExpiresIn,
+	})
+}
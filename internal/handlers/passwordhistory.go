@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+// ========== PASSWORD HISTORY ==========
+
+// passwordReused reports whether password is one of the user's last
+// PASSWORD_HISTORY_COUNT passwords. The current hash is checked as well,
+// since accounts older than the history have none recorded.
+func passwordReused(ctx context.Context, queries *sqlc.Queries, userID uuid.UUID, currentHash, password string) (bool, error) {
+	count := config.Int("PASSWORD_HISTORY_COUNT")
+	if count <= 0 {
+		return false, nil
+	}
+	if auth.CheckPassword(password, currentHash) == nil {
+		return true, nil
+	}
+
+	hashes, err := queries.ListPasswordHistory(ctx, sqlc.ListPasswordHistoryParams{
+		UserID: userID,
+		Limit:  int32(count),
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, hash := range hashes {
+		if auth.CheckPassword(password, hash) == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// recordPassword adds a password the user set to their history, dropping
+// hashes past PASSWORD_HISTORY_COUNT
+func recordPassword(ctx context.Context, queries *sqlc.Queries, userID uuid.UUID, passwordHash string) error {
+	count := config.Int("PASSWORD_HISTORY_COUNT")
+	if count <= 0 {
+		return nil
+	}
+	if err := queries.AddPasswordHistory(ctx, sqlc.AddPasswordHistoryParams{
+		UserID:       userID,
+		PasswordHash: passwordHash,
+	}); err != nil {
+		return err
+	}
+	return queries.PrunePasswordHistory(ctx, sqlc.PrunePasswordHistoryParams{
+		UserID: userID,
+		Keep:   int32(count),
+	})
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/i18n"
+	"hyperwhisper/internal/mail"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== PASSWORD RESET ==========
+
+// ForgotPasswordRequest asks for a reset link to be emailed
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest sets a new password with the token from a reset link
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// ForgotPassword emails a single-use reset link valid for
+// PASSWORD_RESET_TTL. It answers the same whether or not the email belongs
+// to an account, so it cannot be used to find out who has one.
+func (h *AuthHandler) ForgotPassword(c echo.Context) error {
+	var req ForgotPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if email == "" {
+		return apiError(http.StatusBadRequest, "email is required")
+	}
+
+	accepted := map[string]string{"message": "if the email belongs to an account, a reset link has been sent to it"}
+	ctx := context.Background()
+
+	user, err := h.queries.GetUserByEmail(ctx, email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusAccepted, accepted)
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if !userOfRequestTenant(c, user) {
+		return c.JSON(http.StatusAccepted, accepted)
+	}
+
+	// Keep the endpoint from being used to flood someone's inbox
+	recent, err := h.queries.CountRecentPasswordResetTokens(ctx, sqlc.CountRecentPasswordResetTokensParams{
+		UserID:    user.ID,
+		CreatedAt: time.Now().Add(-time.Hour),
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if recent >= int64(config.Int("PASSWORD_RESET_MAX_PER_HOUR")) {
+		log.Printf("[Auth] Password reset for user %s skipped: hourly limit reached", user.ID)
+		return c.JSON(http.StatusAccepted, accepted)
+	}
+
+	token, err := newRandomToken()
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to generate reset link")
+	}
+
+	// Only the newest link works
+	if err := h.queries.InvalidatePasswordResetTokens(ctx, user.ID); err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	ttl := config.Duration("PASSWORD_RESET_TTL")
+	if _, err := h.queries.CreatePasswordResetToken(ctx, sqlc.CreatePasswordResetTokenParams{
+		UserID:    user.ID,
+		TokenHash: hashAPIKey(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	// Sent in the background so the response time does not tell whether
+	// the account exists
+	lang := localeOr(user, requestLanguage(c))
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: i18n.Translate(lang, i18n.PasswordResetSubject),
+		Body:    fmt.Sprintf(i18n.Translate(lang, i18n.PasswordResetBody), user.Username, ttl, getPasswordResetURL(appBaseURL(ctx, h.queries, user.TenantID), token)),
+	}
+	go func() {
+		if err := h.mailer.Send(context.Background(), msg); err != nil {
+			log.Printf("[Auth] Failed to send password reset email to user %s: %v", user.ID, err)
+		}
+	}()
+
+	log.Printf("[Auth] Password reset link issued to user %s", user.ID)
+	return c.JSON(http.StatusAccepted, accepted)
+}
+
+// ResetPassword sets a new password with a reset link's token. The token is
+// used up, and the user's other reset links and tokens are revoked, signing
+// every session out.
+func (h *AuthHandler) ResetPassword(c echo.Context) error {
+	var req ResetPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	token := strings.TrimSpace(req.Token)
+	if token == "" || req.Password == "" {
+		return apiError(http.StatusBadRequest, "token and password are required")
+	}
+
+	if err := auth.ValidatePassword(req.Password); err != nil {
+		return newAPIError(http.StatusBadRequest, ErrorResponse{
+			Error:   "password validation failed",
+			Details: map[string]string{"password": err.Error()},
+		})
+	}
+
+	passwordHash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to process password")
+	}
+
+	ctx := context.Background()
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	defer tx.Rollback()
+	queries := h.queries.WithTx(tx)
+
+	reset, err := queries.ConsumePasswordResetToken(ctx, hashAPIKey(token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusBadRequest, "invalid or expired reset link")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	// Refusing rolls back, so the link can be used again with another
+	// password
+	user, err := queries.GetUserByID(ctx, reset.UserID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	reused, err := passwordReused(ctx, queries, user.ID, user.PasswordHash, req.Password)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if reused {
+		return apiError(http.StatusBadRequest, "password was used recently")
+	}
+
+	if err := queries.UpdateUserPassword(ctx, sqlc.UpdateUserPasswordParams{
+		ID:           reset.UserID,
+		PasswordHash: passwordHash,
+	}); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to update password")
+	}
+	if err := recordPassword(ctx, queries, reset.UserID, passwordHash); err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if err := queries.InvalidatePasswordResetTokens(ctx, reset.UserID); err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if err := queries.RevokeUserRefreshTokens(ctx, sqlc.RevokeUserRefreshTokensParams{
+		UserID:        reset.UserID,
+		RevokedReason: sql.NullString{String: "password_reset", Valid: true},
+	}); err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to update password")
+	}
+
+	denyUserAccessTokens(ctx, h.queries, reset.UserID)
+	log.Printf("[Auth] Password of user %s reset", reset.UserID)
+	return c.JSON(http.StatusOK, map[string]string{"message": "password has been reset"})
+}
+
+// newRandomToken generates a random 256-bit secret, hex-encoded
+func newRandomToken() (string, error) {
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(randomBytes), nil
+}
+
+// getPasswordResetURL returns the dashboard page a reset link opens, on
+// the user's appBaseURL
+func getPasswordResetURL(baseURL, token string) string {
+	return baseURL + "/reset-password?token=" + url.QueryEscape(token)
+}
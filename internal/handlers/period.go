@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== USAGE PERIODS ==========
+
+// Period selectors accepted by the usage summary endpoints
+const (
+	periodMonth        = "month"
+	periodCurrentCycle = "current_cycle"
+)
+
+// usagePeriod is the reporting window of a usage summary
+type usagePeriod struct {
+	Kind     string
+	Start    time.Time
+	End      time.Time
+	Timezone string
+}
+
+var (
+	errInvalidTimezone = errors.New("invalid timezone")
+	errInvalidPeriod   = errors.New("invalid period, expected month or current_cycle")
+	errNoBillingCycle  = errors.New("period current_cycle requires a single user")
+)
+
+// monthPeriod returns the calendar month containing now, with its
+// boundaries at local midnight in loc
+func monthPeriod(now time.Time, loc *time.Location) usagePeriod {
+	local := now.In(loc)
+	start := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, loc)
+	return usagePeriod{
+		Kind:     periodMonth,
+		Start:    start,
+		End:      start.AddDate(0, 1, 0),
+		Timezone: loc.String(),
+	}
+}
+
+// cyclePeriod returns the billing cycle containing now. Cycles renew on the
+// monthly anniversary of anchor as seen in loc; anchors on the 29th-31st
+// renew on the last day of shorter months.
+func cyclePeriod(anchor, now time.Time, loc *time.Location) usagePeriod {
+	anchor = anchor.In(loc)
+	local := now.In(loc)
+
+	months := (local.Year()-anchor.Year())*12 + int(local.Month()-anchor.Month())
+	start := addMonthsClamped(anchor, months)
+	if start.After(local) {
+		months--
+		start = addMonthsClamped(anchor, months)
+	}
+
+	return usagePeriod{
+		Kind:     periodCurrentCycle,
+		Start:    start,
+		End:      addMonthsClamped(anchor, months+1),
+		Timezone: loc.String(),
+	}
+}
+
+// addMonthsClamped adds months to t, clamping the day to the end of the
+// resulting month instead of overflowing into the next one
+func addMonthsClamped(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	day := t.Day()
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// billingCycleAnchor returns when the user's billing cycle started: the
+// explicit anchor if one was set, otherwise the signup time
+func billingCycleAnchor(user sqlc.User) time.Time {
+	if user.BillingCycleAnchor.Valid {
+		return user.BillingCycleAnchor.Time
+	}
+	return user.CreatedAt.Time
+}
+
+// resolveUsagePeriod picks the window for a summary request. The period
+// query param selects the calendar month (default) or, when cycleAnchor is
+// set, the current billing cycle. Either is computed in the tz query param,
+// falling back to defaultTZ and then UTC; explicit RFC 3339 start/end params
+// override either boundary.
+func resolveUsagePeriod(c echo.Context, defaultTZ string, cycleAnchor time.Time) (usagePeriod, error) {
+	tz := c.QueryParam("tz")
+	if tz == "" {
+		tz = defaultTZ
+	}
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return usagePeriod{}, errInvalidTimezone
+	}
+
+	var period usagePeriod
+	switch c.QueryParam("period") {
+	case "", periodMonth:
+		period = monthPeriod(time.Now(), loc)
+	case periodCurrentCycle:
+		if cycleAnchor.IsZero() {
+			return usagePeriod{}, errNoBillingCycle
+		}
+		period = cyclePeriod(cycleAnchor, time.Now(), loc)
+	default:
+		return usagePeriod{}, errInvalidPeriod
+	}
+
+	if startParam := c.QueryParam("start"); startParam != "" {
+		if t, err := time.Parse(time.RFC3339, startParam); err == nil {
+			period.Start = t
+		}
+	}
+	if endParam := c.QueryParam("end"); endParam != "" {
+		if t, err := time.Parse(time.RFC3339, endParam); err == nil {
+			period.End = t
+		}
+	}
+	return period, nil
+}
+
+// validTimezone reports whether tz is an IANA time zone name
+func validTimezone(tz string) bool {
+	if tz == "" || tz == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(tz)
+	return err == nil
+}
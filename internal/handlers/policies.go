@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== SESSION POLICIES ==========
+
+// Policy scopes, from least to most specific
+var policyScopes = []string{"all", "trial", "user_type", "user", "api_key"}
+
+// sessionTarget identifies who a proxy session belongs to when matching
+// session policies. Trial and dashboard sessions leave unknown IDs as uuid.Nil.
+type sessionTarget struct {
+	trial    bool
+	userID   uuid.UUID
+	apiKeyID uuid.UUID
+}
+
+// sessionPolicy is what the session policies matching a session enforce
+type sessionPolicy struct {
+	params     map[string]string // Enforced Deepgram params
+	names      []string          // Matching policies, broadest first
+	redactedBy []string          // Matching policies that enforce redactions
+}
+
+// resolveSessionPolicy merges the params of every enabled policy matching
+// target. More specific policies override broader ones, except for redact
+// whose values accumulate so a policy can never remove a redaction.
+func resolveSessionPolicy(ctx context.Context, queries *sqlc.Queries, target sessionTarget) (sessionPolicy, error) {
+	policies, err := queries.ListMatchingSessionPolicies(ctx, sqlc.ListMatchingSessionPoliciesParams{
+		IsTrial:  target.trial,
+		UserID:   target.userID,
+		ApiKeyID: target.apiKeyID,
+	})
+	if err != nil {
+		return sessionPolicy{}, err
+	}
+
+	resolved := sessionPolicy{params: make(map[string]string)}
+	for _, p := range policies {
+		var params map[string]string
+		if err := json.Unmarshal(p.Params, &params); err != nil {
+			return sessionPolicy{}, fmt.Errorf("policy %s has invalid params: %w", p.ID, err)
+		}
+		for k, v := range params {
+			if k == "redact" {
+				resolved.params[k] = mergeRedact(resolved.params[k], v)
+				resolved.redactedBy = append(resolved.redactedBy, p.Name)
+			} else {
+				resolved.params[k] = v
+			}
+		}
+		resolved.names = append(resolved.names, p.Name)
+	}
+	return resolved, nil
+}
+
+// mergeRedact combines comma-separated redact values without duplicates
+func mergeRedact(values ...string) string {
+	seen := make(map[string]bool)
+	var merged []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part != "" && !seen[part] {
+				seen[part] = true
+				merged = append(merged, part)
+			}
+		}
+	}
+	return strings.Join(merged, ",")
+}
+
+// enforceSessionPolicy resolves the policies for target and logs which ones
+// apply. Callers must fail the session on error: compliance policies must
+// never be skipped.
+func enforceSessionPolicy(ctx context.Context, queries *sqlc.Queries, target sessionTarget, logTag string) (sessionPolicy, error) {
+	policy, err := resolveSessionPolicy(ctx, queries, target)
+	if err != nil {
+		log.Printf("[%s] Failed to resolve session policies: %v", logTag, err)
+		return sessionPolicy{}, err
+	}
+	if len(policy.names) > 0 {
+		log.Printf("[%s] Enforcing session policies %v: %v", logTag, policy.names, policy.params)
+	}
+	return policy, nil
+}
+
+// SessionPolicyRequest is the request for creating or updating a policy
+type SessionPolicyRequest struct {
+	Name       string            `json:"name"`
+	Scope      string            `json:"scope"`
+	ScopeValue string            `json:"scope_value"`
+	Params     map[string]string `json:"params"`
+	Enabled    *bool             `json:"enabled"`
+}
+
+// SessionPolicyResponse is the response for session policy admin queries
+type SessionPolicyResponse struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Scope      string            `json:"scope"`
+	ScopeValue *string           `json:"scope_value"`
+	Params     map[string]string `json:"params"`
+	Enabled    bool              `json:"enabled"`
+	CreatedAt  string            `json:"created_at"`
+	UpdatedAt  string            `json:"updated_at"`
+}
+
+// validate checks the request and returns a user-facing error message
+func (r *SessionPolicyRequest) validate() string {
+	if strings.TrimSpace(r.Name) == "" {
+		return "name is required"
+	}
+
+	validScope := false
+	for _, s := range policyScopes {
+		if r.Scope == s {
+			validScope = true
+			break
+		}
+	}
+	if !validScope {
+		return fmt.Sprintf("scope must be one of %v", policyScopes)
+	}
+
+	switch r.Scope {
+	case "all", "trial":
+		if r.ScopeValue != "" {
+			return fmt.Sprintf("scope_value must be empty for scope %q", r.Scope)
+		}
+	case "user_type":
+		if !auth.IsRole(r.ScopeValue) {
+			return "scope_value must be 'user', 'support' or 'admin' for scope \"user_type\""
+		}
+	case "user", "api_key":
+		if _, err := uuid.Parse(r.ScopeValue); err != nil {
+			return fmt.Sprintf("scope_value must be a UUID for scope %q", r.Scope)
+		}
+	}
+
+	if len(r.Params) == 0 {
+		return "params must not be empty"
+	}
+	for k, v := range r.Params {
+		if !isAllowedDeepgramParam(k) {
+			return fmt.Sprintf("unsupported Deepgram parameter %q", k)
+		}
+		if strings.TrimSpace(v) == "" {
+			return fmt.Sprintf("params.%s must not be empty", k)
+		}
+	}
+	return ""
+}
+
+// ListSessionPolicies returns all session policies (admin only)
+func (h *AdminHandler) ListSessionPolicies(c echo.Context) error {
+	ctx := context.Background()
+
+	policies, err := h.queries.ListSessionPolicies(ctx)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	response := make([]SessionPolicyResponse, len(policies))
+	for i, p := range policies {
+		response[i] = toSessionPolicyResponse(p)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// CreateSessionPolicy creates a session policy (admin only)
+func (h *AdminHandler) CreateSessionPolicy(c echo.Context) error {
+	var req SessionPolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if msg := req.validate(); msg != "" {
+		return apiError(http.StatusBadRequest, msg)
+	}
+
+	params, _ := json.Marshal(req.Params)
+	enabled := req.Enabled == nil || *req.Enabled
+
+	ctx := context.Background()
+
+	policy, err := h.queries.CreateSessionPolicy(ctx, sqlc.CreateSessionPolicyParams{
+		Name:       strings.TrimSpace(req.Name),
+		Scope:      req.Scope,
+		ScopeValue: sql.NullString{String: req.ScopeValue, Valid: req.ScopeValue != ""},
+		Params:     params,
+		Enabled:    enabled,
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to create policy")
+	}
+
+	return c.JSON(http.StatusCreated, toSessionPolicyResponse(policy))
+}
+
+// UpdateSessionPolicy replaces a session policy (admin only)
+func (h *AdminHandler) UpdateSessionPolicy(c echo.Context) error {
+	policyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid policy ID")
+	}
+
+	var req SessionPolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if msg := req.validate(); msg != "" {
+		return apiError(http.StatusBadRequest, msg)
+	}
+
+	params, _ := json.Marshal(req.Params)
+	enabled := req.Enabled == nil || *req.Enabled
+
+	ctx := context.Background()
+
+	policy, err := h.queries.UpdateSessionPolicy(ctx, sqlc.UpdateSessionPolicyParams{
+		ID:         policyID,
+		Name:       strings.TrimSpace(req.Name),
+		Scope:      req.Scope,
+		ScopeValue: sql.NullString{String: req.ScopeValue, Valid: req.ScopeValue != ""},
+		Params:     params,
+		Enabled:    enabled,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "policy not found")
+		}
+		return apiError(http.StatusInternalServerError, "failed to update policy")
+	}
+
+	return c.JSON(http.StatusOK, toSessionPolicyResponse(policy))
+}
+
+// DeleteSessionPolicy deletes a session policy (admin only)
+func (h *AdminHandler) DeleteSessionPolicy(c echo.Context) error {
+	policyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid policy ID")
+	}
+
+	ctx := context.Background()
+
+	// Check if policy exists
+	_, err = h.queries.GetSessionPolicy(ctx, policyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "policy not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	if err := h.queries.DeleteSessionPolicy(ctx, policyID); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to delete policy")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "policy deleted"})
+}
+
+// Helper function for session policy response
+func toSessionPolicyResponse(p sqlc.SessionPolicy) SessionPolicyResponse {
+	resp := SessionPolicyResponse{
+		ID:        p.ID.String(),
+		Name:      p.Name,
+		Scope:     p.Scope,
+		Params:    map[string]string{},
+		Enabled:   p.Enabled,
+		CreatedAt: p.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: p.UpdatedAt.Format(time.RFC3339),
+	}
+	if p.ScopeValue.Valid {
+		resp.ScopeValue = &p.ScopeValue.String
+	}
+	_ = json.Unmarshal(p.Params, &resp.Params)
+	return resp
+}
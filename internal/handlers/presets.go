@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+)
+
+// ========== TRIAL PRESETS ==========
+
+// presetNamePattern is the slug format of preset names, which appear in
+// campaign codes
+var presetNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// TrialPresetRequest is the request for creating or updating a trial preset.
+// Name is ignored on update.
+type TrialPresetRequest struct {
+	Name                      string `json:"name"`
+	Description               string `json:"description"`
+	MaxDurationSeconds        int    `json:"max_duration_seconds"`
+	MaxSessions               int    `json:"max_sessions"`
+	MaxSessionDurationSeconds int    `json:"max_session_duration_seconds"`
+	ExpiryDays                int    `json:"expiry_days"`
+
+	// ParamRestrictions applies on create only; new presets copy the
+	// default preset's restrictions when omitted
+	ParamRestrictions *ParamRestrictions `json:"param_restrictions,omitempty"`
+}
+
+// TrialPresetUsageResponse is one preset's row in the preset usage report
+type TrialPresetUsageResponse struct {
+	Name                 string  `json:"name"`
+	TotalTrialKeys       int64   `json:"total_trial_keys"`
+	ActiveTrialKeys      int64   `json:"active_trial_keys"`
+	TotalSessions        int64   `json:"total_sessions"`
+	TotalDurationSeconds float64 `json:"total_duration_seconds"`
+}
+
+// CampaignCodeRequest is the request for signing a campaign code
+type CampaignCodeRequest struct {
+	ExpiresInDays int `json:"expires_in_days"`
+}
+
+// CampaignCodeResponse is a signed campaign code for a preset
+type CampaignCodeResponse struct {
+	Preset    string `json:"preset"`
+	Code      string `json:"code"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// validate checks the preset limits and returns an error message if any is
+// out of range
+func (r TrialPresetRequest) validate() string {
+	switch {
+	case r.MaxDurationSeconds <= 0:
+		return "max_duration_seconds must be positive"
+	case r.MaxSessions <= 0:
+		return "max_sessions must be positive"
+	case r.MaxSessionDurationSeconds <= 0:
+		return "max_session_duration_seconds must be positive"
+	case r.ExpiryDays <= 0:
+		return "expiry_days must be positive"
+	}
+	return ""
+}
+
+// ListTrialPresets returns all trial presets (admin only)
+func (h *AdminHandler) ListTrialPresets(c echo.Context) error {
+	presets, err := h.queries.ListTrialPresets(context.Background())
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	resp := make([]TrialLimitsResponse, len(presets))
+	for i, p := range presets {
+		resp[i] = toTrialLimitsResponse(p)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// CreateTrialPreset adds a named trial preset (admin only)
+func (h *AdminHandler) CreateTrialPreset(c echo.Context) error {
+	var req TrialPresetRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if !presetNamePattern.MatchString(req.Name) {
+		return newAPIError(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid preset name",
+			Details: map[string]string{"name": "lowercase letters, digits and dashes, at most 64 characters"},
+		})
+	}
+	if msg := req.validate(); msg != "" {
+		return apiError(http.StatusBadRequest, msg)
+	}
+
+	ctx := context.Background()
+
+	var restrictions json.RawMessage
+	if req.ParamRestrictions != nil {
+		if msg := req.ParamRestrictions.validate(); msg != "" {
+			return apiError(http.StatusBadRequest, msg)
+		}
+		restrictions, _ = json.Marshal(req.ParamRestrictions)
+	} else {
+		def, err := h.queries.GetTrialPreset(ctx, defaultTrialPreset)
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+		restrictions = def.ParamRestrictions
+	}
+
+	if _, err := h.queries.GetTrialPreset(ctx, req.Name); err == nil {
+		return apiError(http.StatusConflict, "preset already exists")
+	} else if err != sql.ErrNoRows {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	preset, err := h.queries.CreateTrialPreset(ctx, sqlc.CreateTrialPresetParams{
+		Name:                      req.Name,
+		Description:               req.Description,
+		MaxDurationSeconds:        int32(req.MaxDurationSeconds),
+		MaxSessions:               int32(req.MaxSessions),
+		MaxSessionDurationSeconds: int32(req.MaxSessionDurationSeconds),
+		ExpiryDays:                int32(req.ExpiryDays),
+		ParamRestrictions:         restrictions,
+	})
+	if err != nil {
+		log.Printf("[Admin] Failed to create trial preset %s: %v", req.Name, err)
+		return apiError(http.StatusInternalServerError, "failed to create preset")
+	}
+
+	log.Printf("[Admin] Created trial preset %s", preset.Name)
+	return c.JSON(http.StatusCreated, toTrialLimitsResponse(preset))
+}
+
+// UpdateTrialPreset changes a preset's description and limits (admin only).
+// Existing keys on the preset pick up the new limits immediately.
+func (h *AdminHandler) UpdateTrialPreset(c echo.Context) error {
+	var req TrialPresetRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if msg := req.validate(); msg != "" {
+		return apiError(http.StatusBadRequest, msg)
+	}
+
+	preset, err := h.queries.UpdateTrialPreset(context.Background(), sqlc.UpdateTrialPresetParams{
+		Name:                      c.Param("name"),
+		Description:               req.Description,
+		MaxDurationSeconds:        int32(req.MaxDurationSeconds),
+		MaxSessions:               int32(req.MaxSessions),
+		MaxSessionDurationSeconds: int32(req.MaxSessionDurationSeconds),
+		ExpiryDays:                int32(req.ExpiryDays),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "preset not found")
+		}
+		return apiError(http.StatusInternalServerError, "failed to update preset")
+	}
+
+	return c.JSON(http.StatusOK, toTrialLimitsResponse(preset))
+}
+
+// UpdateTrialPresetRestrictions sets a preset's parameter restrictions
+// (admin only)
+func (h *AdminHandler) UpdateTrialPresetRestrictions(c echo.Context) error {
+	var req UpdateParamRestrictionsRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.ParamRestrictions == nil {
+		req.ParamRestrictions = ParamRestrictions{}
+	}
+	if msg := req.ParamRestrictions.validate(); msg != "" {
+		return apiError(http.StatusBadRequest, msg)
+	}
+
+	restrictions, _ := json.Marshal(req.ParamRestrictions)
+
+	preset, err := h.queries.UpdateTrialPresetParamRestrictions(context.Background(), sqlc.UpdateTrialPresetParamRestrictionsParams{
+		Name:              c.Param("name"),
+		ParamRestrictions: restrictions,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "preset not found")
+		}
+		return apiError(http.StatusInternalServerError, "failed to update restrictions")
+	}
+
+	return c.JSON(http.StatusOK, toTrialLimitsResponse(preset))
+}
+
+// DeleteTrialPreset removes a preset no trial key uses (admin only). The
+// default preset cannot be deleted.
+func (h *AdminHandler) DeleteTrialPreset(c echo.Context) error {
+	name := c.Param("name")
+	if name == defaultTrialPreset {
+		return apiError(http.StatusBadRequest, "the default preset cannot be deleted")
+	}
+
+	ctx := context.Background()
+
+	if _, err := h.queries.GetTrialPreset(ctx, name); err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "preset not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	keys, err := h.queries.CountTrialKeysForPreset(ctx, name)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if keys > 0 {
+		return newAPIError(http.StatusConflict, ErrorResponse{
+			Error:   "preset is in use by trial keys",
+			Details: map[string]string{"trial_keys": strconv.FormatInt(keys, 10)},
+		})
+	}
+
+	if err := h.queries.DeleteTrialPreset(ctx, name); err != nil {
+		// Tenants reference their preset
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return apiError(http.StatusConflict, "preset is in use by tenants")
+		}
+		return apiError(http.StatusInternalServerError, "failed to delete preset")
+	}
+
+	log.Printf("[Admin] Deleted trial preset %s", name)
+	return c.JSON(http.StatusOK, map[string]string{"message": "preset deleted"})
+}
+
+// GetTrialPresetUsage reports trial keys and usage per preset for a period,
+// so campaigns can be compared (admin only)
+func (h *AdminHandler) GetTrialPresetUsage(c echo.Context) error {
+	period, err := resolveUsagePeriod(c, "UTC", time.Time{})
+	if err != nil {
+		return apiError(http.StatusBadRequest, err.Error())
+	}
+
+	rows, err := h.queries.GetTrialPresetUsageSummary(context.Background(), sqlc.GetTrialPresetUsageSummaryParams{
+		StartDate: period.Start,
+		EndDate:   period.End,
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	presets := make([]TrialPresetUsageResponse, len(rows))
+	for i, r := range rows {
+		presets[i] = TrialPresetUsageResponse{
+			Name:                 r.Name,
+			TotalTrialKeys:       r.TotalTrialKeys,
+			ActiveTrialKeys:      r.ActiveTrialKeys,
+			TotalSessions:        r.TotalSessions,
+			TotalDurationSeconds: parseDecimalStringAdmin(r.TotalDurationSeconds),
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"presets":      presets,
+		"period_start": period.Start.Format(time.RFC3339),
+		"period_end":   period.End.Format(time.RFC3339),
+		"period":       period.Kind,
+		"timezone":     period.Timezone,
+	})
+}
+
+// CreateCampaignCode signs a campaign code that provisions new trial keys
+// with the preset (admin only)
+func (h *AdminHandler) CreateCampaignCode(c echo.Context) error {
+	var req CampaignCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.ExpiresInDays == 0 {
+		req.ExpiresInDays = 30
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > 365 {
+		return apiError(http.StatusBadRequest, "expires_in_days must be between 1 and 365")
+	}
+
+	preset, err := h.queries.GetTrialPreset(context.Background(), c.Param("name"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "preset not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays).Truncate(time.Second)
+
+	return c.JSON(http.StatusOK, CampaignCodeResponse{
+		Preset:    preset.Name,
+		Code:      auth.GenerateCampaignCode(preset.Name, expiresAt),
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== ERROR RESPONSES ==========
+
+// MIMEApplicationProblemJSON is the content type of error responses
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// APIError is an error response returned by a handler rather than written
+// by it; HTTPErrorHandler turns it into the response:
+//
+//	return apiError(http.StatusNotFound, "user not found")
+type APIError struct {
+	Status   int
+	Response ErrorResponse
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%d: %s", e.Status, e.Response.Error)
+}
+
+// apiError is an error response with just a message
+func apiError(status int, msg string) *APIError {
+	return &APIError{Status: status, Response: ErrorResponse{Error: msg}}
+}
+
+// newAPIError is an error response with details
+func newAPIError(status int, resp ErrorResponse) *APIError {
+	return &APIError{Status: status, Response: resp}
+}
+
+// ProblemDetails is the RFC 7807 body of every error response. The members
+// of ErrorResponse are kept as extension members, so clients that read
+// error, code or details keep working.
+type ProblemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance,omitempty"`
+	ErrorResponse
+}
+
+// toProblem wraps a localized error response for the request's status
+func toProblem(c echo.Context, resp ErrorResponse) ProblemDetails {
+	status := c.Response().Status
+	return ProblemDetails{
+		Type:          "about:blank",
+		Title:         http.StatusText(status),
+		Status:        status,
+		Detail:        resp.Message,
+		Instance:      c.Request().URL.Path,
+		ErrorResponse: resp,
+	}
+}
+
+// HTTPErrorHandler is the server's echo.HTTPErrorHandler. It answers an
+// APIError with its response, Echo's own errors (unknown routes, wrong
+// methods) with their status, and anything else with a logged 500, all as
+// problem+json.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	var apiErr *APIError
+	var httpErr *echo.HTTPError
+	switch {
+	case errors.As(err, &apiErr):
+	case errors.As(err, &httpErr):
+		msg := http.StatusText(httpErr.Code)
+		if m, ok := httpErr.Message.(string); ok {
+			msg = m
+		}
+		apiErr = apiError(httpErr.Code, strings.ToLower(msg))
+	default:
+		if keyID := requestKeyID(c); keyID != "" {
+			log.Printf("[HTTP] %s %s (key %s) failed: %v", c.Request().Method, c.Request().URL.Path, keyID, err)
+		} else {
+			log.Printf("[HTTP] %s %s failed: %v", c.Request().Method, c.Request().URL.Path, err)
+		}
+		apiErr = apiError(http.StatusInternalServerError, "internal server error")
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(apiErr.Status)
+	} else {
+		err = c.JSON(apiErr.Status, apiErr.Response)
+	}
+	if err != nil {
+		log.Printf("[HTTP] Failed to write error response: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/config"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== CLIENT PROTOCOL ==========
+
+// ClientProtocol is the version of the HyperWhisper client protocol a proxy
+// session speaks: the frames the server sends besides Deepgram's own, and
+// how it closes the session. Clients negotiate it at upgrade, either with a
+// hyperwhisper.v<N> WebSocket subprotocol or the protocol_version query
+// param; clients that do neither speak ProtocolV1.
+type ClientProtocol int
+
+const (
+	// ProtocolV1 is what clients got before versions were negotiated:
+	// QueueStatus frames and the close codes in closecodes.go
+	ProtocolV1 ClientProtocol = 1
+	// ProtocolV2 adds a SessionStarted frame once Deepgram is connected
+	ProtocolV2 ClientProtocol = 2
+	// ProtocolV3 adds AdaptationHint frames under sustained backpressure
+	ProtocolV3 ClientProtocol = 3
+
+	latestProtocol = ProtocolV3
+)
+
+// protocolSubprotocolPrefix prefixes the version in the subprotocols
+// clients offer, e.g. hyperwhisper.v2
+const protocolSubprotocolPrefix = "hyperwhisper.v"
+
+func (p ClientProtocol) subprotocol() string {
+	return protocolSubprotocolPrefix + strconv.Itoa(int(p))
+}
+
+// proxySubprotocols lists the subprotocols of every supported version,
+// latest first, so the upgrader picks the latest one the client offers
+func proxySubprotocols() []string {
+	var out []string
+	for p := latestProtocol; p >= ProtocolV1; p-- {
+		out = append(out, p.subprotocol())
+	}
+	return out
+}
+
+// minProtocol is the oldest version clients may still connect with
+func minProtocol() ClientProtocol {
+	// A minimum above the latest version would lock every client out
+	return min(ClientProtocol(config.Int("MIN_CLIENT_PROTOCOL")), latestProtocol)
+}
+
+// negotiateProtocol picks the version of a proxy session: the latest
+// supported one the client offers as a subprotocol, or else the
+// protocol_version it asks for, capped at the latest. It returns the error
+// to respond with when the client can't be served: 400 for versions that
+// don't exist, 426 Upgrade Required for ones older than
+// MIN_CLIENT_PROTOCOL.
+func negotiateProtocol(c echo.Context) (ClientProtocol, error) {
+	protocol := ProtocolV1
+	if offered := offeredProtocols(c.Request()); len(offered) > 0 {
+		protocol = 0
+		for _, p := range offered {
+			if p <= latestProtocol && p > protocol {
+				protocol = p
+			}
+		}
+		if protocol == 0 {
+			return 0, unsupportedProtocolError()
+		}
+	} else if raw := c.QueryParam("protocol_version"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < int(ProtocolV1) {
+			return 0, unsupportedProtocolError()
+		}
+		protocol = min(ClientProtocol(v), latestProtocol)
+	}
+
+	if minimum := minProtocol(); protocol < minimum {
+		return 0, newAPIError(http.StatusUpgradeRequired, ErrorResponse{
+			Error:   "this version of HyperWhisper is no longer supported, please update",
+			Details: map[string]string{"min_protocol_version": strconv.Itoa(int(minimum))},
+		})
+	}
+	return protocol, nil
+}
+
+// offeredProtocols parses the hyperwhisper.v<N> subprotocols of a request,
+// ignoring any others
+func offeredProtocols(r *http.Request) []ClientProtocol {
+	var out []ClientProtocol
+	for _, sub := range websocket.Subprotocols(r) {
+		version, ok := strings.CutPrefix(sub, protocolSubprotocolPrefix)
+		if !ok {
+			continue
+		}
+		if v, err := strconv.Atoi(version); err == nil && v > 0 {
+			out = append(out, ClientProtocol(v))
+		}
+	}
+	return out
+}
+
+func unsupportedProtocolError() error {
+	return newAPIError(http.StatusBadRequest, ErrorResponse{
+		Error: "unsupported protocol version",
+		Details: map[string]string{
+			"supported": strconv.Itoa(int(minProtocol())) + "-" + strconv.Itoa(int(latestProtocol)),
+		},
+	})
+}
+
+// sessionStartedMessage tells ProtocolV2 clients the session is connected.
+// SessionID is the usage log ID to quote in error reports; dashboard
+// sessions have none. MaxDurationSeconds is omitted when only the quota
+// bounds the session.
+type sessionStartedMessage struct {
+	Type               string `json:"type"`
+	ProtocolVersion    int    `json:"protocol_version"`
+	SessionID          string `json:"session_id,omitempty"`
+	MaxDurationSeconds int    `json:"max_duration_seconds,omitempty"`
+}
+
+// sendSessionStarted sends the SessionStarted frame to clients that speak
+// ProtocolV2 or later. It must be called before the proxy loops start
+// writing.
+func sendSessionStarted(conn *websocket.Conn, protocol ClientProtocol, sessionID string, maxDuration time.Duration) error {
+	if protocol < ProtocolV2 {
+		return nil
+	}
+	return writeMessageJSON(conn, sessionStartedMessage{
+		Type:               "SessionStarted",
+		ProtocolVersion:    int(protocol),
+		SessionID:          sessionID,
+		MaxDurationSeconds: int(maxDuration.Seconds()),
+	})
+}
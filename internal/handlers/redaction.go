@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// ========== REDACTION AUDIT ==========
+
+// RedactionAudit records the redactions a session ran with and what required
+// them, so compliance teams can show a policy was active for a given call
+type RedactionAudit struct {
+	Applied    []string `json:"applied"`              // Categories sent to Deepgram
+	Enforced   []string `json:"enforced"`             // Categories the client could not drop
+	Policies   []string `json:"policies,omitempty"`   // Session policies that enforced redactions
+	Restricted bool     `json:"restricted,omitempty"` // The key's parameter restrictions chose the redactions
+}
+
+// auditRedactions builds a session's redaction audit from the params sent
+// to Deepgram and where they came from. It is stored with the session log.
+func auditRedactions(query url.Values, policy sessionPolicy, restrictions ParamRestrictions, params map[string]string) json.RawMessage {
+	audit := RedactionAudit{
+		Applied:  splitRedact(params["redact"]),
+		Enforced: splitRedact(policy.params["redact"]),
+		Policies: policy.redactedBy,
+	}
+	// applyDefaults filled in redact because neither the client nor a
+	// policy set it
+	if query.Get("redact") == "" && policy.params["redact"] == "" && len(restrictions["redact"]) > 0 {
+		audit.Enforced = splitRedact(restrictions["redact"][0])
+		audit.Restricted = true
+	}
+	raw, _ := json.Marshal(audit)
+	return raw
+}
+
+// parseRedactionAudit reads a stored redaction audit; sessions from before
+// audits were recorded, and offline trial usage, have none
+func parseRedactionAudit(raw json.RawMessage) *RedactionAudit {
+	var audit RedactionAudit
+	if err := json.Unmarshal(raw, &audit); err != nil || audit.Applied == nil {
+		return nil
+	}
+	return &audit
+}
+
+// splitRedact lists the categories of a comma-separated redact value
+func splitRedact(value string) []string {
+	categories := []string{}
+	if merged := mergeRedact(value); merged != "" {
+		categories = strings.Split(merged, ",")
+	}
+	return categories
+}
@@ -0,0 +1,444 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	netmail "net/mail"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/mail"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== SCHEDULED REPORTS ==========
+
+const (
+	// reportDelay is how long after its period ends a report is sent, so
+	// sessions running over the boundary have finished
+	reportDelay = 15 * time.Minute
+	// reportTopUsers is how many users the top users report lists
+	reportTopUsers = 10
+)
+
+// Report delivery channels
+const (
+	reportChannelEmail   = "email"
+	reportChannelWebhook = "webhook"
+)
+
+// reportCadence is how often a report is sent and the span it covers, in
+// UTC. Weeks start on Monday.
+type reportCadence int
+
+const (
+	cadenceDaily reportCadence = iota
+	cadenceWeekly
+	cadenceMonthly
+)
+
+// start returns the start of the period t falls in
+func (c reportCadence) start(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch c {
+	case cadenceWeekly:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case cadenceMonthly:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+// add moves a period start n periods on
+func (c reportCadence) add(t time.Time, n int) time.Time {
+	switch c {
+	case cadenceWeekly:
+		return t.AddDate(0, 0, 7*n)
+	case cadenceMonthly:
+		return t.AddDate(0, n, 0)
+	}
+	return t.AddDate(0, 0, n)
+}
+
+// label names the period starting at start in report subjects
+func (c reportCadence) label(start time.Time) string {
+	switch c {
+	case cadenceWeekly:
+		return "week of " + start.Format("2006-01-02")
+	case cadenceMonthly:
+		return start.Format("January 2006")
+	}
+	return start.Format("2006-01-02")
+}
+
+// reportKind is a report admins can subscribe to
+type reportKind struct {
+	title   string
+	cadence reportCadence
+	// build writes the report's body for the period [start, end)
+	build func(ctx context.Context, queries *sqlc.Queries, start, end time.Time) (string, error)
+}
+
+// reportKinds are the reports admins can subscribe to, by name
+var reportKinds = map[string]reportKind{
+	"daily_usage":         {title: "Daily usage summary", cadence: cadenceDaily, build: buildUsageReport},
+	"weekly_trial_funnel": {title: "Weekly trial funnel", cadence: cadenceWeekly, build: buildTrialFunnelReport},
+	"monthly_top_users":   {title: "Monthly top users", cadence: cadenceMonthly, build: buildTopUsersReport},
+}
+
+// CreateReportScheduleRequest subscribes to a report
+type CreateReportScheduleRequest struct {
+	Report  string `json:"report"`  // One of reportKinds
+	Channel string `json:"channel"` // "email" or "webhook"
+	Target  string `json:"target"`  // Email address (default: the caller's) or webhook URL
+}
+
+// ReportScheduleResponse is a report subscription as shown to admins
+type ReportScheduleResponse struct {
+	ID        string  `json:"id"`
+	Report    string  `json:"report"`
+	Channel   string  `json:"channel"`
+	Target    string  `json:"target"`
+	NextRunAt string  `json:"next_run_at"`
+	LastRunAt *string `json:"last_run_at"`
+	LastError *string `json:"last_error"`
+	CreatedBy *string `json:"created_by"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// reportWebhookPayload is posted to webhook subscriptions. Text makes it
+// Slack-compatible, like the budget alert webhook.
+type reportWebhookPayload struct {
+	Text        string `json:"text"`
+	Report      string `json:"report"`
+	PeriodStart string `json:"period_start"`
+	PeriodEnd   string `json:"period_end"`
+}
+
+// ListReportSchedules returns every report subscription (admin only, as
+// webhook URLs often embed a secret)
+func (h *AdminHandler) ListReportSchedules(c echo.Context) error {
+	schedules, err := h.queries.ListReportSchedules(context.Background())
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	responses := make([]ReportScheduleResponse, len(schedules))
+	for i, schedule := range schedules {
+		responses[i] = toReportScheduleResponse(schedule)
+	}
+	return c.JSON(http.StatusOK, responses)
+}
+
+// CreateReportSchedule subscribes to a report. The first one is sent once
+// the current period has ended.
+func (h *AdminHandler) CreateReportSchedule(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	var req CreateReportScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	kind, ok := reportKinds[req.Report]
+	if !ok {
+		names := make([]string, 0, len(reportKinds))
+		for name := range reportKinds {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return newAPIError(http.StatusBadRequest, ErrorResponse{
+			Error:   "unknown report",
+			Details: map[string]string{"report": "must be one of " + strings.Join(names, ", ")},
+		})
+	}
+
+	target := strings.TrimSpace(req.Target)
+	switch req.Channel {
+	case reportChannelEmail:
+		if target == "" {
+			target = claims.Email
+		}
+		addr, err := netmail.ParseAddress(target)
+		if err != nil {
+			return apiError(http.StatusBadRequest, "target must be an email address")
+		}
+		target = addr.Address
+	case reportChannelWebhook:
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return apiError(http.StatusBadRequest, "target must be an http(s) URL")
+		}
+	default:
+		return apiError(http.StatusBadRequest, "channel must be 'email' or 'webhook'")
+	}
+
+	ctx := context.Background()
+	schedule, err := h.queries.CreateReportSchedule(ctx, sqlc.CreateReportScheduleParams{
+		Report:    req.Report,
+		Channel:   req.Channel,
+		Target:    target,
+		NextRunAt: kind.cadence.add(kind.cadence.start(time.Now()), 1).Add(reportDelay),
+		CreatedBy: uuid.NullUUID{UUID: claims.UserID, Valid: true},
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to create report schedule")
+	}
+
+	// Webhook URLs often embed a secret, so only the channel is audited
+	recordAuditEvent(ctx, h.queries, c, auditReportCreate, "report_schedule", schedule.ID.String(), "", map[string]string{
+		"report":  schedule.Report,
+		"channel": schedule.Channel,
+	})
+
+	return c.JSON(http.StatusCreated, toReportScheduleResponse(schedule))
+}
+
+// DeleteReportSchedule unsubscribes from a report (admin only)
+func (h *AdminHandler) DeleteReportSchedule(c echo.Context) error {
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid report schedule ID")
+	}
+
+	ctx := context.Background()
+	deleted, err := h.queries.DeleteReportSchedule(ctx, scheduleID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to delete report schedule")
+	}
+	if deleted == 0 {
+		return apiError(http.StatusNotFound, "report schedule not found")
+	}
+
+	recordAuditEvent(ctx, h.queries, c, auditReportDelete, "report_schedule", scheduleID.String(), "", nil)
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "report schedule deleted"})
+}
+
+// sendDueReports sends every report whose period has ended. Schedules are
+// claimed in the database, so each is sent by one worker only.
+func (h *ExportHandler) sendDueReports(ctx context.Context) {
+	for ctx.Err() == nil {
+		schedule, err := h.queries.ClaimDueReportSchedule(ctx)
+		if err != nil {
+			if err != sql.ErrNoRows && ctx.Err() == nil {
+				log.Printf("[Reports] Failed to claim report schedule: %v", err)
+			}
+			return
+		}
+		h.sendReport(ctx, schedule)
+	}
+}
+
+// sendReport sends a claimed schedule's report for the period that ended
+// last and schedules the next one. Failed deliveries are not retried; the
+// error is kept on the schedule until one succeeds.
+func (h *ExportHandler) sendReport(ctx context.Context, schedule sqlc.ReportSchedule) {
+	kind, ok := reportKinds[schedule.Report]
+	if !ok {
+		log.Printf("[Reports] Schedule %s has unknown report %q", schedule.ID, schedule.Report)
+		return
+	}
+
+	end := kind.cadence.start(time.Now())
+	start := kind.cadence.add(end, -1)
+
+	var lastError sql.NullString
+	if err := h.deliverReport(ctx, schedule, kind, start, end); err != nil {
+		log.Printf("[Reports] Failed to send %s report %s: %v", schedule.Report, schedule.ID, err)
+		lastError = sql.NullString{String: err.Error(), Valid: true}
+	} else {
+		log.Printf("[Reports] Sent %s report for %s via %s", schedule.Report, kind.cadence.label(start), schedule.Channel)
+	}
+
+	if err := h.queries.FinishReportRun(context.Background(), sqlc.FinishReportRunParams{
+		ID:        schedule.ID,
+		NextRunAt: kind.cadence.add(end, 1).Add(reportDelay),
+		LastError: lastError,
+	}); err != nil {
+		log.Printf("[Reports] Failed to reschedule report %s: %v", schedule.ID, err)
+	}
+}
+
+func (h *ExportHandler) deliverReport(ctx context.Context, schedule sqlc.ReportSchedule, kind reportKind, start, end time.Time) error {
+	body, err := kind.build(ctx, h.queries, start, end)
+	if err != nil {
+		return fmt.Errorf("building report: %w", err)
+	}
+	subject := fmt.Sprintf("HyperWhisper %s, %s", strings.ToLower(kind.title), kind.cadence.label(start))
+
+	if schedule.Channel == reportChannelEmail {
+		return h.mailer.Send(ctx, mail.Message{
+			To:      schedule.Target,
+			Subject: subject,
+			Body:    body,
+		})
+	}
+
+	payload, _ := json.Marshal(reportWebhookPayload{
+		Text:        subject + "\n\n" + body,
+		Report:      schedule.Report,
+		PeriodStart: start.Format(time.RFC3339),
+		PeriodEnd:   end.Format(time.RFC3339),
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, schedule.Target, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL may embed a secret; keep it out of the stored error
+		return fmt.Errorf("webhook request failed: %w", unwrapURLError(err))
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// unwrapURLError drops the URL net/http adds to request errors
+func unwrapURLError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err
+	}
+	return err
+}
+
+// buildUsageReport summarizes transcription and trial usage
+func buildUsageReport(ctx context.Context, queries *sqlc.Queries, start, end time.Time) (string, error) {
+	usage, err := queries.GetSystemUsageSummary(ctx, sqlc.GetSystemUsageSummaryParams{
+		StartDate: start,
+		EndDate:   end,
+	})
+	if err != nil {
+		return "", err
+	}
+	trial, err := queries.GetAllTrialUsageSummary(ctx, sqlc.GetAllTrialUsageSummaryParams{
+		StartDate: start,
+		EndDate:   end,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Sessions: %d by %d users\n", usage.TotalSessions, usage.UniqueUsers)
+	fmt.Fprintf(&b, "Audio: %s\n", formatReportHours(parseDecimalStringAdmin(usage.TotalDurationSeconds)))
+	fmt.Fprintf(&b, "Audio data: %.1f MB\n", float64(parseBytesSentAdmin(usage.TotalBytesSent))/1e6)
+	fmt.Fprintf(&b, "Trial sessions: %d, %s of audio\n", trial.TotalSessions, formatReportHours(parseDecimalStringAdmin(trial.TotalDurationSeconds)))
+	return b.String(), nil
+}
+
+// buildTrialFunnelReport follows the trial keys provisioned in the period
+// through the funnel, per preset
+func buildTrialFunnelReport(ctx context.Context, queries *sqlc.Queries, start, end time.Time) (string, error) {
+	funnel, err := queries.ListTrialFunnelCohort(ctx, sqlc.ListTrialFunnelCohortParams{
+		StartDate: start,
+		EndDate:   end,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("Trial keys provisioned this week, per preset:\n\n")
+	var total sqlc.ListTrialFunnelCohortRow
+	for _, row := range funnel {
+		if row.Provisioned == 0 {
+			continue
+		}
+		writeFunnelLine(&b, row.Preset, row)
+		total.Provisioned += row.Provisioned
+		total.FirstSession += row.FirstSession
+		total.QuotaExhausted += row.QuotaExhausted
+		total.Converted += row.Converted
+	}
+	if total.Provisioned == 0 {
+		return "No trial keys were provisioned this week.\n", nil
+	}
+	writeFunnelLine(&b, "Total", total)
+	return b.String(), nil
+}
+
+func writeFunnelLine(b *strings.Builder, name string, row sqlc.ListTrialFunnelCohortRow) {
+	fmt.Fprintf(b, "%s: %d provisioned, %d used (%s), %d out of quota, %d converted (%s)\n",
+		name, row.Provisioned,
+		row.FirstSession, formatReportShare(row.FirstSession, row.Provisioned),
+		row.QuotaExhausted,
+		row.Converted, formatReportShare(row.Converted, row.Provisioned))
+}
+
+// buildTopUsersReport lists the users with the most audio in the period
+func buildTopUsersReport(ctx context.Context, queries *sqlc.Queries, start, end time.Time) (string, error) {
+	users, err := queries.ListUserUsageTotals(ctx, sqlc.ListUserUsageTotalsParams{
+		StartDate: start,
+		EndDate:   end,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(users) == 0 {
+		return "No transcription sessions this month.\n", nil
+	}
+
+	sort.SliceStable(users, func(i, j int) bool {
+		return parseDecimalStringAdmin(users[i].TotalDurationSeconds) > parseDecimalStringAdmin(users[j].TotalDurationSeconds)
+	})
+	users = users[:min(len(users), reportTopUsers)]
+
+	var b strings.Builder
+	for i, u := range users {
+		fmt.Fprintf(&b, "%d. %s: %s in %d sessions\n",
+			i+1, u.Username, formatReportHours(parseDecimalStringAdmin(u.TotalDurationSeconds)), u.TotalSessions)
+	}
+	return b.String(), nil
+}
+
+func formatReportHours(seconds float64) string {
+	return fmt.Sprintf("%.1f hours", seconds/3600)
+}
+
+func formatReportShare(n, of int64) string {
+	if of == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.0f%%", float64(n)*100/float64(of))
+}
+
+func toReportScheduleResponse(schedule sqlc.ReportSchedule) ReportScheduleResponse {
+	resp := ReportScheduleResponse{
+		ID:        schedule.ID.String(),
+		Report:    schedule.Report,
+		Channel:   schedule.Channel,
+		Target:    schedule.Target,
+		NextRunAt: schedule.NextRunAt.Format(time.RFC3339),
+		CreatedAt: schedule.CreatedAt.Format(time.RFC3339),
+	}
+	if schedule.LastRunAt.Valid {
+		t := schedule.LastRunAt.Time.Format(time.RFC3339)
+		resp.LastRunAt = &t
+	}
+	if schedule.LastError.Valid {
+		resp.LastError = &schedule.LastError.String
+	}
+	if schedule.CreatedBy.Valid {
+		id := schedule.CreatedBy.UUID.String()
+		resp.CreatedBy = &id
+	}
+	return resp
+}
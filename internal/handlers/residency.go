@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"slices"
+	"strings"
+
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+// ========== DATA RESIDENCY ==========
+
+// Organizations and tenants can require their sessions to stay in a data
+// region. Such sessions stream to the region's Deepgram endpoint and only
+// save transcripts when the database is in the region (DATABASE_REGION).
+// Audio is never stored.
+
+// defaultDeepgramURL processes sessions without a residency requirement
+const defaultDeepgramURL = "wss://api.deepgram.com/v1/listen"
+
+// dataRegionEndpoints names, per data region, the setting holding the
+// Deepgram endpoint that processes its sessions
+var dataRegionEndpoints = map[string]string{
+	"eu": "DEEPGRAM_EU_URL",
+}
+
+// dataRegions returns the regions residency can be set to
+func dataRegions() []string {
+	regions := make([]string, 0, len(dataRegionEndpoints))
+	for region := range dataRegionEndpoints {
+		regions = append(regions, region)
+	}
+	slices.Sort(regions)
+	return regions
+}
+
+// parseDataRegion validates a requested region; empty removes the
+// requirement
+func parseDataRegion(region string) (sql.NullString, error) {
+	if region == "" {
+		return sql.NullString{}, nil
+	}
+	if _, ok := dataRegionEndpoints[region]; !ok {
+		return sql.NullString{}, newAPIError(http.StatusBadRequest, ErrorResponse{
+			Error:   "unknown data region",
+			Details: map[string]string{"data_region": "must be empty or one of " + strings.Join(dataRegions(), ", ")},
+		})
+	}
+	return sql.NullString{String: region, Valid: true}, nil
+}
+
+// sessionRegion returns the data region a session must stay in: that of
+// the organization owning its API key, or else of the tenant of its user
+// or trial key; "" when neither requires one
+func sessionRegion(ctx context.Context, queries *sqlc.Queries, orgID, tenantID uuid.NullUUID) (string, error) {
+	if orgID.Valid {
+		org, err := queries.GetOrganizationByID(ctx, orgID.UUID)
+		if err != nil {
+			return "", err
+		}
+		if org.DataRegion.Valid {
+			return org.DataRegion.String, nil
+		}
+	}
+	if tenantID.Valid {
+		tenant, err := queries.GetTenant(ctx, tenantID.UUID)
+		if err != nil {
+			return "", err
+		}
+		if tenant.DataRegion.Valid {
+			return tenant.DataRegion.String, nil
+		}
+	}
+	return "", nil
+}
+
+// deepgramEndpoint returns the streaming endpoint that processes sessions
+// of region
+func deepgramEndpoint(region string) string {
+	if setting, ok := dataRegionEndpoints[region]; ok {
+		return config.String(setting)
+	}
+	return defaultDeepgramURL
+}
+
+// transcriptsStorable reports whether transcripts of sessions bound to
+// region may be saved, which takes a database in the region
+func transcriptsStorable(region string) bool {
+	return region == "" || region == config.String("DATABASE_REGION")
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== PARAMETER RESTRICTIONS ==========
+
+// ParamRestrictions limits the Deepgram params a key may request: param name
+// to allowed values. An empty list forbids the param; a missing entry leaves
+// it unrestricted.
+type ParamRestrictions map[string][]string
+
+func parseParamRestrictions(raw json.RawMessage) ParamRestrictions {
+	r := ParamRestrictions{}
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &r)
+	}
+	return r
+}
+
+// validate checks that every restricted param is one the proxy forwards
+func (r ParamRestrictions) validate() string {
+	for param := range r {
+		if !isAllowedDeepgramParam(param) {
+			return fmt.Sprintf("unsupported Deepgram parameter %q", param)
+		}
+	}
+	return ""
+}
+
+func (r ParamRestrictions) allows(param, value string) bool {
+	for _, allowed := range r[param] {
+		if strings.EqualFold(allowed, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// violations checks the client's requested params and returns a message per
+// rejected param, or nil if the request is allowed
+func (r ParamRestrictions) violations(query url.Values) map[string]string {
+	var out map[string]string
+	for param, allowed := range r {
+		value := query.Get(param)
+		if value == "" || r.allows(param, value) {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string)
+		}
+		if len(allowed) == 0 {
+			out[param] = fmt.Sprintf("%s is not allowed for this key", param)
+		} else {
+			out[param] = fmt.Sprintf("%q is not allowed (allowed: %s)", value, strings.Join(allowed, ", "))
+		}
+	}
+	return out
+}
+
+// applyDefaults fills restricted params the client left out with their first
+// allowed value, so Deepgram's own default (e.g. a different model) is
+// never used
+func (r ParamRestrictions) applyDefaults(params map[string]string) {
+	for param, allowed := range r {
+		if _, ok := params[param]; !ok && len(allowed) > 0 {
+			params[param] = allowed[0]
+		}
+	}
+}
+
+// permitted drops the server-side defaults this key may not use, so
+// applyDefaults fills those params instead
+func (r ParamRestrictions) permitted(defaults map[string]string) map[string]string {
+	out := make(map[string]string, len(defaults))
+	for param, value := range defaults {
+		if _, restricted := r[param]; !restricted || r.allows(param, value) {
+			out[param] = value
+		}
+	}
+	return out
+}
+
+// paramRestrictionError is the structured 403 returned when a session
+// requests params its key may not use
+func paramRestrictionError(c echo.Context, violations map[string]string) error {
+	return newAPIError(http.StatusForbidden, ErrorResponse{
+		Error:   "requested parameters are not allowed for this key",
+		Details: violations,
+	})
+}
+
+// UpdateParamRestrictionsRequest is the request for setting key restrictions
+type UpdateParamRestrictionsRequest struct {
+	ParamRestrictions ParamRestrictions `json:"param_restrictions"`
+}
+
+// UpdateAPIKeyRestrictions sets the parameter restrictions of an API key (admin only)
+func (h *AdminHandler) UpdateAPIKeyRestrictions(c echo.Context) error {
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid key ID")
+	}
+
+	var req UpdateParamRestrictionsRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.ParamRestrictions == nil {
+		req.ParamRestrictions = ParamRestrictions{}
+	}
+	if msg := req.ParamRestrictions.validate(); msg != "" {
+		return apiError(http.StatusBadRequest, msg)
+	}
+
+	restrictions, _ := json.Marshal(req.ParamRestrictions)
+	ctx := context.Background()
+
+	key, err := h.queries.UpdateAPIKeyParamRestrictions(ctx, sqlc.UpdateAPIKeyParamRestrictionsParams{
+		ID:                keyID,
+		ParamRestrictions: restrictions,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "API key not found")
+		}
+		return apiError(http.StatusInternalServerError, "failed to update restrictions")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"id":                 key.ID.String(),
+		"param_restrictions": parseParamRestrictions(key.ParamRestrictions),
+	})
+}
+
+// UpdateTrialRestrictions sets the parameter restrictions of the default
+// trial preset (admin only)
+func (h *AdminHandler) UpdateTrialRestrictions(c echo.Context) error {
+	var req UpdateParamRestrictionsRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.ParamRestrictions == nil {
+		req.ParamRestrictions = ParamRestrictions{}
+	}
+	if msg := req.ParamRestrictions.validate(); msg != "" {
+		return apiError(http.StatusBadRequest, msg)
+	}
+
+	restrictions, _ := json.Marshal(req.ParamRestrictions)
+	ctx := context.Background()
+
+	limits, err := h.queries.UpdateTrialPresetParamRestrictions(ctx, sqlc.UpdateTrialPresetParamRestrictionsParams{
+		Name:              defaultTrialPreset,
+		ParamRestrictions: restrictions,
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to update restrictions")
+	}
+
+	return c.JSON(http.StatusOK, toTrialLimitsResponse(limits))
+}
@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== SANDBOX KEYS ==========
+
+// Sandbox keys (hw_test_) let integrators and CI suites develop against the
+// proxy protocol for free. Their sessions go through the same checks as
+// live ones but stream to a built-in mock of Deepgram, and are neither
+// logged nor counted toward quotas or the monthly budget.
+
+const (
+	liveKeyPrefix    = "hw_live_"
+	sandboxKeyPrefix = "hw_test_"
+)
+
+// IsSandboxKey checks if an API key is a sandbox key (hw_test_ prefix)
+func IsSandboxKey(apiKey string) bool {
+	return strings.HasPrefix(apiKey, sandboxKeyPrefix)
+}
+
+// apiKeyPrefix returns the prefix of newly generated keys of a class
+func apiKeyPrefix(sandbox bool) string {
+	if sandbox {
+		return sandboxKeyPrefix
+	}
+	return liveKeyPrefix
+}
+
+// sandboxProxy runs a session of a sandbox key against the mock upstream
+func (h *DeepgramHandler) sandboxProxy(c echo.Context, protocol ClientProtocol, apiKeyRecord sqlc.ApiKey) error {
+	ctx := context.Background()
+
+	// Enforce session policies and key restrictions like live sessions, so
+	// integrators see the same rejections
+	policy, err := enforceSessionPolicy(ctx, h.queries, sessionTarget{
+		userID:   apiKeyRecord.UserID,
+		apiKeyID: apiKeyRecord.ID,
+	}, "Sandbox")
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to resolve session policy")
+	}
+	restrictions := parseParamRestrictions(apiKeyRecord.ParamRestrictions)
+	if violations := restrictions.violations(c.Request().URL.Query()); violations != nil {
+		log.Printf("[Sandbox] Rejected restricted params: %v", violations)
+		return paramRestrictionError(c, violations)
+	}
+	defaults := restrictions.permitted(loadDeepgramDefaults(ctx, h.queries, "Sandbox"))
+	deepgramParams := extractDeepgramParams(c.Request().URL.Query(), defaults, policy.params)
+	restrictions.applyDefaults(deepgramParams)
+
+	user, err := h.queries.GetUserByID(ctx, apiKeyRecord.UserID)
+	if err != nil {
+		log.Printf("[Sandbox] Failed to get user: %v", err)
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	useUserLanguage(c, user)
+
+	mockURL, err := mockUpstreamURL()
+	if err != nil {
+		log.Printf("[Sandbox] Mock upstream unavailable: %v", err)
+		return apiError(http.StatusServiceUnavailable, "transcription service unavailable")
+	}
+
+	// Sandbox sessions hold concurrency slots like live ones, so they
+	// can't be used to exhaust the server
+	slot, ok := reserveSessionSlot(user.UserType, user.ID.String())
+	if !ok {
+		log.Printf("[Sandbox] Concurrent session limit reached for user %s", user.ID)
+		return concurrencyLimitError(c, user.UserType)
+	}
+	defer slot.Release()
+
+	lang := requestLanguage(c)
+	clientConn, err := h.upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		log.Printf("[Sandbox] WebSocket upgrade failed: %v", err)
+		return err
+	}
+	defer clientConn.Close()
+
+	if err := slot.wait(clientConn, "Sandbox", lang); err != nil {
+		return nil
+	}
+
+	dialer := newUpstreamDialer()
+	upstreamConn, _, err := dialer.Dial(withDeepgramParams(mockURL, deepgramParams), nil)
+	if err != nil {
+		log.Printf("[Sandbox] Mock upstream connection failed: %v", err)
+		closeClient(clientConn, CloseUpstreamFailure, lang)
+		return nil
+	}
+	defer upstreamConn.Close()
+
+	session := &proxySession{
+		clientConn:   clientConn,
+		deepgramConn: upstreamConn,
+		lang:         lang,
+		logID:        uuid.New(),
+		apiKeyID:     apiKeyRecord.ID,
+		queries:      h.queries,
+		audio:        newAudioClock(deepgramParams),
+		userID:       apiKeyRecord.UserID,
+		pacing:       newPacingMonitor(protocol, deepgramParams),
+		sandbox:      true,
+	}
+	log.Printf("[Sandbox] Session %s started for key %s", session.logID, requestKeyID(c))
+
+	_ = sendSessionStarted(clientConn, protocol, session.logID.String(), 0)
+	session.run()
+
+	return nil
+}
+
+// ========== MOCK UPSTREAM ==========
+
+// mockSegmentSeconds is how much audio each mock transcript covers
+const mockSegmentSeconds = 1.0
+
+// mockTranscript is the text of every mock transcript segment, so tests
+// can assert on it
+const mockTranscript = "hello from the hyperwhisper sandbox"
+
+// mockUpstream speaks Deepgram's streaming protocol on a loopback port,
+// started with the first sandbox session
+var mockUpstream struct {
+	once sync.Once
+	url  string
+	err  error
+}
+
+var mockUpgrader = websocket.Upgrader{}
+
+// mockUpstreamURL returns the streaming URL of the mock upstream, starting
+// it if needed
+func mockUpstreamURL() (string, error) {
+	mockUpstream.once.Do(func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			mockUpstream.err = err
+			return
+		}
+		server := &http.Server{
+			Handler:           http.HandlerFunc(serveMockUpstream),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			if err := server.Serve(listener); err != nil {
+				log.Printf("[Sandbox] Mock upstream stopped: %v", err)
+			}
+		}()
+		mockUpstream.url = "ws://" + listener.Addr().String() + "/v1/listen"
+		log.Printf("[Sandbox] Mock upstream listening on %s", listener.Addr())
+	})
+	return mockUpstream.url, mockUpstream.err
+}
+
+// mockWord is a word of a mock transcript
+type mockWord struct {
+	Word       string  `json:"word"`
+	Start      float64 `json:"start"`
+	End        float64 `json:"end"`
+	Confidence float64 `json:"confidence"`
+}
+
+// mockAlternative is the transcript of a mock result
+type mockAlternative struct {
+	Transcript string     `json:"transcript"`
+	Confidence float64    `json:"confidence"`
+	Words      []mockWord `json:"words"`
+}
+
+// mockResults is a Deepgram Results message
+type mockResults struct {
+	Type         string  `json:"type"`
+	ChannelIndex []int   `json:"channel_index"`
+	Duration     float64 `json:"duration"`
+	Start        float64 `json:"start"`
+	IsFinal      bool    `json:"is_final"`
+	SpeechFinal  bool    `json:"speech_final"`
+	FromFinalize bool    `json:"from_finalize"`
+	Channel      struct {
+		Alternatives []mockAlternative `json:"alternatives"`
+	} `json:"channel"`
+	Metadata struct {
+		RequestID string `json:"request_id"`
+	} `json:"metadata"`
+}
+
+// mockMetadata is the Metadata message Deepgram sends before closing
+type mockMetadata struct {
+	Type      string  `json:"type"`
+	RequestID string  `json:"request_id"`
+	Created   string  `json:"created"`
+	Duration  float64 `json:"duration"`
+	Channels  int     `json:"channels"`
+}
+
+// mockStream is one session on the mock upstream
+type mockStream struct {
+	conn        *websocket.Conn
+	requestID   string
+	audio       audioClock
+	interim     bool    // Send interim results before each final one
+	transcribed float64 // Seconds of audio covered by sent results
+}
+
+func serveMockUpstream(w http.ResponseWriter, r *http.Request) {
+	conn, err := mockUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	params := make(map[string]string)
+	for name := range r.URL.Query() {
+		params[name] = r.URL.Query().Get(name)
+	}
+	s := &mockStream{
+		conn:      conn,
+		requestID: uuid.NewString(),
+		audio:     newAudioClock(params),
+		interim:   params["interim_results"] == "true",
+	}
+	s.run()
+}
+
+// run transcribes every second of received audio as mockTranscript until
+// the proxy sends CloseStream or disconnects
+func (s *mockStream) run() {
+	for {
+		messageType, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		if messageType == websocket.BinaryMessage {
+			s.audio.add(len(data), time.Now())
+			for s.audio.estimate()-s.transcribed >= mockSegmentSeconds {
+				if err := s.results(s.transcribed+mockSegmentSeconds, false); err != nil {
+					return
+				}
+			}
+			continue
+		}
+
+		var msg struct {
+			Type string `json:"type"`
+		}
+		_ = json.Unmarshal(data, &msg)
+		switch msg.Type {
+		case "Finalize":
+			if err := s.results(s.audio.estimate(), true); err != nil {
+				return
+			}
+		case "CloseStream":
+			if err := s.results(s.audio.estimate(), true); err != nil {
+				return
+			}
+			metadata, _ := json.Marshal(mockMetadata{
+				Type:      "Metadata",
+				RequestID: s.requestID,
+				Created:   time.Now().UTC().Format(time.RFC3339),
+				Duration:  s.audio.estimate(),
+				Channels:  s.audio.channels,
+			})
+			if err := s.conn.WriteMessage(websocket.TextMessage, metadata); err != nil {
+				return
+			}
+			_ = s.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+		}
+	}
+}
+
+// results sends the transcript of the audio up to end, preceded by an
+// interim result if the session asked for them
+func (s *mockStream) results(end float64, fromFinalize bool) error {
+	if end <= s.transcribed {
+		return nil
+	}
+	start := s.transcribed
+	s.transcribed = end
+
+	words := strings.Fields(mockTranscript)
+	step := (end - start) / float64(len(words))
+	msg := mockResults{
+		Type:         "Results",
+		ChannelIndex: []int{0, s.audio.channels},
+		Duration:     end - start,
+		Start:        start,
+	}
+	msg.Metadata.RequestID = s.requestID
+	alt := mockAlternative{Transcript: mockTranscript, Confidence: 1}
+	for i, word := range words {
+		alt.Words = append(alt.Words, mockWord{
+			Word:       word,
+			Start:      roundSeconds(start + float64(i)*step),
+			End:        roundSeconds(start + float64(i+1)*step),
+			Confidence: 1,
+		})
+	}
+	msg.Channel.Alternatives = []mockAlternative{alt}
+
+	if s.interim {
+		data, _ := json.Marshal(msg)
+		if err := s.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			return err
+		}
+	}
+	msg.IsFinal = true
+	msg.SpeechFinal = true
+	msg.FromFinalize = fromFinalize
+	data, _ := json.Marshal(msg)
+	return s.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// roundSeconds keeps word timings to milliseconds, like Deepgram's
+func roundSeconds(seconds float64) float64 {
+	return math.Round(seconds*1000) / 1000
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== SESSION NONCES ==========
+
+// maxSessionNonceLength bounds the session_nonce clients send; a UUID fits
+// several times over
+const maxSessionNonceLength = 128
+
+// sessionNonce returns the session_nonce query parameter, which clients keep
+// the same across retries of one transcription session. ok is false when
+// the nonce is too long.
+func sessionNonce(c echo.Context) (nonce sql.NullString, ok bool) {
+	value := c.QueryParam("session_nonce")
+	if len(value) > maxSessionNonceLength {
+		return nonce, false
+	}
+	return sql.NullString{String: value, Valid: value != ""}, true
+}
+
+// sessionNonceSince is the earliest start of an attempt a retry may reopen,
+// or false when reopening is disabled
+func sessionNonceSince() (time.Time, bool) {
+	window := config.Duration("SESSION_NONCE_WINDOW")
+	if window <= 0 {
+		return time.Time{}, false
+	}
+	return time.Now().Add(-window), true
+}
+
+// openTranscriptionLog creates the log of a session, or reopens the log of
+// an earlier attempt with the same session nonce whose connection failed
+// before any audio was sent
+func openTranscriptionLog(ctx context.Context, queries *sqlc.Queries, arg sqlc.CreateTranscriptionLogParams) (sqlc.TranscriptionLog, error) {
+	if since, ok := sessionNonceSince(); ok && arg.SessionNonce.Valid {
+		txLog, err := queries.RetryTranscriptionLog(ctx, sqlc.RetryTranscriptionLogParams{
+			DeepgramParams: arg.DeepgramParams,
+			ClientIp:       arg.ClientIp,
+			RedactionAudit: arg.RedactionAudit,
+			ApiKeyID:       arg.ApiKeyID,
+			SessionNonce:   arg.SessionNonce,
+			Since:          since,
+		})
+		if err != sql.ErrNoRows {
+			return txLog, err
+		}
+	}
+	return queries.CreateTranscriptionLog(ctx, arg)
+}
+
+// openTrialUsageLog is openTranscriptionLog for trial sessions
+func openTrialUsageLog(ctx context.Context, queries *sqlc.Queries, arg sqlc.CreateTrialUsageLogParams) (sqlc.TrialUsage, error) {
+	if since, ok := sessionNonceSince(); ok && arg.SessionNonce.Valid {
+		usageLog, err := queries.RetryTrialUsageLog(ctx, sqlc.RetryTrialUsageLogParams{
+			DeepgramParams: arg.DeepgramParams,
+			ClientIp:       arg.ClientIp,
+			RedactionAudit: arg.RedactionAudit,
+			TrialKeyID:     arg.TrialKeyID,
+			SessionNonce:   arg.SessionNonce,
+			Since:          since,
+		})
+		if err != sql.ErrNoRows {
+			return usageLog, err
+		}
+	}
+	return queries.CreateTrialUsageLog(ctx, arg)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionTracker keeps track of live WebSocket proxy sessions so the server
+// can wait for them to finish during shutdown. Hijacked WebSocket connections
+// are not tracked by http.Server.Shutdown, so this fills that gap.
+type SessionTracker struct {
+	mu       sync.Mutex
+	nextID   uint64
+	sessions map[uint64]trackedSession
+	draining bool
+	idle     chan struct{}
+}
+
+// trackedSession is a live session and the function that closes it. The tag
+// names what the session belongs to (e.g. "api_key:<id>") so an admin
+// action can end every session of a key.
+type trackedSession struct {
+	tag     string
+	closeFn func(code CloseCode)
+	started time.Time
+}
+
+// Sessions is the process-wide session tracker used by all proxy handlers
+var Sessions = NewSessionTracker()
+
+// apiKeySessionTag is the tracker tag of sessions opened with an API key
+func apiKeySessionTag(id uuid.UUID) string {
+	return "api_key:" + id.String()
+}
+
+// trialKeySessionTag is the tracker tag of sessions opened with a trial key
+func trialKeySessionTag(id uuid.UUID) string {
+	return "trial_key:" + id.String()
+}
+
+// NewSessionTracker creates an empty session tracker
+func NewSessionTracker() *SessionTracker {
+	return &SessionTracker{
+		sessions: make(map[uint64]trackedSession),
+	}
+}
+
+// Add registers a session, its tag and its close function, and announces it
+// on the event bus. It returns false if the server is draining and new
+// sessions should be refused.
+func (t *SessionTracker) Add(tag string, closeFn func(code CloseCode)) (uint64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.draining {
+		return 0, false
+	}
+
+	t.nextID++
+	started := time.Now()
+	t.sessions[t.nextID] = trackedSession{tag: tag, closeFn: closeFn, started: started}
+	Events.Publish(Event{Type: EventSessionStarted, Time: started, Kind: sessionKind(tag), Tag: tag})
+	return t.nextID, true
+}
+
+// Remove unregisters a finished session
+func (t *SessionTracker) Remove(id uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.sessions[id]
+	if !ok {
+		return
+	}
+	delete(t.sessions, id)
+	Events.Publish(Event{
+		Type:            EventSessionEnded,
+		Kind:            sessionKind(s.tag),
+		Tag:             s.tag,
+		DurationSeconds: time.Since(s.started).Seconds(),
+	})
+
+	if len(t.sessions) == 0 && t.idle != nil {
+		close(t.idle)
+		t.idle = nil
+	}
+}
+
+// Count returns the number of live sessions
+func (t *SessionTracker) Count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.sessions)
+}
+
+// CloseTagged closes every live session with the given tag and returns how
+// many were closed
+func (t *SessionTracker) CloseTagged(tag string, code CloseCode) int {
+	t.mu.Lock()
+	var closers []func(CloseCode)
+	for _, s := range t.sessions {
+		if s.tag == tag {
+			closers = append(closers, s.closeFn)
+		}
+	}
+	t.mu.Unlock()
+
+	for _, closeFn := range closers {
+		closeFn(code)
+	}
+	return len(closers)
+}
+
+// Draining reports whether the tracker has stopped accepting new sessions
+func (t *SessionTracker) Draining() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.draining
+}
+
+// Drain stops accepting new sessions and waits until all live sessions have
+// finished or ctx is done. Sessions still running when ctx expires are closed
+// with the given close code. It returns the number of sessions that were
+// forced closed.
+func (t *SessionTracker) Drain(ctx context.Context, code CloseCode) int {
+	t.mu.Lock()
+	t.draining = true
+	if len(t.sessions) == 0 {
+		t.mu.Unlock()
+		return 0
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return 0
+	case <-ctx.Done():
+	}
+
+	t.mu.Lock()
+	closers := make([]func(CloseCode), 0, len(t.sessions))
+	for _, s := range t.sessions {
+		closers = append(closers, s.closeFn)
+	}
+	t.mu.Unlock()
+
+	for _, closeFn := range closers {
+		closeFn(code)
+	}
+
+	// Give sessions a moment to finalize their logs after being closed
+	select {
+	case <-idle:
+	case <-time.After(5 * time.Second):
+	}
+
+	return len(closers)
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== RUNTIME SETTINGS ==========
+
+// SettingsSync applies the runtime setting overrides admins stored in the
+// database, so a change made through any server process reaches every
+// other within CONFIG_RELOAD_INTERVAL
+type SettingsSync struct {
+	queries *sqlc.Queries
+}
+
+// NewSettingsSync creates a sync; Run must be started to apply overrides
+func NewSettingsSync(db *sql.DB) *SettingsSync {
+	return &SettingsSync{queries: sqlc.New(db)}
+}
+
+// Run applies the overrides every CONFIG_RELOAD_INTERVAL until ctx is
+// cancelled
+func (s *SettingsSync) Run(ctx context.Context) {
+	applySettingOverrides(ctx, s.queries)
+	ticker := time.NewTicker(config.Duration("CONFIG_RELOAD_INTERVAL"))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			applySettingOverrides(ctx, s.queries)
+		}
+	}
+}
+
+// applySettingOverrides makes the stored overrides the values of the
+// runtime settings. Overrides are left as they are while the database
+// can't be read.
+func applySettingOverrides(ctx context.Context, queries *sqlc.Queries) []sqlc.SettingOverride {
+	overrides, err := queries.ListSettingOverrides(ctx)
+	if err != nil {
+		log.Printf("[Settings] Failed to load setting overrides: %v", err)
+		return nil
+	}
+	values := make(map[string]string, len(overrides))
+	for _, o := range overrides {
+		values[o.Name] = o.Value
+	}
+	config.ApplyOverrides(values)
+	return overrides
+}
+
+// RuntimeSettingResponse is a setting admins can change without a restart.
+// Values are typed by Kind; durations are strings like "30s".
+type RuntimeSettingResponse struct {
+	Name        string      `json:"name"`
+	Kind        string      `json:"kind"`
+	Description string      `json:"description"`
+	Value       interface{} `json:"value"`
+	// Environment is the value without the override, which clearing it
+	// goes back to
+	Environment interface{} `json:"environment"`
+	Source      string      `json:"source"`
+	UpdatedAt   *string     `json:"updated_at"`
+	UpdatedBy   *string     `json:"updated_by"`
+}
+
+// ListRuntimeSettings lists the settings admins can change without a
+// restart with their current values
+func (h *AdminHandler) ListRuntimeSettings(c echo.Context) error {
+	overrides, err := h.queries.ListSettingOverrides(context.Background())
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	return c.JSON(http.StatusOK, toRuntimeSettingsResponse(overrides))
+}
+
+// UpdateRuntimeSettingsRequest sets runtime settings by name. A null value
+// clears the override, going back to the environment's value.
+type UpdateRuntimeSettingsRequest struct {
+	Settings map[string]json.RawMessage `json:"settings"`
+	Reason   string                     `json:"reason"`
+}
+
+// UpdateRuntimeSettings overrides runtime settings (admin only). Every
+// value is validated first, so either all of them change or none. The
+// change takes effect here at once and on other processes within
+// CONFIG_RELOAD_INTERVAL.
+func (h *AdminHandler) UpdateRuntimeSettings(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	var req UpdateRuntimeSettingsRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if len(req.Settings) == 0 {
+		return apiError(http.StatusBadRequest, "no settings given")
+	}
+
+	values := make(map[string]*string, len(req.Settings))
+	problems := map[string]string{}
+	for name, raw := range req.Settings {
+		value, ok := settingValue(raw)
+		if !ok {
+			problems[name] = "must be a string, number, boolean or null"
+			continue
+		}
+		if value == nil && !config.IsRuntime(name) {
+			problems[name] = "not a runtime setting"
+			continue
+		}
+		if value != nil {
+			if err := config.ValidateOverride(name, *value); err != nil {
+				problems[name] = err.Error()
+				continue
+			}
+		}
+		values[name] = value
+	}
+	if len(problems) > 0 {
+		return newAPIError(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid settings",
+			Details: problems,
+		})
+	}
+
+	ctx := context.Background()
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	defer tx.Rollback()
+	queries := h.queries.WithTx(tx)
+
+	metadata := make(map[string]string, len(values))
+	for name, value := range values {
+		if value == nil {
+			err = queries.DeleteSettingOverride(ctx, name)
+			metadata[name] = "(environment)"
+		} else {
+			err = queries.SetSettingOverride(ctx, sqlc.SetSettingOverrideParams{
+				Name:      name,
+				Value:     *value,
+				UpdatedBy: uuid.NullUUID{UUID: claims.UserID, Valid: true},
+			})
+			metadata[name] = *value
+		}
+		if err != nil {
+			log.Printf("[Settings] Failed to store override of %s: %v", name, err)
+			return apiError(http.StatusInternalServerError, "failed to update settings")
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to update settings")
+	}
+
+	overrides := applySettingOverrides(ctx, h.queries)
+	recordAuditEvent(ctx, h.queries, c, auditRuntimeSettings, "settings", "runtime", strings.TrimSpace(req.Reason), metadata)
+	log.Printf("[Admin] Runtime settings changed by %s: %v", claims.UserID, metadata)
+
+	return c.JSON(http.StatusOK, toRuntimeSettingsResponse(overrides))
+}
+
+func toRuntimeSettingsResponse(overrides []sqlc.SettingOverride) []RuntimeSettingResponse {
+	byName := make(map[string]sqlc.SettingOverride, len(overrides))
+	for _, o := range overrides {
+		byName[o.Name] = o
+	}
+
+	entries := config.RuntimeSettings()
+	resp := make([]RuntimeSettingResponse, 0, len(entries))
+	for _, e := range entries {
+		item := RuntimeSettingResponse{
+			Name:        e.Name,
+			Kind:        string(e.Kind),
+			Description: e.Description,
+			Value:       typedSettingValue(e.Kind, e.Value),
+			Environment: typedSettingValue(e.Kind, config.WithoutOverride(e.Name).Value),
+			Source:      string(e.Source),
+		}
+		if o, ok := byName[e.Name]; ok && e.Source == config.SourceDatabase {
+			t := o.UpdatedAt.Format(time.RFC3339)
+			item.UpdatedAt = &t
+			if o.UpdatedBy.Valid {
+				id := o.UpdatedBy.UUID.String()
+				item.UpdatedBy = &id
+			}
+		}
+		resp = append(resp, item)
+	}
+	sort.Slice(resp, func(i, j int) bool { return resp[i].Name < resp[j].Name })
+	return resp
+}
+
+// settingValue turns a JSON value into a setting's text; nil for null
+func settingValue(raw json.RawMessage) (*string, bool) {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, false
+	}
+	var value string
+	switch v := v.(type) {
+	case nil:
+		return nil, true
+	case string:
+		value = strings.TrimSpace(v)
+	case float64, bool:
+		value = string(raw)
+	default:
+		return nil, false
+	}
+	return &value, true
+}
+
+// typedSettingValue returns a setting's text as its kind's JSON type
+func typedSettingValue(kind config.Kind, value string) interface{} {
+	switch kind {
+	case config.KindInt:
+		if v, err := strconv.Atoi(value); err == nil {
+			return v
+		}
+	case config.KindFloat:
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	case config.KindBool:
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v
+		}
+	}
+	return value
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== SIGNUP POLICY ==========
+
+// SignupPolicyResponse is the signup policy as shown to admins
+type SignupPolicyResponse struct {
+	Enabled             bool     `json:"enabled"`
+	InviteCode          *string  `json:"invite_code"`
+	AllowedEmailDomains []string `json:"allowed_email_domains"`
+	UpdatedAt           string   `json:"updated_at"`
+}
+
+// PublicSignupPolicyResponse tells the signup form what to ask for without
+// revealing the invite code
+type PublicSignupPolicyResponse struct {
+	Enabled             bool     `json:"enabled"`
+	InviteCodeRequired  bool     `json:"invite_code_required"`
+	AllowedEmailDomains []string `json:"allowed_email_domains"`
+	Invited             bool     `json:"invited"` // The ?invite= code is a usable invite link
+}
+
+// UpdateSignupPolicyRequest replaces the signup policy. An empty invite_code
+// or allowed_email_domains lifts that restriction.
+type UpdateSignupPolicyRequest struct {
+	Enabled             *bool    `json:"enabled"`
+	InviteCode          string   `json:"invite_code"`
+	AllowedEmailDomains []string `json:"allowed_email_domains"`
+	Reason              string   `json:"reason"`
+}
+
+// signupRefusal checks a signup against the policy. It returns the error to
+// respond with 403 Forbidden, or nil when the signup may go ahead.
+func signupRefusal(policy sqlc.SignupPolicy, email, inviteCode string) *ErrorResponse {
+	if !policy.Enabled {
+		return &ErrorResponse{Error: "signups are disabled"}
+	}
+
+	if policy.InviteCode.Valid {
+		if inviteCode == "" {
+			return &ErrorResponse{Error: "invite code required"}
+		}
+		if subtle.ConstantTimeCompare([]byte(inviteCode), []byte(policy.InviteCode.String)) != 1 {
+			return &ErrorResponse{Error: "invalid invite code"}
+		}
+	}
+
+	if len(policy.AllowedEmailDomains) > 0 && !contains(policy.AllowedEmailDomains, emailDomain(email)) {
+		return &ErrorResponse{
+			Error:   "email domain not allowed",
+			Details: map[string]string{"email": "must be an address at " + strings.Join(policy.AllowedEmailDomains, ", ")},
+		}
+	}
+	return nil
+}
+
+// checkSignupPolicy applies the signup policy unless no account exists yet;
+// the first account becomes the admin and is always allowed
+func checkSignupPolicy(ctx context.Context, queries *sqlc.Queries, email, inviteCode string) (*ErrorResponse, error) {
+	userCount, err := queries.CountUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if userCount == 0 {
+		return nil, nil
+	}
+
+	policy, err := queries.GetSignupPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return signupRefusal(policy, email, strings.TrimSpace(inviteCode)), nil
+}
+
+// emailDomain is the lowercased domain of an email address
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
+
+// normalizeEmailDomain accepts "company.com" or "@company.com" and returns it
+// lowercased without the "@"
+func normalizeEmailDomain(domain string) (string, bool) {
+	domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+	if domain == "" || !strings.Contains(domain, ".") || strings.ContainsAny(domain, "@ ") {
+		return "", false
+	}
+	return domain, true
+}
+
+// GetSignupPolicy returns what the signup form must ask for, and whether
+// the invite link in ?invite= lets its holder past it
+func (h *AuthHandler) GetSignupPolicy(c echo.Context) error {
+	ctx := context.Background()
+	policy, err := h.queries.GetSignupPolicy(ctx)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	invite, err := findInvite(ctx, h.queries, c.QueryParam("invite"))
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	return c.JSON(http.StatusOK, PublicSignupPolicyResponse{
+		Enabled:             policy.Enabled,
+		InviteCodeRequired:  policy.InviteCode.Valid,
+		AllowedEmailDomains: policy.AllowedEmailDomains,
+		Invited:             invite != nil && inviteUsable(*invite, time.Now()),
+	})
+}
+
+// GetSignupPolicy returns the signup policy (admin only)
+func (h *AdminHandler) GetSignupPolicy(c echo.Context) error {
+	policy, err := h.queries.GetSignupPolicy(context.Background())
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	return c.JSON(http.StatusOK, toSignupPolicyResponse(policy))
+}
+
+// UpdateSignupPolicy replaces the signup policy (admin only)
+func (h *AdminHandler) UpdateSignupPolicy(c echo.Context) error {
+	var req UpdateSignupPolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if req.Enabled == nil {
+		return apiError(http.StatusBadRequest, "enabled is required")
+	}
+
+	domains := []string{}
+	for _, d := range req.AllowedEmailDomains {
+		domain, ok := normalizeEmailDomain(d)
+		if !ok {
+			return newAPIError(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid email domain",
+				Details: map[string]string{"allowed_email_domains": d},
+			})
+		}
+		if !contains(domains, domain) {
+			domains = append(domains, domain)
+		}
+	}
+
+	inviteCode := strings.TrimSpace(req.InviteCode)
+
+	policy, err := h.queries.UpdateSignupPolicy(context.Background(), sqlc.UpdateSignupPolicyParams{
+		Enabled:             *req.Enabled,
+		InviteCode:          sql.NullString{String: inviteCode, Valid: inviteCode != ""},
+		AllowedEmailDomains: domains,
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to update signup policy")
+	}
+
+	recordAuditEvent(context.Background(), h.queries, c, auditSignupPolicy, "settings", "signup", strings.TrimSpace(req.Reason), map[string]string{
+		"enabled":               strconv.FormatBool(policy.Enabled),
+		"invite_code_required":  strconv.FormatBool(policy.InviteCode.Valid),
+		"allowed_email_domains": strings.Join(policy.AllowedEmailDomains, ","),
+	})
+
+	return c.JSON(http.StatusOK, toSignupPolicyResponse(policy))
+}
+
+func toSignupPolicyResponse(policy sqlc.SignupPolicy) SignupPolicyResponse {
+	resp := SignupPolicyResponse{
+		Enabled:             policy.Enabled,
+		AllowedEmailDomains: policy.AllowedEmailDomains,
+		UpdatedAt:           policy.UpdatedAt.Format(time.RFC3339),
+	}
+	if policy.InviteCode.Valid {
+		resp.InviteCode = &policy.InviteCode.String
+	}
+	if resp.AllowedEmailDomains == nil {
+		resp.AllowedEmailDomains = []string{}
+	}
+	return resp
+}
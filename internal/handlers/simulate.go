@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== LIMIT SIMULATION ==========
+
+// Limit simulation replays recent sessions against hypothetical limits, so
+// admins can see who a change would have affected before applying it. Only
+// sessions that were admitted are logged, so the simulation can show what
+// tighter limits would block but not what looser ones would have let in.
+
+const (
+	defaultSimulationDays = 30
+	maxSimulationDays     = 90
+)
+
+// simulationPlans are the plans with their own concurrency limit, in report
+// order
+var simulationPlans = []string{"trial", "user", "admin"}
+
+// SimulateLimitsRequest is the request for simulating limits. Omitted
+// limits keep their current values.
+type SimulateLimitsRequest struct {
+	Days        int                       `json:"days"`
+	TrialPreset string                    `json:"trial_preset"`
+	Trial       *UpdateTrialLimitsRequest `json:"trial"`
+	Concurrency map[string]int            `json:"concurrency"` // Plan to limit; zero means unlimited
+}
+
+// TrialSimulationResponse reports how a trial preset's limits would have
+// affected the sessions of its keys
+type TrialSimulationResponse struct {
+	Preset                    string `json:"preset"`
+	MaxDurationSeconds        int    `json:"max_duration_seconds"`
+	MaxSessions               int    `json:"max_sessions"`
+	MaxSessionDurationSeconds int    `json:"max_session_duration_seconds"`
+	ExpiryDays                int    `json:"expiry_days"`
+
+	KeysEvaluated     int     `json:"keys_evaluated"`
+	SessionsEvaluated int     `json:"sessions_evaluated"`
+	DurationSeconds   float64 `json:"duration_seconds"`
+
+	BlockedKeys         int     `json:"blocked_keys"`
+	BlockedSessions     int     `json:"blocked_sessions"`
+	BlockedByDuration   int     `json:"blocked_by_duration"`
+	BlockedBySessions   int     `json:"blocked_by_sessions"`
+	BlockedByExpiry     int     `json:"blocked_by_expiry"`
+	CutShortSessions    int     `json:"cut_short_sessions"`
+	LostDurationSeconds float64 `json:"lost_duration_seconds"`
+}
+
+// ConcurrencySimulationResponse reports how a plan's concurrency limit would
+// have affected its users (or trial keys). Blocked sessions would have been
+// queued when CONCURRENCY_QUEUE_TIMEOUT is set, and rejected otherwise.
+type ConcurrencySimulationResponse struct {
+	Plan                   string `json:"plan"`
+	Limit                  int    `json:"limit"`
+	OwnersEvaluated        int    `json:"owners_evaluated"`
+	SessionsEvaluated      int    `json:"sessions_evaluated"`
+	PeakConcurrentSessions int    `json:"peak_concurrent_sessions"`
+	BlockedOwners          int    `json:"blocked_owners"`
+	BlockedSessions        int    `json:"blocked_sessions"`
+}
+
+// SimulateLimitsResponse is the result of a limit simulation
+type SimulateLimitsResponse struct {
+	Days        int                             `json:"days"`
+	PeriodStart string                          `json:"period_start"`
+	PeriodEnd   string                          `json:"period_end"`
+	Trial       TrialSimulationResponse         `json:"trial"`
+	Concurrency []ConcurrencySimulationResponse `json:"concurrency"`
+}
+
+// SimulateLimits reports how many trial keys, users and sessions of the last
+// days would have been blocked by the given trial preset and concurrency
+// limits (admin only). Nothing is changed.
+func (h *AdminHandler) SimulateLimits(c echo.Context) error {
+	var req SimulateLimitsRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if req.Days == 0 {
+		req.Days = defaultSimulationDays
+	}
+	if req.Days < 1 || req.Days > maxSimulationDays {
+		return apiError(http.StatusBadRequest, fmt.Sprintf("days must be between 1 and %d", maxSimulationDays))
+	}
+	if req.TrialPreset == "" {
+		req.TrialPreset = defaultTrialPreset
+	}
+
+	limits := make(map[string]int, len(simulationPlans))
+	for _, plan := range simulationPlans {
+		limits[plan] = planConcurrencyLimit(plan)
+	}
+	for plan, limit := range req.Concurrency {
+		if _, ok := limits[plan]; !ok {
+			return apiError(http.StatusBadRequest, "concurrency plans must be trial, user or admin")
+		}
+		if limit < 0 {
+			return apiError(http.StatusBadRequest, "concurrency limits must not be negative")
+		}
+		limits[plan] = limit
+	}
+
+	ctx := context.Background()
+
+	preset, err := h.queries.GetTrialPreset(ctx, req.TrialPreset)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "preset not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	trial := TrialSimulationResponse{
+		Preset:                    preset.Name,
+		MaxDurationSeconds:        int(preset.MaxDurationSeconds),
+		MaxSessions:               int(preset.MaxSessions),
+		MaxSessionDurationSeconds: int(preset.MaxSessionDurationSeconds),
+		ExpiryDays:                int(preset.ExpiryDays),
+	}
+	if req.Trial != nil {
+		hypothetical := TrialPresetRequest{
+			MaxDurationSeconds:        req.Trial.MaxDurationSeconds,
+			MaxSessions:               req.Trial.MaxSessions,
+			MaxSessionDurationSeconds: req.Trial.MaxSessionDurationSeconds,
+			ExpiryDays:                req.Trial.ExpiryDays,
+		}
+		if msg := hypothetical.validate(); msg != "" {
+			return apiError(http.StatusBadRequest, msg)
+		}
+		trial.MaxDurationSeconds = req.Trial.MaxDurationSeconds
+		trial.MaxSessions = req.Trial.MaxSessions
+		trial.MaxSessionDurationSeconds = req.Trial.MaxSessionDurationSeconds
+		trial.ExpiryDays = req.Trial.ExpiryDays
+	}
+
+	end := time.Now()
+	start := end.AddDate(0, 0, -req.Days)
+
+	trialSessions, err := h.queries.ListTrialSessionsForSimulation(ctx, sqlc.ListTrialSessionsForSimulationParams{
+		Preset:    preset.Name,
+		StartDate: start,
+		EndDate:   end,
+	})
+	if err != nil {
+		log.Printf("[Admin] Failed to list trial sessions for simulation: %v", err)
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	simulateTrialLimits(&trial, trialSessions, start)
+
+	sessions, err := h.queries.ListSessionsForSimulation(ctx, sqlc.ListSessionsForSimulationParams{
+		StartDate: start,
+		EndDate:   end,
+	})
+	if err != nil {
+		log.Printf("[Admin] Failed to list sessions for simulation: %v", err)
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	return c.JSON(http.StatusOK, SimulateLimitsResponse{
+		Days:        req.Days,
+		PeriodStart: start.Format(time.RFC3339),
+		PeriodEnd:   end.Format(time.RFC3339),
+		Trial:       trial,
+		Concurrency: simulateConcurrencyLimits(limits, sessions),
+	})
+}
+
+// simulateTrialLimits replays each key's sessions against the limits in
+// report, the way TrialDeepgramProxy enforces them: a session is rejected
+// once the key is expired or out of sessions or duration, and otherwise
+// ends at the per-session limit or when the quota runs out. Sessions before
+// start count toward the quota but not toward the report.
+func simulateTrialLimits(report *TrialSimulationResponse, sessions []sqlc.ListTrialSessionsForSimulationRow, start time.Time) {
+	var (
+		keyID         uuid.UUID
+		usedSessions  int
+		usedDuration  float64
+		keyEvaluated  bool
+		keyBlocked    bool
+		maxDuration   = float64(report.MaxDurationSeconds)
+		maxPerSession = float64(report.MaxSessionDurationSeconds)
+	)
+	for _, s := range sessions {
+		if s.TrialKeyID != keyID {
+			keyID = s.TrialKeyID
+			usedSessions, usedDuration = 0, 0
+			keyEvaluated, keyBlocked = false, false
+		}
+
+		duration := parseDecimalString(s.DurationSeconds)
+		inPeriod := !s.StartedAt.Before(start)
+		if inPeriod {
+			if !keyEvaluated {
+				keyEvaluated = true
+				report.KeysEvaluated++
+			}
+			report.SessionsEvaluated++
+			report.DurationSeconds += duration
+		}
+
+		// Keys without a creation time can't be checked for expiry
+		untilExpiry := math.Inf(1)
+		if s.KeyCreatedAt.Valid {
+			expiresAt := s.KeyCreatedAt.Time.AddDate(0, 0, report.ExpiryDays)
+			untilExpiry = expiresAt.Sub(s.StartedAt).Seconds()
+		}
+
+		var blockedBy *int
+		switch {
+		case untilExpiry <= 0:
+			blockedBy = &report.BlockedByExpiry
+		case usedSessions >= report.MaxSessions:
+			blockedBy = &report.BlockedBySessions
+		case usedDuration >= maxDuration:
+			blockedBy = &report.BlockedByDuration
+		}
+		if blockedBy != nil {
+			if inPeriod {
+				*blockedBy++
+				report.BlockedSessions++
+				report.LostDurationSeconds += duration
+				if !keyBlocked {
+					keyBlocked = true
+					report.BlockedKeys++
+				}
+			}
+			continue
+		}
+
+		allowed := math.Min(maxPerSession, math.Min(maxDuration-usedDuration, untilExpiry))
+		if duration > allowed {
+			if inPeriod {
+				report.CutShortSessions++
+				report.LostDurationSeconds += duration - allowed
+			}
+			duration = allowed
+		}
+		usedSessions++
+		usedDuration += duration
+	}
+	report.DurationSeconds = roundSeconds(report.DurationSeconds)
+	report.LostDurationSeconds = roundSeconds(report.LostDurationSeconds)
+}
+
+// simulateConcurrencyLimits replays sessions in start order against the
+// per-plan limits. A session is blocked when its owner already holds the
+// plan's limit of admitted sessions; blocked sessions hold no slot.
+func simulateConcurrencyLimits(limits map[string]int, sessions []sqlc.ListSessionsForSimulationRow) []ConcurrencySimulationResponse {
+	reports := make(map[string]*ConcurrencySimulationResponse, len(simulationPlans))
+	responses := make([]ConcurrencySimulationResponse, len(simulationPlans))
+	for i, plan := range simulationPlans {
+		responses[i] = ConcurrencySimulationResponse{Plan: plan, Limit: limits[plan]}
+		reports[plan] = &responses[i]
+	}
+
+	// End times of each owner's logged and admitted sessions
+	type ownerState struct {
+		logged   []time.Time
+		admitted []time.Time
+		blocked  bool
+	}
+	owners := make(map[string]*ownerState)
+
+	for _, s := range sessions {
+		report, ok := reports[s.Plan]
+		if !ok {
+			report = reports["user"]
+		}
+		key := report.Plan + ":" + s.OwnerID.String()
+		owner, ok := owners[key]
+		if !ok {
+			owner = &ownerState{}
+			owners[key] = owner
+			report.OwnersEvaluated++
+		}
+		report.SessionsEvaluated++
+
+		owner.logged = append(stillRunning(owner.logged, s.StartedAt), s.EndedAt)
+		if len(owner.logged) > report.PeakConcurrentSessions {
+			report.PeakConcurrentSessions = len(owner.logged)
+		}
+
+		owner.admitted = stillRunning(owner.admitted, s.StartedAt)
+		if report.Limit > 0 && len(owner.admitted) >= report.Limit {
+			report.BlockedSessions++
+			if !owner.blocked {
+				owner.blocked = true
+				report.BlockedOwners++
+			}
+			continue
+		}
+		owner.admitted = append(owner.admitted, s.EndedAt)
+	}
+	return responses
+}
+
+// stillRunning drops the end times at or before t
+func stillRunning(ends []time.Time, t time.Time) []time.Time {
+	running := ends[:0]
+	for _, end := range ends {
+		if end.After(t) {
+			running = append(running, end)
+		}
+	}
+	return running
+}
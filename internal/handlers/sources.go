@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+)
+
+// ========== TRIAL SOURCES ==========
+
+// TrialSourceRequest is the request for adding a trial source
+type TrialSourceRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// TrialSourceResponse is an install channel trials can be attributed to
+type TrialSourceResponse struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// TrialSourceUsageResponse is one source's row in the trial usage summary.
+// Source is null for keys provisioned without one.
+type TrialSourceUsageResponse struct {
+	Source               *string `json:"source"`
+	TotalTrialKeys       int64   `json:"total_trial_keys"`
+	ActiveTrialKeys      int64   `json:"active_trial_keys"`
+	TotalSessions        int64   `json:"total_sessions"`
+	TotalDurationSeconds float64 `json:"total_duration_seconds"`
+}
+
+// ListTrialSources returns the sources trial provisioning accepts (admin only)
+func (h *AdminHandler) ListTrialSources(c echo.Context) error {
+	sources, err := h.queries.ListTrialSources(context.Background())
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	resp := make([]TrialSourceResponse, len(sources))
+	for i, s := range sources {
+		resp[i] = toTrialSourceResponse(s)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// CreateTrialSource adds a source the desktop app can send when
+// provisioning trial keys (admin only)
+func (h *AdminHandler) CreateTrialSource(c echo.Context) error {
+	var req TrialSourceRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	// Sources share the slug format of preset names
+	if !presetNamePattern.MatchString(req.Name) {
+		return newAPIError(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid source name",
+			Details: map[string]string{"name": "lowercase letters, digits and dashes, at most 64 characters"},
+		})
+	}
+
+	source, err := h.queries.CreateTrialSource(context.Background(), sqlc.CreateTrialSourceParams{
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return apiError(http.StatusConflict, "source already exists")
+		}
+		log.Printf("[Admin] Failed to create trial source %s: %v", req.Name, err)
+		return apiError(http.StatusInternalServerError, "failed to create source")
+	}
+
+	log.Printf("[Admin] Created trial source %s", source.Name)
+	return c.JSON(http.StatusCreated, toTrialSourceResponse(source))
+}
+
+// DeleteTrialSource removes a source no trial key was provisioned with
+// (admin only), so attribution of existing keys is never lost
+func (h *AdminHandler) DeleteTrialSource(c echo.Context) error {
+	name := c.Param("name")
+	ctx := context.Background()
+
+	if _, err := h.queries.GetTrialSource(ctx, name); err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "source not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	keys, err := h.queries.CountTrialKeysForSource(ctx, sql.NullString{String: name, Valid: true})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if keys > 0 {
+		return newAPIError(http.StatusConflict, ErrorResponse{
+			Error:   "source is in use by trial keys",
+			Details: map[string]string{"trial_keys": strconv.FormatInt(keys, 10)},
+		})
+	}
+
+	if err := h.queries.DeleteTrialSource(ctx, name); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to delete source")
+	}
+
+	log.Printf("[Admin] Deleted trial source %s", name)
+	return c.JSON(http.StatusOK, map[string]string{"message": "source deleted"})
+}
+
+func toTrialSourceResponse(s sqlc.TrialSource) TrialSourceResponse {
+	return TrialSourceResponse{
+		Name:        s.Name,
+		Description: s.Description,
+		CreatedAt:   s.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func toTrialSourceUsageResponses(rows []sqlc.GetTrialSourceUsageSummaryRow) []TrialSourceUsageResponse {
+	resp := make([]TrialSourceUsageResponse, len(rows))
+	for i, r := range rows {
+		resp[i] = TrialSourceUsageResponse{
+			TotalTrialKeys:       r.TotalTrialKeys,
+			ActiveTrialKeys:      r.ActiveTrialKeys,
+			TotalSessions:        r.TotalSessions,
+			TotalDurationSeconds: parseDecimalStringAdmin(r.TotalDurationSeconds),
+		}
+		if r.Source.Valid {
+			source := r.Source.String
+			resp[i].Source = &source
+		}
+	}
+	return resp
+}
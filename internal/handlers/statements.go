@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/statement"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== USAGE STATEMENTS ==========
+
+var errInvalidStatementPeriod = errors.New("invalid period, expected YYYY-MM or current_cycle")
+
+// GenerateStatementsRequest is the request body for bulk statement generation.
+// Without user_ids, a statement is generated for every user with usage in
+// the period.
+type GenerateStatementsRequest struct {
+	Period  string   `json:"period"`
+	UserIDs []string `json:"user_ids"`
+}
+
+// statementPeriod resolves a statement period: a calendar month (YYYY-MM) or
+// the current billing cycle, both in the user's timezone
+func statementPeriod(period string, user sqlc.User) (usagePeriod, error) {
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	if period == periodCurrentCycle {
+		return cyclePeriod(billingCycleAnchor(user), time.Now(), loc), nil
+	}
+
+	month, err := time.ParseInLocation("2006-01", period, loc)
+	if err != nil {
+		return usagePeriod{}, errInvalidStatementPeriod
+	}
+	return monthPeriod(month, loc), nil
+}
+
+// buildStatement gathers a user's per-key usage for the period
+func buildStatement(ctx context.Context, queries *sqlc.Queries, user sqlc.User, period usagePeriod) (statement.Statement, error) {
+	rows, err := queries.GetUserUsageByAPIKey(ctx, sqlc.GetUserUsageByAPIKeyParams{
+		UserID:    user.ID,
+		StartDate: period.Start,
+		EndDate:   period.End,
+	})
+	if err != nil {
+		return statement.Statement{}, err
+	}
+
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if name == "" {
+		name = user.Username
+	}
+
+	s := statement.Statement{
+		Number:         fmt.Sprintf("HW-%s-%s", period.Start.Format("200601"), strings.ToUpper(user.ID.String()[:8])),
+		Issuer:         config.String("STATEMENT_ISSUER"),
+		IssuedAt:       time.Now(),
+		CustomerName:   name,
+		CustomerEmail:  user.Email,
+		PeriodStart:    period.Start,
+		PeriodEnd:      period.End,
+		Timezone:       period.Timezone,
+		Currency:       config.String("STATEMENT_CURRENCY"),
+		PricePerMinute: config.Float("USAGE_PRICE_PER_MINUTE"),
+	}
+	for _, r := range rows {
+		s.Lines = append(s.Lines, statement.Line{
+			Description: fmt.Sprintf("%s (%s...)", r.ApiKeyName, r.KeyPrefix),
+			Sessions:    r.TotalSessions,
+			Seconds:     parseDecimalString(r.TotalDurationSeconds),
+		})
+	}
+	return s, nil
+}
+
+// statementFilename is the download name of a user's statement
+func statementFilename(user sqlc.User, period usagePeriod) string {
+	return fmt.Sprintf("statement-%s-%s.pdf", user.Username, period.Start.Format("2006-01-02"))
+}
+
+// GetStatement renders the authenticated user's usage statement for a month
+// (GET /me/statements/2026-09.pdf) or the current billing cycle
+// (GET /me/statements/current_cycle.pdf)
+func (h *DeepgramHandler) GetStatement(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	param, ok := strings.CutSuffix(c.Param("period"), ".pdf")
+	if !ok {
+		return apiError(http.StatusNotFound, "statements are only available as PDF")
+	}
+
+	ctx := context.Background()
+
+	user, err := h.queries.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	period, err := statementPeriod(param, user)
+	if err != nil {
+		return apiError(http.StatusBadRequest, err.Error())
+	}
+
+	s, err := buildStatement(ctx, h.queries, user, period)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=%q", statementFilename(user, period)))
+	return c.Blob(http.StatusOK, "application/pdf", statement.Render(s))
+}
+
+// GenerateStatements renders the statements of many users for one month and
+// returns them as a ZIP archive (admin only). Users without usage in the
+// period are skipped.
+func (h *AdminHandler) GenerateStatements(c echo.Context) error {
+	var req GenerateStatementsRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	month, err := time.Parse("2006-01", req.Period)
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid period, expected YYYY-MM")
+	}
+
+	ctx := context.Background()
+
+	var userIDs []uuid.UUID
+	if len(req.UserIDs) > 0 {
+		for _, raw := range req.UserIDs {
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				return newAPIError(http.StatusBadRequest, ErrorResponse{Error: "invalid user ID", Details: map[string]string{"user_id": raw}})
+			}
+			userIDs = append(userIDs, id)
+		}
+	} else {
+		// Each statement covers the month in its user's timezone, so look
+		// for usage a day either side of the UTC month
+		userIDs, err = h.queries.ListUsersWithUsage(ctx, sqlc.ListUsersWithUsageParams{
+			StartDate: month.AddDate(0, 0, -1),
+			EndDate:   month.AddDate(0, 1, 1),
+		})
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+	}
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	generated := 0
+
+	for _, id := range userIDs {
+		user, err := h.queries.GetUserByID(ctx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return newAPIError(http.StatusNotFound, ErrorResponse{Error: "user not found", Details: map[string]string{"user_id": id.String()}})
+			}
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+
+		period, _ := statementPeriod(req.Period, user)
+		s, err := buildStatement(ctx, h.queries, user, period)
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+		if len(s.Lines) == 0 {
+			continue
+		}
+
+		w, err := zw.Create(statementFilename(user, period))
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "failed to build archive")
+		}
+		if _, err := w.Write(statement.Render(s)); err != nil {
+			return apiError(http.StatusInternalServerError, "failed to build archive")
+		}
+		generated++
+	}
+
+	if err := zw.Close(); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to build archive")
+	}
+
+	log.Printf("[Admin] Generated %d usage statements for %s", generated, req.Period)
+
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=%q", "statements-"+req.Period+".zip"))
+	return c.Blob(http.StatusOK, "application/zip", archive.Bytes())
+}
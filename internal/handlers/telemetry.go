@@ -0,0 +1,414 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+)
+
+// ========== CLIENT ERROR TELEMETRY ==========
+
+// Report kinds accepted from the desktop app
+var errorReportKinds = []string{"connection", "audio_device", "crash"}
+
+// Triage states an admin can move a report through
+var errorReportStatuses = []string{"new", "triaged", "resolved", "ignored"}
+
+const (
+	maxReportMessageLength = 4000
+	maxReportDetails       = 20
+	maxReportDetailKey     = 64
+	maxReportDetailValue   = 4000
+
+	// How far back a report's timestamp may reach when matching it to a
+	// session log; sessions can run for hours before failing
+	sessionCorrelationWindow = 24 * time.Hour
+)
+
+// TelemetryHandler accepts error reports from the desktop app
+type TelemetryHandler struct {
+	queries *sqlc.Queries
+}
+
+// NewTelemetryHandler creates a new telemetry handler
+func NewTelemetryHandler(db *sql.DB) *TelemetryHandler {
+	return &TelemetryHandler{
+		queries: sqlc.New(db),
+	}
+}
+
+// ClientErrorReportRequest is the body of POST /telemetry/errors
+type ClientErrorReportRequest struct {
+	Kind       string            `json:"kind"`
+	Message    string            `json:"message"`
+	Signature  string            `json:"signature"`
+	CloseCode  *int              `json:"close_code"`
+	AppVersion string            `json:"app_version"`
+	OS         string            `json:"os"`
+	OccurredAt *time.Time        `json:"occurred_at"`
+	Details    map[string]string `json:"details"`
+}
+
+// ClientErrorReportResponse is a stored client error report
+type ClientErrorReportResponse struct {
+	ID                 string            `json:"id"`
+	Kind               string            `json:"kind"`
+	Message            string            `json:"message"`
+	Signature          *string           `json:"signature"`
+	CloseCode          *int32            `json:"close_code"`
+	AppVersion         *string           `json:"app_version"`
+	OS                 *string           `json:"os"`
+	Details            map[string]string `json:"details"`
+	UserID             *string           `json:"user_id"`
+	APIKeyID           *string           `json:"api_key_id"`
+	TrialKeyID         *string           `json:"trial_key_id"`
+	TranscriptionLogID *string           `json:"transcription_log_id"`
+	TrialUsageID       *string           `json:"trial_usage_id"`
+	SessionStatus      *string           `json:"session_status,omitempty"`
+	SessionError       *string           `json:"session_error,omitempty"`
+	Status             string            `json:"status"`
+	OccurredAt         string            `json:"occurred_at"`
+	CreatedAt          string            `json:"created_at"`
+}
+
+// UpdateErrorReportStatusRequest is the request body for triaging a report
+type UpdateErrorReportStatusRequest struct {
+	Status string `json:"status"`
+}
+
+// validate checks the report against the telemetry schema and returns an
+// error message, or "" if the report is valid
+func (r *ClientErrorReportRequest) validate(now time.Time) string {
+	if !contains(errorReportKinds, r.Kind) {
+		return fmt.Sprintf("kind must be one of %v", errorReportKinds)
+	}
+	r.Message = strings.TrimSpace(r.Message)
+	if r.Message == "" {
+		return "message is required"
+	}
+	if len(r.Message) > maxReportMessageLength {
+		return fmt.Sprintf("message must be at most %d characters", maxReportMessageLength)
+	}
+	if len(r.Signature) > 255 {
+		return "signature must be at most 255 characters"
+	}
+	if len(r.AppVersion) > 50 {
+		return "app_version must be at most 50 characters"
+	}
+	if len(r.OS) > 100 {
+		return "os must be at most 100 characters"
+	}
+	if r.CloseCode != nil && (*r.CloseCode < 1000 || *r.CloseCode > 4999) {
+		return "close_code must be a WebSocket close code (1000-4999)"
+	}
+	if len(r.Details) > maxReportDetails {
+		return fmt.Sprintf("details may have at most %d entries", maxReportDetails)
+	}
+	for k, v := range r.Details {
+		if k == "" || len(k) > maxReportDetailKey {
+			return fmt.Sprintf("details keys must be 1-%d characters", maxReportDetailKey)
+		}
+		if len(v) > maxReportDetailValue {
+			return fmt.Sprintf("details values must be at most %d characters", maxReportDetailValue)
+		}
+	}
+	if r.OccurredAt == nil {
+		r.OccurredAt = &now
+	} else if r.OccurredAt.After(now.Add(5 * time.Minute)) {
+		return "occurred_at is in the future"
+	} else if r.OccurredAt.Before(now.Add(-7 * 24 * time.Hour)) {
+		return "occurred_at is more than 7 days old"
+	}
+	return ""
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// TelemetryRateLimiter limits error reports per client IP to
+// TELEMETRY_RATE_LIMIT per minute
+func TelemetryRateLimiter() echo.MiddlewareFunc {
+	perMinute := config.Int("TELEMETRY_RATE_LIMIT")
+	return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Store: middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Limit(float64(perMinute) / 60),
+			Burst:     perMinute,
+			ExpiresIn: 10 * time.Minute,
+		}),
+		IdentifierExtractor: func(c echo.Context) (string, error) {
+			return c.RealIP(), nil
+		},
+		DenyHandler: func(c echo.Context, identifier string, err error) error {
+			return apiError(http.StatusTooManyRequests, "too many error reports, slow down")
+		},
+	})
+}
+
+// ReportError stores an error report from the desktop app. An API key
+// (live or trial) in X-API-Key is optional; when present the report is
+// linked to the key and to the session log it most likely belongs to.
+func (h *TelemetryHandler) ReportError(c echo.Context) error {
+	var req ClientErrorReportRequest
+	decoder := json.NewDecoder(c.Request().Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		return newAPIError(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid request body",
+			Details: map[string]string{"reason": err.Error()},
+		})
+	}
+	if msg := req.validate(time.Now()); msg != "" {
+		return apiError(http.StatusBadRequest, msg)
+	}
+
+	ctx := context.Background()
+
+	details, _ := json.Marshal(req.Details)
+	if req.Details == nil {
+		details = []byte("{}")
+	}
+	params := sqlc.CreateClientErrorReportParams{
+		Kind:       req.Kind,
+		Message:    req.Message,
+		Signature:  sql.NullString{String: req.Signature, Valid: req.Signature != ""},
+		AppVersion: sql.NullString{String: req.AppVersion, Valid: req.AppVersion != ""},
+		Os:         sql.NullString{String: req.OS, Valid: req.OS != ""},
+		Details:    details,
+		OccurredAt: *req.OccurredAt,
+	}
+	if req.CloseCode != nil {
+		params.CloseCode = sql.NullInt32{Int32: int32(*req.CloseCode), Valid: true}
+	}
+
+	if apiKey := c.Request().Header.Get("X-API-Key"); apiKey != "" {
+		if err := checkKeyFormat(apiKey); err != nil {
+			return err
+		}
+		if err := h.linkReportToKey(ctx, apiKey, &params); err != nil {
+			if err == sql.ErrNoRows {
+				return apiError(http.StatusUnauthorized, "invalid API key")
+			}
+			log.Printf("[Telemetry] Database error: %v", err)
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+	}
+
+	report, err := h.queries.CreateClientErrorReport(ctx, params)
+	if err != nil {
+		log.Printf("[Telemetry] Failed to store error report: %v", err)
+		return apiError(http.StatusInternalServerError, "failed to store report")
+	}
+	log.Printf("[Telemetry] Stored %s report %s (signature: %q)", report.Kind, report.ID, req.Signature)
+
+	return c.JSON(http.StatusAccepted, map[string]string{"id": report.ID.String()})
+}
+
+// linkReportToKey resolves the reporting key and the session log started
+// closest before the error
+func (h *TelemetryHandler) linkReportToKey(ctx context.Context, apiKey string, params *sqlc.CreateClientErrorReportParams) error {
+	// Allow for clock skew between the client and server
+	windowEnd := params.OccurredAt.Add(time.Minute)
+	windowStart := params.OccurredAt.Add(-sessionCorrelationWindow)
+
+	if IsTrialKey(apiKey) {
+		trialKey, err := h.queries.GetTrialAPIKeyByHash(ctx, hashTrialAPIKey(apiKey))
+		if err != nil {
+			return err
+		}
+		params.TrialKeyID = uuid.NullUUID{UUID: trialKey.ID, Valid: true}
+
+		usageID, err := h.queries.GetLatestTrialUsageForKey(ctx, sqlc.GetLatestTrialUsageForKeyParams{
+			TrialKeyID:  trialKey.ID,
+			WindowStart: windowStart,
+			WindowEnd:   windowEnd,
+		})
+		if err == nil {
+			params.TrialUsageID = uuid.NullUUID{UUID: usageID, Valid: true}
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+		return nil
+	}
+
+	key, err := h.queries.GetAPIKeyByHash(ctx, hashAPIKey(apiKey))
+	if err != nil {
+		return err
+	}
+	params.UserID = uuid.NullUUID{UUID: key.UserID, Valid: true}
+	params.ApiKeyID = uuid.NullUUID{UUID: key.ID, Valid: true}
+
+	logID, err := h.queries.GetLatestTranscriptionLogForKey(ctx, sqlc.GetLatestTranscriptionLogForKeyParams{
+		ApiKeyID:    key.ID,
+		WindowStart: windowStart,
+		WindowEnd:   windowEnd,
+	})
+	if err == nil {
+		params.TranscriptionLogID = uuid.NullUUID{UUID: logID, Valid: true}
+	} else if err != sql.ErrNoRows {
+		return err
+	}
+	return nil
+}
+
+// ListErrorReports returns client error reports for triage, newest first,
+// optionally filtered by kind and status (admin only)
+func (h *AdminHandler) ListErrorReports(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	perPage, _ := strconv.Atoi(c.QueryParam("per_page"))
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	kind := c.QueryParam("kind")
+	status := c.QueryParam("status")
+
+	offset := (page - 1) * perPage
+	ctx := context.Background()
+
+	total, err := h.queries.CountClientErrorReports(ctx, sqlc.CountClientErrorReportsParams{
+		Kind:   sql.NullString{String: kind, Valid: kind != ""},
+		Status: sql.NullString{String: status, Valid: status != ""},
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	reports, err := h.queries.ListClientErrorReports(ctx, sqlc.ListClientErrorReportsParams{
+		Kind:       sql.NullString{String: kind, Valid: kind != ""},
+		Status:     sql.NullString{String: status, Valid: status != ""},
+		PageLimit:  int32(perPage),
+		PageOffset: int32(offset),
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	responses := make([]ClientErrorReportResponse, len(reports))
+	for i, r := range reports {
+		responses[i] = toClientErrorReportResponse(sqlc.ClientErrorReport{
+			ID:                 r.ID,
+			Kind:               r.Kind,
+			Message:            r.Message,
+			Signature:          r.Signature,
+			CloseCode:          r.CloseCode,
+			AppVersion:         r.AppVersion,
+			Os:                 r.Os,
+			Details:            r.Details,
+			UserID:             r.UserID,
+			ApiKeyID:           r.ApiKeyID,
+			TrialKeyID:         r.TrialKeyID,
+			TranscriptionLogID: r.TranscriptionLogID,
+			TrialUsageID:       r.TrialUsageID,
+			Status:             r.Status,
+			OccurredAt:         r.OccurredAt,
+			CreatedAt:          r.CreatedAt,
+		})
+		responses[i].SessionStatus = nullStringPtr(r.SessionStatus)
+		responses[i].SessionError = nullStringPtr(r.SessionError)
+	}
+
+	totalPages := int(total) / perPage
+	if int(total)%perPage > 0 {
+		totalPages++
+	}
+
+	return c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       responses,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: totalPages,
+	})
+}
+
+// UpdateErrorReportStatus moves a client error report through triage (admin only)
+func (h *AdminHandler) UpdateErrorReportStatus(c echo.Context) error {
+	reportID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid report ID")
+	}
+
+	var req UpdateErrorReportStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if !contains(errorReportStatuses, req.Status) {
+		return apiError(http.StatusBadRequest, fmt.Sprintf("status must be one of %v", errorReportStatuses))
+	}
+
+	report, err := h.queries.UpdateClientErrorReportStatus(context.Background(), sqlc.UpdateClientErrorReportStatusParams{
+		ID:     reportID,
+		Status: req.Status,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "report not found")
+		}
+		return apiError(http.StatusInternalServerError, "failed to update report")
+	}
+
+	return c.JSON(http.StatusOK, toClientErrorReportResponse(report))
+}
+
+func toClientErrorReportResponse(r sqlc.ClientErrorReport) ClientErrorReportResponse {
+	resp := ClientErrorReportResponse{
+		ID:                 r.ID.String(),
+		Kind:               r.Kind,
+		Message:            r.Message,
+		Signature:          nullStringPtr(r.Signature),
+		AppVersion:         nullStringPtr(r.AppVersion),
+		OS:                 nullStringPtr(r.Os),
+		UserID:             nullUUIDPtr(r.UserID),
+		APIKeyID:           nullUUIDPtr(r.ApiKeyID),
+		TrialKeyID:         nullUUIDPtr(r.TrialKeyID),
+		TranscriptionLogID: nullUUIDPtr(r.TranscriptionLogID),
+		TrialUsageID:       nullUUIDPtr(r.TrialUsageID),
+		Status:             r.Status,
+		OccurredAt:         r.OccurredAt.Format(time.RFC3339),
+		CreatedAt:          r.CreatedAt.Format(time.RFC3339),
+	}
+	if r.CloseCode.Valid {
+		resp.CloseCode = &r.CloseCode.Int32
+	}
+	_ = json.Unmarshal(r.Details, &resp.Details)
+	return resp
+}
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func nullUUIDPtr(id uuid.NullUUID) *string {
+	if !id.Valid {
+		return nil
+	}
+	s := id.UUID.String()
+	return &s
+}
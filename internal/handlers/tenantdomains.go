@@ -0,0 +1,447 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+)
+
+// ========== TENANT DOMAINS ==========
+
+// Requests are routed to a tenant by the host they addressed: the tenant's
+// primary domain or one of its verified custom domains. The host selects
+// the tenant's branding, the origins its WebSocket pages may connect from
+// and the domain of its auth cookies; users and trials of the tenant then
+// stream with its Deepgram key.
+
+// hostRouteTTL is how long the tenant of a host is cached. Other server
+// processes see domain changes once their entries expire.
+const hostRouteTTL = time.Minute
+
+// hostRouteCacheSize bounds the cached hosts, which clients choose
+const hostRouteCacheSize = 1024
+
+// domainVerificationPrefix names the DNS TXT record that verifies a custom
+// domain, at _hyperwhisper-verification.<domain>
+const domainVerificationPrefix = "_hyperwhisper-verification."
+
+// domainVerificationTimeout bounds the DNS lookup of a verification
+const domainVerificationTimeout = 10 * time.Second
+
+// hostRoute is what the host a request addressed selects
+type hostRoute struct {
+	tenant     *sqlc.Tenant // nil on the operator's hosts
+	origins    []string     // Origins of the tenant's domains
+	resolvedAt time.Time
+}
+
+type hostRouteKey struct{}
+
+// HostRouter caches which tenant the hosts requests address belong to
+type HostRouter struct {
+	mu     sync.Mutex
+	routes map[string]hostRoute
+}
+
+// Hosts is the process-wide host router used by TenantMiddleware
+var Hosts = &HostRouter{}
+
+// route returns the route of host, from the cache while it is fresh
+func (h *HostRouter) route(ctx context.Context, queries *sqlc.Queries, host string) (hostRoute, error) {
+	h.mu.Lock()
+	route, ok := h.routes[host]
+	h.mu.Unlock()
+	if ok && time.Since(route.resolvedAt) < hostRouteTTL {
+		return route, nil
+	}
+
+	route = hostRoute{resolvedAt: time.Now()}
+	tenant, err := queries.GetTenantByDomain(ctx, host)
+	if err != nil && err != sql.ErrNoRows {
+		return hostRoute{}, err
+	}
+	if err == nil {
+		domains, err := queries.ListTenantDomains(ctx, tenant.ID)
+		if err != nil {
+			return hostRoute{}, err
+		}
+		route.tenant = &tenant
+		route.origins = []string{"https://" + tenant.Domain}
+		for _, d := range domains {
+			if d.VerifiedAt.Valid {
+				route.origins = append(route.origins, "https://"+d.Domain)
+			}
+		}
+	}
+
+	h.mu.Lock()
+	if h.routes == nil || len(h.routes) >= hostRouteCacheSize {
+		h.routes = make(map[string]hostRoute)
+	}
+	h.routes[host] = route
+	h.mu.Unlock()
+	return route, nil
+}
+
+// Invalidate forgets every cached route, after tenants or their domains
+// changed
+func (h *HostRouter) Invalidate() {
+	h.mu.Lock()
+	h.routes = nil
+	h.mu.Unlock()
+}
+
+// TenantMiddleware resolves the tenant of the host each request addressed,
+// for handlers (requestTenant) and WebSocket origin checks
+func TenantMiddleware(db *sql.DB) echo.MiddlewareFunc {
+	queries := sqlc.New(db)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			host := requestHostname(c.Request())
+			if host == "" {
+				return next(c)
+			}
+			route, err := Hosts.route(c.Request().Context(), queries, host)
+			if err != nil {
+				log.Printf("[Tenants] Failed to resolve host %s: %v", host, err)
+				return apiError(http.StatusInternalServerError, "database error")
+			}
+			c.SetRequest(c.Request().WithContext(context.WithValue(c.Request().Context(), hostRouteKey{}, route)))
+			return next(c)
+		}
+	}
+}
+
+// routeOf returns the route TenantMiddleware resolved for r
+func routeOf(r *http.Request) hostRoute {
+	route, _ := r.Context().Value(hostRouteKey{}).(hostRoute)
+	return route
+}
+
+// requestHostname returns the host the client addressed, lowercased and
+// without port
+func requestHostname(r *http.Request) string {
+	host := strings.ToLower(requestHost(r))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return host
+}
+
+// domainWithin reports whether host is domain or one of its subdomains
+func domainWithin(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// cookieDomain returns the Domain of auth cookies set on the request's
+// host: its tenant's cookie domain when the host is within it, otherwise
+// none, which keeps them to the host
+func cookieDomain(c echo.Context) string {
+	tenant := requestTenant(c)
+	if tenant == nil || !tenant.CookieDomain.Valid {
+		return ""
+	}
+	if !domainWithin(requestHostname(c.Request()), tenant.CookieDomain.String) {
+		return ""
+	}
+	return tenant.CookieDomain.String
+}
+
+// operatorHost returns the host of APP_BASE_URL, which no tenant may claim
+func operatorHost() string {
+	u, err := url.Parse(config.String("APP_BASE_URL"))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// domainClaimConflict returns why tenantID (uuid.Nil for a new tenant) may
+// not route domain to itself or share cookies across cookieDomain (either
+// may be empty), or "" when it may. Domains belong to one tenant, and a
+// cookie domain must not reach another tenant's or the operator's hosts.
+func domainClaimConflict(ctx context.Context, queries *sqlc.Queries, tenantID uuid.UUID, domain, cookieDomain string) (string, error) {
+	tenants, err := queries.ListTenants(ctx)
+	if err != nil {
+		return "", err
+	}
+	verified, err := queries.ListVerifiedTenantDomains(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var others []string
+	for _, t := range tenants {
+		if t.ID == tenantID {
+			continue
+		}
+		others = append(others, t.Domain)
+		if domain != "" && t.CookieDomain.Valid && domainWithin(domain, t.CookieDomain.String) {
+			return "domain already in use", nil
+		}
+	}
+	for _, d := range verified {
+		if d.TenantID != tenantID {
+			others = append(others, d.Domain)
+		}
+	}
+
+	appHost := operatorHost()
+	if domain != "" && (domain == appHost || slices.Contains(others, domain)) {
+		return "domain already in use", nil
+	}
+	if cookieDomain != "" {
+		if appHost != "" && domainWithin(appHost, cookieDomain) {
+			return "cookie domain reaches other hosts", nil
+		}
+		for _, other := range others {
+			if domainWithin(other, cookieDomain) {
+				return "cookie domain reaches other hosts", nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// TenantDomainRequest adds a custom domain to a tenant
+type TenantDomainRequest struct {
+	Domain string `json:"domain"`
+}
+
+// TenantDomainResponse describes a custom domain. It routes to the tenant
+// once a TXT record named VerificationRecord holding VerificationValue
+// exists and it has been verified.
+type TenantDomainResponse struct {
+	ID                 string  `json:"id"`
+	Domain             string  `json:"domain"`
+	Verified           bool    `json:"verified"`
+	VerifiedAt         *string `json:"verified_at"`
+	VerificationRecord string  `json:"verification_record"`
+	VerificationValue  string  `json:"verification_value"`
+	CreatedAt          string  `json:"created_at"`
+}
+
+// domainTenant returns the tenant a domain route manages: the :id of
+// /admin/tenants/:id/domains (operator admins), or the caller's own for
+// /admin/tenant/domains (tenant admins)
+func domainTenant(c echo.Context) (uuid.UUID, error) {
+	if id := c.Param("id"); id != "" {
+		tenantID, err := uuid.Parse(id)
+		if err != nil {
+			return uuid.Nil, apiError(http.StatusBadRequest, "invalid tenant ID")
+		}
+		return tenantID, nil
+	}
+	tenantID := callerTenant(c)
+	if !tenantID.Valid {
+		return uuid.Nil, apiError(http.StatusNotFound, "not a tenant account")
+	}
+	return tenantID.UUID, nil
+}
+
+// ListTenantDomains returns the custom domains of a tenant
+func (h *AdminHandler) ListTenantDomains(c echo.Context) error {
+	tenantID, err := domainTenant(c)
+	if err != nil {
+		return err
+	}
+
+	domains, err := h.queries.ListTenantDomains(context.Background(), tenantID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	resp := make([]TenantDomainResponse, len(domains))
+	for i, d := range domains {
+		resp[i] = toTenantDomainResponse(d)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// AddTenantDomain adds an unverified custom domain to a tenant and returns
+// the DNS record that verifies it
+func (h *AdminHandler) AddTenantDomain(c echo.Context) error {
+	tenantID, err := domainTenant(c)
+	if err != nil {
+		return err
+	}
+
+	var req TenantDomainRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	domain, ok := normalizeTenantDomain(req.Domain)
+	if !ok {
+		return invalidTenantDomainError()
+	}
+
+	ctx := context.Background()
+	tenant, err := h.queries.GetTenant(ctx, tenantID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "tenant not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if domain == tenant.Domain {
+		return apiError(http.StatusConflict, "domain already added")
+	}
+	conflict, err := domainClaimConflict(ctx, h.queries, tenant.ID, domain, "")
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if conflict != "" {
+		return apiError(http.StatusConflict, conflict)
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to generate token")
+	}
+	added, err := h.queries.CreateTenantDomain(ctx, sqlc.CreateTenantDomainParams{
+		TenantID:          tenant.ID,
+		Domain:            domain,
+		VerificationToken: hex.EncodeToString(token),
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return apiError(http.StatusConflict, "domain already added")
+		}
+		log.Printf("[Admin] Failed to add domain %s to tenant %s: %v", domain, tenant.Slug, err)
+		return apiError(http.StatusInternalServerError, "failed to add domain")
+	}
+
+	log.Printf("[Admin] Added domain %s to tenant %s", domain, tenant.Slug)
+	recordAuditEvent(ctx, h.queries, c, auditDomainAdd, "tenant", tenant.ID.String(), "", map[string]string{
+		"domain": domain,
+	})
+	return c.JSON(http.StatusCreated, toTenantDomainResponse(added))
+}
+
+// VerifyTenantDomain looks up the verification record of a custom domain
+// and, once it holds the token, routes the domain to the tenant
+func (h *AdminHandler) VerifyTenantDomain(c echo.Context) error {
+	tenantID, err := domainTenant(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	d, err := h.queries.GetTenantDomain(ctx, sqlc.GetTenantDomainParams{
+		TenantID: tenantID,
+		Domain:   strings.ToLower(c.Param("domain")),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "domain not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if d.VerifiedAt.Valid {
+		return c.JSON(http.StatusOK, toTenantDomainResponse(d))
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, domainVerificationTimeout)
+	records, err := net.DefaultResolver.LookupTXT(lookupCtx, domainVerificationPrefix+d.Domain)
+	cancel()
+	if err != nil || !slices.Contains(records, domainVerificationValue(d)) {
+		log.Printf("[Admin] Verification record of domain %s not found: %v", d.Domain, err)
+		return newAPIError(http.StatusUnprocessableEntity, ErrorResponse{
+			Error: "verification record not found",
+			Details: map[string]string{
+				"record": domainVerificationPrefix + d.Domain,
+				"value":  domainVerificationValue(d),
+			},
+		})
+	}
+
+	conflict, err := domainClaimConflict(ctx, h.queries, tenantID, d.Domain, "")
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if conflict != "" {
+		return apiError(http.StatusConflict, conflict)
+	}
+	verified, err := h.queries.MarkTenantDomainVerified(ctx, d.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return apiError(http.StatusConflict, "domain already in use")
+		}
+		return apiError(http.StatusInternalServerError, "failed to verify domain")
+	}
+	Hosts.Invalidate()
+
+	log.Printf("[Admin] Verified domain %s of tenant %s", d.Domain, tenantID)
+	recordAuditEvent(ctx, h.queries, c, auditDomainVerify, "tenant", tenantID.String(), "", map[string]string{
+		"domain": d.Domain,
+	})
+	return c.JSON(http.StatusOK, toTenantDomainResponse(verified))
+}
+
+// DeleteTenantDomain removes a custom domain from a tenant
+func (h *AdminHandler) DeleteTenantDomain(c echo.Context) error {
+	tenantID, err := domainTenant(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	d, err := h.queries.GetTenantDomain(ctx, sqlc.GetTenantDomainParams{
+		TenantID: tenantID,
+		Domain:   strings.ToLower(c.Param("domain")),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "domain not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if _, err := h.queries.DeleteTenantDomain(ctx, sqlc.DeleteTenantDomainParams{TenantID: tenantID, Domain: d.Domain}); err != nil {
+		return apiError(http.StatusInternalServerError, "failed to delete domain")
+	}
+	Hosts.Invalidate()
+
+	log.Printf("[Admin] Removed domain %s from tenant %s", d.Domain, tenantID)
+	recordAuditEvent(ctx, h.queries, c, auditDomainDelete, "tenant", tenantID.String(), "", map[string]string{
+		"domain": d.Domain,
+	})
+	return c.JSON(http.StatusOK, map[string]string{"message": "domain deleted"})
+}
+
+func domainVerificationValue(d sqlc.TenantDomain) string {
+	return "hyperwhisper-verification=" + d.VerificationToken
+}
+
+func toTenantDomainResponse(d sqlc.TenantDomain) TenantDomainResponse {
+	resp := TenantDomainResponse{
+		ID:                 d.ID.String(),
+		Domain:             d.Domain,
+		Verified:           d.VerifiedAt.Valid,
+		VerificationRecord: domainVerificationPrefix + d.Domain,
+		VerificationValue:  domainVerificationValue(d),
+		CreatedAt:          d.CreatedAt.Format(time.RFC3339),
+	}
+	if d.VerifiedAt.Valid {
+		verifiedAt := d.VerifiedAt.Time.Format(time.RFC3339)
+		resp.VerifiedAt = &verifiedAt
+	}
+	return resp
+}
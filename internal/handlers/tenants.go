@@ -0,0 +1,594 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/encryption"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+)
+
+// ========== TENANTS ==========
+
+// Tenants are white-label resellers sharing the deployment. A tenant's users
+// and trial keys are the ones that signed up or were provisioned through its
+// domain; they stream with its Deepgram key and see its branding. Its staff
+// (users of the tenant with a staff role) may only call admin routes marked
+// auth.TenantScoped, whose handlers limit them to the tenant's users.
+
+// TenantBranding is what white-label clients and dashboards show instead of
+// HyperWhisper's own name and colors. Empty fields use the defaults.
+type TenantBranding struct {
+	ProductName  string `json:"product_name,omitempty"`
+	LogoURL      string `json:"logo_url,omitempty"`
+	PrimaryColor string `json:"primary_color,omitempty"`
+	SupportEmail string `json:"support_email,omitempty"`
+}
+
+// defaultProductName is the product name of the operator's own hosts
+const defaultProductName = "HyperWhisper"
+
+var brandingColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// tenantSlugPattern matches the slugs tenants are created with
+var tenantSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// CreateTenantRequest creates a tenant (operator admins only)
+type CreateTenantRequest struct {
+	Slug     string         `json:"slug"`
+	Name     string         `json:"name"`
+	Domain   string         `json:"domain"`
+	Branding TenantBranding `json:"branding"`
+	// TrialPreset limits the trials provisioned through the tenant's
+	// domain without a campaign code; empty is the default preset
+	TrialPreset    string `json:"trial_preset"`
+	DeepgramAPIKey string `json:"deepgram_api_key"`
+	// CookieDomain shares auth cookies across the hosts within it, e.g.
+	// example.com for app.example.com; it must be the domain or a parent
+	CookieDomain string `json:"cookie_domain"`
+	// DataRegion keeps the sessions of the tenant's users and trials in a
+	// data region (see Data Residency); empty leaves them unbound
+	DataRegion string `json:"data_region"`
+}
+
+// UpdateTenantRequest changes a tenant. Operator admins may set every
+// field; tenant admins only branding and deepgram_api_key. Omitted fields
+// keep their value, an empty deepgram_api_key goes back to the operator's
+// and an empty cookie_domain keeps cookies to each host, as an empty
+// data_region lifts the residency requirement.
+type UpdateTenantRequest struct {
+	Name           *string         `json:"name"`
+	Domain         *string         `json:"domain"`
+	Branding       *TenantBranding `json:"branding"`
+	TrialPreset    *string         `json:"trial_preset"`
+	DeepgramAPIKey *string         `json:"deepgram_api_key"`
+	CookieDomain   *string         `json:"cookie_domain"`
+	DataRegion     *string         `json:"data_region"`
+}
+
+// TenantResponse describes a tenant. Its Deepgram key is never returned.
+type TenantResponse struct {
+	ID                string         `json:"id"`
+	Slug              string         `json:"slug"`
+	Name              string         `json:"name"`
+	Domain            string         `json:"domain"`
+	Branding          TenantBranding `json:"branding"`
+	TrialPreset       string         `json:"trial_preset"`
+	HasDeepgramAPIKey bool           `json:"has_deepgram_api_key"`
+	CookieDomain      *string        `json:"cookie_domain"`
+	DataRegion        *string        `json:"data_region"`
+	CreatedAt         string         `json:"created_at"`
+	UpdatedAt         string         `json:"updated_at"`
+}
+
+// BrandingResponse is the branding of the host a request addressed
+type BrandingResponse struct {
+	Tenant *string `json:"tenant"` // Slug; null on the operator's hosts
+	TenantBranding
+}
+
+// GetBranding returns the branding of the tenant whose domain the request
+// addressed, or HyperWhisper's own (public)
+func (h *AuthHandler) GetBranding(c echo.Context) error {
+	tenant := requestTenant(c)
+	resp := BrandingResponse{TenantBranding: TenantBranding{ProductName: defaultProductName}}
+	if tenant != nil {
+		resp.Tenant = &tenant.Slug
+		resp.TenantBranding = tenantBranding(*tenant)
+		if resp.ProductName == "" {
+			resp.ProductName = tenant.Name
+		}
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// ListTenants returns every tenant (operator admins only)
+func (h *AdminHandler) ListTenants(c echo.Context) error {
+	tenants, err := h.queries.ListTenants(context.Background())
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	resp := make([]TenantResponse, len(tenants))
+	for i, tenant := range tenants {
+		resp[i] = toTenantResponse(tenant)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// CreateTenant creates a tenant (operator admins only). Its domain must
+// reach this server; signups and trials through it join the tenant.
+func (h *AdminHandler) CreateTenant(c echo.Context) error {
+	var req CreateTenantRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if !tenantSlugPattern.MatchString(req.Slug) {
+		return newAPIError(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid tenant slug",
+			Details: map[string]string{"slug": "lowercase letters, digits and dashes, at most 64 characters"},
+		})
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return apiError(http.StatusBadRequest, "name is required")
+	}
+	domain, ok := normalizeTenantDomain(req.Domain)
+	if !ok {
+		return invalidTenantDomainError()
+	}
+	if details := validateBranding(req.Branding); details != nil {
+		return newAPIError(http.StatusBadRequest, ErrorResponse{Error: "invalid branding", Details: details})
+	}
+	branding, err := json.Marshal(req.Branding)
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid branding")
+	}
+	preset := req.TrialPreset
+	if preset == "" {
+		preset = defaultTrialPreset
+	}
+	cookieDomain, ok := normalizeCookieDomain(req.CookieDomain, domain)
+	if !ok {
+		return invalidCookieDomainError()
+	}
+	dataRegion, err := parseDataRegion(req.DataRegion)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if _, err := h.queries.GetTrialPreset(ctx, preset); err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusBadRequest, "unknown trial preset")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	conflict, err := domainClaimConflict(ctx, h.queries, uuid.Nil, domain, cookieDomain.String)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if conflict != "" {
+		return apiError(http.StatusConflict, conflict)
+	}
+
+	apiKey := strings.TrimSpace(req.DeepgramAPIKey)
+	tenant, err := h.queries.CreateTenant(ctx, sqlc.CreateTenantParams{
+		Slug:           req.Slug,
+		Name:           name,
+		Domain:         domain,
+		Branding:       branding,
+		DeepgramApiKey: encryption.NullString{String: apiKey, Valid: apiKey != ""},
+		TrialPreset:    preset,
+		CookieDomain:   cookieDomain,
+		DataRegion:     dataRegion,
+	})
+	if err != nil {
+		if field, ok := tenantUniqueViolation(err); ok {
+			return tenantConflictError(field)
+		}
+		log.Printf("[Admin] Failed to create tenant %s: %v", req.Slug, err)
+		return apiError(http.StatusInternalServerError, "failed to create tenant")
+	}
+	Hosts.Invalidate()
+
+	log.Printf("[Admin] Created tenant %s (%s)", tenant.Slug, tenant.Domain)
+	recordAuditEvent(ctx, h.queries, c, auditTenantCreate, "tenant", tenant.ID.String(), "", map[string]string{
+		"slug":   tenant.Slug,
+		"domain": tenant.Domain,
+	})
+	return c.JSON(http.StatusCreated, toTenantResponse(tenant))
+}
+
+// UpdateTenant changes a tenant (operator admins only)
+func (h *AdminHandler) UpdateTenant(c echo.Context) error {
+	tenantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid tenant ID")
+	}
+	return h.updateTenant(c, tenantID, false)
+}
+
+// GetOwnTenant returns the tenant of the calling tenant staff
+func (h *AdminHandler) GetOwnTenant(c echo.Context) error {
+	tenantID := callerTenant(c)
+	if !tenantID.Valid {
+		return apiError(http.StatusNotFound, "not a tenant account")
+	}
+
+	tenant, err := h.queries.GetTenant(context.Background(), tenantID.UUID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "tenant not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	return c.JSON(http.StatusOK, toTenantResponse(tenant))
+}
+
+// UpdateOwnTenant changes the branding or Deepgram key of the calling
+// tenant admin's tenant
+func (h *AdminHandler) UpdateOwnTenant(c echo.Context) error {
+	tenantID := callerTenant(c)
+	if !tenantID.Valid {
+		return apiError(http.StatusNotFound, "not a tenant account")
+	}
+	return h.updateTenant(c, tenantID.UUID, true)
+}
+
+// updateTenant applies an UpdateTenantRequest. Tenant admins (own) may not
+// rename, move or re-limit their tenant.
+func (h *AdminHandler) updateTenant(c echo.Context, tenantID uuid.UUID, own bool) error {
+	var req UpdateTenantRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, "invalid request body")
+	}
+	if own && (req.Name != nil || req.Domain != nil || req.TrialPreset != nil || req.CookieDomain != nil || req.DataRegion != nil) {
+		return apiError(http.StatusForbidden, "only branding and deepgram_api_key can be changed by tenant admins")
+	}
+
+	ctx := context.Background()
+	tenant, err := h.queries.GetTenant(ctx, tenantID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "tenant not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	params := sqlc.UpdateTenantParams{
+		ID:           tenant.ID,
+		Name:         tenant.Name,
+		Domain:       tenant.Domain,
+		Branding:     tenant.Branding,
+		TrialPreset:  tenant.TrialPreset,
+		CookieDomain: tenant.CookieDomain,
+		DataRegion:   tenant.DataRegion,
+	}
+	if req.Name != nil {
+		if params.Name = strings.TrimSpace(*req.Name); params.Name == "" {
+			return apiError(http.StatusBadRequest, "name is required")
+		}
+	}
+	if req.Domain != nil {
+		domain, ok := normalizeTenantDomain(*req.Domain)
+		if !ok {
+			return invalidTenantDomainError()
+		}
+		params.Domain = domain
+	}
+	if req.Domain != nil || req.CookieDomain != nil {
+		cookieDomain := params.CookieDomain.String
+		if req.CookieDomain != nil {
+			cookieDomain = *req.CookieDomain
+		}
+		var ok bool
+		if params.CookieDomain, ok = normalizeCookieDomain(cookieDomain, params.Domain); !ok {
+			return invalidCookieDomainError()
+		}
+		conflict, err := domainClaimConflict(ctx, h.queries, tenant.ID, params.Domain, params.CookieDomain.String)
+		if err != nil {
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+		if conflict != "" {
+			return apiError(http.StatusConflict, conflict)
+		}
+	}
+	if req.Branding != nil {
+		if details := validateBranding(*req.Branding); details != nil {
+			return newAPIError(http.StatusBadRequest, ErrorResponse{Error: "invalid branding", Details: details})
+		}
+		if params.Branding, err = json.Marshal(req.Branding); err != nil {
+			return apiError(http.StatusBadRequest, "invalid branding")
+		}
+	}
+	if req.TrialPreset != nil {
+		if _, err := h.queries.GetTrialPreset(ctx, *req.TrialPreset); err != nil {
+			if err == sql.ErrNoRows {
+				return apiError(http.StatusBadRequest, "unknown trial preset")
+			}
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+		params.TrialPreset = *req.TrialPreset
+	}
+	if req.DataRegion != nil {
+		if params.DataRegion, err = parseDataRegion(*req.DataRegion); err != nil {
+			return err
+		}
+	}
+	if req.DeepgramAPIKey != nil {
+		apiKey := strings.TrimSpace(*req.DeepgramAPIKey)
+		params.SetDeepgramApiKey = true
+		params.DeepgramApiKey = encryption.NullString{String: apiKey, Valid: apiKey != ""}
+	}
+
+	updated, err := h.queries.UpdateTenant(ctx, params)
+	if err != nil {
+		if field, ok := tenantUniqueViolation(err); ok {
+			return tenantConflictError(field)
+		}
+		log.Printf("[Admin] Failed to update tenant %s: %v", tenant.Slug, err)
+		return apiError(http.StatusInternalServerError, "failed to update tenant")
+	}
+	Hosts.Invalidate()
+
+	changes := map[string]string{}
+	if updated.Name != tenant.Name {
+		changes["name"] = updated.Name
+	}
+	if updated.Domain != tenant.Domain {
+		changes["domain"] = updated.Domain
+	}
+	if req.Branding != nil {
+		changes["branding"] = string(updated.Branding)
+	}
+	if updated.TrialPreset != tenant.TrialPreset {
+		changes["trial_preset"] = updated.TrialPreset
+	}
+	if updated.CookieDomain != tenant.CookieDomain {
+		changes["cookie_domain"] = updated.CookieDomain.String
+	}
+	if updated.DataRegion != tenant.DataRegion {
+		changes["data_region"] = updated.DataRegion.String
+	}
+	if req.DeepgramAPIKey != nil {
+		changes["deepgram_api_key"] = "set"
+		if !updated.DeepgramApiKey.Valid {
+			changes["deepgram_api_key"] = "cleared"
+		}
+	}
+	log.Printf("[Admin] Updated tenant %s: %v", updated.Slug, changes)
+	recordAuditEvent(ctx, h.queries, c, auditTenantUpdate, "tenant", updated.ID.String(), "", changes)
+
+	return c.JSON(http.StatusOK, toTenantResponse(updated))
+}
+
+// DeleteTenant removes a tenant without users or trial keys (operator
+// admins only)
+func (h *AdminHandler) DeleteTenant(c echo.Context) error {
+	tenantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid tenant ID")
+	}
+
+	ctx := context.Background()
+	deleted, err := h.queries.DeleteTenant(ctx, tenantID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return apiError(http.StatusConflict, "tenant still has users or trial keys")
+		}
+		return apiError(http.StatusInternalServerError, "failed to delete tenant")
+	}
+	if deleted == 0 {
+		return apiError(http.StatusNotFound, "tenant not found")
+	}
+	Hosts.Invalidate()
+
+	log.Printf("[Admin] Deleted tenant %s", tenantID)
+	recordAuditEvent(ctx, h.queries, c, auditTenantDelete, "tenant", tenantID.String(), "", nil)
+	return c.JSON(http.StatusOK, map[string]string{"message": "tenant deleted"})
+}
+
+// callerTenant returns the tenant of the signed-in user, with Valid false
+// for the operator's users
+func callerTenant(c echo.Context) uuid.NullUUID {
+	claims := auth.GetUserFromContext(c)
+	if claims == nil || claims.TenantID == uuid.Nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: claims.TenantID, Valid: true}
+}
+
+// managedUser loads a user the calling staff may manage. Users of other
+// tenants are not found for tenant staff, so their IDs reveal nothing.
+func (h *AdminHandler) managedUser(c echo.Context, ctx context.Context, userID uuid.UUID) (sqlc.User, error) {
+	user, err := h.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return user, err
+	}
+	if tenant := callerTenant(c); tenant.Valid && user.TenantID != tenant {
+		return sqlc.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+// requestTenant returns the tenant whose domain the request addressed, as
+// TenantMiddleware resolved it, or nil on the operator's own hosts
+func requestTenant(c echo.Context) *sqlc.Tenant {
+	return routeOf(c.Request()).tenant
+}
+
+// userOfRequestTenant reports whether user belongs to the tenant whose
+// domain the request addressed, or is one of the operator's users on the
+// operator's hosts, so accounts can't be used through another tenant
+func userOfRequestTenant(c echo.Context, user sqlc.User) bool {
+	return user.TenantID == tenantID(requestTenant(c))
+}
+
+// tenantID returns the ID of tenant, with Valid false for nil
+func tenantID(tenant *sqlc.Tenant) uuid.NullUUID {
+	if tenant == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: tenant.ID, Valid: true}
+}
+
+// upstreamAPIKey returns the Deepgram API key sessions of a tenant's users
+// and trials stream with: the tenant's own, or DEEPGRAM_API_KEY
+func upstreamAPIKey(ctx context.Context, queries *sqlc.Queries, tenantID uuid.NullUUID) (string, error) {
+	if tenantID.Valid {
+		tenant, err := queries.GetTenant(ctx, tenantID.UUID)
+		if err != nil {
+			return "", err
+		}
+		if tenant.DeepgramApiKey.Valid {
+			return tenant.DeepgramApiKey.String, nil
+		}
+	}
+	return config.String("DEEPGRAM_API_KEY"), nil
+}
+
+// appBaseURL returns where the dashboard of a tenant's users is served:
+// its domain, or APP_BASE_URL for the operator's users
+func appBaseURL(ctx context.Context, queries *sqlc.Queries, tenantID uuid.NullUUID) string {
+	if tenantID.Valid {
+		tenant, err := queries.GetTenant(ctx, tenantID.UUID)
+		if err == nil {
+			return "https://" + tenant.Domain
+		}
+		log.Printf("[Tenants] Failed to get tenant %s: %v", tenantID.UUID, err)
+	}
+	return config.String("APP_BASE_URL")
+}
+
+// normalizeTenantDomain lowercases a bare host name, rejecting URLs, ports
+// and paths
+func normalizeTenantDomain(raw string) (string, bool) {
+	domain := strings.ToLower(strings.TrimSpace(raw))
+	if domain == "" || len(domain) > 255 || strings.ContainsAny(domain, ":/?#@ ") || !strings.Contains(domain, ".") {
+		return "", false
+	}
+	return domain, true
+}
+
+// normalizeCookieDomain lowercases a cookie domain, which must be domain
+// or a parent of it; empty is none
+func normalizeCookieDomain(raw, domain string) (sql.NullString, bool) {
+	if strings.TrimSpace(raw) == "" {
+		return sql.NullString{}, true
+	}
+	cookieDomain, ok := normalizeTenantDomain(strings.TrimPrefix(strings.TrimSpace(raw), "."))
+	if !ok || !domainWithin(domain, cookieDomain) {
+		return sql.NullString{}, false
+	}
+	return sql.NullString{String: cookieDomain, Valid: true}, true
+}
+
+func invalidCookieDomainError() error {
+	return newAPIError(http.StatusBadRequest, ErrorResponse{
+		Error:   "invalid cookie domain",
+		Details: map[string]string{"cookie_domain": "the tenant's domain or a parent of it, e.g. example.com"},
+	})
+}
+
+func invalidTenantDomainError() error {
+	return newAPIError(http.StatusBadRequest, ErrorResponse{
+		Error:   "invalid tenant domain",
+		Details: map[string]string{"domain": "a host name without scheme or port, e.g. dictate.example.com"},
+	})
+}
+
+// validateBranding returns what is wrong with branding, or nil
+func validateBranding(b TenantBranding) map[string]string {
+	details := map[string]string{}
+	if len(b.ProductName) > 100 {
+		details["product_name"] = "at most 100 characters"
+	}
+	if b.LogoURL != "" {
+		if u, err := url.Parse(b.LogoURL); err != nil || u.Scheme != "https" || u.Host == "" {
+			details["logo_url"] = "must be an https URL"
+		}
+	}
+	if b.PrimaryColor != "" && !brandingColorPattern.MatchString(b.PrimaryColor) {
+		details["primary_color"] = "must be a hex color like #1a2b3c"
+	}
+	if b.SupportEmail != "" {
+		if _, err := mail.ParseAddress(b.SupportEmail); err != nil {
+			details["support_email"] = "must be an email address"
+		}
+	}
+	if len(details) == 0 {
+		return nil
+	}
+	return details
+}
+
+// tenantBranding decodes a tenant's stored branding
+func tenantBranding(tenant sqlc.Tenant) TenantBranding {
+	var branding TenantBranding
+	if err := json.Unmarshal(tenant.Branding, &branding); err != nil {
+		log.Printf("[Tenants] Tenant %s has invalid branding: %v", tenant.Slug, err)
+	}
+	return branding
+}
+
+// tenantUniqueViolation reports whether err is a tenant losing a race on
+// its unique slug or domain, and which field it was
+func tenantUniqueViolation(err error) (string, bool) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+		return "", false
+	}
+	switch pqErr.Constraint {
+	case "tenants_slug_key":
+		return "slug", true
+	case "tenants_domain_key":
+		return "domain", true
+	}
+	return "", false
+}
+
+func tenantConflictError(field string) error {
+	return newAPIError(http.StatusConflict, ErrorResponse{
+		Error:   "tenant already exists",
+		Details: map[string]string{field: "already used by another tenant"},
+	})
+}
+
+func toTenantResponse(tenant sqlc.Tenant) TenantResponse {
+	resp := TenantResponse{
+		ID:                tenant.ID.String(),
+		Slug:              tenant.Slug,
+		Name:              tenant.Name,
+		Domain:            tenant.Domain,
+		Branding:          tenantBranding(tenant),
+		TrialPreset:       tenant.TrialPreset,
+		HasDeepgramAPIKey: tenant.DeepgramApiKey.Valid,
+		CreatedAt:         tenant.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:         tenant.UpdatedAt.Format(time.RFC3339),
+	}
+	if tenant.CookieDomain.Valid {
+		resp.CookieDomain = &tenant.CookieDomain.String
+	}
+	if tenant.DataRegion.Valid {
+		resp.DataRegion = &tenant.DataRegion.String
+	}
+	return resp
+}
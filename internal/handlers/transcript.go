@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/encryption"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ========== SESSION TRANSCRIPTS ==========
+
+// maxTranscriptBytes bounds the transcript a session holds in memory;
+// later results are dropped
+const maxTranscriptBytes = 1 << 20
+
+// transcriptBuffer assembles the final Results of a session into a running
+// transcript. Clients opt in with save_transcript=true so a transcript
+// survives even if the client crashes before saving it locally.
+type transcriptBuffer struct {
+	text      strings.Builder
+	truncated bool
+}
+
+// wantsSavedTranscript reports whether the client asked for the session's
+// transcript to be kept and saving is enabled
+func wantsSavedTranscript(c echo.Context) bool {
+	return c.QueryParam("save_transcript") == "true" && config.Int("TRANSCRIPT_RETENTION_DAYS") > 0
+}
+
+// add appends the transcript of a final Results message; interim results
+// are skipped since a final result replaces them
+func (t *transcriptBuffer) add(data []byte) {
+	var msg struct {
+		Type    string `json:"type"`
+		IsFinal bool   `json:"is_final"`
+		Channel struct {
+			Alternatives []struct {
+				Transcript string `json:"transcript"`
+			} `json:"alternatives"`
+		} `json:"channel"`
+	}
+	if json.Unmarshal(data, &msg) != nil || msg.Type != "Results" || !msg.IsFinal {
+		return
+	}
+	if len(msg.Channel.Alternatives) == 0 {
+		return
+	}
+
+	text := strings.TrimSpace(msg.Channel.Alternatives[0].Transcript)
+	if text == "" || t.truncated {
+		return
+	}
+	if t.text.Len()+len(text)+1 > maxTranscriptBytes {
+		t.truncated = true
+		return
+	}
+	if t.text.Len() > 0 {
+		t.text.WriteByte(' ')
+	}
+	t.text.WriteString(text)
+}
+
+// save persists the transcript of a finished session
+func (t *transcriptBuffer) save(ctx context.Context, queries *sqlc.Queries, logID, userID uuid.UUID) {
+	if t.text.Len() == 0 {
+		return
+	}
+	if t.truncated {
+		log.Printf("[Transcript] Transcript of session %s exceeded %d bytes and was truncated", logID, maxTranscriptBytes)
+	}
+
+	// Expired transcripts are removed whenever a new one is saved
+	if err := queries.DeleteExpiredSessionTranscripts(ctx); err != nil {
+		log.Printf("[Transcript] Failed to delete expired transcripts: %v", err)
+	}
+
+	retention := time.Duration(config.Int("TRANSCRIPT_RETENTION_DAYS")) * 24 * time.Hour
+	err := queries.CreateSessionTranscript(ctx, sqlc.CreateSessionTranscriptParams{
+		TranscriptionLogID: logID,
+		UserID:             userID,
+		Transcript:         encryption.String(t.text.String()),
+		ExpiresAt:          time.Now().Add(retention),
+	})
+	if err != nil {
+		log.Printf("[Transcript] Failed to save transcript of session %s: %v", logID, err)
+	}
+}
+
+// SessionTranscriptResponse is a saved session transcript
+type SessionTranscriptResponse struct {
+	TranscriptionLogID string `json:"transcription_log_id"`
+	Transcript         string `json:"transcript"`
+	CreatedAt          string `json:"created_at"`
+	ExpiresAt          string `json:"expires_at"`
+}
+
+// GetSessionTranscript returns the saved transcript of one of the user's
+// sessions
+func (h *DeepgramHandler) GetSessionTranscript(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	logID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return apiError(http.StatusBadRequest, "invalid log ID")
+	}
+
+	transcript, err := h.queries.GetSessionTranscript(context.Background(), sqlc.GetSessionTranscriptParams{
+		TranscriptionLogID: logID,
+		UserID:             claims.UserID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusNotFound, "transcript not found")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	return c.JSON(http.StatusOK, SessionTranscriptResponse{
+		TranscriptionLogID: transcript.TranscriptionLogID.String(),
+		Transcript:         string(transcript.Transcript),
+		CreatedAt:          transcript.CreatedAt.Format(time.RFC3339),
+		ExpiresAt:          transcript.ExpiresAt.Format(time.RFC3339),
+	})
+}
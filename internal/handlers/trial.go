@@ -10,12 +10,17 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"os"
-	"strconv"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db"
 	"hyperwhisper/internal/db/sqlc"
+	"hyperwhisper/internal/encryption"
+	"hyperwhisper/internal/logging"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
@@ -26,36 +31,49 @@ import (
 type TrialHandler struct {
 	queries  *sqlc.Queries
 	upgrader websocket.Upgrader
+	policy   TrialPolicy
 }
 
 // NewTrialHandler creates a new trial handler
 func NewTrialHandler(db *sql.DB) *TrialHandler {
 	return &TrialHandler{
-		queries: sqlc.New(db),
-		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				if os.Getenv("APP_ENV") == "dev" {
-					return true
-				}
-				return checkAllowedOrigin(r)
-			},
-			ReadBufferSize:  1024,
-			WriteBufferSize: 1024,
-		},
+		queries:  sqlc.New(db),
+		upgrader: newProxyUpgrader(),
+		policy:   ConfigTrialPolicy{},
 	}
 }
 
+// SetPolicy replaces the policy deciding trial provisioning requests
+func (h *TrialHandler) SetPolicy(policy TrialPolicy) {
+	h.policy = policy
+}
+
 // ========== REQUEST/RESPONSE TYPES ==========
 
 // ProvisionTrialKeyRequest is the request body for provisioning a trial key
 type ProvisionTrialKeyRequest struct {
 	DeviceFingerprint string `json:"device_fingerprint"`
+	// CampaignCode optionally selects a non-default limits preset
+	CampaignCode string `json:"campaign_code"`
+	// Source optionally names the install channel, one of the trial
+	// sources admins manage
+	Source string `json:"source"`
+	// Attestation proves the request comes from a genuine app install
+	Attestation *TrialAttestation `json:"attestation"`
+}
+
+// TrialAttestation is a platform attestation token, e.g. a DeviceCheck
+// token with type "apple-devicecheck"
+type TrialAttestation struct {
+	Type  string `json:"type"`
+	Token string `json:"token"`
 }
 
 // TrialKeyResponse is the response for trial key operations
 type TrialKeyResponse struct {
-	Key                      string  `json:"key,omitempty"` // Only returned on first provision
+	Key                      string  `json:"key,omitempty"` // Only returned when a secret is issued
 	KeyPrefix                string  `json:"key_prefix"`
+	Preset                   string  `json:"preset"`
 	RemainingDurationSeconds float64 `json:"remaining_duration_seconds"`
 	RemainingSessions        int64   `json:"remaining_sessions"`
 	MaxSessionDuration       int     `json:"max_session_duration_seconds"`
@@ -79,80 +97,187 @@ type TrialUsageResponse struct {
 // TrialStatusResponse is the response for trial status endpoint
 type TrialStatusResponse struct {
 	Active                   bool    `json:"active"`
+	Preset                   string  `json:"preset"`
 	RemainingDurationSeconds float64 `json:"remaining_duration_seconds"`
 	RemainingSessions        int64   `json:"remaining_sessions"`
 	ExpiresAt                string  `json:"expires_at"`
 	Expired                  bool    `json:"expired"`
 	QuotaExceeded            bool    `json:"quota_exceeded"`
+	Converted                bool    `json:"converted"` // An account was created from the upgrade link
 	UpgradeURL               string  `json:"upgrade_url,omitempty"`
 }
 
+// TrialTermsResponse is what a trial key provisioned now would get
+type TrialTermsResponse struct {
+	Preset                    string `json:"preset"`
+	MaxDurationSeconds        int    `json:"max_duration_seconds"`
+	MaxSessions               int    `json:"max_sessions"`
+	MaxSessionDurationSeconds int    `json:"max_session_duration_seconds"`
+	ExpiryDays                int    `json:"expiry_days"`
+}
+
 // ========== TRIAL KEY PROVISIONING ==========
 
+// GetTrialTerms returns the limits of the preset a trial key provisioned by
+// this request would get: the addressed tenant's, the one selected by the
+// campaign_code query parameter, or the default. Clients show them before
+// provisioning.
+func (h *TrialHandler) GetTrialTerms(c echo.Context) error {
+	preset := defaultTrialPreset
+	if tenant := requestTenant(c); tenant != nil {
+		preset = tenant.TrialPreset
+	}
+	if code := c.QueryParam("campaign_code"); code != "" {
+		var err error
+		if preset, err = auth.ValidateCampaignCode(code); err != nil {
+			return apiError(http.StatusBadRequest, "invalid campaign code")
+		}
+	}
+
+	limits, err := h.queries.GetTrialPreset(context.Background(), preset)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusBadRequest, "invalid campaign code")
+		}
+		log.Printf("[Trial] Failed to get trial limits: %v", err)
+		return apiError(http.StatusInternalServerError, "failed to get trial limits")
+	}
+
+	return c.JSON(http.StatusOK, TrialTermsResponse{
+		Preset:                    limits.Name,
+		MaxDurationSeconds:        int(limits.MaxDurationSeconds),
+		MaxSessions:               int(limits.MaxSessions),
+		MaxSessionDurationSeconds: int(limits.MaxSessionDurationSeconds),
+		ExpiryDays:                int(limits.ExpiryDays),
+	})
+}
+
 // ProvisionTrialKey creates or returns a trial key for a device
 func (h *TrialHandler) ProvisionTrialKey(c echo.Context) error {
 	var req ProvisionTrialKeyRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+		return apiError(http.StatusBadRequest, "invalid request body")
 	}
 
 	if req.DeviceFingerprint == "" {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "device_fingerprint is required"})
+		return apiError(http.StatusBadRequest, "device_fingerprint is required")
 	}
 
 	ctx := context.Background()
 
-	// Get trial limits
-	limits, err := h.queries.GetTrialLimits(ctx)
+	// Existing keys are re-issued too, so attest every provisioning
+	if failure := verifyTrialAttestation(ctx, req.Attestation); failure != nil {
+		return apiError(failure.status, failure.message)
+	}
+
+	// Keys provisioned through a tenant's domain join the tenant and, without
+	// a campaign code, get its preset
+	tenant := requestTenant(c)
+
+	// A campaign code selects the limits preset for new keys
+	preset := defaultTrialPreset
+	if tenant != nil {
+		preset = tenant.TrialPreset
+	}
+	if req.CampaignCode != "" {
+		var err error
+		preset, err = auth.ValidateCampaignCode(req.CampaignCode)
+		if err != nil {
+			return apiError(http.StatusBadRequest, "invalid campaign code")
+		}
+	}
+
+	limits, err := h.queries.GetTrialPreset(ctx, preset)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusBadRequest, "invalid campaign code")
+		}
 		log.Printf("[Trial] Failed to get trial limits: %v", err)
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to get trial limits"})
+		return apiError(http.StatusInternalServerError, "failed to get trial limits")
+	}
+
+	if req.Source != "" {
+		if _, err := h.queries.GetTrialSource(ctx, req.Source); err != nil {
+			if err == sql.ErrNoRows {
+				return apiError(http.StatusBadRequest, "unknown trial source")
+			}
+			return apiError(http.StatusInternalServerError, "database error")
+		}
+	}
+
+	// Fingerprints are stored encrypted, so look them up by blind index
+	fingerprintHash, err := encryption.BlindIndex(req.DeviceFingerprint)
+	if err != nil {
+		log.Printf("[Trial] Failed to hash fingerprint: %v", err)
+		return apiError(http.StatusInternalServerError, "encryption not configured")
+	}
+
+	// Networks are limited by blind index too, so client IPs are not stored
+	var subnetHash string
+	if subnet := clientSubnet(c.RealIP()); subnet != "" {
+		subnetHash, err = encryption.BlindIndex(subnet)
+		if err != nil {
+			log.Printf("[Trial] Failed to hash subnet: %v", err)
+			return apiError(http.StatusInternalServerError, "encryption not configured")
+		}
 	}
 
 	// Check if a trial key already exists for this fingerprint
-	existingKey, err := h.queries.GetTrialAPIKeyByFingerprint(ctx, req.DeviceFingerprint)
+	existingKey, err := h.queries.GetTrialAPIKeyByFingerprint(ctx, sqlc.GetTrialAPIKeyByFingerprintParams{
+		FingerprintHash:   sql.NullString{String: fingerprintHash, Valid: true},
+		DeviceFingerprint: req.DeviceFingerprint,
+	})
 	if err == nil {
-		// Key exists, return usage info
-		return h.returnExistingTrialKey(c, ctx, existingKey, limits)
+		// Key exists, return usage info; it keeps the preset and source it
+		// was provisioned with
+		return h.returnExistingTrialKey(c, ctx, existingKey)
 	}
 
 	if err != sql.ErrNoRows {
 		log.Printf("[Trial] Database error checking fingerprint: %v", err)
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
-	// Generate new trial API key: hw_trial_<32 random hex chars>
-	randomBytes := make([]byte, 16)
-	if _, err := rand.Read(randomBytes); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to generate key"})
+	decision, err := h.policy.Decide(ctx, h.queries, TrialRequest{SubnetHash: subnetHash, Now: time.Now()})
+	if err != nil {
+		log.Printf("[Trial] Failed to apply provisioning policy: %v", err)
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if decision.Action != TrialCreate {
+		log.Printf("[Trial] Refused new trial key: %s", decision.Message)
+		return trialDenied(c, decision)
 	}
 
-	keyRandom := hex.EncodeToString(randomBytes)
-	fullKey := fmt.Sprintf("hw_trial_%s", keyRandom)
-	keyPrefix := fullKey[:16] // "hw_trial_ab12cd34"
-
-	// Hash the key for storage
-	keyHash := hashTrialAPIKey(fullKey)
+	fullKey, keyPrefix, keyHash, err := newTrialKeySecret()
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to generate key")
+	}
 
 	// Calculate expiration
 	expiresAt := time.Now().AddDate(0, 0, int(limits.ExpiryDays))
 
 	trialKey, err := h.queries.CreateTrialAPIKey(ctx, sqlc.CreateTrialAPIKeyParams{
-		KeyHash:           keyHash,
-		KeyPrefix:         keyPrefix,
-		DeviceFingerprint: req.DeviceFingerprint,
-		ExpiresAt:         expiresAt,
+		KeyHash:               keyHash,
+		KeyPrefix:             keyPrefix,
+		DeviceFingerprint:     encryption.String(req.DeviceFingerprint),
+		DeviceFingerprintHash: sql.NullString{String: fingerprintHash, Valid: true},
+		ExpiresAt:             expiresAt,
+		Preset:                limits.Name,
+		SubnetHash:            sql.NullString{String: subnetHash, Valid: subnetHash != ""},
+		Source:                sql.NullString{String: req.Source, Valid: req.Source != ""},
+		TenantID:              tenantID(tenant),
 	})
 	if err != nil {
 		log.Printf("[Trial] Failed to create trial key: %v", err)
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to create trial key"})
+		return apiError(http.StatusInternalServerError, "failed to create trial key")
 	}
 
-	log.Printf("[Trial] Created new trial key for fingerprint: %s (prefix: %s)", req.DeviceFingerprint[:8], keyPrefix)
+	log.Printf("[Trial] Created new trial key %s", logging.KeyID(fullKey))
 
 	return c.JSON(http.StatusCreated, TrialKeyResponse{
 		Key:                      fullKey, // Only returned on creation
 		KeyPrefix:                keyPrefix,
+		Preset:                   trialKey.Preset,
 		RemainingDurationSeconds: float64(limits.MaxDurationSeconds),
 		RemainingSessions:        int64(limits.MaxSessions),
 		MaxSessionDuration:       int(limits.MaxSessionDurationSeconds),
@@ -162,29 +287,32 @@ func (h *TrialHandler) ProvisionTrialKey(c echo.Context) error {
 	})
 }
 
-// returnExistingTrialKey regenerates and returns the key for an existing trial
-func (h *TrialHandler) returnExistingTrialKey(c echo.Context, ctx context.Context, key sqlc.TrialApiKey, limits sqlc.TrialLimit) error {
-	// Check if key is expired
-	expired := time.Now().After(key.ExpiresAt)
-
+// returnExistingTrialKey regenerates and returns the key for an existing
+// trial, if the provisioning policy allows re-issuing it
+func (h *TrialHandler) returnExistingTrialKey(c echo.Context, ctx context.Context, key sqlc.TrialApiKey) error {
 	// Check if key is revoked
 	if key.RevokedAt.Valid {
-		return c.JSON(http.StatusForbidden, ErrorResponse{
+		return newAPIError(http.StatusForbidden, ErrorResponse{
 			Error:   "trial key revoked",
-			Details: map[string]string{"upgrade_url": getUpgradeURL()},
+			Details: map[string]string{"upgrade_url": getUpgradeURL(key.ID)},
 		})
 	}
 
-	// Generate a new key for this device (since we can't retrieve the hashed one)
-	randomBytes := make([]byte, 16)
-	if _, err := rand.Read(randomBytes); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to generate key"})
+	decision, err := h.policy.Decide(ctx, h.queries, TrialRequest{Existing: &key, Now: time.Now()})
+	if err != nil {
+		log.Printf("[Trial] Failed to apply provisioning policy: %v", err)
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	if decision.Action != TrialReissue {
+		log.Printf("[Trial] Refused to re-issue trial key %s: %s", logging.StoredKeyID(key.KeyPrefix, key.KeyHash), decision.Message)
+		return trialDenied(c, decision)
 	}
 
-	keyRandom := hex.EncodeToString(randomBytes)
-	fullKey := fmt.Sprintf("hw_trial_%s", keyRandom)
-	keyPrefix := fullKey[:16]
-	keyHash := hashTrialAPIKey(fullKey)
+	// Generate a new key for this device (since we can't retrieve the hashed one)
+	fullKey, keyPrefix, keyHash, err := newTrialKeySecret()
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to generate key")
+	}
 
 	// Update the key hash in the database
 	updatedKey, err := h.queries.RegenerateTrialAPIKey(ctx, sqlc.RegenerateTrialAPIKeyParams{
@@ -194,16 +322,76 @@ func (h *TrialHandler) returnExistingTrialKey(c echo.Context, ctx context.Contex
 	})
 	if err != nil {
 		log.Printf("[Trial] Failed to regenerate key: %v", err)
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to regenerate key"})
+		return apiError(http.StatusInternalServerError, "failed to regenerate key")
+	}
+
+	log.Printf("[Trial] Regenerated trial key %s", logging.KeyID(fullKey))
+
+	return h.respondWithTrialKey(c, ctx, updatedKey, fullKey)
+}
+
+// RotateTrialKey replaces the secret of the trial key sent in X-API-Key. The
+// old key stops working immediately; sessions already open keep running.
+// Rotating proves possession of the current key, so unlike re-issuing on
+// provisioning it is not limited by the provisioning policy.
+func (h *TrialHandler) RotateTrialKey(c echo.Context) error {
+	apiKey := c.Request().Header.Get("X-API-Key")
+	if apiKey == "" {
+		return apiError(http.StatusBadRequest, "API key required")
+	}
+	if err := checkKeyFormat(apiKey); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	currentHash := hashTrialAPIKey(apiKey)
+	key, err := h.queries.GetTrialAPIKeyByHash(ctx, currentHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apiError(http.StatusUnauthorized, "invalid trial key")
+		}
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	fullKey, keyPrefix, keyHash, err := newTrialKeySecret()
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to generate key")
+	}
+
+	rotated, err := h.queries.RotateTrialAPIKey(ctx, sqlc.RotateTrialAPIKeyParams{
+		NewKeyHash:     keyHash,
+		KeyPrefix:      keyPrefix,
+		ID:             key.ID,
+		CurrentKeyHash: currentHash,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// Rotated or revoked since the lookup
+			return apiError(http.StatusUnauthorized, "invalid trial key")
+		}
+		log.Printf("[Trial] Failed to rotate key: %v", err)
+		return apiError(http.StatusInternalServerError, "failed to rotate key")
 	}
 
-	log.Printf("[Trial] Regenerated trial key for fingerprint (prefix: %s)", keyPrefix)
+	log.Printf("[Trial] Rotated trial key %s -> %s", logging.StoredKeyID(key.KeyPrefix, key.KeyHash), logging.KeyID(fullKey))
+
+	return h.respondWithTrialKey(c, ctx, rotated, fullKey)
+}
+
+// respondWithTrialKey returns a re-issued trial key with its remaining quota
+func (h *TrialHandler) respondWithTrialKey(c echo.Context, ctx context.Context, key sqlc.TrialApiKey, fullKey string) error {
+	limits, err := h.queries.GetTrialPreset(ctx, key.Preset)
+	if err != nil {
+		log.Printf("[Trial] Failed to get trial limits: %v", err)
+		return apiError(http.StatusInternalServerError, "failed to get trial limits")
+	}
 
 	// Get usage summary
 	summary, err := h.queries.GetTrialUsageSummary(ctx, key.ID)
 	if err != nil {
 		log.Printf("[Trial] Failed to get usage summary: %v", err)
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to get usage"})
+		return apiError(http.StatusInternalServerError, "failed to get usage")
 	}
 
 	usedDuration := parseDecimalString(summary.TotalDurationSeconds)
@@ -220,14 +408,15 @@ func (h *TrialHandler) returnExistingTrialKey(c echo.Context, ctx context.Contex
 	quotaExceeded := remainingDuration <= 0 || remainingSessions <= 0
 
 	return c.JSON(http.StatusOK, TrialKeyResponse{
-		Key:                      fullKey, // Return the regenerated key
-		KeyPrefix:                updatedKey.KeyPrefix,
+		Key:                      fullKey,
+		KeyPrefix:                key.KeyPrefix,
+		Preset:                   key.Preset,
 		RemainingDurationSeconds: remainingDuration,
 		RemainingSessions:        remainingSessions,
 		MaxSessionDuration:       int(limits.MaxSessionDurationSeconds),
-		ExpiresAt:                updatedKey.ExpiresAt.Format(time.RFC3339),
+		ExpiresAt:                key.ExpiresAt.Format(time.RFC3339),
 		QuotaExceeded:            quotaExceeded,
-		Expired:                  expired,
+		Expired:                  time.Now().After(key.ExpiresAt),
 	})
 }
 
@@ -239,7 +428,10 @@ func (h *TrialHandler) GetTrialUsage(c echo.Context) error {
 		apiKey = c.Request().Header.Get("X-API-Key")
 	}
 	if apiKey == "" {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "api_key required"})
+		return apiError(http.StatusBadRequest, "api_key required")
+	}
+	if err := checkKeyFormat(apiKey); err != nil {
+		return err
 	}
 
 	ctx := context.Background()
@@ -249,21 +441,21 @@ func (h *TrialHandler) GetTrialUsage(c echo.Context) error {
 	trialKey, err := h.queries.GetTrialAPIKeyByHash(ctx, keyHash)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "invalid trial key"})
+			return apiError(http.StatusUnauthorized, "invalid trial key")
 		}
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	// Get trial limits
-	limits, err := h.queries.GetTrialLimits(ctx)
+	limits, err := h.queries.GetTrialPreset(ctx, trialKey.Preset)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to get limits"})
+		return apiError(http.StatusInternalServerError, "failed to get limits")
 	}
 
 	// Get usage summary
 	summary, err := h.queries.GetTrialUsageSummary(ctx, trialKey.ID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to get usage"})
+		return apiError(http.StatusInternalServerError, "failed to get usage")
 	}
 
 	usedDuration := parseDecimalString(summary.TotalDurationSeconds)
@@ -299,7 +491,10 @@ func (h *TrialHandler) GetTrialStatus(c echo.Context) error {
 		apiKey = c.Request().Header.Get("X-API-Key")
 	}
 	if apiKey == "" {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "api_key required"})
+		return apiError(http.StatusBadRequest, "api_key required")
+	}
+	if err := checkKeyFormat(apiKey); err != nil {
+		return err
 	}
 
 	ctx := context.Background()
@@ -309,24 +504,24 @@ func (h *TrialHandler) GetTrialStatus(c echo.Context) error {
 	trialKey, err := h.queries.GetTrialAPIKeyByHash(ctx, keyHash)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "invalid trial key"})
+			return apiError(http.StatusUnauthorized, "invalid trial key")
 		}
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	// Check if expired
 	expired := time.Now().After(trialKey.ExpiresAt)
 
 	// Get trial limits
-	limits, err := h.queries.GetTrialLimits(ctx)
+	limits, err := h.queries.GetTrialPreset(ctx, trialKey.Preset)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to get limits"})
+		return apiError(http.StatusInternalServerError, "failed to get limits")
 	}
 
 	// Get usage summary
 	summary, err := h.queries.GetTrialUsageSummary(ctx, trialKey.ID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to get usage"})
+		return apiError(http.StatusInternalServerError, "failed to get usage")
 	}
 
 	usedDuration := parseDecimalString(summary.TotalDurationSeconds)
@@ -344,6 +539,7 @@ func (h *TrialHandler) GetTrialStatus(c echo.Context) error {
 
 	response := TrialStatusResponse{
 		Active:                   !expired && !quotaExceeded && !trialKey.RevokedAt.Valid,
+		Preset:                   trialKey.Preset,
 		RemainingDurationSeconds: remainingDuration,
 		RemainingSessions:        remainingSessions,
 		ExpiresAt:                trialKey.ExpiresAt.Format(time.RFC3339),
@@ -351,9 +547,15 @@ func (h *TrialHandler) GetTrialStatus(c echo.Context) error {
 		QuotaExceeded:            quotaExceeded,
 	}
 
+	_, err = h.queries.GetTrialConversionByKey(ctx, uuid.NullUUID{UUID: trialKey.ID, Valid: true})
+	if err != nil && err != sql.ErrNoRows {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	response.Converted = err == nil
+
 	// Add upgrade URL if quota exceeded or expired
-	if quotaExceeded || expired {
-		response.UpgradeURL = getUpgradeURL()
+	if (quotaExceeded || expired) && !response.Converted {
+		response.UpgradeURL = getUpgradeURL(trialKey.ID)
 	}
 
 	return c.JSON(http.StatusOK, response)
@@ -370,9 +572,30 @@ func (h *TrialHandler) TrialDeepgramProxy(c echo.Context) error {
 	}
 	if apiKey == "" {
 		log.Printf("[Trial Deepgram] No API key provided")
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "API key required"})
+		recordAuthEvent(c, apiKey, keyAuthMissing, uuid.NullUUID{})
+		return apiError(http.StatusUnauthorized, "API key required")
+	}
+	if err := checkKeyFormat(apiKey); err != nil {
+		recordAuthEvent(c, apiKey, keyAuthMalformed, uuid.NullUUID{})
+		return err
+	}
+	log.Printf("[Trial Deepgram] API key received (%s)", requestKeyID(c))
+
+	protocol, err := negotiateProtocol(c)
+	if err != nil {
+		return err
+	}
+
+	if Sessions.Draining() {
+		return apiError(http.StatusServiceUnavailable, "server is restarting, please reconnect")
+	}
+
+	// All sessions run on the shared Deepgram key, so all count against
+	// the monthly budget
+	if status, exceeded := Budget.Exceeded(h.queries); exceeded {
+		log.Printf("[Trial Deepgram] Monthly budget reached, refusing session")
+		return budgetExceededError(c, status)
 	}
-	log.Printf("[Trial Deepgram] API key received (prefix: %s...)", apiKey[:16])
 
 	ctx := context.Background()
 
@@ -382,42 +605,49 @@ func (h *TrialHandler) TrialDeepgramProxy(c echo.Context) error {
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("[Trial Deepgram] Invalid trial API key - not found")
-			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "invalid trial key"})
+			outcome := keyAuthInvalid
+			if revoked, err := h.queries.IsTrialAPIKeyRevoked(ctx, keyHash); err == nil && revoked {
+				outcome = keyAuthRevoked
+			}
+			recordAuthEvent(c, apiKey, outcome, uuid.NullUUID{})
+			return apiError(http.StatusUnauthorized, "invalid trial key")
 		}
 		log.Printf("[Trial Deepgram] Database error: %v", err)
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database error"})
+		return apiError(http.StatusInternalServerError, "database error")
 	}
 
 	// Check if key is expired
 	if time.Now().After(trialKey.ExpiresAt) {
 		log.Printf("[Trial Deepgram] Trial key expired")
-		return c.JSON(http.StatusForbidden, ErrorResponse{
+		recordAuthEvent(c, apiKey, keyAuthExpired, uuid.NullUUID{})
+		return newAPIError(http.StatusForbidden, ErrorResponse{
 			Error:   "trial key expired",
-			Details: map[string]string{"upgrade_url": getUpgradeURL()},
+			Details: map[string]string{"upgrade_url": getUpgradeURL(trialKey.ID)},
 		})
 	}
 
 	// Check if key is revoked
 	if trialKey.RevokedAt.Valid {
 		log.Printf("[Trial Deepgram] Trial key revoked")
-		return c.JSON(http.StatusForbidden, ErrorResponse{
+		recordAuthEvent(c, apiKey, keyAuthRevoked, uuid.NullUUID{})
+		return newAPIError(http.StatusForbidden, ErrorResponse{
 			Error:   "trial key revoked",
-			Details: map[string]string{"upgrade_url": getUpgradeURL()},
+			Details: map[string]string{"upgrade_url": getUpgradeURL(trialKey.ID)},
 		})
 	}
 
 	// Get trial limits
-	limits, err := h.queries.GetTrialLimits(ctx)
+	limits, err := h.queries.GetTrialPreset(ctx, trialKey.Preset)
 	if err != nil {
 		log.Printf("[Trial Deepgram] Failed to get limits: %v", err)
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to get limits"})
+		return apiError(http.StatusInternalServerError, "failed to get limits")
 	}
 
 	// Get current usage
 	summary, err := h.queries.GetTrialUsageSummary(ctx, trialKey.ID)
 	if err != nil {
 		log.Printf("[Trial Deepgram] Failed to get usage: %v", err)
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to get usage"})
+		return apiError(http.StatusInternalServerError, "failed to get usage")
 	}
 
 	usedDuration := parseDecimalString(summary.TotalDurationSeconds)
@@ -427,16 +657,27 @@ func (h *TrialHandler) TrialDeepgramProxy(c echo.Context) error {
 	// Check quota
 	if remainingDuration <= 0 || remainingSessions <= 0 {
 		log.Printf("[Trial Deepgram] Quota exceeded - duration: %.2f, sessions: %d", remainingDuration, remainingSessions)
-		return c.JSON(http.StatusForbidden, ErrorResponse{
+		recordAuthEvent(c, apiKey, keyAuthQuotaExceeded, uuid.NullUUID{})
+		return newAPIError(http.StatusForbidden, ErrorResponse{
 			Error:   "trial quota exceeded",
-			Details: map[string]string{"upgrade_url": getUpgradeURL()},
+			Details: map[string]string{"upgrade_url": getUpgradeURL(trialKey.ID)},
 		})
 	}
 
-	// Calculate session timeout: min(per-session limit, remaining quota)
+	recordAuthEvent(c, apiKey, keyAuthSuccess, uuid.NullUUID{})
+
+	// Calculate session timeout: min(per-session limit, remaining quota,
+	// time until the key expires), remembering which limit applies so the
+	// client is closed with the matching code
 	sessionTimeout := time.Duration(limits.MaxSessionDurationSeconds) * time.Second
+	timeoutCode := CloseSessionLimit
 	if remainingDuration < float64(limits.MaxSessionDurationSeconds) {
 		sessionTimeout = time.Duration(remainingDuration) * time.Second
+		timeoutCode = CloseQuotaExceeded
+	}
+	if untilExpiry := time.Until(trialKey.ExpiresAt); untilExpiry < sessionTimeout {
+		sessionTimeout = untilExpiry
+		timeoutCode = CloseTrialExpired
 	}
 	log.Printf("[Trial Deepgram] Session timeout: %v (remaining: %.2fs)", sessionTimeout, remainingDuration)
 
@@ -445,31 +686,74 @@ func (h *TrialHandler) TrialDeepgramProxy(c echo.Context) error {
 		_ = h.queries.UpdateTrialAPIKeyLastUsed(context.Background(), trialKey.ID)
 	}()
 
-	// Extract Deepgram params from query string
-	deepgramParams := extractDeepgramParams(c.Request().URL.Query())
+	// Extract Deepgram params from query string, applying session policies
+	policy, err := enforceSessionPolicy(ctx, h.queries, sessionTarget{
+		trial:    true,
+		apiKeyID: trialKey.ID,
+	}, "Trial Deepgram")
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "failed to resolve session policy")
+	}
+	// Reject params trial keys may not use before anything reaches Deepgram
+	restrictions := parseParamRestrictions(limits.ParamRestrictions)
+	if violations := restrictions.violations(c.Request().URL.Query()); violations != nil {
+		log.Printf("[Trial Deepgram] Rejected restricted params: %v", violations)
+		violations["upgrade_url"] = getUpgradeURL(trialKey.ID)
+		return paramRestrictionError(c, violations)
+	}
 
-	// Get Deepgram API key from environment
-	deepgramAPIKey := os.Getenv("DEEPGRAM_API_KEY")
+	defaults := restrictions.permitted(loadDeepgramDefaults(ctx, h.queries, "Trial Deepgram"))
+	deepgramParams := extractDeepgramParams(c.Request().URL.Query(), defaults, policy.params)
+	restrictions.applyDefaults(deepgramParams)
+	redactionAudit := auditRedactions(c.Request().URL.Query(), policy, restrictions, deepgramParams)
+
+	// Trials provisioned through a tenant stream with its key, if it has one
+	deepgramAPIKey, err := upstreamAPIKey(ctx, h.queries, trialKey.TenantID)
+	if err != nil {
+		log.Printf("[Trial Deepgram] Failed to get tenant of %s: %v", requestKeyID(c), err)
+		return apiError(http.StatusInternalServerError, "database error")
+	}
 	if deepgramAPIKey == "" {
 		log.Printf("[Trial Deepgram] ERROR: DEEPGRAM_API_KEY not set")
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Deepgram not configured"})
+		return apiError(http.StatusInternalServerError, "Deepgram not configured")
+	}
+	region, err := sessionRegion(ctx, h.queries, uuid.NullUUID{}, trialKey.TenantID)
+	if err != nil {
+		log.Printf("[Trial Deepgram] Failed to resolve data region of %s: %v", requestKeyID(c), err)
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	// Enforce the trial plan's concurrent session limit
+	slot, ok := reserveSessionSlot("trial", trialKey.ID.String())
+	if !ok {
+		log.Printf("[Trial Deepgram] Concurrent session limit reached for %s", requestKeyID(c))
+		return concurrencyLimitError(c, "trial")
 	}
+	defer slot.Release()
 
-	// Create usage log
+	// Create the usage log, or reopen the one a failed attempt of the same
+	// session left
+	nonce, ok := sessionNonce(c)
+	if !ok {
+		return apiError(http.StatusBadRequest, "session_nonce is too long")
+	}
 	paramsJSON, _ := json.Marshal(deepgramParams)
 	clientIP := c.RealIP()
 
-	usageLog, err := h.queries.CreateTrialUsageLog(ctx, sqlc.CreateTrialUsageLogParams{
+	usageLog, err := openTrialUsageLog(ctx, h.queries, sqlc.CreateTrialUsageLogParams{
 		TrialKeyID:     trialKey.ID,
 		DeepgramParams: paramsJSON,
-		ClientIp:       sql.NullString{String: clientIP, Valid: clientIP != ""},
+		ClientIp:       encryption.NullString{String: clientIP, Valid: clientIP != ""},
+		RedactionAudit: redactionAudit,
+		SessionNonce:   nonce,
 	})
 	if err != nil {
 		log.Printf("[Trial Deepgram] Failed to create usage log: %v", err)
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to create log"})
+		return apiError(http.StatusInternalServerError, "failed to create log")
 	}
 
 	// Upgrade to WebSocket
+	lang := requestLanguage(c)
 	clientConn, err := h.upgrader.Upgrade(c.Response(), c.Request(), nil)
 	if err != nil {
 		_ = h.queries.UpdateTrialUsageError(ctx, sqlc.UpdateTrialUsageErrorParams{
@@ -481,13 +765,21 @@ func (h *TrialHandler) TrialDeepgramProxy(c echo.Context) error {
 	}
 	defer clientConn.Close()
 
+	// Wait for a concurrency slot if the key is at its limit
+	if err := slot.wait(clientConn, "Trial Deepgram", lang); err != nil {
+		_ = h.queries.UpdateTrialUsageError(ctx, sqlc.UpdateTrialUsageErrorParams{
+			ID:           usageLog.ID,
+			ErrorMessage: sql.NullString{String: err.Error(), Valid: true},
+			BytesSent:    0,
+		})
+		return nil
+	}
+
 	// Connect to Deepgram
-	deepgramURL := buildDeepgramURL(deepgramParams)
+	deepgramURL := buildDeepgramURL(region, deepgramParams)
 	log.Printf("[Trial Deepgram] Connecting to: %s", deepgramURL)
 
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
+	dialer := newUpstreamDialer()
 
 	headers := http.Header{}
 	headers.Set("Authorization", fmt.Sprintf("Token %s", deepgramAPIKey))
@@ -495,6 +787,7 @@ func (h *TrialHandler) TrialDeepgramProxy(c echo.Context) error {
 	deepgramConn, resp, err := dialer.Dial(deepgramURL, headers)
 	if err != nil {
 		log.Printf("[Trial Deepgram] Connection failed: %v", err)
+		publishSessionError("trial", fmt.Sprintf("deepgram connection failed: %v", err))
 		if resp != nil {
 			log.Printf("[Trial Deepgram] Response status: %d", resp.StatusCode)
 		}
@@ -503,8 +796,7 @@ func (h *TrialHandler) TrialDeepgramProxy(c echo.Context) error {
 			ErrorMessage: sql.NullString{String: fmt.Sprintf("deepgram connection failed: %v", err), Valid: true},
 			BytesSent:    0,
 		})
-		_ = clientConn.WriteMessage(websocket.CloseMessage,
-			websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "Failed to connect to Deepgram"))
+		closeClient(clientConn, CloseUpstreamFailure, lang)
 		return nil
 	}
 	defer deepgramConn.Close()
@@ -512,17 +804,24 @@ func (h *TrialHandler) TrialDeepgramProxy(c echo.Context) error {
 
 	// Create trial proxy session
 	session := &trialProxySession{
-		clientConn:     clientConn,
-		deepgramConn:   deepgramConn,
-		logID:          usageLog.ID,
-		queries:        h.queries,
-		bytesSent:      0,
-		duration:       0,
-		maxDuration:    sessionTimeout,
-		startTime:      time.Now(),
-		trialKeyPrefix: trialKey.KeyPrefix,
+		clientConn:    clientConn,
+		deepgramConn:  deepgramConn,
+		lang:          lang,
+		logID:         usageLog.ID,
+		queries:       h.queries,
+		bytesSent:     0,
+		duration:      0,
+		maxDuration:   sessionTimeout,
+		timeoutCode:   timeoutCode,
+		startTime:     time.Now(),
+		audio:         newAudioClock(deepgramParams),
+		trialKeyID:    trialKey.ID,
+		trialKeyLogID: requestKeyID(c),
+		pacing:        newPacingMonitor(protocol, deepgramParams),
 	}
 
+	_ = sendSessionStarted(clientConn, protocol, usageLog.ID.String(), sessionTimeout)
+
 	// Start bidirectional proxy with timeout
 	session.run()
 
@@ -531,27 +830,40 @@ func (h *TrialHandler) TrialDeepgramProxy(c echo.Context) error {
 
 // trialProxySession manages a trial WebSocket proxy session with timeout
 type trialProxySession struct {
-	clientConn     *websocket.Conn
-	deepgramConn   *websocket.Conn
-	logID          uuid.UUID
-	queries        *sqlc.Queries
-	trialKeyPrefix string
-
+	clientConn    *websocket.Conn
+	deepgramConn  *websocket.Conn
+	lang          string // Language of close reasons
+	logID         uuid.UUID
+	queries       *sqlc.Queries
+	trialKeyID    uuid.UUID
+	trialKeyLogID string         // logging.KeyID of the trial key
+	pacing        *pacingMonitor // nil unless the client takes adaptation hints
+
+	// clientMu serializes writes to clientConn
+	clientMu    sync.Mutex
 	mu          sync.Mutex
 	bytesSent   int64
 	duration    float64
 	maxDuration time.Duration
+	timeoutCode CloseCode
 	startTime   time.Time
+	audio       audioClock
 	closed      bool
 }
 
 func (s *trialProxySession) run() {
+	id, ok := Sessions.Add(trialKeySessionTag(s.trialKeyID), s.shutdown)
+	if !ok {
+		s.shutdown(CloseServerRestart)
+	}
+	defer Sessions.Remove(id)
+
 	var wg sync.WaitGroup
 	wg.Add(2)
 
 	// Set up session timeout
 	timeout := time.AfterFunc(s.maxDuration, func() {
-		log.Printf("[Trial Deepgram] Session timeout reached for %s", s.trialKeyPrefix)
+		log.Printf("[Trial Deepgram] Session timeout reached for %s", s.trialKeyLogID)
 		s.closeWithTimeout()
 	})
 	defer timeout.Stop()
@@ -576,10 +888,14 @@ func (s *trialProxySession) run() {
 
 func (s *trialProxySession) proxyClientToDeepgram() {
 	for {
+		extendIdleDeadline(s.clientConn)
 		messageType, data, err := s.clientConn.ReadMessage()
 		if err != nil {
 			log.Printf("[Trial Deepgram] Client read error: %v", err)
-			_ = s.deepgramConn.WriteMessage(websocket.TextMessage, []byte(`{"type":"CloseStream"}`))
+			if isIdleTimeout(err) {
+				closeClient(s.clientConn, CloseIdleTimeout, s.lang)
+			}
+			_ = writeMessage(s.deepgramConn, websocket.TextMessage, []byte(`{"type":"CloseStream"}`))
 			return
 		}
 
@@ -587,17 +903,35 @@ func (s *trialProxySession) proxyClientToDeepgram() {
 		if messageType == websocket.BinaryMessage {
 			s.mu.Lock()
 			s.bytesSent += int64(len(data))
+			s.audio.add(len(data), time.Now())
 			s.mu.Unlock()
+			Events.AddBytes(len(data))
 		}
 
 		// Forward to Deepgram
-		if err := s.deepgramConn.WriteMessage(messageType, data); err != nil {
+		start := time.Now()
+		if err := writeMessage(s.deepgramConn, messageType, data); err != nil {
 			log.Printf("[Trial Deepgram] Error forwarding to Deepgram: %v", err)
 			return
 		}
+		if messageType == websocket.BinaryMessage {
+			if hint := s.pacing.observe(len(data), start, time.Since(start)); hint != nil {
+				log.Printf("[Trial Deepgram] Adaptation hint for %s: %s (write latency %dms, lag %dms)",
+					s.trialKeyLogID, hint.Reason, hint.WriteLatencyMs, hint.LagMs)
+				sendAdaptationHint(s.clientConn, &s.clientMu, hint)
+			}
+		}
 	}
 }
 
+// writeClient forwards a message to the client; adaptation hints are
+// written from another goroutine
+func (s *trialProxySession) writeClient(messageType int, data []byte) error {
+	s.clientMu.Lock()
+	defer s.clientMu.Unlock()
+	return writeMessage(s.clientConn, messageType, data)
+}
+
 func (s *trialProxySession) proxyDeepgramToClient() {
 	clientClosed := false
 
@@ -605,6 +939,10 @@ func (s *trialProxySession) proxyDeepgramToClient() {
 		messageType, data, err := s.deepgramConn.ReadMessage()
 		if err != nil {
 			log.Printf("[Trial Deepgram] Deepgram read error: %v", err)
+			if !clientClosed {
+				closeClient(s.clientConn, upstreamCloseCode(err), s.lang)
+				s.clientConn.Close()
+			}
 			return
 		}
 
@@ -617,7 +955,7 @@ func (s *trialProxySession) proxyDeepgramToClient() {
 			}
 			if json.Unmarshal(data, &msg) == nil && msg.Type == "Metadata" {
 				if !clientClosed {
-					if err := s.clientConn.WriteMessage(messageType, data); err != nil {
+					if err := s.writeClient(messageType, data); err != nil {
 						clientClosed = true
 					}
 				}
@@ -627,7 +965,7 @@ func (s *trialProxySession) proxyDeepgramToClient() {
 
 		// Forward to client
 		if !clientClosed {
-			if err := s.clientConn.WriteMessage(messageType, data); err != nil {
+			if err := s.writeClient(messageType, data); err != nil {
 				log.Printf("[Trial Deepgram] Error forwarding to client: %v", err)
 				clientClosed = true
 			}
@@ -666,12 +1004,17 @@ func (s *trialProxySession) closeWithTimeout() {
 	}
 	s.mu.Unlock()
 
-	_ = s.clientConn.WriteMessage(websocket.CloseMessage,
-		websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Trial session time limit reached"))
+	closeClient(s.clientConn, s.timeoutCode, s.lang)
 	s.clientConn.Close()
 	s.deepgramConn.Close()
 }
 
+// shutdown closes the client connection with the given code
+func (s *trialProxySession) shutdown(code CloseCode) {
+	closeClient(s.clientConn, code, s.lang)
+	s.clientConn.Close()
+}
+
 func (s *trialProxySession) finalize() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -692,8 +1035,18 @@ func (s *trialProxySession) finalize() {
 			DurationSeconds: stringToNumeric(durationStr),
 			BytesSent:       s.bytesSent,
 		})
+	} else if estimate := s.audio.estimate(); estimate > 0 {
+		// The final metadata never arrived; bill what was streamed so
+		// aborting cannot bypass the trial quota
+		durationStr := fmt.Sprintf("%.3f", estimate)
+		log.Printf("[Trial Deepgram] Estimated duration: %s seconds", durationStr)
+		_ = s.queries.UpdateTrialUsageEstimated(ctx, sqlc.UpdateTrialUsageEstimatedParams{
+			ID:              s.logID,
+			DurationSeconds: stringToNumeric(durationStr),
+			BytesSent:       s.bytesSent,
+		})
 	} else {
-		// No duration captured - treat as timeout
+		// No audio was streamed - treat as timeout
 		_ = s.queries.UpdateTrialUsageTimeout(ctx, sqlc.UpdateTrialUsageTimeoutParams{
 			ID:        s.logID,
 			BytesSent: s.bytesSent,
@@ -703,33 +1056,40 @@ func (s *trialProxySession) finalize() {
 
 // ========== HELPER FUNCTIONS ==========
 
+// defaultTrialPreset is the limits preset of keys provisioned without a
+// campaign code
+const defaultTrialPreset = db.DefaultTrialPreset
+
+// newTrialKeySecret generates a trial API key (hw_trial_<32 random hex
+// chars>) with its display prefix and storage hash
+func newTrialKeySecret() (fullKey, keyPrefix, keyHash string, err error) {
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", "", "", err
+	}
+
+	fullKey = trialKeyPrefix + hex.EncodeToString(randomBytes)
+	return fullKey, logging.KeyPrefix(fullKey), hashTrialAPIKey(fullKey), nil
+}
+
 func hashTrialAPIKey(key string) string {
 	hash := sha256.Sum256([]byte(key))
 	return hex.EncodeToString(hash[:])
 }
 
-func getUpgradeURL() string {
-	baseURL := os.Getenv("APP_BASE_URL")
-	if baseURL == "" {
-		baseURL = "https://hyperwhisper.dev"
+// getUpgradeURL returns the trial's signup deep link. Its signed carryover
+// token lets the signup credit the new account and convert the trial.
+func getUpgradeURL(trialKeyID uuid.UUID) string {
+	signup := config.String("APP_BASE_URL") + "/signup"
+	token, err := auth.GenerateTrialUpgradeToken(trialKeyID, config.Duration("TRIAL_UPGRADE_LINK_TTL"))
+	if err != nil {
+		log.Printf("[Trial] Failed to sign upgrade link: %v", err)
+		return signup
 	}
-	return baseURL + "/signup"
+	return signup + "?trial=" + url.QueryEscape(token)
 }
 
 // IsTrialKey checks if an API key is a trial key (hw_trial_ prefix)
 func IsTrialKey(apiKey string) bool {
-	return len(apiKey) >= 9 && apiKey[:9] == "hw_trial_"
-}
-
-// getTrialExpiryDays returns the trial expiry days from env or default
-func getTrialExpiryDays() int {
-	expiryStr := os.Getenv("TRIAL_EXPIRY_DAYS")
-	if expiryStr == "" {
-		return 90
-	}
-	expiry, err := strconv.Atoi(expiryStr)
-	if err != nil {
-		return 90
-	}
-	return expiry
+	return strings.HasPrefix(apiKey, trialKeyPrefix)
 }
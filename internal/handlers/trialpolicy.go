@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== TRIAL PROVISIONING POLICY ==========
+
+// TrialAction is what a trial provisioning request gets
+type TrialAction int
+
+const (
+	// TrialCreate provisions a new key for a device without one
+	TrialCreate TrialAction = iota
+	// TrialReissue replaces the secret of the device's existing key
+	TrialReissue
+	// TrialDeny refuses the request
+	TrialDeny
+)
+
+// TrialRequest is a provisioning request as seen by a TrialPolicy
+type TrialRequest struct {
+	Existing   *sqlc.TrialApiKey // The device's key, nil for a new device
+	SubnetHash string            // Blind index of the client's network, empty if unknown
+	Now        time.Time
+}
+
+// TrialDecision is a TrialPolicy's verdict. Denials carry the HTTP status
+// and message to respond with, and RetryAfter when waiting would help.
+type TrialDecision struct {
+	Action     TrialAction
+	Status     int
+	Message    string
+	RetryAfter time.Duration
+}
+
+// TrialPolicy decides whether a device gets a new trial key, a re-issued
+// secret for its existing one, or nothing. Revoked keys are refused before
+// the policy is asked.
+type TrialPolicy interface {
+	Decide(ctx context.Context, queries *sqlc.Queries, req TrialRequest) (TrialDecision, error)
+}
+
+// ConfigTrialPolicy is the default policy. It re-issues an existing key's
+// secret at most TRIAL_MAX_REGENERATIONS times and no more often than
+// TRIAL_MIN_PROVISION_INTERVAL, so knowing a device fingerprint is not
+// enough to take over its trial, and limits new keys per network to
+// TRIAL_MAX_PER_SUBNET.
+type ConfigTrialPolicy struct{}
+
+// Decide implements TrialPolicy
+func (ConfigTrialPolicy) Decide(ctx context.Context, queries *sqlc.Queries, req TrialRequest) (TrialDecision, error) {
+	if req.Existing != nil {
+		return decideTrialReissue(*req.Existing, req.Now), nil
+	}
+
+	maxPerSubnet := config.Int("TRIAL_MAX_PER_SUBNET")
+	if maxPerSubnet > 0 && req.SubnetHash != "" {
+		count, err := queries.CountRecentTrialAPIKeysBySubnet(ctx, sqlc.CountRecentTrialAPIKeysBySubnetParams{
+			SubnetHash: sql.NullString{String: req.SubnetHash, Valid: true},
+			CreatedAt:  sql.NullTime{Time: req.Now.Add(-config.Duration("TRIAL_SUBNET_WINDOW")), Valid: true},
+		})
+		if err != nil {
+			return TrialDecision{}, err
+		}
+		if count >= int64(maxPerSubnet) {
+			return TrialDecision{Action: TrialDeny, Status: http.StatusTooManyRequests, Message: "too many trials from this network"}, nil
+		}
+	}
+	return TrialDecision{Action: TrialCreate}, nil
+}
+
+// decideTrialReissue applies the re-issue limits to a device's existing key
+func decideTrialReissue(key sqlc.TrialApiKey, now time.Time) TrialDecision {
+	if int(key.Regenerations) >= config.Int("TRIAL_MAX_REGENERATIONS") {
+		return TrialDecision{Action: TrialDeny, Status: http.StatusForbidden, Message: "trial key cannot be re-issued"}
+	}
+
+	// Keys provisioned before the policy existed fall back to their creation
+	last := key.LastProvisionedAt
+	if !last.Valid {
+		last = key.CreatedAt
+	}
+	if interval := config.Duration("TRIAL_MIN_PROVISION_INTERVAL"); interval > 0 && last.Valid {
+		if wait := last.Time.Add(interval).Sub(now); wait > 0 {
+			return TrialDecision{
+				Action:     TrialDeny,
+				Status:     http.StatusTooManyRequests,
+				Message:    "trial key was provisioned too recently",
+				RetryAfter: wait,
+			}
+		}
+	}
+	return TrialDecision{Action: TrialReissue}
+}
+
+// trialDenied responds with a policy's refusal
+func trialDenied(c echo.Context, d TrialDecision) error {
+	if d.RetryAfter > 0 {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.RetryAfter.Seconds()))))
+	}
+	return apiError(d.Status, d.Message)
+}
+
+// clientSubnet is the /24 (IPv4) or /64 (IPv6) network of ip, or "" if ip
+// does not parse
+func clientSubnet(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+	bits := 64
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.String()
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+
+	"hyperwhisper/internal/config"
+)
+
+// ========== TRUSTED PROXIES ==========
+
+// trustedProxySet is TRUSTED_PROXIES parsed, kept with the raw value so a
+// reloaded setting is picked up
+type trustedProxySet struct {
+	raw      string
+	prefixes []netip.Prefix
+}
+
+var trustedProxies atomic.Pointer[trustedProxySet]
+
+// currentTrustedProxies returns the networks whose forwarding headers are
+// believed
+func currentTrustedProxies() []netip.Prefix {
+	raw := config.String("TRUSTED_PROXIES")
+	if set := trustedProxies.Load(); set != nil && set.raw == raw {
+		return set.prefixes
+	}
+
+	var prefixes []netip.Prefix
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" || part == "none" {
+			continue
+		}
+		if !strings.Contains(part, "/") {
+			if addr, err := netip.ParseAddr(part); err == nil {
+				prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			}
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(part); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+		}
+	}
+	trustedProxies.Store(&trustedProxySet{raw: raw, prefixes: prefixes})
+	return prefixes
+}
+
+func isTrustedProxy(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range currentTrustedProxies() {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerAddr is the address of whoever opened the connection
+func peerAddr(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// fromTrustedProxy reports whether the request came through a proxy in
+// TRUSTED_PROXIES, so its X-Forwarded-* headers can be believed
+func fromTrustedProxy(r *http.Request) bool {
+	peer, ok := peerAddr(r)
+	return ok && isTrustedProxy(peer)
+}
+
+// ClientIP is the server's echo.IPExtractor, behind c.RealIP(). Forwarding
+// headers are only read from trusted proxies: X-Forwarded-For is walked
+// from the right past every trusted hop, so clients cannot spoof their
+// address by sending the header themselves.
+func ClientIP(r *http.Request) string {
+	peer, ok := peerAddr(r)
+	if !ok {
+		return r.RemoteAddr
+	}
+	if !isTrustedProxy(peer) {
+		return peer.String()
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	if len(hops) == 0 {
+		if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+			return realIP.Unmap().String()
+		}
+		return peer.String()
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		client = hop.Unmap()
+		if !isTrustedProxy(client) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// requestScheme is "https" or "http" as the client used it, honouring
+// X-Forwarded-Proto from trusted proxies
+func requestScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	if fromTrustedProxy(r) {
+		proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+		if proto = strings.ToLower(strings.TrimSpace(proto)); proto == "https" || proto == "wss" {
+			return "https"
+		}
+	}
+	return "http"
+}
+
+// requestHost is the host the client addressed, honouring X-Forwarded-Host
+// from trusted proxies
+func requestHost(r *http.Request) string {
+	if fromTrustedProxy(r) {
+		host, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Host"), ",")
+		if host = strings.TrimSpace(host); host != "" {
+			return host
+		}
+	}
+	return r.Host
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"hyperwhisper/internal/config"
+)
+
+// withTrustedProxies loads the configuration with TRUSTED_PROXIES set
+func withTrustedProxies(t *testing.T, proxies string) {
+	t.Helper()
+	t.Setenv("TRUSTED_PROXIES", proxies)
+	// Settings required in production may be missing; they aren't read here
+	_ = config.Load()
+	t.Cleanup(func() { _ = config.Load() })
+}
+
+func TestClientIP(t *testing.T) {
+	withTrustedProxies(t, "10.0.0.0/8,192.0.2.1,fd00::/8")
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		want       string
+	}{
+		{
+			name:       "direct client",
+			remoteAddr: "203.0.113.7:51234",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "untrusted peer spoofing X-Forwarded-For",
+			remoteAddr: "203.0.113.7:51234",
+			forwarded:  []string{"198.51.100.1"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "untrusted peer spoofing X-Real-IP",
+			remoteAddr: "203.0.113.7:51234",
+			realIP:     "198.51.100.1",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "trusted proxy",
+			remoteAddr: "10.0.0.1:443",
+			forwarded:  []string{"203.0.113.7"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "trusted proxy given a spoofed leftmost hop",
+			remoteAddr: "10.0.0.1:443",
+			forwarded:  []string{"198.51.100.1, 203.0.113.7"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "chain of trusted proxies",
+			remoteAddr: "192.0.2.1:443",
+			forwarded:  []string{"198.51.100.1, 203.0.113.7, 10.1.2.3"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "hops across repeated headers",
+			remoteAddr: "10.0.0.1:443",
+			forwarded:  []string{"198.51.100.1", "203.0.113.7, 10.1.2.3"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "only trusted hops",
+			remoteAddr: "10.0.0.1:443",
+			forwarded:  []string{"10.9.9.9"},
+			want:       "10.9.9.9",
+		},
+		{
+			name:       "malformed hop stops the walk",
+			remoteAddr: "10.0.0.1:443",
+			forwarded:  []string{"203.0.113.7, not-an-ip"},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "trusted proxy with X-Real-IP",
+			remoteAddr: "10.0.0.1:443",
+			realIP:     "203.0.113.7",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "trusted proxy without headers",
+			remoteAddr: "10.0.0.1:443",
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "IPv4-mapped trusted proxy",
+			remoteAddr: "[::ffff:10.0.0.1]:443",
+			forwarded:  []string{"::ffff:203.0.113.7"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "IPv6 trusted proxy",
+			remoteAddr: "[fd00::1]:443",
+			forwarded:  []string{"2001:db8::7"},
+			want:       "2001:db8::7",
+		},
+		{
+			name:       "unparseable peer",
+			remoteAddr: "@",
+			forwarded:  []string{"203.0.113.7"},
+			want:       "@",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := ClientIP(r); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPTrustsNoProxy(t *testing.T) {
+	withTrustedProxies(t, "none")
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:443"
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+	if got := ClientIP(r); got != "10.0.0.1" {
+		t.Errorf("ClientIP() = %q, want %q", got, "10.0.0.1")
+	}
+}
+
+func TestForwardedSchemeAndHost(t *testing.T) {
+	withTrustedProxies(t, "10.0.0.0/8")
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		wantScheme string
+		wantHost   string
+	}{
+		{name: "trusted proxy", remoteAddr: "10.0.0.1:443", wantScheme: "https", wantHost: "app.example.com"},
+		{name: "untrusted peer", remoteAddr: "203.0.113.7:51234", wantScheme: "http", wantHost: "internal:8080"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://internal:8080/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			r.Header.Set("X-Forwarded-Proto", "https")
+			r.Header.Set("X-Forwarded-Host", "app.example.com")
+			if got := requestScheme(r); got != tt.wantScheme {
+				t.Errorf("requestScheme() = %q, want %q", got, tt.wantScheme)
+			}
+			if got := requestHost(r); got != tt.wantHost {
+				t.Errorf("requestHost() = %q, want %q", got, tt.wantHost)
+			}
+		})
+	}
+}
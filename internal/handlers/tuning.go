@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"hyperwhisper/internal/config"
+
+	"github.com/gorilla/websocket"
+)
+
+// proxyTuning holds the socket settings of the transcription proxies
+type proxyTuning struct {
+	// ReadBufferSize is the I/O read buffer of client and Deepgram
+	// connections. A message larger than the buffer takes several reads.
+	ReadBufferSize int
+	// WriteBufferSize is the I/O write buffer; a message larger than it is
+	// written in several frames
+	WriteBufferSize int
+	// HandshakeTimeout bounds connecting to Deepgram
+	HandshakeTimeout time.Duration
+	// WriteTimeout bounds a single forwarded message so a stalled peer
+	// cannot block a proxy goroutine forever; 0 disables it
+	WriteTimeout time.Duration
+}
+
+// proxyTuningProfiles are the presets selectable with PROXY_TUNING_PROFILE.
+// Desktop clients stream 100 ms chunks: 3.2 kB of 16 kHz linear16 mono, up
+// to 19.2 kB at 48 kHz stereo. balanced reads a whole chunk at once;
+// low-memory suits many mostly idle sessions on a small instance;
+// throughput suits batch uploads of large chunks; legacy restores the
+// original 1 KiB buffers without write deadlines.
+var proxyTuningProfiles = map[string]proxyTuning{
+	"balanced": {
+		ReadBufferSize:   32 * 1024,
+		WriteBufferSize:  16 * 1024,
+		HandshakeTimeout: 10 * time.Second,
+		WriteTimeout:     10 * time.Second,
+	},
+	"low-memory": {
+		ReadBufferSize:   4 * 1024,
+		WriteBufferSize:  4 * 1024,
+		HandshakeTimeout: 10 * time.Second,
+		WriteTimeout:     10 * time.Second,
+	},
+	"throughput": {
+		ReadBufferSize:   64 * 1024,
+		WriteBufferSize:  64 * 1024,
+		HandshakeTimeout: 15 * time.Second,
+		WriteTimeout:     30 * time.Second,
+	},
+	"legacy": {
+		ReadBufferSize:   1024,
+		WriteBufferSize:  1024,
+		HandshakeTimeout: 10 * time.Second,
+	},
+}
+
+// proxyWriteBufferPool shares write buffers between connections; a buffer
+// is only held while a message is being written
+var proxyWriteBufferPool = &sync.Pool{}
+
+// currentProxyTuning is the PROXY_TUNING_PROFILE preset with any explicit
+// PROXY_* overrides applied
+func currentProxyTuning() proxyTuning {
+	t, ok := proxyTuningProfiles[config.String("PROXY_TUNING_PROFILE")]
+	if !ok {
+		t = proxyTuningProfiles["balanced"]
+	}
+	if v := config.Int("PROXY_READ_BUFFER_SIZE"); v > 0 {
+		t.ReadBufferSize = v
+	}
+	if v := config.Int("PROXY_WRITE_BUFFER_SIZE"); v > 0 {
+		t.WriteBufferSize = v
+	}
+	if v := config.Duration("PROXY_UPSTREAM_HANDSHAKE_TIMEOUT"); v > 0 {
+		t.HandshakeTimeout = v
+	}
+	if v := config.Duration("PROXY_WRITE_TIMEOUT"); v > 0 {
+		t.WriteTimeout = v
+	}
+	return t
+}
+
+// newProxyUpgrader creates the upgrader of client connections. Buffer
+// sizes are read once, when the handler is created.
+func newProxyUpgrader() websocket.Upgrader {
+	t := currentProxyTuning()
+	return websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			// Allow all origins in dev, restrict in production
+			if config.IsDev() {
+				return true
+			}
+			return checkAllowedOrigin(r)
+		},
+		ReadBufferSize:  t.ReadBufferSize,
+		WriteBufferSize: t.WriteBufferSize,
+		WriteBufferPool: proxyWriteBufferPool,
+		Subprotocols:    proxySubprotocols(),
+	}
+}
+
+// newUpstreamDialer creates the dialer of Deepgram connections
+func newUpstreamDialer() websocket.Dialer {
+	t := currentProxyTuning()
+	return websocket.Dialer{
+		HandshakeTimeout: t.HandshakeTimeout,
+		ReadBufferSize:   t.ReadBufferSize,
+		WriteBufferSize:  t.WriteBufferSize,
+		WriteBufferPool:  proxyWriteBufferPool,
+	}
+}
+
+// writeMessage forwards a message, bounded by the write timeout. Each
+// connection must only be written to by one goroutine at a time.
+func writeMessage(conn *websocket.Conn, messageType int, data []byte) error {
+	if timeout := currentProxyTuning().WriteTimeout; timeout > 0 {
+		_ = conn.SetWriteDeadline(time.Now().Add(timeout))
+	} else {
+		_ = conn.SetWriteDeadline(time.Time{})
+	}
+	return conn.WriteMessage(messageType, data)
+}
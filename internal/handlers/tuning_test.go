@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"hyperwhisper/internal/config"
+
+	"github.com/gorilla/websocket"
+)
+
+// withProxyTuning loads the configuration with profile and the PROXY_*
+// overrides in pairs of names and values
+func withProxyTuning(tb testing.TB, profile string, overrides ...string) {
+	tb.Helper()
+	tb.Setenv("PROXY_TUNING_PROFILE", profile)
+	for _, name := range []string{"PROXY_READ_BUFFER_SIZE", "PROXY_WRITE_BUFFER_SIZE", "PROXY_UPSTREAM_HANDSHAKE_TIMEOUT", "PROXY_WRITE_TIMEOUT"} {
+		tb.Setenv(name, "")
+	}
+	for i := 0; i+1 < len(overrides); i += 2 {
+		tb.Setenv(overrides[i], overrides[i+1])
+	}
+	// Settings required in production may be missing; they aren't read here
+	_ = config.Load()
+	tb.Cleanup(func() { _ = config.Load() })
+}
+
+func TestCurrentProxyTuning(t *testing.T) {
+	withProxyTuning(t, "low-memory")
+	if got := currentProxyTuning(); got != proxyTuningProfiles["low-memory"] {
+		t.Errorf("low-memory profile = %+v", got)
+	}
+
+	withProxyTuning(t, "legacy", "PROXY_WRITE_BUFFER_SIZE", "8192", "PROXY_WRITE_TIMEOUT", "5s")
+	want := proxyTuning{ReadBufferSize: 1024, WriteBufferSize: 8192, HandshakeTimeout: 10 * time.Second, WriteTimeout: 5 * time.Second}
+	if got := currentProxyTuning(); got != want {
+		t.Errorf("legacy profile with overrides = %+v, want %+v", got, want)
+	}
+}
+
+// proxyChunkSizes are the messages the benchmarks forward: 100 ms of 16 kHz
+// linear16 mono and of 48 kHz stereo, and a batch upload chunk
+var proxyChunkSizes = []int{3200, 19200, 256 * 1024}
+
+// BenchmarkProxyTuning forwards chunks through a client connection and back
+// with each profile's buffers and write deadlines, the way the proxies do
+func BenchmarkProxyTuning(b *testing.B) {
+	profiles := make([]string, 0, len(proxyTuningProfiles))
+	for name := range proxyTuningProfiles {
+		profiles = append(profiles, name)
+	}
+	slices.Sort(profiles)
+
+	for _, profile := range profiles {
+		for _, size := range proxyChunkSizes {
+			b.Run(fmt.Sprintf("%s/%d", profile, size), func(b *testing.B) {
+				withProxyTuning(b, profile)
+				client := dialEchoProxy(b)
+				chunk := bytes.Repeat([]byte{0x7f}, size)
+
+				b.SetBytes(int64(size))
+				b.ReportAllocs()
+				for b.Loop() {
+					if err := writeMessage(client, websocket.BinaryMessage, chunk); err != nil {
+						b.Fatal(err)
+					}
+					if _, _, err := client.ReadMessage(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// dialEchoProxy connects, with the upstream dialer, to a server that
+// accepts like the proxies and sends every message back
+func dialEchoProxy(b *testing.B) *websocket.Conn {
+	b.Helper()
+	upgrader := newProxyUpgrader()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := writeMessage(conn, messageType, data); err != nil {
+				return
+			}
+		}
+	}))
+	b.Cleanup(server.Close)
+
+	dialer := newUpstreamDialer()
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { conn.Close() })
+	return conn
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// ========== SESSION ERROR CODES ==========
+
+// Error codes of failed sessions, returned with their usage log as
+// error_code. They are stable, so clients can show their own message for
+// each; error_message keeps the details.
+const (
+	// errorCodeUpgradeFailed: the client's WebSocket upgrade failed
+	errorCodeUpgradeFailed = "upgrade_failed"
+	// errorCodeConcurrencyLimit: no concurrency slot freed up in time
+	errorCodeConcurrencyLimit = "concurrency_limit"
+	// errorCodeUpstreamUnavailable: Deepgram could not be reached, or the
+	// connection to it dropped
+	errorCodeUpstreamUnavailable = "upstream_unavailable"
+	// errorCodeUpstreamBusy: Deepgram refused the session for being over its
+	// rate limit
+	errorCodeUpstreamBusy = "upstream_busy"
+	// errorCodeInvalidParams: Deepgram rejected the session's parameters
+	errorCodeInvalidParams = "invalid_params"
+	// errorCodeInvalidAudio: Deepgram could not decode the audio
+	errorCodeInvalidAudio = "invalid_audio"
+	// errorCodeNoAudio: Deepgram received no audio in time
+	errorCodeNoAudio = "no_audio"
+	// errorCodeUpstreamError: any other error Deepgram reported
+	errorCodeUpstreamError = "upstream_error"
+)
+
+// upstreamFailure is why Deepgram failed a session
+type upstreamFailure struct {
+	code    string // One of the error codes above
+	message string // Deepgram's own code and reason
+}
+
+// upstreamDialFailure describes a failed connection to Deepgram; resp is
+// the handshake response, if one arrived
+func upstreamDialFailure(err error, resp *http.Response) *upstreamFailure {
+	failure := &upstreamFailure{
+		code:    errorCodeUpstreamUnavailable,
+		message: fmt.Sprintf("deepgram connection failed: %v", err),
+	}
+	if resp == nil {
+		return failure
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		failure.code = errorCodeUpstreamBusy
+	case resp.StatusCode == http.StatusBadRequest:
+		failure.code = errorCodeInvalidParams
+	case resp.StatusCode < 500:
+		failure.code = errorCodeUpstreamError
+	}
+	// Deepgram explains rejected handshakes in these headers
+	if reason := cmp.Or(resp.Header.Get("dg-error"), resp.Header.Get("dg-request-id")); reason != "" {
+		failure.message += " (" + reason + ")"
+	}
+	return failure
+}
+
+// upstreamCloseFailure describes the error that ended a read from
+// Deepgram, or returns nil when Deepgram closed the stream normally
+func upstreamCloseFailure(err error) *upstreamFailure {
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		return &upstreamFailure{
+			code:    errorCodeUpstreamUnavailable,
+			message: fmt.Sprintf("deepgram connection lost: %v", err),
+		}
+	}
+	if closeErr.Code == websocket.CloseNormalClosure {
+		return nil
+	}
+	return &upstreamFailure{
+		code:    classifyUpstreamError(closeErr.Code, closeErr.Text),
+		message: strings.TrimSpace(fmt.Sprintf("deepgram closed with %d %s", closeErr.Code, closeErr.Text)),
+	}
+}
+
+// upstreamErrorFrame parses an in-band Deepgram error message, or returns
+// nil for any other message
+func upstreamErrorFrame(data []byte) *upstreamFailure {
+	var msg struct {
+		Type        string `json:"type"`
+		ErrCode     string `json:"err_code"`
+		ErrMsg      string `json:"err_msg"`
+		Variant     string `json:"variant"`
+		Description string `json:"description"`
+		Message     string `json:"message"`
+	}
+	if json.Unmarshal(data, &msg) != nil || msg.Type != "Error" {
+		return nil
+	}
+	text := strings.TrimSpace(cmp.Or(msg.ErrCode, msg.Variant) + " " + cmp.Or(msg.ErrMsg, msg.Description, msg.Message))
+	return &upstreamFailure{
+		code:    classifyUpstreamError(0, text),
+		message: strings.TrimSpace("deepgram error " + text),
+	}
+}
+
+// classifyUpstreamError maps a Deepgram close code (0 for in-band errors)
+// and reason to an error code. Deepgram prefixes reasons with its own codes,
+// e.g. DATA-0000 for undecodable audio and NET-0001 when no audio arrived.
+func classifyUpstreamError(closeCode int, reason string) string {
+	switch {
+	case strings.Contains(reason, "DATA-") || closeCode == websocket.ClosePolicyViolation:
+		return errorCodeInvalidAudio
+	case strings.Contains(reason, "NET-0001"):
+		return errorCodeNoAudio
+	case closeCode == websocket.CloseAbnormalClosure,
+		closeCode == websocket.CloseServiceRestart,
+		closeCode == websocket.CloseTryAgainLater:
+		return errorCodeUpstreamUnavailable
+	}
+	return errorCodeUpstreamError
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"hyperwhisper/internal/auth"
+	"hyperwhisper/internal/db/sqlc"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== SIGNED-IN SESSIONS ==========
+
+// UserSessionResponse is one of the caller's signed-in devices: an active
+// refresh token and the client it was issued to. Refreshing replaces the
+// token, so issued_at is when the device last refreshed.
+type UserSessionResponse struct {
+	JTI       string  `json:"jti"`
+	IssuedAt  string  `json:"issued_at"`
+	ExpiresAt string  `json:"expires_at"`
+	ClientIP  *string `json:"client_ip"`
+	UserAgent *string `json:"user_agent"`
+	Current   bool    `json:"current"`
+}
+
+// ListSessions returns the caller's active refresh tokens, newest first.
+// The one of the requesting browser is marked current.
+func (h *AuthHandler) ListSessions(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	tokens, err := h.queries.ListUserActiveRefreshTokens(context.Background(), claims.UserID)
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+
+	current := currentRefreshTokenJTI(c)
+	resp := make([]UserSessionResponse, 0, len(tokens))
+	for _, token := range tokens {
+		resp = append(resp, toUserSessionResponse(token, current))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// RevokeSession signs one of the caller's devices out by revoking its
+// refresh token. The device keeps its access token until it expires.
+// Revoking the current session also clears the auth cookies.
+func (h *AuthHandler) RevokeSession(c echo.Context) error {
+	claims := auth.GetUserFromContext(c)
+
+	jti := c.Param("jti")
+	ctx := context.Background()
+	revoked, err := h.queries.RevokeUserRefreshToken(ctx, sqlc.RevokeUserRefreshTokenParams{
+		TokenJti:      jti,
+		UserID:        claims.UserID,
+		RevokedReason: sql.NullString{String: "user_revoked", Valid: true},
+	})
+	if err != nil {
+		return apiError(http.StatusInternalServerError, "database error")
+	}
+	// Other users' tokens are indistinguishable from unknown ones
+	if revoked == 0 {
+		return apiError(http.StatusNotFound, "session not found")
+	}
+	denyAccessToken(ctx, h.queries, jti)
+
+	if jti == currentRefreshTokenJTI(c) {
+		clearAuthCookies(c)
+	}
+
+	log.Printf("[Auth] User %s revoked session %s", claims.UserID, jti)
+	return c.JSON(http.StatusOK, map[string]string{"message": "session revoked"})
+}
+
+// currentRefreshTokenJTI is the JTI of the refresh token cookie sent with
+// the request, or "" for clients that keep the token elsewhere
+func currentRefreshTokenJTI(c echo.Context) string {
+	cookie, err := c.Cookie("refresh_token")
+	if err != nil {
+		return ""
+	}
+	claims, err := auth.ValidateToken(cookie.Value, auth.RefreshToken)
+	if err != nil {
+		return ""
+	}
+	return claims.ID
+}
+
+func toUserSessionResponse(token sqlc.Token, currentJTI string) UserSessionResponse {
+	resp := UserSessionResponse{
+		JTI:       token.TokenJti,
+		ExpiresAt: token.ExpiresAt.Format(time.RFC3339),
+		Current:   currentJTI != "" && token.TokenJti == currentJTI,
+	}
+	if token.IssuedAt.Valid {
+		resp.IssuedAt = token.IssuedAt.Time.Format(time.RFC3339)
+	}
+	if token.ClientIp.Valid {
+		resp.ClientIP = &token.ClientIp.String
+	}
+	if token.UserAgent.Valid {
+		resp.UserAgent = &token.UserAgent.String
+	}
+	return resp
+}
@@ -0,0 +1,156 @@
+package i18n
+
+// Emails sent to users, translated like messages. Bodies are fmt formats
+// whose verbs every translation keeps in the same order.
+const (
+	PasswordResetSubject = "Reset your HyperWhisper password"
+	// PasswordResetBody takes the username, the link's lifetime and the link
+	PasswordResetBody = "Someone, hopefully you, asked to reset the password of the HyperWhisper account %s.\n\n" +
+		"Open this link to choose a new password. It works once and expires in %s:\n\n%s\n\n" +
+		"If you did not ask for this, ignore this email and your password stays the same.\n"
+
+	ForcedPasswordResetSubject = "Choose a new HyperWhisper password"
+	// ForcedPasswordResetBody takes the username, the link's lifetime and
+	// the link
+	ForcedPasswordResetBody = "An administrator reset the password of the HyperWhisper account %s, which signed it out everywhere.\n\n" +
+		"Open this link to choose a new password. It works once and expires in %s:\n\n%s\n\n" +
+		"If it expires, request a new link with \"Forgot password\" on the sign-in page.\n"
+
+	KeyLockedSubject = "Your HyperWhisper API key was locked"
+	// KeyLockedBody takes the key's name, its prefix and one of the
+	// KeyLocked reasons below
+	KeyLockedBody = "Your HyperWhisper API key %q (%s...) was locked after unusual activity: %s.\n\n" +
+		"Sessions using the key are refused until you re-enable it from the API keys of your account.\n\n" +
+		"If you don't recognize this activity, revoke the key and create a new one instead.\n"
+	// KeyLockedIPSpread takes the number of IP addresses
+	KeyLockedIPSpread = "it connected from %s different IP addresses within an hour"
+	// KeyLockedUsageSpike takes the minutes streamed and the daily average
+	KeyLockedUsageSpike = "it streamed %.0f minutes in the last 24 hours, against a daily average of %.0f minutes"
+
+	NewDeviceSubject = "New sign-in to your HyperWhisper account"
+	// NewDeviceBody takes the username, the time, the IP address, the user
+	// agent and the dashboard link
+	NewDeviceBody = "The HyperWhisper account %s was signed in to from a new device on %s.\n\n" +
+		"IP address: %s\nBrowser or app: %s\n\n" +
+		"If this was you, there is nothing to do. If it wasn't, sign the device out under \"Signed-in Devices\" at %s and change your password.\n"
+
+	KeyInactiveSubject = "Your unused HyperWhisper API key will be revoked"
+	// KeyInactiveBody takes the key's name, its prefix, the days it has
+	// gone unused, the revocation date and the reactivation link
+	KeyInactiveBody = "Your HyperWhisper API key %q (%s...) has not been used for %d days. Unused keys are revoked so that forgotten ones can't leak, and this one will be revoked on %s.\n\n" +
+		"To keep it, use it before then or open this link:\n\n%s\n\n" +
+		"If you no longer need the key, there is nothing to do.\n"
+
+	KeyRevokedInactiveSubject = "Your unused HyperWhisper API key was revoked"
+	// KeyRevokedInactiveBody takes the key's name, its prefix, the days it
+	// went unused and the reactivation link
+	KeyRevokedInactiveBody = "Your HyperWhisper API key %q (%s...) was revoked after going unused for %d days.\n\n" +
+		"If you still need it, open this link to reactivate it. It works once:\n\n%s\n\n" +
+		"If you no longer need the key, there is nothing to do.\n"
+
+	AccountDeletionSubject = "Your HyperWhisper account will be deleted"
+	// AccountDeletionBody takes the username, the deletion date and the
+	// dashboard link
+	AccountDeletionBody = "You asked to delete the HyperWhisper account %s. It will be deleted on %s, together with its API keys, sessions and usage history. This can't be undone.\n\n" +
+		"Until then you can still sign in, download your data, and cancel the deletion at %s.\n\n" +
+		"If you did not ask for this, sign in, cancel the deletion and change your password.\n"
+)
+
+var emailTranslations = map[string]map[string]string{
+	"de": {
+		PasswordResetSubject: "Setze dein HyperWhisper-Passwort zurück",
+		PasswordResetBody: "Jemand, hoffentlich du, hat das Zurücksetzen des Passworts für das HyperWhisper-Konto %s angefordert.\n\n" +
+			"Öffne diesen Link, um ein neues Passwort zu wählen. Er funktioniert einmal und läuft in %s ab:\n\n%s\n\n" +
+			"Wenn du das nicht angefordert hast, ignoriere diese E-Mail; dein Passwort bleibt unverändert.\n",
+		ForcedPasswordResetSubject: "Wähle ein neues HyperWhisper-Passwort",
+		ForcedPasswordResetBody: "Ein Administrator hat das Passwort des HyperWhisper-Kontos %s zurückgesetzt und es überall abgemeldet.\n\n" +
+			"Öffne diesen Link, um ein neues Passwort zu wählen. Er funktioniert einmal und läuft in %s ab:\n\n%s\n\n" +
+			"Wenn er abläuft, fordere auf der Anmeldeseite mit \"Passwort vergessen\" einen neuen Link an.\n",
+		KeyLockedSubject: "Dein HyperWhisper-API-Schlüssel wurde gesperrt",
+		KeyLockedBody: "Dein HyperWhisper-API-Schlüssel %q (%s...) wurde nach ungewöhnlicher Aktivität gesperrt: %s.\n\n" +
+			"Sitzungen mit diesem Schlüssel werden abgelehnt, bis du ihn unter den API-Schlüsseln deines Kontos wieder aktivierst.\n\n" +
+			"Wenn du diese Aktivität nicht erkennst, widerrufe den Schlüssel und erstelle stattdessen einen neuen.\n",
+		KeyLockedIPSpread:   "er wurde innerhalb einer Stunde von %s verschiedenen IP-Adressen verwendet",
+		KeyLockedUsageSpike: "er hat in den letzten 24 Stunden %.0f Minuten übertragen, bei einem Tagesdurchschnitt von %.0f Minuten",
+		NewDeviceSubject:    "Neue Anmeldung bei deinem HyperWhisper-Konto",
+		NewDeviceBody: "Beim HyperWhisper-Konto %s hat sich am %s ein neues Gerät angemeldet.\n\n" +
+			"IP-Adresse: %s\nBrowser oder App: %s\n\n" +
+			"Wenn du das warst, ist nichts zu tun. Wenn nicht, melde das Gerät unter \"Angemeldete Geräte\" auf %s ab und ändere dein Passwort.\n",
+		KeyInactiveSubject: "Dein ungenutzter HyperWhisper-API-Schlüssel wird widerrufen",
+		KeyInactiveBody: "Dein HyperWhisper-API-Schlüssel %q (%s...) wurde seit %d Tagen nicht verwendet. Ungenutzte Schlüssel werden widerrufen, damit vergessene nicht in falsche Hände geraten; dieser wird am %s widerrufen.\n\n" +
+			"Um ihn zu behalten, verwende ihn vorher oder öffne diesen Link:\n\n%s\n\n" +
+			"Wenn du den Schlüssel nicht mehr brauchst, ist nichts zu tun.\n",
+		KeyRevokedInactiveSubject: "Dein ungenutzter HyperWhisper-API-Schlüssel wurde widerrufen",
+		KeyRevokedInactiveBody: "Dein HyperWhisper-API-Schlüssel %q (%s...) wurde widerrufen, nachdem er %d Tage nicht verwendet wurde.\n\n" +
+			"Wenn du ihn noch brauchst, öffne diesen Link, um ihn wieder zu aktivieren. Er funktioniert einmal:\n\n%s\n\n" +
+			"Wenn du den Schlüssel nicht mehr brauchst, ist nichts zu tun.\n",
+		AccountDeletionSubject: "Dein HyperWhisper-Konto wird gelöscht",
+		AccountDeletionBody: "Du hast die Löschung des HyperWhisper-Kontos %s angefordert. Es wird am %s gelöscht, zusammen mit seinen API-Schlüsseln, Sitzungen und seinem Nutzungsverlauf. Das lässt sich nicht rückgängig machen.\n\n" +
+			"Bis dahin kannst du dich weiterhin anmelden, deine Daten herunterladen und die Löschung unter %s abbrechen.\n\n" +
+			"Wenn du das nicht angefordert hast, melde dich an, brich die Löschung ab und ändere dein Passwort.\n",
+	},
+	"es": {
+		PasswordResetSubject: "Restablece tu contraseña de HyperWhisper",
+		PasswordResetBody: "Alguien, esperamos que tú, ha pedido restablecer la contraseña de la cuenta de HyperWhisper %s.\n\n" +
+			"Abre este enlace para elegir una contraseña nueva. Funciona una vez y caduca en %s:\n\n%s\n\n" +
+			"Si no lo has pedido tú, ignora este correo y tu contraseña seguirá igual.\n",
+		ForcedPasswordResetSubject: "Elige una nueva contraseña de HyperWhisper",
+		ForcedPasswordResetBody: "Un administrador ha restablecido la contraseña de la cuenta de HyperWhisper %s, lo que ha cerrado su sesión en todas partes.\n\n" +
+			"Abre este enlace para elegir una contraseña nueva. Funciona una vez y caduca en %s:\n\n%s\n\n" +
+			"Si caduca, pide un enlace nuevo con \"¿Olvidaste tu contraseña?\" en la página de inicio de sesión.\n",
+		KeyLockedSubject: "Tu clave de API de HyperWhisper ha sido bloqueada",
+		KeyLockedBody: "Tu clave de API de HyperWhisper %q (%s...) ha sido bloqueada tras una actividad inusual: %s.\n\n" +
+			"Las sesiones que usen la clave se rechazarán hasta que la vuelvas a activar en las claves de API de tu cuenta.\n\n" +
+			"Si no reconoces esta actividad, revoca la clave y crea una nueva.\n",
+		KeyLockedIPSpread:   "se conectó desde %s direcciones IP distintas en una hora",
+		KeyLockedUsageSpike: "transmitió %.0f minutos en las últimas 24 horas, frente a una media diaria de %.0f minutos",
+		NewDeviceSubject:    "Nuevo inicio de sesión en tu cuenta de HyperWhisper",
+		NewDeviceBody: "Se ha iniciado sesión en la cuenta de HyperWhisper %s desde un dispositivo nuevo el %s.\n\n" +
+			"Dirección IP: %s\nNavegador o aplicación: %s\n\n" +
+			"Si has sido tú, no tienes que hacer nada. Si no, cierra la sesión del dispositivo en \"Dispositivos conectados\" en %s y cambia tu contraseña.\n",
+		KeyInactiveSubject: "Tu clave de API de HyperWhisper sin usar será revocada",
+		KeyInactiveBody: "Tu clave de API de HyperWhisper %q (%s...) no se ha usado en %d días. Las claves sin usar se revocan para que las olvidadas no se filtren, y esta se revocará el %s.\n\n" +
+			"Para conservarla, úsala antes de esa fecha o abre este enlace:\n\n%s\n\n" +
+			"Si ya no necesitas la clave, no tienes que hacer nada.\n",
+		KeyRevokedInactiveSubject: "Tu clave de API de HyperWhisper sin usar ha sido revocada",
+		KeyRevokedInactiveBody: "Tu clave de API de HyperWhisper %q (%s...) ha sido revocada tras %d días sin usarse.\n\n" +
+			"Si todavía la necesitas, abre este enlace para reactivarla. Funciona una vez:\n\n%s\n\n" +
+			"Si ya no necesitas la clave, no tienes que hacer nada.\n",
+		AccountDeletionSubject: "Tu cuenta de HyperWhisper será eliminada",
+		AccountDeletionBody: "Has pedido eliminar la cuenta de HyperWhisper %s. Se eliminará el %s, junto con sus claves de API, sesiones e historial de uso. No se puede deshacer.\n\n" +
+			"Hasta entonces puedes seguir iniciando sesión, descargar tus datos y cancelar la eliminación en %s.\n\n" +
+			"Si no lo has pedido tú, inicia sesión, cancela la eliminación y cambia tu contraseña.\n",
+	},
+	"fr": {
+		PasswordResetSubject: "Réinitialisez votre mot de passe HyperWhisper",
+		PasswordResetBody: "Quelqu'un, vous sans doute, a demandé à réinitialiser le mot de passe du compte HyperWhisper %s.\n\n" +
+			"Ouvrez ce lien pour choisir un nouveau mot de passe. Il fonctionne une fois et expire dans %s :\n\n%s\n\n" +
+			"Si vous n'êtes pas à l'origine de cette demande, ignorez cet e-mail : votre mot de passe reste inchangé.\n",
+		ForcedPasswordResetSubject: "Choisissez un nouveau mot de passe HyperWhisper",
+		ForcedPasswordResetBody: "Un administrateur a réinitialisé le mot de passe du compte HyperWhisper %s, ce qui l'a déconnecté partout.\n\n" +
+			"Ouvrez ce lien pour choisir un nouveau mot de passe. Il fonctionne une fois et expire dans %s :\n\n%s\n\n" +
+			"S'il expire, demandez un nouveau lien avec « Mot de passe oublié » sur la page de connexion.\n",
+		KeyLockedSubject: "Votre clé d'API HyperWhisper a été verrouillée",
+		KeyLockedBody: "Votre clé d'API HyperWhisper %q (%s...) a été verrouillée après une activité inhabituelle : %s.\n\n" +
+			"Les sessions utilisant la clé sont refusées jusqu'à ce que vous la réactiviez depuis les clés d'API de votre compte.\n\n" +
+			"Si vous ne reconnaissez pas cette activité, révoquez la clé et créez-en une nouvelle.\n",
+		KeyLockedIPSpread:   "elle s'est connectée depuis %s adresses IP différentes en une heure",
+		KeyLockedUsageSpike: "elle a diffusé %.0f minutes au cours des dernières 24 heures, pour une moyenne quotidienne de %.0f minutes",
+		NewDeviceSubject:    "Nouvelle connexion à votre compte HyperWhisper",
+		NewDeviceBody: "Un nouvel appareil s'est connecté au compte HyperWhisper %s le %s.\n\n" +
+			"Adresse IP : %s\nNavigateur ou application : %s\n\n" +
+			"Si c'était vous, il n'y a rien à faire. Sinon, déconnectez l'appareil dans « Appareils connectés » sur %s et changez votre mot de passe.\n",
+		KeyInactiveSubject: "Votre clé d'API HyperWhisper inutilisée va être révoquée",
+		KeyInactiveBody: "Votre clé d'API HyperWhisper %q (%s...) n'a pas été utilisée depuis %d jours. Les clés inutilisées sont révoquées pour que celles qui sont oubliées ne puissent pas fuiter, et celle-ci sera révoquée le %s.\n\n" +
+			"Pour la conserver, utilisez-la d'ici là ou ouvrez ce lien :\n\n%s\n\n" +
+			"Si vous n'avez plus besoin de la clé, il n'y a rien à faire.\n",
+		KeyRevokedInactiveSubject: "Votre clé d'API HyperWhisper inutilisée a été révoquée",
+		KeyRevokedInactiveBody: "Votre clé d'API HyperWhisper %q (%s...) a été révoquée après %d jours sans utilisation.\n\n" +
+			"Si vous en avez encore besoin, ouvrez ce lien pour la réactiver. Il fonctionne une fois :\n\n%s\n\n" +
+			"Si vous n'avez plus besoin de la clé, il n'y a rien à faire.\n",
+		AccountDeletionSubject: "Votre compte HyperWhisper va être supprimé",
+		AccountDeletionBody: "Vous avez demandé la suppression du compte HyperWhisper %s. Il sera supprimé le %s, avec ses clés d'API, ses sessions et son historique d'utilisation. Cette action est irréversible.\n\n" +
+			"D'ici là, vous pouvez toujours vous connecter, télécharger vos données et annuler la suppression sur %s.\n\n" +
+			"Si vous n'êtes pas à l'origine de cette demande, connectez-vous, annulez la suppression et changez votre mot de passe.\n",
+	},
+}
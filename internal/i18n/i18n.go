@@ -0,0 +1,95 @@
+// Package i18n translates API error messages, WebSocket close reasons and
+// emails into the client's language. English is the source language: every message
+// is identified by its English text, which also yields a stable
+// machine-readable code that does not change with the language.
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// English is the source language and the fallback for unsupported ones
+const English = "en"
+
+// Supported returns the languages messages are translated into, English
+// first
+func Supported() []string {
+	langs := []string{English}
+	for lang := range translations {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs[1:])
+	return langs
+}
+
+// IsSupported reports whether lang is English or a language messages are
+// translated into
+func IsSupported(lang string) bool {
+	_, ok := translations[lang]
+	return ok || lang == English
+}
+
+// Negotiate picks the best supported language for an Accept-Language header
+// value ("de-CH, de;q=0.9, en;q=0.8"). Regional variants match their base
+// language; anything unsupported falls back to English.
+func Negotiate(acceptLanguage string) string {
+	best, bestQ := English, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if base != English {
+			if _, ok := translations[base]; !ok {
+				continue
+			}
+		}
+		if q > bestQ {
+			best, bestQ = base, q
+		}
+	}
+	return best
+}
+
+// Translate returns msg in lang, or msg unchanged if it has no translation
+func Translate(lang, msg string) string {
+	if t, ok := translations[lang][msg]; ok {
+		return t
+	}
+	if t, ok := emailTranslations[lang][msg]; ok {
+		return t
+	}
+	return msg
+}
+
+// Code returns the stable machine-readable code of an English message, e.g.
+// "invalid_trial_key" for "invalid trial key"
+func Code(msg string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(msg) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if underscore && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			underscore = false
+			b.WriteRune(r)
+			continue
+		}
+		underscore = true
+		// Keep codes short; anything after a colon is detail
+		if r == ':' {
+			break
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,323 @@
+package i18n
+
+// translations maps a language to the translations of English messages.
+// Only messages the desktop app and trial clients can run into are
+// translated; admin-only messages stay in English. Close reasons must stay
+// under 123 bytes to fit a WebSocket close frame.
+var translations = map[string]map[string]string{
+	"de": {
+		// Requests and authentication
+		"invalid request body":                              "Ungültiger Anfrageinhalt",
+		"request body too large":                            "Anfrageinhalt ist zu groß",
+		"database error":                                    "Datenbankfehler",
+		"database unavailable, please retry shortly":        "Datenbank nicht erreichbar, bitte gleich erneut versuchen",
+		"not authenticated":                                 "Nicht angemeldet",
+		"authentication required":                           "Anmeldung erforderlich",
+		"missing authentication token":                      "Anmeldetoken fehlt",
+		"admin access required":                             "Administratorrechte erforderlich",
+		"not available to tenant staff":                     "Für Mitarbeiter von Partnern nicht verfügbar",
+		"invalid CSRF token":                                "Ungültiges CSRF-Token",
+		"insufficient scope":                                "Unzureichende Berechtigungen für diese Anwendung",
+		"attestation required":                              "Geräteattestierung erforderlich",
+		"attestation rejected":                              "Geräteattestierung abgelehnt",
+		"unsupported attestation type":                      "Nicht unterstützter Attestierungstyp",
+		"attestation unavailable":                           "Geräteattestierung derzeit nicht verfügbar",
+		"invalid token":                                     "Ungültiges Token",
+		"token has expired":                                 "Token ist abgelaufen",
+		"token has been revoked":                            "Token wurde widerrufen",
+		"account suspended":                                 "Konto gesperrt",
+		"refresh token reuse detected":                      "Wiederverwendung des Aktualisierungstokens erkannt, bitte erneut anmelden",
+		"refresh token required":                            "Aktualisierungstoken erforderlich",
+		"invalid credentials":                               "Ungültige Anmeldedaten",
+		"identifier and password are required":              "Benutzername und Passwort sind erforderlich",
+		"username, email, and password are required":        "Benutzername, E-Mail und Passwort sind erforderlich",
+		"username already taken":                            "Benutzername ist bereits vergeben",
+		"email already taken":                               "E-Mail-Adresse ist bereits vergeben",
+		"password validation failed":                        "Passwort erfüllt die Anforderungen nicht",
+		"signups are disabled":                              "Registrierung ist deaktiviert",
+		"invite code required":                              "Einladungscode erforderlich",
+		"invalid invite code":                               "Ungültiger Einladungscode",
+		"email domain not allowed":                          "E-Mail-Domain ist nicht zugelassen",
+		"invite link is no longer valid":                    "Einladungslink ist nicht mehr gültig",
+		"email is required":                                 "E-Mail-Adresse ist erforderlich",
+		"token and password are required":                   "Token und Passwort sind erforderlich",
+		"invalid or expired reset link":                     "Ungültiger oder abgelaufener Link zum Zurücksetzen",
+		"invalid or used reactivation link":                 "Ungültiger oder bereits verwendeter Link zur Reaktivierung",
+		"API key has been revoked":                          "Der API-Schlüssel wurde widerrufen",
+		"unknown application":                               "Unbekannte Anwendung",
+		"redirect URI not registered":                       "Weiterleitungs-URI ist nicht registriert",
+		"invalid scope":                                     "Ungültiger Berechtigungsumfang",
+		"unsupported response type":                         "Nicht unterstützter Antworttyp",
+		"unsupported code challenge method":                 "Nicht unterstützte Code-Challenge-Methode",
+		"code challenge required":                           "Code-Challenge erforderlich",
+		"invalid or expired user code":                      "Ungültiger oder abgelaufener Benutzercode",
+		"password is incorrect":                             "Passwort ist falsch",
+		"admins can't delete their own account":             "Administratoren können ihr eigenes Konto nicht löschen",
+		"no account deletion scheduled":                     "Keine Kontolöschung geplant",
+		"an organization must keep at least one owner":      "Eine Organisation muss mindestens einen Eigentümer behalten",
+		"user not found":                                    "Benutzer nicht gefunden",
+		"invalid timezone":                                  "Ungültige Zeitzone",
+		"unsupported locale":                                "Nicht unterstützte Sprache",
+		"password was used recently":                        "Dieses Passwort wurde kürzlich bereits verwendet",
+		"API key required":                                  "API-Schlüssel erforderlich",
+		"api_key required":                                  "API-Schlüssel erforderlich",
+		"invalid API key":                                   "Ungültiger API-Schlüssel",
+		"malformed API key":                                 "Fehlerhafter API-Schlüssel",
+		"API key limit reached":                             "API-Schlüssel-Limit erreicht",
+		"API keys are not accepted by this endpoint":        "Dieser Endpunkt akzeptiert keine API-Schlüssel",
+		"API key not found":                                 "API-Schlüssel nicht gefunden",
+		"failed to get usage":                               "Nutzung konnte nicht abgerufen werden",
+		"failed to get limits":                              "Limits konnten nicht abgerufen werden",
+		"statements are only available as PDF":              "Abrechnungen sind nur als PDF verfügbar",
+		"requested parameters are not allowed for this key": "Die angeforderten Parameter sind für diesen Schlüssel nicht erlaubt",
+		"transcript not found":                              "Transkript nicht gefunden",
+
+		// Transcription sessions
+		"organization quota exceeded":                                            "Kontingent der Organisation aufgebraucht",
+		"Deepgram not configured":                                                "Transkriptionsdienst ist nicht konfiguriert",
+		"server is restarting, please reconnect":                                 "Server startet neu, bitte erneut verbinden",
+		"concurrent session limit reached":                                       "Maximale Anzahl gleichzeitiger Sitzungen erreicht",
+		"transcription is temporarily unavailable: monthly usage budget reached": "Transkription vorübergehend nicht verfügbar: Monatsbudget erreicht",
+		"session ended":                                                          "Sitzung beendet",
+		"session_nonce is too long":                                              "Session-Nonce ist zu lang",
+		"unsupported protocol version":                                           "Nicht unterstützte Protokollversion",
+		"this version of HyperWhisper is no longer supported, please update":     "Diese HyperWhisper-Version wird nicht mehr unterstützt, bitte aktualisieren",
+		"session time limit reached":                                             "Zeitlimit der Sitzung erreicht",
+		"quota exceeded":                                                         "Kontingent aufgebraucht",
+		"trial expired":                                                          "Testzeitraum abgelaufen",
+		"idle timeout":                                                           "Zeitüberschreitung wegen Inaktivität",
+		"terminated by administrator":                                            "Von einem Administrator beendet",
+		"transcription service unavailable":                                      "Transkriptionsdienst nicht erreichbar",
+		"API key locked":                                                         "API-Schlüssel gesperrt",
+		"API key locked after unusual activity":                                  "API-Schlüssel wegen ungewöhnlicher Aktivität gesperrt",
+		"transcripts can't be saved in your data region":                         "Transkripte können in dieser Datenregion nicht gespeichert werden",
+
+		// Trials
+		"device_fingerprint is required":         "Geräte-Fingerabdruck erforderlich",
+		"invalid campaign code":                  "Ungültiger Aktionscode",
+		"unknown trial source":                   "Unbekannte Testquelle",
+		"invalid trial upgrade link":             "Ungültiger Upgrade-Link",
+		"invalid trial key":                      "Ungültiger Testschlüssel",
+		"trial key expired":                      "Testschlüssel ist abgelaufen",
+		"trial key revoked":                      "Testschlüssel wurde widerrufen",
+		"trial quota exceeded":                   "Testkontingent aufgebraucht",
+		"trial key cannot be re-issued":          "Testschlüssel kann nicht erneut ausgestellt werden",
+		"trial key was provisioned too recently": "Testschlüssel wurde erst kürzlich ausgestellt",
+		"too many trials from this network":      "Zu viele Testzugänge aus diesem Netzwerk",
+		"offline grants are disabled":            "Offline-Nutzung ist deaktiviert",
+		"invalid grant":                          "Ungültige Offline-Freigabe",
+		"grant already reconciled":               "Offline-Freigabe wurde bereits abgerechnet",
+		"used_seconds must not be negative":      "used_seconds darf nicht negativ sein",
+		"too many attempts, try again later":     "Zu viele Versuche, bitte später erneut versuchen",
+	},
+	"es": {
+		// Requests and authentication
+		"invalid request body":                              "Cuerpo de la solicitud no válido",
+		"request body too large":                            "El cuerpo de la solicitud es demasiado grande",
+		"database error":                                    "Error de base de datos",
+		"database unavailable, please retry shortly":        "Base de datos no disponible, inténtalo de nuevo en breve",
+		"not authenticated":                                 "No autenticado",
+		"authentication required":                           "Se requiere autenticación",
+		"missing authentication token":                      "Falta el token de autenticación",
+		"admin access required":                             "Se requiere acceso de administrador",
+		"not available to tenant staff":                     "No disponible para el personal de socios",
+		"invalid CSRF token":                                "Token CSRF no válido",
+		"insufficient scope":                                "Permisos insuficientes para esta aplicación",
+		"attestation required":                              "Se requiere la atestación del dispositivo",
+		"attestation rejected":                              "Atestación del dispositivo rechazada",
+		"unsupported attestation type":                      "Tipo de atestación no compatible",
+		"attestation unavailable":                           "La atestación del dispositivo no está disponible",
+		"invalid token":                                     "Token no válido",
+		"token has expired":                                 "El token ha caducado",
+		"token has been revoked":                            "El token ha sido revocado",
+		"account suspended":                                 "Cuenta suspendida",
+		"refresh token reuse detected":                      "Se detectó la reutilización del token de actualización, inicia sesión de nuevo",
+		"refresh token required":                            "Se requiere el token de actualización",
+		"invalid credentials":                               "Credenciales no válidas",
+		"identifier and password are required":              "Se requieren el usuario y la contraseña",
+		"username, email, and password are required":        "Se requieren el usuario, el correo y la contraseña",
+		"username already taken":                            "El nombre de usuario ya está en uso",
+		"email already taken":                               "El correo electrónico ya está en uso",
+		"password validation failed":                        "La contraseña no cumple los requisitos",
+		"signups are disabled":                              "El registro está desactivado",
+		"invite code required":                              "Se requiere un código de invitación",
+		"invalid invite code":                               "Código de invitación no válido",
+		"email domain not allowed":                          "Dominio de correo electrónico no permitido",
+		"invite link is no longer valid":                    "El enlace de invitación ya no es válido",
+		"email is required":                                 "El correo electrónico es obligatorio",
+		"token and password are required":                   "El token y la contraseña son obligatorios",
+		"invalid or expired reset link":                     "Enlace de restablecimiento no válido o caducado",
+		"invalid or used reactivation link":                 "Enlace de reactivación no válido o ya usado",
+		"API key has been revoked":                          "La clave de API ha sido revocada",
+		"unknown application":                               "Aplicación desconocida",
+		"redirect URI not registered":                       "URI de redirección no registrada",
+		"invalid scope":                                     "Ámbito no válido",
+		"unsupported response type":                         "Tipo de respuesta no compatible",
+		"unsupported code challenge method":                 "Método de code challenge no compatible",
+		"code challenge required":                           "Se requiere un code challenge",
+		"invalid or expired user code":                      "Código de usuario no válido o caducado",
+		"password is incorrect":                             "La contraseña es incorrecta",
+		"admins can't delete their own account":             "Los administradores no pueden eliminar su propia cuenta",
+		"no account deletion scheduled":                     "No hay ninguna eliminación de cuenta programada",
+		"an organization must keep at least one owner":      "Una organización debe conservar al menos un propietario",
+		"user not found":                                    "Usuario no encontrado",
+		"invalid timezone":                                  "Zona horaria no válida",
+		"unsupported locale":                                "Idioma no admitido",
+		"password was used recently":                        "Esta contraseña se ha usado recientemente",
+		"API key required":                                  "Se requiere una clave de API",
+		"api_key required":                                  "Se requiere una clave de API",
+		"invalid API key":                                   "Clave de API no válida",
+		"malformed API key":                                 "Clave de API mal formada",
+		"API key limit reached":                             "Se alcanzó el límite de claves de API",
+		"API keys are not accepted by this endpoint":        "Este endpoint no acepta claves de API",
+		"API key not found":                                 "Clave de API no encontrada",
+		"failed to get usage":                               "No se pudo obtener el uso",
+		"failed to get limits":                              "No se pudieron obtener los límites",
+		"statements are only available as PDF":              "Los extractos solo están disponibles en PDF",
+		"requested parameters are not allowed for this key": "Los parámetros solicitados no están permitidos para esta clave",
+		"transcript not found":                              "Transcripción no encontrada",
+
+		// Transcription sessions
+		"organization quota exceeded":                                            "Cuota de la organización agotada",
+		"Deepgram not configured":                                                "El servicio de transcripción no está configurado",
+		"server is restarting, please reconnect":                                 "El servidor se está reiniciando, vuelve a conectarte",
+		"concurrent session limit reached":                                       "Se alcanzó el límite de sesiones simultáneas",
+		"transcription is temporarily unavailable: monthly usage budget reached": "Transcripción no disponible temporalmente: presupuesto mensual agotado",
+		"session ended":                                                          "Sesión finalizada",
+		"session_nonce is too long":                                              "El nonce de sesión es demasiado largo",
+		"unsupported protocol version":                                           "Versión de protocolo no compatible",
+		"this version of HyperWhisper is no longer supported, please update":     "Esta versión de HyperWhisper ya no es compatible, actualízala",
+		"session time limit reached":                                             "Se alcanzó el tiempo máximo de la sesión",
+		"quota exceeded":                                                         "Cuota agotada",
+		"trial expired":                                                          "La prueba ha caducado",
+		"idle timeout":                                                           "Tiempo de inactividad agotado",
+		"terminated by administrator":                                            "Finalizada por un administrador",
+		"transcription service unavailable":                                      "Servicio de transcripción no disponible",
+		"API key locked":                                                         "Clave de API bloqueada",
+		"API key locked after unusual activity":                                  "Clave de API bloqueada por actividad inusual",
+		"transcripts can't be saved in your data region":                         "No se pueden guardar transcripciones en esta región de datos",
+
+		// Trials
+		"device_fingerprint is required":         "Se requiere la huella del dispositivo",
+		"invalid campaign code":                  "Código de campaña no válido",
+		"unknown trial source":                   "Origen de prueba desconocido",
+		"invalid trial upgrade link":             "Enlace de mejora no válido",
+		"invalid trial key":                      "Clave de prueba no válida",
+		"trial key expired":                      "La clave de prueba ha caducado",
+		"trial key revoked":                      "La clave de prueba ha sido revocada",
+		"trial quota exceeded":                   "Cuota de prueba agotada",
+		"trial key cannot be re-issued":          "La clave de prueba no se puede volver a emitir",
+		"trial key was provisioned too recently": "La clave de prueba se emitió hace muy poco",
+		"too many trials from this network":      "Demasiadas pruebas desde esta red",
+		"offline grants are disabled":            "El uso sin conexión está desactivado",
+		"invalid grant":                          "Autorización sin conexión no válida",
+		"grant already reconciled":               "La autorización sin conexión ya se ha conciliado",
+		"used_seconds must not be negative":      "used_seconds no puede ser negativo",
+		"too many attempts, try again later":     "Demasiados intentos, inténtalo de nuevo más tarde",
+	},
+	"fr": {
+		// Requests and authentication
+		"invalid request body":                              "Corps de requête invalide",
+		"request body too large":                            "Corps de requête trop volumineux",
+		"database error":                                    "Erreur de base de données",
+		"database unavailable, please retry shortly":        "Base de données indisponible, réessayez sous peu",
+		"not authenticated":                                 "Non authentifié",
+		"authentication required":                           "Authentification requise",
+		"missing authentication token":                      "Jeton d'authentification manquant",
+		"admin access required":                             "Accès administrateur requis",
+		"not available to tenant staff":                     "Non disponible pour le personnel des partenaires",
+		"invalid CSRF token":                                "Jeton CSRF invalide",
+		"insufficient scope":                                "Autorisations insuffisantes pour cette application",
+		"attestation required":                              "Attestation de l'appareil requise",
+		"attestation rejected":                              "Attestation de l'appareil refusée",
+		"unsupported attestation type":                      "Type d'attestation non pris en charge",
+		"attestation unavailable":                           "Attestation de l'appareil indisponible",
+		"invalid token":                                     "Jeton invalide",
+		"token has expired":                                 "Le jeton a expiré",
+		"token has been revoked":                            "Le jeton a été révoqué",
+		"account suspended":                                 "Compte suspendu",
+		"refresh token reuse detected":                      "Réutilisation du jeton d'actualisation détectée, veuillez vous reconnecter",
+		"refresh token required":                            "Jeton de rafraîchissement requis",
+		"invalid credentials":                               "Identifiants invalides",
+		"identifier and password are required":              "L'identifiant et le mot de passe sont requis",
+		"username, email, and password are required":        "Le nom d'utilisateur, l'e-mail et le mot de passe sont requis",
+		"username already taken":                            "Ce nom d'utilisateur est déjà pris",
+		"email already taken":                               "Cette adresse e-mail est déjà utilisée",
+		"password validation failed":                        "Le mot de passe ne respecte pas les exigences",
+		"signups are disabled":                              "Les inscriptions sont désactivées",
+		"invite code required":                              "Code d'invitation requis",
+		"invalid invite code":                               "Code d'invitation invalide",
+		"email domain not allowed":                          "Domaine d'adresse e-mail non autorisé",
+		"invite link is no longer valid":                    "Le lien d'invitation n'est plus valide",
+		"email is required":                                 "L'adresse e-mail est requise",
+		"token and password are required":                   "Le jeton et le mot de passe sont requis",
+		"invalid or expired reset link":                     "Lien de réinitialisation invalide ou expiré",
+		"invalid or used reactivation link":                 "Lien de réactivation invalide ou déjà utilisé",
+		"API key has been revoked":                          "La clé API a été révoquée",
+		"unknown application":                               "Application inconnue",
+		"redirect URI not registered":                       "URI de redirection non enregistrée",
+		"invalid scope":                                     "Portée invalide",
+		"unsupported response type":                         "Type de réponse non pris en charge",
+		"unsupported code challenge method":                 "Méthode de code challenge non prise en charge",
+		"code challenge required":                           "Code challenge requis",
+		"invalid or expired user code":                      "Code utilisateur invalide ou expiré",
+		"password is incorrect":                             "Le mot de passe est incorrect",
+		"admins can't delete their own account":             "Les administrateurs ne peuvent pas supprimer leur propre compte",
+		"no account deletion scheduled":                     "Aucune suppression de compte n'est programmée",
+		"an organization must keep at least one owner":      "Une organisation doit conserver au moins un propriétaire",
+		"user not found":                                    "Utilisateur introuvable",
+		"invalid timezone":                                  "Fuseau horaire invalide",
+		"unsupported locale":                                "Langue non prise en charge",
+		"password was used recently":                        "Ce mot de passe a été utilisé récemment",
+		"API key required":                                  "Clé API requise",
+		"api_key required":                                  "Clé API requise",
+		"invalid API key":                                   "Clé API invalide",
+		"malformed API key":                                 "Clé API mal formée",
+		"API key limit reached":                             "Limite de clés API atteinte",
+		"API keys are not accepted by this endpoint":        "Ce point de terminaison n'accepte pas les clés API",
+		"API key not found":                                 "Clé API introuvable",
+		"failed to get usage":                               "Impossible de récupérer l'utilisation",
+		"failed to get limits":                              "Impossible de récupérer les limites",
+		"statements are only available as PDF":              "Les relevés ne sont disponibles qu'en PDF",
+		"requested parameters are not allowed for this key": "Les paramètres demandés ne sont pas autorisés pour cette clé",
+		"transcript not found":                              "Transcription introuvable",
+
+		// Transcription sessions
+		"organization quota exceeded":                                            "Quota de l'organisation épuisé",
+		"Deepgram not configured":                                                "Le service de transcription n'est pas configuré",
+		"server is restarting, please reconnect":                                 "Le serveur redémarre, veuillez vous reconnecter",
+		"concurrent session limit reached":                                       "Limite de sessions simultanées atteinte",
+		"transcription is temporarily unavailable: monthly usage budget reached": "Transcription temporairement indisponible : budget mensuel atteint",
+		"session ended":                                                          "Session terminée",
+		"session_nonce is too long":                                              "Le nonce de session est trop long",
+		"unsupported protocol version":                                           "Version de protocole non prise en charge",
+		"this version of HyperWhisper is no longer supported, please update":     "Cette version de HyperWhisper n'est plus prise en charge, veuillez la mettre à jour",
+		"session time limit reached":                                             "Durée maximale de session atteinte",
+		"quota exceeded":                                                         "Quota épuisé",
+		"trial expired":                                                          "Essai expiré",
+		"idle timeout":                                                           "Délai d'inactivité dépassé",
+		"terminated by administrator":                                            "Interrompue par un administrateur",
+		"transcription service unavailable":                                      "Service de transcription indisponible",
+		"API key locked":                                                         "Clé API verrouillée",
+		"API key locked after unusual activity":                                  "Clé API verrouillée suite à une activité inhabituelle",
+		"transcripts can't be saved in your data region":                         "Les transcriptions ne peuvent pas être enregistrées dans cette région de données",
+
+		// Trials
+		"device_fingerprint is required":         "L'empreinte de l'appareil est requise",
+		"invalid campaign code":                  "Code de campagne invalide",
+		"unknown trial source":                   "Source d'essai inconnue",
+		"invalid trial upgrade link":             "Lien de mise à niveau invalide",
+		"invalid trial key":                      "Clé d'essai invalide",
+		"trial key expired":                      "La clé d'essai a expiré",
+		"trial key revoked":                      "La clé d'essai a été révoquée",
+		"trial quota exceeded":                   "Quota d'essai épuisé",
+		"trial key cannot be re-issued":          "La clé d'essai ne peut pas être réémise",
+		"trial key was provisioned too recently": "La clé d'essai a été émise trop récemment",
+		"too many trials from this network":      "Trop d'essais depuis ce réseau",
+		"offline grants are disabled":            "L'utilisation hors ligne est désactivée",
+		"invalid grant":                          "Autorisation hors ligne invalide",
+		"grant already reconciled":               "L'autorisation hors ligne a déjà été rapprochée",
+		"used_seconds must not be negative":      "used_seconds ne peut pas être négatif",
+		"too many attempts, try again later":     "Trop de tentatives, réessayez plus tard",
+	},
+}
@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// keySchemes are the API key prefixes and how many characters of the
+// random part follow them in a key's display prefix
+var keySchemes = []struct {
+	prefix string
+	shown  int
+}{
+	{"hw_trial_", 7},
+	{"hw_live_", 4},
+	{"hw_test_", 4},
+}
+
+// keyIDHashLength is how many hex digits of a key's hash its ID ends with
+const keyIDHashLength = 8
+
+// KeyPrefix returns the display prefix of an API key, e.g. hw_live_abcd: its
+// scheme and the start of its random part, never more than half of it. Keys
+// without a known scheme have no prefix, since they may be other secrets.
+func KeyPrefix(key string) string {
+	for _, s := range keySchemes {
+		if rest, ok := strings.CutPrefix(key, s.prefix); ok {
+			return s.prefix + rest[:min(s.shown, len(rest)/2)]
+		}
+	}
+	return ""
+}
+
+// KeyID returns the identifier logs and errors show for an API key, e.g.
+// hw_live_abcd...1a2b3c4d: its display prefix and the start of its SHA-256
+// hash, which tells keys with the same prefix apart. It never panics, however
+// short or malformed the key.
+func KeyID(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return StoredKeyID(KeyPrefix(key), hex.EncodeToString(hash[:]))
+}
+
+// StoredKeyID returns the KeyID of a stored key from its display prefix and
+// hex SHA-256 hash, so it matches what was logged for the key itself
+func StoredKeyID(prefix, hash string) string {
+	return prefix + "..." + hash[:min(keyIDHashLength, len(hash))]
+}
@@ -0,0 +1,162 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"hyperwhisper/internal/config"
+)
+
+// Category is a kind of personal data that can be redacted from logs
+type Category string
+
+const (
+	Transcripts Category = "transcripts"
+	Emails      Category = "emails"
+	IPs         Category = "ips"
+	Keys        Category = "keys"
+)
+
+var allCategories = []Category{Transcripts, Emails, IPs, Keys}
+
+var (
+	// Transcript text appears in Deepgram results as transcript, word and
+	// punctuated_word fields
+	transcriptPattern = regexp.MustCompile(`"(transcript|word|punctuated_word|text)"(\s*):(\s*)"(?:[^"\\]|\\.)*"`)
+	emailPattern      = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	ipv4Pattern       = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	ipv6Pattern       = regexp.MustCompile(`\b[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}\b`)
+	keyPattern        = regexp.MustCompile(`\bhw_(live|trial|test)_[0-9A-Za-z]+`)
+)
+
+// enabled holds the active set of categories. Everything is redacted until
+// Configure runs so nothing leaks during startup.
+var enabled atomic.Value
+
+func init() {
+	enabled.Store(categorySet(allCategories))
+}
+
+func categorySet(categories []Category) map[Category]bool {
+	set := make(map[Category]bool, len(categories))
+	for _, c := range categories {
+		set[c] = true
+	}
+	return set
+}
+
+// ParseCategories parses a LOG_REDACT value: a comma-separated list of
+// categories, "all" or "none"
+func ParseCategories(spec string) ([]Category, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "none":
+		return nil, nil
+	case "all", "":
+		return allCategories, nil
+	}
+
+	var categories []Category
+	for _, part := range strings.Split(spec, ",") {
+		c := Category(strings.TrimSpace(part))
+		valid := false
+		for _, known := range allCategories {
+			if c == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown redaction category %q (expected %v, all or none)", c, allCategories)
+		}
+		categories = append(categories, c)
+	}
+	return categories, nil
+}
+
+// Configure applies LOG_REDACT and keeps it up to date when the setting is
+// reloaded
+func Configure() error {
+	if err := apply(config.String("LOG_REDACT")); err != nil {
+		return err
+	}
+
+	config.OnChange(func(name, previous string) {
+		if name != "LOG_REDACT" {
+			return
+		}
+		if err := apply(config.String("LOG_REDACT")); err != nil {
+			log.Printf("[Logging] Ignoring LOG_REDACT change: %v", err)
+		}
+	})
+	return nil
+}
+
+func apply(spec string) error {
+	categories, err := ParseCategories(spec)
+	if err != nil {
+		return err
+	}
+	enabled.Store(categorySet(categories))
+	return nil
+}
+
+// Sanitize removes the enabled categories of personal data from s
+func Sanitize(s string) string {
+	set := enabled.Load().(map[Category]bool)
+
+	if set[Transcripts] {
+		s = transcriptPattern.ReplaceAllString(s, `"$1"$2:$3"[redacted]"`)
+	}
+	if set[Emails] {
+		s = emailPattern.ReplaceAllString(s, "[email]")
+	}
+	if set[IPs] {
+		s = ipv4Pattern.ReplaceAllStringFunc(s, func(m string) string {
+			if net.ParseIP(m) == nil {
+				return m
+			}
+			return "[ip]"
+		})
+		// Only replace candidates that really parse as IPv6 so timestamps
+		// like 12:34:56 are left alone
+		s = ipv6Pattern.ReplaceAllStringFunc(s, func(m string) string {
+			if net.ParseIP(m) == nil {
+				return m
+			}
+			return "[ip]"
+		})
+	}
+	if set[Keys] {
+		s = keyPattern.ReplaceAllString(s, "hw_${1}_[redacted]")
+	}
+	return s
+}
+
+// writer sanitizes everything written through it
+type writer struct {
+	out io.Writer
+}
+
+// NewWriter wraps out so every write is sanitized
+func NewWriter(out io.Writer) io.Writer {
+	return &writer{out: out}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.out, Sanitize(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Install routes the standard logger through the sanitizer
+func Install() {
+	log.SetOutput(NewWriter(os.Stderr))
+}
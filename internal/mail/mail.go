@@ -0,0 +1,102 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"hyperwhisper/internal/config"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers emails. The server uses the SMTP sender from the SMTP_*
+// settings; other transports (an email API, a queue) can be plugged in
+// where a handler accepts a Sender.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewFromConfig returns the SMTP sender when SMTP_HOST is set, and
+// otherwise a sender that only logs that a message was dropped
+func NewFromConfig() Sender {
+	if config.String("SMTP_HOST") == "" {
+		return LogSender{}
+	}
+	return &SMTPSender{timeout: 30 * time.Second}
+}
+
+// SMTPSender sends through SMTP_HOST:SMTP_PORT, upgrading to TLS when the
+// server offers STARTTLS and authenticating with SMTP_USERNAME and
+// SMTP_PASSWORD when set. Settings are read on every send.
+type SMTPSender struct {
+	timeout time.Duration
+}
+
+// Send implements Sender
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	host := config.String("SMTP_HOST")
+	addr := net.JoinHostPort(host, config.String("SMTP_PORT"))
+	from := config.String("MAIL_FROM")
+
+	// The envelope takes the bare address of "Name <address>"
+	sender, err := netmail.ParseAddress(from)
+	if err != nil {
+		return fmt.Errorf("invalid MAIL_FROM: %w", err)
+	}
+
+	var auth smtp.Auth
+	if username := config.String("SMTP_USERNAME"); username != "" {
+		auth = smtp.PlainAuth("", username, config.String("SMTP_PASSWORD"), host)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, sender.Address, []string{msg.To}, formatMessage(from, msg))
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("sending mail via %s: %w", addr, ctx.Err())
+	}
+}
+
+// LogSender drops messages, logging only the subject; recipients and links
+// are left out of the log
+type LogSender struct{}
+
+// Send implements Sender
+func (LogSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("[Mail] SMTP_HOST is not set, dropped %q", msg.Subject)
+	return nil
+}
+
+// headerSanitizer keeps header values on one line
+var headerSanitizer = strings.NewReplacer("\r", "", "\n", "")
+
+func formatMessage(from string, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", headerSanitizer.Replace(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerSanitizer.Replace(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerSanitizer.Replace(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,40 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"hyperwhisper/internal/awsapi"
+	"hyperwhisper/internal/config"
+)
+
+// fetchAWS reads a secret from AWS Secrets Manager. The secret string must
+// be a JSON object keyed by setting name.
+func (s *Store) fetchAWS(ctx context.Context) (map[string]string, error) {
+	secretID := config.String("AWS_SECRET_ID")
+	if secretID == "" {
+		return nil, errors.New("AWS_SECRET_ID is required")
+	}
+
+	client, err := awsapi.NewFromConfig(s.client.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload struct {
+		SecretString string `json:"SecretString"`
+	}
+	err = client.Call(ctx, "secretsmanager", "secretsmanager.GetSecretValue",
+		map[string]string{"SecretId": secretID}, &payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(payload.SecretString), &data); err != nil {
+		return nil, fmt.Errorf("secret %s is not a JSON object: %w", secretID, err)
+	}
+	return stringValues(data), nil
+}
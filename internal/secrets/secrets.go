@@ -0,0 +1,55 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"hyperwhisper/internal/config"
+)
+
+// Store fetches setting values from the secret backend selected by
+// SECRETS_PROVIDER. The backend is chosen on every fetch so it always uses
+// the current connection settings.
+type Store struct {
+	client *http.Client
+}
+
+// NewStore creates a secret store that talks to the configured backend
+func NewStore() *Store {
+	return &Store{
+		client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Name identifies the store in logs and validation errors
+func (s *Store) Name() string {
+	return "SECRETS_PROVIDER=" + config.String("SECRETS_PROVIDER")
+}
+
+// Fetch returns the secret values keyed by setting name (e.g. JWT_SECRET,
+// DEEPGRAM_API_KEY). It returns nil when no provider is configured.
+func (s *Store) Fetch(ctx context.Context) (map[string]string, error) {
+	switch config.String("SECRETS_PROVIDER") {
+	case "none":
+		return nil, nil
+	case "vault":
+		return s.fetchVault(ctx)
+	case "aws":
+		return s.fetchAWS(ctx)
+	default:
+		return nil, fmt.Errorf("unknown secrets provider %q", config.String("SECRETS_PROVIDER"))
+	}
+}
+
+// stringValues keeps only string values from a decoded secret payload
+func stringValues(data map[string]interface{}) map[string]string {
+	out := make(map[string]string, len(data))
+	for k, v := range data {
+		if s, ok := v.(string); ok {
+			out[k] = s
+		}
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"hyperwhisper/internal/config"
+)
+
+// fetchVault reads a KV secret from HashiCorp Vault. Both KV v1 and KV v2
+// mounts are supported; for v2 the path must include the "data/" segment
+// (e.g. secret/data/hyperwhisper).
+func (s *Store) fetchVault(ctx context.Context) (map[string]string, error) {
+	addr := strings.TrimRight(config.String("VAULT_ADDR"), "/")
+	token := config.String("VAULT_TOKEN")
+	path := strings.Trim(config.String("VAULT_SECRET_PATH"), "/")
+
+	if addr == "" || token == "" || path == "" {
+		return nil, errors.New("VAULT_ADDR, VAULT_TOKEN and VAULT_SECRET_PATH are required")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr+"/v1/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := config.String("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault returned status %d", resp.StatusCode)
+	}
+
+	var payload struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid vault response: %w", err)
+	}
+
+	// KV v2 nests the secret under data.data alongside data.metadata
+	if nested, ok := payload.Data["data"].(map[string]interface{}); ok {
+		if _, hasMeta := payload.Data["metadata"]; hasMeta {
+			return stringValues(nested), nil
+		}
+	}
+	return stringValues(payload.Data), nil
+}
@@ -0,0 +1,134 @@
+// Package statement renders monthly usage statements as PDF documents.
+package statement
+
+import (
+	"fmt"
+	"time"
+)
+
+// Line is one API key's usage within the statement period
+type Line struct {
+	Description string
+	Sessions    int64
+	Seconds     float64
+}
+
+// Statement is everything printed on a usage statement
+type Statement struct {
+	Number         string
+	Issuer         string
+	IssuedAt       time.Time
+	CustomerName   string
+	CustomerEmail  string
+	PeriodStart    time.Time
+	PeriodEnd      time.Time
+	Timezone       string
+	Currency       string
+	PricePerMinute float64
+	Lines          []Line
+}
+
+// Amount returns the charge for the given number of seconds
+func (s Statement) Amount(seconds float64) float64 {
+	return seconds / 60 * s.PricePerMinute
+}
+
+// Totals sums the statement lines
+func (s Statement) Totals() (sessions int64, seconds float64) {
+	for _, l := range s.Lines {
+		sessions += l.Sessions
+		seconds += l.Seconds
+	}
+	return sessions, seconds
+}
+
+// Column positions of the usage table
+const (
+	marginLeft   = 50.0
+	marginRight  = pageWidth - 50
+	colSessions  = 330.0
+	colMinutes   = 430.0
+	bottomMargin = 80.0
+	rowHeight    = 18.0
+)
+
+// Render lays the statement out as a PDF
+func Render(s Statement) []byte {
+	d := newDocument()
+	y := pageHeight - 70
+
+	d.text(marginLeft, y, 20, true, s.Issuer)
+	d.textRight(marginRight, y, 20, true, "Usage Statement")
+	y -= 36
+
+	d.text(marginLeft, y, 10, false, s.CustomerName)
+	d.textRight(marginRight, y, 10, false, "Statement "+s.Number)
+	y -= 14
+	d.text(marginLeft, y, 10, false, s.CustomerEmail)
+	d.textRight(marginRight, y, 10, false, "Issued "+s.IssuedAt.Format("2006-01-02"))
+	y -= 14
+	// The end boundary is exclusive, so show the last day it covers
+	period := fmt.Sprintf("Period %s to %s (%s)",
+		s.PeriodStart.Format("2006-01-02"), s.PeriodEnd.Add(-time.Second).Format("2006-01-02"), s.Timezone)
+	d.text(marginLeft, y, 10, false, period)
+	y -= 36
+
+	header := func() {
+		d.text(marginLeft, y, 10, true, "API key")
+		d.textRight(colSessions, y, 10, true, "Sessions")
+		d.textRight(colMinutes, y, 10, true, "Minutes")
+		d.textRight(marginRight, y, 10, true, "Amount ("+s.Currency+")")
+		y -= 6
+		d.rule(marginLeft, marginRight, y)
+		y -= rowHeight - 6
+	}
+	header()
+
+	for _, l := range s.Lines {
+		if y < bottomMargin {
+			d.newPage()
+			y = pageHeight - 70
+			header()
+		}
+		d.text(marginLeft, y, 10, false, l.Description)
+		d.textRight(colSessions, y, 10, false, fmt.Sprintf("%d", l.Sessions))
+		d.textRight(colMinutes, y, 10, false, formatMinutes(l.Seconds))
+		d.textRight(marginRight, y, 10, false, formatAmount(s.Amount(l.Seconds)))
+		y -= rowHeight
+	}
+	if len(s.Lines) == 0 {
+		d.text(marginLeft, y, 10, false, "No transcription usage in this period.")
+		y -= rowHeight
+	}
+
+	if y < bottomMargin+2*rowHeight {
+		d.newPage()
+		y = pageHeight - 70
+	}
+	y += rowHeight - 6
+	d.rule(marginLeft, marginRight, y)
+	y -= rowHeight - 6
+
+	sessions, seconds := s.Totals()
+	d.text(marginLeft, y, 10, true, "Total")
+	d.textRight(colSessions, y, 10, true, fmt.Sprintf("%d", sessions))
+	d.textRight(colMinutes, y, 10, true, formatMinutes(seconds))
+	d.textRight(marginRight, y, 10, true, formatAmount(s.Amount(seconds)))
+	y -= 2 * rowHeight
+
+	d.text(marginLeft, y, 8, false, fmt.Sprintf("Rate: %s %s per minute of audio streamed.", formatRate(s.PricePerMinute), s.Currency))
+
+	return d.bytes()
+}
+
+func formatMinutes(seconds float64) string {
+	return fmt.Sprintf("%.2f", seconds/60)
+}
+
+func formatAmount(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+func formatRate(rate float64) string {
+	return fmt.Sprintf("%.4f", rate)
+}
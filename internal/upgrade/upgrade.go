@@ -0,0 +1,181 @@
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Environment variables used to hand the listening socket and the readiness
+// pipe from a parent process to the upgraded child process.
+const (
+	listenFDEnv = "HYPERWHISPER_LISTEN_FD"
+	readyFDEnv  = "HYPERWHISPER_READY_FD"
+)
+
+var (
+	ErrUpgradeInProgress = errors.New("upgrade already in progress")
+	ErrNotReady          = errors.New("child process did not become ready")
+)
+
+// Upgrader manages a listener that can be handed to a replacement process
+// so a new binary can start accepting connections before the old one exits.
+type Upgrader struct {
+	listener *net.TCPListener
+
+	mu        sync.Mutex
+	upgrading bool
+	readyOnce sync.Once
+}
+
+// Listen returns an Upgrader bound to addr. If this process was started by
+// Upgrade, the inherited socket is reused instead of binding a new one.
+// Freshly bound sockets set SO_REUSEPORT so an independently started process
+// can also bind the same address during a rolling restart.
+func Listen(addr string) (*Upgrader, error) {
+	if fdStr := os.Getenv(listenFDEnv); fdStr != "" {
+		fd, err := strconv.Atoi(fdStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", listenFDEnv, err)
+		}
+
+		file := os.NewFile(uintptr(fd), "inherited-listener")
+		ln, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to inherit listener: %w", err)
+		}
+
+		tcpLn, ok := ln.(*net.TCPListener)
+		if !ok {
+			ln.Close()
+			return nil, fmt.Errorf("inherited listener is not TCP")
+		}
+		return &Upgrader{listener: tcpLn}, nil
+	}
+
+	lc := net.ListenConfig{Control: reusePortControl}
+	ln, err := lc.Listen(context.Background(), "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Upgrader{listener: ln.(*net.TCPListener)}, nil
+}
+
+// Listener returns the listening socket
+func (u *Upgrader) Listener() net.Listener {
+	return u.listener
+}
+
+// Inherited reports whether the listener was handed over by a parent process
+func (u *Upgrader) Inherited() bool {
+	return os.Getenv(listenFDEnv) != ""
+}
+
+// Ready notifies the parent process (if any) that this process is serving
+// requests and the parent may begin draining. It is a no-op otherwise.
+func (u *Upgrader) Ready() {
+	u.readyOnce.Do(func() {
+		fdStr := os.Getenv(readyFDEnv)
+		if fdStr == "" {
+			return
+		}
+		fd, err := strconv.Atoi(fdStr)
+		if err != nil {
+			return
+		}
+		pipe := os.NewFile(uintptr(fd), "ready-pipe")
+		_, _ = pipe.Write([]byte{1})
+		pipe.Close()
+
+		// Children of this process must not think they were upgraded into
+		os.Unsetenv(listenFDEnv)
+		os.Unsetenv(readyFDEnv)
+	})
+}
+
+// Upgrade starts a new copy of the current binary with the same arguments,
+// passing it the listening socket. It blocks until the child reports ready
+// or timeout elapses. On success the caller should stop accepting
+// connections and drain in-flight sessions before exiting.
+func (u *Upgrader) Upgrade(timeout time.Duration) error {
+	u.mu.Lock()
+	if u.upgrading {
+		u.mu.Unlock()
+		return ErrUpgradeInProgress
+	}
+	u.upgrading = true
+	u.mu.Unlock()
+
+	defer func() {
+		u.mu.Lock()
+		u.upgrading = false
+		u.mu.Unlock()
+	}()
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to resolve executable: %w", err)
+	}
+
+	lnFile, err := u.listener.File()
+	if err != nil {
+		return fmt.Errorf("failed to duplicate listener: %w", err)
+	}
+	defer lnFile.Close()
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create ready pipe: %w", err)
+	}
+	defer readyR.Close()
+
+	// ExtraFiles start at fd 3 in the child
+	child := exec.Command(executable, os.Args[1:]...)
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	child.ExtraFiles = []*os.File{lnFile, readyW}
+	child.Env = append(os.Environ(),
+		listenFDEnv+"=3",
+		readyFDEnv+"=4",
+	)
+
+	if err := child.Start(); err != nil {
+		readyW.Close()
+		return fmt.Errorf("failed to start child: %w", err)
+	}
+	readyW.Close()
+
+	// Reap the child if it exits early so it doesn't linger as a zombie
+	exited := make(chan error, 1)
+	go func() {
+		exited <- child.Wait()
+	}()
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		_, err := readyR.Read(buf)
+		ready <- err
+	}()
+
+	select {
+	case err := <-ready:
+		if err != nil {
+			_ = child.Process.Kill()
+			return ErrNotReady
+		}
+		return nil
+	case err := <-exited:
+		return fmt.Errorf("child exited before becoming ready: %v", err)
+	case <-time.After(timeout):
+		_ = child.Process.Kill()
+		return ErrNotReady
+	}
+}
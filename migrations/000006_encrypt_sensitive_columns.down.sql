@@ -0,0 +1,7 @@
+-- Values encrypted after the up migration stay encrypted; the columns keep
+-- the TEXT type so they are not truncated.
+ALTER TABLE trial_api_keys DROP COLUMN IF EXISTS device_fingerprint_hash;
+ALTER TABLE trial_api_keys ADD CONSTRAINT trial_api_keys_device_fingerprint_key UNIQUE (device_fingerprint);
+CREATE INDEX idx_trial_api_keys_fingerprint ON trial_api_keys(device_fingerprint);
+
+DROP TABLE IF EXISTS encryption_keys;
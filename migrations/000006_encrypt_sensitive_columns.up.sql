@@ -0,0 +1,20 @@
+-- Encryption keys table - data and index keys, wrapped by the master key
+CREATE TABLE encryption_keys (
+    id SERIAL PRIMARY KEY,
+    purpose VARCHAR(16) NOT NULL CHECK (purpose IN ('data', 'index')),
+    wrapped_key TEXT NOT NULL,
+    master_key_id VARCHAR(255) NOT NULL,  -- Master key that wrapped this key (e.g. "local:1a2b3c4d")
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    retired_at TIMESTAMP WITH TIME ZONE NULL  -- Retired data keys only decrypt, never encrypt
+);
+
+-- Encrypted values are longer than the plaintext they replace
+ALTER TABLE trial_api_keys ALTER COLUMN device_fingerprint TYPE TEXT;
+ALTER TABLE transcription_logs ALTER COLUMN client_ip TYPE TEXT;
+ALTER TABLE trial_usage ALTER COLUMN client_ip TYPE TEXT;
+
+-- Encrypted fingerprints are randomized, so uniqueness and lookups move to a
+-- keyed hash (blind index). Existing rows are backfilled by `encryption reencrypt`.
+ALTER TABLE trial_api_keys DROP CONSTRAINT trial_api_keys_device_fingerprint_key;
+DROP INDEX IF EXISTS idx_trial_api_keys_fingerprint;
+ALTER TABLE trial_api_keys ADD COLUMN device_fingerprint_hash VARCHAR(64) NULL UNIQUE;
@@ -0,0 +1,14 @@
+-- Session policies - Deepgram parameters forced on matching sessions,
+-- overriding whatever the client requested (e.g. redact=pci,ssn)
+CREATE TABLE session_policies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    scope VARCHAR(20) NOT NULL CHECK (scope IN ('all', 'trial', 'user_type', 'user', 'api_key')),
+    scope_value VARCHAR(255) NULL,  -- user type, user ID or API key ID, depending on scope
+    params JSONB NOT NULL DEFAULT '{}',  -- e.g. {"redact": "pci,ssn", "profanity_filter": "true"}
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_session_policies_enabled ON session_policies(scope) WHERE enabled;
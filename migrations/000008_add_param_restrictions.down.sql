@@ -0,0 +1,2 @@
+ALTER TABLE trial_limits DROP COLUMN IF EXISTS param_restrictions;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS param_restrictions;
@@ -0,0 +1,8 @@
+-- Per-key Deepgram parameter restrictions: param name -> allowed values.
+-- An empty list forbids the param entirely; '{}' means unrestricted.
+ALTER TABLE api_keys ADD COLUMN param_restrictions JSONB NOT NULL DEFAULT '{}';
+
+-- Restrictions shared by all trial keys (trial keys are locked to nova-2
+-- general without diarization by default)
+ALTER TABLE trial_limits ADD COLUMN param_restrictions JSONB NOT NULL
+    DEFAULT '{"model": ["nova-2", "nova-2-general"], "diarize": ["false"]}';
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS client_error_reports;
@@ -0,0 +1,24 @@
+-- Client error reports - connection failures, audio device errors and crash
+-- signatures reported by the desktop app, kept for admin triage
+CREATE TABLE client_error_reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('connection', 'audio_device', 'crash')),
+    message TEXT NOT NULL,
+    signature VARCHAR(255) NULL,  -- Crash signature or error code, used to group reports
+    close_code INTEGER NULL,  -- WebSocket close code the client received, if any
+    app_version VARCHAR(50) NULL,
+    os VARCHAR(100) NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    user_id UUID NULL REFERENCES users(id) ON DELETE CASCADE,
+    api_key_id UUID NULL REFERENCES api_keys(id) ON DELETE SET NULL,
+    trial_key_id UUID NULL REFERENCES trial_api_keys(id) ON DELETE SET NULL,
+    transcription_log_id UUID NULL REFERENCES transcription_logs(id) ON DELETE SET NULL,  -- Session the error most likely belongs to
+    trial_usage_id UUID NULL REFERENCES trial_usage(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'new' CHECK (status IN ('new', 'triaged', 'resolved', 'ignored')),
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_client_error_reports_created ON client_error_reports(created_at);
+CREATE INDEX idx_client_error_reports_triage ON client_error_reports(kind, status);
+CREATE INDEX idx_client_error_reports_signature ON client_error_reports(signature);
@@ -0,0 +1,15 @@
+-- Audit events - admin actions taken against users and keys, kept for
+-- abuse response and accountability
+CREATE TABLE audit_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_user_id UUID NULL REFERENCES users(id) ON DELETE SET NULL,  -- Admin who acted; NULL once the admin is deleted
+    action VARCHAR(64) NOT NULL,  -- e.g. "api_key.revoke"
+    target_type VARCHAR(32) NOT NULL,  -- e.g. "api_key"
+    target_id VARCHAR(255) NOT NULL,
+    reason TEXT NULL,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_events_target ON audit_events(target_type, target_id);
+CREATE INDEX idx_audit_events_created ON audit_events(created_at);
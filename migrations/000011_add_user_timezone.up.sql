@@ -0,0 +1,2 @@
+-- IANA time zone used to anchor a user's usage periods (e.g. "Europe/Berlin")
+ALTER TABLE users ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
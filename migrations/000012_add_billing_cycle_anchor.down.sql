@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN IF EXISTS billing_cycle_anchor;
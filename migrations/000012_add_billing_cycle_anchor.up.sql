@@ -0,0 +1,3 @@
+-- Start of the user's billing cycle; quota windows renew on its monthly
+-- anniversary. NULL means the cycle is anchored to the signup date.
+ALTER TABLE users ADD COLUMN billing_cycle_anchor TIMESTAMP WITH TIME ZONE NULL;
@@ -0,0 +1,13 @@
+-- Offline grace grants: seconds a trial client may transcribe locally while
+-- the server is unreachable, reconciled into trial_usage on reconnect
+CREATE TABLE trial_grants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    trial_key_id UUID NOT NULL REFERENCES trial_api_keys(id) ON DELETE CASCADE,
+    granted_seconds INTEGER NOT NULL CHECK (granted_seconds > 0),
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    reconciled_at TIMESTAMP WITH TIME ZONE NULL,
+    used_seconds DECIMAL(12, 3) NULL
+);
+
+CREATE INDEX idx_trial_grants_key ON trial_grants(trial_key_id);
@@ -0,0 +1,17 @@
+CREATE TABLE trial_limits (
+    id INTEGER PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    max_duration_seconds INTEGER NOT NULL DEFAULT 3600,
+    max_sessions INTEGER NOT NULL DEFAULT 100,
+    max_session_duration_seconds INTEGER NOT NULL DEFAULT 600,
+    expiry_days INTEGER NOT NULL DEFAULT 90,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    param_restrictions JSONB NOT NULL
+        DEFAULT '{"model": ["nova-2", "nova-2-general"], "diarize": ["false"]}'
+);
+
+INSERT INTO trial_limits (max_duration_seconds, max_sessions, max_session_duration_seconds, expiry_days, param_restrictions)
+SELECT max_duration_seconds, max_sessions, max_session_duration_seconds, expiry_days, param_restrictions
+FROM trial_presets WHERE name = 'default';
+
+ALTER TABLE trial_api_keys DROP COLUMN IF EXISTS preset;
+DROP TABLE IF EXISTS trial_presets;
@@ -0,0 +1,32 @@
+-- Named trial limit presets replace the single trial_limits row. Keys are
+-- provisioned with 'default' unless a signed campaign code selects another.
+CREATE TABLE trial_presets (
+    name VARCHAR(64) PRIMARY KEY CHECK (name ~ '^[a-z0-9][a-z0-9-]*$'),
+    description TEXT NOT NULL DEFAULT '',
+    max_duration_seconds INTEGER NOT NULL,
+    max_sessions INTEGER NOT NULL,
+    max_session_duration_seconds INTEGER NOT NULL,
+    expiry_days INTEGER NOT NULL,
+    param_restrictions JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO trial_presets (name, description, max_duration_seconds, max_sessions, max_session_duration_seconds, expiry_days, param_restrictions)
+SELECT 'default', 'Standard trial', max_duration_seconds, max_sessions, max_session_duration_seconds, expiry_days, param_restrictions
+FROM trial_limits WHERE id = 1;
+
+INSERT INTO trial_presets (name, description, max_duration_seconds, max_sessions, max_session_duration_seconds, expiry_days, param_restrictions)
+SELECT 'extended-beta', 'Beta testers', max_duration_seconds * 4, max_sessions * 4, max_session_duration_seconds * 2, expiry_days * 2, param_restrictions
+FROM trial_presets WHERE name = 'default';
+
+INSERT INTO trial_presets (name, description, max_duration_seconds, max_sessions, max_session_duration_seconds, expiry_days, param_restrictions)
+SELECT 'press-demo', 'Press and reviewers', max_duration_seconds * 2, max_sessions * 2, max_session_duration_seconds * 2, 30, '{}'
+FROM trial_presets WHERE name = 'default';
+
+ALTER TABLE trial_api_keys ADD COLUMN preset VARCHAR(64) NOT NULL DEFAULT 'default'
+    REFERENCES trial_presets(name) ON UPDATE CASCADE;
+
+CREATE INDEX idx_trial_api_keys_preset ON trial_api_keys(preset);
+
+DROP TABLE trial_limits;
@@ -0,0 +1,13 @@
+-- Trial keys converted into accounts through the signed upgrade link. The
+-- unused trial quota (carryover) and the conversion bonus are credited to
+-- the new user.
+CREATE TABLE trial_conversions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    trial_key_id UUID UNIQUE NULL REFERENCES trial_api_keys(id) ON DELETE SET NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    carryover_seconds INTEGER NOT NULL DEFAULT 0 CHECK (carryover_seconds >= 0),
+    bonus_seconds INTEGER NOT NULL DEFAULT 0 CHECK (bonus_seconds >= 0),
+    converted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_trial_conversions_user ON trial_conversions(user_id);
@@ -0,0 +1,2 @@
+DROP FUNCTION IF EXISTS maintain_access_log_partitions(INTEGER);
+DROP TABLE IF EXISTS access_logs;
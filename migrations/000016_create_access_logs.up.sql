@@ -0,0 +1,56 @@
+-- Structured HTTP access records for investigating API abuse. The table is
+-- range-partitioned by month; maintain_access_log_partitions() creates the
+-- current and next month's partitions and drops those past the retention
+-- window. The server calls it periodically.
+CREATE TABLE access_logs (
+    id UUID NOT NULL DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    method VARCHAR(10) NOT NULL,
+    route VARCHAR(255) NOT NULL,
+    path TEXT NOT NULL,
+    status INTEGER NOT NULL,
+    latency_ms INTEGER NOT NULL,
+    user_id UUID NULL,
+    client_ip TEXT NULL,
+    client_ip_hash VARCHAR(64) NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+-- Catches rows if maintenance has not created a month's partition in time
+CREATE TABLE access_logs_default PARTITION OF access_logs DEFAULT;
+
+CREATE INDEX idx_access_logs_created_at ON access_logs(created_at);
+CREATE INDEX idx_access_logs_user ON access_logs(user_id, created_at);
+CREATE INDEX idx_access_logs_ip ON access_logs(client_ip_hash, created_at);
+
+CREATE FUNCTION maintain_access_log_partitions(retention_days INTEGER) RETURNS VOID AS $$
+DECLARE
+    first_month TIMESTAMP := date_trunc('month', NOW() AT TIME ZONE 'UTC');
+    part RECORD;
+BEGIN
+    FOR n IN 0..1 LOOP
+        EXECUTE format(
+            'CREATE TABLE IF NOT EXISTS %I PARTITION OF access_logs FOR VALUES FROM (%L) TO (%L)',
+            'access_logs_' || to_char(first_month + make_interval(months => n), 'YYYY_MM'),
+            (first_month + make_interval(months => n)) AT TIME ZONE 'UTC',
+            (first_month + make_interval(months => n + 1)) AT TIME ZONE 'UTC');
+    END LOOP;
+
+    -- A monthly partition is dropped once all of it is past the retention
+    FOR part IN
+        SELECT c.relname
+        FROM pg_inherits inh
+        JOIN pg_class c ON c.oid = inh.inhrelid
+        JOIN pg_class parent ON parent.oid = inh.inhparent
+        WHERE parent.relname = 'access_logs' AND c.relname ~ '^access_logs_[0-9]{4}_[0-9]{2}$'
+    LOOP
+        IF (to_date(substring(part.relname FROM 13), 'YYYY_MM') + INTERVAL '1 month') AT TIME ZONE 'UTC'
+            < NOW() - make_interval(days => retention_days) THEN
+            EXECUTE format('DROP TABLE %I', part.relname);
+        END IF;
+    END LOOP;
+
+    DELETE FROM access_logs_default WHERE created_at < NOW() - make_interval(days => retention_days);
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,45 @@
+-- Archived months are not restored; only rows still in the database are kept
+ALTER TABLE transcription_logs RENAME TO transcription_logs_partitioned;
+CREATE TABLE transcription_logs (
+    LIKE transcription_logs_partitioned INCLUDING DEFAULTS INCLUDING CONSTRAINTS
+);
+INSERT INTO transcription_logs SELECT * FROM transcription_logs_partitioned;
+DROP TABLE transcription_logs_partitioned;
+
+ALTER TABLE transcription_logs ADD PRIMARY KEY (id);
+ALTER TABLE transcription_logs ADD FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE transcription_logs ADD FOREIGN KEY (api_key_id) REFERENCES api_keys(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_transcription_logs_user ON transcription_logs(user_id);
+CREATE INDEX idx_transcription_logs_api_key ON transcription_logs(api_key_id);
+CREATE INDEX idx_transcription_logs_started ON transcription_logs(started_at);
+CREATE INDEX idx_transcription_logs_status ON transcription_logs(status);
+CREATE INDEX idx_transcription_logs_user_date ON transcription_logs(user_id, started_at);
+
+ALTER TABLE trial_usage RENAME TO trial_usage_partitioned;
+CREATE TABLE trial_usage (
+    LIKE trial_usage_partitioned INCLUDING DEFAULTS INCLUDING CONSTRAINTS
+);
+INSERT INTO trial_usage SELECT * FROM trial_usage_partitioned;
+DROP TABLE trial_usage_partitioned;
+
+ALTER TABLE trial_usage ADD PRIMARY KEY (id);
+ALTER TABLE trial_usage ADD FOREIGN KEY (trial_key_id) REFERENCES trial_api_keys(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_trial_usage_key ON trial_usage(trial_key_id);
+CREATE INDEX idx_trial_usage_started ON trial_usage(started_at);
+CREATE INDEX idx_trial_usage_status ON trial_usage(status);
+
+-- Reports of archived sessions lose their link
+UPDATE client_error_reports SET transcription_log_id = NULL
+WHERE transcription_log_id IS NOT NULL AND transcription_log_id NOT IN (SELECT id FROM transcription_logs);
+UPDATE client_error_reports SET trial_usage_id = NULL
+WHERE trial_usage_id IS NOT NULL AND trial_usage_id NOT IN (SELECT id FROM trial_usage);
+
+ALTER TABLE client_error_reports ADD CONSTRAINT client_error_reports_transcription_log_id_fkey
+    FOREIGN KEY (transcription_log_id) REFERENCES transcription_logs(id) ON DELETE SET NULL;
+ALTER TABLE client_error_reports ADD CONSTRAINT client_error_reports_trial_usage_id_fkey
+    FOREIGN KEY (trial_usage_id) REFERENCES trial_usage(id) ON DELETE SET NULL;
+
+DROP FUNCTION IF EXISTS drop_monthly_partition(TEXT, TIMESTAMP WITH TIME ZONE);
+DROP FUNCTION IF EXISTS ensure_monthly_partitions(TEXT, TIMESTAMP WITH TIME ZONE, TIMESTAMP WITH TIME ZONE);
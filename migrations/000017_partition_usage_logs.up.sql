@@ -0,0 +1,97 @@
+-- Range-partition the usage logs by month so old months can be archived to
+-- object storage and dropped without a long DELETE. Partitioned tables need
+-- the partition key in every unique constraint, so the primary keys become
+-- (id, started_at) and client error reports no longer reference the logs by
+-- a foreign key.
+
+-- Creates the monthly partitions of parent (named <parent>_YYYY_MM, UTC
+-- months) from the month of first_month through the month of last_month.
+-- Rows the default partition caught for a new month are moved into it.
+CREATE FUNCTION ensure_monthly_partitions(parent TEXT, first_month TIMESTAMP WITH TIME ZONE, last_month TIMESTAMP WITH TIME ZONE) RETURNS VOID AS $$
+DECLARE
+    cur_month TIMESTAMP := date_trunc('month', first_month AT TIME ZONE 'UTC');
+    part TEXT;
+    lower_bound TIMESTAMP WITH TIME ZONE;
+    upper_bound TIMESTAMP WITH TIME ZONE;
+BEGIN
+    WHILE cur_month <= date_trunc('month', last_month AT TIME ZONE 'UTC') LOOP
+        part := parent || '_' || to_char(cur_month, 'YYYY_MM');
+        lower_bound := cur_month AT TIME ZONE 'UTC';
+        upper_bound := (cur_month + INTERVAL '1 month') AT TIME ZONE 'UTC';
+        cur_month := cur_month + INTERVAL '1 month';
+
+        IF to_regclass(part) IS NULL THEN
+            EXECUTE format('CREATE TABLE %I (LIKE %I INCLUDING DEFAULTS INCLUDING CONSTRAINTS)', part, parent);
+            EXECUTE format(
+                'WITH moved AS (DELETE FROM %I WHERE started_at >= %L AND started_at < %L RETURNING *) INSERT INTO %I SELECT * FROM moved',
+                parent || '_default', lower_bound, upper_bound, part);
+            EXECUTE format('ALTER TABLE %I ATTACH PARTITION %I FOR VALUES FROM (%L) TO (%L)',
+                parent, part, lower_bound, upper_bound);
+        END IF;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Drops the partition of parent holding archived_month, along with any
+-- rows of that month left in the default partition
+CREATE FUNCTION drop_monthly_partition(parent TEXT, archived_month TIMESTAMP WITH TIME ZONE) RETURNS VOID AS $$
+DECLARE
+    start_month TIMESTAMP := date_trunc('month', archived_month AT TIME ZONE 'UTC');
+BEGIN
+    EXECUTE format('DROP TABLE IF EXISTS %I', parent || '_' || to_char(start_month, 'YYYY_MM'));
+    EXECUTE format('DELETE FROM %I WHERE started_at >= %L AND started_at < %L',
+        parent || '_default',
+        start_month AT TIME ZONE 'UTC',
+        (start_month + INTERVAL '1 month') AT TIME ZONE 'UTC');
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE client_error_reports DROP CONSTRAINT IF EXISTS client_error_reports_transcription_log_id_fkey;
+ALTER TABLE client_error_reports DROP CONSTRAINT IF EXISTS client_error_reports_trial_usage_id_fkey;
+
+-- Transcription logs
+ALTER TABLE transcription_logs RENAME TO transcription_logs_unpartitioned;
+
+CREATE TABLE transcription_logs (
+    LIKE transcription_logs_unpartitioned INCLUDING DEFAULTS INCLUDING CONSTRAINTS
+) PARTITION BY RANGE (started_at);
+
+CREATE TABLE transcription_logs_default PARTITION OF transcription_logs DEFAULT;
+
+SELECT ensure_monthly_partitions('transcription_logs', COALESCE(MIN(started_at), NOW()), NOW() + INTERVAL '1 month')
+FROM transcription_logs_unpartitioned;
+
+INSERT INTO transcription_logs SELECT * FROM transcription_logs_unpartitioned;
+DROP TABLE transcription_logs_unpartitioned;
+
+ALTER TABLE transcription_logs ADD PRIMARY KEY (id, started_at);
+ALTER TABLE transcription_logs ADD FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE transcription_logs ADD FOREIGN KEY (api_key_id) REFERENCES api_keys(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_transcription_logs_user ON transcription_logs(user_id);
+CREATE INDEX idx_transcription_logs_api_key ON transcription_logs(api_key_id);
+CREATE INDEX idx_transcription_logs_started ON transcription_logs(started_at);
+CREATE INDEX idx_transcription_logs_status ON transcription_logs(status);
+CREATE INDEX idx_transcription_logs_user_date ON transcription_logs(user_id, started_at);
+
+-- Trial usage
+ALTER TABLE trial_usage RENAME TO trial_usage_unpartitioned;
+
+CREATE TABLE trial_usage (
+    LIKE trial_usage_unpartitioned INCLUDING DEFAULTS INCLUDING CONSTRAINTS
+) PARTITION BY RANGE (started_at);
+
+CREATE TABLE trial_usage_default PARTITION OF trial_usage DEFAULT;
+
+SELECT ensure_monthly_partitions('trial_usage', COALESCE(MIN(started_at), NOW()), NOW() + INTERVAL '1 month')
+FROM trial_usage_unpartitioned;
+
+INSERT INTO trial_usage SELECT * FROM trial_usage_unpartitioned;
+DROP TABLE trial_usage_unpartitioned;
+
+ALTER TABLE trial_usage ADD PRIMARY KEY (id, started_at);
+ALTER TABLE trial_usage ADD FOREIGN KEY (trial_key_id) REFERENCES trial_api_keys(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_trial_usage_key ON trial_usage(trial_key_id);
+CREATE INDEX idx_trial_usage_started ON trial_usage(started_at);
+CREATE INDEX idx_trial_usage_status ON trial_usage(status);
@@ -0,0 +1,5 @@
+-- Sessions whose final Deepgram metadata never arrived are billed with a
+-- duration estimated from the audio bytes and wall clock; the flag tells
+-- them apart from durations Deepgram reported
+ALTER TABLE transcription_logs ADD COLUMN duration_estimated BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE trial_usage ADD COLUMN duration_estimated BOOLEAN NOT NULL DEFAULT FALSE;
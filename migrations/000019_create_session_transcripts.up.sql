@@ -0,0 +1,13 @@
+-- Best-effort transcripts assembled by the proxy from final Results when a
+-- client opts in with save_transcript=true. The transcript is encrypted at
+-- rest. transcription_logs is partitioned, so the log is referenced without
+-- a foreign key.
+CREATE TABLE session_transcripts (
+    transcription_log_id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    transcript TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_session_transcripts_expires ON session_transcripts(expires_at);
@@ -0,0 +1,3 @@
+ALTER TABLE api_keys DROP COLUMN use_count;
+ALTER TABLE api_keys DROP COLUMN last_used_user_agent;
+ALTER TABLE api_keys DROP COLUMN last_used_ip;
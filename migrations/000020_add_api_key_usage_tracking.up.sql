@@ -0,0 +1,6 @@
+-- Where and how often each API key was last used, so users can spot a
+-- leaked key being used from an unfamiliar place. last_used_ip is encrypted
+-- at rest like the other client IPs.
+ALTER TABLE api_keys ADD COLUMN last_used_ip TEXT;
+ALTER TABLE api_keys ADD COLUMN last_used_user_agent TEXT;
+ALTER TABLE api_keys ADD COLUMN use_count BIGINT NOT NULL DEFAULT 0;
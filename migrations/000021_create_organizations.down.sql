@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_api_keys_organization;
+ALTER TABLE api_keys DROP COLUMN organization_id;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
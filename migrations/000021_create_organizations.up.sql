@@ -0,0 +1,25 @@
+-- Organizations own API keys shared by their members. Sessions on an
+-- organization's keys draw from its monthly pool rather than a member's.
+CREATE TABLE organizations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    monthly_quota_seconds INTEGER NOT NULL DEFAULT 0 CHECK (monthly_quota_seconds >= 0),  -- 0 = unlimited
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Owners and admins manage members and keys; members can view and use them
+CREATE TABLE organization_members (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'admin', 'member')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX idx_organization_members_user ON organization_members(user_id);
+
+-- user_id stays the member who created the key
+ALTER TABLE api_keys ADD COLUMN organization_id UUID NULL REFERENCES organizations(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_api_keys_organization ON api_keys(organization_id) WHERE organization_id IS NOT NULL;
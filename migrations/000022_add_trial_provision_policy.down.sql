@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_trial_api_keys_subnet;
+ALTER TABLE trial_api_keys DROP COLUMN subnet_hash;
+ALTER TABLE trial_api_keys DROP COLUMN last_provisioned_at;
+ALTER TABLE trial_api_keys DROP COLUMN regenerations;
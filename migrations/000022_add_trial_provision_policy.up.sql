@@ -0,0 +1,8 @@
+-- State for the trial provisioning policy: how often a device's secret was
+-- re-issued, when it was last handed out, and a blind index of the network
+-- (/24 or /64) the trial was created from
+ALTER TABLE trial_api_keys ADD COLUMN regenerations INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE trial_api_keys ADD COLUMN last_provisioned_at TIMESTAMP WITH TIME ZONE NULL;
+ALTER TABLE trial_api_keys ADD COLUMN subnet_hash VARCHAR(64) NULL;
+
+CREATE INDEX idx_trial_api_keys_subnet ON trial_api_keys(subnet_hash, created_at) WHERE subnet_hash IS NOT NULL;
@@ -0,0 +1,12 @@
+-- Who may create an account: a single row edited through the admin settings
+-- endpoint. The first account can always be created, so a fresh deployment
+-- still gets its admin.
+CREATE TABLE signup_policy (
+    id INTEGER PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    invite_code VARCHAR(255) NULL,  -- Code every signup must send; NULL = not required
+    allowed_email_domains TEXT[] NOT NULL DEFAULT '{}',  -- Lowercase, without '@'; empty = any
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO signup_policy (id) VALUES (1);
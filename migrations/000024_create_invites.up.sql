@@ -0,0 +1,17 @@
+-- Invite links created by admins. A valid invite lets its holder sign up
+-- regardless of the signup policy, with the user_type the invite presets.
+CREATE TABLE invites (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code_hash VARCHAR(64) NOT NULL UNIQUE,  -- SHA-256 of the code; the code is only shown once
+    code_prefix VARCHAR(20) NOT NULL,
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    user_type VARCHAR(50) NOT NULL DEFAULT 'user' CHECK (user_type IN ('admin', 'user')),
+    max_uses INTEGER NOT NULL DEFAULT 1 CHECK (max_uses >= 0),  -- 0 = unlimited
+    uses INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NULL,  -- NULL = never
+    created_by UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX idx_invites_created_at ON invites(created_at DESC);
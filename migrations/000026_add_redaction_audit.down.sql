@@ -0,0 +1,2 @@
+ALTER TABLE trial_usage DROP COLUMN IF EXISTS redaction_audit;
+ALTER TABLE transcription_logs DROP COLUMN IF EXISTS redaction_audit;
@@ -0,0 +1,5 @@
+-- Which redactions each session ran with and what required them, so
+-- compliance teams can show a policy was active for a given call. Sessions
+-- from before this migration and offline trial usage keep '{}'.
+ALTER TABLE transcription_logs ADD COLUMN redaction_audit JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE trial_usage ADD COLUMN redaction_audit JSONB NOT NULL DEFAULT '{}';
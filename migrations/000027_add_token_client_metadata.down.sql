@@ -0,0 +1,2 @@
+ALTER TABLE tokens DROP COLUMN user_agent;
+ALTER TABLE tokens DROP COLUMN client_ip;
@@ -0,0 +1,4 @@
+-- Where each refresh token was issued, so users can tell their signed-in
+-- devices apart. client_ip is encrypted at rest like the other client IPs.
+ALTER TABLE tokens ADD COLUMN client_ip TEXT;
+ALTER TABLE tokens ADD COLUMN user_agent TEXT;
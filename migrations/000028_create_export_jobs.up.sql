@@ -0,0 +1,25 @@
+-- Long-running exports (account data, CSV dumps) built in the background.
+-- The generated file lives in EXPORT_DIR under file_name until expires_at,
+-- after which it is deleted and the job marked expired.
+CREATE TABLE export_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL,
+    params JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'running', 'completed', 'failed', 'expired')),
+    rows_written BIGINT NOT NULL DEFAULT 0,
+    rows_total BIGINT NOT NULL DEFAULT 0,
+    file_name TEXT NULL,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    error TEXT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP WITH TIME ZONE NULL,
+    completed_at TIMESTAMP WITH TIME ZONE NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX idx_export_jobs_user ON export_jobs(user_id, created_at DESC);
+CREATE INDEX idx_export_jobs_pending ON export_jobs(created_at) WHERE status = 'pending';
+CREATE INDEX idx_export_jobs_expires ON export_jobs(expires_at) WHERE status = 'completed';
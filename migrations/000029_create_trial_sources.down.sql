@@ -0,0 +1,2 @@
+ALTER TABLE trial_api_keys DROP COLUMN IF EXISTS source;
+DROP TABLE IF EXISTS trial_sources;
@@ -0,0 +1,13 @@
+-- Install channels (app stores, ads, partner sites) a trial can be
+-- attributed to. The desktop app sends the source it was installed from;
+-- keys remember the source of their first provisioning.
+CREATE TABLE trial_sources (
+    name VARCHAR(64) PRIMARY KEY CHECK (name ~ '^[a-z0-9][a-z0-9-]*$'),
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE trial_api_keys ADD COLUMN source VARCHAR(64)
+    REFERENCES trial_sources(name) ON UPDATE CASCADE;
+
+CREATE INDEX idx_trial_api_keys_source ON trial_api_keys(source);
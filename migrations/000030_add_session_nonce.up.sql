@@ -0,0 +1,10 @@
+-- Client-chosen ID of a transcription session, the same for every retry of
+-- it, so a retried connection reopens the log of the failed attempt instead
+-- of adding another failed entry.
+ALTER TABLE transcription_logs ADD COLUMN session_nonce TEXT;
+ALTER TABLE trial_usage ADD COLUMN session_nonce TEXT;
+
+CREATE INDEX idx_transcription_logs_session_nonce ON transcription_logs(api_key_id, session_nonce)
+    WHERE session_nonce IS NOT NULL;
+CREATE INDEX idx_trial_usage_session_nonce ON trial_usage(trial_key_id, session_nonce)
+    WHERE session_nonce IS NOT NULL;
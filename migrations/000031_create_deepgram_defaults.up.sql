@@ -0,0 +1,10 @@
+-- Deepgram params the proxy sends when the client leaves them out, so a
+-- model upgrade can roll out to every install without a desktop release.
+-- A single row edited through the admin settings endpoint.
+CREATE TABLE deepgram_defaults (
+    id INTEGER PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    params JSONB NOT NULL DEFAULT '{}',  -- Param name to value, e.g. {"model": "nova-3"}
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO deepgram_defaults (id) VALUES (1);
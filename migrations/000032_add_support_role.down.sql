@@ -0,0 +1,9 @@
+UPDATE users SET user_type = 'user' WHERE user_type = 'support';
+ALTER TABLE users DROP CONSTRAINT users_user_type_check;
+ALTER TABLE users ADD CONSTRAINT users_user_type_check
+    CHECK (user_type IN ('admin', 'user'));
+
+UPDATE invites SET user_type = 'user' WHERE user_type = 'support';
+ALTER TABLE invites DROP CONSTRAINT invites_user_type_check;
+ALTER TABLE invites ADD CONSTRAINT invites_user_type_check
+    CHECK (user_type IN ('admin', 'user'));
@@ -0,0 +1,8 @@
+-- Support staff get read-only access to the admin API
+ALTER TABLE users DROP CONSTRAINT users_user_type_check;
+ALTER TABLE users ADD CONSTRAINT users_user_type_check
+    CHECK (user_type IN ('admin', 'support', 'user'));
+
+ALTER TABLE invites DROP CONSTRAINT invites_user_type_check;
+ALTER TABLE invites ADD CONSTRAINT invites_user_type_check
+    CHECK (user_type IN ('admin', 'support', 'user'));
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN IF EXISTS security_flagged_at;
+DROP INDEX IF EXISTS idx_tokens_family;
+ALTER TABLE tokens DROP COLUMN IF EXISTS family_id;
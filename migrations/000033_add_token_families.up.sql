@@ -0,0 +1,9 @@
+-- Refresh tokens rotated from the same sign-in share a family. Replaying a
+-- token that was already rotated means it leaked, so the whole family is
+-- revoked and the account flagged for review. Existing tokens each start
+-- their own family.
+ALTER TABLE tokens ADD COLUMN family_id UUID NOT NULL DEFAULT gen_random_uuid();
+
+CREATE INDEX idx_tokens_family ON tokens(family_id);
+
+ALTER TABLE users ADD COLUMN security_flagged_at TIMESTAMP WITH TIME ZONE NULL;
@@ -0,0 +1,15 @@
+-- Access tokens revoked before they expire, e.g. by signing out or an admin
+-- revoking a session. Every server process loads new rows into its own
+-- denylist, so a revocation on one takes effect on all of them. Rows are
+-- deleted once no token they match can still be valid.
+CREATE TABLE access_token_denials (
+    id BIGSERIAL PRIMARY KEY,
+    token_jti VARCHAR(255) NULL,                 -- Access token JTI, or refresh token JTI denying its session
+    user_id UUID NULL,                           -- With issued_before, denies every access token of the user
+    issued_before TIMESTAMP WITH TIME ZONE NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (token_jti IS NOT NULL OR (user_id IS NOT NULL AND issued_before IS NOT NULL))
+);
+
+CREATE INDEX idx_access_token_denials_expires_at ON access_token_denials(expires_at);
@@ -0,0 +1,17 @@
+-- Reports admins subscribe to. The export worker claims schedules once
+-- next_run_at has passed, sends the report covering the period that just
+-- ended and moves next_run_at to the end of the next one.
+CREATE TABLE report_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    report VARCHAR(50) NOT NULL
+        CHECK (report IN ('daily_usage', 'weekly_trial_funnel', 'monthly_top_users')),
+    channel VARCHAR(20) NOT NULL CHECK (channel IN ('email', 'webhook')),
+    target TEXT NOT NULL,  -- Email address or webhook URL
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_run_at TIMESTAMP WITH TIME ZONE NULL,
+    last_error TEXT NULL,  -- Why the last delivery failed; NULL once one succeeds
+    created_by UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_report_schedules_next_run ON report_schedules(next_run_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS api_key_ip_sightings;
+DROP TABLE IF EXISTS api_key_lockdowns;
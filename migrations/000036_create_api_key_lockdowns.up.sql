@@ -0,0 +1,27 @@
+-- API keys locked after unusual activity. A key with an unlifted lockdown
+-- is refused until its owner or an admin re-enables it; lifted rows stay as
+-- the key's history.
+CREATE TABLE api_key_lockdowns (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    api_key_id UUID NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    reason VARCHAR(50) NOT NULL CHECK (reason IN ('usage_spike', 'ip_spread')),
+    details JSONB NOT NULL DEFAULT '{}',  -- Measurements that tripped the lockdown
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    lifted_at TIMESTAMP WITH TIME ZONE NULL,
+    lifted_by UUID NULL REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- At most one active lockdown per key, so concurrent sessions tripping the
+-- same check notify the owner once
+CREATE UNIQUE INDEX idx_api_key_lockdowns_active ON api_key_lockdowns(api_key_id) WHERE lifted_at IS NULL;
+CREATE INDEX idx_api_key_lockdowns_key_created ON api_key_lockdowns(api_key_id, created_at);
+
+-- Client IPs each key connected from, by blind index, for counting the
+-- distinct IPs of the last hour. Rows older than that are deleted as the key
+-- is used.
+CREATE TABLE api_key_ip_sightings (
+    api_key_id UUID NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    ip_hash VARCHAR(64) NOT NULL,
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (api_key_id, ip_hash)
+);
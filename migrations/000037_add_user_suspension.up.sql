@@ -0,0 +1,3 @@
+-- Suspended users can't sign in, use their access tokens or API keys until
+-- an admin lifts the suspension. Their data is kept, unlike deletion.
+ALTER TABLE users ADD COLUMN suspended_at TIMESTAMP WITH TIME ZONE NULL;
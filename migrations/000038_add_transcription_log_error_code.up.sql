@@ -0,0 +1,3 @@
+-- Why a session failed, as a stable code clients can map to their own
+-- message; error_message keeps the details, e.g. Deepgram's close reason
+ALTER TABLE transcription_logs ADD COLUMN error_code VARCHAR(50) NULL;
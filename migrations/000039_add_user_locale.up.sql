@@ -0,0 +1,3 @@
+-- The language the user chose for emails and error messages. NULL follows
+-- the Accept-Language of each request instead.
+ALTER TABLE users ADD COLUMN locale VARCHAR(10) NULL;
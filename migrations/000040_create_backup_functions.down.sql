@@ -0,0 +1,4 @@
+DROP FUNCTION IF EXISTS reset_table_sequences(TEXT);
+DROP FUNCTION IF EXISTS truncate_tables(TEXT[]);
+DROP FUNCTION IF EXISTS restore_table_rows(TEXT, JSONB);
+DROP FUNCTION IF EXISTS backup_table_rows(TEXT, BIGINT, TID, INTEGER);
@@ -0,0 +1,52 @@
+-- Generic row access for `backup create` and `backup restore`, which copy
+-- whole tables without knowing their columns. Rows travel as JSONB, so a
+-- backup restores into any database migrated to the same schema version.
+
+-- Returns up to batch_size rows of tbl after the row at (after_table,
+-- after_ctid), in physical order. Partitioned tables include the rows of
+-- every partition, which is why the partition (tableoid) is part of the
+-- position. Only stable within one snapshot.
+CREATE FUNCTION backup_table_rows(tbl TEXT, after_table BIGINT, after_ctid TID, batch_size INTEGER)
+RETURNS TABLE (table_oid BIGINT, row_ctid TID, row_data JSONB) AS $$
+BEGIN
+    RETURN QUERY EXECUTE format(
+        'SELECT t.tableoid::bigint, t.ctid, to_jsonb(t) FROM %I t '
+        'WHERE (t.tableoid::bigint, t.ctid) > ($1, $2) '
+        'ORDER BY t.tableoid::bigint, t.ctid LIMIT $3', tbl)
+    USING after_table, after_ctid, batch_size;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Inserts rows (a JSONB array of objects as backup_table_rows returned
+-- them) into tbl
+CREATE FUNCTION restore_table_rows(tbl TEXT, rows JSONB) RETURNS VOID AS $$
+BEGIN
+    EXECUTE format('INSERT INTO %I SELECT * FROM jsonb_populate_recordset(NULL::%I, $1)', tbl, tbl)
+    USING rows;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Empties tbls, and whatever references them, before a restore
+CREATE FUNCTION truncate_tables(tbls TEXT[]) RETURNS VOID AS $$
+BEGIN
+    EXECUTE 'TRUNCATE ' || (SELECT string_agg(quote_ident(t), ', ') FROM unnest(tbls) AS t) || ' CASCADE';
+END;
+$$ LANGUAGE plpgsql;
+
+-- Moves the sequences of tbl's serial columns past the restored IDs
+CREATE FUNCTION reset_table_sequences(tbl TEXT) RETURNS VOID AS $$
+DECLARE
+    col TEXT;
+    seq TEXT;
+BEGIN
+    FOR col IN
+        SELECT a.attname FROM pg_attribute a
+        WHERE a.attrelid = tbl::regclass AND a.attnum > 0 AND NOT a.attisdropped
+    LOOP
+        seq := pg_get_serial_sequence(quote_ident(tbl), col);
+        IF seq IS NOT NULL THEN
+            EXECUTE format('SELECT setval(%L, COALESCE((SELECT MAX(%I) FROM %I), 0) + 1, false)', seq, col, tbl);
+        END IF;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,10 @@
+-- Hashes of the passwords each user set, so recent ones can't be reused
+-- (PASSWORD_HISTORY_COUNT). Only the newest are kept.
+CREATE TABLE password_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_password_history_user ON password_history(user_id, created_at DESC);
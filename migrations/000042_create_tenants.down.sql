@@ -0,0 +1,3 @@
+ALTER TABLE trial_api_keys DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+DROP TABLE IF EXISTS tenants;
@@ -0,0 +1,21 @@
+-- Tenants are white-label resellers sharing the deployment. Users and trial
+-- keys belong to the tenant whose domain they signed up or were provisioned
+-- through; a NULL tenant_id is the operator's own.
+CREATE TABLE tenants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug VARCHAR(64) NOT NULL UNIQUE CHECK (slug ~ '^[a-z0-9][a-z0-9-]*$'),
+    name VARCHAR(255) NOT NULL,
+    domain VARCHAR(255) NOT NULL UNIQUE,
+    branding JSONB NOT NULL DEFAULT '{}',
+    deepgram_api_key TEXT NULL,  -- Encrypted; NULL uses DEEPGRAM_API_KEY
+    trial_preset VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES trial_presets(name),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- A tenant with users or trial keys can't be deleted
+ALTER TABLE users ADD COLUMN tenant_id UUID NULL REFERENCES tenants(id);
+ALTER TABLE trial_api_keys ADD COLUMN tenant_id UUID NULL REFERENCES tenants(id);
+
+CREATE INDEX idx_users_tenant ON users(tenant_id) WHERE tenant_id IS NOT NULL;
+CREATE INDEX idx_trial_api_keys_tenant ON trial_api_keys(tenant_id) WHERE tenant_id IS NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE tenants DROP COLUMN IF EXISTS cookie_domain;
+DROP TABLE IF EXISTS tenant_domains;
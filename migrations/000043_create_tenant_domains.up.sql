@@ -0,0 +1,19 @@
+-- Custom domains tenants serve their users from besides their primary
+-- domain. They only route to the tenant once its DNS shows the
+-- verification token, so a domain can be claimed by several tenants but
+-- verified by one.
+CREATE TABLE tenant_domains (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    domain VARCHAR(255) NOT NULL,
+    verification_token VARCHAR(64) NOT NULL,
+    verified_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (tenant_id, domain)
+);
+
+CREATE UNIQUE INDEX idx_tenant_domains_verified ON tenant_domains(domain) WHERE verified_at IS NOT NULL;
+
+-- Auth cookies set on hosts within it are shared by them; NULL keeps them
+-- to the host they were set on
+ALTER TABLE tenants ADD COLUMN cookie_domain VARCHAR(255) NULL;
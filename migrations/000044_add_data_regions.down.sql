@@ -0,0 +1,2 @@
+ALTER TABLE tenants DROP COLUMN IF EXISTS data_region;
+ALTER TABLE organizations DROP COLUMN IF EXISTS data_region;
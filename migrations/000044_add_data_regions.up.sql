@@ -0,0 +1,6 @@
+-- Data residency: sessions of an organization's keys, or of a tenant's
+-- users and trials, are processed and stored only in this region. NULL has
+-- no requirement. An organization's region takes precedence over its
+-- members' tenant.
+ALTER TABLE organizations ADD COLUMN data_region VARCHAR(16) NULL CHECK (data_region IN ('eu'));
+ALTER TABLE tenants ADD COLUMN data_region VARCHAR(16) NULL CHECK (data_region IN ('eu'));
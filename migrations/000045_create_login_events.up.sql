@@ -0,0 +1,17 @@
+-- Every sign-in and token refresh, successful or not, with the client it
+-- came from. user_id is NULL when no account could be told, e.g. sign-ins
+-- naming an unknown account. Events expire after LOGIN_EVENT_RETENTION_DAYS.
+CREATE TABLE login_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('sign_in', 'token_refresh')),
+    outcome VARCHAR(32) NOT NULL,
+    client_ip TEXT NULL,
+    client_ip_hash VARCHAR(64) NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_login_events_user ON login_events(user_id, created_at DESC);
+CREATE INDEX idx_login_events_ip ON login_events(client_ip_hash, created_at);
+CREATE INDEX idx_login_events_created ON login_events(created_at);
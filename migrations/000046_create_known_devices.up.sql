@@ -0,0 +1,11 @@
+-- Devices users have signed in from, so a sign-in from an unseen one can be
+-- announced by email. A device is a client IP address and user agent; only
+-- a blind index of the pair is kept, not the IP address itself.
+CREATE TABLE known_devices (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device_hash VARCHAR(64) NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    first_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, device_hash)
+);
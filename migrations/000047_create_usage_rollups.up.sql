@@ -0,0 +1,24 @@
+-- Monthly totals of the usage logs, kept when a month is archived and its
+-- partition dropped, so aggregate stats (like the public usage badge) don't
+-- scan the logs. The server refreshes the current and previous months
+-- hourly, and `archive usage-logs` refreshes a month before dropping it.
+CREATE TABLE usage_rollups (
+    month DATE NOT NULL,  -- First day of the UTC month
+    source VARCHAR(32) NOT NULL CHECK (source IN ('transcription_logs', 'trial_usage')),
+    sessions BIGINT NOT NULL DEFAULT 0,
+    duration_seconds DECIMAL(16, 3) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (month, source)
+);
+
+-- Months archived before now are not in the database anymore and stay
+-- uncounted
+INSERT INTO usage_rollups (month, source, sessions, duration_seconds)
+SELECT date_trunc('month', started_at AT TIME ZONE 'UTC')::date, 'transcription_logs', COUNT(*), COALESCE(SUM(duration_seconds), 0)
+FROM transcription_logs
+GROUP BY 1;
+
+INSERT INTO usage_rollups (month, source, sessions, duration_seconds)
+SELECT date_trunc('month', started_at AT TIME ZONE 'UTC')::date, 'trial_usage', COUNT(*), COALESCE(SUM(duration_seconds), 0)
+FROM trial_usage
+GROUP BY 1;
@@ -0,0 +1,7 @@
+-- Per-user overrides of MAX_API_KEYS_PER_USER, set by admins for users who
+-- need more (or fewer) active API keys than everyone else
+CREATE TABLE api_key_limits (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    max_keys INTEGER NOT NULL CHECK (max_keys >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,13 @@
+-- API keys flagged for going unused for KEY_INACTIVITY_DAYS. The owner is
+-- warned with a reactivation link when the row is written, and the key is
+-- revoked KEY_INACTIVITY_WARNING_DAYS later unless it is used or the link
+-- is opened first. A notice is pending while warned_at is after the key's
+-- last use and reactivation; older rows are kept so that reactivation
+-- counts as activity.
+CREATE TABLE api_key_inactivity_notices (
+    api_key_id UUID PRIMARY KEY REFERENCES api_keys(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,  -- SHA-256 of the reactivation link's secret
+    warned_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE NULL,
+    reactivated_at TIMESTAMP WITH TIME ZONE NULL
+);
@@ -0,0 +1,46 @@
+-- Third-party applications registered by users to access the API on other
+-- users' behalf. The secret is only shown at registration.
+CREATE TABLE oauth_clients (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    client_id VARCHAR(64) NOT NULL UNIQUE,
+    client_secret_hash VARCHAR(64) NOT NULL,  -- SHA-256 of the client secret
+    name VARCHAR(100) NOT NULL,
+    redirect_uris TEXT[] NOT NULL,  -- Exact matches only
+    scopes TEXT[] NOT NULL,  -- Scopes the client may ask for
+    owner_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_oauth_clients_owner ON oauth_clients(owner_user_id);
+
+-- Single-use authorization codes, issued when a user consents and
+-- exchanged by the client for tokens within minutes
+CREATE TABLE oauth_authorization_codes (
+    code_hash VARCHAR(64) PRIMARY KEY,  -- SHA-256 of the code
+    client_id UUID NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    redirect_uri TEXT NOT NULL,
+    scopes TEXT[] NOT NULL,
+    code_challenge VARCHAR(128) NULL,  -- PKCE S256 challenge, if the client sent one
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- A user's authorization of a client. Access tokens issued under a grant
+-- carry its ID as their session, so revoking the grant denies them; the
+-- refresh token is rotated on every use.
+CREATE TABLE oauth_grants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    client_id UUID NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    scopes TEXT[] NOT NULL,
+    refresh_token_hash VARCHAR(64) NOT NULL UNIQUE,  -- SHA-256 of the current refresh token
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP WITH TIME ZONE NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,  -- Pushed back with every refresh
+    revoked_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX idx_oauth_grants_user ON oauth_grants(user_id) WHERE revoked_at IS NULL;
+CREATE INDEX idx_oauth_grants_client ON oauth_grants(client_id);
@@ -0,0 +1,8 @@
+-- Values admins set for runtime settings, overriding the environment
+-- without a restart. Every server process polls this table.
+CREATE TABLE setting_overrides (
+    name VARCHAR(100) PRIMARY KEY,  -- Setting name, e.g. DEEPGRAM_MONTHLY_BUDGET
+    value TEXT NOT NULL,
+    updated_by UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+DELETE FROM oauth_authorization_codes WHERE client_id IS NULL;
+ALTER TABLE oauth_authorization_codes ALTER COLUMN client_id SET NOT NULL;
@@ -0,0 +1,3 @@
+-- Authorization codes of the desktop app, a first-party client that isn't
+-- registered in oauth_clients, have no client
+ALTER TABLE oauth_authorization_codes ALTER COLUMN client_id DROP NOT NULL;
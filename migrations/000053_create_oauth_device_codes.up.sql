@@ -0,0 +1,14 @@
+-- Device authorization requests of clients that can't open a browser on the
+-- user's machine (RFC 8628). The client polls with the device code while
+-- the user enters the user code on the dashboard.
+CREATE TABLE oauth_device_codes (
+    device_code_hash VARCHAR(64) PRIMARY KEY,  -- SHA-256 of the device code
+    user_code VARCHAR(8) NOT NULL UNIQUE,  -- Without the dash shown to users
+    client_id VARCHAR(64) NOT NULL,  -- client_id of the registered or first-party app
+    scopes TEXT[] NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'denied', 'redeemed')),
+    user_id UUID NULL REFERENCES users(id) ON DELETE CASCADE,  -- Who approved or denied the request
+    last_polled_at TIMESTAMP WITH TIME ZONE NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
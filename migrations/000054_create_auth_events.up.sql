@@ -0,0 +1,17 @@
+-- Every authentication attempt at the streaming proxy, successful or not,
+-- with the display prefix of the key it carried and the client it came
+-- from, so key guessing shows up. user_id is set once a key resolved to
+-- its owner. Events expire after AUTH_EVENT_RETENTION_DAYS.
+CREATE TABLE auth_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    key_prefix VARCHAR(32) NOT NULL DEFAULT '',
+    outcome VARCHAR(32) NOT NULL,
+    user_id UUID NULL REFERENCES users(id) ON DELETE CASCADE,
+    client_ip TEXT NULL,
+    client_ip_hash VARCHAR(64) NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_auth_events_ip ON auth_events(client_ip_hash, created_at);
+CREATE INDEX idx_auth_events_created ON auth_events(created_at);
@@ -0,0 +1,10 @@
+-- Accounts their users asked to delete. The account stays usable, and the
+-- request can be cancelled, until delete_after; then the user is deleted
+-- along with everything that references it.
+CREATE TABLE account_deletions (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    requested_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    delete_after TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_account_deletions_due ON account_deletions(delete_after);
@@ -4,19 +4,36 @@ import (
 	"context"
 	"fmt"
 	"os"
+	_ "time/tzdata" // Usage periods resolve IANA zones even without system tzdata
 
 	"hyperwhisper/cmd"
+	"hyperwhisper/internal/config"
+	"hyperwhisper/internal/logging"
+	"hyperwhisper/internal/secrets"
 
 	"github.com/urfave/cli/v3"
 )
 
 func main() {
+	// Strip transcripts, emails, IPs and API keys from log output
+	logging.Install()
+
+	// Settings not set via env or *_FILE may come from Vault or AWS Secrets
+	// Manager (see SECRETS_PROVIDER)
+	config.UseProvider(secrets.NewStore())
+
 	app := &cli.Command{
 		Name:  "hyperwhisper",
 		Usage: "HyperWhisper server CLI",
 		Commands: []*cli.Command{
 			cmd.ServeCommand,
 			cmd.MigrateCommand,
+			cmd.ConfigCommand,
+			cmd.EncryptionCommand,
+			cmd.ArchiveCommand,
+			cmd.UsageCommand,
+			cmd.BackupCommand,
+			cmd.TopCommand,
 		},
 	}
 
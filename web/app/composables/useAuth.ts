@@ -9,6 +9,14 @@ const isInitialized = ref(false)
 // Refresh timer
 let refreshTimer: ReturnType<typeof setTimeout> | null = null
 
+// Echo the CSRF cookie set at sign-in; the server requires it on
+// state-changing requests authenticated by cookie
+const csrfHeaders = (): Record<string, string> => {
+  if (typeof document === 'undefined') return {}
+  const match = document.cookie.match(/(?:^|;\s*)csrf_token=([^;]+)/)
+  return match ? { 'X-CSRF-Token': decodeURIComponent(match[1]) } : {}
+}
+
 export function useAuth() {
   const isAuthenticated = computed(() => !!accessToken.value && !!user.value)
 
@@ -92,6 +100,7 @@ export function useAuth() {
     try {
       await $fetch('/api/v1/signout', {
         method: 'POST',
+        headers: csrfHeaders(),
         credentials: 'include', // Ensure cookies are cleared
       })
     } catch (e) {
@@ -106,6 +115,7 @@ export function useAuth() {
     try {
       const response = await $fetch<TokenRefreshResponse>('/api/v1/token_refresh', {
         method: 'POST',
+        headers: csrfHeaders(),
         credentials: 'include', // Ensure cookies are sent
       })
 
@@ -189,7 +199,23 @@ export function useAuth() {
     if (accessToken.value && accessToken.value !== 'cookie') {
       return { Authorization: `Bearer ${accessToken.value}` }
     }
-    return {}
+    return csrfHeaders()
+  }
+
+  // Run a destructive admin operation: a dry run first, then the real run
+  // with the confirmation token the dry run issued
+  const confirmedFetch = async (url: string, method: 'POST' | 'DELETE') => {
+    const separator = url.includes('?') ? '&' : '?'
+    const preview = await $fetch<{ confirmation_token: string }>(`${url}${separator}dry_run=true`, {
+      method,
+      headers: getAuthHeaders(),
+      credentials: 'include'
+    })
+    return await $fetch(`${url}${separator}confirmation_token=${encodeURIComponent(preview.confirmation_token)}`, {
+      method,
+      headers: getAuthHeaders(),
+      credentials: 'include'
+    })
   }
 
   return {
@@ -207,5 +233,6 @@ export function useAuth() {
     fetchUser,
     initAuth,
     getAuthHeaders,
+    confirmedFetch,
   }
 }
@@ -14,8 +14,8 @@ export default defineNuxtRouteMiddleware(async (to) => {
     })
   }
 
-  // Return 401 if not admin
-  if (user.value?.user_type !== 'admin') {
+  // Return 401 if not staff; support staff get the read-only views
+  if (user.value?.user_type !== 'admin' && user.value?.user_type !== 'support') {
     throw createError({
       statusCode: 401,
       statusMessage: 'Unauthorized',
@@ -4,7 +4,7 @@ export interface User {
   email: string
   first_name: string
   last_name: string
-  user_type: 'admin' | 'user'
+  user_type: 'admin' | 'support' | 'user'
   created_at: string
 }
 
@@ -20,6 +20,15 @@ export interface SignUpPayload {
   password: string
   first_name?: string
   last_name?: string
+  trial_token?: string
+  invite_code?: string
+}
+
+export interface SignupPolicy {
+  enabled: boolean
+  invite_code_required: boolean
+  allowed_email_domains: string[]
+  invited: boolean
 }
 
 export interface SignInPayload {
@@ -32,7 +41,25 @@ export interface TokenRefreshResponse {
   expires_in: number
 }
 
+// A signed-in device: one of the user's active refresh tokens
+export interface UserSession {
+  jti: string
+  issued_at: string
+  expires_at: string
+  client_ip: string | null
+  user_agent: string | null
+  current: boolean
+}
+
+// Error bodies are RFC 7807 problems (application/problem+json)
 export interface ApiError {
+  type: string
+  title: string
+  status: number
+  detail: string
+  instance?: string
   error: string
+  code: string
+  message: string
   details?: Record<string, string>
 }
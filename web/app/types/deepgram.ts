@@ -4,6 +4,10 @@ export interface APIKey {
   key_prefix: string
   created_at: string
   last_used_at: string | null
+  last_used_ip: string | null
+  last_used_user_agent: string | null
+  use_count: number
+  organization_id?: string | null
   revoked_at?: string | null
 }
 
@@ -19,15 +23,30 @@ export interface UsageSummary {
   period_end: string
 }
 
+// Redactions a session ran with and what required them; null for sessions
+// recorded before redaction audits existed
+export interface RedactionAudit {
+  applied: string[]
+  // Categories the client could not drop
+  enforced: string[]
+  // Session policies that enforced redactions
+  policies?: string[]
+  // The key's parameter restrictions chose the redactions
+  restricted?: boolean
+}
+
 export interface TranscriptionLog {
   id: string
   started_at: string
   ended_at: string | null
   duration_seconds: number | null
+  // Estimated from the streamed audio; Deepgram's final metadata never arrived
+  duration_estimated: boolean
   status: 'active' | 'completed' | 'error' | 'timeout'
   error_message?: string
   deepgram_params: Record<string, string>
   bytes_sent: number
+  redaction: RedactionAudit | null
 }
 
 // Admin types
@@ -8,6 +8,9 @@ export interface TrialAPIKey {
   revoked_at: string | null
   total_sessions: number
   total_duration_seconds: number
+  preset: string
+  source: string | null
+  regenerations: number
 }
 
 export interface TrialUsageSummary {
@@ -18,6 +21,15 @@ export interface TrialUsageSummary {
   total_bytes_sent: number
   period_start: string
   period_end: string
+  by_source: TrialSourceUsage[]
+}
+
+export interface TrialSourceUsage {
+  source: string | null
+  total_trial_keys: number
+  active_trial_keys: number
+  total_sessions: number
+  total_duration_seconds: number
 }
 
 export interface TrialLimits {